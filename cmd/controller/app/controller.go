@@ -17,15 +17,14 @@ limitations under the License.
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -40,87 +39,316 @@ import (
 	informers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
 	"github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/controller/clusterissuers"
+	gatewayshim "github.com/jetstack/cert-manager/pkg/controller/gateway-shim"
 	dnsutil "github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/pkg/util"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
 	kubeinformers "k8s.io/client-go/informers"
 )
 
-const controllerAgentName = "cert-manager"
+const (
+	controllerAgentName = "cert-manager"
 
-func Run(opts *options.ControllerOptions, stopCh <-chan struct{}) {
-	ctx, kubeCfg, err := buildControllerContext(opts)
+	controllerRestartInitialBackoff = time.Second
+	controllerRestartMaxBackoff     = time.Minute * 5
+)
 
+// RunWithError starts the cert-manager controller and blocks until ctx is
+// cancelled and all control loops have exited, or until a control loop exits
+// with an error. Once shutdown has been triggered, control loops are given up
+// to opts.ShutdownTimeout to exit before an error is returned.
+func RunWithError(ctx context.Context, opts *options.ControllerOptions) error {
+	cctxs, kubeCfg, err := buildControllerContexts(opts)
 	if err != nil {
-		glog.Fatalf(err.Error())
+		return err
+	}
+
+	if opts.EnableMetrics {
+		err := metrics.Default.Configure(metrics.ServerOptions{
+			ListenAddress:   opts.MetricsListenAddress,
+			TLSCertFile:     opts.MetricsTLSCertFile,
+			TLSKeyFile:      opts.MetricsTLSKeyFile,
+			TLSClientCAFile: opts.MetricsTLSClientCAFile,
+		})
+		if err != nil {
+			return fmt.Errorf("error configuring metrics server: %s", err.Error())
+		}
 	}
 
+	healthz := newHealthzServer(opts.HealthProbeBindAddress, opts.EnableProfiling)
+	go healthz.Start(ctx.Done())
+
+	// controllers are constructed and their informers started before leader
+	// election, so that every replica is marked as ready once its caches
+	// have synced, whether or not it is the leader
+	ctrls := buildControllers(cctxs, opts)
+	startInformers(cctxs, healthz, ctx.Done())
+
+	errCh := make(chan error, 1)
+	started := make(chan struct{})
 	run := func(_ <-chan struct{}) {
-		var wg sync.WaitGroup
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			metrics.Default.Start(stopCh)
-		}()
+		close(started)
+		errCh <- runControllers(ctx, ctrls, opts)
+	}
+
+	if !opts.LeaderElect {
+		healthz.SetLive()
+		run(ctx.Done())
+		return <-errCh
+	}
+
+	leaderElectionClient, err := kubernetes.NewForConfig(rest.AddUserAgent(kubeCfg, "leader-election"))
+	if err != nil {
+		return fmt.Errorf("error creating leader election client: %s", err.Error())
+	}
+
+	onNewLeader := func(identity string) {
+		logs.Infof("Observed leader %q", identity)
+		healthz.SetLive()
+	}
+	go startLeaderElection(opts, leaderElectionClient, cctxs[0].Recorder, run, onNewLeader)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	select {
+	case <-started:
+		// we are the leader, so wait for the control loops to shut down
+		return <-errCh
+	default:
+		logs.Infof("Shutting down before leadership was acquired")
+		return nil
+	}
+}
+
+// controllerToRun is an enabled controller that has been constructed for one
+// of the controller contexts.
+type controllerToRun struct {
+	name string
+	run  controller.Interface
+	log  logs.Logger
+}
+
+// buildControllers constructs all enabled controllers for each of the given
+// contexts. Constructing a controller registers the informers it requires
+// with the context's informer factories.
+func buildControllers(cctxs []*controller.Context, opts *options.ControllerOptions) []controllerToRun {
+	// cert-manager is either scoped to one or more namespaces, or watches all
+	// namespaces, so the first context is representative of all of them
+	namespaced := cctxs[0].Namespace != ""
+	for n := range controller.Known() {
+		enabled := util.Contains(opts.EnabledControllers, n) && !(namespaced && n == clusterissuers.ControllerName) &&
+			(opts.EnableGatewayShim || n != gatewayshim.ControllerName)
+		metrics.Default.SetControllerEnabled(n, enabled)
+	}
+
+	var ctrls []controllerToRun
+	for _, cctx := range cctxs {
 		for n, fn := range controller.Known() {
+			log := logs.WithValues("controller", n, "namespace", cctx.Namespace)
+
 			// only run a controller if it's been enabled
 			if !util.Contains(opts.EnabledControllers, n) {
-				glog.Infof("%s controller is not in list of controllers to enable, so not enabling it", n)
+				log.Infof("controller is not in list of controllers to enable, so not enabling it")
+				continue
+			}
+
+			// the gateway-shim controller is experimental, and requires the
+			// Gateway API CRDs to be installed, so is behind a feature flag
+			if n == gatewayshim.ControllerName && !opts.EnableGatewayShim {
+				log.Infof("gateway-shim controller is not enabled, set --enable-gateway-shim to enable it")
 				continue
 			}
 
 			// don't run clusterissuers controller if scoped to a single namespace
-			if ctx.Namespace != "" && n == clusterissuers.ControllerName {
-				glog.Infof("Skipping ClusterIssuer controller as cert-manager is scoped to a single namespace")
+			if cctx.Namespace != "" && n == clusterissuers.ControllerName {
+				log.Infof("skipping ClusterIssuer controller as cert-manager is scoped to a namespace")
 				continue
 			}
 
-			wg.Add(1)
-			go func(n string, fn controller.Interface) {
-				defer wg.Done()
-				glog.Infof("Starting %s controller", n)
+			ctrls = append(ctrls, controllerToRun{name: n, run: fn(cctx), log: log})
+		}
+	}
+	return ctrls
+}
 
-				workers := 5
-				err := fn(workers, stopCh)
+// startInformers starts the shared informer factories for each of the given
+// contexts, and marks the health probe server as ready once they have
+// synced. It does not block.
+func startInformers(cctxs []*controller.Context, healthz *healthzServer, stopCh <-chan struct{}) {
+	for _, cctx := range cctxs {
+		logs.V(4).Infof("Starting shared informer factories for namespace %q", cctx.Namespace)
+		cctx.SharedInformerFactory.Start(stopCh)
+		cctx.KubeSharedInformerFactory.Start(stopCh)
+	}
+	go waitForInformersSynced(cctxs, healthz, stopCh)
+}
 
-				if err != nil {
-					glog.Fatalf("error running %s controller: %s", n, err.Error())
-				}
-			}(n, fn(ctx))
+// runControllers runs the given controllers and blocks until they have all
+// exited. If any controller returns an error, all other controllers are
+// stopped and the error is returned.
+func runControllers(ctx context.Context, ctrls []controllerToRun, opts *options.ControllerOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopCh := ctx.Done()
+
+	var errLock sync.Mutex
+	var runErr error
+	recordErr := func(err error) {
+		errLock.Lock()
+		defer errLock.Unlock()
+		if runErr == nil {
+			runErr = err
 		}
-		glog.V(4).Infof("Starting shared informer factory")
-		ctx.SharedInformerFactory.Start(stopCh)
-		ctx.KubeSharedInformerFactory.Start(stopCh)
+		cancel()
+	}
+
+	var wg sync.WaitGroup
+	startMetricsServer(opts, metrics.Default, &wg, stopCh)
+	for _, c := range ctrls {
+		wg.Add(1)
+		go func(c controllerToRun) {
+			defer wg.Done()
+			err := superviseController(c.name, c.run, opts, c.log, stopCh)
+			if err != nil {
+				recordErr(err)
+				return
+			}
+			c.log.Infof("controller exited")
+		}(c)
+	}
+
+	done := make(chan struct{})
+	go func() {
 		wg.Wait()
-		glog.Fatalf("Control loops exited")
+		close(done)
+	}()
+
+	<-stopCh
+	logs.Infof("Waiting up to %s for control loops to exit", opts.ShutdownTimeout)
+	select {
+	case <-done:
+	case <-time.After(opts.ShutdownTimeout):
+		return fmt.Errorf("timed out after %s waiting for control loops to exit", opts.ShutdownTimeout)
 	}
+	logs.Infof("Control loops exited")
 
-	if !opts.LeaderElect {
-		run(stopCh)
-		return
+	errLock.Lock()
+	defer errLock.Unlock()
+	return runErr
+}
+
+// waitForInformersSynced marks the health probe server as ready once all of
+// the informers requested by enabled controllers have synced.
+func waitForInformersSynced(cctxs []*controller.Context, healthz *healthzServer, stopCh <-chan struct{}) {
+	for _, cctx := range cctxs {
+		for t, ok := range cctx.SharedInformerFactory.WaitForCacheSync(stopCh) {
+			if !ok {
+				logs.Errorf("Timed out waiting for %v informer to sync", t)
+				return
+			}
+		}
+		for t, ok := range cctx.KubeSharedInformerFactory.WaitForCacheSync(stopCh) {
+			if !ok {
+				logs.Errorf("Timed out waiting for %v informer to sync", t)
+				return
+			}
+		}
 	}
+	logs.V(4).Infof("All informer caches synced")
+	healthz.SetReady()
+}
 
-	leaderElectionClient, err := kubernetes.NewForConfig(rest.AddUserAgent(kubeCfg, "leader-election"))
+// superviseController runs the given controller, restarting it with an
+// exponential backoff each time it exits with an error. Once the controller
+// has been restarted opts.ControllerMaxRestarts times, the last error is
+// returned. nil is returned if the controller exits cleanly, or if stopCh is
+// closed whilst waiting to restart it.
+func superviseController(n string, fn controller.Interface, opts *options.ControllerOptions, log logs.Logger, stopCh <-chan struct{}) error {
+	backoff := controllerRestartInitialBackoff
+	for restarts := 0; ; restarts++ {
+		workers := opts.WorkersFor(n)
+		log.WithValues("workers", workers).Infof("starting controller")
+		err := fn(workers, stopCh)
+		if err == nil {
+			return nil
+		}
 
-	if err != nil {
-		glog.Fatalf("error creating leader election client: %s", err.Error())
+		log.Errorf("error running controller: %s", err.Error())
+		if restarts >= opts.ControllerMaxRestarts {
+			return fmt.Errorf("error running %s controller after %d restarts: %s", n, restarts, err.Error())
+		}
+
+		log.Infof("restarting controller in %s", backoff)
+		select {
+		case <-stopCh:
+			return nil
+		case <-time.After(backoff):
+		}
+
+		metrics.Default.IncrementControllerRestarts(n)
+		backoff *= 2
+		if backoff > controllerRestartMaxBackoff {
+			backoff = controllerRestartMaxBackoff
+		}
+	}
+}
+
+// metricsServer is implemented by *metrics.Metrics.
+type metricsServer interface {
+	Start(stopCh <-chan struct{})
+}
+
+// startMetricsServer runs m in the background until stopCh is closed, unless
+// metrics have been disabled. wg is marked as done once the server has shut
+// down.
+func startMetricsServer(opts *options.ControllerOptions, m metricsServer, wg *sync.WaitGroup, stopCh <-chan struct{}) {
+	if !opts.EnableMetrics {
+		logs.Infof("Prometheus metrics server is disabled")
+		return
 	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.Start(stopCh)
+	}()
+}
+
+// rateLimitedConfigs returns copies of cfg with the client-side rate limits
+// configured in opts applied. The first is used for the Kubernetes clients
+// and the second for the cert-manager client, so the two can be limited
+// independently.
+func rateLimitedConfigs(cfg *rest.Config, opts *options.ControllerOptions) (*rest.Config, *rest.Config) {
+	kubeCfg := rest.CopyConfig(cfg)
+	kubeCfg.QPS = opts.KubernetesAPIQPS
+	kubeCfg.Burst = opts.KubernetesAPIBurst
 
-	startLeaderElection(opts, leaderElectionClient, ctx.Recorder, run)
-	panic("unreachable")
+	cmCfg := rest.CopyConfig(cfg)
+	cmCfg.QPS = opts.CertManagerAPIQPS
+	cmCfg.Burst = opts.CertManagerAPIBurst
+
+	return kubeCfg, cmCfg
 }
 
-func buildControllerContext(opts *options.ControllerOptions) (*controller.Context, *rest.Config, error) {
+// buildControllerContexts builds a controller Context for each namespace
+// cert-manager has been configured to watch. Each Context has its own set of
+// informer factories, but they all share the same clients and event recorder.
+func buildControllerContexts(opts *options.ControllerOptions) ([]*controller.Context, *rest.Config, error) {
 	// Load the users Kubernetes config
 	kubeCfg, err := kube.KubeConfig(opts.APIServerHost)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("error creating rest config: %s", err.Error())
 	}
+	kubeCfg, cmCfg := rateLimitedConfigs(kubeCfg, opts)
 
 	// Create a Navigator api client
-	intcl, err := clientset.NewForConfig(kubeCfg)
+	intcl, err := clientset.NewForConfig(cmCfg)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("error creating internal group client: %s", err.Error())
@@ -133,12 +361,22 @@ func buildControllerContext(opts *options.ControllerOptions) (*controller.Contex
 		return nil, nil, fmt.Errorf("error creating kubernetes client: %s", err.Error())
 	}
 
+	// ClusterIssuers are disabled when only watching a limited set of
+	// namespaces, so the cluster resource namespace is not used
+	clusterResourceNamespace := opts.ClusterResourceNamespace
+	if len(opts.Namespaces) == 0 {
+		clusterResourceNamespace, err = resolveClusterResourceNamespace(cl, clusterResourceNamespace)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	nameservers := opts.DNS01RecursiveNameservers
 	if len(nameservers) == 0 {
 		nameservers = dnsutil.RecursiveNameservers
 	}
 
-	glog.Infof("Using the following nameservers for DNS01 checks: %v", nameservers)
+	logs.Infof("Using the following nameservers for DNS01 checks: %v", nameservers)
 
 	HTTP01SolverResourceRequestCPU, err := resource.ParseQuantity(opts.ACMEHTTP01SolverResourceRequestCPU)
 	if err != nil {
@@ -164,35 +402,52 @@ func buildControllerContext(opts *options.ControllerOptions) (*controller.Contex
 	// Add cert-manager types to the default Kubernetes Scheme so Events can be
 	// logged properly
 	intscheme.AddToScheme(scheme.Scheme)
-	glog.V(4).Info("Creating event broadcaster")
+	logs.V(4).Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(glog.V(4).Infof)
+	eventBroadcaster.StartLogging(logs.V(4).Infof)
 	eventBroadcaster.StartRecordingToSink(&corev1.EventSinkImpl{Interface: cl.CoreV1().Events("")})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: controllerAgentName})
 
-	sharedInformerFactory := informers.NewFilteredSharedInformerFactory(intcl, time.Second*30, opts.Namespace, nil)
-	kubeSharedInformerFactory := kubeinformers.NewFilteredSharedInformerFactory(cl, time.Second*30, opts.Namespace, nil)
-	return &controller.Context{
-		Client:                    cl,
-		CMClient:                  intcl,
-		Recorder:                  recorder,
-		KubeSharedInformerFactory: kubeSharedInformerFactory,
-		SharedInformerFactory:     sharedInformerFactory,
-		Namespace:                 opts.Namespace,
+	// a single breaker is shared by the contexts of all watched namespaces,
+	// so that the failures of a ClusterIssuer are counted together
+	issuerBreaker := controller.NewIssuerBreaker(intcl, opts.IssuerFailureThreshold,
+		opts.IssuerFailureWindow, opts.IssuerFailureCooldown)
+
+	base := controller.Context{
+		Client:        cl,
+		CMClient:      intcl,
+		Recorder:      recorder,
+		RESTConfig:    kubeCfg,
+		ResyncPeriod:  opts.ResyncPeriod,
+		DryRun:        opts.DryRun,
+		IssuerBreaker: issuerBreaker,
 		ACMEOptions: controller.ACMEOptions{
-			HTTP01SolverImage:                 opts.ACMEHTTP01SolverImage,
-			HTTP01SolverResourceRequestCPU:    HTTP01SolverResourceRequestCPU,
-			HTTP01SolverResourceRequestMemory: HTTP01SolverResourceRequestMemory,
-			HTTP01SolverResourceLimitsCPU:     HTTP01SolverResourceLimitsCPU,
-			HTTP01SolverResourceLimitsMemory:  HTTP01SolverResourceLimitsMemory,
-			DNS01CheckAuthoritative:           !opts.DNS01RecursiveNameserversOnly,
-			DNS01Nameservers:                  nameservers,
+			HTTP01SolverImage:                  opts.ACMEHTTP01SolverImage,
+			HTTP01SolverResourceRequestCPU:     HTTP01SolverResourceRequestCPU,
+			HTTP01SolverResourceRequestMemory:  HTTP01SolverResourceRequestMemory,
+			HTTP01SolverResourceLimitsCPU:      HTTP01SolverResourceLimitsCPU,
+			HTTP01SolverResourceLimitsMemory:   HTTP01SolverResourceLimitsMemory,
+			HTTP01SolverNodeSelector:           opts.ACMEHTTP01SolverNodeSelector,
+			HTTP01SolverTolerations:            opts.ACMEHTTP01SolverTolerations,
+			HTTP01SolverImagePullSecrets:       opts.ACMEHTTP01SolverImagePullSecrets,
+			HTTP01SolverRunAsNonRoot:           opts.ACMEHTTP01SolverRunAsNonRoot,
+			HTTP01SolverRunAsUser:              opts.ACMEHTTP01SolverRunAsUser,
+			HTTP01SolverReadOnlyRootFilesystem: opts.ACMEHTTP01SolverReadOnlyRootFilesystem,
+			HTTP01SolverIngressClass:           opts.ACMEHTTP01SolverIngressClass,
+			DNS01CheckAuthoritative:            !opts.DNS01RecursiveNameserversOnly,
+			DNS01Nameservers:                   nameservers,
+			DNS01CheckTimeout:                  opts.DNS01CheckTimeout,
+			DNS01CheckRetryInterval:            opts.DNS01CheckRetryInterval,
+			OrderPollInitialInterval:           opts.ACMEOrderPollInitialInterval,
+			OrderPollMaxInterval:               opts.ACMEOrderPollMaxInterval,
 		},
 		IssuerOptions: controller.IssuerOptions{
 			ClusterIssuerAmbientCredentials: opts.ClusterIssuerAmbientCredentials,
 			IssuerAmbientCredentials:        opts.IssuerAmbientCredentials,
-			ClusterResourceNamespace:        opts.ClusterResourceNamespace,
+			ClusterResourceNamespace:        clusterResourceNamespace,
 			RenewBeforeExpiryDuration:       opts.RenewBeforeExpiryDuration,
+			RenewalJitter:                   opts.RenewalJitter,
+			CredentialsDir:                  opts.IssuerCredentialsDir,
 		},
 		IngressShimOptions: controller.IngressShimOptions{
 			DefaultIssuerName:                  opts.DefaultIssuerName,
@@ -200,43 +455,88 @@ func buildControllerContext(opts *options.ControllerOptions) (*controller.Contex
 			DefaultAutoCertificateAnnotations:  opts.DefaultAutoCertificateAnnotations,
 			DefaultACMEIssuerChallengeType:     opts.DefaultACMEIssuerChallengeType,
 			DefaultACMEIssuerDNS01ProviderName: opts.DefaultACMEIssuerDNS01ProviderName,
+			WatchedIngressClasses:              opts.IngressShimWatchedIngressClasses,
 		},
 		CertificateOptions: controller.CertificateOptions{
-			EnableOwnerRef: opts.EnableCertificateOwnerRef,
+			EnableOwnerRef:          opts.EnableCertificateOwnerRef,
+			SecretUpdateMinInterval: opts.SecretUpdateMinInterval,
+			ClockSkewTolerance:      opts.ClockSkewTolerance,
+			EnableSecretless:        opts.EnableSecretlessCertificates,
+		},
+		CertificateRequestOptions: controller.CertificateRequestOptions{
+			RequireApproval: opts.CertificateRequestRequireApproval,
 		},
-	}, kubeCfg, nil
+	}
+
+	// an empty namespace watches all namespaces
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var cctxs []*controller.Context
+	for _, ns := range namespaces {
+		cctx := base
+		cctx.Namespace = ns
+		cctx.SharedInformerFactory = informers.NewFilteredSharedInformerFactory(intcl, opts.ResyncPeriod, ns, nil)
+		cctx.KubeSharedInformerFactory = kubeinformers.NewFilteredSharedInformerFactory(cl, opts.ResyncPeriod, ns, nil)
+		cctxs = append(cctxs, &cctx)
+	}
+
+	return cctxs, kubeCfg, nil
 }
 
-func startLeaderElection(opts *options.ControllerOptions, leaderElectionClient kubernetes.Interface, recorder record.EventRecorder, run func(<-chan struct{})) {
+func startLeaderElection(opts *options.ControllerOptions, leaderElectionClient kubernetes.Interface, recorder record.EventRecorder, run func(<-chan struct{}), onNewLeader func(string)) {
 	// Identity used to distinguish between multiple controller manager instances
-	id, err := os.Hostname()
-	if err != nil {
-		glog.Fatalf("error getting hostname: %s", err.Error())
+	id := opts.LeaderElectionID
+	if id == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			logs.Fatalf("error getting hostname: %s", err.Error())
+		}
+		id = hostname + "-external-cert-manager-controller"
 	}
 
 	// Lock required for leader election
-	rl := resourcelock.ConfigMapLock{
-		ConfigMapMeta: metav1.ObjectMeta{
-			Namespace: opts.LeaderElectionNamespace,
-			Name:      "cert-manager-controller",
-		},
-		Client: leaderElectionClient.CoreV1(),
-		LockConfig: resourcelock.ResourceLockConfig{
-			Identity:      id + "-external-cert-manager-controller",
+	rl, err := newResourceLock(
+		opts.LeaderElectionResourceLock,
+		opts.LeaderElectionNamespace,
+		opts.LeaderElectionLockName,
+		leaderElectionClient,
+		resourcelock.ResourceLockConfig{
+			Identity:      id,
 			EventRecorder: recorder,
 		},
+	)
+	if err != nil {
+		logs.Fatalf("error creating leader election lock: %s", err.Error())
 	}
 
+	metrics.Default.SetLeaderElectionStatus(opts.LeaderElectionLockName, false)
+
 	// Try and become the leader and start controller manager loops
 	leaderelection.RunOrDie(leaderelection.LeaderElectionConfig{
-		Lock:          &rl,
+		Lock:          rl,
 		LeaseDuration: opts.LeaderElectionLeaseDuration,
 		RenewDeadline: opts.LeaderElectionRenewDeadline,
 		RetryPeriod:   opts.LeaderElectionRetryPeriod,
 		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: run,
+			OnStartedLeading: func(stopCh <-chan struct{}) {
+				metrics.Default.SetLeaderElectionStatus(opts.LeaderElectionLockName, true)
+				run(stopCh)
+			},
 			OnStoppedLeading: func() {
-				glog.Fatalf("leaderelection lost")
+				metrics.Default.SetLeaderElectionStatus(opts.LeaderElectionLockName, false)
+				logs.Fatalf("leaderelection lost")
+			},
+			OnNewLeader: func(identity string) {
+				// The leader elector already records 'became leader' and
+				// 'stopped leading' events against the lock for this
+				// instance, so only other leaders are recorded here.
+				if identity != id {
+					rl.RecordEvent(fmt.Sprintf("observed new leader %s", identity))
+				}
+				onNewLeader(identity)
 			},
 		},
 	})
@@ -0,0 +1,176 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/cert-manager/cmd/controller/app/options"
+	cmfake "github.com/jetstack/cert-manager/pkg/client/clientset/versioned/fake"
+	informers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	"github.com/jetstack/cert-manager/pkg/controller"
+)
+
+func TestRateLimitedConfigs(t *testing.T) {
+	tests := map[string]struct {
+		args    []string
+		qps     float32
+		burst   int
+		cmQPS   float32
+		cmBurst int
+	}{
+		"defaults": {
+			qps:     20,
+			burst:   50,
+			cmQPS:   20,
+			cmBurst: 50,
+		},
+		"kubernetes client configured": {
+			args:    []string{"--kube-api-qps=100", "--kube-api-burst=200"},
+			qps:     100,
+			burst:   200,
+			cmQPS:   20,
+			cmBurst: 50,
+		},
+		"cert-manager client configured": {
+			args:    []string{"--cm-api-qps=5", "--cm-api-burst=10"},
+			qps:     20,
+			burst:   50,
+			cmQPS:   5,
+			cmBurst: 10,
+		},
+		"both clients configured": {
+			args:    []string{"--kube-api-qps=100", "--kube-api-burst=200", "--cm-api-qps=30", "--cm-api-burst=40"},
+			qps:     100,
+			burst:   200,
+			cmQPS:   30,
+			cmBurst: 40,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := options.NewControllerOptions()
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			opts.AddFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("error parsing flags: %v", err)
+			}
+			if err := opts.Validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+
+			cfg := &rest.Config{Host: "https://example.com"}
+			kubeCfg, cmCfg := rateLimitedConfigs(cfg, opts)
+			if kubeCfg.QPS != tt.qps || kubeCfg.Burst != tt.burst {
+				t.Errorf("expected kubernetes client QPS %v and Burst %d, got %v and %d", tt.qps, tt.burst, kubeCfg.QPS, kubeCfg.Burst)
+			}
+			if cmCfg.QPS != tt.cmQPS || cmCfg.Burst != tt.cmBurst {
+				t.Errorf("expected cert-manager client QPS %v and Burst %d, got %v and %d", tt.cmQPS, tt.cmBurst, cmCfg.QPS, cmCfg.Burst)
+			}
+			if kubeCfg.Host != cfg.Host || cmCfg.Host != cfg.Host {
+				t.Errorf("expected both configs to be copied from the base config")
+			}
+			if cfg.QPS != 0 || cfg.Burst != 0 {
+				t.Errorf("expected the base config not to be modified")
+			}
+		})
+	}
+}
+
+type fakeMetricsServer struct {
+	started chan struct{}
+}
+
+func (f *fakeMetricsServer) Start(stopCh <-chan struct{}) {
+	close(f.started)
+	<-stopCh
+}
+
+func TestStartMetricsServer(t *testing.T) {
+	tests := map[string]struct {
+		enabled bool
+	}{
+		"metrics enabled": {
+			enabled: true,
+		},
+		"metrics disabled": {
+			enabled: false,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := options.NewControllerOptions()
+			opts.EnableMetrics = tt.enabled
+			m := &fakeMetricsServer{started: make(chan struct{})}
+			stopCh := make(chan struct{})
+			var wg sync.WaitGroup
+
+			startMetricsServer(opts, m, &wg, stopCh)
+
+			select {
+			case <-m.started:
+				if !tt.enabled {
+					t.Errorf("expected the metrics server not to be started")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.enabled {
+					t.Errorf("expected the metrics server to be started")
+				}
+			}
+
+			close(stopCh)
+			wg.Wait()
+		})
+	}
+}
+
+func TestStartInformersMarksNonLeaderReady(t *testing.T) {
+	cctx := &controller.Context{
+		SharedInformerFactory:     informers.NewSharedInformerFactory(cmfake.NewSimpleClientset(), 0),
+		KubeSharedInformerFactory: kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0),
+	}
+	// register informers as constructing a controller would
+	cctx.SharedInformerFactory.Certmanager().V1alpha1().Certificates().Informer()
+	cctx.KubeSharedInformerFactory.Core().V1().Secrets().Informer()
+
+	healthz := newHealthzServer("127.0.0.1:0", false)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	// the replica never acquires leadership, so runControllers is never
+	// called, but it should still become ready once its caches have synced
+	startInformers([]*controller.Context{cctx}, healthz, stopCh)
+
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return atomic.LoadInt32(&healthz.ready) == 1, nil
+	})
+	if err != nil {
+		t.Errorf("expected non-leader replica to be marked as ready once informers had synced")
+	}
+	if atomic.LoadInt32(&healthz.live) != 0 {
+		t.Errorf("expected starting informers not to mark the replica as live")
+	}
+}
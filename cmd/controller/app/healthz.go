@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/jetstack/cert-manager/pkg/logs"
+)
+
+const (
+	healthzServerShutdownTimeout = 5 * time.Second
+	healthzServerReadTimeout     = 8 * time.Second
+	healthzServerWriteTimeout    = 8 * time.Second
+	// profilingWriteTimeout is used instead of healthzServerWriteTimeout when
+	// profiling is enabled, so that CPU profiles and traces taken over a
+	// period of time have time to be written
+	profilingWriteTimeout = 2 * time.Minute
+)
+
+// healthzServer serves the /healthz and /readyz endpoints used by Kubernetes
+// liveness and readiness probes, and optionally the /debug/pprof endpoints.
+type healthzServer struct {
+	http.Server
+
+	// live and ready are accessed atomically, and are set to 1 once the
+	// controller is live or ready respectively.
+	live  int32
+	ready int32
+}
+
+func newHealthzServer(addr string, enableProfiling bool) *healthzServer {
+	mux := http.NewServeMux()
+	h := &healthzServer{
+		Server: http.Server{
+			Addr:         addr,
+			ReadTimeout:  healthzServerReadTimeout,
+			WriteTimeout: healthzServerWriteTimeout,
+			Handler:      mux,
+		},
+	}
+	mux.HandleFunc("/healthz", h.probeHandler(&h.live))
+	mux.HandleFunc("/readyz", h.probeHandler(&h.ready))
+	if enableProfiling {
+		h.WriteTimeout = profilingWriteTimeout
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return h
+}
+
+func (h *healthzServer) probeHandler(flag *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(flag) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}
+}
+
+// SetLive marks the controller as live. It should be called once leader
+// election has been set up.
+func (h *healthzServer) SetLive() {
+	atomic.StoreInt32(&h.live, 1)
+}
+
+// SetReady marks the controller as ready. It should be called once the
+// informer caches for all enabled controllers have synced.
+func (h *healthzServer) SetReady() {
+	atomic.StoreInt32(&h.ready, 1)
+}
+
+// Start starts the health probe server and blocks until stopCh is closed.
+func (h *healthzServer) Start(stopCh <-chan struct{}) {
+	go func() {
+		logs.Infof("Listening for health probes on http://%s", h.Addr)
+		if err := h.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logs.Errorf("Error running health probe server: %s", err.Error())
+		}
+	}()
+
+	<-stopCh
+	ctx, cancel := context.WithTimeout(context.Background(), healthzServerShutdownTimeout)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		logs.Errorf("Health probe server shutdown error: %v", err)
+		return
+	}
+	logs.Info("Health probe server gracefully stopped")
+}
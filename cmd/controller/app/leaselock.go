@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/jetstack/cert-manager/cmd/controller/app/options"
+)
+
+// leaseAPIVersion is the API version Leases are managed with. The vendored
+// Kubernetes client library does not include a client for Leases, so they
+// are managed as JSON using the core REST client.
+const leaseAPIVersion = "coordination.k8s.io/v1beta1"
+
+// newResourceLock returns a leader election lock of the given type, held in
+// the resource with the given namespace and name. Only the 'leases' lock
+// type is implemented here, and the others by the Kubernetes client library.
+func newResourceLock(lockType, ns, name string, client kubernetes.Interface, rlc resourcelock.ResourceLockConfig) (resourcelock.Interface, error) {
+	if lockType == options.LeasesResourceLock {
+		return &leaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Namespace: ns, Name: name},
+			Client:     client.CoreV1().RESTClient(),
+			LockConfig: rlc,
+		}, nil
+	}
+	return resourcelock.New(lockType, ns, name, client.CoreV1(), rlc)
+}
+
+// lease is the subset of the coordination.k8s.io Lease resource that is used
+// to hold a leader election record.
+type lease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec leaseSpec `json:"spec,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       *string           `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int32            `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *metav1.MicroTime `json:"acquireTime,omitempty"`
+	RenewTime            *metav1.MicroTime `json:"renewTime,omitempty"`
+	LeaseTransitions     *int32            `json:"leaseTransitions,omitempty"`
+}
+
+func (l *lease) DeepCopyObject() runtime.Object {
+	out := *l
+	l.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	// the spec is only ever replaced as a whole, so its pointers are shared
+	return &out
+}
+
+// leaseLock is a resourcelock.Interface that holds the leader election record
+// in the spec of a Lease, following the ConfigMap and Endpoints locks of the
+// Kubernetes client library.
+type leaseLock struct {
+	// LeaseMeta should contain a Name and a Namespace of a Lease object
+	// that the LeaderElector will attempt to lead.
+	LeaseMeta  metav1.ObjectMeta
+	Client     rest.Interface
+	LockConfig resourcelock.ResourceLockConfig
+	lease      *lease
+}
+
+// Get returns the election record from the spec of the Lease.
+func (ll *leaseLock) Get() (*resourcelock.LeaderElectionRecord, error) {
+	body, err := ll.Client.Get().AbsPath(ll.path(ll.LeaseMeta.Name)).SetHeader("Accept", "application/json").Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	l := &lease{}
+	if err := json.Unmarshal(body, l); err != nil {
+		return nil, fmt.Errorf("error decoding lease %s: %v", ll.Describe(), err)
+	}
+	ll.lease = l
+	return leaseSpecToRecord(&l.Spec), nil
+}
+
+// Create attempts to create a Lease holding the election record.
+func (ll *leaseLock) Create(ler resourcelock.LeaderElectionRecord) error {
+	l := &lease{
+		TypeMeta: metav1.TypeMeta{APIVersion: leaseAPIVersion, Kind: "Lease"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ll.LeaseMeta.Name,
+			Namespace: ll.LeaseMeta.Namespace,
+		},
+		Spec: recordToLeaseSpec(&ler),
+	}
+	return ll.write(ll.Client.Post().AbsPath(ll.path("")), l)
+}
+
+// Update will update the election record held in an existing Lease.
+func (ll *leaseLock) Update(ler resourcelock.LeaderElectionRecord) error {
+	if ll.lease == nil {
+		return errors.New("lease not initialized, call get or create first")
+	}
+	l := ll.lease.DeepCopyObject().(*lease)
+	l.TypeMeta = metav1.TypeMeta{APIVersion: leaseAPIVersion, Kind: "Lease"}
+	l.Spec = recordToLeaseSpec(&ler)
+	return ll.write(ll.Client.Put().AbsPath(ll.path(ll.LeaseMeta.Name)), l)
+}
+
+// write sends l as the body of req, and stores the Lease returned.
+func (ll *leaseLock) write(req *rest.Request, l *lease) error {
+	reqBody, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	body, err := req.SetHeader("Content-Type", "application/json").SetHeader("Accept", "application/json").Body(reqBody).Do().Raw()
+	if err != nil {
+		return err
+	}
+	written := &lease{}
+	if err := json.Unmarshal(body, written); err != nil {
+		return fmt.Errorf("error decoding lease %s: %v", ll.Describe(), err)
+	}
+	ll.lease = written
+	return nil
+}
+
+func (ll *leaseLock) path(name string) string {
+	p := fmt.Sprintf("/apis/%s/namespaces/%s/leases", leaseAPIVersion, ll.LeaseMeta.Namespace)
+	if name != "" {
+		p += "/" + name
+	}
+	return p
+}
+
+// RecordEvent in leader election while adding meta-data
+func (ll *leaseLock) RecordEvent(s string) {
+	if ll.LockConfig.EventRecorder == nil || ll.lease == nil {
+		return
+	}
+	events := fmt.Sprintf("%v %v", ll.LockConfig.Identity, s)
+	l := ll.lease.DeepCopyObject().(*lease)
+	l.TypeMeta = metav1.TypeMeta{APIVersion: leaseAPIVersion, Kind: "Lease"}
+	ll.LockConfig.EventRecorder.Event(l, corev1.EventTypeNormal, "LeaderElection", events)
+}
+
+// Describe is used to convert details on current resource lock
+// into a string
+func (ll *leaseLock) Describe() string {
+	return fmt.Sprintf("%v/%v", ll.LeaseMeta.Namespace, ll.LeaseMeta.Name)
+}
+
+// Identity returns the Identity of the lock
+func (ll *leaseLock) Identity() string {
+	return ll.LockConfig.Identity
+}
+
+func leaseSpecToRecord(spec *leaseSpec) *resourcelock.LeaderElectionRecord {
+	r := &resourcelock.LeaderElectionRecord{}
+	if spec.HolderIdentity != nil {
+		r.HolderIdentity = *spec.HolderIdentity
+	}
+	if spec.LeaseDurationSeconds != nil {
+		r.LeaseDurationSeconds = int(*spec.LeaseDurationSeconds)
+	}
+	if spec.LeaseTransitions != nil {
+		r.LeaderTransitions = int(*spec.LeaseTransitions)
+	}
+	if spec.AcquireTime != nil {
+		r.AcquireTime = metav1.NewTime(spec.AcquireTime.Time)
+	}
+	if spec.RenewTime != nil {
+		r.RenewTime = metav1.NewTime(spec.RenewTime.Time)
+	}
+	return r
+}
+
+func recordToLeaseSpec(ler *resourcelock.LeaderElectionRecord) leaseSpec {
+	leaseDurationSeconds := int32(ler.LeaseDurationSeconds)
+	leaseTransitions := int32(ler.LeaderTransitions)
+	return leaseSpec{
+		HolderIdentity:       &ler.HolderIdentity,
+		LeaseDurationSeconds: &leaseDurationSeconds,
+		AcquireTime:          &metav1.MicroTime{Time: ler.AcquireTime.Time},
+		RenewTime:            &metav1.MicroTime{Time: ler.RenewTime.Time},
+		LeaseTransitions:     &leaseTransitions,
+	}
+}
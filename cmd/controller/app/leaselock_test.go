@@ -0,0 +1,144 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/cert-manager/cmd/controller/app/options"
+)
+
+const testLeasePath = "/apis/coordination.k8s.io/v1beta1/namespaces/kube-system/leases"
+
+// fakeLeaseServer serves the Lease API for a single Lease, storing it as the
+// JSON most recently written.
+type fakeLeaseServer struct {
+	lock  sync.Mutex
+	lease []byte
+}
+
+func (f *fakeLeaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == testLeasePath+"/cert-manager-controller":
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+			return
+		}
+	case r.Method == http.MethodPost && r.URL.Path == testLeasePath,
+		r.Method == http.MethodPut && r.URL.Path == testLeasePath+"/cert-manager-controller":
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.lease = body
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Write(f.lease)
+}
+
+func TestLeaseLock(t *testing.T) {
+	server := httptest.NewServer(&fakeLeaseServer{})
+	defer server.Close()
+	cl, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+
+	rl, err := newResourceLock(options.LeasesResourceLock, "kube-system", "cert-manager-controller", cl, resourcelock.ResourceLockConfig{
+		Identity:      "test-id",
+		EventRecorder: record.NewFakeRecorder(1),
+	})
+	if err != nil {
+		t.Fatalf("error creating lock: %v", err)
+	}
+	if d := rl.Describe(); d != "kube-system/cert-manager-controller" {
+		t.Errorf("unexpected lock description %q", d)
+	}
+
+	if _, err := rl.Get(); !k8sErrors.IsNotFound(err) {
+		t.Fatalf("expected a not found error before the lease is created, got %v", err)
+	}
+
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	ler := resourcelock.LeaderElectionRecord{
+		HolderIdentity:       "test-id",
+		LeaseDurationSeconds: 60,
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+	if err := rl.Create(ler); err != nil {
+		t.Fatalf("error creating lease: %v", err)
+	}
+	got, err := rl.Get()
+	if err != nil {
+		t.Fatalf("error getting lease: %v", err)
+	}
+	if !got.RenewTime.Equal(&now) || got.HolderIdentity != "test-id" || got.LeaseDurationSeconds != 60 {
+		t.Errorf("expected the created record to be returned, got %+v", got)
+	}
+
+	renewed := metav1.NewTime(now.Add(time.Minute))
+	ler.HolderIdentity = "other-id"
+	ler.RenewTime = renewed
+	ler.LeaderTransitions = 1
+	if err := rl.Update(ler); err != nil {
+		t.Fatalf("error updating lease: %v", err)
+	}
+	got, err = rl.Get()
+	if err != nil {
+		t.Fatalf("error getting lease: %v", err)
+	}
+	if !got.RenewTime.Equal(&renewed) || got.HolderIdentity != "other-id" || got.LeaderTransitions != 1 {
+		t.Errorf("expected the updated record to be returned, got %+v", got)
+	}
+
+	rl.RecordEvent("became leader")
+}
+
+func TestLeaseLockUpdateBeforeGet(t *testing.T) {
+	cl, err := kubernetes.NewForConfig(&rest.Config{Host: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	rl, err := newResourceLock(options.LeasesResourceLock, "kube-system", "cert-manager-controller", cl, resourcelock.ResourceLockConfig{})
+	if err != nil {
+		t.Fatalf("error creating lock: %v", err)
+	}
+	if err := rl.Update(resourcelock.LeaderElectionRecord{}); err == nil {
+		t.Errorf("expected an error updating a lease that has not been read")
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// serviceAccountNamespaceFile is the file, mounted into every pod that uses
+// a service account, that contains the namespace the pod runs in.
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// resolveClusterResourceNamespace returns the namespace that resources
+// referenced by cluster scoped resources, such as ClusterIssuer Secrets, are
+// read from. If ns is empty, the namespace the controller is running in is
+// used. An error is returned if no namespace can be determined, or if the
+// namespace does not exist.
+func resolveClusterResourceNamespace(cl kubernetes.Interface, ns string) (string, error) {
+	if ns == "" {
+		data, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return "", fmt.Errorf("--cluster-resource-namespace is not set and the controller's own namespace could not be detected: %v", err)
+		}
+		ns = strings.TrimSpace(string(data))
+		if ns == "" {
+			return "", fmt.Errorf("--cluster-resource-namespace is not set and %s is empty", serviceAccountNamespaceFile)
+		}
+		logs.Infof("Detected cluster resource namespace %q from the controller's service account", ns)
+	}
+
+	_, err := cl.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return "", fmt.Errorf("cluster resource namespace %q does not exist, check the --cluster-resource-namespace flag", ns)
+	case apierrors.IsForbidden(err):
+		// older RBAC configurations do not allow reading namespaces, so we
+		// cannot verify the namespace but should not refuse to start
+		logs.Warningf("Unable to verify that cluster resource namespace %q exists: %v", ns, err)
+	case err != nil:
+		return "", fmt.Errorf("error checking cluster resource namespace %q: %v", ns, err)
+	}
+
+	return ns, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveClusterResourceNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-manager-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "cert-manager"}}
+
+	tests := map[string]struct {
+		flag          string
+		saNamespace   *string
+		expected      string
+		expectedError bool
+	}{
+		"uses the flag if the namespace exists": {
+			flag:     "cert-manager",
+			expected: "cert-manager",
+		},
+		"fails if the namespace in the flag does not exist": {
+			flag:          "missing",
+			expectedError: true,
+		},
+		"detects the namespace from the service account if the flag is empty": {
+			saNamespace: strPtr("cert-manager\n"),
+			expected:    "cert-manager",
+		},
+		"fails if the detected namespace does not exist": {
+			saNamespace:   strPtr("missing"),
+			expectedError: true,
+		},
+		"fails if the flag is empty and there is no service account": {
+			expectedError: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			serviceAccountNamespaceFile = filepath.Join(dir, "missing")
+			if tt.saNamespace != nil {
+				serviceAccountNamespaceFile = filepath.Join(dir, "namespace")
+				if err := ioutil.WriteFile(serviceAccountNamespaceFile, []byte(*tt.saNamespace), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ns, err := resolveClusterResourceNamespace(fake.NewSimpleClientset(existing), tt.flag)
+			if err != nil {
+				if !tt.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if tt.expectedError {
+				t.Fatalf("expected an error, got namespace %q", ns)
+			}
+			if ns != tt.expected {
+				t.Errorf("expected namespace %q, got %q", tt.expected, ns)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
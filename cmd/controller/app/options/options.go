@@ -19,42 +19,157 @@ package options
 import (
 	"fmt"
 	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
-
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/jetstack/cert-manager/pkg/controller"
+	dnsutil "github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/pkg/util"
 
 	challengescontroller "github.com/jetstack/cert-manager/pkg/controller/acmechallenges"
 	orderscontroller "github.com/jetstack/cert-manager/pkg/controller/acmeorders"
+	certificaterequestscontroller "github.com/jetstack/cert-manager/pkg/controller/certificaterequests"
 	certificatescontroller "github.com/jetstack/cert-manager/pkg/controller/certificates"
 	clusterissuerscontroller "github.com/jetstack/cert-manager/pkg/controller/clusterissuers"
+	gatewayshimcontroller "github.com/jetstack/cert-manager/pkg/controller/gateway-shim"
 	ingressshimcontroller "github.com/jetstack/cert-manager/pkg/controller/ingress-shim"
 	issuerscontroller "github.com/jetstack/cert-manager/pkg/controller/issuers"
 )
 
+// LeasesResourceLock is the leader election lock type that holds the lock in
+// a coordination.k8s.io Lease. It is implemented by cert-manager, as the
+// Kubernetes client library it is built against only provides the
+// 'configmaps' and 'endpoints' lock types.
+const LeasesResourceLock = "leases"
+
 type ControllerOptions struct {
 	APIServerHost            string
+	LogFormat                string
 	ClusterResourceNamespace string
-	Namespace                string
+	Namespaces               []string
+
+	// KubernetesAPIQPS and KubernetesAPIBurst configure the client-side
+	// rate limit of the clients used to talk to the Kubernetes API server.
+	KubernetesAPIQPS   float32
+	KubernetesAPIBurst int
+	// CertManagerAPIQPS and CertManagerAPIBurst configure the client-side
+	// rate limit of the client used for cert-manager's own resources, so
+	// that Order and Challenge churn can be tuned separately.
+	CertManagerAPIQPS   float32
+	CertManagerAPIBurst int
+
+	// ResyncPeriod is the interval at which informers perform a full resync
+	// of all watched resources. A value of 0 disables periodic resyncs.
+	ResyncPeriod time.Duration
+
+	// HealthProbeBindAddress is the address the /healthz and /readyz
+	// endpoints are served on.
+	HealthProbeBindAddress string
+
+	// EnableMetrics serves the Prometheus metrics endpoint. It can be
+	// disabled to free the port when metrics are collected another way.
+	EnableMetrics bool
+	// MetricsListenAddress is the host:port the metrics server listens on.
+	MetricsListenAddress string
+	// MetricsTLSCertFile and MetricsTLSKeyFile are used to serve metrics
+	// over HTTPS. If MetricsTLSClientCAFile is also set, scrapers must
+	// present a client certificate signed by it.
+	MetricsTLSCertFile     string
+	MetricsTLSKeyFile      string
+	MetricsTLSClientCAFile string
+
+	// EnableProfiling serves the net/http/pprof endpoints on the health
+	// probe server.
+	EnableProfiling bool
 
 	LeaderElect                 bool
 	LeaderElectionNamespace     string
+	LeaderElectionResourceLock  string
+	LeaderElectionLockName      string
+	LeaderElectionID            string
 	LeaderElectionLeaseDuration time.Duration
 	LeaderElectionRenewDeadline time.Duration
 	LeaderElectionRetryPeriod   time.Duration
 
 	EnabledControllers []string
 
+	// ControllerMaxRestarts is the number of times a controller that exits
+	// with an error will be restarted before cert-manager exits.
+	ControllerMaxRestarts int
+
+	// ShutdownTimeout is the maximum amount of time to wait for control
+	// loops to exit once shutdown has been triggered.
+	ShutdownTimeout time.Duration
+
+	// DefaultWorkers is the number of workers to run for a controller that
+	// does not have an explicit entry in ControllerWorkers.
+	DefaultWorkers int
+	// ControllerWorkerCounts is the raw list of name=count pairs passed on
+	// the command line. It is parsed into ControllerWorkers by Validate.
+	ControllerWorkerCounts []string
+	// ControllerWorkers maps a controller name to the number of workers that
+	// should be run for it.
+	ControllerWorkers map[string]int
+
 	ACMEHTTP01SolverImage                 string
 	ACMEHTTP01SolverResourceRequestCPU    string
 	ACMEHTTP01SolverResourceRequestMemory string
 	ACMEHTTP01SolverResourceLimitsCPU     string
 	ACMEHTTP01SolverResourceLimitsMemory  string
+	// ACMEHTTP01SolverNodeSelectorLabels is the raw list of key=value node
+	// selector labels passed on the command line. It is parsed into
+	// ACMEHTTP01SolverNodeSelector by Validate.
+	ACMEHTTP01SolverNodeSelectorLabels []string
+	// ACMEHTTP01SolverNodeSelector is the node selector applied to HTTP01
+	// challenge solver pods.
+	ACMEHTTP01SolverNodeSelector map[string]string
+	// ACMEHTTP01SolverTolerationSpecs is the raw list of tolerations passed
+	// on the command line. It is parsed into ACMEHTTP01SolverTolerations by
+	// Validate.
+	ACMEHTTP01SolverTolerationSpecs []string
+	// ACMEHTTP01SolverTolerations are the tolerations applied to HTTP01
+	// challenge solver pods.
+	ACMEHTTP01SolverTolerations []corev1.Toleration
+	// ACMEHTTP01SolverImagePullSecrets are the names of the secrets used to
+	// pull the HTTP01 challenge solver image.
+	ACMEHTTP01SolverImagePullSecrets []string
+	// ACMEHTTP01SolverImageRequireDigest requires ACMEHTTP01SolverImage to
+	// be pinned by a sha256 digest rather than a mutable tag.
+	ACMEHTTP01SolverImageRequireDigest bool
+	// ACMEHTTP01SolverRunAsNonRoot, ACMEHTTP01SolverRunAsUser and
+	// ACMEHTTP01SolverReadOnlyRootFilesystem configure the security context
+	// of HTTP01 challenge solver containers.
+	ACMEHTTP01SolverRunAsNonRoot           bool
+	ACMEHTTP01SolverRunAsUser              int64
+	ACMEHTTP01SolverReadOnlyRootFilesystem bool
+	// ACMEHTTP01SolverIngressClass is the ingress class used for HTTP01
+	// challenge solver ingresses when the solver config does not specify one.
+	ACMEHTTP01SolverIngressClass string
 
 	ClusterIssuerAmbientCredentials bool
 	IssuerAmbientCredentials        bool
 	RenewBeforeExpiryDuration       time.Duration
+	RenewalJitter                   time.Duration
+	// IssuerCredentialsDir is the directory that issuers with
+	// credentialsFrom set to 'file' read their credentials from.
+	IssuerCredentialsDir string
+	// IssuerFailureThreshold is the number of consecutive failures of an
+	// issuer within IssuerFailureWindow after which work for the issuer is
+	// paused for IssuerFailureCooldown.
+	IssuerFailureThreshold int
+	IssuerFailureWindow    time.Duration
+	IssuerFailureCooldown  time.Duration
 
 	// Default issuer/certificates details consumed by ingress-shim
 	DefaultIssuerName                  string
@@ -62,38 +177,128 @@ type ControllerOptions struct {
 	DefaultAutoCertificateAnnotations  []string
 	DefaultACMEIssuerChallengeType     string
 	DefaultACMEIssuerDNS01ProviderName string
+	// IngressShimWatchedIngressClasses restricts ingress-shim to Ingresses
+	// with one of these ingress classes. If empty, all Ingresses are watched.
+	IngressShimWatchedIngressClasses []string
 
 	// Allows specifying a list of custom nameservers to perform DNS checks on.
 	DNS01RecursiveNameservers []string
 	// Allows controlling if recursive nameservers are only used for all checks.
 	// Normally authoritative nameservers are used for checking propagation.
 	DNS01RecursiveNameserversOnly bool
+	// DNS01CheckTimeout is the timeout for each DNS query made while
+	// performing the ACME DNS01 self check.
+	DNS01CheckTimeout time.Duration
+	// DNS01CheckRetryInterval is how long to wait before re-checking for
+	// propagation of a DNS01 TXT record after an unsuccessful self check.
+	DNS01CheckRetryInterval time.Duration
+
+	// ACMEOrderPollInitialInterval is how long to wait before first
+	// re-checking the status of an ACME order or authorization that is
+	// waiting on the ACME server. The interval doubles on each subsequent
+	// check.
+	ACMEOrderPollInitialInterval time.Duration
+	// ACMEOrderPollMaxInterval is the maximum interval between checks of the
+	// status of an ACME order or authorization that is waiting on the ACME
+	// server.
+	ACMEOrderPollMaxInterval time.Duration
 
 	EnableCertificateOwnerRef bool
+
+	// SecretUpdateMinInterval is the minimum time between writes to a
+	// Certificate's Secret that do not change its certificate or private key.
+	SecretUpdateMinInterval time.Duration
+
+	// ClockSkewTolerance is how far the controller's clock may differ from
+	// the issuer's before a certificate is treated as expired or not yet
+	// valid.
+	ClockSkewTolerance time.Duration
+
+	// EnableSecretlessCertificates allows Certificates to use the
+	// 'secretless' output mode, which stores their private key in their
+	// status.
+	EnableSecretlessCertificates bool
+
+	// CertificateRequestRequireApproval causes CertificateRequests to only be
+	// signed once they have an Approved condition.
+	CertificateRequestRequireApproval bool
+
+	// EnableGatewayShim enables the experimental gateway-shim controller,
+	// which creates Certificates for the TLS listeners of Gateway API
+	// Gateway resources.
+	EnableGatewayShim bool
+
+	// DryRun causes controllers to log changes they would have made instead
+	// of creating or updating resources.
+	DryRun bool
 }
 
 const (
 	defaultAPIServerHost            = ""
+	defaultLogFormat                = logs.FormatText
 	defaultClusterResourceNamespace = "kube-system"
-	defaultNamespace                = ""
+
+	defaultResyncPeriod = 30 * time.Second
+
+	// The client-go defaults of 5 QPS and a burst of 10 are too low for a
+	// controller managing many resources on a large cluster.
+	defaultKubernetesAPIQPS   = 20
+	defaultKubernetesAPIBurst = 50
+
+	defaultCertManagerAPIQPS   = 20
+	defaultCertManagerAPIBurst = 50
+
+	defaultHealthProbeBindAddress = ":6060"
+	defaultEnableProfiling        = false
+	defaultEnableMetrics          = true
+	defaultMetricsListenAddress   = metrics.DefaultListenAddress
 
 	defaultLeaderElect                 = true
 	defaultLeaderElectionNamespace     = "kube-system"
+	defaultLeaderElectionResourceLock  = "configmaps"
+	defaultLeaderElectionLockName      = "cert-manager-controller"
+	defaultLeaderElectionID            = ""
 	defaultLeaderElectionLeaseDuration = 60 * time.Second
 	defaultLeaderElectionRenewDeadline = 40 * time.Second
 	defaultLeaderElectionRetryPeriod   = 15 * time.Second
 
+	defaultDefaultWorkers = 5
+
+	defaultShutdownTimeout = 30 * time.Second
+
+	defaultControllerMaxRestarts = 5
+
 	defaultClusterIssuerAmbientCredentials = true
 	defaultIssuerAmbientCredentials        = false
 	defaultRenewBeforeExpiryDuration       = time.Hour * 24 * 30
-
-	defaultTLSACMEIssuerName           = ""
-	defaultTLSACMEIssuerKind           = "Issuer"
-	defaultACMEIssuerChallengeType     = "http01"
-	defaultACMEIssuerDNS01ProviderName = ""
-	defaultEnableCertificateOwnerRef   = false
+	defaultRenewalJitter                   = time.Duration(0)
+	defaultIssuerFailureThreshold          = 5
+	defaultIssuerFailureWindow             = 10 * time.Minute
+	defaultIssuerFailureCooldown           = 5 * time.Minute
+
+	defaultTLSACMEIssuerName                 = ""
+	defaultTLSACMEIssuerKind                 = "Issuer"
+	defaultACMEIssuerChallengeType           = "http01"
+	defaultACMEIssuerDNS01ProviderName       = ""
+	defaultEnableCertificateOwnerRef         = false
+	defaultSecretUpdateMinInterval           = time.Duration(0)
+	defaultClockSkewTolerance                = time.Duration(0)
+	defaultEnableSecretlessCertificates      = false
+	defaultCertificateRequestRequireApproval = false
+	defaultEnableGatewayShim                 = false
+	defaultDryRun                            = false
 
 	defaultDNS01RecursiveNameserversOnly = false
+	defaultDNS01CheckTimeout             = 10 * time.Second
+	defaultDNS01CheckRetryInterval       = 10 * time.Second
+
+	defaultACMEHTTP01SolverRunAsNonRoot           = true
+	defaultACMEHTTP01SolverRunAsUser              = 1000
+	defaultACMEHTTP01SolverReadOnlyRootFilesystem = true
+
+	// maxClockSkewTolerance bounds ClockSkewTolerance, which is intended to
+	// absorb small amounts of clock drift rather than delay renewals.
+	maxClockSkewTolerance = 10 * time.Minute
 )
 
 var (
@@ -109,7 +314,9 @@ var (
 		issuerscontroller.ControllerName,
 		clusterissuerscontroller.ControllerName,
 		certificatescontroller.ControllerName,
+		certificaterequestscontroller.ControllerName,
 		ingressshimcontroller.ControllerName,
+		gatewayshimcontroller.ControllerName,
 		orderscontroller.ControllerName,
 		challengescontroller.ControllerName,
 	}
@@ -117,26 +324,65 @@ var (
 
 func NewControllerOptions() *ControllerOptions {
 	return &ControllerOptions{
-		APIServerHost:                      defaultAPIServerHost,
-		ClusterResourceNamespace:           defaultClusterResourceNamespace,
-		Namespace:                          defaultNamespace,
-		LeaderElect:                        defaultLeaderElect,
-		LeaderElectionNamespace:            defaultLeaderElectionNamespace,
-		LeaderElectionLeaseDuration:        defaultLeaderElectionLeaseDuration,
-		LeaderElectionRenewDeadline:        defaultLeaderElectionRenewDeadline,
-		LeaderElectionRetryPeriod:          defaultLeaderElectionRetryPeriod,
-		EnabledControllers:                 defaultEnabledControllers,
-		ClusterIssuerAmbientCredentials:    defaultClusterIssuerAmbientCredentials,
-		IssuerAmbientCredentials:           defaultIssuerAmbientCredentials,
-		RenewBeforeExpiryDuration:          defaultRenewBeforeExpiryDuration,
-		DefaultIssuerName:                  defaultTLSACMEIssuerName,
-		DefaultIssuerKind:                  defaultTLSACMEIssuerKind,
-		DefaultAutoCertificateAnnotations:  defaultAutoCertificateAnnotations,
-		DefaultACMEIssuerChallengeType:     defaultACMEIssuerChallengeType,
-		DefaultACMEIssuerDNS01ProviderName: defaultACMEIssuerDNS01ProviderName,
-		DNS01RecursiveNameservers:          []string{},
-		DNS01RecursiveNameserversOnly:      defaultDNS01RecursiveNameserversOnly,
-		EnableCertificateOwnerRef:          defaultEnableCertificateOwnerRef,
+		APIServerHost:                          defaultAPIServerHost,
+		LogFormat:                              defaultLogFormat,
+		ClusterResourceNamespace:               defaultClusterResourceNamespace,
+		Namespaces:                             []string{},
+		KubernetesAPIQPS:                       defaultKubernetesAPIQPS,
+		KubernetesAPIBurst:                     defaultKubernetesAPIBurst,
+		CertManagerAPIQPS:                      defaultCertManagerAPIQPS,
+		CertManagerAPIBurst:                    defaultCertManagerAPIBurst,
+		ResyncPeriod:                           defaultResyncPeriod,
+		HealthProbeBindAddress:                 defaultHealthProbeBindAddress,
+		EnableProfiling:                        defaultEnableProfiling,
+		EnableMetrics:                          defaultEnableMetrics,
+		MetricsListenAddress:                   defaultMetricsListenAddress,
+		LeaderElect:                            defaultLeaderElect,
+		LeaderElectionNamespace:                defaultLeaderElectionNamespace,
+		LeaderElectionResourceLock:             defaultLeaderElectionResourceLock,
+		LeaderElectionLockName:                 defaultLeaderElectionLockName,
+		LeaderElectionID:                       defaultLeaderElectionID,
+		LeaderElectionLeaseDuration:            defaultLeaderElectionLeaseDuration,
+		LeaderElectionRenewDeadline:            defaultLeaderElectionRenewDeadline,
+		LeaderElectionRetryPeriod:              defaultLeaderElectionRetryPeriod,
+		EnabledControllers:                     defaultEnabledControllers,
+		ControllerMaxRestarts:                  defaultControllerMaxRestarts,
+		ShutdownTimeout:                        defaultShutdownTimeout,
+		DefaultWorkers:                         defaultDefaultWorkers,
+		ControllerWorkerCounts:                 []string{},
+		ControllerWorkers:                      map[string]int{},
+		ACMEHTTP01SolverNodeSelectorLabels:     []string{},
+		ACMEHTTP01SolverTolerationSpecs:        []string{},
+		ACMEHTTP01SolverImagePullSecrets:       []string{},
+		ACMEHTTP01SolverRunAsNonRoot:           defaultACMEHTTP01SolverRunAsNonRoot,
+		ACMEHTTP01SolverRunAsUser:              defaultACMEHTTP01SolverRunAsUser,
+		ACMEHTTP01SolverReadOnlyRootFilesystem: defaultACMEHTTP01SolverReadOnlyRootFilesystem,
+		ClusterIssuerAmbientCredentials:        defaultClusterIssuerAmbientCredentials,
+		IssuerAmbientCredentials:               defaultIssuerAmbientCredentials,
+		RenewBeforeExpiryDuration:              defaultRenewBeforeExpiryDuration,
+		RenewalJitter:                          defaultRenewalJitter,
+		IssuerFailureThreshold:                 defaultIssuerFailureThreshold,
+		IssuerFailureWindow:                    defaultIssuerFailureWindow,
+		IssuerFailureCooldown:                  defaultIssuerFailureCooldown,
+		DefaultIssuerName:                      defaultTLSACMEIssuerName,
+		DefaultIssuerKind:                      defaultTLSACMEIssuerKind,
+		DefaultAutoCertificateAnnotations:      defaultAutoCertificateAnnotations,
+		DefaultACMEIssuerChallengeType:         defaultACMEIssuerChallengeType,
+		DefaultACMEIssuerDNS01ProviderName:     defaultACMEIssuerDNS01ProviderName,
+		IngressShimWatchedIngressClasses:       []string{},
+		DNS01RecursiveNameservers:              []string{},
+		DNS01RecursiveNameserversOnly:          defaultDNS01RecursiveNameserversOnly,
+		DNS01CheckTimeout:                      defaultDNS01CheckTimeout,
+		DNS01CheckRetryInterval:                defaultDNS01CheckRetryInterval,
+		ACMEOrderPollInitialInterval:           controller.DefaultACMEPollInitialInterval,
+		ACMEOrderPollMaxInterval:               controller.DefaultACMEPollMaxInterval,
+		EnableCertificateOwnerRef:              defaultEnableCertificateOwnerRef,
+		SecretUpdateMinInterval:                defaultSecretUpdateMinInterval,
+		ClockSkewTolerance:                     defaultClockSkewTolerance,
+		EnableSecretlessCertificates:           defaultEnableSecretlessCertificates,
+		CertificateRequestRequireApproval:      defaultCertificateRequestRequireApproval,
+		EnableGatewayShim:                      defaultEnableGatewayShim,
+		DryRun:                                 defaultDryRun,
 	}
 }
 
@@ -144,17 +390,68 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.APIServerHost, "master", defaultAPIServerHost, ""+
 		"Optional apiserver host address to connect to. If not specified, autoconfiguration "+
 		"will be attempted.")
+	fs.StringVar(&s.LogFormat, "log-format", defaultLogFormat, ""+
+		"The format to write logs in. One of 'text' or 'json'. When set to 'json', each "+
+		"line logged by cert-manager is written as a JSON object, with fields such as the "+
+		"controller name where available. Lines logged directly by vendored libraries are "+
+		"written in the text format.")
 	fs.StringVar(&s.ClusterResourceNamespace, "cluster-resource-namespace", defaultClusterResourceNamespace, ""+
 		"Namespace to store resources owned by cluster scoped resources such as ClusterIssuer in. "+
-		"This must be specified if ClusterIssuers are enabled.")
-	fs.StringVar(&s.Namespace, "namespace", defaultNamespace, ""+
-		"If set, this limits the scope of cert-manager to a single namespace and ClusterIssuers are disabled. "+
+		"If set to an empty string, the namespace the controller is running in is used. "+
+		"The namespace must exist if ClusterIssuers are enabled.")
+	fs.StringSliceVar(&s.Namespaces, "namespace", []string{}, ""+
+		"If set, this limits the scope of cert-manager to a comma separated list of namespaces and ClusterIssuers are disabled. "+
 		"If not specified, all namespaces will be watched")
+	fs.Float32Var(&s.KubernetesAPIQPS, "kube-api-qps", defaultKubernetesAPIQPS, ""+
+		"The maximum number of queries per second the controller makes to the Kubernetes API server.")
+	fs.IntVar(&s.KubernetesAPIBurst, "kube-api-burst", defaultKubernetesAPIBurst, ""+
+		"The maximum burst of queries the controller makes to the Kubernetes API server "+
+		"above --kube-api-qps. Must be at least --kube-api-qps.")
+	fs.Float32Var(&s.CertManagerAPIQPS, "cm-api-qps", defaultCertManagerAPIQPS, ""+
+		"The maximum number of queries per second the controller makes for cert-manager resources, "+
+		"such as Certificates, Orders and Challenges. This is limited separately to --kube-api-qps.")
+	fs.IntVar(&s.CertManagerAPIBurst, "cm-api-burst", defaultCertManagerAPIBurst, ""+
+		"The maximum burst of queries the controller makes for cert-manager resources "+
+		"above --cm-api-qps. Must be at least --cm-api-qps.")
+	fs.DurationVar(&s.ResyncPeriod, "resync-period", defaultResyncPeriod, ""+
+		"The interval at which all watched resources are re-queued for processing. "+
+		"Setting this to 0 disables periodic resyncs.")
+	fs.StringVar(&s.HealthProbeBindAddress, "health-probe-bind-address", defaultHealthProbeBindAddress, ""+
+		"The address to serve the /healthz liveness and /readyz readiness endpoints on.")
+	fs.BoolVar(&s.EnableMetrics, "enable-metrics", defaultEnableMetrics, ""+
+		"If true, serve Prometheus metrics on --metrics-listen-address. Set to false to disable "+
+		"the metrics server entirely, so that the port is not bound.")
+	fs.StringVar(&s.MetricsListenAddress, "metrics-listen-address", defaultMetricsListenAddress, ""+
+		"The host:port to serve Prometheus metrics on.")
+	fs.StringVar(&s.MetricsTLSCertFile, "metrics-tls-cert", "", ""+
+		"Path to a PEM encoded certificate used to serve metrics over HTTPS. "+
+		"Must be set together with --metrics-tls-key. The certificate and key are reloaded when they change on disk.")
+	fs.StringVar(&s.MetricsTLSKeyFile, "metrics-tls-key", "", ""+
+		"Path to the PEM encoded private key for --metrics-tls-cert.")
+	fs.StringVar(&s.MetricsTLSClientCAFile, "metrics-tls-client-ca", "", ""+
+		"Path to a PEM encoded CA bundle. If set, clients scraping metrics must present a "+
+		"certificate signed by one of these CAs. Requires --metrics-tls-cert and --metrics-tls-key.")
+	fs.BoolVar(&s.EnableProfiling, "enable-profiling", defaultEnableProfiling, ""+
+		"If true, serve the Go profiling endpoints under /debug/pprof/ on the health probe "+
+		"address. This exposes internal details of the process, so should only be enabled "+
+		"while debugging.")
 	fs.BoolVar(&s.LeaderElect, "leader-elect", true, ""+
 		"If true, cert-manager will perform leader election between instances to ensure no more "+
 		"than one instance of cert-manager operates at a time")
 	fs.StringVar(&s.LeaderElectionNamespace, "leader-election-namespace", defaultLeaderElectionNamespace, ""+
 		"Namespace used to perform leader election. Only used if leader election is enabled")
+	fs.StringVar(&s.LeaderElectionResourceLock, "leader-election-resource-lock", defaultLeaderElectionResourceLock, ""+
+		"The type of resource used to hold the leader election lock. One of 'configmaps', 'endpoints' or "+
+		"'leases'. 'leases' requires the coordination.k8s.io/v1beta1 API, available since Kubernetes 1.12. "+
+		"Only used if leader election is enabled")
+	fs.StringVar(&s.LeaderElectionLockName, "leader-election-lock-name", defaultLeaderElectionLockName, ""+
+		"The name of the resource used to hold the leader election lock. Instances of "+
+		"cert-manager using the same lock name and namespace will compete for leadership. "+
+		"Only used if leader election is enabled")
+	fs.StringVar(&s.LeaderElectionID, "leader-election-id", defaultLeaderElectionID, ""+
+		"The identity this instance uses when acquiring the leader election lock, as recorded "+
+		"in the lock's holderIdentity. If empty, an identity derived from the hostname is used. "+
+		"Only used if leader election is enabled")
 	fs.DurationVar(&s.LeaderElectionLeaseDuration, "leader-election-lease-duration", defaultLeaderElectionLeaseDuration, ""+
 		"The duration that non-leader candidates will wait after observing a leadership "+
 		"renewal until attempting to acquire leadership of a led but unrenewed leader "+
@@ -171,6 +468,18 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.StringSliceVar(&s.EnabledControllers, "controllers", defaultEnabledControllers, ""+
 		"The set of controllers to enable.")
+	fs.IntVar(&s.ControllerMaxRestarts, "controller-max-restarts", defaultControllerMaxRestarts, ""+
+		"The number of times a controller that exits with an error will be restarted, "+
+		"with an exponential backoff, before cert-manager exits.")
+	fs.DurationVar(&s.ShutdownTimeout, "shutdown-timeout", defaultShutdownTimeout, ""+
+		"The maximum amount of time to wait for control loops to exit after a shutdown "+
+		"signal has been received before exiting anyway.")
+	fs.IntVar(&s.DefaultWorkers, "default-workers", defaultDefaultWorkers, ""+
+		"The number of workers to run for each controller that is not listed in --controller-workers.")
+	fs.StringSliceVar(&s.ControllerWorkerCounts, "controller-workers", []string{}, ""+
+		"A comma separated list of name=count pairs setting the number of workers "+
+		"to run for individual controllers, for example certificates=20,clusterissuers=1. "+
+		"Controllers not listed use the value of --default-workers.")
 
 	fs.StringVar(&s.ACMEHTTP01SolverImage, "acme-http01-solver-image", defaultACMEHTTP01SolverImage, ""+
 		"The docker image to use to solve ACME HTTP01 challenges. You most likely will not "+
@@ -188,6 +497,41 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ACMEHTTP01SolverResourceLimitsMemory, "acme-http01-solver-resource-limits-memory", defaultACMEHTTP01SolverResourceLimitsMemory, ""+
 		"Defines the resource limits Memory size when spawning new ACME HTTP01 challenge solver pods.")
 
+	fs.BoolVar(&s.ACMEHTTP01SolverRunAsNonRoot, "acme-http01-solver-run-as-non-root", defaultACMEHTTP01SolverRunAsNonRoot, ""+
+		"Whether ACME HTTP01 challenge solver containers must run as a non-root user.")
+
+	fs.Int64Var(&s.ACMEHTTP01SolverRunAsUser, "acme-http01-solver-run-as-user", defaultACMEHTTP01SolverRunAsUser, ""+
+		"The UID to run ACME HTTP01 challenge solver containers as. If set to 0 and "+
+		"--acme-http01-solver-run-as-non-root is false, the image's default user is used.")
+
+	fs.BoolVar(&s.ACMEHTTP01SolverReadOnlyRootFilesystem, "acme-http01-solver-read-only-root-filesystem", defaultACMEHTTP01SolverReadOnlyRootFilesystem, ""+
+		"Whether ACME HTTP01 challenge solver containers should have a read-only root filesystem.")
+
+	fs.BoolVar(&s.ACMEHTTP01SolverImageRequireDigest, "acme-http01-solver-image-require-digest", false, ""+
+		"If true, cert-manager refuses to start unless --acme-http01-solver-image is pinned by "+
+		"digest, for example quay.io/jetstack/cert-manager-acmesolver@sha256:<digest>.")
+
+	fs.StringSliceVar(&s.ACMEHTTP01SolverImagePullSecrets, "acme-http01-solver-image-pull-secrets", []string{}, ""+
+		"A comma separated list of secret names, in the namespace of each challenge, to use "+
+		"when pulling the ACME HTTP01 challenge solver image. Issuers may override these "+
+		"using spec.acme.http01.imagePullSecrets.")
+
+	fs.StringVar(&s.ACMEHTTP01SolverIngressClass, "acme-http01-solver-ingress-class", "", ""+
+		"The ingress class to set on ACME HTTP01 challenge solver ingresses when the "+
+		"certificate's http01 solver config does not specify an ingressClass. If not set, "+
+		"solver ingresses only get a class annotation when one is configured explicitly.")
+
+	fs.StringSliceVar(&s.ACMEHTTP01SolverNodeSelectorLabels, "acme-http01-solver-node-selector", []string{}, ""+
+		"A comma separated list of key=value node labels that ACME HTTP01 challenge solver "+
+		"pods must be scheduled on, for example kubernetes.io/role=ingress. Issuers may add "+
+		"to or override these using spec.acme.http01.nodeSelector.")
+
+	fs.StringSliceVar(&s.ACMEHTTP01SolverTolerationSpecs, "acme-http01-solver-tolerations", []string{}, ""+
+		"A comma separated list of tolerations to add to ACME HTTP01 challenge solver pods, "+
+		"each of the form key[=value][:effect], for example dedicated=ingress:NoSchedule. "+
+		"A toleration without a value tolerates any value of the taint, and one without an "+
+		"effect tolerates all effects. Issuers may add to these using spec.acme.http01.tolerations.")
+
 	fs.BoolVar(&s.ClusterIssuerAmbientCredentials, "cluster-issuer-ambient-credentials", defaultClusterIssuerAmbientCredentials, ""+
 		"Whether a cluster-issuer may make use of ambient credentials for issuers. 'Ambient Credentials' are credentials drawn from the environment, metadata services, or local files which are not explicitly configured in the ClusterIssuer API object. "+
 		"When this flag is enabled, the following sources for credentials are also used: "+
@@ -196,10 +540,27 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 		"Whether an issuer may make use of ambient credentials. 'Ambient Credentials' are credentials drawn from the environment, metadata services, or local files which are not explicitly configured in the Issuer API object. "+
 		"When this flag is enabled, the following sources for credentials are also used: "+
 		"AWS - All sources the Go SDK defaults to, notably including any EC2 IAM roles available via instance metadata.")
+	fs.StringVar(&s.IssuerCredentialsDir, "issuer-credentials-dir", "", ""+
+		"Directory that issuers with credentialsFrom set to 'file' read their credentials from, "+
+		"for example a CSI secret store or projected volume mount. A secret named 'name' in namespace "+
+		"'ns' is read from <dir>/ns/name, with one file per key. For ClusterIssuers, the namespace is "+
+		"the cluster resource namespace. If not set, issuers may not read credentials from files.")
+	fs.IntVar(&s.IssuerFailureThreshold, "issuer-failure-threshold", defaultIssuerFailureThreshold, ""+
+		"The number of consecutive failures of an issuer within --issuer-failure-window after which "+
+		"all work for the issuer is paused for --issuer-failure-cooldown, and its TemporarilyUnavailable "+
+		"condition is set. Set to 0 to never pause work for an issuer.")
+	fs.DurationVar(&s.IssuerFailureWindow, "issuer-failure-window", defaultIssuerFailureWindow, ""+
+		"The window within which consecutive failures of an issuer are counted.")
+	fs.DurationVar(&s.IssuerFailureCooldown, "issuer-failure-cooldown", defaultIssuerFailureCooldown, ""+
+		"How long work for an issuer is paused after it has failed too many times in a row.")
 	fs.DurationVar(&s.RenewBeforeExpiryDuration, "renew-before-expiry-duration", defaultRenewBeforeExpiryDuration, ""+
 		"The default 'renew before expiry' time for Certificates. "+
 		"Once a certificate is within this duration until expiry, a new Certificate "+
 		"will be attempted to be issued.")
+	fs.DurationVar(&s.RenewalJitter, "renewal-jitter", defaultRenewalJitter, ""+
+		"The maximum duration by which the renewal of a Certificate is brought forward. "+
+		"Each Certificate is renewed at a fixed offset within this window, derived from its name, "+
+		"to avoid certificates with the same expiry being renewed at once. Set to 0 to disable.")
 	fs.StringSliceVar(&s.DefaultAutoCertificateAnnotations, "auto-certificate-annotations", defaultAutoCertificateAnnotations, ""+
 		"The annotation consumed by the ingress-shim controller to indicate a ingress is requesting a certificate")
 
@@ -212,14 +573,23 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.DefaultACMEIssuerDNS01ProviderName, "default-acme-issuer-dns01-provider-name", defaultACMEIssuerDNS01ProviderName, ""+
 		"Required if --default-acme-issuer-challenge-type is set to dns01. The DNS01 provider to use for ingresses using ACME dns01 "+
 		"validation that do not explicitly state a dns provider.")
+	fs.StringSliceVar(&s.IngressShimWatchedIngressClasses, "ingress-shim-watched-ingress-classes", []string{}, ""+
+		"A list of comma separated ingress classes that ingress-shim will create Certificates for. "+
+		"If set, Ingresses without one of these classes in their 'kubernetes.io/ingress.class' annotation are ignored. "+
+		"If empty, Ingresses of all classes are acted upon.")
 	fs.StringSliceVar(&s.DNS01RecursiveNameservers, "dns01-recursive-nameservers",
 		[]string{}, "A list of comma seperated dns server endpoints used for "+
 			"DNS01 check requests. This should be a list containing IP address and "+
-			"port, for example 8.8.8.8:53,8.8.4.4:53")
+			"port, for example 8.8.8.8:53,8.8.4.4:53. DNS-over-HTTPS servers may be "+
+			"given as a URL, for example https://1.1.1.1/dns-query. When only "+
+			"DNS-over-HTTPS servers are reachable, --dns01-recursive-nameservers-only "+
+			"should also be set.")
 	fs.BoolVar(&s.DNS01RecursiveNameserversOnly, "dns01-recursive-nameservers-only",
 		defaultDNS01RecursiveNameserversOnly,
 		"When true, cert-manager will only ever query the configured DNS resolvers "+
-			"to perform the ACME DNS01 self check. This is useful in DNS constrained "+
+			"to perform the ACME DNS01 self check. By default, the authoritative "+
+			"nameservers are queried directly and the configured resolvers are only "+
+			"used if they cannot be reached. This is useful in DNS constrained "+
 			"environments, where access to authoritative nameservers is restricted. "+
 			"Enabling this option could cause the DNS01 self check to take longer "+
 			"due to caching performed by the recursive nameservers.")
@@ -228,12 +598,59 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 			"DNS01 check requests. This should be a list containing IP address and "+
 			"port, for example 8.8.8.8:53,8.8.4.4:53")
 	fs.MarkDeprecated("dns01-self-check-nameservers", "Deprecated in favour of dns01-recursive-nameservers")
+	fs.DurationVar(&s.DNS01CheckTimeout, "dns01-check-timeout", defaultDNS01CheckTimeout, ""+
+		"The timeout for each DNS query made while performing the ACME DNS01 self check. "+
+		"Increase this when authoritative nameservers are slow to respond.")
+	fs.DurationVar(&s.DNS01CheckRetryInterval, "dns01-check-retry-interval", defaultDNS01CheckRetryInterval, ""+
+		"How long to wait before re-checking whether a DNS01 TXT record has propagated "+
+		"after an unsuccessful self check.")
+	fs.DurationVar(&s.ACMEOrderPollInitialInterval, "acme-order-poll-initial-interval", controller.DefaultACMEPollInitialInterval, ""+
+		"How long to wait before first re-checking the status of an ACME order or "+
+		"authorization that is waiting on the ACME server. The interval doubles on each subsequent check, up to "+
+		"acme-order-poll-max-interval.")
+	fs.DurationVar(&s.ACMEOrderPollMaxInterval, "acme-order-poll-max-interval", controller.DefaultACMEPollMaxInterval, ""+
+		"The maximum interval between checks of the status of an ACME order or "+
+		"authorization that is waiting on the ACME server.")
 	fs.BoolVar(&s.EnableCertificateOwnerRef, "enable-certificate-owner-ref", defaultEnableCertificateOwnerRef, ""+
 		"Whether to set the certificate resource as an owner of secret where the tls certificate is stored. "+
-		"When this flag is enabled, the secret will be automatically removed when the certificate resource is deleted.")
+		"When this flag is enabled, the secret will be automatically removed when the certificate resource is deleted. "+
+		"ACME Orders and Challenges are always owned by their Certificate and Order respectively, regardless of this flag.")
+	fs.DurationVar(&s.SecretUpdateMinInterval, "secret-update-min-interval", defaultSecretUpdateMinInterval, ""+
+		"The minimum time between two updates to the same certificate secret that do not change "+
+		"the certificate or private key it contains, such as metadata or keystore updates. "+
+		"Secrets are always updated straight away when a new certificate is issued. "+
+		"Set to 0 to disable the limit.")
+	fs.DurationVar(&s.ClockSkewTolerance, "clock-skew-tolerance", defaultClockSkewTolerance, ""+
+		"How far the controller's clock may be out of step with the issuer's when checking a "+
+		"certificate's validity period. Certificates are not marked as expired or not yet valid, "+
+		"and are not renewed, until their NotAfter or NotBefore time is exceeded by more than this "+
+		fmt.Sprintf("duration. Certificates are only marked as not yet valid when this is set. Must be between 0 and %s.", maxClockSkewTolerance))
+	fs.BoolVar(&s.EnableSecretlessCertificates, "enable-secretless-certificates", defaultEnableSecretlessCertificates, ""+
+		"Allow Certificates to use the 'secretless' output mode, which stores the issued certificate "+
+		"and its private key in the status of the Certificate instead of a Secret. Anyone who can read "+
+		"Certificates can then read these private keys. Secretless Certificates are not issued unless "+
+		"this flag is enabled.")
+	fs.BoolVar(&s.CertificateRequestRequireApproval, "certificate-request-require-approval", defaultCertificateRequestRequireApproval, ""+
+		"If true, CertificateRequests will not be signed until an approver has added an Approved "+
+		"condition to them. CertificateRequests with a Denied condition are always failed, "+
+		"regardless of this flag.")
+	fs.BoolVar(&s.EnableGatewayShim, "enable-gateway-shim", defaultEnableGatewayShim, ""+
+		"Enable the experimental gateway-shim controller, which creates Certificates for the TLS "+
+		"listeners of Gateway API (gateway.networking.k8s.io) Gateway resources. The Gateway API "+
+		"CRDs must be installed in the cluster when this is enabled.")
+	fs.BoolVar(&s.DryRun, "dry-run", defaultDryRun, ""+
+		"If true, cert-manager will log and record an event for each Certificate, Secret or ACME order "+
+		"it would have created or updated, instead of actually making the change. "+
+		"No orders will be placed with ACME servers in this mode.")
 }
 
 func (o *ControllerOptions) Validate() error {
+	switch o.LogFormat {
+	case logs.FormatText, logs.FormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %q: must be one of %q or %q", o.LogFormat, logs.FormatText, logs.FormatJSON)
+	}
+
 	switch o.DefaultIssuerKind {
 	case "Issuer":
 	case "ClusterIssuer":
@@ -241,7 +658,162 @@ func (o *ControllerOptions) Validate() error {
 		return fmt.Errorf("invalid default issuer kind: %v", o.DefaultIssuerKind)
 	}
 
+	for _, ns := range o.Namespaces {
+		if ns == "" {
+			return fmt.Errorf("invalid namespace list %v: namespaces must not be empty", o.Namespaces)
+		}
+	}
+
+	if o.KubernetesAPIQPS <= 0 {
+		return fmt.Errorf("invalid kubernetes api qps: %v: must be greater than zero", o.KubernetesAPIQPS)
+	}
+
+	if float32(o.KubernetesAPIBurst) < o.KubernetesAPIQPS {
+		return fmt.Errorf("invalid kubernetes api burst: %d: must be at least the kubernetes api qps (%v)", o.KubernetesAPIBurst, o.KubernetesAPIQPS)
+	}
+
+	if o.CertManagerAPIQPS <= 0 {
+		return fmt.Errorf("invalid cert-manager api qps: %v: must be greater than zero", o.CertManagerAPIQPS)
+	}
+
+	if float32(o.CertManagerAPIBurst) < o.CertManagerAPIQPS {
+		return fmt.Errorf("invalid cert-manager api burst: %d: must be at least the cert-manager api qps (%v)", o.CertManagerAPIBurst, o.CertManagerAPIQPS)
+	}
+
+	if err := validateListenAddress(o.MetricsListenAddress); err != nil {
+		return fmt.Errorf("invalid metrics listen address %q: %s", o.MetricsListenAddress, err.Error())
+	}
+
+	if (o.MetricsTLSCertFile == "") != (o.MetricsTLSKeyFile == "") {
+		return fmt.Errorf("invalid metrics TLS configuration: --metrics-tls-cert and --metrics-tls-key must be set together")
+	}
+
+	if o.MetricsTLSClientCAFile != "" && o.MetricsTLSCertFile == "" {
+		return fmt.Errorf("invalid metrics TLS configuration: --metrics-tls-client-ca requires --metrics-tls-cert and --metrics-tls-key")
+	}
+
+	if o.ResyncPeriod < 0 {
+		return fmt.Errorf("invalid resync period: %v", o.ResyncPeriod)
+	}
+
+	switch o.LeaderElectionResourceLock {
+	case resourcelock.ConfigMapsResourceLock, resourcelock.EndpointsResourceLock, LeasesResourceLock:
+	default:
+		return fmt.Errorf("invalid leader election resource lock %q: must be one of %q, %q or %q",
+			o.LeaderElectionResourceLock, resourcelock.ConfigMapsResourceLock, resourcelock.EndpointsResourceLock, LeasesResourceLock)
+	}
+
+	if o.LeaderElectionLockName == "" {
+		return fmt.Errorf("invalid leader election lock name: must not be empty")
+	}
+
+	if o.ControllerMaxRestarts < 0 {
+		return fmt.Errorf("invalid number of controller restarts: %d", o.ControllerMaxRestarts)
+	}
+
+	if o.RenewalJitter < 0 {
+		return fmt.Errorf("invalid renewal jitter: %v", o.RenewalJitter)
+	}
+
+	if o.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid shutdown timeout: %v", o.ShutdownTimeout)
+	}
+
+	if o.IssuerFailureThreshold < 0 {
+		return fmt.Errorf("invalid issuer failure threshold: %d", o.IssuerFailureThreshold)
+	}
+
+	if o.IssuerFailureWindow < 0 {
+		return fmt.Errorf("invalid issuer failure window: %v", o.IssuerFailureWindow)
+	}
+
+	if o.IssuerFailureCooldown < 0 {
+		return fmt.Errorf("invalid issuer failure cooldown: %v", o.IssuerFailureCooldown)
+	}
+
+	if o.SecretUpdateMinInterval < 0 {
+		return fmt.Errorf("invalid secret update minimum interval: %v", o.SecretUpdateMinInterval)
+	}
+
+	if o.ClockSkewTolerance < 0 || o.ClockSkewTolerance > maxClockSkewTolerance {
+		return fmt.Errorf("invalid clock skew tolerance %v: must be between 0 and %v", o.ClockSkewTolerance, maxClockSkewTolerance)
+	}
+
+	if o.DefaultWorkers < 1 {
+		return fmt.Errorf("invalid number of default workers: %d", o.DefaultWorkers)
+	}
+
+	workers, err := parseControllerWorkers(o.ControllerWorkerCounts)
+	if err != nil {
+		return err
+	}
+	o.ControllerWorkers = workers
+
+	nodeSelector, err := parseNodeSelector(o.ACMEHTTP01SolverNodeSelectorLabels)
+	if err != nil {
+		return err
+	}
+	o.ACMEHTTP01SolverNodeSelector = nodeSelector
+
+	tolerations, err := parseTolerations(o.ACMEHTTP01SolverTolerationSpecs)
+	if err != nil {
+		return err
+	}
+	o.ACMEHTTP01SolverTolerations = tolerations
+
+	if o.ACMEHTTP01SolverRunAsUser < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver user ID: %d", o.ACMEHTTP01SolverRunAsUser)
+	}
+
+	if o.ACMEHTTP01SolverRunAsNonRoot && o.ACMEHTTP01SolverRunAsUser == 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver user ID: must not be 0 when --acme-http01-solver-run-as-non-root is set")
+	}
+
+	if o.ACMEHTTP01SolverImageRequireDigest {
+		if err := validateImageDigest(o.ACMEHTTP01SolverImage); err != nil {
+			return fmt.Errorf("invalid ACME HTTP01 solver image %q: %v", o.ACMEHTTP01SolverImage, err)
+		}
+	}
+
+	for _, name := range o.ACMEHTTP01SolverImagePullSecrets {
+		if name == "" {
+			return fmt.Errorf("invalid ACME HTTP01 solver image pull secrets %v: names must not be empty", o.ACMEHTTP01SolverImagePullSecrets)
+		}
+	}
+
+	if o.IssuerCredentialsDir != "" && !filepath.IsAbs(o.IssuerCredentialsDir) {
+		return fmt.Errorf("invalid issuer credentials directory %q: must be an absolute path", o.IssuerCredentialsDir)
+	}
+
+	if o.ACMEHTTP01SolverIngressClass != "" && strings.TrimSpace(o.ACMEHTTP01SolverIngressClass) == "" {
+		return fmt.Errorf("invalid ACME HTTP01 solver ingress class %q: must not be empty", o.ACMEHTTP01SolverIngressClass)
+	}
+
+	if o.DNS01CheckTimeout <= 0 {
+		return fmt.Errorf("invalid DNS01 check timeout: %v", o.DNS01CheckTimeout)
+	}
+
+	if o.DNS01CheckRetryInterval <= 0 {
+		return fmt.Errorf("invalid DNS01 check retry interval: %v", o.DNS01CheckRetryInterval)
+	}
+
+	if o.ACMEOrderPollInitialInterval <= 0 {
+		return fmt.Errorf("invalid ACME order poll initial interval: %v", o.ACMEOrderPollInitialInterval)
+	}
+
+	if o.ACMEOrderPollMaxInterval < o.ACMEOrderPollInitialInterval {
+		return fmt.Errorf("invalid ACME order poll max interval %v: must not be less than the initial interval %v", o.ACMEOrderPollMaxInterval, o.ACMEOrderPollInitialInterval)
+	}
+
 	for _, server := range o.DNS01RecursiveNameservers {
+		// DNS-over-HTTPS servers are specified as a URL
+		if dnsutil.IsDoHNameserver(server) {
+			if _, err := url.Parse(server); err != nil {
+				return fmt.Errorf("invalid DNS-over-HTTPS server (%v): %v", err, server)
+			}
+			continue
+		}
+
 		// ensure all servers have a port number
 		host, _, err := net.SplitHostPort(server)
 		if err != nil {
@@ -254,3 +826,129 @@ func (o *ControllerOptions) Validate() error {
 	}
 	return nil
 }
+
+// WorkersFor returns the number of workers that should be run for the named
+// controller.
+func (o *ControllerOptions) WorkersFor(name string) int {
+	if n, ok := o.ControllerWorkers[name]; ok {
+		return n
+	}
+	return o.DefaultWorkers
+}
+
+// parseControllerWorkers parses a list of name=count pairs into a map of
+// controller name to worker count. An error is returned if any of the named
+// controllers are not known, or if a count is not a positive integer.
+func parseControllerWorkers(pairs []string) (map[string]int, error) {
+	known := controller.Known()
+	workers := make(map[string]int, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid controller workers entry %q: must be of the form name=count", pair)
+		}
+		name := strings.TrimSpace(parts[0])
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("invalid controller workers entry %q: unknown controller %q", pair, name)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid controller workers entry %q: count must be a positive integer", pair)
+		}
+		workers[name] = n
+	}
+	return workers, nil
+}
+
+// imageDigestRegexp matches a sha256 digest as it appears in an image reference.
+var imageDigestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// validateImageDigest checks that the given image reference is pinned by a
+// sha256 digest, for example example.com/image@sha256:<digest>. An image may
+// include a tag as well as a digest, in which case the tag is ignored when
+// the image is pulled.
+func validateImageDigest(image string) error {
+	i := strings.LastIndex(image, "@")
+	if i < 0 {
+		return fmt.Errorf("must be pinned by digest (@sha256:<digest>)")
+	}
+	if i == 0 {
+		return fmt.Errorf("must include a repository name")
+	}
+	if !imageDigestRegexp.MatchString(image[i+1:]) {
+		return fmt.Errorf("invalid digest %q: must be sha256: followed by 64 lowercase hex characters", image[i+1:])
+	}
+	return nil
+}
+
+// validateListenAddress checks that addr is a host:port pair that can be
+// listened on. The host may be empty to listen on all interfaces.
+func validateListenAddress(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("must be of the form host:port")
+	}
+	if host != "" && net.ParseIP(host) == nil && len(validation.IsDNS1123Subdomain(host)) > 0 {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// parseNodeSelector parses a list of key=value pairs into a node selector.
+func parseNodeSelector(pairs []string) (map[string]string, error) {
+	selector := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid ACME HTTP01 solver node selector entry %q: must be of the form key=value", pair)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid ACME HTTP01 solver node selector entry %q: %s", pair, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid ACME HTTP01 solver node selector entry %q: %s", pair, strings.Join(errs, "; "))
+		}
+		selector[key] = value
+	}
+	return selector, nil
+}
+
+// parseTolerations parses a list of key[=value][:effect] entries into pod
+// tolerations. Entries without a value use the Exists operator.
+func parseTolerations(specs []string) ([]corev1.Toleration, error) {
+	tolerations := make([]corev1.Toleration, 0, len(specs))
+	for _, spec := range specs {
+		t := corev1.Toleration{}
+		rest := strings.TrimSpace(spec)
+		if i := strings.LastIndex(rest, ":"); i >= 0 {
+			t.Effect = corev1.TaintEffect(rest[i+1:])
+			rest = rest[:i]
+			switch t.Effect {
+			case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			default:
+				return nil, fmt.Errorf("invalid ACME HTTP01 solver toleration %q: effect must be one of %q, %q or %q",
+					spec, corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute)
+			}
+		}
+		t.Operator = corev1.TolerationOpExists
+		if i := strings.Index(rest, "="); i >= 0 {
+			t.Operator = corev1.TolerationOpEqual
+			t.Value = rest[i+1:]
+			rest = rest[:i]
+			if errs := validation.IsValidLabelValue(t.Value); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid ACME HTTP01 solver toleration %q: %s", spec, strings.Join(errs, "; "))
+			}
+		}
+		t.Key = rest
+		if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid ACME HTTP01 solver toleration %q: %s", spec, strings.Join(errs, "; "))
+		}
+		tolerations = append(tolerations, t)
+	}
+	return tolerations, nil
+}
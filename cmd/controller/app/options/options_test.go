@@ -0,0 +1,252 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDigest = "sha256:4e1af7b4ba2fb20bba56d5b9b6bd2bb602a7bd7ed0c5f4cda82f4ea2bd3fd1e0"
+
+func TestValidateImageDigest(t *testing.T) {
+	tests := map[string]struct {
+		image string
+		err   string
+	}{
+		"image pinned by digest": {
+			image: "quay.io/jetstack/cert-manager-acmesolver@" + testDigest,
+		},
+		"image with a tag and a digest": {
+			image: "quay.io/jetstack/cert-manager-acmesolver:v0.8.0@" + testDigest,
+		},
+		"registry with a port": {
+			image: "registry.example.com:5000/acmesolver@" + testDigest,
+		},
+		"image with a tag only": {
+			image: "quay.io/jetstack/cert-manager-acmesolver:v0.8.0",
+			err:   "must be pinned by digest",
+		},
+		"image with neither a tag nor a digest": {
+			image: "acmesolver",
+			err:   "must be pinned by digest",
+		},
+		"digest without a repository": {
+			image: "@" + testDigest,
+			err:   "must include a repository name",
+		},
+		"truncated digest": {
+			image: "acmesolver@sha256:4e1af7b4",
+			err:   "invalid digest",
+		},
+		"digest with another algorithm": {
+			image: "acmesolver@sha512:" + strings.Repeat("a", 128),
+			err:   "invalid digest",
+		},
+		"digest with uppercase characters": {
+			image: "acmesolver@" + strings.ToUpper(testDigest),
+			err:   "invalid digest",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateImageDigest(tt.image)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateRequiresSolverImageDigest(t *testing.T) {
+	o := NewControllerOptions()
+	o.ACMEHTTP01SolverImage = "quay.io/jetstack/cert-manager-acmesolver:canary"
+	if err := o.Validate(); err != nil {
+		t.Fatalf("expected a solver image tag to be accepted by default, got: %v", err)
+	}
+
+	o.ACMEHTTP01SolverImageRequireDigest = true
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "must be pinned by digest") {
+		t.Errorf("expected a solver image without a digest to be rejected, got: %v", err)
+	}
+
+	o.ACMEHTTP01SolverImage = "quay.io/jetstack/cert-manager-acmesolver@" + testDigest
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected a solver image pinned by digest to be accepted, got: %v", err)
+	}
+}
+
+func TestValidateKubernetesAPIRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		qps   float32
+		burst int
+		err   string
+	}{
+		"burst larger than qps": {
+			qps:   20,
+			burst: 50,
+		},
+		"burst equal to qps": {
+			qps:   50,
+			burst: 50,
+		},
+		"zero qps": {
+			qps:   0,
+			burst: 50,
+			err:   "invalid kubernetes api qps",
+		},
+		"burst lower than qps": {
+			qps:   100,
+			burst: 50,
+			err:   "invalid kubernetes api burst",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := NewControllerOptions()
+			o.KubernetesAPIQPS = tt.qps
+			o.KubernetesAPIBurst = tt.burst
+			err := o.Validate()
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected error containing %q, got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateCertManagerAPIRateLimits(t *testing.T) {
+	o := NewControllerOptions()
+	o.CertManagerAPIQPS = 0
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "invalid cert-manager api qps") {
+		t.Errorf("expected a zero cert-manager api qps to be rejected, got: %v", err)
+	}
+
+	o = NewControllerOptions()
+	o.CertManagerAPIQPS = 100
+	o.CertManagerAPIBurst = 50
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "invalid cert-manager api burst") {
+		t.Errorf("expected a cert-manager api burst lower than the qps to be rejected, got: %v", err)
+	}
+
+	o.KubernetesAPIQPS = 5
+	o.KubernetesAPIBurst = 10
+	o.CertManagerAPIBurst = 100
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected independent rate limits to be accepted, got: %v", err)
+	}
+}
+
+func TestValidateACMEOrderPollIntervals(t *testing.T) {
+	o := NewControllerOptions()
+	o.ACMEOrderPollInitialInterval = 0
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "invalid ACME order poll initial interval") {
+		t.Errorf("expected a zero initial poll interval to be rejected, got: %v", err)
+	}
+
+	o = NewControllerOptions()
+	o.ACMEOrderPollInitialInterval = time.Minute
+	o.ACMEOrderPollMaxInterval = time.Second
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "invalid ACME order poll max interval") {
+		t.Errorf("expected a max poll interval lower than the initial interval to be rejected, got: %v", err)
+	}
+
+	o.ACMEOrderPollMaxInterval = time.Minute
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected equal poll intervals to be accepted, got: %v", err)
+	}
+}
+
+func TestValidateListenAddress(t *testing.T) {
+	tests := map[string]struct {
+		addr string
+		err  string
+	}{
+		"all interfaces": {
+			addr: ":9402",
+		},
+		"ipv4 address": {
+			addr: "0.0.0.0:9402",
+		},
+		"ipv6 address": {
+			addr: "[::1]:9402",
+		},
+		"hostname": {
+			addr: "localhost:8080",
+		},
+		"missing port": {
+			addr: "0.0.0.0",
+			err:  "must be of the form host:port",
+		},
+		"empty": {
+			addr: "",
+			err:  "must be of the form host:port",
+		},
+		"non-numeric port": {
+			addr: "0.0.0.0:metrics",
+			err:  "invalid port",
+		},
+		"port out of range": {
+			addr: ":70000",
+			err:  "invalid port",
+		},
+		"invalid hostname": {
+			addr: "not_a_host:9402",
+			err:  "invalid host",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateListenAddress(tt.addr)
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected error containing %q, got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateMetricsTLS(t *testing.T) {
+	o := NewControllerOptions()
+	o.MetricsTLSCertFile = "/tls/tls.crt"
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "must be set together") {
+		t.Errorf("expected a certificate without a key to be rejected, got: %v", err)
+	}
+
+	o.MetricsTLSKeyFile = "/tls/tls.key"
+	o.MetricsTLSClientCAFile = "/tls/ca.crt"
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected a certificate, key and client CA to be accepted, got: %v", err)
+	}
+
+	o = NewControllerOptions()
+	o.MetricsTLSClientCAFile = "/tls/ca.crt"
+	if err := o.Validate(); err == nil || !strings.Contains(err.Error(), "requires --metrics-tls-cert") {
+		t.Errorf("expected a client CA without a serving certificate to be rejected, got: %v", err)
+	}
+}
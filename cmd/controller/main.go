@@ -22,8 +22,6 @@ import (
 	"os/signal"
 	"syscall"
 
-	"github.com/golang/glog"
-
 	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
@@ -36,7 +34,9 @@ func main() {
 	cmd.Flags().AddGoFlagSet(flag.CommandLine)
 	flag.CommandLine.Parse([]string{})
 	if err := cmd.Execute(); err != nil {
-		glog.Fatal(err)
+		logs.Error(err)
+		logs.FlushLogs()
+		os.Exit(1)
 	}
 }
 
@@ -17,10 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 
-	"github.com/golang/glog"
 	"github.com/spf13/cobra"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -29,14 +29,19 @@ import (
 	"github.com/jetstack/cert-manager/cmd/controller/app/options"
 	_ "github.com/jetstack/cert-manager/pkg/controller/acmechallenges"
 	_ "github.com/jetstack/cert-manager/pkg/controller/acmeorders"
+	_ "github.com/jetstack/cert-manager/pkg/controller/certificaterequests"
 	_ "github.com/jetstack/cert-manager/pkg/controller/certificates"
 	_ "github.com/jetstack/cert-manager/pkg/controller/clusterissuers"
+	_ "github.com/jetstack/cert-manager/pkg/controller/gateway-shim"
 	_ "github.com/jetstack/cert-manager/pkg/controller/ingress-shim"
 	_ "github.com/jetstack/cert-manager/pkg/controller/issuers"
 	_ "github.com/jetstack/cert-manager/pkg/issuer/acme"
 	_ "github.com/jetstack/cert-manager/pkg/issuer/ca"
+	_ "github.com/jetstack/cert-manager/pkg/issuer/external"
 	_ "github.com/jetstack/cert-manager/pkg/issuer/selfsigned"
 	_ "github.com/jetstack/cert-manager/pkg/issuer/vault"
+	_ "github.com/jetstack/cert-manager/pkg/issuer/venafi"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util"
 )
 
@@ -72,14 +77,22 @@ TLS certificates from various issuing sources.
 It will ensure certificates are valid and up to date periodically, and attempt
 to renew certificates at an appropriate time before expiry.`,
 
+		// Errors returned from RunE are reported by main, so don't print the
+		// usage text when the controller fails after flags have been parsed.
+		SilenceUsage: true,
+
 		// TODO: Refactor this function from this package
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Validate(args); err != nil {
-				glog.Fatalf("error validating options: %s", err.Error())
+				return fmt.Errorf("error validating options: %s", err.Error())
+			}
+
+			if err := logs.SetFormat(o.ControllerOptions.LogFormat); err != nil {
+				return err
 			}
 
-			glog.Infof("starting cert-manager %s (revision %s)", util.AppVersion, util.AppGitCommit)
-			o.RunCertManagerController(stopCh)
+			logs.Infof("starting cert-manager %s (revision %s)", util.AppVersion, util.AppGitCommit)
+			return o.RunCertManagerController(stopCh)
 		},
 	}
 
@@ -95,6 +108,7 @@ func (o CertManagerControllerOptions) Validate(args []string) error {
 	return utilerrors.NewAggregate(errors)
 }
 
-func (o CertManagerControllerOptions) RunCertManagerController(stopCh <-chan struct{}) {
-	app.Run(o.ControllerOptions, stopCh)
+func (o CertManagerControllerOptions) RunCertManagerController(stopCh <-chan struct{}) error {
+	ctx := util.ContextWithStopCh(context.Background(), stopCh)
+	return app.RunWithError(ctx, o.ControllerOptions)
 }
@@ -27,6 +27,7 @@ import (
 var certHook cmd.ValidatingAdmissionHook = &webhooks.CertificateAdmissionHook{}
 var issuerHook cmd.ValidatingAdmissionHook = &webhooks.IssuerAdmissionHook{}
 var clusterIssuerHook cmd.ValidatingAdmissionHook = &webhooks.ClusterIssuerAdmissionHook{}
+var certDefaultingHook cmd.MutatingAdmissionHook = &webhooks.CertificateDefaultingHook{}
 
 func main() {
 	// Avoid "logging before flag.Parse" errors from glog
@@ -36,5 +37,6 @@ func main() {
 		certHook,
 		issuerHook,
 		clusterIssuerHook,
+		certDefaultingHook,
 	)
 }
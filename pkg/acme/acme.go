@@ -18,8 +18,11 @@ package acme
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
@@ -40,7 +43,7 @@ import (
 
 type Helper interface {
 	ClientForIssuer(iss cmapi.GenericIssuer) (acme.Interface, error)
-	ReadPrivateKey(sel cmapi.SecretKeySelector, ns string) (*rsa.PrivateKey, error)
+	ReadPrivateKey(sel cmapi.SecretKeySelector, ns string) (crypto.Signer, error)
 }
 
 // Helper is a structure that provides 'glue' between cert-managers API types and
@@ -76,9 +79,9 @@ func PrivateKeySelector(sel cmapi.SecretKeySelector) cmapi.SecretKeySelector {
 // ReadPrivateKey will attempt to read and parse an ACME private key from a secret.
 // If the referenced secret or key within that secret does not exist, an error will
 // be returned.
-// A *rsa.PrivateKey will be returned here, as ACME private keys can currently
-// only be RSA.
-func (h *helperImpl) ReadPrivateKey(sel cmapi.SecretKeySelector, ns string) (*rsa.PrivateKey, error) {
+// Either a *rsa.PrivateKey or *ecdsa.PrivateKey will be returned here, as
+// these are the only key types that can be used to sign ACME requests.
+func (h *helperImpl) ReadPrivateKey(sel cmapi.SecretKeySelector, ns string) (crypto.Signer, error) {
 	sel = PrivateKeySelector(sel)
 
 	s, err := h.SecretLister.Secrets(ns).Get(sel.Name)
@@ -97,23 +100,27 @@ func (h *helperImpl) ReadPrivateKey(sel cmapi.SecretKeySelector, ns string) (*rs
 		return nil, err
 	}
 
-	rsaKey, ok := pk.(*rsa.PrivateKey)
-	if !ok {
-		return nil, cmerrors.NewInvalidData("ACME private key in %q is not of type RSA", sel.Name)
+	switch pk.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, cmerrors.NewInvalidData("ACME private key in %q is not of type RSA or ECDSA", sel.Name)
 	}
 
-	return rsaKey, nil
+	return pk, nil
 }
 
 // ClientWithKey will construct a new ACME client for the provided Issuer, using
-// the given RSA private key.
-func ClientWithKey(iss cmapi.GenericIssuer, pk *rsa.PrivateKey) (acme.Interface, error) {
+// the given RSA or ECDSA private key.
+func ClientWithKey(iss cmapi.GenericIssuer, pk crypto.Signer) (acme.Interface, error) {
 	acmeSpec := iss.GetSpec().ACME
 	if acmeSpec == nil {
 		return nil, fmt.Errorf("issuer %q is not an ACME issuer. Ensure the 'acme' stanza is correctly specified on your Issuer resource", iss.GetObjectMeta().Name)
 	}
 	acmeStatus := iss.GetStatus().ACME
-	acmeCl := lookupClient(acmeSpec, acmeStatus, pk)
+	acmeCl, err := lookupClient(acmeSpec, acmeStatus, iss.GetSpec().Proxy, pk)
+	if err != nil {
+		return nil, err
+	}
 
 	return acmemw.NewLogger(acmeCl), nil
 }
@@ -153,12 +160,13 @@ var (
 type repoKey struct {
 	accounturi string
 	skiptls    bool
+	cabundle   string
+	proxy      string
 	server     string
 	publickey  string
-	exponent   int
 }
 
-func lookupClient(spec *cmapi.ACMEIssuer, status *cmapi.ACMEIssuerStatus, pk *rsa.PrivateKey) *acmecl.Client {
+func lookupClient(spec *cmapi.ACMEIssuer, status *cmapi.ACMEIssuerStatus, proxy string, pk crypto.Signer) (*acmecl.Client, error) {
 	clientRepoMu.Lock()
 	defer clientRepoMu.Unlock()
 	if clientRepo == nil {
@@ -171,26 +179,31 @@ func lookupClient(spec *cmapi.ACMEIssuer, status *cmapi.ACMEIssuerStatus, pk *rs
 	repokey := repoKey{
 		accounturi: accountURI,
 		skiptls:    spec.SkipTLSVerify,
-		server:     spec.Server,
+		cabundle:   string(spec.CABundle),
+		proxy:      proxy,
+		server:     spec.ServerURL(),
 	}
-	// Encoding a big.Int cannot fail
-	pkbytes, _ := pk.PublicKey.N.GobEncode()
+	// Marshalling an RSA or ECDSA public key cannot fail
+	pkbytes, _ := x509.MarshalPKIXPublicKey(pk.Public())
 	repokey.publickey = string(pkbytes)
-	repokey.exponent = pk.PublicKey.E
 
 	client := clientRepo[repokey]
 	if client != nil {
-		return client
+		return client, nil
+	}
+	httpClient, err := buildHTTPClient(spec.SkipTLSVerify, spec.CABundle, proxy)
+	if err != nil {
+		return nil, err
 	}
 	acmeCl := &acmecl.Client{
-		HTTPClient:   buildHTTPClient(spec.SkipTLSVerify),
+		HTTPClient:   httpClient,
 		Key:          pk,
-		DirectoryURL: spec.Server,
+		DirectoryURL: spec.ServerURL(),
 		UserAgent:    util.CertManagerUserAgent,
 	}
 	acmeCl.SetAccountURL(accountURI)
 	clientRepo[repokey] = acmeCl
-	return acmeCl
+	return acmeCl, nil
 }
 
 func ClearClientCache() {
@@ -205,19 +218,35 @@ func ClearClientCache() {
 // itself.
 // In future, we may change to having two global HTTP clients - one that ignores
 // TLS connection errors, and the other that does not.
-func buildHTTPClient(skipTLSVerify bool) *http.Client {
+// If caBundle is not empty, it replaces the system root certificates used to
+// verify the ACME server.
+// If proxy is not empty, all requests are sent through it instead of the
+// proxy configured in the environment.
+func buildHTTPClient(skipTLSVerify bool, caBundle []byte, proxy string) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: skipTLSVerify}
+	if len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("error loading ACME server CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	proxyFunc, err := util.ProxyFunc(proxy)
+	if err != nil {
+		return nil, err
+	}
 	return acme.NewInstrumentedClient(&http.Client{
 		Transport: &http.Transport{
-			Proxy:                 http.ProxyFromEnvironment,
+			Proxy:                 proxyFunc,
 			DialContext:           dialTimeout,
-			TLSClientConfig:       &tls.Config{InsecureSkipVerify: skipTLSVerify},
+			TLSClientConfig:       tlsConfig,
 			MaxIdleConns:          100,
 			IdleConnTimeout:       90 * time.Second,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 		},
 		Timeout: time.Second * 30,
-	})
+	}), nil
 }
 
 var timeout = time.Duration(5 * time.Second)
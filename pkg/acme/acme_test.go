@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuildHTTPClientCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tests := map[string]struct {
+		skipTLSVerify bool
+		caBundle      []byte
+		buildErr      bool
+		requestErr    bool
+	}{
+		"server is not trusted without a CA bundle": {
+			requestErr: true,
+		},
+		"server is trusted using the CA bundle": {
+			caBundle: caBundle,
+		},
+		"server is trusted if TLS verification is skipped": {
+			skipTLSVerify: true,
+		},
+		"invalid CA bundle": {
+			caBundle: []byte("not a certificate"),
+			buildErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl, err := buildHTTPClient(test.skipTLSVerify, test.caBundle, "")
+			if err != nil != test.buildErr {
+				t.Fatalf("expected build error %t, got %v", test.buildErr, err)
+			}
+			if err != nil {
+				return
+			}
+			resp, err := cl.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if err != nil != test.requestErr {
+				t.Errorf("expected request error %t, got %v", test.requestErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildHTTPClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	cl, err := buildHTTPClient(false, nil, proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := cl.Get("http://acme.example.com/directory")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://acme.example.com/directory" {
+		t.Errorf("expected request to be sent through the proxy, but proxy received %q", proxied)
+	}
+
+	if _, err := buildHTTPClient(false, nil, "ftp://proxy.example.com"); err == nil {
+		t.Errorf("expected error for unsupported proxy scheme")
+	}
+}
@@ -31,6 +31,7 @@ type FakeACME struct {
 	FakeCreateOrder             func(ctx context.Context, order *acme.Order) (*acme.Order, error)
 	FakeGetOrder                func(ctx context.Context, url string) (*acme.Order, error)
 	FakeGetCertificate          func(ctx context.Context, url string) ([][]byte, error)
+	FakeListCertAlternates      func(ctx context.Context, url string) ([]string, error)
 	FakeWaitOrder               func(ctx context.Context, url string) (*acme.Order, error)
 	FakeFinalizeOrder           func(ctx context.Context, finalizeURL string, csr []byte) (der [][]byte, err error)
 	FakeAcceptChallenge         func(ctx context.Context, chal *acme.Challenge) (*acme.Challenge, error)
@@ -39,8 +40,10 @@ type FakeACME struct {
 	FakeWaitAuthorization       func(ctx context.Context, url string) (*acme.Authorization, error)
 	FakeCreateAccount           func(ctx context.Context, a *acme.Account) (*acme.Account, error)
 	FakeGetAccount              func(ctx context.Context) (*acme.Account, error)
+	FakeUpdateAccount           func(ctx context.Context, a *acme.Account) (*acme.Account, error)
 	FakeHTTP01ChallengeResponse func(token string) (string, error)
 	FakeDNS01ChallengeRecord    func(token string) (string, error)
+	FakeDiscover                func(ctx context.Context) (acme.Directory, error)
 }
 
 func (f *FakeACME) CreateOrder(ctx context.Context, order *acme.Order) (*acme.Order, error) {
@@ -64,6 +67,13 @@ func (f *FakeACME) GetCertificate(ctx context.Context, url string) ([][]byte, er
 	return nil, fmt.Errorf("GetCertificate not implemented")
 }
 
+func (f *FakeACME) ListCertAlternates(ctx context.Context, url string) ([]string, error) {
+	if f.FakeListCertAlternates != nil {
+		return f.FakeListCertAlternates(ctx, url)
+	}
+	return nil, fmt.Errorf("ListCertAlternates not implemented")
+}
+
 func (f *FakeACME) WaitOrder(ctx context.Context, url string) (*acme.Order, error) {
 	if f.FakeWaitOrder != nil {
 		return f.FakeWaitOrder(ctx, url)
@@ -120,6 +130,13 @@ func (f *FakeACME) GetAccount(ctx context.Context) (*acme.Account, error) {
 	return nil, fmt.Errorf("GetAccount not implemented")
 }
 
+func (f *FakeACME) UpdateAccount(ctx context.Context, a *acme.Account) (*acme.Account, error) {
+	if f.FakeUpdateAccount != nil {
+		return f.FakeUpdateAccount(ctx, a)
+	}
+	return nil, fmt.Errorf("UpdateAccount not implemented")
+}
+
 func (f *FakeACME) HTTP01ChallengeResponse(token string) (string, error) {
 	if f.FakeHTTP01ChallengeResponse != nil {
 		return f.FakeHTTP01ChallengeResponse(token)
@@ -133,3 +150,10 @@ func (f *FakeACME) DNS01ChallengeRecord(token string) (string, error) {
 	}
 	return "", fmt.Errorf("DNS01ChallengeRecord not implemented")
 }
+
+func (f *FakeACME) Discover(ctx context.Context) (acme.Directory, error) {
+	if f.FakeDiscover != nil {
+		return f.FakeDiscover(ctx)
+	}
+	return acme.Directory{}, fmt.Errorf("Discover not implemented")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+func TestPathProcessor(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"/directory":               "/directory",
+		"/acme/new-order":          "/acme/new-order",
+		"/acme/authz/abc123/xyz":   "/acme/authz",
+		"/acme/chall/abc123/xyz/1": "/acme/chall",
+	}
+	for path, expected := range tests {
+		if got := pathProcessor(path); got != expected {
+			t.Errorf("pathProcessor(%q): expected %q but got %q", path, expected, got)
+		}
+	}
+}
+
+func TestInstrumentedClientRequestCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/acme/new-order" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("error parsing test server url: %v", err)
+	}
+
+	countFor := func(path, method, status string) float64 {
+		return testutil.ToFloat64(metrics.Default.ACMEClientRequestCount.WithLabelValues("http", serverURL.Host, path, method, status))
+	}
+	getBefore := countFor("/directory", http.MethodGet, "200")
+	postBefore := countFor("/acme/new-order", http.MethodPost, "429")
+
+	cl := NewInstrumentedClient(&http.Client{})
+	for i := 0; i < 2; i++ {
+		resp, err := cl.Get(server.URL + "/directory")
+		if err != nil {
+			t.Fatalf("unexpected error making request: %v", err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := cl.Post(server.URL+"/acme/new-order", "application/jose+json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error making request: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := countFor("/directory", http.MethodGet, "200") - getBefore; got != 2 {
+		t.Errorf("expected 2 GET requests to be counted but got %v", got)
+	}
+	if got := countFor("/acme/new-order", http.MethodPost, "429") - postBefore; got != 1 {
+		t.Errorf("expected 1 rate limited POST request to be counted but got %v", got)
+	}
+}
+
+func TestInstrumentedClientRequestCountOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("error parsing test server url: %v", err)
+	}
+	// close the server so that requests to it fail
+	server.Close()
+
+	before := testutil.ToFloat64(metrics.Default.ACMEClientRequestCount.WithLabelValues("http", serverURL.Host, "/directory", http.MethodGet, "999"))
+
+	cl := NewInstrumentedClient(&http.Client{})
+	if _, err := cl.Get(server.URL + "/directory"); err == nil {
+		t.Fatalf("expected request to a closed server to fail")
+	}
+
+	after := testutil.ToFloat64(metrics.Default.ACMEClientRequestCount.WithLabelValues("http", serverURL.Host, "/directory", http.MethodGet, "999"))
+	if after-before != 1 {
+		t.Errorf("expected failed request to be counted with status 999 but got %v", after-before)
+	}
+}
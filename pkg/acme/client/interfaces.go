@@ -26,6 +26,7 @@ type Interface interface {
 	CreateOrder(ctx context.Context, order *acme.Order) (*acme.Order, error)
 	GetOrder(ctx context.Context, url string) (*acme.Order, error)
 	GetCertificate(ctx context.Context, url string) ([][]byte, error)
+	ListCertAlternates(ctx context.Context, url string) ([]string, error)
 	WaitOrder(ctx context.Context, url string) (*acme.Order, error)
 	FinalizeOrder(ctx context.Context, finalizeURL string, csr []byte) (der [][]byte, err error)
 	AcceptChallenge(ctx context.Context, chal *acme.Challenge) (*acme.Challenge, error)
@@ -34,8 +35,10 @@ type Interface interface {
 	WaitAuthorization(ctx context.Context, url string) (*acme.Authorization, error)
 	CreateAccount(ctx context.Context, a *acme.Account) (*acme.Account, error)
 	GetAccount(ctx context.Context) (*acme.Account, error)
+	UpdateAccount(ctx context.Context, a *acme.Account) (*acme.Account, error)
 	HTTP01ChallengeResponse(token string) (string, error)
 	DNS01ChallengeRecord(token string) (string, error)
+	Discover(ctx context.Context) (acme.Directory, error)
 }
 
 var _ Interface = &acme.Client{}
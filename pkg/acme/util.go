@@ -17,7 +17,11 @@ limitations under the License.
 package acme
 
 import (
+	"net/http"
+	"time"
+
 	v1alpha1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
 
 // IsFinalState will return true if the given ACME State is a 'final' state.
@@ -39,3 +43,40 @@ func IsFailureState(s v1alpha1.State) bool {
 	}
 	return false
 }
+
+// RetryAfter returns the duration the ACME server has asked us to wait before
+// retrying a request that failed with err.
+// It returns false if err is not a rate limit error or if the response did
+// not contain a valid Retry-After header. Dates in the past result in a zero
+// duration.
+func RetryAfter(err error) (time.Duration, bool) {
+	t, ok := acmeapi.RateLimit(err)
+	if !ok || t.IsZero() {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// IsMalformed returns true if err is an error returned by the ACME server
+// because the request was malformed.
+func IsMalformed(err error) bool {
+	acmeErr, ok := err.(*acmeapi.Error)
+	return ok && acmeErr.Type == "urn:ietf:params:acme:error:malformed"
+}
+
+// IsAccountDoesNotExist returns true if err is an error returned by the ACME
+// server because no account is registered for the client's private key.
+// Older servers respond with a plain 404 instead of the accountDoesNotExist
+// error type, so both are treated as the account not existing.
+func IsAccountDoesNotExist(err error) bool {
+	acmeErr, ok := err.(*acmeapi.Error)
+	if !ok {
+		return false
+	}
+	return acmeErr.StatusCode == http.StatusNotFound ||
+		acmeErr.Type == "urn:ietf:params:acme:error:accountDoesNotExist"
+}
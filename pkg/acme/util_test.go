@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
+)
+
+func TestIsAccountDoesNotExist(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"non-acme error": {
+			err: fmt.Errorf("some error"),
+		},
+		"not found": {
+			err:      &acmeapi.Error{StatusCode: http.StatusNotFound},
+			expected: true,
+		},
+		"bad request with accountDoesNotExist type": {
+			err:      &acmeapi.Error{StatusCode: http.StatusBadRequest, Type: "urn:ietf:params:acme:error:accountDoesNotExist"},
+			expected: true,
+		},
+		"bad request with another type": {
+			err: &acmeapi.Error{StatusCode: http.StatusBadRequest, Type: "urn:ietf:params:acme:error:malformed"},
+		},
+		"bad request without a type": {
+			err: &acmeapi.Error{StatusCode: http.StatusBadRequest},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := IsAccountDoesNotExist(test.err); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	const rateLimited = "urn:ietf:params:acme:error:rateLimited"
+	retryAfter := func(v string) http.Header {
+		header := http.Header{}
+		header.Set("Retry-After", v)
+		return header
+	}
+
+	tests := map[string]struct {
+		err      error
+		expected time.Duration
+		ok       bool
+	}{
+		"non-acme error": {
+			err: fmt.Errorf("some error"),
+		},
+		"acme error that is not rate limited": {
+			err: &acmeapi.Error{StatusCode: http.StatusBadRequest, Header: retryAfter("60")},
+		},
+		"rate limited without a Retry-After header": {
+			err: &acmeapi.Error{StatusCode: http.StatusTooManyRequests, Type: rateLimited},
+		},
+		"rate limited with an invalid Retry-After header": {
+			err: &acmeapi.Error{StatusCode: http.StatusTooManyRequests, Type: rateLimited, Header: retryAfter("soon")},
+		},
+		"rate limited with a delay in seconds": {
+			err:      &acmeapi.Error{StatusCode: http.StatusTooManyRequests, Type: rateLimited, Header: retryAfter("120")},
+			expected: 2 * time.Minute,
+			ok:       true,
+		},
+		"rate limited with an http date": {
+			err:      &acmeapi.Error{StatusCode: http.StatusTooManyRequests, Type: rateLimited, Header: retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))},
+			expected: time.Hour,
+			ok:       true,
+		},
+		"rate limited with an http date in the past": {
+			err: &acmeapi.Error{StatusCode: http.StatusTooManyRequests, Type: rateLimited, Header: retryAfter("Fri, 01 Mar 2019 09:00:00 GMT")},
+			ok:  true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, ok := RetryAfter(test.err)
+			if ok != test.ok {
+				t.Errorf("expected ok=%v but got %v", test.ok, ok)
+			}
+			// the delay is relative to the current time, so allow for the
+			// time taken to run the test and for http dates being truncated
+			// to the second
+			if d > test.expected || d < test.expected-2*time.Second {
+				t.Errorf("expected duration %v but got %v", test.expected, d)
+			}
+		})
+	}
+}
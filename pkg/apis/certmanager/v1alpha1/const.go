@@ -34,4 +34,25 @@ const (
 
 const (
 	ACMEFinalizer = "finalizer.acme.cert-manager.io"
+
+	// CertificateFinalizer is added to Certificates so that the controller
+	// can apply the Certificate's SecretDeletionPolicy before it is removed.
+	CertificateFinalizer = "finalizer.certificates.cert-manager.io"
+)
+
+const (
+	// ACMEServerLetsEncrypt is the shorthand name for the Let's Encrypt
+	// production ACME server.
+	ACMEServerLetsEncrypt = "lets-encrypt"
+
+	// ACMEServerLetsEncryptStaging is the shorthand name for the Let's Encrypt
+	// staging ACME server.
+	ACMEServerLetsEncryptStaging = "lets-encrypt-staging"
 )
+
+// ACMEServerAliases maps the shorthand names that may be used in the server
+// field of an ACME issuer to the directory URL they refer to.
+var ACMEServerAliases = map[string]string{
+	ACMEServerLetsEncrypt:        "https://acme-v02.api.letsencrypt.org/directory",
+	ACMEServerLetsEncryptStaging: "https://acme-staging-v02.api.letsencrypt.org/directory",
+}
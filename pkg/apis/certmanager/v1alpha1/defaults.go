@@ -23,3 +23,18 @@ import (
 func addDefaultingFuncs(scheme *runtime.Scheme) error {
 	return RegisterDefaults(scheme)
 }
+
+// SetDefaults_CertificateSpec sets the private key parameters that are used
+// for a Certificate that does not specify them.
+// duration is not defaulted, as its default depends on the issuer, and
+// renewBefore is not defaulted, as its default is set by a flag on the
+// controller. The RSA key size is defaulted by the webhook, as its default is
+// defined in pkg/util/pki.
+func SetDefaults_CertificateSpec(obj *CertificateSpec) {
+	if obj.KeyAlgorithm == "" {
+		obj.KeyAlgorithm = RSAKeyAlgorithm
+	}
+	if obj.KeyAlgorithm == ECDSAKeyAlgorithm && obj.KeySize == 0 && obj.KeyCurve == "" {
+		obj.KeyCurve = P256KeyCurve
+	}
+}
@@ -20,9 +20,10 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 func (i *IssuerStatus) ACMEStatus() *ACMEIssuerStatus {
@@ -36,6 +37,15 @@ func (i *IssuerStatus) ACMEStatus() *ACMEIssuerStatus {
 	return i.ACME
 }
 
+// ServerURL returns the directory URL of the ACME server, expanding any
+// shorthand name set in the server field. Full URLs are returned verbatim.
+func (a *ACMEIssuer) ServerURL() string {
+	if u, ok := ACMEServerAliases[a.Server]; ok {
+		return u
+	}
+	return a.Server
+}
+
 func (a *ACMEIssuerDNS01Config) Provider(name string) (*ACMEIssuerDNS01Provider, error) {
 	if a == nil {
 		return nil, fmt.Errorf("issuer does not contain DNS01 configuration for provider named %q", name)
@@ -86,14 +96,14 @@ func (iss *Issuer) UpdateStatusCondition(conditionType IssuerConditionType, stat
 	t := time.Now()
 
 	if len(iss.Status.Conditions) == 0 {
-		glog.Infof("Setting lastTransitionTime for Issuer %q condition %q to %v", iss.Name, conditionType, t)
+		logs.Infof("Setting lastTransitionTime for Issuer %q condition %q to %v", iss.Name, conditionType, t)
 		newCondition.LastTransitionTime = metav1.NewTime(t)
 		iss.Status.Conditions = []IssuerCondition{newCondition}
 	} else {
 		for i, cond := range iss.Status.Conditions {
 			if cond.Type == conditionType {
 				if cond.Status != newCondition.Status {
-					glog.Infof("Found status change for Issuer %q condition %q: %q -> %q; setting lastTransitionTime to %v", iss.Name, conditionType, cond.Status, status, t)
+					logs.Infof("Found status change for Issuer %q condition %q: %q -> %q; setting lastTransitionTime to %v", iss.Name, conditionType, cond.Status, status, t)
 					newCondition.LastTransitionTime = metav1.NewTime(t)
 				} else {
 					newCondition.LastTransitionTime = cond.LastTransitionTime
@@ -133,14 +143,14 @@ func (iss *ClusterIssuer) UpdateStatusCondition(conditionType IssuerConditionTyp
 	t := time.Now()
 
 	if len(iss.Status.Conditions) == 0 {
-		glog.Infof("Setting lastTransitionTime for ClusterIssuer %q condition %q to %v", iss.Name, conditionType, t)
+		logs.Infof("Setting lastTransitionTime for ClusterIssuer %q condition %q to %v", iss.Name, conditionType, t)
 		newCondition.LastTransitionTime = metav1.NewTime(t)
 		iss.Status.Conditions = []IssuerCondition{newCondition}
 	} else {
 		for i, cond := range iss.Status.Conditions {
 			if cond.Type == conditionType {
 				if cond.Status != newCondition.Status {
-					glog.Infof("Found status change for ClusterIssuer %q condition %q: %q -> %q; setting lastTransitionTime to %v", iss.Name, conditionType, cond.Status, status, t)
+					logs.Infof("Found status change for ClusterIssuer %q condition %q: %q -> %q; setting lastTransitionTime to %v", iss.Name, conditionType, cond.Status, status, t)
 					newCondition.LastTransitionTime = metav1.NewTime(t)
 				} else {
 					newCondition.LastTransitionTime = cond.LastTransitionTime
@@ -180,18 +190,22 @@ func (crt *Certificate) UpdateStatusCondition(conditionType CertificateCondition
 	t := time.Now()
 
 	if len(crt.Status.Conditions) == 0 {
-		glog.Infof("Setting lastTransitionTime for Certificate %q condition %q to %v", crt.Name, conditionType, t)
+		logs.Infof("Setting lastTransitionTime for Certificate %q condition %q to %v", crt.Name, conditionType, t)
 		newCondition.LastTransitionTime = metav1.NewTime(t)
 		crt.Status.Conditions = []CertificateCondition{newCondition}
+		crt.recordConditionTransition(newCondition, t)
 	} else {
 		for i, cond := range crt.Status.Conditions {
 			if cond.Type == conditionType {
 				if cond.Status != newCondition.Status || forceTime {
-					glog.Infof("Found status change for Certificate %q condition %q: %q -> %q; setting lastTransitionTime to %v", crt.Name, conditionType, cond.Status, status, t)
+					logs.Infof("Found status change for Certificate %q condition %q: %q -> %q; setting lastTransitionTime to %v", crt.Name, conditionType, cond.Status, status, t)
 					newCondition.LastTransitionTime = metav1.NewTime(t)
 				} else {
 					newCondition.LastTransitionTime = cond.LastTransitionTime
 				}
+				if cond.Status != newCondition.Status || cond.Reason != newCondition.Reason {
+					crt.recordConditionTransition(newCondition, t)
+				}
 
 				crt.Status.Conditions[i] = newCondition
 				return
@@ -199,7 +213,61 @@ func (crt *Certificate) UpdateStatusCondition(conditionType CertificateCondition
 		}
 
 		crt.Status.Conditions = append(crt.Status.Conditions, newCondition)
+		crt.recordConditionTransition(newCondition, t)
+	}
+}
+
+// recordConditionTransition appends a transition to the given condition to
+// the condition history of the Certificate, dropping the oldest transitions
+// once there are more than MaxCertificateConditionHistory.
+func (crt *Certificate) recordConditionTransition(cond CertificateCondition, t time.Time) {
+	cond.LastTransitionTime = metav1.NewTime(t)
+	crt.Status.ConditionHistory = append(crt.Status.ConditionHistory, cond)
+	if n := len(crt.Status.ConditionHistory); n > MaxCertificateConditionHistory {
+		crt.Status.ConditionHistory = append([]CertificateCondition(nil), crt.Status.ConditionHistory[n-MaxCertificateConditionHistory:]...)
+	}
+}
+
+func (cr *CertificateRequest) HasCondition(condition CertificateRequestCondition) bool {
+	// this is an edge case, but this will prevent panics
+	if cr == nil {
+		return false
+	}
+	for _, cond := range cr.Status.Conditions {
+		if condition.Type == cond.Type && condition.Status == cond.Status && condition.Reason == cond.Reason {
+			return true
+		}
 	}
+	return false
+}
+
+func (cr *CertificateRequest) UpdateStatusCondition(conditionType CertificateRequestConditionType, status ConditionStatus, reason, message string) {
+	newCondition := CertificateRequestCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	t := time.Now()
+
+	for i, cond := range cr.Status.Conditions {
+		if cond.Type == conditionType {
+			if cond.Status != newCondition.Status || cond.Reason != newCondition.Reason {
+				logs.Infof("Found status change for CertificateRequest %q condition %q: %q -> %q; setting lastTransitionTime to %v", cr.Name, conditionType, cond.Status, status, t)
+				newCondition.LastTransitionTime = metav1.NewTime(t)
+			} else {
+				newCondition.LastTransitionTime = cond.LastTransitionTime
+			}
+
+			cr.Status.Conditions[i] = newCondition
+			return
+		}
+	}
+
+	logs.Infof("Setting lastTransitionTime for CertificateRequest %q condition %q to %v", cr.Name, conditionType, t)
+	newCondition.LastTransitionTime = metav1.NewTime(t)
+	cr.Status.Conditions = append(cr.Status.Conditions, newCondition)
 }
 
 type GenericIssuer interface {
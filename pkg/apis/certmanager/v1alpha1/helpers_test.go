@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestACMEIssuerServerURL(t *testing.T) {
+	tests := map[string]struct {
+		server   string
+		expected string
+	}{
+		"lets-encrypt shorthand is expanded": {
+			server:   "lets-encrypt",
+			expected: "https://acme-v02.api.letsencrypt.org/directory",
+		},
+		"lets-encrypt-staging shorthand is expanded": {
+			server:   "lets-encrypt-staging",
+			expected: "https://acme-staging-v02.api.letsencrypt.org/directory",
+		},
+		"full URL is passed through verbatim": {
+			server:   "https://acme.example.com/directory",
+			expected: "https://acme.example.com/directory",
+		},
+		"empty server is passed through": {
+			server:   "",
+			expected: "",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := &ACMEIssuer{Server: test.server}
+			if u := a.ServerURL(); u != test.expected {
+				t.Errorf("expected server URL %q but got %q", test.expected, u)
+			}
+		})
+	}
+}
+
+func TestCertificateUpdateStatusConditionHistory(t *testing.T) {
+	crt := &Certificate{}
+
+	crt.UpdateStatusCondition(CertificateConditionReady, ConditionFalse, "NotFound", "Certificate does not exist", false)
+	crt.UpdateStatusCondition(CertificateConditionReady, ConditionFalse, "NotFound", "Certificate does not exist", false)
+	crt.UpdateStatusCondition(CertificateConditionReady, ConditionTrue, "Ready", "Certificate is up to date and has not expired", false)
+	crt.UpdateStatusCondition(CertificateConditionReady, ConditionFalse, "Expired", "Certificate has expired", false)
+
+	expected := []string{"NotFound", "Ready", "Expired"}
+	history := crt.Status.ConditionHistory
+	if len(history) != len(expected) {
+		t.Fatalf("expected %d transitions to be recorded, got %+v", len(expected), history)
+	}
+	for i, reason := range expected {
+		if history[i].Reason != reason {
+			t.Errorf("expected transition %d to have reason %q, got %q", i, reason, history[i].Reason)
+		}
+		if history[i].LastTransitionTime.IsZero() {
+			t.Errorf("expected transition %d to have its time set", i)
+		}
+	}
+	if history[2].Message != "Certificate has expired" || history[2].Status != ConditionFalse {
+		t.Errorf("expected the last transition to match the current condition, got %+v", history[2])
+	}
+}
+
+func TestCertificateUpdateStatusConditionHistoryTrimmed(t *testing.T) {
+	crt := &Certificate{}
+
+	total := MaxCertificateConditionHistory + 5
+	for i := 0; i < total; i++ {
+		crt.UpdateStatusCondition(CertificateConditionReady, ConditionFalse, fmt.Sprintf("Reason%d", i), "", false)
+	}
+
+	history := crt.Status.ConditionHistory
+	if len(history) != MaxCertificateConditionHistory {
+		t.Fatalf("expected the history to be trimmed to %d transitions, got %d", MaxCertificateConditionHistory, len(history))
+	}
+	for i, cond := range history {
+		if expected := fmt.Sprintf("Reason%d", total-MaxCertificateConditionHistory+i); cond.Reason != expected {
+			t.Errorf("expected transition %d to have reason %q, got %q", i, expected, cond.Reason)
+		}
+	}
+}
@@ -19,10 +19,32 @@ package v1alpha1
 const (
 	AltNamesAnnotationKey   = "certmanager.k8s.io/alt-names"
 	IPSANAnnotationKey      = "certmanager.k8s.io/ip-sans"
+	URISANAnnotationKey     = "certmanager.k8s.io/uri-sans"
 	CommonNameAnnotationKey = "certmanager.k8s.io/common-name"
 	IssuerNameAnnotationKey = "certmanager.k8s.io/issuer-name"
 	IssuerKindAnnotationKey = "certmanager.k8s.io/issuer-kind"
 	CertificateNameKey      = "certmanager.k8s.io/certificate-name"
+
+	// PrivateKeySecretNameAnnotationKey is set on a CertificateRequest to
+	// name the Secret containing the private key of its certificate signing
+	// request, for issuers such as SelfSigned that sign with that key.
+	PrivateKeySecretNameAnnotationKey = "certmanager.k8s.io/private-key-secret-name"
+
+	// SecretDataHashAnnotationKey is set on a Certificate's Secret to a hash
+	// of the data, labels and annotations that were last written to it. It
+	// is used to skip updating the Secret when nothing has changed.
+	SecretDataHashAnnotationKey = "certmanager.k8s.io/secret-data-hash"
+
+	// ForceRenewAnnotationKey can be set on a Certificate to have it
+	// re-issued immediately, regardless of when it expires. The annotation
+	// is removed once the new certificate has been issued.
+	ForceRenewAnnotationKey = "certmanager.k8s.io/force-renew"
+
+	// VenafiPickupIDAnnotationKey is set on a Certificate by the Venafi
+	// issuer to the ID of the certificate request it is waiting on, so that
+	// the certificate can be retrieved on a later sync instead of being
+	// requested again.
+	VenafiPickupIDAnnotationKey = "certmanager.k8s.io/venafi-pickup-id"
 )
 
 // ConditionStatus represents a condition's status.
@@ -61,6 +83,8 @@ const (
 	ClusterIssuerKind = "ClusterIssuer"
 	IssuerKind        = "Issuer"
 	CertificateKind   = "Certificate"
+
+	CertificateRequestKind = "CertificateRequest"
 )
 
 type SecretKeySelector struct {
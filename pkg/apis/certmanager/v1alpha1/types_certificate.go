@@ -45,8 +45,91 @@ type CertificateList struct {
 type KeyAlgorithm string
 
 const (
-	RSAKeyAlgorithm   KeyAlgorithm = "rsa"
-	ECDSAKeyAlgorithm KeyAlgorithm = "ecdsa"
+	RSAKeyAlgorithm     KeyAlgorithm = "rsa"
+	ECDSAKeyAlgorithm   KeyAlgorithm = "ecdsa"
+	Ed25519KeyAlgorithm KeyAlgorithm = "ed25519"
+)
+
+// KeyCurve is the elliptic curve used to generate an ECDSA private key.
+type KeyCurve string
+
+const (
+	P256KeyCurve KeyCurve = "P256"
+	P384KeyCurve KeyCurve = "P384"
+	P521KeyCurve KeyCurve = "P521"
+)
+
+// CertificateOutputMode controls where the issued certificate and private key
+// of a Certificate are stored.
+type CertificateOutputMode string
+
+const (
+	// CertificateOutputSecret stores the issued certificate and private key
+	// in the Secret named by secretName.
+	CertificateOutputSecret CertificateOutputMode = "secret"
+	// CertificateOutputSecretless stores the issued certificate and private
+	// key in the status of the Certificate, and no Secret is managed.
+	CertificateOutputSecretless CertificateOutputMode = "secretless"
+)
+
+// MaxCertificateOutputSize is the maximum combined size in bytes of the
+// certificate, CA and private key stored in the status of a Certificate with
+// the 'secretless' output mode, to keep the resource well below the object
+// size limit of the API server.
+const MaxCertificateOutputSize = 64 * 1024
+
+// SecretDeletionPolicy controls what happens to a Certificate's Secret when
+// the Certificate is deleted.
+type SecretDeletionPolicy string
+
+const (
+	// SecretDeletionPolicyRetain leaves the Secret in place when the
+	// Certificate is deleted.
+	SecretDeletionPolicyRetain SecretDeletionPolicy = "retain"
+	// SecretDeletionPolicyDelete deletes the Secret before the Certificate
+	// is removed.
+	SecretDeletionPolicyDelete SecretDeletionPolicy = "delete"
+)
+
+// PrivateKeyRotationPolicy controls whether a Certificate's private key is
+// replaced when the certificate is renewed or re-issued.
+type PrivateKeyRotationPolicy string
+
+const (
+	// RotationPolicyNever re-uses the private key stored in the Secret for
+	// every issuance, as long as it matches the requested key parameters.
+	RotationPolicyNever PrivateKeyRotationPolicy = "Never"
+	// RotationPolicyAlways generates a new private key each time the
+	// certificate is issued.
+	RotationPolicyAlways PrivateKeyRotationPolicy = "Always"
+)
+
+// KeyUsage is a key usage or extended key usage to be set on an issued
+// certificate.
+type KeyUsage string
+
+const (
+	KeyUsageSigning           KeyUsage = "signing"
+	KeyUsageDigitalSignature  KeyUsage = "digital signature"
+	KeyUsageContentCommitment KeyUsage = "content commitment"
+	KeyUsageKeyEncipherment   KeyUsage = "key encipherment"
+	KeyUsageKeyAgreement      KeyUsage = "key agreement"
+	KeyUsageDataEncipherment  KeyUsage = "data encipherment"
+	KeyUsageCertSign          KeyUsage = "cert sign"
+	KeyUsageCRLSign           KeyUsage = "crl sign"
+	KeyUsageEncipherOnly      KeyUsage = "encipher only"
+	KeyUsageDecipherOnly      KeyUsage = "decipher only"
+
+	KeyUsageAny             KeyUsage = "any"
+	KeyUsageServerAuth      KeyUsage = "server auth"
+	KeyUsageClientAuth      KeyUsage = "client auth"
+	KeyUsageCodeSigning     KeyUsage = "code signing"
+	KeyUsageEmailProtection KeyUsage = "email protection"
+	KeyUsageIPsecEndSystem  KeyUsage = "ipsec end system"
+	KeyUsageIPsecTunnel     KeyUsage = "ipsec tunnel"
+	KeyUsageIPsecUser       KeyUsage = "ipsec user"
+	KeyUsageTimestamping    KeyUsage = "timestamping"
+	KeyUsageOCSPSigning     KeyUsage = "ocsp signing"
 )
 
 // CertificateSpec defines the desired state of Certificate
@@ -63,15 +146,38 @@ type CertificateSpec struct {
 	// Certificate renew before expiration duration
 	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
 
+	// RenewBeforePercentage is the percentage of the certificate's total
+	// lifetime that should remain when it is renewed. For example, a value
+	// of 33 renews a certificate two thirds of the way through its lifetime.
+	// Must be between 1 and 99, and may not be set with RenewBefore.
+	// If neither is set, the controller's default renewBefore is used.
+	// +optional
+	RenewBeforePercentage *int32 `json:"renewBeforePercentage,omitempty"`
+
 	// DNSNames is a list of subject alt names to be used on the Certificate
 	DNSNames []string `json:"dnsNames,omitempty"`
 
 	// IPAddresses is a list of IP addresses to be used on the Certificate
 	IPAddresses []string `json:"ipAddresses,omitempty"`
 
-	// SecretName is the name of the secret resource to store this secret in
+	// URIs is a list of URI subject alt names to be used on the Certificate,
+	// such as SPIFFE IDs (spiffe://trust-domain/path)
+	// +optional
+	URIs []string `json:"uris,omitempty"`
+
+	// SecretName is the name of the secret resource to store this secret in.
+	// It must not be set if Output is 'secretless'.
 	SecretName string `json:"secretName"`
 
+	// Output controls where the issued certificate and private key are
+	// stored. Allowed values are 'secret', which writes them to the Secret
+	// named by SecretName, and 'secretless', which writes them to the
+	// status of this Certificate instead. Defaults to 'secret'.
+	// The 'secretless' mode is only honoured if it has been enabled on the
+	// controller with --enable-secretless-certificates.
+	// +optional
+	Output CertificateOutputMode `json:"output,omitempty"`
+
 	// IssuerRef is a reference to the issuer for this certificate.
 	// If the 'kind' field is not set, or set to 'Issuer', an Issuer resource
 	// with the given name in the same namespace as the Certificate will be used.
@@ -93,14 +199,137 @@ type CertificateSpec struct {
 	// KeySize is the key bit size of the corresponding private key for this certificate.
 	// If provided, value must be between 2048 and 8192 inclusive when KeyAlgorithm is
 	// empty or is set to "rsa", and value must be one of (256, 384, 521) when
-	// KeyAlgorithm is set to "ecdsa".
+	// KeyAlgorithm is set to "ecdsa". New ECDSA Certificates should set KeyCurve
+	// instead. KeySize may not be set when KeyAlgorithm is "ed25519".
 	KeySize int `json:"keySize,omitempty"`
 	// KeyAlgorithm is the private key algorithm of the corresponding private key
-	// for this certificate. If provided, allowed values are either "rsa" or "ecdsa"
-	// If KeyAlgorithm is specified and KeySize is not provided,
-	// key size of 256 will be used for "ecdsa" key algorithm and
+	// for this certificate. If provided, allowed values are "rsa", "ecdsa" or
+	// "ed25519". Ed25519 keys are only supported by the CA and SelfSigned issuers.
+	// If KeyAlgorithm is specified and neither KeySize nor KeyCurve is provided,
+	// the P256 curve will be used for "ecdsa" key algorithm and
 	// key size of 2048 will be used for "rsa" key algorithm.
 	KeyAlgorithm KeyAlgorithm `json:"keyAlgorithm,omitempty"`
+	// KeyCurve is the elliptic curve of the corresponding private key for this
+	// certificate. It may only be set when KeyAlgorithm is "ecdsa", and allowed
+	// values are "P256", "P384" or "P521".
+	KeyCurve KeyCurve `json:"keyCurve,omitempty"`
+
+	// Extensions is a list of additional x509 extensions to add to the
+	// issued certificate. An extension with the same OID as one generated
+	// from the other fields on this Certificate, such as the subject
+	// alternative names, replaces the generated extension.
+	// Only supported by the SelfSigned issuer.
+	// +optional
+	Extensions []X509Extension `json:"extensions,omitempty"`
+
+	// Usages is the set of key usages and extended key usages to set on the
+	// issued certificate, such as 'digital signature' or 'client auth'.
+	// Defaults to 'digital signature' and 'key encipherment' if not set.
+	// 'cert sign' is always added if IsCA is set.
+	// Only supported by the CA and SelfSigned issuers.
+	// +optional
+	Usages []KeyUsage `json:"usages,omitempty"`
+
+	// Keystores configures additional keystore output formats to be written
+	// to the Certificate's Secret alongside the PEM encoded certificate and
+	// private key.
+	// +optional
+	Keystores *CertificateKeystores `json:"keystores,omitempty"`
+
+	// CAChain, if true, causes the 'ca.crt' key of the Certificate's Secret
+	// to contain the full chain of CA certificates that issued the
+	// certificate: any intermediates returned alongside the certificate,
+	// followed by the CA certificate exposed by the issuer. By default only
+	// the issuer's CA certificate is written, if it exposes one.
+	// +optional
+	CAChain bool `json:"caChain,omitempty"`
+
+	// CombinedPEM, if true, causes a 'tls-combined.pem' key to also be
+	// written to the Certificate's Secret, containing the PEM encoded
+	// private key, certificate and intermediates concatenated in that
+	// order, for software such as HAProxy that expects a single file.
+	// +optional
+	CombinedPEM bool `json:"combinedPEM,omitempty"`
+
+	// SecretDeletionPolicy controls whether the Secret named by SecretName
+	// is deleted when this Certificate is deleted. Allowed values are
+	// 'retain' and 'delete'. Defaults to 'retain'.
+	// +optional
+	SecretDeletionPolicy SecretDeletionPolicy `json:"secretDeletionPolicy,omitempty"`
+
+	// PrivateKey configures how the private key for this certificate is
+	// managed across renewals.
+	// +optional
+	PrivateKey *CertificatePrivateKey `json:"privateKey,omitempty"`
+}
+
+// CertificatePrivateKey configures the private key of a Certificate.
+type CertificatePrivateKey struct {
+	// RotationPolicy controls whether the private key stored in the Secret
+	// is re-used when the certificate is renewed or re-issued. Allowed
+	// values are 'Never' and 'Always'. With 'Never', issuance fails if the
+	// existing private key does not match keyAlgorithm, keySize and
+	// keyCurve. If not set, an existing private key is re-used without
+	// being checked against these fields.
+	// +optional
+	RotationPolicy PrivateKeyRotationPolicy `json:"rotationPolicy,omitempty"`
+}
+
+// CertificateKeystores configures the additional keystore output formats
+// written to a Certificate's Secret.
+type CertificateKeystores struct {
+	// PKCS12 configures a PKCS#12 keystore containing the certificate chain
+	// and private key, written to the 'keystore.p12' key of the Secret.
+	// +optional
+	PKCS12 *PKCS12Keystore `json:"pkcs12,omitempty"`
+
+	// JKS configures a Java KeyStore containing the certificate chain and
+	// private key, written to the 'keystore.jks' key of the Secret.
+	// +optional
+	JKS *JKSKeystore `json:"jks,omitempty"`
+}
+
+// PKCS12Keystore configures a PKCS#12 keystore.
+type PKCS12Keystore struct {
+	// PasswordSecretRef is a reference to a key in a Secret in the
+	// Certificate's namespace containing the password used to protect the
+	// keystore.
+	PasswordSecretRef SecretKeySelector `json:"passwordSecretRef"`
+}
+
+// JKSKeystore configures a Java KeyStore.
+type JKSKeystore struct {
+	// PasswordSecretRef is a reference to a key in a Secret in the
+	// Certificate's namespace containing the password used to protect the
+	// keystore and the private key within it.
+	PasswordSecretRef SecretKeySelector `json:"passwordSecretRef"`
+
+	// Alias is the alias of the private key entry in the keystore.
+	// Defaults to 'certificate'.
+	// +optional
+	Alias string `json:"alias,omitempty"`
+
+	// Truststore, if true, causes a Java KeyStore containing the CA
+	// certificate as a trusted certificate entry to also be written to the
+	// 'truststore.jks' key of the Secret, protected with the same password.
+	// It is only written if the issuer provides a CA certificate.
+	// +optional
+	Truststore bool `json:"truststore,omitempty"`
+}
+
+// X509Extension is an x509 certificate extension.
+type X509Extension struct {
+	// OID is the object identifier of the extension in dotted decimal
+	// notation, for example '1.3.6.1.5.5.7.1.24'.
+	OID string `json:"oid"`
+
+	// Critical marks the extension as critical, meaning certificate
+	// verification must fail if the extension is not understood.
+	// +optional
+	Critical bool `json:"critical,omitempty"`
+
+	// Value is the base64 encoded, ASN.1 DER encoded value of the extension.
+	Value []byte `json:"value"`
 }
 
 // ACMECertificateConfig contains the configuration for the ACME certificate provider
@@ -116,8 +345,43 @@ type CertificateStatus struct {
 	// The expiration time of the certificate stored in the secret named
 	// by this resource in spec.secretName.
 	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RenewalTime is the time at which cert-manager will next attempt to
+	// renew the certificate, taking renewBefore and any renewal jitter into
+	// account.
+	// +optional
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+
+	// Certificate is the PEM encoded certificate issued for this
+	// Certificate, followed by any intermediate certificates. It is only set
+	// if spec.output is 'secretless'.
+	// +optional
+	Certificate []byte `json:"certificate,omitempty"`
+
+	// CA is the PEM encoded CA certificate of the issuer that signed the
+	// certificate, if the issuer exposes one. It is only set if spec.output
+	// is 'secretless'.
+	// +optional
+	CA []byte `json:"ca,omitempty"`
+
+	// PrivateKey is the PEM encoded private key of the certificate. It is
+	// only set if spec.output is 'secretless', in which case anyone who can
+	// read this Certificate can read its private key.
+	// +optional
+	PrivateKey []byte `json:"privateKey,omitempty"`
+
+	// ConditionHistory records the most recent transitions of the conditions
+	// of this Certificate, oldest first. A transition is recorded when the
+	// status or reason of a condition changes. At most
+	// MaxCertificateConditionHistory transitions are kept.
+	// +optional
+	ConditionHistory []CertificateCondition `json:"conditionHistory,omitempty"`
 }
 
+// MaxCertificateConditionHistory is the maximum number of condition
+// transitions kept in the status of a Certificate.
+const MaxCertificateConditionHistory = 10
+
 // CertificateCondition contains condition information for an Certificate.
 type CertificateCondition struct {
 	// Type of the condition, currently ('Ready').
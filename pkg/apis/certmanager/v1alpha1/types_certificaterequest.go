@@ -0,0 +1,163 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +kubebuilder:resource:path=certificaterequests
+// CertificateRequest is a type to represent a request for a PEM encoded
+// certificate signing request to be signed by an Issuer. Unlike a
+// Certificate, the private key and the resulting certificate are not stored
+// in a Secret; the signed certificate is returned in the status.
+type CertificateRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateRequestSpec   `json:"spec,omitempty"`
+	Status CertificateRequestStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateRequestList is a list of CertificateRequests
+type CertificateRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CertificateRequest `json:"items"`
+}
+
+// CertificateRequestSpec defines the desired state of CertificateRequest
+type CertificateRequestSpec struct {
+	// CSRPEM is the PEM encoded certificate signing request to be signed.
+	// The subject and subject alternative names of the issued certificate
+	// are taken from the request.
+	CSRPEM []byte `json:"csr"`
+
+	// IssuerRef is a reference to the issuer that should sign this request.
+	// If the 'kind' field is not set, or set to 'Issuer', an Issuer resource
+	// with the given name in the same namespace as the CertificateRequest
+	// will be used.
+	// If the 'kind' field is set to 'ClusterIssuer', a ClusterIssuer with the
+	// provided name will be used.
+	// The 'name' field in this stanza is required at all times.
+	IssuerRef ObjectReference `json:"issuerRef"`
+
+	// Duration is the requested lifetime of the issued certificate.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// IsCA will mark the issued certificate as valid for signing.
+	// +optional
+	IsCA bool `json:"isCA,omitempty"`
+
+	// Usages is the set of key usages and extended key usages to set on the
+	// issued certificate. Defaults to 'digital signature' and
+	// 'key encipherment' if not set.
+	// +optional
+	Usages []KeyUsage `json:"usages,omitempty"`
+}
+
+// CertificateRequestStatus defines the observed state of CertificateRequest
+type CertificateRequestStatus struct {
+	Conditions []CertificateRequestCondition `json:"conditions,omitempty"`
+
+	// Certificate is the PEM encoded certificate issued for this request,
+	// followed by any intermediate certificates. It is only set once the
+	// request has been issued.
+	// +optional
+	Certificate []byte `json:"certificate,omitempty"`
+
+	// CA is the PEM encoded CA certificate of the issuer that signed this
+	// request, if the issuer exposes one.
+	// +optional
+	CA []byte `json:"ca,omitempty"`
+
+	// FailureTime is the time at which the request was marked as failed.
+	// A failed request will not be retried.
+	// +optional
+	FailureTime *metav1.Time `json:"failureTime,omitempty"`
+}
+
+// CertificateRequestCondition contains condition information for a
+// CertificateRequest.
+type CertificateRequestCondition struct {
+	// Type of the condition, one of ('Ready', 'Approved', 'Denied').
+	Type CertificateRequestConditionType `json:"type"`
+
+	// Status of the condition, one of ('True', 'False', 'Unknown').
+	Status ConditionStatus `json:"status"`
+
+	// LastTransitionTime is the timestamp corresponding to the last status
+	// change of this condition.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// Reason is a brief machine readable explanation for the condition's last
+	// transition. For the Ready condition this is one of ('Pending',
+	// 'Issued', 'Failed', 'Denied'). Approved and Denied conditions are set
+	// by an approver, which may use any reason.
+	Reason string `json:"reason"`
+
+	// Message is a human readable description of the details of the last
+	// transition, complementing reason.
+	Message string `json:"message"`
+}
+
+// CertificateRequestConditionType represents a CertificateRequest condition
+// value.
+type CertificateRequestConditionType string
+
+const (
+	// CertificateRequestConditionReady indicates that the request has been
+	// signed and the signed certificate is available in the status.
+	CertificateRequestConditionReady CertificateRequestConditionType = "Ready"
+
+	// CertificateRequestConditionApproved is set to True by an external
+	// approver to allow the request to be signed. It is only required if
+	// the controller is configured to require approval. Approvers set it
+	// through the status subresource, which users that may only update
+	// CertificateRequests themselves cannot write to.
+	CertificateRequestConditionApproved CertificateRequestConditionType = "Approved"
+
+	// CertificateRequestConditionDenied is set to True by an external
+	// approver to refuse the request. A denied request is failed and will
+	// never be signed.
+	CertificateRequestConditionDenied CertificateRequestConditionType = "Denied"
+)
+
+const (
+	// CertificateRequestReasonPending is the reason of the Ready condition of
+	// a CertificateRequest that has not yet been signed.
+	CertificateRequestReasonPending = "Pending"
+
+	// CertificateRequestReasonIssued is the reason of the Ready condition of
+	// a CertificateRequest that has been signed.
+	CertificateRequestReasonIssued = "Issued"
+
+	// CertificateRequestReasonFailed is the reason of the Ready condition of
+	// a CertificateRequest that could not be signed and will not be retried.
+	CertificateRequestReasonFailed = "Failed"
+
+	// CertificateRequestReasonDenied is the reason of the Ready condition of
+	// a CertificateRequest that has been denied by an approver, and so will
+	// not be signed.
+	CertificateRequestReasonDenied = "Denied"
+)
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -79,11 +80,116 @@ type IssuerConfig struct {
 	CA         *CAIssuer         `json:"ca,omitempty"`
 	Vault      *VaultIssuer      `json:"vault,omitempty"`
 	SelfSigned *SelfSignedIssuer `json:"selfSigned,omitempty"`
+	Venafi     *VenafiIssuer     `json:"venafi,omitempty"`
+	External   *ExternalIssuer   `json:"external,omitempty"`
+
+	// CredentialsFrom controls where the credentials referenced by this
+	// issuer, such as DNS01 provider API keys, are read from. When set to
+	// 'file', each secret reference is read from the directory
+	// <credentials dir>/<namespace>/<secret name> on the controller's
+	// filesystem, with one file per key, as laid out by a secret volume or
+	// CSI secret store mount. Allowed values are 'secret' and 'file'.
+	// Defaults to 'secret'.
+	// +optional
+	CredentialsFrom CredentialsSource `json:"credentialsFrom,omitempty"`
+
+	// Proxy is the URL of an HTTP, HTTPS or SOCKS5 proxy that this issuer's
+	// outbound connections are sent through, e.g. http://proxy:3128 or
+	// socks5://proxy:1080. If not set, the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables of the controller are used.
+	// +optional
+	Proxy string `json:"proxy,omitempty"`
 }
 
+// CredentialsSource is where the credentials referenced by an issuer are read
+// from.
+type CredentialsSource string
+
+const (
+	// CredentialsFromSecret reads credentials from Kubernetes Secrets.
+	CredentialsFromSecret CredentialsSource = "secret"
+	// CredentialsFromFile reads credentials from files on the controller's
+	// filesystem.
+	CredentialsFromFile CredentialsSource = "file"
+)
+
 type SelfSignedIssuer struct {
 }
 
+// VenafiIssuer describes issuer configuration details for Venafi Cloud or
+// Venafi Trust Protection Platform.
+// Exactly one of TPP or Cloud must be specified.
+type VenafiIssuer struct {
+	// Zone is the Venafi policy zone to use for this issuer. For TPP this is
+	// the policy folder under \VED\Policy, and for Venafi Cloud it is the
+	// zone tag. All requests made to Venafi will be restricted by the named
+	// zone's policy.
+	Zone string `json:"zone"`
+
+	// TPP specifies Trust Protection Platform configuration settings.
+	// +optional
+	TPP *VenafiTPP `json:"tpp,omitempty"`
+
+	// Cloud specifies the Venafi Cloud configuration settings.
+	// +optional
+	Cloud *VenafiCloud `json:"cloud,omitempty"`
+}
+
+// VenafiTPP defines connection configuration details for a Venafi TPP
+// instance.
+type VenafiTPP struct {
+	// URL is the base URL for the Venafi TPP instance, for example
+	// https://tpp.example.com/vedsdk.
+	URL string `json:"url"`
+
+	// CredentialsRef is a reference to a Secret containing either an
+	// 'access-token' key, or a 'username' and 'password' key used to
+	// authenticate with the TPP instance.
+	CredentialsRef LocalObjectReference `json:"credentialsRef"`
+
+	// Base64 encoded CA bundle used to validate the TPP server certificate.
+	// If not set, the system root certificates are used.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// VenafiCloud defines connection configuration details for Venafi Cloud.
+type VenafiCloud struct {
+	// URL is the base URL for Venafi Cloud. Defaults to
+	// https://api.venafi.cloud/v1.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// APITokenSecretRef is a secret key selector for the Venafi Cloud API
+	// key.
+	APITokenSecretRef SecretKeySelector `json:"apiTokenSecretRef"`
+}
+
+// ExternalIssuer describes issuer configuration details for an external
+// signing service. Certificate signing requests are POSTed to the configured
+// URL, which is expected to respond with a signed certificate chain.
+type ExternalIssuer struct {
+	// URL is the endpoint of the signing webhook, for example
+	// https://pki.example.com/sign.
+	URL string `json:"url"`
+
+	// Base64 encoded CA bundle used to validate the webhook server
+	// certificate. If not set, the system root certificates are used.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// ClientCertSecretRef is a reference to a kubernetes.io/tls Secret
+	// containing a client certificate and private key to present to the
+	// webhook. If not set, no client certificate is sent.
+	// +optional
+	ClientCertSecretRef *LocalObjectReference `json:"clientCertSecretRef,omitempty"`
+
+	// Timeout is the maximum amount of time to wait for the webhook to
+	// respond. Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
 type VaultIssuer struct {
 	// Vault authentication
 	Auth VaultAuth `json:"auth"`
@@ -102,11 +208,16 @@ type VaultIssuer struct {
 // - With a secret containing a token. Cert-manager is using this token as-is.
 // - With a secret containing a AppRole. This AppRole is used to authenticate to
 //   Vault and retrieve a token.
+// - With a Kubernetes service account token. This token is used to
+//   authenticate to Vault using the Kubernetes auth method.
 type VaultAuth struct {
 	// This Secret contains the Vault token key
 	TokenSecretRef SecretKeySelector `json:"tokenSecretRef,omitempty"`
 	// This Secret contains a AppRole and Secret
 	AppRole VaultAppRole `json:"appRole,omitempty"`
+	// This configures authentication using a Kubernetes service account token
+	// +optional
+	Kubernetes *VaultKubernetesAuth `json:"kubernetes,omitempty"`
 }
 
 type VaultAppRole struct {
@@ -117,33 +228,251 @@ type VaultAppRole struct {
 	SecretRef SecretKeySelector `json:"secretRef"`
 }
 
+// VaultKubernetesAuth configures authenticating with Vault using the
+// Kubernetes auth method.
+type VaultKubernetesAuth struct {
+	// Where the Kubernetes authentication backend is mounted in Vault.
+	// Defaults to 'kubernetes'.
+	// +optional
+	Path string `json:"mountPath,omitempty"`
+
+	// Role is the name of the Vault role to log in as. The role must be
+	// bound to the service account whose token is used.
+	Role string `json:"role"`
+
+	// SecretRef references a Secret containing the service account token
+	// used to log in to Vault. The key defaults to 'token', which is the key
+	// used in service account token Secrets.
+	// Required on Issuers. On ClusterIssuers, at most one of SecretRef or
+	// TokenPath may be specified.
+	// +optional
+	SecretRef SecretKeySelector `json:"secretRef,omitempty"`
+
+	// TokenPath is the path of a file in the cert-manager controller's
+	// container containing the service account token used to log in to
+	// Vault. Only supported on ClusterIssuers. If neither SecretRef nor
+	// TokenPath is set on a ClusterIssuer, the token of the service account
+	// the cert-manager controller runs as is read from
+	// /var/run/secrets/kubernetes.io/serviceaccount/token.
+	// +optional
+	TokenPath string `json:"tokenPath,omitempty"`
+}
+
 type CAIssuer struct {
 	// SecretName is the name of the secret used to sign Certificates issued
 	// by this Issuer.
 	SecretName string `json:"secretName"`
+
+	// SerialNumber configures how serial numbers are allocated to
+	// certificates signed by this Issuer. If not set, random serial numbers
+	// are used.
+	// +optional
+	SerialNumber *CASerialNumber `json:"serialNumber,omitempty"`
+}
+
+// CASerialNumberStrategy is the method used to allocate serial numbers to
+// certificates signed by a CA issuer.
+type CASerialNumberStrategy string
+
+const (
+	// CASerialNumberRandom allocates random 128 bit serial numbers.
+	CASerialNumberRandom CASerialNumberStrategy = "random"
+
+	// CASerialNumberTimestamp allocates serial numbers from the time, in
+	// nanoseconds since the Unix epoch, at which the certificate is signed.
+	CASerialNumberTimestamp CASerialNumberStrategy = "timestamp"
+
+	// CASerialNumberCounter allocates serial numbers from a counter stored
+	// in a ConfigMap, which is incremented for every certificate signed.
+	CASerialNumberCounter CASerialNumberStrategy = "counter"
+)
+
+type CASerialNumber struct {
+	// Strategy is the method used to allocate serial numbers. One of
+	// 'random', 'timestamp' or 'counter'. Defaults to 'random'.
+	// +optional
+	Strategy CASerialNumberStrategy `json:"strategy,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap the counter is stored in
+	// when using the 'counter' strategy. The ConfigMap is created in the
+	// same namespace as the CA secret if it does not already exist.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
 }
 
 // ACMEIssuer contains the specification for an ACME issuer
 type ACMEIssuer struct {
-	// Email is the email for this account
-	Email string `json:"email"`
-	// Server is the ACME server URL
+	// Email is the email for this account. It is used as the only contact
+	// for the account if Emails is not set.
+	// +optional
+	Email string `json:"email,omitempty"`
+	// Emails is a list of contact email addresses for this account. It may
+	// not be set together with Email. If neither Email nor Emails is set,
+	// the account is registered without any contacts.
+	// +optional
+	Emails []string `json:"emails,omitempty"`
+	// Server is the ACME server URL. The shorthand names 'lets-encrypt' and
+	// 'lets-encrypt-staging' may be used in place of the Let's Encrypt
+	// production and staging directory URLs.
 	Server string `json:"server"`
 	// If true, skip verifying the ACME server TLS certificate
 	SkipTLSVerify bool `json:"skipTLSVerify,omitempty"`
+	// Base64 encoded CA bundle used to validate the ACME server's TLS
+	// certificate. If not set, the system root certificates are used.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+	// PreferredChain is the Common Name of the issuer of the topmost
+	// certificate in the preferred certificate chain. If the ACME server
+	// offers alternate chains for an issued certificate, the first chain whose
+	// topmost certificate was issued by a CA with this Common Name is used.
+	// If no chain matches, the default chain offered by the server is used.
+	// +optional
+	PreferredChain string `json:"preferredChain,omitempty"`
+	// EnableDurationFeature requests a notAfter time matching the duration
+	// of a Certificate when ordering it. It is disabled by default, as some
+	// ACME servers, including Let's Encrypt, reject orders that set notAfter.
+	// +optional
+	EnableDurationFeature bool `json:"enableDurationFeature,omitempty"`
 	// PrivateKey is the name of a secret containing the private key for this
 	// user account.
 	PrivateKey SecretKeySelector `json:"privateKeySecretRef"`
+	// PrivateKeyAlgorithm is the algorithm of the account private key
+	// generated when the Secret referenced by PrivateKey does not exist.
+	// Allowed values are "rsa" or "ecdsa", defaulting to "rsa". Existing
+	// account private keys are not changed.
+	// +optional
+	PrivateKeyAlgorithm KeyAlgorithm `json:"privateKeyAlgorithm,omitempty"`
+	// PrivateKeySize is the bit size of a generated RSA account private key.
+	// It may only be set when PrivateKeyAlgorithm is "rsa", and must be
+	// between 2048 and 8192 inclusive. Defaults to 2048.
+	// +optional
+	PrivateKeySize int `json:"privateKeySize,omitempty"`
+	// PrivateKeyCurve is the elliptic curve of a generated ECDSA account
+	// private key. It may only be set when PrivateKeyAlgorithm is "ecdsa",
+	// and allowed values are "P256", "P384" or "P521". Defaults to "P256".
+	// +optional
+	PrivateKeyCurve KeyCurve `json:"privateKeyCurve,omitempty"`
+	// ExternalAccountBinding binds the ACME account to an existing account
+	// with the CA. It is required by some commercial ACME servers, and is
+	// only used when the account is first registered.
+	// +optional
+	ExternalAccountBinding *ACMEExternalAccountBinding `json:"externalAccountBinding,omitempty"`
 	// HTTP-01 config
 	HTTP01 *ACMEIssuerHTTP01Config `json:"http01,omitempty"`
 	// DNS-01 config
 	DNS01 *ACMEIssuerDNS01Config `json:"dns01,omitempty"`
+	// Solvers is a list of challenge solvers used for Certificates that do
+	// not set spec.acme. The solver for each identifier in an order is chosen
+	// using the solvers' selectors.
+	// +optional
+	Solvers []ACMEChallengeSolver `json:"solvers,omitempty"`
+	// DNS01RecursiveNameservers is a list of nameservers to use when
+	// performing DNS01 self checks for this issuer, overriding the nameservers
+	// configured on the controller. Entries should be an IP address and port
+	// (e.g. 10.0.0.53:53) or a DNS-over-HTTPS URL.
+	// +optional
+	DNS01RecursiveNameservers []string `json:"dns01RecursiveNameservers,omitempty"`
+	// SkipDNS01PropagationCheck disables the DNS01 self check for this
+	// issuer. When true, challenges are accepted as soon as the record has
+	// been presented, without waiting for it to be visible via the
+	// recursive nameservers. This should only be enabled when the ACME
+	// server queries DNS that is known to be immediately consistent.
+	// +optional
+	SkipDNS01PropagationCheck bool `json:"skipDNS01PropagationCheck,omitempty"`
+}
+
+// ACMEExternalAccountBinding is a reference to an account with the CA that an
+// ACME account is bound to when it is registered.
+type ACMEExternalAccountBinding struct {
+	// KeyID is the key identifier of the external account, as provided by
+	// the CA.
+	KeyID string `json:"keyID"`
+	// KeySecretRef is a reference to a key in a Secret containing the MAC
+	// key of the external account. The key must be base64url encoded, as
+	// provided by the CA.
+	Key SecretKeySelector `json:"keySecretRef"`
+	// KeyAlgorithm is the MAC algorithm used to sign the binding. Allowed
+	// values are "HS256", "HS384" or "HS512".
+	KeyAlgorithm HMACKeyAlgorithm `json:"keyAlgorithm"`
+}
+
+// ACMEChallengeSolver configures how challenges are solved for the
+// identifiers matched by its selector.
+type ACMEChallengeSolver struct {
+	// Selector selects the identifiers this solver is used for. If not set,
+	// the solver is used for any identifier not selected by another solver.
+	// +optional
+	Selector *CertificateDNSNameSelector `json:"selector,omitempty"`
+
+	// SolverConfig is the solver configuration used for the selected
+	// identifiers. DNS01 providers are referenced by name from
+	// spec.acme.dns01.providers.
+	SolverConfig `json:",inline"`
+}
+
+// CertificateDNSNameSelector selects identifiers by their DNS name and by the
+// labels of the Certificate they are being solved for.
+//
+// If an identifier is matched by more than one solver, a solver listing it in
+// DNSNames is used over one matching it by DNSZones, and otherwise the solver
+// with the longest matching zone is used. Solvers that set neither DNSNames
+// nor DNSZones match any identifier, and the first of these is used if no
+// other solver matches.
+type CertificateDNSNameSelector struct {
+	// MatchLabels restricts the solver to Certificates that have all of the
+	// given labels. If not set, the solver applies to all Certificates.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+
+	// DNSNames is a list of identifiers matched exactly. Wildcard identifiers
+	// are written with their '*.' prefix, e.g. '*.example.com'.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// DNSZones is a list of DNS zones. An identifier matches if it is equal
+	// to, or a subdomain of, one of the zones.
+	// +optional
+	DNSZones []string `json:"dnsZones,omitempty"`
 }
 
+// HMACKeyAlgorithm is the MAC algorithm used to sign an external account
+// binding.
+type HMACKeyAlgorithm string
+
+const (
+	HS256 HMACKeyAlgorithm = "HS256"
+	HS384 HMACKeyAlgorithm = "HS384"
+	HS512 HMACKeyAlgorithm = "HS512"
+)
+
 // ACMEIssuerHTTP01Config is a structure containing the ACME HTTP configuration options
 type ACMEIssuerHTTP01Config struct {
-	// Optional service type for Kubernetes solver service
+	// Optional service type for Kubernetes solver service. One of NodePort
+	// (the default), ClusterIP or LoadBalancer. When set to LoadBalancer,
+	// the solver is exposed directly on port 80 of the service's external
+	// address and no Ingress is created.
 	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// NodeSelector is merged into the node selector configured on the
+	// controller when scheduling the HTTP01 solver pod. Keys set here take
+	// precedence.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are added to the tolerations configured on the controller
+	// when scheduling the HTTP01 solver pod.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// If specified, the HTTP01 solver pod's scheduling constraints.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// ImagePullSecrets are the secrets used to pull the HTTP01 solver image.
+	// If set, these replace the image pull secrets configured on the
+	// controller.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
 }
 
 // ACMEIssuerDNS01Config is a structure containing the ACME DNS configuration
@@ -163,6 +492,13 @@ type ACMEIssuerDNS01Provider struct {
 	// records when found in DNS zones.
 	CNAMEStrategy CNAMEStrategy `json:"cnameStrategy"`
 
+	// TTL is the time to live, in seconds, of the TXT records created to
+	// solve challenges. If not set, the provider's default is used, which is
+	// 60 seconds for most providers. It is ignored by providers whose API
+	// does not support setting a TTL.
+	// +optional
+	TTL int `json:"ttl,omitempty"`
+
 	Akamai       *ACMEIssuerDNS01ProviderAkamai       `json:"akamai,omitempty"`
 	CloudDNS     *ACMEIssuerDNS01ProviderCloudDNS     `json:"clouddns,omitempty"`
 	Cloudflare   *ACMEIssuerDNS01ProviderCloudflare   `json:"cloudflare,omitempty"`
@@ -171,6 +507,8 @@ type ACMEIssuerDNS01Provider struct {
 	DigitalOcean *ACMEIssuerDNS01ProviderDigitalOcean `json:"digitalocean,omitempty"`
 	AcmeDNS      *ACMEIssuerDNS01ProviderAcmeDNS      `json:"acmedns,omitempty"`
 	RFC2136      *ACMEIssuerDNS01ProviderRFC2136      `json:"rfc2136,omitempty"`
+	Hetzner      *ACMEIssuerDNS01ProviderHetzner      `json:"hetzner,omitempty"`
+	Webhook      *ACMEIssuerDNS01ProviderWebhook      `json:"webhook,omitempty"`
 }
 
 // CNAMEStrategy configures how the DNS01 provider should handle CNAME records
@@ -210,8 +548,20 @@ type ACMEIssuerDNS01ProviderCloudDNS struct {
 // ACMEIssuerDNS01ProviderCloudflare is a structure containing the DNS
 // configuration for Cloudflare
 type ACMEIssuerDNS01ProviderCloudflare struct {
-	Email  string            `json:"email"`
-	APIKey SecretKeySelector `json:"apiKeySecretRef"`
+	// Email is the email address of the account that APIKey belongs to.
+	// Only used with APIKey.
+	// +optional
+	Email string `json:"email,omitempty"`
+
+	// APIKey is a reference to a secret containing the account's global API
+	// key. Exactly one of APIKey or APIToken must be set.
+	// +optional
+	APIKey SecretKeySelector `json:"apiKeySecretRef,omitempty"`
+
+	// APIToken is a reference to a secret containing a scoped API token.
+	// Exactly one of APIKey or APIToken must be set.
+	// +optional
+	APIToken *SecretKeySelector `json:"apiTokenSecretRef,omitempty"`
 }
 
 // ACMEIssuerDNS01ProviderDigitalOcean is a structure containing the DNS
@@ -220,6 +570,31 @@ type ACMEIssuerDNS01ProviderDigitalOcean struct {
 	Token SecretKeySelector `json:"tokenSecretRef"`
 }
 
+// ACMEIssuerDNS01ProviderHetzner is a structure containing the DNS
+// configuration for Hetzner DNS
+type ACMEIssuerDNS01ProviderHetzner struct {
+	APIToken SecretKeySelector `json:"apiTokenSecretRef"`
+}
+
+// ACMEIssuerDNS01ProviderWebhook is a structure containing the
+// configuration for an external DNS01 solver webhook. The webhook is
+// registered with the Kubernetes API server as an aggregated API, and
+// cert-manager sends it requests to present and clean up DNS records.
+type ACMEIssuerDNS01ProviderWebhook struct {
+	// GroupName is the API group name the webhook is registered under, e.g.
+	// acme.example.com.
+	GroupName string `json:"groupName"`
+
+	// SolverName is the name of the solver to use within the API group, as
+	// served by the webhook.
+	SolverName string `json:"solverName"`
+
+	// Config is passed to the webhook unmodified with every request. Its
+	// schema is defined by the webhook.
+	// +optional
+	Config *apiext.JSON `json:"config,omitempty"`
+}
+
 // ACMEIssuerDNS01ProviderRoute53 is a structure containing the Route 53
 // configuration for AWS
 type ACMEIssuerDNS01ProviderRoute53 struct {
@@ -227,19 +602,60 @@ type ACMEIssuerDNS01ProviderRoute53 struct {
 	SecretAccessKey SecretKeySelector `json:"secretAccessKeySecretRef"`
 	HostedZoneID    string            `json:"hostedZoneID"`
 	Region          string            `json:"region"`
+
+	// Role is a Role ARN which the Route53 provider will assume using either
+	// the explicit credentials AccessKeyID/SecretAccessKey or the inferred
+	// credentials from environment variables, shared files, or AWS Instance
+	// metadata.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// ExternalID is passed to STS when assuming Role. It may only be set if
+	// Role is also set.
+	// +optional
+	ExternalID string `json:"externalID,omitempty"`
 }
 
 // ACMEIssuerDNS01ProviderAzureDNS is a structure containing the
 // configuration for Azure DNS
 type ACMEIssuerDNS01ProviderAzureDNS struct {
-	ClientID          string            `json:"clientID"`
-	ClientSecret      SecretKeySelector `json:"clientSecretSecretRef"`
-	SubscriptionID    string            `json:"subscriptionID"`
-	TenantID          string            `json:"tenantID"`
-	ResourceGroupName string            `json:"resourceGroupName"`
+	// ClientID is the client ID of the service principal used to
+	// authenticate with Azure DNS. Required if ClientSecret is set.
+	// +optional
+	ClientID string `json:"clientID,omitempty"`
+
+	// ClientSecret references a secret containing the service principal's
+	// client secret. If it is not set, cert-manager will authenticate using
+	// the managed identity of the host it is running on.
+	// +optional
+	ClientSecret SecretKeySelector `json:"clientSecretSecretRef"`
+
+	SubscriptionID string `json:"subscriptionID"`
+
+	// TenantID is the ID of the Azure Active Directory tenant the service
+	// principal belongs to. Required if ClientSecret is set.
+	// +optional
+	TenantID string `json:"tenantID,omitempty"`
+
+	ResourceGroupName string `json:"resourceGroupName"`
 
 	// + optional
 	HostedZoneName string `json:"hostedZoneName"`
+
+	// ManagedIdentity configures the managed identity used to authenticate
+	// with Azure DNS when no ClientSecret is set. It may not be set if
+	// ClientSecret is set.
+	// +optional
+	ManagedIdentity *AzureManagedIdentity `json:"managedIdentity,omitempty"`
+}
+
+// AzureManagedIdentity selects the Azure managed identity used to obtain
+// tokens from the Instance Metadata Service.
+type AzureManagedIdentity struct {
+	// ClientID is the client ID of a user-assigned managed identity. If not
+	// set, the system-assigned identity of the host is used.
+	// +optional
+	ClientID string `json:"clientID,omitempty"`
 }
 
 // ACMEIssuerDNS01ProviderAcmeDNS is a structure containing the
@@ -269,7 +685,8 @@ type ACMEIssuerDNS01ProviderRFC2136 struct {
 	// The TSIG Algorithm configured in the DNS supporting RFC2136. Used only
 	// when ``tsigSecretSecretRef`` and ``tsigKeyName`` are defined.
 	// Supported values are (case-insensitive): ``HMACMD5`` (default),
-	// ``HMACSHA1``, ``HMACSHA256`` or ``HMACSHA512``.
+	// ``HMACSHA1``, ``HMACSHA256`` or ``HMACSHA512``. The RFC 4635 names of
+	// these algorithms, such as ``hmac-sha256``, are also accepted.
 	// +optional
 	TSIGAlgorithm string `json:"tsigAlgorithm"`
 }
@@ -284,11 +701,17 @@ type ACMEIssuerStatus struct {
 	// URI is the unique account identifier, which can also be used to retrieve
 	// account details from the CA
 	URI string `json:"uri"`
+	// LastRegisteredContacts is the list of contacts the account was last
+	// registered or updated with. It is used to detect changes to the
+	// contacts configured on the issuer.
+	// +optional
+	LastRegisteredContacts []string `json:"lastRegisteredContacts,omitempty"`
 }
 
 // IssuerCondition contains condition information for an Issuer.
 type IssuerCondition struct {
-	// Type of the condition, currently ('Ready').
+	// Type of the condition, currently ('Ready', 'ConfigurationValid',
+	// 'TemporarilyUnavailable').
 	Type IssuerConditionType `json:"type"`
 
 	// Status of the condition, one of ('True', 'False', 'Unknown').
@@ -314,4 +737,14 @@ const (
 	// IssuerConditionReady represents the fact that a given Issuer condition
 	// is in ready state.
 	IssuerConditionReady IssuerConditionType = "Ready"
+
+	// IssuerConditionConfigurationValid indicates whether the configuration
+	// of an Issuer, such as the reachability of its backing service and its
+	// credentials, has been verified without issuing a certificate.
+	IssuerConditionConfigurationValid IssuerConditionType = "ConfigurationValid"
+
+	// IssuerConditionTemporarilyUnavailable indicates that the Issuer failed
+	// too many times in a row, and that work for it is paused for a cooldown
+	// period to avoid overloading its backing service.
+	IssuerConditionTemporarilyUnavailable IssuerConditionType = "TemporarilyUnavailable"
 )
@@ -78,6 +78,13 @@ type OrderSpec struct {
 	// should be solved when performing ACME challenges.
 	// A config entry must exist for each domain listed in DNSNames and CommonName.
 	Config []DomainSolverConfig `json:"config"`
+
+	// Duration is the requested validity duration of the certificate.
+	// If set, it is used to request a notAfter time from the ACME server
+	// when the order is created. ACME servers that do not support this will
+	// issue a certificate with their default validity duration.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
 }
 
 type OrderStatus struct {
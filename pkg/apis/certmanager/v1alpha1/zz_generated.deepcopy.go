@@ -21,6 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	core_v1 "k8s.io/api/core/v1"
+	apiextensions_v1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -48,17 +50,79 @@ func (in *ACMECertificateConfig) DeepCopy() *ACMECertificateConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEChallengeSolver) DeepCopyInto(out *ACMEChallengeSolver) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(CertificateDNSNameSelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	in.SolverConfig.DeepCopyInto(&out.SolverConfig)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEChallengeSolver.
+func (in *ACMEChallengeSolver) DeepCopy() *ACMEChallengeSolver {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEChallengeSolver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEExternalAccountBinding) DeepCopyInto(out *ACMEExternalAccountBinding) {
+	*out = *in
+	out.Key = in.Key
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEExternalAccountBinding.
+func (in *ACMEExternalAccountBinding) DeepCopy() *ACMEExternalAccountBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEExternalAccountBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ACMEIssuer) DeepCopyInto(out *ACMEIssuer) {
 	*out = *in
+	if in.Emails != nil {
+		in, out := &in.Emails, &out.Emails
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
 	out.PrivateKey = in.PrivateKey
+	if in.ExternalAccountBinding != nil {
+		in, out := &in.ExternalAccountBinding, &out.ExternalAccountBinding
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ACMEExternalAccountBinding)
+			**out = **in
+		}
+	}
 	if in.HTTP01 != nil {
 		in, out := &in.HTTP01, &out.HTTP01
 		if *in == nil {
 			*out = nil
 		} else {
 			*out = new(ACMEIssuerHTTP01Config)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.DNS01 != nil {
@@ -70,6 +134,18 @@ func (in *ACMEIssuer) DeepCopyInto(out *ACMEIssuer) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Solvers != nil {
+		in, out := &in.Solvers, &out.Solvers
+		*out = make([]ACMEChallengeSolver, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DNS01RecursiveNameservers != nil {
+		in, out := &in.DNS01RecursiveNameservers, &out.DNS01RecursiveNameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -133,7 +209,7 @@ func (in *ACMEIssuerDNS01Provider) DeepCopyInto(out *ACMEIssuerDNS01Provider) {
 			*out = nil
 		} else {
 			*out = new(ACMEIssuerDNS01ProviderCloudflare)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Route53 != nil {
@@ -151,7 +227,7 @@ func (in *ACMEIssuerDNS01Provider) DeepCopyInto(out *ACMEIssuerDNS01Provider) {
 			*out = nil
 		} else {
 			*out = new(ACMEIssuerDNS01ProviderAzureDNS)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.DigitalOcean != nil {
@@ -181,6 +257,24 @@ func (in *ACMEIssuerDNS01Provider) DeepCopyInto(out *ACMEIssuerDNS01Provider) {
 			**out = **in
 		}
 	}
+	if in.Hetzner != nil {
+		in, out := &in.Hetzner, &out.Hetzner
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ACMEIssuerDNS01ProviderHetzner)
+			**out = **in
+		}
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ACMEIssuerDNS01ProviderWebhook)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -234,6 +328,15 @@ func (in *ACMEIssuerDNS01ProviderAkamai) DeepCopy() *ACMEIssuerDNS01ProviderAkam
 func (in *ACMEIssuerDNS01ProviderAzureDNS) DeepCopyInto(out *ACMEIssuerDNS01ProviderAzureDNS) {
 	*out = *in
 	out.ClientSecret = in.ClientSecret
+	if in.ManagedIdentity != nil {
+		in, out := &in.ManagedIdentity, &out.ManagedIdentity
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AzureManagedIdentity)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -268,6 +371,15 @@ func (in *ACMEIssuerDNS01ProviderCloudDNS) DeepCopy() *ACMEIssuerDNS01ProviderCl
 func (in *ACMEIssuerDNS01ProviderCloudflare) DeepCopyInto(out *ACMEIssuerDNS01ProviderCloudflare) {
 	*out = *in
 	out.APIKey = in.APIKey
+	if in.APIToken != nil {
+		in, out := &in.APIToken, &out.APIToken
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SecretKeySelector)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -298,6 +410,23 @@ func (in *ACMEIssuerDNS01ProviderDigitalOcean) DeepCopy() *ACMEIssuerDNS01Provid
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEIssuerDNS01ProviderHetzner) DeepCopyInto(out *ACMEIssuerDNS01ProviderHetzner) {
+	*out = *in
+	out.APIToken = in.APIToken
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEIssuerDNS01ProviderHetzner.
+func (in *ACMEIssuerDNS01ProviderHetzner) DeepCopy() *ACMEIssuerDNS01ProviderHetzner {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEIssuerDNS01ProviderHetzner)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ACMEIssuerDNS01ProviderRFC2136) DeepCopyInto(out *ACMEIssuerDNS01ProviderRFC2136) {
 	*out = *in
@@ -332,9 +461,62 @@ func (in *ACMEIssuerDNS01ProviderRoute53) DeepCopy() *ACMEIssuerDNS01ProviderRou
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEIssuerDNS01ProviderWebhook) DeepCopyInto(out *ACMEIssuerDNS01ProviderWebhook) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(apiextensions_v1beta1.JSON)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEIssuerDNS01ProviderWebhook.
+func (in *ACMEIssuerDNS01ProviderWebhook) DeepCopy() *ACMEIssuerDNS01ProviderWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEIssuerDNS01ProviderWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ACMEIssuerHTTP01Config) DeepCopyInto(out *ACMEIssuerHTTP01Config) {
 	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]core_v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(core_v1.Affinity)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]core_v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -351,6 +533,11 @@ func (in *ACMEIssuerHTTP01Config) DeepCopy() *ACMEIssuerHTTP01Config {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ACMEIssuerStatus) DeepCopyInto(out *ACMEIssuerStatus) {
 	*out = *in
+	if in.LastRegisteredContacts != nil {
+		in, out := &in.LastRegisteredContacts, &out.LastRegisteredContacts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -364,9 +551,34 @@ func (in *ACMEIssuerStatus) DeepCopy() *ACMEIssuerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureManagedIdentity) DeepCopyInto(out *AzureManagedIdentity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureManagedIdentity.
+func (in *AzureManagedIdentity) DeepCopy() *AzureManagedIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureManagedIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CAIssuer) DeepCopyInto(out *CAIssuer) {
 	*out = *in
+	if in.SerialNumber != nil {
+		in, out := &in.SerialNumber, &out.SerialNumber
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(CASerialNumber)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -380,6 +592,22 @@ func (in *CAIssuer) DeepCopy() *CAIssuer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CASerialNumber) DeepCopyInto(out *CASerialNumber) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CASerialNumber.
+func (in *CASerialNumber) DeepCopy() *CASerialNumber {
+	if in == nil {
+		return nil
+	}
+	out := new(CASerialNumber)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Certificate) DeepCopyInto(out *Certificate) {
 	*out = *in
@@ -426,40 +654,281 @@ func (in *CertificateCondition) DeepCopy() *CertificateCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateDNSNameSelector) DeepCopyInto(out *CertificateDNSNameSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSZones != nil {
+		in, out := &in.DNSZones, &out.DNSZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateDNSNameSelector.
+func (in *CertificateDNSNameSelector) DeepCopy() *CertificateDNSNameSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateDNSNameSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateKeystores) DeepCopyInto(out *CertificateKeystores) {
+	*out = *in
+	if in.PKCS12 != nil {
+		in, out := &in.PKCS12, &out.PKCS12
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PKCS12Keystore)
+			**out = **in
+		}
+	}
+	if in.JKS != nil {
+		in, out := &in.JKS, &out.JKS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(JKSKeystore)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateKeystores.
+func (in *CertificateKeystores) DeepCopy() *CertificateKeystores {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateKeystores)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateList) DeepCopyInto(out *CertificateList) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Certificate, len(*in))
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Certificate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateList.
+func (in *CertificateList) DeepCopy() *CertificateList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePrivateKey) DeepCopyInto(out *CertificatePrivateKey) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePrivateKey.
+func (in *CertificatePrivateKey) DeepCopy() *CertificatePrivateKey {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePrivateKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequest) DeepCopyInto(out *CertificateRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequest.
+func (in *CertificateRequest) DeepCopy() *CertificateRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestCondition) DeepCopyInto(out *CertificateRequestCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestCondition.
+func (in *CertificateRequestCondition) DeepCopy() *CertificateRequestCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestList) DeepCopyInto(out *CertificateRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestList.
+func (in *CertificateRequestList) DeepCopy() *CertificateRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestSpec) DeepCopyInto(out *CertificateRequestSpec) {
+	*out = *in
+	if in.CSRPEM != nil {
+		in, out := &in.CSRPEM, &out.CSRPEM
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	out.IssuerRef = in.IssuerRef
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	if in.Usages != nil {
+		in, out := &in.Usages, &out.Usages
+		*out = make([]KeyUsage, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestSpec.
+func (in *CertificateRequestSpec) DeepCopy() *CertificateRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestStatus) DeepCopyInto(out *CertificateRequestStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]CertificateRequestCondition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailureTime != nil {
+		in, out := &in.FailureTime, &out.FailureTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateList.
-func (in *CertificateList) DeepCopy() *CertificateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestStatus.
+func (in *CertificateRequestStatus) DeepCopy() *CertificateRequestStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CertificateList)
+	out := new(CertificateRequestStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CertificateList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	} else {
-		return nil
-	}
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
 	*out = *in
@@ -486,6 +955,15 @@ func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.RenewBeforePercentage != nil {
+		in, out := &in.RenewBeforePercentage, &out.RenewBeforePercentage
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(int32)
+			**out = **in
+		}
+	}
 	if in.DNSNames != nil {
 		in, out := &in.DNSNames, &out.DNSNames
 		*out = make([]string, len(*in))
@@ -496,6 +974,11 @@ func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.URIs != nil {
+		in, out := &in.URIs, &out.URIs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.IssuerRef = in.IssuerRef
 	if in.ACME != nil {
 		in, out := &in.ACME, &out.ACME
@@ -506,6 +989,36 @@ func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]X509Extension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Usages != nil {
+		in, out := &in.Usages, &out.Usages
+		*out = make([]KeyUsage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Keystores != nil {
+		in, out := &in.Keystores, &out.Keystores
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(CertificateKeystores)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.PrivateKey != nil {
+		in, out := &in.PrivateKey, &out.PrivateKey
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(CertificatePrivateKey)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -547,6 +1060,37 @@ func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.RenewalTime != nil {
+		in, out := &in.RenewalTime, &out.RenewalTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrivateKey != nil {
+		in, out := &in.PrivateKey, &out.PrivateKey
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConditionHistory != nil {
+		in, out := &in.ConditionHistory, &out.ConditionHistory
+		*out = make([]CertificateCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -758,6 +1302,45 @@ func (in *DomainSolverConfig) DeepCopy() *DomainSolverConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalIssuer) DeepCopyInto(out *ExternalIssuer) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClientCertSecretRef != nil {
+		in, out := &in.ClientCertSecretRef, &out.ClientCertSecretRef
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(LocalObjectReference)
+			**out = **in
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalIssuer.
+func (in *ExternalIssuer) DeepCopy() *ExternalIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTP01SolverConfig) DeepCopyInto(out *HTTP01SolverConfig) {
 	*out = *in
@@ -847,7 +1430,7 @@ func (in *IssuerConfig) DeepCopyInto(out *IssuerConfig) {
 			*out = nil
 		} else {
 			*out = new(CAIssuer)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Vault != nil {
@@ -868,6 +1451,24 @@ func (in *IssuerConfig) DeepCopyInto(out *IssuerConfig) {
 			**out = **in
 		}
 	}
+	if in.Venafi != nil {
+		in, out := &in.Venafi, &out.Venafi
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(VenafiIssuer)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ExternalIssuer)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -948,7 +1549,7 @@ func (in *IssuerStatus) DeepCopyInto(out *IssuerStatus) {
 			*out = nil
 		} else {
 			*out = new(ACMEIssuerStatus)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	return
@@ -964,6 +1565,23 @@ func (in *IssuerStatus) DeepCopy() *IssuerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JKSKeystore) DeepCopyInto(out *JKSKeystore) {
+	*out = *in
+	out.PasswordSecretRef = in.PasswordSecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JKSKeystore.
+func (in *JKSKeystore) DeepCopy() *JKSKeystore {
+	if in == nil {
+		return nil
+	}
+	out := new(JKSKeystore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
 	*out = *in
@@ -1080,6 +1698,15 @@ func (in *OrderSpec) DeepCopyInto(out *OrderSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -1130,6 +1757,23 @@ func (in *OrderStatus) DeepCopy() *OrderStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKCS12Keystore) DeepCopyInto(out *PKCS12Keystore) {
+	*out = *in
+	out.PasswordSecretRef = in.PasswordSecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKCS12Keystore.
+func (in *PKCS12Keystore) DeepCopy() *PKCS12Keystore {
+	if in == nil {
+		return nil
+	}
+	out := new(PKCS12Keystore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
 	*out = *in
@@ -1219,6 +1863,15 @@ func (in *VaultAuth) DeepCopyInto(out *VaultAuth) {
 	*out = *in
 	out.TokenSecretRef = in.TokenSecretRef
 	out.AppRole = in.AppRole
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(VaultKubernetesAuth)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -1235,7 +1888,7 @@ func (in *VaultAuth) DeepCopy() *VaultAuth {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultIssuer) DeepCopyInto(out *VaultIssuer) {
 	*out = *in
-	out.Auth = in.Auth
+	in.Auth.DeepCopyInto(&out.Auth)
 	if in.CABundle != nil {
 		in, out := &in.CABundle, &out.CABundle
 		*out = make([]byte, len(*in))
@@ -1253,3 +1906,114 @@ func (in *VaultIssuer) DeepCopy() *VaultIssuer {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKubernetesAuth) DeepCopyInto(out *VaultKubernetesAuth) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKubernetesAuth.
+func (in *VaultKubernetesAuth) DeepCopy() *VaultKubernetesAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKubernetesAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VenafiCloud) DeepCopyInto(out *VenafiCloud) {
+	*out = *in
+	out.APITokenSecretRef = in.APITokenSecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VenafiCloud.
+func (in *VenafiCloud) DeepCopy() *VenafiCloud {
+	if in == nil {
+		return nil
+	}
+	out := new(VenafiCloud)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VenafiIssuer) DeepCopyInto(out *VenafiIssuer) {
+	*out = *in
+	if in.TPP != nil {
+		in, out := &in.TPP, &out.TPP
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(VenafiTPP)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Cloud != nil {
+		in, out := &in.Cloud, &out.Cloud
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(VenafiCloud)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VenafiIssuer.
+func (in *VenafiIssuer) DeepCopy() *VenafiIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(VenafiIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VenafiTPP) DeepCopyInto(out *VenafiTPP) {
+	*out = *in
+	out.CredentialsRef = in.CredentialsRef
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VenafiTPP.
+func (in *VenafiTPP) DeepCopy() *VenafiTPP {
+	if in == nil {
+		return nil
+	}
+	out := new(VenafiTPP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *X509Extension) DeepCopyInto(out *X509Extension) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new X509Extension.
+func (in *X509Extension) DeepCopy() *X509Extension {
+	if in == nil {
+		return nil
+	}
+	out := new(X509Extension)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -28,5 +28,18 @@ import (
 // Public to allow building arbitrary schemes.
 // All generated defaulters are covering - they call all nested defaulters.
 func RegisterDefaults(scheme *runtime.Scheme) error {
+	scheme.AddTypeDefaultingFunc(&Certificate{}, func(obj interface{}) { SetObjectDefaults_Certificate(obj.(*Certificate)) })
+	scheme.AddTypeDefaultingFunc(&CertificateList{}, func(obj interface{}) { SetObjectDefaults_CertificateList(obj.(*CertificateList)) })
 	return nil
 }
+
+func SetObjectDefaults_Certificate(in *Certificate) {
+	SetDefaults_CertificateSpec(&in.Spec)
+}
+
+func SetObjectDefaults_CertificateList(in *CertificateList) {
+	for i := range in.Items {
+		a := &in.Items[i]
+		SetObjectDefaults_Certificate(a)
+	}
+}
@@ -19,10 +19,12 @@ package validation
 import (
 	"fmt"
 	"net"
+	"net/url"
 
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
 )
 
 // Validation functions for cert-manager v1alpha1 Certificate types
@@ -34,8 +36,16 @@ func ValidateCertificate(crt *v1alpha1.Certificate) field.ErrorList {
 
 func ValidateCertificateSpec(crt *v1alpha1.CertificateSpec, fldPath *field.Path) field.ErrorList {
 	el := field.ErrorList{}
-	if crt.SecretName == "" {
-		el = append(el, field.Required(fldPath.Child("secretName"), "must be specified"))
+	switch crt.Output {
+	case v1alpha1.CertificateOutputMode(""), v1alpha1.CertificateOutputSecret:
+		if crt.SecretName == "" {
+			el = append(el, field.Required(fldPath.Child("secretName"), "must be specified"))
+		}
+	case v1alpha1.CertificateOutputSecretless:
+		el = append(el, validateSecretlessOutput(crt, fldPath)...)
+	default:
+		el = append(el, field.NotSupported(fldPath.Child("output"), crt.Output,
+			[]string{string(v1alpha1.CertificateOutputSecret), string(v1alpha1.CertificateOutputSecretless)}))
 	}
 	issuerRefPath := fldPath.Child("issuerRef")
 	if crt.IssuerRef.Name == "" {
@@ -47,12 +57,15 @@ func ValidateCertificateSpec(crt *v1alpha1.CertificateSpec, fldPath *field.Path)
 	default:
 		el = append(el, field.Invalid(issuerRefPath.Child("kind"), crt.IssuerRef.Kind, "must be one of Issuer or ClusterIssuer"))
 	}
-	if len(crt.CommonName) == 0 && len(crt.DNSNames) == 0 {
-		el = append(el, field.Required(fldPath.Child("dnsNames"), "at least one dnsName is required if commonName is not set"))
+	if len(crt.CommonName) == 0 && len(crt.DNSNames) == 0 && len(crt.IPAddresses) == 0 && len(crt.URIs) == 0 {
+		el = append(el, field.Required(fldPath.Child("dnsNames"), "at least one dnsName, ipAddress or uri is required if commonName is not set"))
 	}
 	if len(crt.IPAddresses) > 0 {
 		el = append(el, validateIPAddresses(crt, fldPath)...)
 	}
+	if len(crt.URIs) > 0 {
+		el = append(el, validateURIs(crt, fldPath)...)
+	}
 	if crt.ACME != nil {
 		el = append(el, validateACMEConfigForAllDNSNames(crt, fldPath)...)
 		el = append(el, ValidateACMECertificateConfig(crt.ACME, fldPath.Child("acme"))...)
@@ -67,17 +80,115 @@ func ValidateCertificateSpec(crt *v1alpha1.CertificateSpec, fldPath *field.Path)
 			el = append(el, field.Invalid(fldPath.Child("keySize"), crt.KeySize, "must be between 2048 & 8192 for rsa keyAlgorithm"))
 		}
 	case v1alpha1.ECDSAKeyAlgorithm:
-		if crt.KeySize > 0 && crt.KeySize != 256 && crt.KeySize != 384 && crt.KeySize != 521 {
+		if crt.KeySize > 0 && crt.KeyCurve != "" {
+			el = append(el, field.Invalid(fldPath.Child("keySize"), crt.KeySize, "may not be set together with keyCurve for ecdsa keyAlgorithm"))
+		} else if crt.KeySize > 0 && crt.KeySize != 256 && crt.KeySize != 384 && crt.KeySize != 521 {
 			el = append(el, field.NotSupported(fldPath.Child("keySize"), crt.KeySize, []string{"256", "384", "521"}))
 		}
+		switch crt.KeyCurve {
+		case v1alpha1.KeyCurve(""), v1alpha1.P256KeyCurve, v1alpha1.P384KeyCurve, v1alpha1.P521KeyCurve:
+		default:
+			el = append(el, field.NotSupported(fldPath.Child("keyCurve"), crt.KeyCurve, []string{string(v1alpha1.P256KeyCurve), string(v1alpha1.P384KeyCurve), string(v1alpha1.P521KeyCurve)}))
+		}
+	case v1alpha1.Ed25519KeyAlgorithm:
+		if crt.KeySize > 0 {
+			el = append(el, field.Invalid(fldPath.Child("keySize"), crt.KeySize, "may not be set for ed25519 keyAlgorithm"))
+		}
 	default:
-		el = append(el, field.Invalid(fldPath.Child("keyAlgorithm"), crt.KeyAlgorithm, "must be either empty or one of rsa or ecdsa"))
+		el = append(el, field.Invalid(fldPath.Child("keyAlgorithm"), crt.KeyAlgorithm, "must be either empty or one of rsa, ecdsa or ed25519"))
+	}
+	if crt.KeyCurve != "" && crt.KeyAlgorithm != v1alpha1.ECDSAKeyAlgorithm {
+		el = append(el, field.Invalid(fldPath.Child("keyCurve"), crt.KeyCurve, "may only be set for ecdsa keyAlgorithm"))
 	}
 
-	if crt.Duration != nil || crt.RenewBefore != nil {
+	if crt.Duration != nil || crt.RenewBefore != nil || crt.RenewBeforePercentage != nil {
 		el = append(el, ValidateDuration(crt, fldPath)...)
 	}
 
+	if len(crt.Extensions) > 0 {
+		el = append(el, validateExtensions(crt.Extensions, fldPath.Child("extensions"))...)
+	}
+
+	for i, u := range crt.Usages {
+		if !pki.IsKnownKeyUsage(u) {
+			el = append(el, field.NotSupported(fldPath.Child("usages").Index(i), u, knownKeyUsages))
+		}
+	}
+
+	if crt.Keystores != nil && crt.Keystores.PKCS12 != nil {
+		el = append(el, validateKeystorePasswordSecretRef(crt.Keystores.PKCS12.PasswordSecretRef, fldPath.Child("keystores", "pkcs12", "passwordSecretRef"))...)
+	}
+
+	if crt.Keystores != nil && crt.Keystores.JKS != nil {
+		el = append(el, validateKeystorePasswordSecretRef(crt.Keystores.JKS.PasswordSecretRef, fldPath.Child("keystores", "jks", "passwordSecretRef"))...)
+	}
+
+	switch crt.SecretDeletionPolicy {
+	case v1alpha1.SecretDeletionPolicy(""), v1alpha1.SecretDeletionPolicyRetain, v1alpha1.SecretDeletionPolicyDelete:
+	default:
+		el = append(el, field.NotSupported(fldPath.Child("secretDeletionPolicy"), crt.SecretDeletionPolicy,
+			[]string{string(v1alpha1.SecretDeletionPolicyRetain), string(v1alpha1.SecretDeletionPolicyDelete)}))
+	}
+
+	if crt.PrivateKey != nil {
+		switch crt.PrivateKey.RotationPolicy {
+		case v1alpha1.PrivateKeyRotationPolicy(""), v1alpha1.RotationPolicyNever, v1alpha1.RotationPolicyAlways:
+		default:
+			el = append(el, field.NotSupported(fldPath.Child("privateKey", "rotationPolicy"), crt.PrivateKey.RotationPolicy,
+				[]string{string(v1alpha1.RotationPolicyNever), string(v1alpha1.RotationPolicyAlways)}))
+		}
+	}
+
+	return el
+}
+
+// validateSecretlessOutput checks that no fields that configure the
+// Certificate's Secret are set when no Secret is managed.
+func validateSecretlessOutput(crt *v1alpha1.CertificateSpec, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if crt.SecretName != "" {
+		el = append(el, field.Forbidden(fldPath.Child("secretName"), "may not be set when output is secretless"))
+	}
+	if crt.Keystores != nil {
+		el = append(el, field.Forbidden(fldPath.Child("keystores"), "may not be set when output is secretless"))
+	}
+	if crt.CombinedPEM {
+		el = append(el, field.Forbidden(fldPath.Child("combinedPEM"), "may not be set when output is secretless"))
+	}
+	if crt.PrivateKey != nil && crt.PrivateKey.RotationPolicy == v1alpha1.RotationPolicyNever {
+		el = append(el, field.Forbidden(fldPath.Child("privateKey", "rotationPolicy"), "may not be Never when output is secretless"))
+	}
+	return el
+}
+
+func validateKeystorePasswordSecretRef(ref v1alpha1.SecretKeySelector, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if len(ref.Name) == 0 {
+		el = append(el, field.Required(fldPath.Child("name"), "must be specified"))
+	}
+	if len(ref.Key) == 0 {
+		el = append(el, field.Required(fldPath.Child("key"), "must be specified"))
+	}
+	return el
+}
+
+func validateExtensions(exts []v1alpha1.X509Extension, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	seen := make(map[string]bool)
+	for i, e := range exts {
+		oidPath := fldPath.Index(i).Child("oid")
+		oid, err := pki.ParseObjectIdentifier(e.OID)
+		if err != nil {
+			el = append(el, field.Invalid(oidPath, e.OID, err.Error()))
+		} else if seen[oid.String()] {
+			el = append(el, field.Duplicate(oidPath, e.OID))
+		} else {
+			seen[oid.String()] = true
+		}
+		if len(e.Value) == 0 {
+			el = append(el, field.Required(fldPath.Index(i).Child("value"), "extension value must be specified"))
+		}
+	}
 	return el
 }
 
@@ -122,6 +233,45 @@ func validateIPAddresses(a *v1alpha1.CertificateSpec, fldPath *field.Path) field
 	return el
 }
 
+// knownKeyUsages are the values accepted in the usages field.
+var knownKeyUsages = []string{
+	string(v1alpha1.KeyUsageSigning),
+	string(v1alpha1.KeyUsageDigitalSignature),
+	string(v1alpha1.KeyUsageContentCommitment),
+	string(v1alpha1.KeyUsageKeyEncipherment),
+	string(v1alpha1.KeyUsageKeyAgreement),
+	string(v1alpha1.KeyUsageDataEncipherment),
+	string(v1alpha1.KeyUsageCertSign),
+	string(v1alpha1.KeyUsageCRLSign),
+	string(v1alpha1.KeyUsageEncipherOnly),
+	string(v1alpha1.KeyUsageDecipherOnly),
+	string(v1alpha1.KeyUsageAny),
+	string(v1alpha1.KeyUsageServerAuth),
+	string(v1alpha1.KeyUsageClientAuth),
+	string(v1alpha1.KeyUsageCodeSigning),
+	string(v1alpha1.KeyUsageEmailProtection),
+	string(v1alpha1.KeyUsageIPsecEndSystem),
+	string(v1alpha1.KeyUsageIPsecTunnel),
+	string(v1alpha1.KeyUsageIPsecUser),
+	string(v1alpha1.KeyUsageTimestamping),
+	string(v1alpha1.KeyUsageOCSPSigning),
+}
+
+func validateURIs(a *v1alpha1.CertificateSpec, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	for i, u := range a.URIs {
+		uri, err := url.Parse(u)
+		if err != nil {
+			el = append(el, field.Invalid(fldPath.Child("uris").Index(i), u, "invalid URI"))
+			continue
+		}
+		if !uri.IsAbs() || (uri.Host == "" && uri.Opaque == "") {
+			el = append(el, field.Invalid(fldPath.Child("uris").Index(i), u, "must be an absolute URI with a scheme and host, e.g. spiffe://example.org/service"))
+		}
+	}
+	return el
+}
+
 func ValidateACMECertificateConfig(a *v1alpha1.ACMECertificateConfig, fldPath *field.Path) field.ErrorList {
 	el := field.ErrorList{}
 	for i, cfg := range a.Config {
@@ -178,13 +328,24 @@ func ValidateDuration(crt *v1alpha1.CertificateSpec, fldPath *field.Path) field.
 	if crt.Duration != nil {
 		duration = crt.Duration.Duration
 	}
+	if duration < v1alpha1.MinimumCertificateDuration {
+		el = append(el, field.Invalid(fldPath.Child("duration"), duration, fmt.Sprintf("certificate duration must be greater than %s", v1alpha1.MinimumCertificateDuration)))
+	}
+	if crt.RenewBeforePercentage != nil {
+		if crt.RenewBefore != nil {
+			el = append(el, field.Forbidden(fldPath.Child("renewBeforePercentage"), "may not be set when renewBefore is set"))
+		}
+		if p := *crt.RenewBeforePercentage; p < 1 || p > 99 {
+			el = append(el, field.Invalid(fldPath.Child("renewBeforePercentage"), p, "must be between 1 and 99"))
+		}
+		// the renewal time is derived from the issued certificate's
+		// lifetime, so it cannot fall outside of the certificate duration
+		return el
+	}
 	renewBefore := v1alpha1.DefaultRenewBefore
 	if crt.RenewBefore != nil {
 		renewBefore = crt.RenewBefore.Duration
 	}
-	if duration < v1alpha1.MinimumCertificateDuration {
-		el = append(el, field.Invalid(fldPath.Child("duration"), duration, fmt.Sprintf("certificate duration must be greater than %s", v1alpha1.MinimumCertificateDuration)))
-	}
 	if renewBefore < v1alpha1.MinimumRenewBefore {
 		el = append(el, field.Invalid(fldPath.Child("renewBefore"), renewBefore, fmt.Sprintf("certificate renewBefore must be greater than %s", v1alpha1.MinimumRenewBefore)))
 	}
@@ -34,6 +34,18 @@ func ValidateCertificateForIssuer(crt *v1alpha1.Certificate, issuerObj v1alpha1.
 		return el
 	}
 
+	if issuerType != controller.IssuerSelfSigned && len(crt.Spec.Extensions) > 0 {
+		el = append(el, field.Forbidden(path.Child("extensions"), "custom extensions are only supported by the SelfSigned issuer"))
+	}
+
+	if crt.Spec.KeyAlgorithm == v1alpha1.Ed25519KeyAlgorithm && issuerType != controller.IssuerSelfSigned && issuerType != controller.IssuerCA {
+		el = append(el, field.Forbidden(path.Child("keyAlgorithm"), "ed25519 keys are only supported by the CA and SelfSigned issuers"))
+	}
+
+	if len(crt.Spec.Usages) > 0 && issuerType != controller.IssuerSelfSigned && issuerType != controller.IssuerCA {
+		el = append(el, field.Forbidden(path.Child("usages"), "key usages are only supported by the CA and SelfSigned issuers"))
+	}
+
 	switch issuerType {
 	case controller.IssuerACME:
 		el = append(el, ValidateCertificateForACMEIssuer(&crt.Spec, issuerObj.GetSpec(), path)...)
@@ -43,6 +55,10 @@ func ValidateCertificateForIssuer(crt *v1alpha1.Certificate, issuerObj v1alpha1.
 		el = append(el, ValidateCertificateForVaultIssuer(&crt.Spec, issuerObj.GetSpec(), path)...)
 	case controller.IssuerSelfSigned:
 		el = append(el, ValidateCertificateForSelfSignedIssuer(&crt.Spec, issuerObj.GetSpec(), path)...)
+	case controller.IssuerVenafi:
+		el = append(el, ValidateCertificateForVenafiIssuer(&crt.Spec, issuerObj.GetSpec(), path)...)
+	case controller.IssuerExternal:
+		el = append(el, ValidateCertificateForExternalIssuer(&crt.Spec, issuerObj.GetSpec(), path)...)
 	}
 
 	return el
@@ -59,14 +75,24 @@ func ValidateCertificateForACMEIssuer(crt *v1alpha1.CertificateSpec, issuer *v1a
 		el = append(el, field.Invalid(specPath.Child("organization"), crt.Organization, "ACME does not support setting the organization name"))
 	}
 
-	if crt.Duration != nil {
-		el = append(el, field.Invalid(specPath.Child("duration"), crt.Duration, "ACME does not support certificate durations"))
+	if crt.Duration != nil && (issuer.ACME == nil || !issuer.ACME.EnableDurationFeature) {
+		el = append(el, field.Invalid(specPath.Child("duration"), crt.Duration, "ACME does not support certificate durations unless enableDurationFeature is set on the issuer"))
 	}
 
 	if len(crt.IPAddresses) != 0 {
 		el = append(el, field.Invalid(specPath.Child("ipAddresses"), crt.IPAddresses, "ACME does not support certificate ip addresses"))
 	}
 
+	if len(crt.URIs) != 0 {
+		el = append(el, field.Invalid(specPath.Child("uris"), crt.URIs, "ACME does not support certificate uris"))
+	}
+
+	// the ACME issuer stores the private key in the Certificate's Secret
+	// while an order is in progress
+	if crt.Output == v1alpha1.CertificateOutputSecretless {
+		el = append(el, field.Invalid(specPath.Child("output"), crt.Output, "ACME does not support secretless output"))
+	}
+
 	return el
 }
 
@@ -95,3 +121,19 @@ func ValidateCertificateForSelfSignedIssuer(crt *v1alpha1.CertificateSpec, issue
 
 	return el
 }
+
+func ValidateCertificateForVenafiIssuer(crt *v1alpha1.CertificateSpec, issuer *v1alpha1.IssuerSpec, specPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	if crt.IsCA {
+		el = append(el, field.Invalid(specPath.Child("isCA"), crt.KeyAlgorithm, "Venafi issuer does not currently support CA certificates"))
+	}
+
+	return el
+}
+
+func ValidateCertificateForExternalIssuer(crt *v1alpha1.CertificateSpec, issuer *v1alpha1.IssuerSpec, specPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	return el
+}
@@ -133,6 +133,102 @@ func TestValidateCertificateForIssuer(t *testing.T) {
 				field.Invalid(fldPath.Child("organization"), []string{"shouldfailorg"}, "ACME does not support setting the organization name"),
 			},
 		},
+		"acme certificate with extensions set": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					IssuerRef: validIssuerRef,
+					Extensions: []v1alpha1.X509Extension{
+						{OID: "1.2.3.4", Value: []byte{0x05, 0x00}},
+					},
+					ACME: &v1alpha1.ACMECertificateConfig{
+						Config: []v1alpha1.DomainSolverConfig{
+							{
+								Domains: []string{"example.com"},
+								SolverConfig: v1alpha1.SolverConfig{
+									HTTP01: &v1alpha1.HTTP01SolverConfig{},
+								},
+							},
+						},
+					},
+				},
+			},
+			issuer: generate.Issuer(generate.IssuerConfig{
+				Name:      defaultTestIssuerName,
+				Namespace: defaultTestNamespace,
+			}),
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("extensions"), "custom extensions are only supported by the SelfSigned issuer"),
+			},
+		},
+		"acme certificate with usages set": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					IssuerRef: validIssuerRef,
+					Usages:    []v1alpha1.KeyUsage{v1alpha1.KeyUsageClientAuth},
+					ACME: &v1alpha1.ACMECertificateConfig{
+						Config: []v1alpha1.DomainSolverConfig{
+							{
+								Domains: []string{"example.com"},
+								SolverConfig: v1alpha1.SolverConfig{
+									HTTP01: &v1alpha1.HTTP01SolverConfig{},
+								},
+							},
+						},
+					},
+				},
+			},
+			issuer: generate.Issuer(generate.IssuerConfig{
+				Name:      defaultTestIssuerName,
+				Namespace: defaultTestNamespace,
+			}),
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("usages"), "key usages are only supported by the CA and SelfSigned issuers"),
+			},
+		},
+		"acme certificate with ed25519 keyAlgorithm": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					KeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+					IssuerRef:    validIssuerRef,
+					ACME: &v1alpha1.ACMECertificateConfig{
+						Config: []v1alpha1.DomainSolverConfig{
+							{
+								Domains: []string{"example.com"},
+								SolverConfig: v1alpha1.SolverConfig{
+									HTTP01: &v1alpha1.HTTP01SolverConfig{},
+								},
+							},
+						},
+					},
+				},
+			},
+			issuer: generate.Issuer(generate.IssuerConfig{
+				Name:      defaultTestIssuerName,
+				Namespace: defaultTestNamespace,
+			}),
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("keyAlgorithm"), "ed25519 keys are only supported by the CA and SelfSigned issuers"),
+			},
+		},
+		"ca certificate with ed25519 keyAlgorithm": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					KeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+					IssuerRef:    validIssuerRef,
+				},
+			},
+			issuer: &v1alpha1.Issuer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      defaultTestIssuerName,
+					Namespace: defaultTestNamespace,
+				},
+				Spec: v1alpha1.IssuerSpec{
+					IssuerConfig: v1alpha1.IssuerConfig{
+						CA: &v1alpha1.CAIssuer{SecretName: "ca"},
+					},
+				},
+			},
+		},
 		"acme certificate with duration set": {
 			crt: &v1alpha1.Certificate{
 				Spec: v1alpha1.CertificateSpec{
@@ -155,7 +251,36 @@ func TestValidateCertificateForIssuer(t *testing.T) {
 				Namespace: defaultTestNamespace,
 			}),
 			errs: []*field.Error{
-				field.Invalid(fldPath.Child("duration"), &metav1.Duration{Duration: time.Minute * 60}, "ACME does not support certificate durations"),
+				field.Invalid(fldPath.Child("duration"), &metav1.Duration{Duration: time.Minute * 60}, "ACME does not support certificate durations unless enableDurationFeature is set on the issuer"),
+			},
+		},
+		"acme certificate with duration set and the duration feature enabled": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					Duration:  &metav1.Duration{Duration: time.Minute * 60},
+					IssuerRef: validIssuerRef,
+					ACME: &v1alpha1.ACMECertificateConfig{
+						Config: []v1alpha1.DomainSolverConfig{
+							{
+								Domains: []string{"example.com"},
+								SolverConfig: v1alpha1.SolverConfig{
+									HTTP01: &v1alpha1.HTTP01SolverConfig{},
+								},
+							},
+						},
+					},
+				},
+			},
+			issuer: &v1alpha1.Issuer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      defaultTestIssuerName,
+					Namespace: defaultTestNamespace,
+				},
+				Spec: v1alpha1.IssuerSpec{
+					IssuerConfig: v1alpha1.IssuerConfig{
+						ACME: &v1alpha1.ACMEIssuer{EnableDurationFeature: true},
+					},
+				},
 			},
 		},
 		"acme certificate with ipAddresses set": {
@@ -183,6 +308,57 @@ func TestValidateCertificateForIssuer(t *testing.T) {
 				field.Invalid(fldPath.Child("ipAddresses"), []string{"127.0.0.1"}, "ACME does not support certificate ip addresses"),
 			},
 		},
+		"acme certificate with uris set": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					URIs:      []string{"spiffe://example.org/service"},
+					IssuerRef: validIssuerRef,
+					ACME: &v1alpha1.ACMECertificateConfig{
+						Config: []v1alpha1.DomainSolverConfig{
+							{
+								Domains: []string{"example.com"},
+								SolverConfig: v1alpha1.SolverConfig{
+									HTTP01: &v1alpha1.HTTP01SolverConfig{},
+								},
+							},
+						},
+					},
+				},
+			},
+			issuer: generate.Issuer(generate.IssuerConfig{
+				Name:      defaultTestIssuerName,
+				Namespace: defaultTestNamespace,
+			}),
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("uris"), []string{"spiffe://example.org/service"}, "ACME does not support certificate uris"),
+			},
+		},
+		"acme certificate with secretless output": {
+			crt: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "example.com",
+					IssuerRef:  validIssuerRef,
+					Output:     v1alpha1.CertificateOutputSecretless,
+					ACME: &v1alpha1.ACMECertificateConfig{
+						Config: []v1alpha1.DomainSolverConfig{
+							{
+								Domains: []string{"example.com"},
+								SolverConfig: v1alpha1.SolverConfig{
+									HTTP01: &v1alpha1.HTTP01SolverConfig{},
+								},
+							},
+						},
+					},
+				},
+			},
+			issuer: generate.Issuer(generate.IssuerConfig{
+				Name:      defaultTestIssuerName,
+				Namespace: defaultTestNamespace,
+			}),
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("output"), v1alpha1.CertificateOutputSecretless, "ACME does not support secretless output"),
+			},
+		},
 		"acme certificate with renewBefore set": {
 			crt: &v1alpha1.Certificate{
 				Spec: v1alpha1.CertificateSpec{
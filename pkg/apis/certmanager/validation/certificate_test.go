@@ -113,6 +113,58 @@ func TestValidateCertificate(t *testing.T) {
 				field.Required(fldPath.Child("secretName"), "must be specified"),
 			},
 		},
+		"valid secretless certificate": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					IssuerRef:  validIssuerRef,
+					Output:     v1alpha1.CertificateOutputSecretless,
+				},
+			},
+		},
+		"secretless certificate with secret fields set": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:  "testcn",
+					SecretName:  "abc",
+					IssuerRef:   validIssuerRef,
+					Output:      v1alpha1.CertificateOutputSecretless,
+					Keystores:   &v1alpha1.CertificateKeystores{},
+					CombinedPEM: true,
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("secretName"), "may not be set when output is secretless"),
+				field.Forbidden(fldPath.Child("keystores"), "may not be set when output is secretless"),
+				field.Forbidden(fldPath.Child("combinedPEM"), "may not be set when output is secretless"),
+			},
+		},
+		"secretless certificate that never rotates its private key": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					IssuerRef:  validIssuerRef,
+					Output:     v1alpha1.CertificateOutputSecretless,
+					PrivateKey: &v1alpha1.CertificatePrivateKey{RotationPolicy: v1alpha1.RotationPolicyNever},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("privateKey", "rotationPolicy"), "may not be Never when output is secretless"),
+			},
+		},
+		"certificate with unknown output": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Output:     v1alpha1.CertificateOutputMode("configmap"),
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("output"), v1alpha1.CertificateOutputMode("configmap"), []string{"secret", "secretless"}),
+			},
+		},
 		"certificate with no domains": {
 			cfg: &v1alpha1.Certificate{
 				Spec: v1alpha1.CertificateSpec{
@@ -121,7 +173,7 @@ func TestValidateCertificate(t *testing.T) {
 				},
 			},
 			errs: []*field.Error{
-				field.Required(fldPath.Child("dnsNames"), "at least one dnsName is required if commonName is not set"),
+				field.Required(fldPath.Child("dnsNames"), "at least one dnsName, ipAddress or uri is required if commonName is not set"),
 			},
 		},
 		"certificate with no issuerRef": {
@@ -369,7 +421,85 @@ func TestValidateCertificate(t *testing.T) {
 				},
 			},
 			errs: []*field.Error{
-				field.Invalid(fldPath.Child("keyAlgorithm"), v1alpha1.KeyAlgorithm("blah"), "must be either empty or one of rsa or ecdsa"),
+				field.Invalid(fldPath.Child("keyAlgorithm"), v1alpha1.KeyAlgorithm("blah"), "must be either empty or one of rsa, ecdsa or ed25519"),
+			},
+		},
+		"valid certificate with ecdsa keyAlgorithm specified with keyCurve P384": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:   "testcn",
+					SecretName:   "abc",
+					IssuerRef:    validIssuerRef,
+					KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+					KeyCurve:     v1alpha1.P384KeyCurve,
+				},
+			},
+		},
+		"certificate with ecdsa keyAlgorithm specified with keySize and keyCurve": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:   "testcn",
+					SecretName:   "abc",
+					IssuerRef:    validIssuerRef,
+					KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+					KeySize:      384,
+					KeyCurve:     v1alpha1.P384KeyCurve,
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("keySize"), 384, "may not be set together with keyCurve for ecdsa keyAlgorithm"),
+			},
+		},
+		"certificate with ecdsa keyAlgorithm specified and invalid keyCurve": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:   "testcn",
+					SecretName:   "abc",
+					IssuerRef:    validIssuerRef,
+					KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+					KeyCurve:     v1alpha1.KeyCurve("P192"),
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("keyCurve"), v1alpha1.KeyCurve("P192"), []string{"P256", "P384", "P521"}),
+			},
+		},
+		"certificate with rsa keyAlgorithm specified and keyCurve": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:   "testcn",
+					SecretName:   "abc",
+					IssuerRef:    validIssuerRef,
+					KeyAlgorithm: v1alpha1.RSAKeyAlgorithm,
+					KeyCurve:     v1alpha1.P256KeyCurve,
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("keyCurve"), v1alpha1.P256KeyCurve, "may only be set for ecdsa keyAlgorithm"),
+			},
+		},
+		"valid certificate with ed25519 keyAlgorithm specified": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:   "testcn",
+					SecretName:   "abc",
+					IssuerRef:    validIssuerRef,
+					KeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+				},
+			},
+		},
+		"certificate with ed25519 keyAlgorithm specified with keySize": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:   "testcn",
+					SecretName:   "abc",
+					IssuerRef:    validIssuerRef,
+					KeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+					KeySize:      256,
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("keySize"), 256, "may not be set for ed25519 keyAlgorithm"),
 			},
 		},
 		"valid certificate with ipAddresses": {
@@ -382,6 +512,61 @@ func TestValidateCertificate(t *testing.T) {
 				},
 			},
 		},
+		"valid certificate with only ipAddresses": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					SecretName:  "abc",
+					IPAddresses: []string{"10.0.0.1", "fd00::1"},
+					IssuerRef:   validIssuerRef,
+				},
+			},
+		},
+		"valid certificate with usages": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Usages:     []v1alpha1.KeyUsage{v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageClientAuth},
+				},
+			},
+		},
+		"certificate with unknown usage": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Usages:     []v1alpha1.KeyUsage{v1alpha1.KeyUsageServerAuth, "client-auth"},
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("usages").Index(1), v1alpha1.KeyUsage("client-auth"), knownKeyUsages),
+			},
+		},
+		"valid certificate with only uris": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					SecretName: "abc",
+					URIs:       []string{"spiffe://example.org/ns/default/sa/app"},
+					IssuerRef:  validIssuerRef,
+				},
+			},
+		},
+		"certificate with invalid uris": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					URIs:       []string{"spiffe://example.org/service", "/relative/path", "spiffe://exa mple.org"},
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("uris").Index(1), "/relative/path", "must be an absolute URI with a scheme and host, e.g. spiffe://example.org/service"),
+				field.Invalid(fldPath.Child("uris").Index(2), "spiffe://exa mple.org", "invalid URI"),
+			},
+		},
 		"certificate with invalid ipAddresses": {
 			cfg: &v1alpha1.Certificate{
 				Spec: v1alpha1.CertificateSpec{
@@ -395,6 +580,156 @@ func TestValidateCertificate(t *testing.T) {
 				field.Invalid(fldPath.Child("ipAddresses").Index(0), "blah", "invalid IP address"),
 			},
 		},
+		"certificate with valid extensions": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Extensions: []v1alpha1.X509Extension{
+						{OID: "1.3.6.1.5.5.7.1.24", Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05}},
+						{OID: "1.2.3.4", Critical: true, Value: []byte{0x05, 0x00}},
+					},
+				},
+			},
+		},
+		"certificate with invalid extensions": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Extensions: []v1alpha1.X509Extension{
+						{OID: "1.3.6.x", Value: []byte{0x05, 0x00}},
+						{OID: "1.2.3.4", Value: []byte{0x05, 0x00}},
+						{OID: "1.2.3.4"},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("extensions").Index(0).Child("oid"), "1.3.6.x", `invalid object identifier component "x"`),
+				field.Duplicate(fldPath.Child("extensions").Index(2).Child("oid"), "1.2.3.4"),
+				field.Required(fldPath.Child("extensions").Index(2).Child("value"), "extension value must be specified"),
+			},
+		},
+		"certificate with valid pkcs12 keystore": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Keystores: &v1alpha1.CertificateKeystores{
+						PKCS12: &v1alpha1.PKCS12Keystore{
+							PasswordSecretRef: v1alpha1.SecretKeySelector{
+								LocalObjectReference: v1alpha1.LocalObjectReference{Name: "keystore-password"},
+								Key:                  "password",
+							},
+						},
+					},
+				},
+			},
+		},
+		"certificate with pkcs12 keystore missing password secret": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Keystores: &v1alpha1.CertificateKeystores{
+						PKCS12: &v1alpha1.PKCS12Keystore{},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("keystores", "pkcs12", "passwordSecretRef", "name"), "must be specified"),
+				field.Required(fldPath.Child("keystores", "pkcs12", "passwordSecretRef", "key"), "must be specified"),
+			},
+		},
+		"certificate with valid jks keystore": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Keystores: &v1alpha1.CertificateKeystores{
+						JKS: &v1alpha1.JKSKeystore{
+							PasswordSecretRef: v1alpha1.SecretKeySelector{
+								LocalObjectReference: v1alpha1.LocalObjectReference{Name: "keystore-password"},
+								Key:                  "password",
+							},
+							Alias:      "server",
+							Truststore: true,
+						},
+					},
+				},
+			},
+		},
+		"certificate with jks keystore missing password secret": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					Keystores: &v1alpha1.CertificateKeystores{
+						JKS: &v1alpha1.JKSKeystore{},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("keystores", "jks", "passwordSecretRef", "name"), "must be specified"),
+				field.Required(fldPath.Child("keystores", "jks", "passwordSecretRef", "key"), "must be specified"),
+			},
+		},
+		"certificate with delete secret deletion policy": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:           "testcn",
+					SecretName:           "abc",
+					IssuerRef:            validIssuerRef,
+					SecretDeletionPolicy: v1alpha1.SecretDeletionPolicyDelete,
+				},
+			},
+		},
+		"certificate with invalid secret deletion policy": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName:           "testcn",
+					SecretName:           "abc",
+					IssuerRef:            validIssuerRef,
+					SecretDeletionPolicy: "orphan",
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("secretDeletionPolicy"), v1alpha1.SecretDeletionPolicy("orphan"), []string{"retain", "delete"}),
+			},
+		},
+		"certificate with never private key rotation policy": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					PrivateKey: &v1alpha1.CertificatePrivateKey{
+						RotationPolicy: v1alpha1.RotationPolicyNever,
+					},
+				},
+			},
+		},
+		"certificate with invalid private key rotation policy": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					CommonName: "testcn",
+					SecretName: "abc",
+					IssuerRef:  validIssuerRef,
+					PrivateKey: &v1alpha1.CertificatePrivateKey{
+						RotationPolicy: "Sometimes",
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("privateKey", "rotationPolicy"), v1alpha1.PrivateKeyRotationPolicy("Sometimes"), []string{"Never", "Always"}),
+			},
+		},
 	}
 	for n, s := range scenarios {
 		t.Run(n, func(t *testing.T) {
@@ -687,6 +1022,51 @@ func TestValidateDuration(t *testing.T) {
 			},
 			errs: []*field.Error{field.Invalid(fldPath.Child("duration"), usefulDurations["half hour"].Duration, fmt.Sprintf("certificate duration must be greater than %s", v1alpha1.MinimumCertificateDuration))},
 		},
+		"valid renewBeforePercentage with a short duration": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					Duration:              usefulDurations["one hour"],
+					RenewBeforePercentage: int32Ptr(33),
+					CommonName:            "testcn",
+					SecretName:            "abc",
+					IssuerRef:             validIssuerRef,
+				},
+			},
+		},
+		"renewBeforePercentage set with renewBefore": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					RenewBefore:           usefulDurations["one month"],
+					RenewBeforePercentage: int32Ptr(33),
+					CommonName:            "testcn",
+					SecretName:            "abc",
+					IssuerRef:             validIssuerRef,
+				},
+			},
+			errs: []*field.Error{field.Forbidden(fldPath.Child("renewBeforePercentage"), "may not be set when renewBefore is set")},
+		},
+		"renewBeforePercentage is zero": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					RenewBeforePercentage: int32Ptr(0),
+					CommonName:            "testcn",
+					SecretName:            "abc",
+					IssuerRef:             validIssuerRef,
+				},
+			},
+			errs: []*field.Error{field.Invalid(fldPath.Child("renewBeforePercentage"), int32(0), "must be between 1 and 99")},
+		},
+		"renewBeforePercentage is 100": {
+			cfg: &v1alpha1.Certificate{
+				Spec: v1alpha1.CertificateSpec{
+					RenewBeforePercentage: int32Ptr(100),
+					CommonName:            "testcn",
+					SecretName:            "abc",
+					IssuerRef:             validIssuerRef,
+				},
+			},
+			errs: []*field.Error{field.Invalid(fldPath.Child("renewBeforePercentage"), int32(100), "must be between 1 and 99")},
+		},
 	}
 	for n, s := range scenarios {
 		t.Run(n, func(t *testing.T) {
@@ -704,3 +1084,7 @@ func TestValidateDuration(t *testing.T) {
 		})
 	}
 }
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
@@ -19,11 +19,16 @@ package validation
 import (
 	"crypto/x509"
 	"fmt"
-	"strings"
+	"net"
+	"net/url"
 
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/rfc2136"
+	dnsutil "github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/util"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
@@ -33,9 +38,28 @@ import (
 
 func ValidateIssuer(iss *v1alpha1.Issuer) field.ErrorList {
 	allErrs := ValidateIssuerSpec(&iss.Spec, field.NewPath("spec"))
+	allErrs = append(allErrs, validateNamespacedIssuerConfig(&iss.Spec.IssuerConfig, field.NewPath("spec"))...)
 	return allErrs
 }
 
+// validateNamespacedIssuerConfig checks that an Issuer does not use any
+// options that would give it access to cert-manager's own credentials, which
+// are only available to ClusterIssuers.
+func validateNamespacedIssuerConfig(iss *v1alpha1.IssuerConfig, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if iss.Vault != nil && iss.Vault.Auth.Kubernetes != nil {
+		auth := iss.Vault.Auth.Kubernetes
+		authPath := fldPath.Child("vault", "auth", "kubernetes")
+		if len(auth.SecretRef.Name) == 0 {
+			el = append(el, field.Required(authPath.Child("secretRef"), "must be specified on Issuers, which may not use the cert-manager service account token"))
+		}
+		if len(auth.TokenPath) > 0 {
+			el = append(el, field.Forbidden(authPath.Child("tokenPath"), "may only be specified on ClusterIssuers"))
+		}
+	}
+	return el
+}
+
 func ValidateIssuerSpec(iss *v1alpha1.IssuerSpec, fldPath *field.Path) field.ErrorList {
 	el := field.ErrorList{}
 	el = ValidateIssuerConfig(&iss.IssuerConfig, fldPath)
@@ -77,23 +101,65 @@ func ValidateIssuerConfig(iss *v1alpha1.IssuerConfig, fldPath *field.Path) field
 			el = append(el, ValidateVaultIssuerConfig(iss.Vault, fldPath.Child("vault"))...)
 		}
 	}
+	if iss.Venafi != nil {
+		if numConfigs > 0 {
+			el = append(el, field.Forbidden(fldPath.Child("venafi"), "may not specify more than one issuer type"))
+		} else {
+			numConfigs++
+			el = append(el, ValidateVenafiIssuerConfig(iss.Venafi, fldPath.Child("venafi"))...)
+		}
+	}
+	if iss.External != nil {
+		if numConfigs > 0 {
+			el = append(el, field.Forbidden(fldPath.Child("external"), "may not specify more than one issuer type"))
+		} else {
+			numConfigs++
+			el = append(el, ValidateExternalIssuerConfig(iss.External, fldPath.Child("external"))...)
+		}
+	}
 	if numConfigs == 0 {
 		el = append(el, field.Required(fldPath, "at least one issuer must be configured"))
 	}
+	switch iss.CredentialsFrom {
+	case v1alpha1.CredentialsSource(""), v1alpha1.CredentialsFromSecret, v1alpha1.CredentialsFromFile:
+	default:
+		el = append(el, field.NotSupported(fldPath.Child("credentialsFrom"), iss.CredentialsFrom,
+			[]string{string(v1alpha1.CredentialsFromSecret), string(v1alpha1.CredentialsFromFile)}))
+	}
+
+	if len(iss.Proxy) > 0 {
+		if _, err := util.ParseProxyURL(iss.Proxy); err != nil {
+			el = append(el, field.Invalid(fldPath.Child("proxy"), iss.Proxy, err.Error()))
+		}
+	}
 
 	return el
 }
 
 func ValidateACMEIssuerConfig(iss *v1alpha1.ACMEIssuer, fldPath *field.Path) field.ErrorList {
 	el := field.ErrorList{}
-	if len(iss.Email) == 0 {
-		el = append(el, field.Required(fldPath.Child("email"), "email address is a required field"))
+	if len(iss.Email) > 0 && len(iss.Emails) > 0 {
+		el = append(el, field.Forbidden(fldPath.Child("emails"), "may not be specified together with email"))
+	}
+	for i, email := range iss.Emails {
+		if len(email) == 0 {
+			el = append(el, field.Required(fldPath.Child("emails").Index(i), "email address may not be empty"))
+		}
 	}
 	if len(iss.PrivateKey.Name) == 0 {
 		el = append(el, field.Required(fldPath.Child("privateKeySecretRef", "name"), "private key secret name is a required field"))
 	}
 	if len(iss.Server) == 0 {
 		el = append(el, field.Required(fldPath.Child("server"), "acme server URL is a required field"))
+	} else {
+		el = append(el, validateACMEServer(iss.Server, fldPath.Child("server"))...)
+	}
+	if len(iss.CABundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(iss.CABundle) {
+		el = append(el, field.Invalid(fldPath.Child("caBundle"), "", "Specified CA bundle is invalid"))
+	}
+	el = append(el, validateACMEAccountKeyParams(iss, fldPath)...)
+	if iss.ExternalAccountBinding != nil {
+		el = append(el, validateACMEExternalAccountBinding(iss.ExternalAccountBinding, fldPath.Child("externalAccountBinding"))...)
 	}
 	if iss.HTTP01 != nil {
 		el = append(el, ValidateACMEIssuerHTTP01Config(iss.HTTP01, fldPath.Child("http01"))...)
@@ -101,6 +167,72 @@ func ValidateACMEIssuerConfig(iss *v1alpha1.ACMEIssuer, fldPath *field.Path) fie
 	if iss.DNS01 != nil {
 		el = append(el, ValidateACMEIssuerDNS01Config(iss.DNS01, fldPath.Child("dns01"))...)
 	}
+	for i, ns := range iss.DNS01RecursiveNameservers {
+		el = append(el, validateDNS01Nameserver(ns, fldPath.Child("dns01RecursiveNameservers").Index(i))...)
+	}
+	for i, s := range iss.Solvers {
+		el = append(el, validateACMEChallengeSolver(iss, &s, fldPath.Child("solvers").Index(i))...)
+	}
+	return el
+}
+
+// validateACMEChallengeSolver checks that s configures exactly one solver
+// type, that it references configuration that exists on the issuer, and that
+// its selector contains valid labels and no empty entries.
+func validateACMEChallengeSolver(iss *v1alpha1.ACMEIssuer, s *v1alpha1.ACMEChallengeSolver, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if sel := s.Selector; sel != nil {
+		el = append(el, metav1validation.ValidateLabels(sel.MatchLabels, fldPath.Child("selector", "matchLabels"))...)
+		for i, n := range sel.DNSNames {
+			if len(n) == 0 {
+				el = append(el, field.Required(fldPath.Child("selector", "dnsNames").Index(i), "dns name may not be empty"))
+			}
+		}
+		for i, z := range sel.DNSZones {
+			if len(z) == 0 {
+				el = append(el, field.Required(fldPath.Child("selector", "dnsZones").Index(i), "dns zone may not be empty"))
+			}
+		}
+	}
+	switch {
+	case s.DNS01 != nil && s.HTTP01 != nil:
+		el = append(el, field.Forbidden(fldPath.Child("http01"), "may not specify more than one solver type"))
+	case s.DNS01 != nil:
+		el = append(el, ValidateDNS01SolverConfig(s.DNS01, fldPath.Child("dns01"))...)
+		if len(s.DNS01.Provider) > 0 {
+			if _, err := iss.DNS01.Provider(s.DNS01.Provider); err != nil {
+				el = append(el, field.NotFound(fldPath.Child("dns01", "provider"), s.DNS01.Provider))
+			}
+		}
+	case s.HTTP01 != nil:
+		el = append(el, ValidateHTTP01SolverConfig(s.HTTP01, fldPath.Child("http01"))...)
+		if iss.HTTP01 == nil {
+			el = append(el, field.Forbidden(fldPath.Child("http01"), "spec.acme.http01 must be set to use http01 solvers"))
+		}
+	default:
+		el = append(el, field.Required(fldPath, "at least one solver must be configured"))
+	}
+	return el
+}
+
+// validateDNS01Nameserver checks that ns is either an IP:port pair or a
+// DNS-over-HTTPS URL.
+func validateDNS01Nameserver(ns string, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if dnsutil.IsDoHNameserver(ns) {
+		if _, err := url.Parse(ns); err != nil {
+			el = append(el, field.Invalid(fldPath, ns, fmt.Sprintf("invalid DNS-over-HTTPS server: %v", err)))
+		}
+		return el
+	}
+	host, _, err := net.SplitHostPort(ns)
+	if err != nil {
+		el = append(el, field.Invalid(fldPath, ns, "must be an IP address and port, or a DNS-over-HTTPS URL"))
+		return el
+	}
+	if net.ParseIP(host) == nil {
+		el = append(el, field.Invalid(fldPath, ns, "invalid IP address"))
+	}
 	return el
 }
 
@@ -109,6 +241,30 @@ func ValidateCAIssuerConfig(iss *v1alpha1.CAIssuer, fldPath *field.Path) field.E
 	if len(iss.SecretName) == 0 {
 		el = append(el, field.Required(fldPath.Child("secretName"), ""))
 	}
+	if iss.SerialNumber != nil {
+		el = append(el, ValidateCASerialNumber(iss.SerialNumber, fldPath.Child("serialNumber"))...)
+	}
+	return el
+}
+
+func ValidateCASerialNumber(sn *v1alpha1.CASerialNumber, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	switch sn.Strategy {
+	case "", v1alpha1.CASerialNumberRandom, v1alpha1.CASerialNumberTimestamp:
+		if len(sn.ConfigMapName) > 0 {
+			el = append(el, field.Forbidden(fldPath.Child("configMapName"), "may only be specified when using the counter strategy"))
+		}
+	case v1alpha1.CASerialNumberCounter:
+		if len(sn.ConfigMapName) == 0 {
+			el = append(el, field.Required(fldPath.Child("configMapName"), "configMapName is required when using the counter strategy"))
+		}
+	default:
+		el = append(el, field.NotSupported(fldPath.Child("strategy"), sn.Strategy, []string{
+			string(v1alpha1.CASerialNumberRandom),
+			string(v1alpha1.CASerialNumberTimestamp),
+			string(v1alpha1.CASerialNumberCounter),
+		}))
+	}
 	return el
 }
 
@@ -135,10 +291,147 @@ func ValidateVaultIssuerConfig(iss *v1alpha1.VaultIssuer, fldPath *field.Path) f
 		}
 	}
 
+	if iss.Auth.Kubernetes != nil {
+		if len(iss.Auth.TokenSecretRef.Name) > 0 || len(iss.Auth.AppRole.RoleId) > 0 {
+			el = append(el, field.Forbidden(fldPath.Child("auth", "kubernetes"), "may not be specified with another authentication method"))
+		}
+		el = append(el, ValidateVaultKubernetesAuth(iss.Auth.Kubernetes, fldPath.Child("auth", "kubernetes"))...)
+	}
+
 	return el
 	// TODO: add validation for Vault authentication types
 }
 
+func ValidateVaultKubernetesAuth(auth *v1alpha1.VaultKubernetesAuth, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if len(auth.Role) == 0 {
+		el = append(el, field.Required(fldPath.Child("role"), "vault role is a required field"))
+	}
+	if len(auth.SecretRef.Name) > 0 && len(auth.TokenPath) > 0 {
+		el = append(el, field.Forbidden(fldPath, "only one of secretRef or tokenPath may be specified"))
+	}
+	return el
+}
+
+func ValidateVenafiIssuerConfig(iss *v1alpha1.VenafiIssuer, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	if len(iss.Zone) == 0 {
+		el = append(el, field.Required(fldPath.Child("zone"), "venafi zone is a required field"))
+	}
+
+	switch {
+	case iss.TPP != nil && iss.Cloud != nil:
+		el = append(el, field.Forbidden(fldPath, "only one of tpp or cloud may be specified"))
+	case iss.TPP != nil:
+		if len(iss.TPP.URL) == 0 {
+			el = append(el, field.Required(fldPath.Child("tpp", "url"), "tpp url is a required field"))
+		} else if _, err := url.ParseRequestURI(iss.TPP.URL); err != nil {
+			el = append(el, field.Invalid(fldPath.Child("tpp", "url"), iss.TPP.URL, err.Error()))
+		}
+		if len(iss.TPP.CredentialsRef.Name) == 0 {
+			el = append(el, field.Required(fldPath.Child("tpp", "credentialsRef", "name"), "secret name is required"))
+		}
+		if len(iss.TPP.CABundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(iss.TPP.CABundle) {
+			el = append(el, field.Invalid(fldPath.Child("tpp", "caBundle"), "", "Specified CA bundle is invalid"))
+		}
+	case iss.Cloud != nil:
+		if len(iss.Cloud.URL) > 0 {
+			if _, err := url.ParseRequestURI(iss.Cloud.URL); err != nil {
+				el = append(el, field.Invalid(fldPath.Child("cloud", "url"), iss.Cloud.URL, err.Error()))
+			}
+		}
+		el = append(el, ValidateSecretKeySelector(&iss.Cloud.APITokenSecretRef, fldPath.Child("cloud", "apiTokenSecretRef"))...)
+	default:
+		el = append(el, field.Required(fldPath, "one of tpp or cloud must be specified"))
+	}
+
+	return el
+}
+
+// validateACMEAccountKeyParams validates the parameters used to generate a
+// new ACME account private key.
+// validateACMEServer checks that server is either a full URL or one of the
+// known shorthand names for an ACME server.
+func validateACMEServer(server string, fldPath *field.Path) field.ErrorList {
+	if _, ok := v1alpha1.ACMEServerAliases[server]; ok {
+		return nil
+	}
+	u, err := url.Parse(server)
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fldPath, server, []string{
+		v1alpha1.ACMEServerLetsEncrypt,
+		v1alpha1.ACMEServerLetsEncryptStaging,
+		"a full ACME directory URL",
+	})}
+}
+
+func validateACMEAccountKeyParams(iss *v1alpha1.ACMEIssuer, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	switch iss.PrivateKeyAlgorithm {
+	case v1alpha1.KeyAlgorithm(""), v1alpha1.RSAKeyAlgorithm:
+		if iss.PrivateKeySize != 0 && (iss.PrivateKeySize < 2048 || iss.PrivateKeySize > 8192) {
+			el = append(el, field.Invalid(fldPath.Child("privateKeySize"), iss.PrivateKeySize, "must be between 2048 & 8192 for rsa privateKeyAlgorithm"))
+		}
+		if iss.PrivateKeyCurve != "" {
+			el = append(el, field.Invalid(fldPath.Child("privateKeyCurve"), iss.PrivateKeyCurve, "may only be set for ecdsa privateKeyAlgorithm"))
+		}
+	case v1alpha1.ECDSAKeyAlgorithm:
+		if iss.PrivateKeySize != 0 {
+			el = append(el, field.Invalid(fldPath.Child("privateKeySize"), iss.PrivateKeySize, "may only be set for rsa privateKeyAlgorithm, use privateKeyCurve instead"))
+		}
+		switch iss.PrivateKeyCurve {
+		case v1alpha1.KeyCurve(""), v1alpha1.P256KeyCurve, v1alpha1.P384KeyCurve, v1alpha1.P521KeyCurve:
+		default:
+			el = append(el, field.NotSupported(fldPath.Child("privateKeyCurve"), iss.PrivateKeyCurve, []string{string(v1alpha1.P256KeyCurve), string(v1alpha1.P384KeyCurve), string(v1alpha1.P521KeyCurve)}))
+		}
+	default:
+		el = append(el, field.Invalid(fldPath.Child("privateKeyAlgorithm"), iss.PrivateKeyAlgorithm, "must be either empty or one of rsa or ecdsa"))
+	}
+	return el
+}
+
+func validateACMEExternalAccountBinding(eab *v1alpha1.ACMEExternalAccountBinding, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if len(eab.KeyID) == 0 {
+		el = append(el, field.Required(fldPath.Child("keyID"), "external account binding key ID is a required field"))
+	}
+	el = append(el, ValidateSecretKeySelector(&eab.Key, fldPath.Child("keySecretRef"))...)
+	switch eab.KeyAlgorithm {
+	case v1alpha1.HS256, v1alpha1.HS384, v1alpha1.HS512:
+	case v1alpha1.HMACKeyAlgorithm(""):
+		el = append(el, field.Required(fldPath.Child("keyAlgorithm"), "external account binding key algorithm is a required field"))
+	default:
+		el = append(el, field.NotSupported(fldPath.Child("keyAlgorithm"), eab.KeyAlgorithm, []string{string(v1alpha1.HS256), string(v1alpha1.HS384), string(v1alpha1.HS512)}))
+	}
+	return el
+}
+
+func ValidateExternalIssuerConfig(iss *v1alpha1.ExternalIssuer, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	if len(iss.URL) == 0 {
+		el = append(el, field.Required(fldPath.Child("url"), "external issuer url is a required field"))
+	} else if u, err := url.ParseRequestURI(iss.URL); err != nil {
+		el = append(el, field.Invalid(fldPath.Child("url"), iss.URL, err.Error()))
+	} else if u.Scheme != "https" && u.Scheme != "http" {
+		el = append(el, field.Invalid(fldPath.Child("url"), iss.URL, "url scheme must be one of 'https' or 'http'"))
+	}
+	if len(iss.CABundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(iss.CABundle) {
+		el = append(el, field.Invalid(fldPath.Child("caBundle"), "", "Specified CA bundle is invalid"))
+	}
+	if iss.ClientCertSecretRef != nil && len(iss.ClientCertSecretRef.Name) == 0 {
+		el = append(el, field.Required(fldPath.Child("clientCertSecretRef", "name"), "secret name is required"))
+	}
+	if iss.Timeout != nil && iss.Timeout.Duration <= 0 {
+		el = append(el, field.Invalid(fldPath.Child("timeout"), iss.Timeout.Duration.String(), "timeout must be greater than zero"))
+	}
+
+	return el
+}
+
 func ValidateACMEIssuerHTTP01Config(iss *v1alpha1.ACMEIssuerHTTP01Config, fldPath *field.Path) field.ErrorList {
 	el := field.ErrorList{}
 
@@ -146,6 +439,7 @@ func ValidateACMEIssuerHTTP01Config(iss *v1alpha1.ACMEIssuerHTTP01Config, fldPat
 		validTypes := []corev1.ServiceType{
 			corev1.ServiceTypeClusterIP,
 			corev1.ServiceTypeNodePort,
+			corev1.ServiceTypeLoadBalancer,
 		}
 		validType := false
 		for _, validTypeName := range validTypes {
@@ -159,6 +453,67 @@ func ValidateACMEIssuerHTTP01Config(iss *v1alpha1.ACMEIssuerHTTP01Config, fldPat
 		}
 	}
 
+	for k, v := range iss.NodeSelector {
+		for _, msg := range validation.IsQualifiedName(k) {
+			el = append(el, field.Invalid(fldPath.Child("nodeSelector"), k, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(v) {
+			el = append(el, field.Invalid(fldPath.Child("nodeSelector").Key(k), v, msg))
+		}
+	}
+
+	el = append(el, ValidateTolerations(iss.Tolerations, fldPath.Child("tolerations"))...)
+
+	for i, s := range iss.ImagePullSecrets {
+		if len(s.Name) == 0 {
+			el = append(el, field.Required(fldPath.Child("imagePullSecrets").Index(i).Child("name"), "secret name is required"))
+		}
+	}
+
+	return el
+}
+
+// ValidateTolerations validates a list of pod tolerations, following the
+// same rules the Kubernetes API server applies to a PodSpec.
+func ValidateTolerations(tolerations []corev1.Toleration, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	for i, t := range tolerations {
+		idxPath := fldPath.Index(i)
+		if len(t.Key) > 0 {
+			for _, msg := range validation.IsQualifiedName(t.Key) {
+				el = append(el, field.Invalid(idxPath.Child("key"), t.Key, msg))
+			}
+		}
+		if len(t.Key) == 0 && t.Operator != corev1.TolerationOpExists {
+			el = append(el, field.Invalid(idxPath.Child("operator"), t.Operator, "operator must be Exists when key is empty"))
+		}
+		if t.TolerationSeconds != nil && t.Effect != corev1.TaintEffectNoExecute {
+			el = append(el, field.Invalid(idxPath.Child("effect"), t.Effect, "effect must be 'NoExecute' when tolerationSeconds is set"))
+		}
+
+		switch t.Operator {
+		case corev1.TolerationOpEqual, "":
+			for _, msg := range validation.IsValidLabelValue(t.Value) {
+				el = append(el, field.Invalid(idxPath.Child("value"), t.Value, msg))
+			}
+		case corev1.TolerationOpExists:
+			if len(t.Value) > 0 {
+				el = append(el, field.Invalid(idxPath.Child("value"), t.Value, "value must be empty when operator is Exists"))
+			}
+		default:
+			el = append(el, field.NotSupported(idxPath.Child("operator"), t.Operator, []string{string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists)}))
+		}
+
+		switch t.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			el = append(el, field.NotSupported(idxPath.Child("effect"), t.Effect, []string{
+				string(corev1.TaintEffectNoSchedule),
+				string(corev1.TaintEffectPreferNoSchedule),
+				string(corev1.TaintEffectNoExecute),
+			}))
+		}
+	}
 	return el
 }
 
@@ -180,6 +535,9 @@ func ValidateACMEIssuerDNS01Config(iss *v1alpha1.ACMEIssuerDNS01Config, fldPath
 				el = append(el, field.Invalid(fldPath.Child("cnameStrategy"), p.CNAMEStrategy, fmt.Sprintf("must be one of %q or %q", v1alpha1.NoneStrategy, v1alpha1.FollowStrategy)))
 			}
 		}
+		if p.TTL < 0 {
+			el = append(el, field.Invalid(fldPath.Child("ttl"), p.TTL, "must not be negative"))
+		}
 		numProviders := 0
 		if p.Akamai != nil {
 			numProviders++
@@ -195,16 +553,24 @@ func ValidateACMEIssuerDNS01Config(iss *v1alpha1.ACMEIssuerDNS01Config, fldPath
 				el = append(el, field.Forbidden(fldPath.Child("azuredns"), "may not specify more than one provider type"))
 			} else {
 				numProviders++
-				el = append(el, ValidateSecretKeySelector(&p.AzureDNS.ClientSecret, fldPath.Child("azuredns", "clientSecretSecretRef"))...)
-				if len(p.AzureDNS.ClientID) == 0 {
-					el = append(el, field.Required(fldPath.Child("azuredns", "clientID"), ""))
+				// if either of clientSecretSecretRef.name or
+				// clientSecretSecretRef.key is set, a service principal is
+				// used. Otherwise we fall back to the host's managed identity.
+				if p.AzureDNS.ClientSecret.Name != "" || p.AzureDNS.ClientSecret.Key != "" {
+					el = append(el, ValidateSecretKeySelector(&p.AzureDNS.ClientSecret, fldPath.Child("azuredns", "clientSecretSecretRef"))...)
+					if len(p.AzureDNS.ClientID) == 0 {
+						el = append(el, field.Required(fldPath.Child("azuredns", "clientID"), ""))
+					}
+					if len(p.AzureDNS.TenantID) == 0 {
+						el = append(el, field.Required(fldPath.Child("azuredns", "tenantID"), ""))
+					}
+					if p.AzureDNS.ManagedIdentity != nil {
+						el = append(el, field.Forbidden(fldPath.Child("azuredns", "managedIdentity"), "may not be set when clientSecretSecretRef is set"))
+					}
 				}
 				if len(p.AzureDNS.SubscriptionID) == 0 {
 					el = append(el, field.Required(fldPath.Child("azuredns", "subscriptionID"), ""))
 				}
-				if len(p.AzureDNS.TenantID) == 0 {
-					el = append(el, field.Required(fldPath.Child("azuredns", "tenantID"), ""))
-				}
 				if len(p.AzureDNS.ResourceGroupName) == 0 {
 					el = append(el, field.Required(fldPath.Child("azuredns", "resourceGroupName"), ""))
 				}
@@ -230,9 +596,17 @@ func ValidateACMEIssuerDNS01Config(iss *v1alpha1.ACMEIssuerDNS01Config, fldPath
 				el = append(el, field.Forbidden(fldPath.Child("cloudflare"), "may not specify more than one provider type"))
 			} else {
 				numProviders++
-				el = append(el, ValidateSecretKeySelector(&p.Cloudflare.APIKey, fldPath.Child("cloudflare", "apiKeySecretRef"))...)
-				if len(p.Cloudflare.Email) == 0 {
-					el = append(el, field.Required(fldPath.Child("cloudflare", "email"), ""))
+				apiKeySet := p.Cloudflare.APIKey.Name != "" || p.Cloudflare.APIKey.Key != ""
+				switch {
+				case p.Cloudflare.APIToken != nil && apiKeySet:
+					el = append(el, field.Forbidden(fldPath.Child("cloudflare"), "only one of apiKeySecretRef or apiTokenSecretRef may be specified"))
+				case p.Cloudflare.APIToken != nil:
+					el = append(el, ValidateSecretKeySelector(p.Cloudflare.APIToken, fldPath.Child("cloudflare", "apiTokenSecretRef"))...)
+				default:
+					el = append(el, ValidateSecretKeySelector(&p.Cloudflare.APIKey, fldPath.Child("cloudflare", "apiKeySecretRef"))...)
+					if len(p.Cloudflare.Email) == 0 {
+						el = append(el, field.Required(fldPath.Child("cloudflare", "email"), ""))
+					}
 				}
 			}
 		}
@@ -245,6 +619,9 @@ func ValidateACMEIssuerDNS01Config(iss *v1alpha1.ACMEIssuerDNS01Config, fldPath
 				if len(p.Route53.Region) == 0 {
 					el = append(el, field.Required(fldPath.Child("route53", "region"), ""))
 				}
+				if len(p.Route53.ExternalID) > 0 && len(p.Route53.Role) == 0 {
+					el = append(el, field.Required(fldPath.Child("route53", "role"), "role must be set when externalID is specified"))
+				}
 			}
 		}
 		if p.AcmeDNS != nil {
@@ -277,13 +654,7 @@ func ValidateACMEIssuerDNS01Config(iss *v1alpha1.ACMEIssuerDNS01Config, fldPath
 					}
 				}
 				if len(p.RFC2136.TSIGAlgorithm) > 0 {
-					present := false
-					for _, b := range rfc2136.GetSupportedAlgorithms() {
-						if b == strings.ToUpper(p.RFC2136.TSIGAlgorithm) {
-							present = true
-						}
-					}
-					if !present {
+					if _, err := rfc2136.TSIGAlgorithm(p.RFC2136.TSIGAlgorithm); err != nil {
 						el = append(el, field.NotSupported(fldPath.Child("rfc2136", "tsigAlgorithm"), "", rfc2136.GetSupportedAlgorithms()))
 					}
 				}
@@ -299,6 +670,27 @@ func ValidateACMEIssuerDNS01Config(iss *v1alpha1.ACMEIssuerDNS01Config, fldPath
 				}
 			}
 		}
+		if p.Hetzner != nil {
+			if numProviders > 0 {
+				el = append(el, field.Forbidden(fldPath.Child("hetzner"), "may not specify more than one provider type"))
+			} else {
+				numProviders++
+				el = append(el, ValidateSecretKeySelector(&p.Hetzner.APIToken, fldPath.Child("hetzner", "apiTokenSecretRef"))...)
+			}
+		}
+		if p.Webhook != nil {
+			if numProviders > 0 {
+				el = append(el, field.Forbidden(fldPath.Child("webhook"), "may not specify more than one provider type"))
+			} else {
+				numProviders++
+				if len(p.Webhook.GroupName) == 0 {
+					el = append(el, field.Required(fldPath.Child("webhook", "groupName"), ""))
+				}
+				if len(p.Webhook.SolverName) == 0 {
+					el = append(el, field.Required(fldPath.Child("webhook", "solverName"), ""))
+				}
+			}
+		}
 		if numProviders == 0 {
 			el = append(el, field.Required(fldPath, "at least one provider must be configured"))
 		}
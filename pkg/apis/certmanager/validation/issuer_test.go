@@ -19,8 +19,10 @@ package validation
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
@@ -44,14 +46,14 @@ var (
 	}
 	validACMEIssuer = v1alpha1.ACMEIssuer{
 		Email:      "valid-email",
-		Server:     "valid-server",
+		Server:     "https://acme.example.com/directory",
 		PrivateKey: validSecretKeyRef,
 	}
 	validVaultIssuer = v1alpha1.VaultIssuer{
 		Auth: v1alpha1.VaultAuth{
 			TokenSecretRef: validSecretKeyRef,
 		},
-		Server: "something",
+		Server: "https://acme.example.com/directory",
 		Path:   "a/b/c",
 	}
 )
@@ -74,7 +76,7 @@ func TestValidateVaultIssuerConfig(t *testing.T) {
 		},
 		"vault issuer with invalid fields": {
 			spec: &v1alpha1.VaultIssuer{
-				Server:   "something",
+				Server:   "https://acme.example.com/directory",
 				Path:     "a/b/c",
 				CABundle: []byte("invalid"),
 			},
@@ -82,6 +84,49 @@ func TestValidateVaultIssuerConfig(t *testing.T) {
 				field.Invalid(fldPath.Child("caBundle"), "", "Specified CA bundle is invalid"),
 			},
 		},
+		"valid vault issuer with kubernetes auth": {
+			spec: &v1alpha1.VaultIssuer{
+				Server: "https://acme.example.com/directory",
+				Path:   "a/b/c",
+				Auth: v1alpha1.VaultAuth{
+					Kubernetes: &v1alpha1.VaultKubernetesAuth{
+						Role:      "cert-manager",
+						SecretRef: validSecretKeyRef,
+					},
+				},
+			},
+		},
+		"vault issuer with kubernetes auth and another auth method": {
+			spec: &v1alpha1.VaultIssuer{
+				Server: "https://acme.example.com/directory",
+				Path:   "a/b/c",
+				Auth: v1alpha1.VaultAuth{
+					TokenSecretRef: validSecretKeyRef,
+					Kubernetes: &v1alpha1.VaultKubernetesAuth{
+						Role: "cert-manager",
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("auth", "kubernetes"), "may not be specified with another authentication method"),
+			},
+		},
+		"vault issuer with invalid kubernetes auth": {
+			spec: &v1alpha1.VaultIssuer{
+				Server: "https://acme.example.com/directory",
+				Path:   "a/b/c",
+				Auth: v1alpha1.VaultAuth{
+					Kubernetes: &v1alpha1.VaultKubernetesAuth{
+						SecretRef: validSecretKeyRef,
+						TokenPath: "/var/run/secrets/token",
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("auth", "kubernetes", "role"), "vault role is a required field"),
+				field.Forbidden(fldPath.Child("auth", "kubernetes"), "only one of secretRef or tokenPath may be specified"),
+			},
+		},
 	}
 	for n, s := range scenarios {
 		t.Run(n, func(t *testing.T) {
@@ -100,6 +145,81 @@ func TestValidateVaultIssuerConfig(t *testing.T) {
 	}
 }
 
+func TestValidateCAIssuerConfig(t *testing.T) {
+	fldPath := field.NewPath("")
+	scenarios := map[string]struct {
+		spec *v1alpha1.CAIssuer
+		errs []*field.Error
+	}{
+		"valid ca issuer": {
+			spec: &v1alpha1.CAIssuer{SecretName: "ca"},
+		},
+		"ca issuer with missing secret name": {
+			spec: &v1alpha1.CAIssuer{},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("secretName"), ""),
+			},
+		},
+		"ca issuer with timestamp serial numbers": {
+			spec: &v1alpha1.CAIssuer{
+				SecretName:   "ca",
+				SerialNumber: &v1alpha1.CASerialNumber{Strategy: v1alpha1.CASerialNumberTimestamp},
+			},
+		},
+		"ca issuer with counter serial numbers": {
+			spec: &v1alpha1.CAIssuer{
+				SecretName: "ca",
+				SerialNumber: &v1alpha1.CASerialNumber{
+					Strategy:      v1alpha1.CASerialNumberCounter,
+					ConfigMapName: "ca-serial",
+				},
+			},
+		},
+		"ca issuer with counter serial numbers missing a configmap": {
+			spec: &v1alpha1.CAIssuer{
+				SecretName:   "ca",
+				SerialNumber: &v1alpha1.CASerialNumber{Strategy: v1alpha1.CASerialNumberCounter},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("serialNumber", "configMapName"), "configMapName is required when using the counter strategy"),
+			},
+		},
+		"ca issuer with a configmap for random serial numbers": {
+			spec: &v1alpha1.CAIssuer{
+				SecretName:   "ca",
+				SerialNumber: &v1alpha1.CASerialNumber{ConfigMapName: "ca-serial"},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("serialNumber", "configMapName"), "may only be specified when using the counter strategy"),
+			},
+		},
+		"ca issuer with an unknown serial number strategy": {
+			spec: &v1alpha1.CAIssuer{
+				SecretName:   "ca",
+				SerialNumber: &v1alpha1.CASerialNumber{Strategy: "sequential"},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("serialNumber", "strategy"), v1alpha1.CASerialNumberStrategy("sequential"), []string{"random", "timestamp", "counter"}),
+			},
+		},
+	}
+	for n, s := range scenarios {
+		t.Run(n, func(t *testing.T) {
+			errs := ValidateCAIssuerConfig(s.spec, fldPath)
+			if len(errs) != len(s.errs) {
+				t.Errorf("Expected %v but got %v", s.errs, errs)
+				return
+			}
+			for i, e := range errs {
+				expectedErr := s.errs[i]
+				if !reflect.DeepEqual(e, expectedErr) {
+					t.Errorf("Expected %v but got %v", expectedErr, e)
+				}
+			}
+		})
+	}
+}
+
 func TestValidateACMEIssuerConfig(t *testing.T) {
 	fldPath := field.NewPath("")
 	scenarios := map[string]struct {
@@ -112,15 +232,35 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 		"acme issuer with missing fields": {
 			spec: &v1alpha1.ACMEIssuer{},
 			errs: []*field.Error{
-				field.Required(fldPath.Child("email"), "email address is a required field"),
 				field.Required(fldPath.Child("privateKeySecretRef", "name"), "private key secret name is a required field"),
 				field.Required(fldPath.Child("server"), "acme server URL is a required field"),
 			},
 		},
+		"acme issuer with lets-encrypt server shorthand": {
+			spec: &v1alpha1.ACMEIssuer{
+				Server:     "lets-encrypt",
+				PrivateKey: validSecretKeyRef,
+			},
+		},
+		"acme issuer with lets-encrypt-staging server shorthand": {
+			spec: &v1alpha1.ACMEIssuer{
+				Server:     "lets-encrypt-staging",
+				PrivateKey: validSecretKeyRef,
+			},
+		},
+		"acme issuer with unknown server shorthand": {
+			spec: &v1alpha1.ACMEIssuer{
+				Server:     "lets-encrypt-prod",
+				PrivateKey: validSecretKeyRef,
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("server"), "lets-encrypt-prod", []string{"lets-encrypt", "lets-encrypt-staging", "a full ACME directory URL"}),
+			},
+		},
 		"acme issuer with invalid dns01 config": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				DNS01: &v1alpha1.ACMEIssuerDNS01Config{
 					Providers: []v1alpha1.ACMEIssuerDNS01Provider{
@@ -137,7 +277,7 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 		"acme issuer with valid dns01 config": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				DNS01: &v1alpha1.ACMEIssuerDNS01Config{
 					Providers: []v1alpha1.ACMEIssuerDNS01Provider{
@@ -149,10 +289,138 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 				},
 			},
 		},
+		"acme issuer with valid dns01 recursive nameservers": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:                     "valid-email",
+				Server:                    "https://acme.example.com/directory",
+				PrivateKey:                validSecretKeyRef,
+				DNS01RecursiveNameservers: []string{"10.0.0.53:53", "https://1.1.1.1/dns-query"},
+			},
+		},
+		"acme issuer with invalid dns01 recursive nameservers": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:                     "valid-email",
+				Server:                    "https://acme.example.com/directory",
+				PrivateKey:                validSecretKeyRef,
+				DNS01RecursiveNameservers: []string{"10.0.0.53", "ns.example.com:53"},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("dns01RecursiveNameservers").Index(0), "10.0.0.53", "must be an IP address and port, or a DNS-over-HTTPS URL"),
+				field.Invalid(fldPath.Child("dns01RecursiveNameservers").Index(1), "ns.example.com:53", "invalid IP address"),
+			},
+		},
+		"acme issuer with multiple emails": {
+			spec: &v1alpha1.ACMEIssuer{
+				Emails:     []string{"one@example.com", "two@example.com"},
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+			},
+		},
+		"acme issuer with invalid emails": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Emails:     []string{"one@example.com", ""},
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("emails"), "may not be specified together with email"),
+				field.Required(fldPath.Child("emails").Index(1), "email address may not be empty"),
+			},
+		},
+		"acme issuer with valid ecdsa account key params": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:               "valid-email",
+				Server:              "https://acme.example.com/directory",
+				PrivateKey:          validSecretKeyRef,
+				PrivateKeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+				PrivateKeyCurve:     v1alpha1.P384KeyCurve,
+			},
+		},
+		"acme issuer with invalid rsa account key params": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:               "valid-email",
+				Server:              "https://acme.example.com/directory",
+				PrivateKey:          validSecretKeyRef,
+				PrivateKeyAlgorithm: v1alpha1.RSAKeyAlgorithm,
+				PrivateKeySize:      1024,
+				PrivateKeyCurve:     v1alpha1.P256KeyCurve,
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("privateKeySize"), 1024, "must be between 2048 & 8192 for rsa privateKeyAlgorithm"),
+				field.Invalid(fldPath.Child("privateKeyCurve"), v1alpha1.P256KeyCurve, "may only be set for ecdsa privateKeyAlgorithm"),
+			},
+		},
+		"acme issuer with invalid ecdsa account key params": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:               "valid-email",
+				Server:              "https://acme.example.com/directory",
+				PrivateKey:          validSecretKeyRef,
+				PrivateKeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+				PrivateKeySize:      384,
+				PrivateKeyCurve:     v1alpha1.KeyCurve("P192"),
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("privateKeySize"), 384, "may only be set for rsa privateKeyAlgorithm, use privateKeyCurve instead"),
+				field.NotSupported(fldPath.Child("privateKeyCurve"), v1alpha1.KeyCurve("P192"), []string{"P256", "P384", "P521"}),
+			},
+		},
+		"acme issuer with unsupported account key algorithm": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:               "valid-email",
+				Server:              "https://acme.example.com/directory",
+				PrivateKey:          validSecretKeyRef,
+				PrivateKeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("privateKeyAlgorithm"), v1alpha1.Ed25519KeyAlgorithm, "must be either empty or one of rsa or ecdsa"),
+			},
+		},
+		"acme issuer with valid external account binding": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				ExternalAccountBinding: &v1alpha1.ACMEExternalAccountBinding{
+					KeyID:        "valid-key-id",
+					Key:          validSecretKeyRef,
+					KeyAlgorithm: v1alpha1.HS256,
+				},
+			},
+		},
+		"acme issuer with incomplete external account binding": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:                  "valid-email",
+				Server:                 "https://acme.example.com/directory",
+				PrivateKey:             validSecretKeyRef,
+				ExternalAccountBinding: &v1alpha1.ACMEExternalAccountBinding{},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("externalAccountBinding", "keyID"), "external account binding key ID is a required field"),
+				field.Required(fldPath.Child("externalAccountBinding", "keySecretRef", "name"), "secret name is required"),
+				field.Required(fldPath.Child("externalAccountBinding", "keySecretRef", "key"), "secret key is required"),
+				field.Required(fldPath.Child("externalAccountBinding", "keyAlgorithm"), "external account binding key algorithm is a required field"),
+			},
+		},
+		"acme issuer with unsupported external account binding algorithm": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				ExternalAccountBinding: &v1alpha1.ACMEExternalAccountBinding{
+					KeyID:        "valid-key-id",
+					Key:          validSecretKeyRef,
+					KeyAlgorithm: v1alpha1.HMACKeyAlgorithm("HS1"),
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("externalAccountBinding", "keyAlgorithm"), v1alpha1.HMACKeyAlgorithm("HS1"), []string{"HS256", "HS384", "HS512"}),
+			},
+		},
 		"acme issuer with valid http01 config": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				HTTP01:     &v1alpha1.ACMEIssuerHTTP01Config{},
 			},
@@ -160,7 +428,7 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 		"acme issue with valid http01 service config serviceType ClusterIP": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
 					ServiceType: corev1.ServiceType("ClusterIP"),
@@ -170,34 +438,168 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 		"acme issue with valid http01 service config serviceType NodePort": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
 					ServiceType: corev1.ServiceType("NodePort"),
 				},
 			},
 		},
+		"acme issue with valid http01 service config serviceType LoadBalancer": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
+					ServiceType: corev1.ServiceType("LoadBalancer"),
+				},
+			},
+		},
 		"acme issue with valid http01 service config serviceType (empty string)": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
 					ServiceType: corev1.ServiceType(""),
 				},
 			},
 		},
+		"acme issuer with valid http01 scheduling config": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
+					NodeSelector: map[string]string{"kubernetes.io/role": "ingress"},
+					Tolerations: []corev1.Toleration{
+						{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ingress", Effect: corev1.TaintEffectNoSchedule},
+						{Key: "node.kubernetes.io/unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: int64Ptr(30)},
+						{Operator: corev1.TolerationOpExists},
+					},
+				},
+			},
+		},
+		"acme issuer with invalid http01 tolerations": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
+					Tolerations: []corev1.Toleration{
+						{Value: "ingress"},
+						{Key: "dedicated", Operator: corev1.TolerationOpExists, Value: "ingress"},
+						{Key: "dedicated", Operator: "In", Effect: "NoRun"},
+						{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule, TolerationSeconds: int64Ptr(30)},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("http01", "tolerations").Index(0).Child("operator"), corev1.TolerationOperator(""), "operator must be Exists when key is empty"),
+				field.Invalid(fldPath.Child("http01", "tolerations").Index(1).Child("value"), "ingress", "value must be empty when operator is Exists"),
+				field.NotSupported(fldPath.Child("http01", "tolerations").Index(2).Child("operator"), corev1.TolerationOperator("In"), []string{"Equal", "Exists"}),
+				field.NotSupported(fldPath.Child("http01", "tolerations").Index(2).Child("effect"), corev1.TaintEffect("NoRun"), []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}),
+				field.Invalid(fldPath.Child("http01", "tolerations").Index(3).Child("effect"), corev1.TaintEffectNoSchedule, "effect must be 'NoExecute' when tolerationSeconds is set"),
+			},
+		},
+		"acme issuer with invalid http01 image pull secrets": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}, {}},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("http01", "imagePullSecrets").Index(1).Child("name"), "secret name is required"),
+			},
+		},
 		"acme issue with invalid http01 service config": {
 			spec: &v1alpha1.ACMEIssuer{
 				Email:      "valid-email",
-				Server:     "valid-server",
+				Server:     "https://acme.example.com/directory",
 				PrivateKey: validSecretKeyRef,
 				HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
 					ServiceType: corev1.ServiceType("InvalidServiceType"),
 				},
 			},
 			errs: []*field.Error{
-				field.Invalid(fldPath.Child("http01", "serviceType"), corev1.ServiceType("InvalidServiceType"), "optional field serviceType must be one of [\"ClusterIP\" \"NodePort\"]"),
+				field.Invalid(fldPath.Child("http01", "serviceType"), corev1.ServiceType("InvalidServiceType"), "optional field serviceType must be one of [\"ClusterIP\" \"NodePort\" \"LoadBalancer\"]"),
+			},
+		},
+		"acme issuer with invalid CA bundle": {
+			spec: &v1alpha1.ACMEIssuer{
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				CABundle:   []byte("not a certificate"),
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("caBundle"), "", "Specified CA bundle is invalid"),
+			},
+		},
+		"acme issuer with valid solvers": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				HTTP01:     &v1alpha1.ACMEIssuerHTTP01Config{},
+				DNS01: &v1alpha1.ACMEIssuerDNS01Config{
+					Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+						{
+							Name:     "clouddns",
+							CloudDNS: &validCloudDNSProvider,
+						},
+					},
+				},
+				Solvers: []v1alpha1.ACMEChallengeSolver{
+					{
+						Selector: &v1alpha1.CertificateDNSNameSelector{
+							DNSZones: []string{"example.com"},
+						},
+						SolverConfig: v1alpha1.SolverConfig{
+							DNS01: &v1alpha1.DNS01SolverConfig{Provider: "clouddns"},
+						},
+					},
+					{
+						SolverConfig: v1alpha1.SolverConfig{
+							HTTP01: &v1alpha1.HTTP01SolverConfig{},
+						},
+					},
+				},
+			},
+		},
+		"acme issuer with invalid solvers": {
+			spec: &v1alpha1.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "https://acme.example.com/directory",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []v1alpha1.ACMEChallengeSolver{
+					{
+						Selector: &v1alpha1.CertificateDNSNameSelector{
+							MatchLabels: map[string]string{"exposure": "internal/public"},
+							DNSNames:    []string{""},
+							DNSZones:    []string{"example.com", ""},
+						},
+						SolverConfig: v1alpha1.SolverConfig{
+							DNS01: &v1alpha1.DNS01SolverConfig{Provider: "route53"},
+						},
+					},
+					{
+						SolverConfig: v1alpha1.SolverConfig{
+							HTTP01: &v1alpha1.HTTP01SolverConfig{},
+						},
+					},
+					{},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("solvers").Index(0).Child("selector", "matchLabels"), "internal/public", "a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')"),
+				field.Required(fldPath.Child("solvers").Index(0).Child("selector", "dnsNames").Index(0), "dns name may not be empty"),
+				field.Required(fldPath.Child("solvers").Index(0).Child("selector", "dnsZones").Index(1), "dns zone may not be empty"),
+				field.NotFound(fldPath.Child("solvers").Index(0).Child("dns01", "provider"), "route53"),
+				field.Forbidden(fldPath.Child("solvers").Index(1).Child("http01"), "spec.acme.http01 must be set to use http01 solvers"),
+				field.Required(fldPath.Child("solvers").Index(2), "at least one solver must be configured"),
 			},
 		},
 	}
@@ -218,6 +620,150 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 	}
 }
 
+func TestValidateVenafiIssuerConfig(t *testing.T) {
+	fldPath := field.NewPath("")
+	scenarios := map[string]struct {
+		spec *v1alpha1.VenafiIssuer
+		errs []*field.Error
+	}{
+		"valid tpp issuer": {
+			spec: &v1alpha1.VenafiIssuer{
+				Zone: "devops\\cert-manager",
+				TPP: &v1alpha1.VenafiTPP{
+					URL:            "https://tpp.example.com/vedsdk",
+					CredentialsRef: v1alpha1.LocalObjectReference{Name: "tpp-credentials"},
+				},
+			},
+		},
+		"valid cloud issuer": {
+			spec: &v1alpha1.VenafiIssuer{
+				Zone: "Default",
+				Cloud: &v1alpha1.VenafiCloud{
+					APITokenSecretRef: validSecretKeyRef,
+				},
+			},
+		},
+		"venafi issuer with missing fields": {
+			spec: &v1alpha1.VenafiIssuer{},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("zone"), "venafi zone is a required field"),
+				field.Required(fldPath, "one of tpp or cloud must be specified"),
+			},
+		},
+		"venafi issuer with both tpp and cloud": {
+			spec: &v1alpha1.VenafiIssuer{
+				Zone: "Default",
+				TPP: &v1alpha1.VenafiTPP{
+					URL:            "https://tpp.example.com/vedsdk",
+					CredentialsRef: v1alpha1.LocalObjectReference{Name: "tpp-credentials"},
+				},
+				Cloud: &v1alpha1.VenafiCloud{
+					APITokenSecretRef: validSecretKeyRef,
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath, "only one of tpp or cloud may be specified"),
+			},
+		},
+		"tpp issuer with invalid fields": {
+			spec: &v1alpha1.VenafiIssuer{
+				Zone: "Default",
+				TPP: &v1alpha1.VenafiTPP{
+					CABundle: []byte("invalid"),
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("tpp", "url"), "tpp url is a required field"),
+				field.Required(fldPath.Child("tpp", "credentialsRef", "name"), "secret name is required"),
+				field.Invalid(fldPath.Child("tpp", "caBundle"), "", "Specified CA bundle is invalid"),
+			},
+		},
+		"cloud issuer with missing api token": {
+			spec: &v1alpha1.VenafiIssuer{
+				Zone:  "Default",
+				Cloud: &v1alpha1.VenafiCloud{},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("cloud", "apiTokenSecretRef", "name"), "secret name is required"),
+				field.Required(fldPath.Child("cloud", "apiTokenSecretRef", "key"), "secret key is required"),
+			},
+		},
+	}
+	for n, s := range scenarios {
+		t.Run(n, func(t *testing.T) {
+			errs := ValidateVenafiIssuerConfig(s.spec, fldPath)
+			if len(errs) != len(s.errs) {
+				t.Errorf("Expected %v but got %v", s.errs, errs)
+				return
+			}
+			for i, e := range errs {
+				expectedErr := s.errs[i]
+				if !reflect.DeepEqual(e, expectedErr) {
+					t.Errorf("Expected %v but got %v", expectedErr, e)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateExternalIssuerConfig(t *testing.T) {
+	fldPath := field.NewPath("")
+	scenarios := map[string]struct {
+		spec *v1alpha1.ExternalIssuer
+		errs []*field.Error
+	}{
+		"valid external issuer": {
+			spec: &v1alpha1.ExternalIssuer{
+				URL:                 "https://pki.example.com/sign",
+				ClientCertSecretRef: &v1alpha1.LocalObjectReference{Name: "client-cert"},
+				Timeout:             &metav1.Duration{Duration: time.Minute},
+			},
+		},
+		"external issuer with missing url": {
+			spec: &v1alpha1.ExternalIssuer{},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("url"), "external issuer url is a required field"),
+			},
+		},
+		"external issuer with unsupported url scheme": {
+			spec: &v1alpha1.ExternalIssuer{
+				URL: "ftp://pki.example.com/sign",
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("url"), "ftp://pki.example.com/sign", "url scheme must be one of 'https' or 'http'"),
+			},
+		},
+		"external issuer with invalid fields": {
+			spec: &v1alpha1.ExternalIssuer{
+				URL:                 "https://pki.example.com/sign",
+				CABundle:            []byte("invalid"),
+				ClientCertSecretRef: &v1alpha1.LocalObjectReference{},
+				Timeout:             &metav1.Duration{},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("caBundle"), "", "Specified CA bundle is invalid"),
+				field.Required(fldPath.Child("clientCertSecretRef", "name"), "secret name is required"),
+				field.Invalid(fldPath.Child("timeout"), "0s", "timeout must be greater than zero"),
+			},
+		},
+	}
+	for n, s := range scenarios {
+		t.Run(n, func(t *testing.T) {
+			errs := ValidateExternalIssuerConfig(s.spec, fldPath)
+			if len(errs) != len(s.errs) {
+				t.Errorf("Expected %v but got %v", s.errs, errs)
+				return
+			}
+			for i, e := range errs {
+				expectedErr := s.errs[i]
+				if !reflect.DeepEqual(e, expectedErr) {
+					t.Errorf("Expected %v but got %v", expectedErr, e)
+				}
+			}
+		})
+	}
+}
+
 func TestValidateIssuerSpec(t *testing.T) {
 	fldPath := field.NewPath("")
 	scenarios := map[string]struct {
@@ -262,6 +808,44 @@ func TestValidateIssuerSpec(t *testing.T) {
 				},
 			},
 		},
+		"valid vault issuer with credentials from files": {
+			spec: &v1alpha1.IssuerSpec{
+				IssuerConfig: v1alpha1.IssuerConfig{
+					Vault:           &validVaultIssuer,
+					CredentialsFrom: v1alpha1.CredentialsFromFile,
+				},
+			},
+		},
+		"invalid credentials source": {
+			spec: &v1alpha1.IssuerSpec{
+				IssuerConfig: v1alpha1.IssuerConfig{
+					Vault:           &validVaultIssuer,
+					CredentialsFrom: "vault",
+				},
+			},
+			errs: []*field.Error{
+				field.NotSupported(fldPath.Child("credentialsFrom"), v1alpha1.CredentialsSource("vault"), []string{"secret", "file"}),
+			},
+		},
+		"valid vault issuer with a socks5 proxy": {
+			spec: &v1alpha1.IssuerSpec{
+				IssuerConfig: v1alpha1.IssuerConfig{
+					Vault: &validVaultIssuer,
+					Proxy: "socks5://proxy.example.com:1080",
+				},
+			},
+		},
+		"invalid proxy scheme": {
+			spec: &v1alpha1.IssuerSpec{
+				IssuerConfig: v1alpha1.IssuerConfig{
+					Vault: &validVaultIssuer,
+					Proxy: "ftp://proxy.example.com",
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("proxy"), "ftp://proxy.example.com", "proxy url scheme must be one of 'http', 'https' or 'socks5'"),
+			},
+		},
 		"missing issuer config": {
 			spec: &v1alpha1.IssuerSpec{
 				IssuerConfig: v1alpha1.IssuerConfig{},
@@ -301,6 +885,53 @@ func TestValidateIssuerSpec(t *testing.T) {
 	}
 }
 
+func TestValidateVaultKubernetesAuthForIssuerKinds(t *testing.T) {
+	fldPath := field.NewPath("spec", "vault", "auth", "kubernetes")
+	vaultConfig := func(auth *v1alpha1.VaultKubernetesAuth) v1alpha1.IssuerSpec {
+		return v1alpha1.IssuerSpec{
+			IssuerConfig: v1alpha1.IssuerConfig{
+				Vault: &v1alpha1.VaultIssuer{
+					Server: "https://vault.example.com",
+					Path:   "a/b/c",
+					Auth:   v1alpha1.VaultAuth{Kubernetes: auth},
+				},
+			},
+		}
+	}
+	scenarios := map[string]struct {
+		auth       *v1alpha1.VaultKubernetesAuth
+		issuerErrs []*field.Error
+	}{
+		"secretRef is allowed on both kinds": {
+			auth: &v1alpha1.VaultKubernetesAuth{Role: "cert-manager", SecretRef: validSecretKeyRef},
+		},
+		"the cert-manager service account token may only be used by ClusterIssuers": {
+			auth: &v1alpha1.VaultKubernetesAuth{Role: "cert-manager"},
+			issuerErrs: []*field.Error{
+				field.Required(fldPath.Child("secretRef"), "must be specified on Issuers, which may not use the cert-manager service account token"),
+			},
+		},
+		"tokenPath may only be used by ClusterIssuers": {
+			auth: &v1alpha1.VaultKubernetesAuth{Role: "cert-manager", TokenPath: "/var/run/secrets/token"},
+			issuerErrs: []*field.Error{
+				field.Required(fldPath.Child("secretRef"), "must be specified on Issuers, which may not use the cert-manager service account token"),
+				field.Forbidden(fldPath.Child("tokenPath"), "may only be specified on ClusterIssuers"),
+			},
+		},
+	}
+	for n, s := range scenarios {
+		t.Run(n, func(t *testing.T) {
+			errs := ValidateIssuer(&v1alpha1.Issuer{Spec: vaultConfig(s.auth)})
+			if !reflect.DeepEqual([]*field.Error(errs), s.issuerErrs) && !(len(errs) == 0 && len(s.issuerErrs) == 0) {
+				t.Errorf("Expected Issuer errors %v but got %v", s.issuerErrs, errs)
+			}
+			if errs := ValidateClusterIssuer(&v1alpha1.ClusterIssuer{Spec: vaultConfig(s.auth)}); len(errs) > 0 {
+				t.Errorf("Expected no ClusterIssuer errors but got %v", errs)
+			}
+		})
+	}
+}
+
 func TestValidateACMEIssuerDNS01Config(t *testing.T) {
 	fldPath := field.NewPath("")
 	providersPath := fldPath.Child("providers")
@@ -399,6 +1030,52 @@ func TestValidateACMEIssuerDNS01Config(t *testing.T) {
 				field.Required(providersPath.Index(0).Child("cloudflare", "apiKeySecretRef", "key"), "secret key is required"),
 			},
 		},
+		"valid cloudflare api token": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						Cloudflare: &v1alpha1.ACMEIssuerDNS01ProviderCloudflare{
+							APIToken: &validSecretKeyRef,
+						},
+					},
+				},
+			},
+			errs: []*field.Error{},
+		},
+		"invalid cloudflare api token": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						Cloudflare: &v1alpha1.ACMEIssuerDNS01ProviderCloudflare{
+							APIToken: &v1alpha1.SecretKeySelector{},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(providersPath.Index(0).Child("cloudflare", "apiTokenSecretRef", "name"), "secret name is required"),
+				field.Required(providersPath.Index(0).Child("cloudflare", "apiTokenSecretRef", "key"), "secret key is required"),
+			},
+		},
+		"cloudflare api token and api key both set": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						Cloudflare: &v1alpha1.ACMEIssuerDNS01ProviderCloudflare{
+							Email:    "valid",
+							APIKey:   validSecretKeyRef,
+							APIToken: &validSecretKeyRef,
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(providersPath.Index(0).Child("cloudflare"), "only one of apiKeySecretRef or apiTokenSecretRef may be specified"),
+			},
+		},
 		"missing cloudflare email": {
 			cfg: &v1alpha1.ACMEIssuerDNS01Config{
 				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
@@ -414,6 +1091,52 @@ func TestValidateACMEIssuerDNS01Config(t *testing.T) {
 				field.Required(providersPath.Index(0).Child("cloudflare", "email"), ""),
 			},
 		},
+		"missing hetzner token": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name:    "a name",
+						Hetzner: &v1alpha1.ACMEIssuerDNS01ProviderHetzner{},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(providersPath.Index(0).Child("hetzner", "apiTokenSecretRef", "name"), "secret name is required"),
+				field.Required(providersPath.Index(0).Child("hetzner", "apiTokenSecretRef", "key"), "secret key is required"),
+			},
+		},
+		"negative provider ttl": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						TTL:  -1,
+						Hetzner: &v1alpha1.ACMEIssuerDNS01ProviderHetzner{
+							APIToken: validSecretKeyRef,
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(providersPath.Index(0).Child("ttl"), -1, "must not be negative"),
+			},
+		},
+		"route53 externalID without role": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						Route53: &v1alpha1.ACMEIssuerDNS01ProviderRoute53{
+							Region:     "us-west-2",
+							ExternalID: "my-external-id",
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(providersPath.Index(0).Child("route53", "role"), "role must be set when externalID is specified"),
+			},
+		},
 		"missing route53 region": {
 			cfg: &v1alpha1.ACMEIssuerDNS01Config{
 				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
@@ -449,12 +1172,82 @@ func TestValidateACMEIssuerDNS01Config(t *testing.T) {
 				},
 			},
 			errs: []*field.Error{
-				field.Required(providersPath.Index(0).Child("azuredns", "clientSecretSecretRef", "name"), "secret name is required"),
+				field.Required(providersPath.Index(0).Child("azuredns", "subscriptionID"), ""),
+				field.Required(providersPath.Index(0).Child("azuredns", "resourceGroupName"), ""),
+			},
+		},
+		"missing azuredns service principal config": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						AzureDNS: &v1alpha1.ACMEIssuerDNS01ProviderAzureDNS{
+							ClientSecret: v1alpha1.SecretKeySelector{
+								LocalObjectReference: v1alpha1.LocalObjectReference{Name: "a-secret"},
+							},
+							SubscriptionID:    "a-subscription",
+							ResourceGroupName: "a-resource-group",
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
 				field.Required(providersPath.Index(0).Child("azuredns", "clientSecretSecretRef", "key"), "secret key is required"),
 				field.Required(providersPath.Index(0).Child("azuredns", "clientID"), ""),
-				field.Required(providersPath.Index(0).Child("azuredns", "subscriptionID"), ""),
 				field.Required(providersPath.Index(0).Child("azuredns", "tenantID"), ""),
-				field.Required(providersPath.Index(0).Child("azuredns", "resourceGroupName"), ""),
+			},
+		},
+		"azuredns with both service principal and managed identity": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						AzureDNS: &v1alpha1.ACMEIssuerDNS01ProviderAzureDNS{
+							ClientID: "a-client-id",
+							ClientSecret: v1alpha1.SecretKeySelector{
+								LocalObjectReference: v1alpha1.LocalObjectReference{Name: "a-secret"},
+								Key:                  "a-key",
+							},
+							TenantID:          "a-tenant",
+							SubscriptionID:    "a-subscription",
+							ResourceGroupName: "a-resource-group",
+							ManagedIdentity:   &v1alpha1.AzureManagedIdentity{},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(providersPath.Index(0).Child("azuredns", "managedIdentity"), "may not be set when clientSecretSecretRef is set"),
+			},
+		},
+		"valid azuredns managed identity config": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						AzureDNS: &v1alpha1.ACMEIssuerDNS01ProviderAzureDNS{
+							SubscriptionID:    "a-subscription",
+							ResourceGroupName: "a-resource-group",
+							ManagedIdentity: &v1alpha1.AzureManagedIdentity{
+								ClientID: "an-identity-client-id",
+							},
+						},
+					},
+				},
+			},
+		},
+		"missing webhook config": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name:    "a name",
+						Webhook: &v1alpha1.ACMEIssuerDNS01ProviderWebhook{},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(providersPath.Index(0).Child("webhook", "groupName"), ""),
+				field.Required(providersPath.Index(0).Child("webhook", "solverName"), ""),
 			},
 		},
 		"missing akamai config": {
@@ -547,6 +1340,20 @@ func TestValidateACMEIssuerDNS01Config(t *testing.T) {
 			},
 			errs: []*field.Error{},
 		},
+		"rfc2136 provider using rfc4635 algorithm name": {
+			cfg: &v1alpha1.ACMEIssuerDNS01Config{
+				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "a name",
+						RFC2136: &v1alpha1.ACMEIssuerDNS01ProviderRFC2136{
+							Nameserver:    "127.0.0.1",
+							TSIGAlgorithm: "hmac-sha256",
+						},
+					},
+				},
+			},
+			errs: []*field.Error{},
+		},
 		"rfc2136 provider using unsupported algorithm": {
 			cfg: &v1alpha1.ACMEIssuerDNS01Config{
 				Providers: []v1alpha1.ACMEIssuerDNS01Provider{
@@ -692,3 +1499,7 @@ func TestValidateSecretKeySelector(t *testing.T) {
 		})
 	}
 }
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+// CertificateDefaultingHook is a mutating admission hook that writes the
+// defaults used by the controller into Certificate resources, so that the
+// effective configuration is shown on the resource.
+type CertificateDefaultingHook struct {
+}
+
+func (c *CertificateDefaultingHook) Initialize(kubeClientConfig *restclient.Config, stopCh <-chan struct{}) error {
+	return nil
+}
+
+func (c *CertificateDefaultingHook) MutatingResource() (plural schema.GroupVersionResource, singular string) {
+	gv := v1alpha1.SchemeGroupVersion
+	gv.Group = "admission." + gv.Group
+	// override version to be the version of the admissionresponse resource
+	gv.Version = "v1beta1"
+	return gv.WithResource("certificatedefaults"), "certificatedefault"
+}
+
+func (c *CertificateDefaultingHook) Admit(admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	status := &admissionv1beta1.AdmissionResponse{}
+
+	obj := &v1alpha1.Certificate{}
+	err := json.Unmarshal(admissionSpec.Object.Raw, obj)
+	if err != nil {
+		status.Allowed = false
+		status.Result = &metav1.Status{
+			Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
+			Message: err.Error(),
+		}
+		return status
+	}
+
+	status.Allowed = true
+
+	patch := certificateDefaultsPatch(obj)
+	if len(patch) == 0 {
+		return status
+	}
+	status.Patch, err = json.Marshal(patch)
+	if err != nil {
+		status.Allowed = false
+		status.Result = &metav1.Status{
+			Status: metav1.StatusFailure, Code: http.StatusInternalServerError, Reason: metav1.StatusReasonInternalError,
+			Message: err.Error(),
+		}
+		return status
+	}
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	status.PatchType = &patchType
+
+	return status
+}
+
+// jsonPatchOperation is a single operation of an RFC 6902 JSON patch.
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// setCertificateDefaults applies the defaults of the API types to crt, as
+// well as the RSA key size used by pki.GeneratePrivateKeyForCertificate.
+func setCertificateDefaults(crt *v1alpha1.Certificate) {
+	v1alpha1.SetObjectDefaults_Certificate(crt)
+	if crt.Spec.KeyAlgorithm == v1alpha1.RSAKeyAlgorithm && crt.Spec.KeySize == 0 {
+		crt.Spec.KeySize = pki.MinRSAKeySize
+	}
+}
+
+// certificateDefaultsPatch returns the JSON patch operations that set the
+// defaulted fields of crt.
+func certificateDefaultsPatch(crt *v1alpha1.Certificate) []jsonPatchOperation {
+	defaulted := crt.DeepCopy()
+	setCertificateDefaults(defaulted)
+
+	var patch []jsonPatchOperation
+	add := func(path string, old, new interface{}) {
+		if !reflect.DeepEqual(old, new) {
+			patch = append(patch, jsonPatchOperation{Op: "add", Path: "/spec/" + path, Value: new})
+		}
+	}
+	add("keyAlgorithm", crt.Spec.KeyAlgorithm, defaulted.Spec.KeyAlgorithm)
+	add("keySize", crt.Spec.KeySize, defaulted.Spec.KeySize)
+	add("keyCurve", crt.Spec.KeyCurve, defaulted.Spec.KeyCurve)
+
+	return patch
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/validation"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+func TestCertificateDefaultingHook(t *testing.T) {
+	tests := map[string]struct {
+		spec     v1alpha1.CertificateSpec
+		expected v1alpha1.CertificateSpec
+	}{
+		"defaults are set if no fields are set": {
+			spec: v1alpha1.CertificateSpec{SecretName: "example-tls"},
+			expected: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.RSAKeyAlgorithm,
+				KeySize:      2048,
+			},
+		},
+		"set fields are not changed": {
+			spec: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				Duration:     &metav1.Duration{Duration: 24 * time.Hour},
+				KeyAlgorithm: v1alpha1.RSAKeyAlgorithm,
+				KeySize:      4096,
+			},
+			expected: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				Duration:     &metav1.Duration{Duration: 24 * time.Hour},
+				KeyAlgorithm: v1alpha1.RSAKeyAlgorithm,
+				KeySize:      4096,
+			},
+		},
+		"ecdsa certificates default to the P256 curve": {
+			spec: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+			},
+			expected: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+				KeyCurve:     v1alpha1.P256KeyCurve,
+			},
+		},
+		"ecdsa key size is not replaced by a curve": {
+			spec: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+				KeySize:      384,
+			},
+			expected: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+				KeySize:      384,
+			},
+		},
+		"duration and renewBefore are not defaulted": {
+			spec: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+			},
+			expected: v1alpha1.CertificateSpec{
+				SecretName:   "example-tls",
+				KeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm,
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := &v1alpha1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec:       test.spec,
+			}
+			req := admissionRequest(t, crt)
+
+			hook := &CertificateDefaultingHook{}
+			resp := hook.Admit(req)
+			if !resp.Allowed {
+				t.Fatalf("expected request to be allowed, but got %+v", resp.Result)
+			}
+
+			raw := req.Object.Raw
+			if resp.Patch != nil {
+				patch, err := jsonpatch.DecodePatch(resp.Patch)
+				if err != nil {
+					t.Fatalf("error decoding patch: %v", err)
+				}
+				raw, err = patch.Apply(raw)
+				if err != nil {
+					t.Fatalf("error applying patch: %v", err)
+				}
+			}
+			admitted := &v1alpha1.Certificate{}
+			if err := json.Unmarshal(raw, admitted); err != nil {
+				t.Fatalf("error decoding patched certificate: %v", err)
+			}
+			if !reflect.DeepEqual(admitted.Spec, test.expected) {
+				t.Errorf("expected spec %+v but got %+v", test.expected, admitted.Spec)
+			}
+		})
+	}
+}
+
+// TestCertificateDefaultsMatchController checks that a defaulted Certificate
+// results in the same private key and certificate parameters as the
+// controller uses when the fields are not set.
+func TestCertificateDefaultsMatchController(t *testing.T) {
+	for _, alg := range []v1alpha1.KeyAlgorithm{"", v1alpha1.RSAKeyAlgorithm, v1alpha1.ECDSAKeyAlgorithm} {
+		crt := &v1alpha1.Certificate{
+			Spec: v1alpha1.CertificateSpec{
+				CommonName:   "example.com",
+				KeyAlgorithm: alg,
+			},
+		}
+		defaulted := crt.DeepCopy()
+		setCertificateDefaults(defaulted)
+
+		key, err := pki.GeneratePrivateKeyForCertificate(crt)
+		if err != nil {
+			t.Fatalf("error generating key: %v", err)
+		}
+		defaultedKey, err := pki.GeneratePrivateKeyForCertificate(defaulted)
+		if err != nil {
+			t.Fatalf("error generating key for defaulted certificate: %v", err)
+		}
+		if keySize(key) != keySize(defaultedKey) {
+			t.Errorf("key algorithm %q: expected key size %d but defaulted certificate has %d", alg, keySize(key), keySize(defaultedKey))
+		}
+
+		_, sigAlg, err := pki.SignatureAlgorithm(crt)
+		if err != nil {
+			t.Fatalf("error getting signature algorithm: %v", err)
+		}
+		_, defaultedSigAlg, err := pki.SignatureAlgorithm(defaulted)
+		if err != nil {
+			t.Fatalf("error getting signature algorithm for defaulted certificate: %v", err)
+		}
+		if sigAlg != defaultedSigAlg {
+			t.Errorf("key algorithm %q: expected signature algorithm %v but defaulted certificate has %v", alg, sigAlg, defaultedSigAlg)
+		}
+	}
+}
+
+// TestCertificateDefaultsValidForIssuers checks that defaulting a Certificate
+// does not make it invalid for any issuer type.
+func TestCertificateDefaultsValidForIssuers(t *testing.T) {
+	issuers := map[string]v1alpha1.IssuerConfig{
+		"acme":       {ACME: &v1alpha1.ACMEIssuer{}},
+		"ca":         {CA: &v1alpha1.CAIssuer{}},
+		"vault":      {Vault: &v1alpha1.VaultIssuer{}},
+		"selfsigned": {SelfSigned: &v1alpha1.SelfSignedIssuer{}},
+		"venafi":     {Venafi: &v1alpha1.VenafiIssuer{}},
+		"external":   {External: &v1alpha1.ExternalIssuer{}},
+	}
+	for name, cfg := range issuers {
+		t.Run(name, func(t *testing.T) {
+			crt := &v1alpha1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.CertificateSpec{
+					SecretName: "example-tls",
+					CommonName: "example.com",
+					DNSNames:   []string{"example.com"},
+				},
+			}
+			setCertificateDefaults(crt)
+			issuer := &v1alpha1.Issuer{Spec: v1alpha1.IssuerSpec{IssuerConfig: cfg}}
+			if errs := validation.ValidateCertificateForIssuer(crt, issuer); len(errs) > 0 {
+				t.Errorf("expected defaulted certificate to be valid, got %v", errs)
+			}
+		})
+	}
+}
+
+func keySize(key interface{}) int {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k.N.BitLen()
+	case *ecdsa.PrivateKey:
+		return k.Curve.Params().BitSize
+	}
+	return 0
+}
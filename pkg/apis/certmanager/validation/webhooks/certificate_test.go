@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// admissionRequest returns an admission request for the JSON encoding of obj.
+func admissionRequest(t *testing.T, obj interface{}) *admissionv1beta1.AdmissionRequest {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("error encoding object: %v", err)
+	}
+	return &admissionv1beta1.AdmissionRequest{
+		Object: runtime.RawExtension{Raw: raw},
+	}
+}
+
+// checkResponse verifies that resp allows or rejects the request, and that the
+// rejection message contains expectedMessage.
+func checkResponse(t *testing.T, resp *admissionv1beta1.AdmissionResponse, expectedCode int32, expectedMessage string) {
+	if expectedMessage == "" {
+		if !resp.Allowed {
+			t.Errorf("expected request to be allowed, but got %+v", resp.Result)
+		}
+		return
+	}
+	if resp.Allowed {
+		t.Fatalf("expected request to be rejected")
+	}
+	if resp.Result.Code != expectedCode {
+		t.Errorf("expected status code %d but got %d", expectedCode, resp.Result.Code)
+	}
+	if !strings.Contains(resp.Result.Message, expectedMessage) {
+		t.Errorf("expected message to contain %q but got %q", expectedMessage, resp.Result.Message)
+	}
+}
+
+func TestCertificateAdmissionHook(t *testing.T) {
+	validSpec := func() v1alpha1.CertificateSpec {
+		return v1alpha1.CertificateSpec{
+			SecretName: "example-tls",
+			DNSNames:   []string{"example.com"},
+			IssuerRef: v1alpha1.ObjectReference{
+				Name: "ca-issuer",
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		spec            func() v1alpha1.CertificateSpec
+		expectedMessage string
+	}{
+		"valid certificate": {
+			spec: validSpec,
+		},
+		"missing secret name": {
+			spec: func() v1alpha1.CertificateSpec {
+				spec := validSpec()
+				spec.SecretName = ""
+				return spec
+			},
+			expectedMessage: "spec.secretName: Required value",
+		},
+		"invalid rsa key size": {
+			spec: func() v1alpha1.CertificateSpec {
+				spec := validSpec()
+				spec.KeyAlgorithm = v1alpha1.RSAKeyAlgorithm
+				spec.KeySize = 1024
+				return spec
+			},
+			expectedMessage: "spec.keySize: Invalid value: 1024: must be between 2048 & 8192 for rsa keyAlgorithm",
+		},
+		"duration shorter than the minimum": {
+			spec: func() v1alpha1.CertificateSpec {
+				spec := validSpec()
+				spec.Duration = &metav1.Duration{Duration: time.Minute}
+				return spec
+			},
+			expectedMessage: "spec.duration: Invalid value",
+		},
+		"mutually exclusive renewal fields": {
+			spec: func() v1alpha1.CertificateSpec {
+				spec := validSpec()
+				percentage := int32(30)
+				spec.RenewBefore = &metav1.Duration{Duration: 24 * time.Hour}
+				spec.RenewBeforePercentage = &percentage
+				return spec
+			},
+			expectedMessage: "spec.renewBeforePercentage: Forbidden: may not be set when renewBefore is set",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := &v1alpha1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec:       test.spec(),
+			}
+			hook := &CertificateAdmissionHook{}
+			resp := hook.Validate(admissionRequest(t, crt))
+			checkResponse(t, resp, http.StatusNotAcceptable, test.expectedMessage)
+		})
+	}
+}
+
+func TestCertificateAdmissionHookInvalidObject(t *testing.T) {
+	hook := &CertificateAdmissionHook{}
+	resp := hook.Validate(&admissionv1beta1.AdmissionRequest{
+		Object: runtime.RawExtension{Raw: []byte("not json")},
+	})
+	checkResponse(t, resp, http.StatusBadRequest, "invalid character")
+}
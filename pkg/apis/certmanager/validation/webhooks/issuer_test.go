@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"net/http"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+func TestIssuerAdmissionHooks(t *testing.T) {
+	tests := map[string]struct {
+		config          v1alpha1.IssuerConfig
+		expectedMessage string
+	}{
+		"valid issuer": {
+			config: v1alpha1.IssuerConfig{
+				CA: &v1alpha1.CAIssuer{SecretName: "ca-key-pair"},
+			},
+		},
+		"no issuer type configured": {
+			config:          v1alpha1.IssuerConfig{},
+			expectedMessage: "spec: Required value: at least one issuer must be configured",
+		},
+		"mutually exclusive issuer types": {
+			config: v1alpha1.IssuerConfig{
+				SelfSigned: &v1alpha1.SelfSignedIssuer{},
+				CA:         &v1alpha1.CAIssuer{SecretName: "ca-key-pair"},
+			},
+			expectedMessage: "may not specify more than one issuer type",
+		},
+		"missing vault server": {
+			config: v1alpha1.IssuerConfig{
+				Vault: &v1alpha1.VaultIssuer{Path: "pki/sign/example-dot-com"},
+			},
+			expectedMessage: "spec.vault.server: Required value",
+		},
+		"invalid proxy": {
+			config: v1alpha1.IssuerConfig{
+				CA:    &v1alpha1.CAIssuer{SecretName: "ca-key-pair"},
+				Proxy: "ftp://proxy.example.com",
+			},
+			expectedMessage: "spec.proxy: Invalid value",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.IssuerSpec{IssuerConfig: test.config}
+
+			issuerHook := &IssuerAdmissionHook{}
+			resp := issuerHook.Validate(admissionRequest(t, &v1alpha1.Issuer{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec:       spec,
+			}))
+			checkResponse(t, resp, http.StatusNotAcceptable, test.expectedMessage)
+
+			clusterIssuerHook := &ClusterIssuerAdmissionHook{}
+			resp = clusterIssuerHook.Validate(admissionRequest(t, &v1alpha1.ClusterIssuer{
+				ObjectMeta: metav1.ObjectMeta{Name: "example"},
+				Spec:       spec,
+			}))
+			checkResponse(t, resp, http.StatusNotAcceptable, test.expectedMessage)
+		})
+	}
+}
@@ -30,6 +30,10 @@ func (c *FakeCertmanagerV1alpha1) Certificates(namespace string) v1alpha1.Certif
 	return &FakeCertificates{c, namespace}
 }
 
+func (c *FakeCertmanagerV1alpha1) CertificateRequests(namespace string) v1alpha1.CertificateRequestInterface {
+	return &FakeCertificateRequests{c, namespace}
+}
+
 func (c *FakeCertmanagerV1alpha1) Challenges(namespace string) v1alpha1.ChallengeInterface {
 	return &FakeChallenges{c, namespace}
 }
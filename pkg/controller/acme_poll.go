@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// DefaultACMEPollInitialInterval is the amount of time to wait before
+	// first re-checking an ACME order or authorization that is waiting on
+	// the ACME server, if no interval has been configured.
+	DefaultACMEPollInitialInterval = time.Second * 2
+
+	// DefaultACMEPollMaxInterval is the maximum amount of time to wait
+	// between checks of an ACME order or authorization, if no maximum has
+	// been configured.
+	DefaultACMEPollMaxInterval = time.Minute
+)
+
+// NewACMEPollBackoff returns a rate limiter used to compute how long to wait
+// before re-checking an ACME order or authorization that is waiting on the
+// ACME server. The delay starts at the configured initial interval and
+// doubles on each check, up to the configured maximum interval.
+func NewACMEPollBackoff(opts ACMEOptions) workqueue.RateLimiter {
+	initial := opts.OrderPollInitialInterval
+	if initial <= 0 {
+		initial = DefaultACMEPollInitialInterval
+	}
+	max := opts.OrderPollMaxInterval
+	if max <= 0 {
+		max = DefaultACMEPollMaxInterval
+	}
+	if max < initial {
+		max = initial
+	}
+	return workqueue.NewItemExponentialFailureRateLimiter(initial, max)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestACMEPollBackoff(t *testing.T) {
+	tests := map[string]struct {
+		opts     ACMEOptions
+		expected []time.Duration
+	}{
+		"uses the default intervals if none are configured": {
+			expected: []time.Duration{
+				2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+				32 * time.Second, time.Minute, time.Minute,
+			},
+		},
+		"doubles the configured initial interval up to the maximum": {
+			opts: ACMEOptions{
+				OrderPollInitialInterval: time.Second,
+				OrderPollMaxInterval:     5 * time.Second,
+			},
+			expected: []time.Duration{
+				time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+			},
+		},
+		"never waits less than the initial interval": {
+			opts: ACMEOptions{
+				OrderPollInitialInterval: 10 * time.Second,
+				OrderPollMaxInterval:     time.Second,
+			},
+			expected: []time.Duration{10 * time.Second, 10 * time.Second},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			backoff := NewACMEPollBackoff(tt.opts)
+			for i, expected := range tt.expected {
+				if actual := backoff.When("default/order"); actual != expected {
+					t.Errorf("poll %d: expected delay %s but got %s", i, expected, actual)
+				}
+			}
+
+			// other resources have their own backoff
+			if actual := backoff.When("default/other"); actual != tt.expected[0] {
+				t.Errorf("expected delay %s for a different resource but got %s", tt.expected[0], actual)
+			}
+
+			backoff.Forget("default/order")
+			if actual := backoff.When("default/order"); actual != tt.expected[0] {
+				t.Errorf("expected delay to be reset to %s after forgetting the resource but got %s", tt.expected[0], actual)
+			}
+		})
+	}
+}
@@ -22,7 +22,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
@@ -37,6 +36,8 @@ import (
 	"github.com/jetstack/cert-manager/pkg/controller/acmechallenges/scheduler"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/http"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/pkg/util"
 )
 
@@ -63,14 +64,22 @@ type Controller struct {
 	watchedInformers []cache.InformerSynced
 	queue            workqueue.RateLimitingInterface
 
+	// authzPollBackoff computes how long to wait before re-checking the
+	// authorization of a Challenge that has been accepted but is still
+	// waiting on the ACME server.
+	authzPollBackoff workqueue.RateLimiter
+
 	scheduler *scheduler.Scheduler
+
+	metrics *metrics.Metrics
 }
 
 func New(ctx *controllerpkg.Context) *Controller {
 	ctrl := &Controller{Context: *ctx}
 	ctrl.syncHandler = ctrl.processNextWorkItem
 
-	ctrl.queue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second*5, time.Minute*30), "challenges")
+	ctrl.queue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second*5, time.Minute*30), ControllerName)
+	ctrl.authzPollBackoff = controllerpkg.NewACMEPollBackoff(ctx.ACMEOptions)
 
 	challengeInformer := ctrl.SharedInformerFactory.Certmanager().V1alpha1().Challenges()
 	challengeInformer.Informer().AddEventHandler(&controllerpkg.QueuingEventHandler{Queue: ctrl.queue})
@@ -107,12 +116,13 @@ func New(ctx *controllerpkg.Context) *Controller {
 	ctrl.httpSolver = http.NewSolver(ctx)
 	ctrl.dnsSolver = dns.NewSolver(ctx)
 	ctrl.scheduler = scheduler.New(ctrl.challengeLister)
+	ctrl.metrics = metrics.Default
 
 	return ctrl
 }
 
 func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
-	glog.V(4).Infof("Starting %s control loop", ControllerName)
+	logs.V(4).Infof("Starting %s control loop", ControllerName)
 	// wait for all the informer caches we depend on are synced
 	if !cache.WaitForCacheSync(stopCh, c.watchedInformers...) {
 		// c.challengeInformerSynced) {
@@ -134,11 +144,11 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
 	go wait.Until(c.runScheduler, time.Second*1, stopCh)
 
 	<-stopCh
-	glog.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
+	logs.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
 	c.queue.ShutDown()
-	glog.V(4).Infof("Waiting for workers to exit...")
+	logs.V(4).Infof("Waiting for workers to exit...")
 	wg.Wait()
-	glog.V(4).Infof("Workers exited.")
+	logs.V(4).Infof("Workers exited.")
 	return nil
 }
 
@@ -179,12 +189,12 @@ func (c *Controller) runScheduler() {
 		if len(toSchedule) > 1 {
 			plural = "s"
 		}
-		glog.V(4).Infof("Scheduled %d challenge%s for processing", len(toSchedule), plural)
+		logs.V(4).Infof("Scheduled %d challenge%s for processing", len(toSchedule), plural)
 	}
 }
 
 func (c *Controller) worker(stopCh <-chan struct{}) {
-	glog.V(4).Infof("Starting %q worker", ControllerName)
+	logs.V(4).Infof("Starting %q worker", ControllerName)
 	for {
 		obj, shutdown := c.queue.Get()
 		if shutdown {
@@ -202,17 +212,18 @@ func (c *Controller) worker(stopCh <-chan struct{}) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			ctx = util.ContextWithStopCh(ctx, stopCh)
-			glog.Infof("%s controller: syncing item '%s'", ControllerName, key)
+			log := logs.WithValues("controller", ControllerName, "key", key)
+			log.Infof("syncing item")
 			if err := c.syncHandler(ctx, key); err != nil {
-				glog.Errorf("%s controller: Re-queuing item %q due to error processing: %s", ControllerName, key, err.Error())
+				log.Errorf("re-queuing item due to error processing: %s", err.Error())
 				c.queue.AddRateLimited(obj)
 				return
 			}
-			glog.Infof("%s controller: Finished processing work item %q", ControllerName, key)
+			log.Infof("finished processing work item")
 			c.queue.Forget(obj)
 		}()
 	}
-	glog.V(4).Infof("Exiting %q worker loop", ControllerName)
+	logs.V(4).Infof("Exiting %q worker loop", ControllerName)
 }
 
 func (c *Controller) processNextWorkItem(ctx context.Context, key string) error {
@@ -227,6 +238,7 @@ func (c *Controller) processNextWorkItem(ctx context.Context, key string) error
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			runtime.HandleError(fmt.Errorf("ch '%s' in work queue no longer exists", key))
+			c.authzPollBackoff.Forget(key)
 			return nil
 		}
 
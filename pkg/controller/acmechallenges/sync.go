@@ -22,7 +22,6 @@ import (
 	"reflect"
 	"time"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
@@ -30,11 +29,17 @@ import (
 	acmecl "github.com/jetstack/cert-manager/pkg/acme/client"
 	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
 
 const (
 	reasonDomainVerified = "DomainVerified"
+
+	// defaultCheckRetryInterval is the amount of time to wait before
+	// re-checking challenge propagation if no interval has been configured.
+	defaultCheckRetryInterval = time.Second * 10
 )
 
 // solver solves ACME challenges by presenting the given token and key in an
@@ -56,6 +61,9 @@ func (c *Controller) Sync(ctx context.Context, ch *cmapi.Challenge) (err error)
 	oldChal := ch
 	ch = ch.DeepCopy()
 
+	// problem is the ACME error that caused the Challenge to fail, if any
+	var problem *acmeapi.Error
+
 	defer func() {
 		// TODO: replace with more efficient comparison
 		if reflect.DeepEqual(oldChal.Status, ch.Status) && len(oldChal.Finalizers) == len(ch.Finalizers) {
@@ -65,6 +73,11 @@ func (c *Controller) Sync(ctx context.Context, ch *cmapi.Challenge) (err error)
 		if err != nil {
 			err = utilerrors.NewAggregate([]error{err, updateErr})
 		}
+		// only record the result once it has been persisted, so that a
+		// failed update and retry does not count the Challenge twice
+		if updateErr == nil {
+			c.recordChallengeResult(oldChal, ch, problem)
+		}
 	}()
 
 	if ch.DeletionTimestamp != nil {
@@ -88,13 +101,13 @@ func (c *Controller) Sync(ctx context.Context, ch *cmapi.Challenge) (err error)
 		if ch.Status.Presented {
 			solver, err := c.solverFor(ch.Spec.Type)
 			if err != nil {
-				glog.Errorf("Error getting solver for challenge %q (type %q): %v", ch.Name, ch.Spec.Type, err)
+				logs.Errorf("Error getting solver for challenge %q (type %q): %v", ch.Name, ch.Spec.Type, err)
 				return err
 			}
 
 			err = solver.CleanUp(ctx, genericIssuer, ch)
 			if err != nil {
-				glog.Errorf("Error cleaning up challenge %q on deletion: %v", ch.Name, err)
+				logs.Errorf("Error cleaning up challenge %q on deletion: %v", ch.Name, err)
 				return err
 			}
 
@@ -112,7 +125,8 @@ func (c *Controller) Sync(ctx context.Context, ch *cmapi.Challenge) (err error)
 	}
 
 	if ch.Status.State == "" {
-		err := c.syncChallengeStatus(ctx, cl, ch)
+		var err error
+		problem, err = c.syncChallengeStatus(ctx, cl, ch)
 		if err != nil {
 			// TODO: check acme error types and potentially mark the challenge
 			// as failed if there is some known error
@@ -146,9 +160,16 @@ func (c *Controller) Sync(ctx context.Context, ch *cmapi.Challenge) (err error)
 		c.Recorder.Eventf(ch, corev1.EventTypeNormal, "Presented", "Presented challenge using %s challenge mechanism", ch.Spec.Type)
 	}
 
+	// the challenge has already been accepted, so only its authorization
+	// needs to be checked with the ACME server
+	if ch.Status.State == cmapi.Processing {
+		problem, err = c.syncAuthorization(ctx, cl, ch)
+		return err
+	}
+
 	err = solver.Check(ctx, genericIssuer, ch)
 	if err != nil {
-		glog.Infof("propagation check failed: %v", err)
+		logs.Infof("propagation check failed: %v", err)
 		ch.Status.Reason = fmt.Sprintf("Waiting for %s challenge propagation: %s", ch.Spec.Type, err)
 
 		key, err := controllerpkg.KeyFunc(ch)
@@ -157,13 +178,12 @@ func (c *Controller) Sync(ctx context.Context, ch *cmapi.Challenge) (err error)
 			return err
 		}
 
-		// retry after 10s
-		c.queue.AddAfter(key, time.Second*10)
+		c.queue.AddAfter(key, c.checkRetryInterval(ch))
 
 		return nil
 	}
 
-	err = c.acceptChallenge(ctx, cl, ch)
+	problem, err = c.acceptChallenge(ctx, cl, ch)
 	if err != nil {
 		return err
 	}
@@ -176,7 +196,7 @@ func (c *Controller) handleFinalizer(ctx context.Context, ch *cmapi.Challenge) e
 		return nil
 	}
 	if ch.Finalizers[0] != cmapi.ACMEFinalizer {
-		glog.V(4).Infof("Waiting to run challenge %q finalization...", ch.Name)
+		logs.V(4).Infof("Waiting to run challenge %q finalization...", ch.Name)
 		return nil
 	}
 	ch.Finalizers = ch.Finalizers[1:]
@@ -192,13 +212,13 @@ func (c *Controller) handleFinalizer(ctx context.Context, ch *cmapi.Challenge) e
 
 	solver, err := c.solverFor(ch.Spec.Type)
 	if err != nil {
-		glog.Errorf("Error getting solver for challenge %q (type %q): %v", ch.Name, ch.Spec.Type, err)
+		logs.Errorf("Error getting solver for challenge %q (type %q): %v", ch.Name, ch.Spec.Type, err)
 		return nil
 	}
 
 	err = solver.CleanUp(ctx, genericIssuer, ch)
 	if err != nil {
-		glog.Errorf("Error cleaning up challenge %q on deletion: %v", ch.Name, err)
+		logs.Errorf("Error cleaning up challenge %q on deletion: %v", ch.Name, err)
 		return nil
 	}
 
@@ -208,14 +228,15 @@ func (c *Controller) handleFinalizer(ctx context.Context, ch *cmapi.Challenge) e
 // syncChallengeStatus will communicate with the ACME server to retrieve the current
 // state of the Challenge. It will then update the Challenge's status block with the new
 // state of the Challenge.
-func (c *Controller) syncChallengeStatus(ctx context.Context, cl acmecl.Interface, ch *cmapi.Challenge) error {
+// It returns the error reported by the ACME server for the Challenge, if any.
+func (c *Controller) syncChallengeStatus(ctx context.Context, cl acmecl.Interface, ch *cmapi.Challenge) (*acmeapi.Error, error) {
 	if ch.Spec.URL == "" {
-		return fmt.Errorf("challenge URL is blank - challenge has not been created yet")
+		return nil, fmt.Errorf("challenge URL is blank - challenge has not been created yet")
 	}
 
 	acmeChallenge, err := cl.GetChallenge(ctx, ch.Spec.URL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// TODO: should we validate the State returned by the ACME server here?
@@ -232,16 +253,17 @@ func (c *Controller) syncChallengeStatus(ctx context.Context, cl acmecl.Interfac
 	}
 	ch.Status.State = cmState
 
-	return nil
+	return acmeChallenge.Error, nil
 }
 
-// acceptChallenge will accept the challenge with the acme server and then wait
-// for the authorization to reach a 'final' state.
+// acceptChallenge will accept the challenge with the acme server and then
+// check the status of its authorization.
 // It will update the challenge's status to reflect the final state of the
-// challenge if it failed, or the final state of the challenge's authorization
-// if accepting the challenge succeeds.
-func (c *Controller) acceptChallenge(ctx context.Context, cl acmecl.Interface, ch *cmapi.Challenge) error {
-	glog.Infof("Accepting challenge for domain %q", ch.Spec.DNSName)
+// challenge if it failed, or the state of the challenge's authorization if
+// accepting the challenge succeeds.
+// It returns the error reported by the ACME server if the challenge failed.
+func (c *Controller) acceptChallenge(ctx context.Context, cl acmecl.Interface, ch *cmapi.Challenge) (*acmeapi.Error, error) {
+	logs.Infof("Accepting challenge for domain %q", ch.Spec.DNSName)
 	// We manually construct an ACME challenge here from our own internal type
 	// to save additional round trips to the ACME server.
 	acmeChal := &acmeapi.Challenge{
@@ -253,35 +275,111 @@ func (c *Controller) acceptChallenge(ctx context.Context, cl acmecl.Interface, c
 		ch.Status.State = cmapi.State(acmeChal.Status)
 	}
 	if err != nil {
-		glog.Infof("%s: Error accepting challenge: %v", ch.Name, err)
+		logs.Infof("%s: Error accepting challenge: %v", ch.Name, err)
 		ch.Status.Reason = fmt.Sprintf("Error accepting challenge: %v", err)
-		return err
+		problem, _ := err.(*acmeapi.Error)
+		return problem, err
 	}
 
-	glog.Infof("Waiting for authorization for domain %q", ch.Spec.DNSName)
-	authorization, err := cl.WaitAuthorization(ctx, ch.Spec.AuthzURL)
+	// The ACME server may still report the challenge as pending in its
+	// response. Record it as processing so that it is not accepted again
+	// while its authorization is being checked.
+	if !acme.IsFinalState(ch.Status.State) {
+		ch.Status.State = cmapi.Processing
+	}
+
+	return c.syncAuthorization(ctx, cl, ch)
+}
+
+// syncAuthorization checks the status of the authorization of an accepted
+// challenge with the acme server.
+// If the authorization has reached a 'final' state, the challenge's status is
+// updated to reflect it. Otherwise the challenge is requeued to be checked
+// again after the next backoff interval.
+// It returns the error reported by the ACME server if the challenge failed.
+func (c *Controller) syncAuthorization(ctx context.Context, cl acmecl.Interface, ch *cmapi.Challenge) (*acmeapi.Error, error) {
+	key, err := controllerpkg.KeyFunc(ch)
+	// This is an unexpected edge case and should never occur
 	if err != nil {
-		authErr, ok := err.(acmeapi.AuthorizationError)
-		if !ok {
-			glog.Infof("%s: Unexpected error waiting for authorization: %v", ch.Name, err)
-			return err
-		}
+		return nil, err
+	}
+
+	authorization, err := cl.GetAuthorization(ctx, ch.Spec.AuthzURL)
+	if err != nil {
+		logs.Infof("%s: Unexpected error checking authorization: %v", ch.Name, err)
+		return nil, err
+	}
+
+	switch authorization.Status {
+	case acmeapi.StatusValid:
+		c.authzPollBackoff.Forget(key)
+
+		ch.Status.State = cmapi.State(authorization.Status)
+		ch.Status.Reason = "Successfully authorized domain"
+		c.Context.Recorder.Eventf(ch, corev1.EventTypeNormal, reasonDomainVerified, "Domain %q verified with %q validation", ch.Spec.DNSName, ch.Spec.Type)
 
-		ch.Status.State = cmapi.State(authErr.Authorization.Status)
+		return nil, nil
+	case acmeapi.StatusInvalid, acmeapi.StatusDeactivated, acmeapi.StatusRevoked:
+		c.authzPollBackoff.Forget(key)
+
+		authErr := acmeapi.AuthorizationError{Authorization: authorization}
+		ch.Status.State = cmapi.State(authorization.Status)
 		ch.Status.Reason = fmt.Sprintf("Error accepting authorization: %v", authErr)
 
 		c.Recorder.Eventf(ch, corev1.EventTypeWarning, "Failed", "Accepting challenge authorization failed: %v", authErr)
 
 		// return nil here, as accepting the challenge did not error, the challenge
 		// simply failed
+		return authorizationProblem(authorization, ch), nil
+	case acmeapi.StatusPending, acmeapi.StatusProcessing:
+		ch.Status.Reason = fmt.Sprintf("Waiting for authorization for domain %q", ch.Spec.DNSName)
+
+		delay := c.authzPollBackoff.When(key)
+		logs.V(4).Infof("Authorization for challenge %q is %q, checking its status again in %s", key, authorization.Status, delay)
+		c.queue.AddAfter(key, delay)
+
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown authorization status %q", authorization.Status)
+	}
+}
+
+// authorizationProblem returns the error reported by the ACME server for the
+// challenge of authz corresponding to ch, or nil if there is none.
+func authorizationProblem(authz *acmeapi.Authorization, ch *cmapi.Challenge) *acmeapi.Error {
+	if authz == nil {
 		return nil
 	}
+	for _, acmeChal := range authz.Challenges {
+		if acmeChal.URL == ch.Spec.URL {
+			return acmeChal.Error
+		}
+	}
+	return nil
+}
 
-	ch.Status.State = cmapi.State(authorization.Status)
-	ch.Status.Reason = "Successfully authorized domain"
-	c.Context.Recorder.Eventf(ch, corev1.EventTypeNormal, reasonDomainVerified, "Domain %q verified with %q validation", ch.Spec.DNSName, ch.Spec.Type)
+// recordChallengeResult increments the ACME challenge result metric if ch
+// has entered a final state since oldChal was observed. The reason label is
+// derived from problem for failed Challenges, as the reason on the status is
+// free text.
+func (c *Controller) recordChallengeResult(oldChal, ch *cmapi.Challenge, problem *acmeapi.Error) {
+	if acme.IsFinalState(oldChal.Status.State) || !acme.IsFinalState(ch.Status.State) {
+		return
+	}
+	reason := metrics.ACMEReasonNone
+	if acme.IsFailureState(ch.Status.State) {
+		reason = metrics.ACMEProblemReason(problem)
+	}
+	c.metrics.IncrementACMEChallengeResult(ch.Spec.Type, ch.Status.State, reason)
+}
 
-	return nil
+// checkRetryInterval returns how long to wait before re-checking the
+// propagation of the given challenge.
+func (c *Controller) checkRetryInterval(ch *cmapi.Challenge) time.Duration {
+	if ch.Spec.Type == "dns-01" && c.DNS01CheckRetryInterval > 0 {
+		return c.DNS01CheckRetryInterval
+	}
+	return defaultCheckRetryInterval
 }
 
 func (c *Controller) solverFor(challengeType string) (solver, error) {
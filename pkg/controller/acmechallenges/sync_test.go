@@ -20,13 +20,18 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"k8s.io/apimachinery/pkg/runtime"
 	coretesting "k8s.io/client-go/testing"
 
 	acmecl "github.com/jetstack/cert-manager/pkg/acme/client"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
 	testpkg "github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/test/unit/gen"
 	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
@@ -181,7 +186,7 @@ func TestSyncHappyPath(t *testing.T) {
 					// status and not the challenges
 					return &acmeapi.Challenge{Status: acmeapi.StatusPending}, nil
 				},
-				FakeWaitAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+				FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
 					return &acmeapi.Authorization{Status: acmeapi.StatusValid}, nil
 				},
 			},
@@ -233,21 +238,108 @@ func TestSyncHappyPath(t *testing.T) {
 					// status and not the challenges
 					return &acmeapi.Challenge{Status: acmeapi.StatusPending}, nil
 				},
-				FakeWaitAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
-					return nil, acmeapi.AuthorizationError{
-						Authorization: &acmeapi.Authorization{
-							Status: acmeapi.StatusInvalid,
-							Identifier: acmeapi.AuthzID{
-								Value: "example.com",
-							},
+				FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+					return &acmeapi.Authorization{
+						Status: acmeapi.StatusInvalid,
+						Identifier: acmeapi.AuthzID{
+							Value: "example.com",
 						},
-					}
+					}, nil
 				},
 			},
 			CheckFn: func(t *testing.T, s *controllerFixture, args ...interface{}) {
 			},
 			Err: false,
 		},
+		"requeue the challenge with backoff if its authorization is still pending": {
+			Issuer: testIssuerHTTP01Enabled,
+			Challenge: gen.Challenge("testchal",
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeDNSName("example.com"),
+				gen.SetChallengeState(v1alpha1.Pending),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresented(true),
+			),
+			HTTP01: &fakeSolver{
+				fakeCheck: func(ctx context.Context, issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) error {
+					return nil
+				},
+			},
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{gen.Challenge("testchal",
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeDNSName("example.com"),
+					gen.SetChallengeState(v1alpha1.Pending),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresented(true),
+				)},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateAction(v1alpha1.SchemeGroupVersion.WithResource("challenges"), gen.DefaultTestNamespace,
+						gen.Challenge("testchal",
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeDNSName("example.com"),
+							gen.SetChallengeState(v1alpha1.Processing),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeReason(`Waiting for authorization for domain "example.com"`),
+						))),
+				},
+			},
+			Client: &acmecl.FakeACME{
+				FakeAcceptChallenge: func(context.Context, *acmeapi.Challenge) (*acmeapi.Challenge, error) {
+					return &acmeapi.Challenge{Status: acmeapi.StatusPending}, nil
+				},
+				FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+					return &acmeapi.Authorization{Status: acmeapi.StatusPending}, nil
+				},
+			},
+			CheckFn: func(t *testing.T, s *controllerFixture, args ...interface{}) {
+				if n := s.Controller.authzPollBackoff.NumRequeues(gen.DefaultTestNamespace + "/testchal"); n != 1 {
+					t.Errorf("expected the authorization check to have been scheduled once, but got %d", n)
+				}
+			},
+			Err: false,
+		},
+		"check the authorization without accepting again if the challenge is processing": {
+			Issuer: testIssuerHTTP01Enabled,
+			Challenge: gen.Challenge("testchal",
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(v1alpha1.Processing),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresented(true),
+			),
+			HTTP01: &fakeSolver{},
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{gen.Challenge("testchal",
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(v1alpha1.Processing),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresented(true),
+				)},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateAction(v1alpha1.SchemeGroupVersion.WithResource("challenges"), gen.DefaultTestNamespace,
+						gen.Challenge("testchal",
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(v1alpha1.Valid),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeReason("Successfully authorized domain"),
+						))),
+				},
+			},
+			Client: &acmecl.FakeACME{
+				FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+					return &acmeapi.Authorization{Status: acmeapi.StatusValid}, nil
+				},
+			},
+			Err: false,
+		},
 		"mark the challenge as not processing if it is already valid": {
 			Issuer: testIssuerHTTP01Enabled,
 			Challenge: gen.Challenge("testchal",
@@ -336,3 +428,120 @@ func TestSyncHappyPath(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckRetryInterval(t *testing.T) {
+	tests := map[string]struct {
+		challengeType string
+		interval      time.Duration
+		expected      time.Duration
+	}{
+		"dns-01 uses the configured interval": {
+			challengeType: "dns-01",
+			interval:      time.Minute,
+			expected:      time.Minute,
+		},
+		"dns-01 uses the default interval if unset": {
+			challengeType: "dns-01",
+			expected:      defaultCheckRetryInterval,
+		},
+		"http-01 ignores the dns01 interval": {
+			challengeType: "http-01",
+			interval:      time.Minute,
+			expected:      defaultCheckRetryInterval,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{}
+			c.Context = controllerpkg.Context{
+				ACMEOptions: controllerpkg.ACMEOptions{
+					DNS01CheckRetryInterval: test.interval,
+				},
+			}
+			ch := gen.Challenge("testchal", gen.SetChallengeType(test.challengeType))
+			if actual := c.checkRetryInterval(ch); actual != test.expected {
+				t.Errorf("expected retry interval %v, but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordChallengeResult(t *testing.T) {
+	connectionProblem := &acmeapi.Error{Type: "urn:ietf:params:acme:error:connection"}
+	tests := map[string]struct {
+		oldState, newState v1alpha1.State
+		problem            *acmeapi.Error
+		// expected is the reason label that should be incremented, or empty
+		// if no result should be recorded
+		expected string
+	}{
+		"records a valid challenge": {
+			oldState: v1alpha1.Pending,
+			newState: v1alpha1.Valid,
+			expected: metrics.ACMEReasonNone,
+		},
+		"records the problem type of a failed challenge": {
+			oldState: v1alpha1.Pending,
+			newState: v1alpha1.Invalid,
+			problem:  connectionProblem,
+			expected: "connection",
+		},
+		"records an unknown reason without a problem": {
+			oldState: v1alpha1.Processing,
+			newState: v1alpha1.Invalid,
+			expected: metrics.ACMEReasonUnknown,
+		},
+		"does not record a challenge that is not final": {
+			oldState: v1alpha1.Pending,
+			newState: v1alpha1.Processing,
+		},
+		"does not record a challenge that was already final": {
+			oldState: v1alpha1.Invalid,
+			newState: v1alpha1.Invalid,
+			problem:  connectionProblem,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := metrics.New()
+			m.ACMEChallengeResultCount = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test"}, []string{"type", "result", "reason"})
+			c := &Controller{metrics: m}
+			oldChal := gen.Challenge("testchal", gen.SetChallengeType("http-01"), gen.SetChallengeState(test.oldState))
+			ch := gen.ChallengeFrom(oldChal.DeepCopy(), gen.SetChallengeState(test.newState))
+
+			c.recordChallengeResult(oldChal, ch, test.problem)
+
+			if test.expected == "" {
+				metricsCh := make(chan prometheus.Metric, 1)
+				m.ACMEChallengeResultCount.Collect(metricsCh)
+				if len(metricsCh) != 0 {
+					t.Errorf("expected no results to be recorded, got %d", len(metricsCh))
+				}
+				return
+			}
+			counter := m.ACMEChallengeResultCount.With(prometheus.Labels{"type": "http-01", "result": string(test.newState), "reason": test.expected})
+			if v := testutil.ToFloat64(counter); v != 1 {
+				t.Errorf("expected result with reason %q to be recorded once, got %v", test.expected, v)
+			}
+		})
+	}
+}
+
+func TestAuthorizationProblem(t *testing.T) {
+	problem := &acmeapi.Error{Type: "urn:ietf:params:acme:error:dns"}
+	authz := &acmeapi.Authorization{
+		Challenges: []*acmeapi.Challenge{
+			{URL: "http://example.com/other"},
+			{URL: "http://example.com/chal", Error: problem},
+		},
+	}
+	ch := gen.Challenge("testchal", gen.SetChallengeURL("http://example.com/chal"))
+	if got := authorizationProblem(authz, ch); got != problem {
+		t.Errorf("expected problem %v, got %v", problem, got)
+	}
+	if got := authorizationProblem(nil, ch); got != nil {
+		t.Errorf("expected no problem for a nil authorization, got %v", got)
+	}
+}
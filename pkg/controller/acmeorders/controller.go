@@ -22,7 +22,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -36,6 +35,8 @@ import (
 	"github.com/jetstack/cert-manager/pkg/acme"
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
 	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/pkg/util"
 )
 
@@ -57,6 +58,12 @@ type Controller struct {
 	watchedInformers []cache.InformerSynced
 	queue            workqueue.RateLimitingInterface
 
+	// orderPollBackoff computes how long to wait before re-checking Orders
+	// that are waiting on the ACME server
+	orderPollBackoff workqueue.RateLimiter
+
+	metrics *metrics.Metrics
+
 	// used for testing
 	clock clock.Clock
 }
@@ -65,7 +72,8 @@ func New(ctx *controllerpkg.Context) *Controller {
 	ctrl := &Controller{Context: *ctx}
 	ctrl.syncHandler = ctrl.processNextWorkItem
 
-	ctrl.queue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second*5, time.Minute*30), "orders")
+	ctrl.queue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second*5, time.Minute*30), ControllerName)
+	ctrl.orderPollBackoff = controllerpkg.NewACMEPollBackoff(ctx.ACMEOptions)
 
 	orderInformer := ctrl.SharedInformerFactory.Certmanager().V1alpha1().Orders()
 	orderInformer.Informer().AddEventHandler(&controllerpkg.QueuingEventHandler{Queue: ctrl.queue})
@@ -97,6 +105,7 @@ func New(ctx *controllerpkg.Context) *Controller {
 	ctrl.helper = controllerpkg.NewHelper(ctrl.issuerLister, ctrl.clusterIssuerLister)
 	ctrl.acmeHelper = acme.NewHelper(ctrl.secretLister, ctrl.Context.ClusterResourceNamespace)
 	ctrl.clock = clock.RealClock{}
+	ctrl.metrics = metrics.Default
 
 	return ctrl
 }
@@ -104,7 +113,7 @@ func New(ctx *controllerpkg.Context) *Controller {
 func (c *Controller) handleOwnedResource(obj interface{}) {
 	metaobj, ok := obj.(metav1.Object)
 	if !ok {
-		glog.Errorf("item passed to handleOwnedResource does not implement ObjectMetaAccessor")
+		logs.Errorf("item passed to handleOwnedResource does not implement ObjectMetaAccessor")
 		return
 	}
 
@@ -113,7 +122,7 @@ func (c *Controller) handleOwnedResource(obj interface{}) {
 		// Parse the Group out of the OwnerReference to compare it to what was parsed out of the requested OwnerType
 		refGV, err := schema.ParseGroupVersion(ref.APIVersion)
 		if err != nil {
-			glog.Errorf("Could not parse OwnerReference GroupVersion: %v", err)
+			logs.Errorf("Could not parse OwnerReference GroupVersion: %v", err)
 			continue
 		}
 
@@ -121,7 +130,7 @@ func (c *Controller) handleOwnedResource(obj interface{}) {
 			// TODO: how to handle namespace of owner references?
 			order, err := c.orderLister.Orders(metaobj.GetNamespace()).Get(ref.Name)
 			if err != nil {
-				glog.Errorf("Error getting Order %q referenced by resource %q", ref.Name, metaobj.GetName())
+				logs.Errorf("Error getting Order %q referenced by resource %q", ref.Name, metaobj.GetName())
 				continue
 			}
 			objKey, err := keyFunc(order)
@@ -135,7 +144,7 @@ func (c *Controller) handleOwnedResource(obj interface{}) {
 }
 
 func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
-	glog.V(4).Infof("Starting %s control loop", ControllerName)
+	logs.V(4).Infof("Starting %s control loop", ControllerName)
 	// wait for all the informer caches we depend on are synced
 	if !cache.WaitForCacheSync(stopCh, c.watchedInformers...) {
 		// c.challengeInformerSynced) {
@@ -153,16 +162,16 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
 			time.Second, stopCh)
 	}
 	<-stopCh
-	glog.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
+	logs.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
 	c.queue.ShutDown()
-	glog.V(4).Infof("Waiting for workers to exit...")
+	logs.V(4).Infof("Waiting for workers to exit...")
 	wg.Wait()
-	glog.V(4).Infof("Workers exited.")
+	logs.V(4).Infof("Workers exited.")
 	return nil
 }
 
 func (c *Controller) worker(stopCh <-chan struct{}) {
-	glog.V(4).Infof("Starting %q worker", ControllerName)
+	logs.V(4).Infof("Starting %q worker", ControllerName)
 	for {
 		obj, shutdown := c.queue.Get()
 		if shutdown {
@@ -180,17 +189,18 @@ func (c *Controller) worker(stopCh <-chan struct{}) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			ctx = util.ContextWithStopCh(ctx, stopCh)
-			glog.Infof("%s controller: syncing item '%s'", ControllerName, key)
+			log := logs.WithValues("controller", ControllerName, "key", key)
+			log.Infof("syncing item")
 			if err := c.syncHandler(ctx, key); err != nil {
-				glog.Errorf("%s controller: Re-queuing item %q due to error processing: %s", ControllerName, key, err.Error())
+				log.Errorf("re-queuing item due to error processing: %s", err.Error())
 				c.queue.AddRateLimited(obj)
 				return
 			}
-			glog.Infof("%s controller: Finished processing work item %q", ControllerName, key)
+			log.Infof("finished processing work item")
 			c.queue.Forget(obj)
 		}()
 	}
-	glog.V(4).Infof("Exiting %q worker loop", ControllerName)
+	logs.V(4).Infof("Exiting %q worker loop", ControllerName)
 }
 
 func (c *Controller) processNextWorkItem(ctx context.Context, key string) error {
@@ -205,6 +215,7 @@ func (c *Controller) processNextWorkItem(ctx context.Context, key string) error
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			runtime.HandleError(fmt.Errorf("order '%s' in work queue no longer exists", key))
+			c.forgetOrderPoll(key)
 			return nil
 		}
 
@@ -0,0 +1,42 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmeorders
+
+import (
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// scheduleOrderPoll requeues the Order to be checked again with the ACME
+// server after the next backoff interval.
+func (c *Controller) scheduleOrderPoll(o *cmapi.Order) {
+	key, err := keyFunc(o)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	delay := c.orderPollBackoff.When(key)
+	logs.V(4).Infof("Order %q is %q, checking its status again in %s", key, o.Status.State, delay)
+	c.queue.AddAfter(key, delay)
+}
+
+// forgetOrderPoll resets the backoff interval for the given Order key.
+func (c *Controller) forgetOrderPoll(key string) {
+	c.orderPollBackoff.Forget(key)
+}
@@ -19,21 +19,26 @@ package acmeorders
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 
-	"github.com/golang/glog"
 	"github.com/jetstack/cert-manager/pkg/acme"
 	acmecl "github.com/jetstack/cert-manager/pkg/acme/client"
 	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
 
@@ -51,6 +56,9 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 	oldOrder := o
 	o = o.DeepCopy()
 
+	// problem is the ACME error that caused the Order to fail, if any
+	var problem *acmeapi.Error
+
 	defer func() {
 		// TODO: replace with more efficient comparison
 		if reflect.DeepEqual(oldOrder.Status, o.Status) {
@@ -60,6 +68,11 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 		if err != nil {
 			err = utilerrors.NewAggregate([]error{err, updateErr})
 		}
+		// only record the result once it has been persisted, so that a
+		// failed update and retry does not count the Order twice
+		if updateErr == nil {
+			c.recordOrderResult(oldOrder, o, problem)
+		}
 	}()
 
 	genericIssuer, err := c.helper.GetGenericIssuer(o.Spec.IssuerRef, o.Namespace)
@@ -73,15 +86,27 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 	}
 
 	if o.Status.URL == "" {
+		// never place new orders with the ACME server in dry-run mode
+		if c.DryRun {
+			logs.Infof("Dry run: would have created order with ACME server for Order %s/%s", o.Namespace, o.Name)
+			c.Recorder.Event(o, corev1.EventTypeNormal, "DryRunCreateOrder", "Dry run: would have created order with ACME server")
+			return nil
+		}
+
 		err := c.createOrder(ctx, cl, genericIssuer, o)
 
 		if err != nil {
+			if c.requeueAfterRateLimit(o, err) {
+				return nil
+			}
+
 			// If we get a 4xx error, we mark the Order as 'error'.
 			// 4xx error codes include rate limit errors (429).
 			// This will cause the Certificate controller to retry the Order
 			// after the regular back-off algorithm has been applied.
 			acmeErr, ok := err.(*acmeapi.Error)
 			if ok && acmeErr.StatusCode >= 400 && acmeErr.StatusCode < 500 {
+				problem = acmeErr
 				c.setOrderState(&o.Status, cmapi.Errored)
 				o.Status.Reason = fmt.Sprintf("Failed to create order: %v", err)
 				return err
@@ -123,6 +148,11 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 			return err
 		}
 
+		certs, err = c.selectPreferredChain(ctx, cl, genericIssuer, acmeOrder.CertificateURL, certs)
+		if err != nil {
+			return err
+		}
+
 		err = c.storeCertificateOnStatus(o, certs)
 		if err != nil {
 			return err
@@ -138,6 +168,10 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 	// TODO: if the certificate bytes are nil, we should attempt to retrieve
 	// the certificate for the order using GetCertificate
 	if acme.IsFinalState(o.Status.State) {
+		if key, err := keyFunc(o); err == nil {
+			c.forgetOrderPoll(key)
+		}
+
 		existingChallenges, err := c.listChallengesForOrder(o)
 		if err != nil {
 			return err
@@ -169,12 +203,17 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 	case cmapi.Unknown:
 		err := c.syncOrderStatus(ctx, cl, o)
 		if err != nil {
+			if c.requeueAfterRateLimit(o, err) {
+				return nil
+			}
+
 			// If we get a 4xx error, we mark the Order as 'error'.
 			// 4xx error codes include rate limit errors (429).
 			// This will cause the Certificate controller to retry the Order
 			// after the regular back-off algorithm has been applied.
 			acmeErr, ok := err.(*acmeapi.Error)
 			if ok && acmeErr.StatusCode >= 400 && acmeErr.StatusCode < 500 {
+				problem = acmeErr
 				c.setOrderState(&o.Status, cmapi.Errored)
 				o.Status.Reason = fmt.Sprintf("Failed to create order: %v", err)
 				return nil
@@ -210,16 +249,35 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 		// then retrieve the Certificate resource.
 		errUpdate := c.syncOrderStatus(ctx, cl, o)
 		if errUpdate != nil {
+			if c.requeueAfterRateLimit(o, errUpdate) {
+				return nil
+			}
 			// TODO: mark permenant failure?
 			return fmt.Errorf("error syncing order status: %v", errUpdate)
 		}
 
 		// check for errors from FinalizeOrder
 		if err != nil {
+			if c.requeueAfterRateLimit(o, err) {
+				return nil
+			}
 			// TODO: check for acme error type and potentially mark order as errored
 			return fmt.Errorf("error finalizing order: %v", err)
 		}
 
+		// the certificate URL is not returned by FinalizeOrder, so we only
+		// fetch the order again if we may need to look up alternate chains
+		if preferredChainForIssuer(genericIssuer) != "" {
+			acmeOrder, err := cl.GetOrder(ctx, o.Status.URL)
+			if err != nil {
+				return fmt.Errorf("error retrieving order to look up alternate certificate chains: %v", err)
+			}
+			certSlice, err = c.selectPreferredChain(ctx, cl, genericIssuer, acmeOrder.CertificateURL, certSlice)
+			if err != nil {
+				return err
+			}
+		}
+
 		err = c.storeCertificateOnStatus(o, certSlice)
 		if err != nil {
 			// TODO: mark Order as 'errored'
@@ -271,7 +329,7 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 		specsToCreate[i] = s
 	}
 
-	glog.Infof("Need to create %d challenges", len(specsToCreate))
+	logs.Infof("Need to create %d challenges", len(specsToCreate))
 
 	// create a Challenge resource for each challenge we need to create.
 	var errs []error
@@ -313,18 +371,40 @@ func (c *Controller) Sync(ctx context.Context, o *cmapi.Order) (err error) {
 	}
 
 	if allChallengesValid || anyChallengesFailed {
+		state := o.Status.State
 		err = c.syncOrderStatus(ctx, cl, o)
 		if err != nil {
 			return err
 		}
+		// If the ACME server has not yet updated the order (e.g. whilst the
+		// authorizations are being validated or the order is processing),
+		// there will be no change to the resource to trigger a resync, so
+		// poll the order again after backing off.
+		if o.Status.State == state {
+			c.scheduleOrderPoll(o)
+		}
 		return nil
 	}
 
-	glog.Infof("Waiting for all challenges for order %q to enter 'valid' state", o.Name)
+	logs.Infof("Waiting for all challenges for order %q to enter 'valid' state", o.Name)
 
 	return nil
 }
 
+// recordOrderResult increments the ACME order result metric if o has entered
+// a final state since oldOrder was observed. The reason label is derived
+// from problem for failed Orders, as the reason on the status is free text.
+func (c *Controller) recordOrderResult(oldOrder, o *cmapi.Order, problem *acmeapi.Error) {
+	if acme.IsFinalState(oldOrder.Status.State) || !acme.IsFinalState(o.Status.State) {
+		return
+	}
+	reason := metrics.ACMEReasonNone
+	if acme.IsFailureState(o.Status.State) {
+		reason = metrics.ACMEProblemReason(problem)
+	}
+	c.metrics.IncrementACMEOrderResult(o.Spec.IssuerRef.Name, o.Status.State, reason)
+}
+
 func (c *Controller) listChallengesForOrder(o *cmapi.Order) ([]*cmapi.Challenge, error) {
 	// create a selector that we can use to find all existing Challenges for the order
 	sel, err := challengeSelectorForOrder(o)
@@ -351,8 +431,26 @@ func (c *Controller) createOrder(ctx context.Context, cl acmecl.Interface, issue
 	}
 	// create a new order with the acme server
 	orderTemplate := acmeapi.NewOrder(identifierSet.List()...)
+	// not all ACME servers allow the validity of a certificate to be
+	// requested, so notAfter is only sent if the issuer opts in
+	if o.Spec.Duration != nil && issuer.GetSpec().ACME.EnableDurationFeature {
+		orderTemplate.NotAfter = c.clock.Now().Add(o.Spec.Duration.Duration)
+	}
 	acmeOrder, err := cl.CreateOrder(ctx, orderTemplate)
+	// servers that do not support it may reject a requested notAfter as
+	// malformed, in which case the order is retried without it so that the
+	// server's default validity is used
+	if err != nil && !orderTemplate.NotAfter.IsZero() && acme.IsMalformed(err) {
+		logs.Infof("ACME server rejected requested notAfter for Order %s/%s, retrying without it: %v", o.Namespace, o.Name, err)
+		orderTemplate.NotAfter = time.Time{}
+		acmeOrder, err = cl.CreateOrder(ctx, orderTemplate)
+	}
 	if err != nil {
+		// return ACME errors as-is so that callers can inspect the status
+		// code and headers returned by the server
+		if acmeErr, ok := err.(*acmeapi.Error); ok {
+			return acmeErr
+		}
 		return fmt.Errorf("error creating new order: %v", err)
 	}
 
@@ -380,16 +478,24 @@ func (c *Controller) createOrder(ctx context.Context, cl acmecl.Interface, issue
 }
 
 func (c *Controller) challengeSpecForAuthorization(ctx context.Context, cl acmecl.Interface, issuer cmapi.GenericIssuer, o *cmapi.Order, authz *acmeapi.Authorization) (*cmapi.ChallengeSpec, error) {
-	cfg, err := solverConfigurationForAuthorization(o.Spec.Config, authz)
-	if err != nil {
-		return nil, err
-	}
-
 	acmeSpec := issuer.GetSpec().ACME
 	if acmeSpec == nil {
 		return nil, fmt.Errorf("issuer %q is not configured as an ACME Issuer. Cannot be used for creating ACME orders", issuer.GetObjectMeta().Name)
 	}
 
+	// solver configuration on the Certificate takes precedence over the
+	// solvers configured on the issuer
+	var cfg *cmapi.SolverConfig
+	var err error
+	if len(o.Spec.Config) > 0 {
+		cfg, err = solverConfigurationForAuthorization(o.Spec.Config, authz)
+	} else {
+		cfg, err = solverForAuthorization(acmeSpec.Solvers, o.Labels, authz)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	var challenge *acmeapi.Challenge
 	for _, ch := range authz.Challenges {
 		switch {
@@ -452,6 +558,57 @@ func solverConfigurationForAuthorization(cfgs []cmapi.DomainSolverConfig, authz
 	return nil, fmt.Errorf("solver configuration for domain %q not found. Ensure you have configured a challenge mechanism using the certificate.spec.acme.config field", domainToFind)
 }
 
+// solverForAuthorization selects the solver from the issuer's
+// spec.acme.solvers to use for the given authorization of an Order with the
+// given labels. Solvers whose matchLabels are not all present on the Order
+// are skipped. Of the remaining solvers, one listing the identifier in its
+// dnsNames selector is preferred over one matching it by dnsZones, and of
+// those matching by zone the one with the longest zone is used. Solvers that
+// select neither dnsNames nor dnsZones are only used if no other solver
+// matches. If more than one solver matches equally well, the first is used.
+func solverForAuthorization(solvers []cmapi.ACMEChallengeSolver, lbls map[string]string, authz *acmeapi.Authorization) (*cmapi.SolverConfig, error) {
+	domain := strings.ToLower(authz.Identifier.Value)
+	dnsName := domain
+	if authz.Wildcard {
+		dnsName = "*." + domain
+	}
+
+	var selected *cmapi.ACMEChallengeSolver
+	// bestZone is the length of the longest zone matched so far, or -1 if
+	// no solver has matched by zone
+	bestZone := -1
+	for i := range solvers {
+		s := &solvers[i]
+		if s.Selector != nil && !labels.SelectorFromSet(s.Selector.MatchLabels).Matches(labels.Set(lbls)) {
+			continue
+		}
+		if s.Selector == nil || (len(s.Selector.DNSNames) == 0 && len(s.Selector.DNSZones) == 0) {
+			if selected == nil {
+				selected = s
+			}
+			continue
+		}
+		for _, n := range s.Selector.DNSNames {
+			if strings.ToLower(n) == dnsName {
+				return &s.SolverConfig, nil
+			}
+		}
+		for _, z := range s.Selector.DNSZones {
+			z = strings.ToLower(strings.TrimSuffix(z, "."))
+			if (domain == z || strings.HasSuffix(domain, "."+z)) && len(z) > bestZone {
+				selected = s
+				bestZone = len(z)
+			}
+		}
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("no solver found for domain %q. Ensure you have configured a challenge mechanism using the certificate.spec.acme.config field or the issuer's spec.acme.solvers field", dnsName)
+	}
+
+	return &selected.SolverConfig, nil
+}
+
 // syncOrderStatus will communicate with the ACME server to retrieve the current
 // state of the Order. It will then update the Order's status block with the new
 // state of the order.
@@ -528,6 +685,25 @@ func challengeSelectorForOrder(o *cmapi.Order) (labels.Selector, error) {
 	return labels.NewSelector().Add(reqs...), nil
 }
 
+// requeueAfterRateLimit checks whether err is a rate limit error returned by
+// the ACME server along with a Retry-After header. If so, the Order is
+// requeued to be processed again after the requested delay and true is
+// returned, so that the caller does not apply the generic retry schedule on top.
+func (c *Controller) requeueAfterRateLimit(o *cmapi.Order, err error) bool {
+	delay, ok := acme.RetryAfter(err)
+	if !ok {
+		return false
+	}
+	key, kerr := keyFunc(o)
+	if kerr != nil {
+		runtime.HandleError(kerr)
+		return false
+	}
+	c.Recorder.Eventf(o, corev1.EventTypeWarning, "RateLimited", "ACME server rate limited the request, retrying in %s as requested by the server", delay)
+	c.queue.AddAfter(key, delay)
+	return true
+}
+
 // setOrderState will set the 'State' field of the given Order to 's'.
 // It will set the Orders failureTime field if the state provided is classed as
 // a failure state.
@@ -540,6 +716,59 @@ func (c *Controller) setOrderState(o *cmapi.OrderStatus, s cmapi.State) {
 	}
 }
 
+// preferredChainForIssuer returns the preferred chain configured on the ACME
+// issuer, if any.
+func preferredChainForIssuer(iss cmapi.GenericIssuer) string {
+	if iss.GetSpec().ACME == nil {
+		return ""
+	}
+	return iss.GetSpec().ACME.PreferredChain
+}
+
+// selectPreferredChain returns the certificate chain to store for an order.
+// If the issuer has a preferred chain configured and the default chain does
+// not match it, each of the alternate chains offered by the ACME server for
+// certURL is checked in turn and the first matching chain is returned.
+// If no chain matches, defaultChain is returned.
+func (c *Controller) selectPreferredChain(ctx context.Context, cl acmecl.Interface, iss cmapi.GenericIssuer, certURL string, defaultChain [][]byte) ([][]byte, error) {
+	preferred := preferredChainForIssuer(iss)
+	if preferred == "" || chainMatchesIssuer(defaultChain, preferred) {
+		return defaultChain, nil
+	}
+
+	altURLs, err := cl.ListCertAlternates(ctx, certURL)
+	if err != nil {
+		return nil, fmt.Errorf("error listing alternate certificate chains: %v", err)
+	}
+
+	for _, altURL := range altURLs {
+		chain, err := cl.GetCertificate(ctx, altURL)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving alternate certificate chain %q: %v", altURL, err)
+		}
+		if chainMatchesIssuer(chain, preferred) {
+			logs.Infof("Using alternate certificate chain %q issued by %q", altURL, preferred)
+			return chain, nil
+		}
+	}
+
+	logs.Infof("No certificate chain issued by %q offered by the ACME server, using the default chain", preferred)
+	return defaultChain, nil
+}
+
+// chainMatchesIssuer returns true if the topmost certificate in the given DER
+// encoded chain was issued by a CA with the given Common Name.
+func chainMatchesIssuer(chain [][]byte, issuerCN string) bool {
+	if len(chain) == 0 {
+		return false
+	}
+	top, err := x509.ParseCertificate(chain[len(chain)-1])
+	if err != nil {
+		return false
+	}
+	return top.Issuer.CommonName == issuerCN
+}
+
 func (c *Controller) storeCertificateOnStatus(o *cmapi.Order, certs [][]byte) error {
 	// encode the retrieved certificates (including the chain)
 	certBuffer := bytes.NewBuffer([]byte{})
@@ -18,6 +18,13 @@ package acmeorders
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -168,6 +175,30 @@ dGVzdA==
 			},
 			Err: false,
 		},
+		"requeue the order without marking it errored if the acme server rate limits order creation": {
+			Issuer: testIssuerHTTP01Enabled,
+			Order:  testOrder,
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testOrder},
+				ExpectedActions:    []testpkg.Action{},
+			},
+			Client: &acmecl.FakeACME{
+				FakeCreateOrder: func(ctx context.Context, o *acmeapi.Order) (*acmeapi.Order, error) {
+					return nil, &acmeapi.Error{
+						StatusCode: http.StatusTooManyRequests,
+						Type:       "urn:ietf:params:acme:error:rateLimited",
+						Header:     http.Header{"Retry-After": []string{"3600"}},
+					}
+				},
+			},
+			CheckFn: func(t *testing.T, s *controllerFixture, args ...interface{}) {
+				o := args[0].(*v1alpha1.Order)
+				if o.Status.State != "" {
+					t.Errorf("expected order state to be unset but got %q", o.Status.State)
+				}
+			},
+			Err: false,
+		},
 		"create a challenge resource for the test.com dnsName on the order": {
 			Issuer: testIssuerHTTP01Enabled,
 			Order:  testOrderPending,
@@ -232,6 +263,44 @@ dGVzdA==
 			},
 			Err: false,
 		},
+		"poll the order again if all challenges are 'valid' but the acme order is still 'pending'": {
+			Order: testOrderPending,
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testOrderPending, testAuthorizationChallengeValid},
+				ExpectedActions:    []testpkg.Action{},
+			},
+			Client: &acmecl.FakeACME{
+				FakeGetOrder: func(_ context.Context, url string) (*acmeapi.Order, error) {
+					return testACMEOrderPending, nil
+				},
+			},
+			CheckFn: func(t *testing.T, s *controllerFixture, args ...interface{}) {
+				if n := s.Controller.orderPollBackoff.NumRequeues("default/testorder"); n != 1 {
+					t.Errorf("expected the order to be scheduled to be polled once, got %d", n)
+				}
+			},
+			Err: false,
+		},
+		"do not poll the order if the acme order state has changed": {
+			Order: testOrderPending,
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testOrderPending, testAuthorizationChallengeValid},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateAction(v1alpha1.SchemeGroupVersion.WithResource("orders"), testOrderReady.Namespace, testOrderReady)),
+				},
+			},
+			Client: &acmecl.FakeACME{
+				FakeGetOrder: func(_ context.Context, url string) (*acmeapi.Order, error) {
+					return testACMEOrderReady, nil
+				},
+			},
+			CheckFn: func(t *testing.T, s *controllerFixture, args ...interface{}) {
+				if n := s.Controller.orderPollBackoff.NumRequeues("default/testorder"); n != 0 {
+					t.Errorf("expected the order not to be polled, got %d", n)
+				}
+			},
+			Err: false,
+		},
 		"call GetOrder and update the order state if the challenge is 'failed'": {
 			Order: testOrderPending,
 			Builder: &testpkg.Builder{
@@ -463,3 +532,392 @@ func TestSolverConfigurationForAuthorization(t *testing.T) {
 		})
 	}
 }
+
+func TestSolverForAuthorization(t *testing.T) {
+	dns01Solver := func(provider string, sel *v1alpha1.CertificateDNSNameSelector) v1alpha1.ACMEChallengeSolver {
+		return v1alpha1.ACMEChallengeSolver{
+			Selector: sel,
+			SolverConfig: v1alpha1.SolverConfig{
+				DNS01: &v1alpha1.DNS01SolverConfig{Provider: provider},
+			},
+		}
+	}
+	solvers := []v1alpha1.ACMEChallengeSolver{
+		{
+			SolverConfig: v1alpha1.SolverConfig{
+				HTTP01: &v1alpha1.HTTP01SolverConfig{},
+			},
+		},
+		dns01Solver("route53", &v1alpha1.CertificateDNSNameSelector{
+			DNSZones: []string{"example.com"},
+		}),
+		dns01Solver("clouddns", &v1alpha1.CertificateDNSNameSelector{
+			DNSZones: []string{"example.org", "prod.example.com"},
+		}),
+		dns01Solver("cloudflare", &v1alpha1.CertificateDNSNameSelector{
+			DNSNames: []string{"www.prod.example.com", "*.example.org"},
+		}),
+	}
+	type testT struct {
+		solvers     []v1alpha1.ACMEChallengeSolver
+		labels      map[string]string
+		authz       *acmeapi.Authorization
+		expectedCfg *v1alpha1.SolverConfig
+		expectedErr bool
+	}
+	authz := func(domain string, wildcard bool) *acmeapi.Authorization {
+		return &acmeapi.Authorization{
+			Wildcard:   wildcard,
+			Identifier: acmeapi.AuthzID{Value: domain},
+		}
+	}
+	tests := map[string]testT{
+		"selects the solver for a zone apex": {
+			solvers:     solvers,
+			authz:       authz("example.com", false),
+			expectedCfg: &solvers[1].SolverConfig,
+		},
+		"selects the solver for a subdomain of a zone": {
+			solvers:     solvers,
+			authz:       authz("www.example.com", false),
+			expectedCfg: &solvers[1].SolverConfig,
+		},
+		"selects the solver with the longest matching zone": {
+			solvers:     solvers,
+			authz:       authz("api.prod.example.com", false),
+			expectedCfg: &solvers[2].SolverConfig,
+		},
+		"prefers a solver matching by dns name over one matching by zone": {
+			solvers:     solvers,
+			authz:       authz("www.prod.example.com", false),
+			expectedCfg: &solvers[3].SolverConfig,
+		},
+		"matches wildcard identifiers by dns name": {
+			solvers:     solvers,
+			authz:       authz("example.org", true),
+			expectedCfg: &solvers[3].SolverConfig,
+		},
+		"matches wildcard identifiers by zone": {
+			solvers:     solvers,
+			authz:       authz("example.com", true),
+			expectedCfg: &solvers[1].SolverConfig,
+		},
+		"does not match a zone that is only a suffix of the domain": {
+			solvers:     solvers,
+			authz:       authz("notexample.com", false),
+			expectedCfg: &solvers[0].SolverConfig,
+		},
+		"uses the first solver without a selector if no selector matches": {
+			solvers:     solvers,
+			authz:       authz("example.net", false),
+			expectedCfg: &solvers[0].SolverConfig,
+		},
+		"returns an error when no solver matches": {
+			solvers:     solvers[1:],
+			authz:       authz("example.net", false),
+			expectedErr: true,
+		},
+	}
+	for n, test := range tests {
+		t.Run(n, func(t *testing.T) {
+			actualCfg, err := solverForAuthorization(test.solvers, test.labels, test.authz)
+			if err != nil && !test.expectedErr {
+				t.Errorf("Expected to return non-nil error, but got %v", err)
+				return
+			}
+			if err == nil && test.expectedErr {
+				t.Errorf("Expected error, but got none")
+				return
+			}
+			if !reflect.DeepEqual(test.expectedCfg, actualCfg) {
+				t.Errorf("Expected did not equal actual: %v", diff.ObjectDiff(test.expectedCfg, actualCfg))
+			}
+		})
+	}
+}
+
+func TestSolverForAuthorizationMatchLabels(t *testing.T) {
+	solvers := []v1alpha1.ACMEChallengeSolver{
+		{
+			Selector: &v1alpha1.CertificateDNSNameSelector{
+				MatchLabels: map[string]string{"exposure": "internal"},
+			},
+			SolverConfig: v1alpha1.SolverConfig{
+				DNS01: &v1alpha1.DNS01SolverConfig{Provider: "clouddns"},
+			},
+		},
+		{
+			Selector: &v1alpha1.CertificateDNSNameSelector{
+				MatchLabels: map[string]string{"exposure": "internal", "team": "payments"},
+				DNSZones:    []string{"example.com"},
+			},
+			SolverConfig: v1alpha1.SolverConfig{
+				DNS01: &v1alpha1.DNS01SolverConfig{Provider: "route53"},
+			},
+		},
+		{
+			Selector: &v1alpha1.CertificateDNSNameSelector{
+				MatchLabels: map[string]string{"exposure": "public"},
+			},
+			SolverConfig: v1alpha1.SolverConfig{
+				HTTP01: &v1alpha1.HTTP01SolverConfig{},
+			},
+		},
+	}
+	type testT struct {
+		labels      map[string]string
+		expectedCfg *v1alpha1.SolverConfig
+		expectedErr bool
+	}
+	tests := map[string]testT{
+		"selects the dns01 solver for internal certificates": {
+			labels:      map[string]string{"exposure": "internal"},
+			expectedCfg: &solvers[0].SolverConfig,
+		},
+		"selects the http01 solver for public certificates": {
+			labels:      map[string]string{"exposure": "public", "team": "payments"},
+			expectedCfg: &solvers[2].SolverConfig,
+		},
+		"prefers a solver matching by zone among those matching the labels": {
+			labels:      map[string]string{"exposure": "internal", "team": "payments"},
+			expectedCfg: &solvers[1].SolverConfig,
+		},
+		"returns an error when no solver matches the labels": {
+			labels:      map[string]string{"team": "payments"},
+			expectedErr: true,
+		},
+		"returns an error when the certificate has no labels": {
+			expectedErr: true,
+		},
+	}
+	authz := &acmeapi.Authorization{Identifier: acmeapi.AuthzID{Value: "www.example.com"}}
+	for n, test := range tests {
+		t.Run(n, func(t *testing.T) {
+			actualCfg, err := solverForAuthorization(solvers, test.labels, authz)
+			if err != nil && !test.expectedErr {
+				t.Errorf("Expected to return non-nil error, but got %v", err)
+				return
+			}
+			if err == nil && test.expectedErr {
+				t.Errorf("Expected error, but got none")
+				return
+			}
+			if !reflect.DeepEqual(test.expectedCfg, actualCfg) {
+				t.Errorf("Expected did not equal actual: %v", diff.ObjectDiff(test.expectedCfg, actualCfg))
+			}
+		})
+	}
+}
+
+func TestSelectPreferredChain(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// buildChain returns a DER encoded chain of a leaf certificate and an
+	// intermediate certificate that was issued by a CA named issuerCN
+	buildChain := func(issuerCN string) [][]byte {
+		var chain [][]byte
+		for _, names := range [][2]string{{"example.com", "Intermediate"}, {"Intermediate", issuerCN}} {
+			tmpl := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: names[0]},
+				NotBefore:    time.Now(),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+			parent := &x509.Certificate{Subject: pkix.Name{CommonName: names[1]}}
+			der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chain = append(chain, der)
+		}
+		return chain
+	}
+
+	defaultChain := buildChain("Default Root")
+	alternateChain := buildChain("Alternate Root")
+	alternates := map[string][][]byte{
+		"http://testurl.com/cert/1": buildChain("Other Root"),
+		"http://testurl.com/cert/2": alternateChain,
+	}
+	fakeClient := &acmecl.FakeACME{
+		FakeListCertAlternates: func(_ context.Context, url string) ([]string, error) {
+			if url != "http://testurl.com/cert" {
+				t.Errorf("unexpected certificate URL %q", url)
+			}
+			return []string{"http://testurl.com/cert/1", "http://testurl.com/cert/2"}, nil
+		},
+		FakeGetCertificate: func(_ context.Context, url string) ([][]byte, error) {
+			chain, ok := alternates[url]
+			if !ok {
+				t.Errorf("unexpected alternate certificate URL %q", url)
+			}
+			return chain, nil
+		},
+	}
+
+	tests := map[string]struct {
+		preferredChain string
+		expected       [][]byte
+	}{
+		"no preferred chain uses the default chain": {
+			expected: defaultChain,
+		},
+		"preferred chain matching the default chain uses the default chain": {
+			preferredChain: "Default Root",
+			expected:       defaultChain,
+		},
+		"preferred chain matching an alternate chain uses the alternate chain": {
+			preferredChain: "Alternate Root",
+			expected:       alternateChain,
+		},
+		"preferred chain matching no chain falls back to the default chain": {
+			preferredChain: "Unknown Root",
+			expected:       defaultChain,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			iss := &v1alpha1.Issuer{
+				Spec: v1alpha1.IssuerSpec{
+					IssuerConfig: v1alpha1.IssuerConfig{
+						ACME: &v1alpha1.ACMEIssuer{PreferredChain: test.preferredChain},
+					},
+				},
+			}
+			c := &Controller{}
+			chain, err := c.selectPreferredChain(context.Background(), fakeClient, iss, "http://testurl.com/cert", defaultChain)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(chain, test.expected) {
+				t.Errorf("selected an unexpected certificate chain")
+			}
+		})
+	}
+}
+
+func TestBuildChallengeOwnerReference(t *testing.T) {
+	o := &v1alpha1.Order{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-order", Namespace: "default", UID: "test-order-uid"},
+	}
+	ch := buildChallenge(0, o, v1alpha1.ChallengeSpec{DNSName: "example.com"})
+	refs := ch.OwnerReferences
+	if len(refs) != 1 {
+		t.Fatalf("expected one owner reference, got %v", refs)
+	}
+	if refs[0].Kind != "Order" || refs[0].Name != o.Name || refs[0].UID != o.UID {
+		t.Errorf("expected challenge to be owned by order %q, got %+v", o.Name, refs[0])
+	}
+	if refs[0].Controller == nil || !*refs[0].Controller {
+		t.Errorf("expected the owner reference to be a controller reference")
+	}
+}
+
+func TestCreateOrderNotAfter(t *testing.T) {
+	nowTime := time.Now()
+	fixedClock := fakeclock.NewFakeClock(nowTime)
+	duration := time.Hour * 24 * 30
+
+	testOrder := &v1alpha1.Order{
+		ObjectMeta: metav1.ObjectMeta{Name: "testorder", Namespace: "default"},
+		Spec: v1alpha1.OrderSpec{
+			CommonName: "test.com",
+		},
+	}
+	testOrderWithDuration := testOrder.DeepCopy()
+	testOrderWithDuration.Spec.Duration = &metav1.Duration{Duration: duration}
+
+	durationIssuer := &v1alpha1.Issuer{
+		Spec: v1alpha1.IssuerSpec{
+			IssuerConfig: v1alpha1.IssuerConfig{
+				ACME: &v1alpha1.ACMEIssuer{EnableDurationFeature: true},
+			},
+		},
+	}
+
+	malformed := &acmeapi.Error{
+		StatusCode: http.StatusBadRequest,
+		Type:       "urn:ietf:params:acme:error:malformed",
+		Detail:     "NotBefore and NotAfter are not supported",
+	}
+
+	tests := map[string]struct {
+		issuer v1alpha1.GenericIssuer
+		order  *v1alpha1.Order
+		// errFn is called with the order passed to CreateOrder
+		errFn             func(*acmeapi.Order) error
+		expectedNotAfters []time.Time
+		expectErr         bool
+	}{
+		"order without a duration does not request a notAfter": {
+			issuer:            durationIssuer,
+			order:             testOrder,
+			expectedNotAfters: []time.Time{{}},
+		},
+		"order with a duration requests a notAfter if the issuer enables it": {
+			issuer:            durationIssuer,
+			order:             testOrderWithDuration,
+			expectedNotAfters: []time.Time{nowTime.Add(duration)},
+		},
+		"order with a duration does not request a notAfter by default": {
+			order:             testOrderWithDuration,
+			expectedNotAfters: []time.Time{{}},
+		},
+		"order is retried without a notAfter if the acme server does not support it": {
+			issuer: durationIssuer,
+			order:  testOrderWithDuration,
+			errFn: func(o *acmeapi.Order) error {
+				if !o.NotAfter.IsZero() {
+					return malformed
+				}
+				return nil
+			},
+			expectedNotAfters: []time.Time{nowTime.Add(duration), {}},
+		},
+		"order without a notAfter is not retried if it is malformed": {
+			issuer: durationIssuer,
+			order:  testOrder,
+			errFn: func(o *acmeapi.Order) error {
+				return malformed
+			},
+			expectedNotAfters: []time.Time{{}},
+			expectErr:         true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var notAfters []time.Time
+			f := &controllerFixture{
+				Issuer: test.issuer,
+				Order:  test.order,
+				Clock:  fixedClock,
+				Client: &acmecl.FakeACME{
+					FakeCreateOrder: func(ctx context.Context, o *acmeapi.Order) (*acmeapi.Order, error) {
+						notAfters = append(notAfters, o.NotAfter)
+						if test.errFn != nil {
+							if err := test.errFn(o); err != nil {
+								return nil, err
+							}
+						}
+						return &acmeapi.Order{URL: "http://testurl.com/abcde", Status: acmeapi.StatusPending}, nil
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Builder.Stop()
+
+			err := f.Controller.createOrder(f.Ctx, f.Client, f.Issuer, test.order.DeepCopy())
+			if err != nil && !test.expectErr {
+				t.Errorf("expected no error, but got: %v", err)
+			}
+			if err == nil && test.expectErr {
+				t.Errorf("expected an error, but got none")
+			}
+			if !reflect.DeepEqual(notAfters, test.expectedNotAfters) {
+				t.Errorf("expected CreateOrder to be called with notAfter %v, got %v", test.expectedNotAfters, notAfters)
+			}
+		})
+	}
+}
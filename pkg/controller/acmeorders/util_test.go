@@ -18,7 +18,7 @@ package acmeorders
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"fmt"
 	"testing"
 	"time"
@@ -120,6 +120,6 @@ func (f *controllerFixture) ClientForIssuer(iss v1alpha1.GenericIssuer) (client.
 	return f.Client, nil
 }
 
-func (f *controllerFixture) ReadPrivateKey(sel v1alpha1.SecretKeySelector, ns string) (*rsa.PrivateKey, error) {
+func (f *controllerFixture) ReadPrivateKey(sel v1alpha1.SecretKeySelector, ns string) (crypto.Signer, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+
+	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util"
+)
+
+type Controller struct {
+	controllerpkg.Context
+
+	helper controllerpkg.Helper
+
+	// To allow injection for testing.
+	syncHandler func(ctx context.Context, key string) error
+
+	certificateRequestLister cmlisters.CertificateRequestLister
+	issuerLister             cmlisters.IssuerLister
+	clusterIssuerLister      cmlisters.ClusterIssuerLister
+
+	watchedInformers []cache.InformerSynced
+	queue            workqueue.RateLimitingInterface
+
+	// used for testing
+	clock clock.Clock
+}
+
+func New(ctx *controllerpkg.Context) *Controller {
+	ctrl := &Controller{Context: *ctx}
+	ctrl.syncHandler = ctrl.processNextWorkItem
+
+	ctrl.queue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second*5, time.Minute*30), ControllerName)
+
+	certificateRequestInformer := ctrl.SharedInformerFactory.Certmanager().V1alpha1().CertificateRequests()
+	certificateRequestInformer.Informer().AddEventHandler(&controllerpkg.QueuingEventHandler{Queue: ctrl.queue})
+	ctrl.watchedInformers = append(ctrl.watchedInformers, certificateRequestInformer.Informer().HasSynced)
+	ctrl.certificateRequestLister = certificateRequestInformer.Lister()
+
+	issuerInformer := ctrl.SharedInformerFactory.Certmanager().V1alpha1().Issuers()
+	issuerInformer.Informer().AddEventHandler(&controllerpkg.BlockingEventHandler{WorkFunc: ctrl.handleGenericIssuer})
+	ctrl.watchedInformers = append(ctrl.watchedInformers, issuerInformer.Informer().HasSynced)
+	ctrl.issuerLister = issuerInformer.Lister()
+
+	if ctx.Namespace == "" {
+		clusterIssuerInformer := ctrl.SharedInformerFactory.Certmanager().V1alpha1().ClusterIssuers()
+		clusterIssuerInformer.Informer().AddEventHandler(&controllerpkg.BlockingEventHandler{WorkFunc: ctrl.handleGenericIssuer})
+		ctrl.watchedInformers = append(ctrl.watchedInformers, clusterIssuerInformer.Informer().HasSynced)
+		ctrl.clusterIssuerLister = clusterIssuerInformer.Lister()
+	}
+
+	// issuers read the Secrets they sign with from this informer
+	secretInformer := ctrl.KubeSharedInformerFactory.Core().V1().Secrets()
+	ctrl.watchedInformers = append(ctrl.watchedInformers, secretInformer.Informer().HasSynced)
+
+	ctrl.helper = controllerpkg.NewHelper(ctrl.issuerLister, ctrl.clusterIssuerLister)
+	ctrl.clock = clock.RealClock{}
+
+	return ctrl
+}
+
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
+	logs.V(4).Infof("Starting %s control loop", ControllerName)
+	// wait for all the informer caches we depend on are synced
+	if !cache.WaitForCacheSync(stopCh, c.watchedInformers...) {
+		return fmt.Errorf("error waiting for informer caches to sync")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go wait.Until(func() {
+			defer wg.Done()
+			c.worker(stopCh)
+		},
+			time.Second, stopCh)
+	}
+	<-stopCh
+	logs.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
+	c.queue.ShutDown()
+	logs.V(4).Infof("Waiting for workers to exit...")
+	wg.Wait()
+	logs.V(4).Infof("Workers exited.")
+	return nil
+}
+
+func (c *Controller) worker(stopCh <-chan struct{}) {
+	logs.V(4).Infof("Starting %q worker", ControllerName)
+	for {
+		obj, shutdown := c.queue.Get()
+		if shutdown {
+			break
+		}
+
+		var key string
+		// use an inlined function so we can use defer
+		func() {
+			defer c.queue.Done(obj)
+			var ok bool
+			if key, ok = obj.(string); !ok {
+				return
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctx = util.ContextWithStopCh(ctx, stopCh)
+			log := logs.WithValues("controller", ControllerName, "key", key)
+			log.Infof("syncing item")
+			if err := c.syncHandler(ctx, key); err != nil {
+				log.Errorf("re-queuing item due to error processing: %s", err.Error())
+				c.queue.AddRateLimited(obj)
+				return
+			}
+			log.Infof("finished processing work item")
+			c.queue.Forget(obj)
+		}()
+	}
+	logs.V(4).Infof("Exiting %q worker loop", ControllerName)
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	cr, err := c.certificateRequestLister.CertificateRequests(namespace).Get(name)
+
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			runtime.HandleError(fmt.Errorf("certificaterequest '%s' in work queue no longer exists", key))
+			return nil
+		}
+
+		return err
+	}
+
+	return c.Sync(ctx, cr)
+}
+
+var keyFunc = controllerpkg.KeyFunc
+
+const (
+	ControllerName = "certificaterequests"
+)
+
+func init() {
+	controllerpkg.Register(ControllerName, func(ctx *controllerpkg.Context) controllerpkg.Interface {
+		return New(ctx).Run
+	})
+}
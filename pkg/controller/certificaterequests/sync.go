@@ -0,0 +1,195 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequests
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+)
+
+const (
+	errorIssuerNotFound    = "IssuerNotFound"
+	errorIssuerNotReady    = "IssuerNotReady"
+	errorIssuerInit        = "IssuerInitError"
+	errorIssuerUnsupported = "IssuerUnsupported"
+	errorSigning           = "ErrorSigning"
+	errorIssuerUnavailable = "IssuerUnavailable"
+	errorRequestDenied     = "RequestDenied"
+
+	successCertificateIssued = "CertificateIssued"
+)
+
+// Sync will sign the certificate signing request of the given
+// CertificateRequest using the referenced Issuer, and store the signed
+// certificate in its status.
+// Requests that have been issued or have failed are never signed again.
+// Requests that have been denied are failed, and if approval is required,
+// requests are only signed once they have been approved.
+func (c *Controller) Sync(ctx context.Context, cr *cmapi.CertificateRequest) (err error) {
+	crCopy := cr.DeepCopy()
+	defer func() {
+		if _, saveErr := c.updateCertificateRequestStatus(cr, crCopy); saveErr != nil {
+			err = utilerrors.NewAggregate([]error{saveErr, err})
+		}
+	}()
+
+	if isFinal(crCopy) {
+		return nil
+	}
+
+	if cond := trueCondition(crCopy, cmapi.CertificateRequestConditionDenied); cond != nil {
+		s := "The CertificateRequest was denied by an approver"
+		if cond.Message != "" {
+			s = fmt.Sprintf("%s: %s", s, cond.Message)
+		}
+		c.Recorder.Event(crCopy, corev1.EventTypeWarning, errorRequestDenied, s)
+		c.setDenied(crCopy, s)
+		return nil
+	}
+
+	if c.CertificateRequestOptions.RequireApproval && trueCondition(crCopy, cmapi.CertificateRequestConditionApproved) == nil {
+		c.setPending(crCopy, "Waiting for the CertificateRequest to be approved")
+		return nil
+	}
+
+	issuerObj, err := c.helper.GetGenericIssuer(crCopy.Spec.IssuerRef, crCopy.Namespace)
+	if k8sErrors.IsNotFound(err) {
+		c.Recorder.Event(crCopy, corev1.EventTypeWarning, errorIssuerNotFound, err.Error())
+		c.setPending(crCopy, fmt.Sprintf("Referenced issuer %q not found", crCopy.Spec.IssuerRef.Name))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	issuerReady := issuerObj.HasCondition(cmapi.IssuerCondition{
+		Type:   cmapi.IssuerConditionReady,
+		Status: cmapi.ConditionTrue,
+	})
+	if !issuerReady {
+		c.Recorder.Eventf(crCopy, corev1.EventTypeWarning, errorIssuerNotReady, "Issuer %s not ready", issuerObj.GetObjectMeta().Name)
+		c.setPending(crCopy, fmt.Sprintf("Referenced issuer %q is not ready", issuerObj.GetObjectMeta().Name))
+		return nil
+	}
+
+	i, err := c.IssuerFactory().IssuerFor(issuerObj)
+	if err != nil {
+		c.Recorder.Eventf(crCopy, corev1.EventTypeWarning, errorIssuerInit, "Internal error initialising issuer: %v", err)
+		return nil
+	}
+
+	signer, ok := i.(issuer.Signer)
+	if !ok {
+		s := fmt.Sprintf("Referenced issuer %q does not support CertificateRequests", issuerObj.GetObjectMeta().Name)
+		c.Recorder.Event(crCopy, corev1.EventTypeWarning, errorIssuerUnsupported, s)
+		c.setFailed(crCopy, s)
+		return nil
+	}
+
+	// if the issuer has failed too many times in a row, try again once its
+	// cooldown has passed
+	if ok, wait := c.IssuerBreaker.Allow(issuerObj); !ok {
+		s := fmt.Sprintf("Issuer %s is temporarily unavailable after repeated failures, retrying in %s", issuerObj.GetObjectMeta().Name, wait)
+		c.Recorder.Event(crCopy, corev1.EventTypeWarning, errorIssuerUnavailable, s)
+		c.setPending(crCopy, s)
+		key, err := keyFunc(crCopy)
+		if err != nil {
+			return err
+		}
+		c.queue.AddAfter(key, wait)
+		return nil
+	}
+
+	resp, err := signer.Sign(ctx, crCopy)
+	if errors.IsInvalidData(err) {
+		s := fmt.Sprintf("Failed to sign certificate request: %v", err)
+		c.Recorder.Event(crCopy, corev1.EventTypeWarning, errorSigning, s)
+		c.setFailed(crCopy, s)
+		return nil
+	}
+	// requests that can never be signed are not counted as failures of the
+	// issuer
+	c.IssuerBreaker.Record(issuerObj, err)
+	if err != nil {
+		c.Recorder.Eventf(crCopy, corev1.EventTypeWarning, errorSigning, "Error signing certificate request: %v", err)
+		c.setPending(crCopy, fmt.Sprintf("Error signing certificate request: %v", err))
+		return err
+	}
+
+	crCopy.Status.Certificate = resp.Certificate
+	crCopy.Status.CA = resp.CA
+	crCopy.UpdateStatusCondition(cmapi.CertificateRequestConditionReady, cmapi.ConditionTrue, cmapi.CertificateRequestReasonIssued, "Certificate issued successfully")
+	c.Recorder.Event(crCopy, corev1.EventTypeNormal, successCertificateIssued, "Certificate issued successfully")
+
+	return nil
+}
+
+// isFinal returns true if the CertificateRequest has been issued or has
+// failed, and so will not be signed again.
+func isFinal(cr *cmapi.CertificateRequest) bool {
+	return cr.Status.FailureTime != nil || cr.HasCondition(cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmapi.ConditionTrue,
+		Reason: cmapi.CertificateRequestReasonIssued,
+	})
+}
+
+// trueCondition returns the condition of the given type if its status is
+// True, or nil otherwise.
+func trueCondition(cr *cmapi.CertificateRequest, conditionType cmapi.CertificateRequestConditionType) *cmapi.CertificateRequestCondition {
+	for i, cond := range cr.Status.Conditions {
+		if cond.Type == conditionType && cond.Status == cmapi.ConditionTrue {
+			return &cr.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func (c *Controller) setPending(cr *cmapi.CertificateRequest, message string) {
+	cr.UpdateStatusCondition(cmapi.CertificateRequestConditionReady, cmapi.ConditionFalse, cmapi.CertificateRequestReasonPending, message)
+}
+
+func (c *Controller) setFailed(cr *cmapi.CertificateRequest, message string) {
+	nowTime := metav1.NewTime(c.clock.Now())
+	cr.Status.FailureTime = &nowTime
+	cr.UpdateStatusCondition(cmapi.CertificateRequestConditionReady, cmapi.ConditionFalse, cmapi.CertificateRequestReasonFailed, message)
+}
+
+func (c *Controller) setDenied(cr *cmapi.CertificateRequest, message string) {
+	nowTime := metav1.NewTime(c.clock.Now())
+	cr.Status.FailureTime = &nowTime
+	cr.UpdateStatusCondition(cmapi.CertificateRequestConditionReady, cmapi.ConditionFalse, cmapi.CertificateRequestReasonDenied, message)
+}
+
+func (c *Controller) updateCertificateRequestStatus(old, new *cmapi.CertificateRequest) (*cmapi.CertificateRequest, error) {
+	if reflect.DeepEqual(old.Status, new.Status) {
+		return nil, nil
+	}
+	// the status, including the Approved and Denied conditions, can only be
+	// written through the /status subresource
+	return c.CMClient.CertmanagerV1alpha1().CertificateRequests(new.Namespace).UpdateStatus(new)
+}
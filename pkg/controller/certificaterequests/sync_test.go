@@ -0,0 +1,385 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequests
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclock "k8s.io/utils/clock/testing"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	_ "github.com/jetstack/cert-manager/pkg/issuer/ca"
+	_ "github.com/jetstack/cert-manager/pkg/issuer/selfsigned"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func generateCSR(t *testing.T, key crypto.Signer, commonName string) []byte {
+	der, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		t.Fatalf("error generating certificate request: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func tlsSecret(t *testing.T, name string, key crypto.Signer, cert []byte) *corev1.Secret {
+	keyPEM, err := pki.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding private key: %v", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			corev1.TLSPrivateKeyKey: keyPEM,
+			corev1.TLSCertKey:       cert,
+		},
+	}
+}
+
+func TestSync(t *testing.T) {
+	caKey, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	caTemplate, err := pki.GenerateTemplate(
+		gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})),
+		gen.Certificate("root-ca", gen.SetCertificateCommonName("root-ca"), gen.SetCertificateIsCA(true)),
+	)
+	if err != nil {
+		t.Fatalf("error generating CA template: %v", err)
+	}
+	caPEM, _, err := pki.SignCertificate(caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("error signing CA certificate: %v", err)
+	}
+
+	key, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+
+	readyCondition := v1alpha1.IssuerCondition{
+		Type:   v1alpha1.IssuerConditionReady,
+		Status: v1alpha1.ConditionTrue,
+	}
+	caIssuer := gen.Issuer("ca-issuer",
+		gen.SetIssuerCA(v1alpha1.CAIssuer{SecretName: "root-ca"}),
+		gen.AddIssuerCondition(readyCondition),
+	)
+	selfSignedIssuer := gen.Issuer("selfsigned-issuer",
+		gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}),
+		gen.AddIssuerCondition(readyCondition),
+	)
+
+	baseCR := gen.CertificateRequest("test-cr",
+		gen.SetCertificateRequestCSR(generateCSR(t, key, "example.com")),
+		gen.SetCertificateRequestIssuer(v1alpha1.ObjectReference{Name: "ca-issuer"}),
+	)
+
+	tests := map[string]struct {
+		cr          *v1alpha1.CertificateRequest
+		kubeObjects []runtime.Object
+		cmObjects   []runtime.Object
+		reason      string
+		err         bool
+		// breakerOpen opens the circuit breaker of the CA issuer
+		breakerOpen bool
+	}{
+		"issue a CertificateRequest using a CA issuer": {
+			cr:          baseCR.DeepCopy(),
+			kubeObjects: []runtime.Object{tlsSecret(t, "root-ca", caKey, caPEM)},
+			cmObjects:   []runtime.Object{caIssuer},
+			reason:      v1alpha1.CertificateRequestReasonIssued,
+		},
+		"issue a CertificateRequest using a SelfSigned issuer": {
+			cr: gen.CertificateRequestFrom(baseCR.DeepCopy(),
+				gen.SetCertificateRequestIssuer(v1alpha1.ObjectReference{Name: "selfsigned-issuer"}),
+				gen.SetCertificateRequestAnnotations(map[string]string{
+					v1alpha1.PrivateKeySecretNameAnnotationKey: "test-key",
+				}),
+			),
+			kubeObjects: []runtime.Object{tlsSecret(t, "test-key", key, nil)},
+			cmObjects:   []runtime.Object{selfSignedIssuer},
+			reason:      v1alpha1.CertificateRequestReasonIssued,
+		},
+		"fail a CertificateRequest with an invalid certificate signing request": {
+			cr:          gen.CertificateRequestFrom(baseCR.DeepCopy(), gen.SetCertificateRequestCSR([]byte("invalid"))),
+			kubeObjects: []runtime.Object{tlsSecret(t, "root-ca", caKey, caPEM)},
+			cmObjects:   []runtime.Object{caIssuer},
+			reason:      v1alpha1.CertificateRequestReasonFailed,
+		},
+		"leave a CertificateRequest pending if its issuer does not exist": {
+			cr:     baseCR.DeepCopy(),
+			reason: v1alpha1.CertificateRequestReasonPending,
+		},
+		"leave a CertificateRequest pending if its issuer is not ready": {
+			cr: baseCR.DeepCopy(),
+			cmObjects: []runtime.Object{gen.Issuer("ca-issuer",
+				gen.SetIssuerCA(v1alpha1.CAIssuer{SecretName: "root-ca"}),
+			)},
+			reason: v1alpha1.CertificateRequestReasonPending,
+		},
+		"leave a CertificateRequest pending if its issuer is temporarily unavailable": {
+			cr:          baseCR.DeepCopy(),
+			kubeObjects: []runtime.Object{tlsSecret(t, "root-ca", caKey, caPEM)},
+			cmObjects:   []runtime.Object{caIssuer},
+			reason:      v1alpha1.CertificateRequestReasonPending,
+			breakerOpen: true,
+		},
+		"retry a CertificateRequest if its issuer cannot sign yet": {
+			cr:        baseCR.DeepCopy(),
+			cmObjects: []runtime.Object{caIssuer},
+			reason:    v1alpha1.CertificateRequestReasonPending,
+			err:       true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &test.Builder{
+				KubeObjects:        tc.kubeObjects,
+				CertManagerObjects: append(tc.cmObjects, tc.cr),
+			}
+			b.Start()
+			defer b.Stop()
+			if tc.breakerOpen {
+				b.Context.IssuerBreaker = controllerpkg.NewIssuerBreaker(b.CMClient, 1, time.Hour, time.Hour)
+				b.Context.IssuerBreaker.Record(caIssuer, fmt.Errorf("backend unavailable"))
+			}
+			c := New(b.Context)
+			c.clock = fakeclock.NewFakeClock(time.Now())
+			b.Sync()
+
+			err := c.Sync(context.Background(), tc.cr)
+			if err != nil != tc.err {
+				t.Fatalf("expected error %v, got: %v", tc.err, err)
+			}
+
+			cr, err := b.CMClient.CertmanagerV1alpha1().CertificateRequests(tc.cr.Namespace).Get(tc.cr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting CertificateRequest: %v", err)
+			}
+			if len(cr.Status.Conditions) != 1 || cr.Status.Conditions[0].Reason != tc.reason {
+				t.Fatalf("expected Ready condition with reason %q, got: %+v", tc.reason, cr.Status.Conditions)
+			}
+
+			issued := tc.reason == v1alpha1.CertificateRequestReasonIssued
+			if issued != (cr.Status.Conditions[0].Status == v1alpha1.ConditionTrue) {
+				t.Errorf("unexpected Ready condition status %q", cr.Status.Conditions[0].Status)
+			}
+			if issued != (len(cr.Status.Certificate) > 0) {
+				t.Errorf("expected certificate to be set %v, got %q", issued, cr.Status.Certificate)
+			}
+			failed := tc.reason == v1alpha1.CertificateRequestReasonFailed
+			if failed != (cr.Status.FailureTime != nil) {
+				t.Errorf("expected failure time to be set %v, got %v", failed, cr.Status.FailureTime)
+			}
+			if !issued {
+				return
+			}
+
+			cert, err := pki.DecodeX509CertificateBytes(cr.Status.Certificate)
+			if err != nil {
+				t.Fatalf("error decoding issued certificate: %v", err)
+			}
+			matches, err := pki.PublicKeyMatchesCertificate(key.Public(), cert)
+			if err != nil || !matches {
+				t.Errorf("expected issued certificate to match the certificate request, matches=%v err=%v", matches, err)
+			}
+		})
+	}
+}
+
+func TestSyncFinalCertificateRequest(t *testing.T) {
+	failureTime := metav1.Now()
+	tests := map[string]*v1alpha1.CertificateRequest{
+		"issued": gen.CertificateRequest("test-cr",
+			gen.AddCertificateRequestCondition(v1alpha1.CertificateRequestCondition{
+				Type:   v1alpha1.CertificateRequestConditionReady,
+				Status: v1alpha1.ConditionTrue,
+				Reason: v1alpha1.CertificateRequestReasonIssued,
+			}),
+		),
+		"failed": gen.CertificateRequestFrom(gen.CertificateRequest("test-cr",
+			gen.AddCertificateRequestCondition(v1alpha1.CertificateRequestCondition{
+				Type:   v1alpha1.CertificateRequestConditionReady,
+				Status: v1alpha1.ConditionFalse,
+				Reason: v1alpha1.CertificateRequestReasonFailed,
+			}),
+		), func(cr *v1alpha1.CertificateRequest) {
+			cr.Status.FailureTime = &failureTime
+		}),
+	}
+
+	for name, cr := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &test.Builder{CertManagerObjects: []runtime.Object{cr}}
+			b.Start()
+			defer b.Stop()
+			c := New(b.Context)
+			b.Sync()
+
+			if err := c.Sync(context.Background(), cr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := b.AllActionsExecuted(); err != nil {
+				t.Errorf("expected the CertificateRequest not to be updated: %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncApproval(t *testing.T) {
+	key, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+
+	selfSignedIssuer := gen.Issuer("selfsigned-issuer",
+		gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}),
+		gen.AddIssuerCondition(v1alpha1.IssuerCondition{
+			Type:   v1alpha1.IssuerConditionReady,
+			Status: v1alpha1.ConditionTrue,
+		}),
+	)
+	approved := func(status v1alpha1.ConditionStatus) gen.CertificateRequestModifier {
+		return gen.AddCertificateRequestCondition(v1alpha1.CertificateRequestCondition{
+			Type:   v1alpha1.CertificateRequestConditionApproved,
+			Status: status,
+			Reason: "ApprovedByPolicy",
+		})
+	}
+	denied := gen.AddCertificateRequestCondition(v1alpha1.CertificateRequestCondition{
+		Type:    v1alpha1.CertificateRequestConditionDenied,
+		Status:  v1alpha1.ConditionTrue,
+		Reason:  "DeniedByPolicy",
+		Message: "example.com may not be requested",
+	})
+	newCR := func(mods ...gen.CertificateRequestModifier) *v1alpha1.CertificateRequest {
+		return gen.CertificateRequest("test-cr", append([]gen.CertificateRequestModifier{
+			gen.SetCertificateRequestCSR(generateCSR(t, key, "example.com")),
+			gen.SetCertificateRequestIssuer(v1alpha1.ObjectReference{Name: "selfsigned-issuer"}),
+			gen.SetCertificateRequestAnnotations(map[string]string{
+				v1alpha1.PrivateKeySecretNameAnnotationKey: "test-key",
+			}),
+		}, mods...)...)
+	}
+
+	tests := map[string]struct {
+		cr              *v1alpha1.CertificateRequest
+		requireApproval bool
+		reason          string
+	}{
+		"issue a CertificateRequest without approval if approval is not required": {
+			cr:     newCR(),
+			reason: v1alpha1.CertificateRequestReasonIssued,
+		},
+		"wait for a CertificateRequest to be approved": {
+			cr:              newCR(),
+			requireApproval: true,
+			reason:          v1alpha1.CertificateRequestReasonPending,
+		},
+		"wait if the Approved condition is not True": {
+			cr:              newCR(approved(v1alpha1.ConditionFalse)),
+			requireApproval: true,
+			reason:          v1alpha1.CertificateRequestReasonPending,
+		},
+		"issue an approved CertificateRequest": {
+			cr:              newCR(approved(v1alpha1.ConditionTrue)),
+			requireApproval: true,
+			reason:          v1alpha1.CertificateRequestReasonIssued,
+		},
+		"fail a denied CertificateRequest": {
+			cr:              newCR(denied),
+			requireApproval: true,
+			reason:          v1alpha1.CertificateRequestReasonDenied,
+		},
+		"fail a denied CertificateRequest if approval is not required": {
+			cr:     newCR(denied),
+			reason: v1alpha1.CertificateRequestReasonDenied,
+		},
+		"fail a CertificateRequest that is both approved and denied": {
+			cr:              newCR(approved(v1alpha1.ConditionTrue), denied),
+			requireApproval: true,
+			reason:          v1alpha1.CertificateRequestReasonDenied,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &test.Builder{
+				KubeObjects:        []runtime.Object{tlsSecret(t, "test-key", key, nil)},
+				CertManagerObjects: []runtime.Object{selfSignedIssuer, tc.cr},
+			}
+			b.Start()
+			defer b.Stop()
+			b.Context.CertificateRequestOptions.RequireApproval = tc.requireApproval
+			c := New(b.Context)
+			c.clock = fakeclock.NewFakeClock(time.Now())
+			b.Sync()
+
+			if err := c.Sync(context.Background(), tc.cr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, a := range b.FakeCMClient().Actions() {
+				if a.GetVerb() == "update" && a.GetSubresource() != "status" {
+					t.Errorf("expected the CertificateRequest to only be updated through the status subresource, got %v", a)
+				}
+			}
+
+			cr, err := b.CMClient.CertmanagerV1alpha1().CertificateRequests(tc.cr.Namespace).Get(tc.cr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting CertificateRequest: %v", err)
+			}
+			var ready *v1alpha1.CertificateRequestCondition
+			for i, cond := range cr.Status.Conditions {
+				if cond.Type == v1alpha1.CertificateRequestConditionReady {
+					ready = &cr.Status.Conditions[i]
+				}
+			}
+			if ready == nil || ready.Reason != tc.reason {
+				t.Fatalf("expected Ready condition with reason %q, got: %+v", tc.reason, cr.Status.Conditions)
+			}
+
+			issued := tc.reason == v1alpha1.CertificateRequestReasonIssued
+			if issued != (len(cr.Status.Certificate) > 0) {
+				t.Errorf("expected certificate to be set %v, got %q", issued, cr.Status.Certificate)
+			}
+			denied := tc.reason == v1alpha1.CertificateRequestReasonDenied
+			if denied != (cr.Status.FailureTime != nil) {
+				t.Errorf("expected failure time to be set %v, got %v", denied, cr.Status.FailureTime)
+			}
+		})
+	}
+}
@@ -22,7 +22,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -32,6 +31,7 @@ import (
 
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
 	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/pkg/scheduler"
 	"github.com/jetstack/cert-manager/pkg/util"
@@ -47,12 +47,18 @@ type Controller struct {
 	clusterIssuerLister cmlisters.ClusterIssuerLister
 	certificateLister   cmlisters.CertificateLister
 	secretLister        corelisters.SecretLister
+	// namespaceLister is used to read per-namespace overrides of the
+	// certificate owner reference option. It is nil if the controller is
+	// scoped to a single namespace.
+	namespaceLister corelisters.NamespaceLister
 
 	queue              workqueue.RateLimitingInterface
 	scheduledWorkQueue scheduler.ScheduledWorkQueue
 	workerWg           sync.WaitGroup
 	syncedFuncs        []cache.InformerSynced
 	metrics            *metrics.Metrics
+	secretWrites       secretWriteTracker
+	forceRenewals      forceRenewTracker
 }
 
 // New returns a new Certificates controller. It sets up the informer handler
@@ -60,7 +66,7 @@ type Controller struct {
 func New(ctx *controllerpkg.Context) *Controller {
 	ctrl := &Controller{Context: ctx}
 	ctrl.syncHandler = ctrl.processNextWorkItem
-	ctrl.queue = workqueue.NewNamedRateLimitingQueue(controllerpkg.DefaultItemBasedRateLimiter(), "certificates")
+	ctrl.queue = workqueue.NewNamedRateLimitingQueue(controllerpkg.DefaultItemBasedRateLimiter(), ControllerName)
 
 	// Create a scheduled work queue that calls the ctrl.queue.Add method for
 	// each object in the queue. This is used to schedule re-checks of
@@ -83,6 +89,10 @@ func New(ctx *controllerpkg.Context) *Controller {
 		clusterIssuerInformer.Informer().AddEventHandler(&controllerpkg.BlockingEventHandler{WorkFunc: ctrl.handleGenericIssuer})
 		ctrl.clusterIssuerLister = clusterIssuerInformer.Lister()
 		ctrl.syncedFuncs = append(ctrl.syncedFuncs, clusterIssuerInformer.Informer().HasSynced)
+
+		namespaceInformer := ctrl.KubeSharedInformerFactory.Core().V1().Namespaces()
+		ctrl.namespaceLister = namespaceInformer.Lister()
+		ctrl.syncedFuncs = append(ctrl.syncedFuncs, namespaceInformer.Informer().HasSynced)
 	}
 
 	secretsInformer := ctrl.KubeSharedInformerFactory.Core().V1().Secrets()
@@ -100,13 +110,13 @@ func New(ctx *controllerpkg.Context) *Controller {
 }
 
 func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
-	glog.V(4).Infof("Starting %s control loop", ControllerName)
+	logs.V(4).Infof("Starting %s control loop", ControllerName)
 	// wait for all the informer caches we depend to sync
 	if !cache.WaitForCacheSync(stopCh, c.syncedFuncs...) {
 		return fmt.Errorf("error waiting for informer caches to sync")
 	}
 
-	glog.V(4).Infof("Synced all caches for %s control loop", ControllerName)
+	logs.V(4).Infof("Synced all caches for %s control loop", ControllerName)
 
 	for i := 0; i < workers; i++ {
 		c.workerWg.Add(1)
@@ -114,17 +124,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
 		go wait.Until(func() { c.worker(stopCh) }, time.Second, stopCh)
 	}
 	<-stopCh
-	glog.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
+	logs.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
 	c.queue.ShutDown()
-	glog.V(4).Infof("Waiting for workers to exit...")
+	logs.V(4).Infof("Waiting for workers to exit...")
 	c.workerWg.Wait()
-	glog.V(4).Infof("Workers exited.")
+	logs.V(4).Infof("Workers exited.")
 	return nil
 }
 
 func (c *Controller) worker(stopCh <-chan struct{}) {
 	defer c.workerWg.Done()
-	glog.V(4).Infof("Starting %q worker", ControllerName)
+	logs.V(4).Infof("Starting %q worker", ControllerName)
 	for {
 		obj, shutdown := c.queue.Get()
 		if shutdown {
@@ -142,17 +152,18 @@ func (c *Controller) worker(stopCh <-chan struct{}) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			ctx = util.ContextWithStopCh(ctx, stopCh)
-			glog.Infof("%s controller: syncing item '%s'", ControllerName, key)
+			log := logs.WithValues("controller", ControllerName, "key", key)
+			log.Infof("syncing item")
 			if err := c.syncHandler(ctx, key); err != nil {
-				glog.Errorf("%s controller: Re-queuing item %q due to error processing: %s", ControllerName, key, err.Error())
+				log.Errorf("re-queuing item due to error processing: %s", err.Error())
 				c.queue.AddRateLimited(obj)
 				return
 			}
-			glog.Infof("%s controller: Finished processing work item %q", ControllerName, key)
+			log.Infof("finished processing work item")
 			c.queue.Forget(obj)
 		}()
 	}
-	glog.V(4).Infof("Exiting %q worker loop", ControllerName)
+	logs.V(4).Infof("Exiting %q worker loop", ControllerName)
 }
 
 func (c *Controller) processNextWorkItem(ctx context.Context, key string) error {
@@ -167,6 +178,8 @@ func (c *Controller) processNextWorkItem(ctx context.Context, key string) error
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			c.scheduledWorkQueue.Forget(key)
+			c.metrics.RemoveCertificateExpiry(name, namespace)
+			c.metrics.RemoveCertificateReadyStatus(name, namespace)
 			runtime.HandleError(fmt.Errorf("certificate '%s' in work queue no longer exists", key))
 			return nil
 		}
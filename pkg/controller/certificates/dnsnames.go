@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+// normalizeDNSNames returns a copy of crt with its common name, DNS names and
+// ACME solver domains normalized by pki.NormalizeDNSName, which is the form
+// sent to the issuer. crt itself is not modified. An error is returned if any
+// of the DNS names are invalid.
+func normalizeDNSNames(crt *v1alpha1.Certificate) (*v1alpha1.Certificate, error) {
+	normalized := crt.DeepCopy()
+
+	var errs []string
+	for i, name := range normalized.Spec.DNSNames {
+		n, err := pki.NormalizeDNSName(name)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		normalized.Spec.DNSNames[i] = n
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+
+	// the common name does not have to be a DNS name, so it is only
+	// normalized if it is a valid one
+	if n, err := pki.NormalizeDNSName(normalized.Spec.CommonName); err == nil {
+		normalized.Spec.CommonName = n
+	}
+	if normalized.Spec.ACME != nil {
+		for _, cfg := range normalized.Spec.ACME.Config {
+			for i, domain := range cfg.Domains {
+				if n, err := pki.NormalizeDNSName(domain); err == nil {
+					cfg.Domains[i] = n
+				}
+			}
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/pkg/util"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestNormalizeDNSNames(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateCommonName("Bücher.Example."),
+		gen.SetCertificateDNSNames("Bücher.Example.", "*.Example.com."),
+	)
+	crt.Spec.ACME = &v1alpha1.ACMECertificateConfig{
+		Config: []v1alpha1.DomainSolverConfig{{Domains: []string{"Bücher.Example.", "*.Example.com."}}},
+	}
+	original := crt.DeepCopy()
+
+	normalized, err := normalizeDNSNames(crt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"xn--bcher-kva.example", "*.example.com"}
+	if normalized.Spec.CommonName != "xn--bcher-kva.example" {
+		t.Errorf("expected normalized common name, got %q", normalized.Spec.CommonName)
+	}
+	if !reflect.DeepEqual(normalized.Spec.DNSNames, expected) {
+		t.Errorf("expected dns names %v, got %v", expected, normalized.Spec.DNSNames)
+	}
+	if !reflect.DeepEqual(normalized.Spec.ACME.Config[0].Domains, expected) {
+		t.Errorf("expected acme domains %v, got %v", expected, normalized.Spec.ACME.Config[0].Domains)
+	}
+	if !reflect.DeepEqual(crt, original) {
+		t.Errorf("expected the original certificate not to be modified")
+	}
+
+	// a common name that is not a DNS name is left alone
+	crt = gen.Certificate("test-crt", gen.SetCertificateCommonName("My CA"))
+	normalized, err = normalizeDNSNames(crt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if normalized.Spec.CommonName != "My CA" {
+		t.Errorf("expected common name to be left unchanged, got %q", normalized.Spec.CommonName)
+	}
+
+	crt = gen.Certificate("test-crt", gen.SetCertificateDNSNames("example.com", "foo..example.com"))
+	if _, err := normalizeDNSNames(crt); err == nil || !strings.Contains(err.Error(), "foo..example.com") {
+		t.Errorf("expected an error naming the invalid dns name, got: %v", err)
+	}
+}
+
+func TestSyncNormalizesDNSNames(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateDNSNames("Bücher.Example.", "www.example.com."),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	c := New(b.Context)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	secret, err := b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting secret: %v", err)
+	}
+	cert, err := pki.DecodeX509CertificateBytes(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("error decoding certificate: %v", err)
+	}
+	expected := []string{"xn--bcher-kva.example", "www.example.com"}
+	if !util.EqualUnsorted(cert.DNSNames, expected) {
+		t.Errorf("expected certificate to be issued for %v, got %v", expected, cert.DNSNames)
+	}
+	if cert.Subject.CommonName != "xn--bcher-kva.example" {
+		t.Errorf("expected normalized common name, got %q", cert.Subject.CommonName)
+	}
+
+	issued, err := b.FakeCMClient().CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	if !reflect.DeepEqual(issued.Spec, crt.Spec) {
+		t.Errorf("expected the certificate spec to be left untouched, got %v", issued.Spec)
+	}
+	// the issued certificate matches the spec once normalized, so is not
+	// re-issued
+	key, err := pki.DecodePrivateKeyBytes(secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		t.Fatalf("error decoding private key: %v", err)
+	}
+	if _, errs := c.certificateMatchesSpec(issued, key, cert); len(errs) > 0 {
+		t.Errorf("expected the issued certificate to match the spec, got %v", errs)
+	}
+}
+
+func TestSyncRejectsInvalidDNSNames(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateDNSNames("example.com", "foo bar.example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	ctx := *b.Context
+	recorder := record.NewFakeRecorder(10)
+	ctx.Recorder = recorder
+	c := New(&ctx)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	var event string
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, errorInvalidDNSName) {
+			event = e
+		}
+	}
+	if !strings.Contains(event, "foo bar.example.com") {
+		t.Errorf("expected an %s event naming the invalid dns name, got %q", errorInvalidDNSName, event)
+	}
+	for _, a := range b.FakeKubeClient().Actions() {
+		if a.GetVerb() == "create" && a.GetResource().Resource == "secrets" {
+			t.Errorf("expected no certificate to be issued for invalid dns names")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+)
+
+const (
+	reasonSecretDeleted  = "SecretDeleted"
+	reasonSecretRetained = "SecretRetained"
+	errorDeletingSecret  = "DeleteSecretError"
+)
+
+// ensureFinalizer adds the CertificateFinalizer to crt if it is not already
+// present, so that the SecretDeletionPolicy can be applied when crt is
+// deleted. It will not actually submit the resource to the apiserver.
+func (c *Controller) ensureFinalizer(crt *v1alpha1.Certificate) {
+	// never add the finalizer in dry-run mode, as nothing would remove it
+	if c.DryRun || hasFinalizer(crt) {
+		return
+	}
+	crt.Finalizers = append(crt.Finalizers, v1alpha1.CertificateFinalizer)
+}
+
+// finalizeCertificate applies the SecretDeletionPolicy of a Certificate that
+// is being deleted, and then removes the CertificateFinalizer from it.
+// The Secret is deleted before the finalizer is removed, so that a failure
+// to delete it is retried rather than leaving it behind.
+func (c *Controller) finalizeCertificate(crt *v1alpha1.Certificate) error {
+	if !hasFinalizer(crt) {
+		return nil
+	}
+
+	switch {
+	case isSecretless(crt):
+		// the issued certificate is stored in the status of the
+		// Certificate, so there is no Secret to delete or retain
+	case crt.Spec.SecretDeletionPolicy == v1alpha1.SecretDeletionPolicyDelete:
+		if err := c.deleteCertificateSecret(crt); err != nil {
+			s := fmt.Sprintf("Error deleting secret %q: %v", crt.Spec.SecretName, err)
+			logs.Infof("%s/%s: %s", crt.Namespace, crt.Name, s)
+			c.Recorder.Event(crt, corev1.EventTypeWarning, errorDeletingSecret, s)
+			return err
+		}
+	default:
+		c.Recorder.Eventf(crt, corev1.EventTypeNormal, reasonSecretRetained, "Retained secret %q as the secret deletion policy is %q", crt.Spec.SecretName, v1alpha1.SecretDeletionPolicyRetain)
+	}
+
+	removeFinalizer(crt)
+	return nil
+}
+
+// deleteCertificateSecret deletes the Secret of crt. Secrets that do not
+// exist, or that are not labelled as belonging to crt, are left alone.
+func (c *Controller) deleteCertificateSecret(crt *v1alpha1.Certificate) error {
+	secret, err := c.Client.CoreV1().Secrets(crt.Namespace).Get(crt.Spec.SecretName, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if secret.Labels[v1alpha1.CertificateNameKey] != crt.Name {
+		c.Recorder.Eventf(crt, corev1.EventTypeWarning, reasonSecretRetained, "Not deleting secret %q as it does not belong to this certificate", crt.Spec.SecretName)
+		return nil
+	}
+
+	if c.DryRun {
+		s := fmt.Sprintf("Dry run: would have deleted secret %q", crt.Spec.SecretName)
+		logs.Infof("%s/%s: %s", crt.Namespace, crt.Name, s)
+		return nil
+	}
+
+	err = c.Client.CoreV1().Secrets(crt.Namespace).Delete(crt.Spec.SecretName, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &secret.UID},
+	})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	c.Recorder.Eventf(crt, corev1.EventTypeNormal, reasonSecretDeleted, "Deleted secret %q as the secret deletion policy is %q", crt.Spec.SecretName, v1alpha1.SecretDeletionPolicyDelete)
+	return nil
+}
+
+func hasFinalizer(crt *v1alpha1.Certificate) bool {
+	for _, f := range crt.Finalizers {
+		if f == v1alpha1.CertificateFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(crt *v1alpha1.Certificate) {
+	var finalizers []string
+	for _, f := range crt.Finalizers {
+		if f != v1alpha1.CertificateFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	crt.Finalizers = finalizers
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestSyncAddsFinalizer(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "missing"}),
+	)
+	b := &test.Builder{CertManagerObjects: []runtime.Object{crt}}
+	b.Start()
+	defer b.Stop()
+	c := New(b.Context)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	updated, err := b.CMClient.CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	if !reflect.DeepEqual(updated.Finalizers, []string{v1alpha1.CertificateFinalizer}) {
+		t.Errorf("expected the certificate finalizer to be added, got %v", updated.Finalizers)
+	}
+}
+
+func TestSyncDeletedCertificate(t *testing.T) {
+	tests := map[string]struct {
+		policy       v1alpha1.SecretDeletionPolicy
+		secretLabels map[string]string
+		expectSecret bool
+	}{
+		"secret is retained by default": {
+			secretLabels: map[string]string{v1alpha1.CertificateNameKey: "test-crt"},
+			expectSecret: true,
+		},
+		"secret is retained with the retain policy": {
+			policy:       v1alpha1.SecretDeletionPolicyRetain,
+			secretLabels: map[string]string{v1alpha1.CertificateNameKey: "test-crt"},
+			expectSecret: true,
+		},
+		"secret is deleted with the delete policy": {
+			policy:       v1alpha1.SecretDeletionPolicyDelete,
+			secretLabels: map[string]string{v1alpha1.CertificateNameKey: "test-crt"},
+			expectSecret: false,
+		},
+		"secret belonging to another certificate is not deleted": {
+			policy:       v1alpha1.SecretDeletionPolicyDelete,
+			secretLabels: map[string]string{v1alpha1.CertificateNameKey: "other-crt"},
+			expectSecret: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			deletionTime := metav1.Now()
+			crt := gen.Certificate("test-crt",
+				gen.SetCertificateSecretName("output"),
+				gen.SetCertificateCommonName("example.com"),
+			)
+			crt.Spec.SecretDeletionPolicy = tt.policy
+			crt.DeletionTimestamp = &deletionTime
+			crt.Finalizers = []string{"example.com/other", v1alpha1.CertificateFinalizer}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, Labels: tt.secretLabels},
+			}
+
+			b := &test.Builder{
+				KubeObjects:        []runtime.Object{secret},
+				CertManagerObjects: []runtime.Object{crt},
+			}
+			b.Start()
+			defer b.Stop()
+			c := New(b.Context)
+			b.Sync()
+
+			if err := c.Sync(context.Background(), crt); err != nil {
+				t.Fatalf("unexpected error syncing certificate: %v", err)
+			}
+
+			_, err := b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+			if tt.expectSecret && err != nil {
+				t.Errorf("expected the secret to be retained, but got: %v", err)
+			}
+			if !tt.expectSecret && !k8sErrors.IsNotFound(err) {
+				t.Errorf("expected the secret to be deleted, but got: %v", err)
+			}
+
+			updated, err := b.CMClient.CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting certificate: %v", err)
+			}
+			if !reflect.DeepEqual(updated.Finalizers, []string{"example.com/other"}) {
+				t.Errorf("expected only the certificate finalizer to be removed, got %v", updated.Finalizers)
+			}
+		})
+	}
+}
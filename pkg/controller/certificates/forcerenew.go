@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"sync"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// forceRenewRequested returns true if the force-renew annotation is set on
+// the Certificate.
+func forceRenewRequested(crt *v1alpha1.Certificate) bool {
+	_, ok := crt.Annotations[v1alpha1.ForceRenewAnnotationKey]
+	return ok
+}
+
+// forceRenewTracker records the resourceVersion of each Certificate that has
+// been re-issued because of the force-renew annotation. Until the removal of
+// the annotation has been observed, a stale copy of the Certificate, or a
+// failure to save it, cannot trigger a second re-issue.
+type forceRenewTracker struct {
+	lock    sync.Mutex
+	handled map[string]string
+}
+
+// pending returns true if the renewal requested for the Certificate has not
+// already been handled.
+func (t *forceRenewTracker) pending(crt *v1alpha1.Certificate) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	rv, ok := t.handled[crt.Namespace+"/"+crt.Name]
+	return !ok || rv != crt.ResourceVersion
+}
+
+// renewed records that the renewal requested for the Certificate has been
+// handled.
+func (t *forceRenewTracker) renewed(crt *v1alpha1.Certificate) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.handled == nil {
+		t.handled = make(map[string]string)
+	}
+	t.handled[crt.Namespace+"/"+crt.Name] = crt.ResourceVersion
+}
+
+// forget removes the record for the Certificate once the force-renew
+// annotation is no longer set on it.
+func (t *forceRenewTracker) forget(crt *v1alpha1.Certificate) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.handled, crt.Namespace+"/"+crt.Name)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestSyncForceRenew(t *testing.T) {
+	issued := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	certPEM, keyPEM := generateTestCertificate(t, issued)
+	// the certificate is up to date and far from expiry, so is only
+	// re-issued because of the annotation
+	crt := issued.DeepCopy()
+	crt.ResourceVersion = "1"
+	crt.Annotations = map[string]string{v1alpha1.ForceRenewAnnotationKey: "true"}
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		KubeObjects: []runtime.Object{&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       certPEM,
+				corev1.TLSPrivateKeyKey: keyPEM,
+			},
+		}},
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	ctx := *b.Context
+	recorder := record.NewFakeRecorder(10)
+	ctx.Recorder = recorder
+	c := New(&ctx)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	secret, err := b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting secret: %v", err)
+	}
+	renewedPEM := secret.Data[corev1.TLSCertKey]
+	if bytes.Equal(renewedPEM, certPEM) {
+		t.Errorf("expected the certificate to be re-issued")
+	}
+
+	var reissueEvent string
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, reasonReissuingCertificate) {
+			reissueEvent = e
+		}
+	}
+	if !strings.Contains(reissueEvent, v1alpha1.ForceRenewAnnotationKey) {
+		t.Errorf("expected a %s event naming the annotation, got %q", reasonReissuingCertificate, reissueEvent)
+	}
+
+	updated, err := b.FakeCMClient().CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	if _, ok := updated.Annotations[v1alpha1.ForceRenewAnnotationKey]; ok {
+		t.Errorf("expected the %s annotation to be removed", v1alpha1.ForceRenewAnnotationKey)
+	}
+
+	// a stale copy of the Certificate that still has the annotation must not
+	// trigger a second re-issue
+	stale := crt.DeepCopy()
+	stale.Status = updated.Status
+	if err := c.Sync(context.Background(), stale); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, reasonReissuingCertificate) || strings.Contains(e, successCertificateIssued) {
+			t.Errorf("expected the certificate not to be re-issued again, got event %q", e)
+		}
+	}
+	secret, err = b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting secret: %v", err)
+	}
+	if !bytes.Equal(secret.Data[corev1.TLSCertKey], renewedPEM) {
+		t.Errorf("expected the re-issued certificate to be left unchanged")
+	}
+}
+
+func TestForceRenewTracker(t *testing.T) {
+	crt := gen.Certificate("test-crt")
+	crt.ResourceVersion = "1"
+	var tracker forceRenewTracker
+
+	if !tracker.pending(crt) {
+		t.Errorf("expected a renewal to be pending before it has been handled")
+	}
+	tracker.renewed(crt)
+	if tracker.pending(crt) {
+		t.Errorf("expected the renewal not to be pending once handled")
+	}
+
+	// setting the annotation again changes the resourceVersion
+	crt.ResourceVersion = "2"
+	if !tracker.pending(crt) {
+		t.Errorf("expected a renewal requested on a newer version to be pending")
+	}
+
+	crt.ResourceVersion = "1"
+	tracker.forget(crt)
+	if !tracker.pending(crt) {
+		t.Errorf("expected a renewal to be pending once the record is forgotten")
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+const (
+	// PKCS12SecretKey is the key of the PKCS#12 keystore in a Certificate's
+	// Secret.
+	PKCS12SecretKey = "keystore.p12"
+	// JKSSecretKey is the key of the JKS keystore in a Certificate's Secret.
+	JKSSecretKey = "keystore.jks"
+	// JKSTruststoreSecretKey is the key of the JKS truststore in a
+	// Certificate's Secret.
+	JKSTruststoreSecretKey = "truststore.jks"
+	// CombinedPEMSecretKey is the key of the combined PEM bundle of private
+	// key, certificate and intermediates in a Certificate's Secret.
+	CombinedPEMSecretKey = "tls-combined.pem"
+
+	// defaultJKSAlias is the alias of the private key entry in a JKS
+	// keystore if none is configured.
+	defaultJKSAlias = "certificate"
+)
+
+// setKeystores writes the keystores and combined PEM bundle configured on
+// crt into data, built from the given PEM encoded certificate chain, private
+// key and CA. Outputs that are no longer configured, or that cannot be built
+// because the certificate has not been issued yet, are removed.
+func (c *Controller) setKeystores(crt *v1alpha1.Certificate, data map[string][]byte, cert, key, ca []byte) error {
+	if crt.Spec.CombinedPEM && len(cert) > 0 && len(key) > 0 {
+		combined, err := combinedPEM(cert, key)
+		if err != nil {
+			return err
+		}
+		data[CombinedPEMSecretKey] = combined
+	} else {
+		delete(data, CombinedPEMSecretKey)
+	}
+
+	ks := crt.Spec.Keystores
+	if ks == nil || len(cert) == 0 || len(key) == 0 {
+		ks = &v1alpha1.CertificateKeystores{}
+	}
+	if ks.PKCS12 == nil {
+		delete(data, PKCS12SecretKey)
+	}
+	if ks.JKS == nil {
+		delete(data, JKSSecretKey)
+	}
+	if ks.JKS == nil || !ks.JKS.Truststore || len(ca) == 0 {
+		delete(data, JKSTruststoreSecretKey)
+	}
+	if ks.PKCS12 == nil && ks.JKS == nil {
+		return nil
+	}
+
+	privateKey, err := pki.DecodePrivateKeyBytes(key)
+	if err != nil {
+		return err
+	}
+	chain, err := pki.DecodeX509CertificateChainBytes(cert)
+	if err != nil {
+		return err
+	}
+	var caCerts []*x509.Certificate
+	if len(ca) > 0 {
+		caCerts, err = pki.DecodeX509CertificateChainBytes(ca)
+		if err != nil {
+			return err
+		}
+		for _, caCert := range caCerts {
+			if !containsCertificate(chain, caCert.Raw) {
+				chain = append(chain, caCert)
+			}
+		}
+	}
+
+	if ks.PKCS12 != nil {
+		password, err := c.keystorePassword(crt.Namespace, ks.PKCS12.PasswordSecretRef)
+		if err != nil {
+			return err
+		}
+		keystore, err := pki.EncodePKCS12(privateKey, chain, password)
+		if err != nil {
+			return err
+		}
+		data[PKCS12SecretKey] = keystore
+	}
+
+	if ks.JKS != nil {
+		password, err := c.keystorePassword(crt.Namespace, ks.JKS.PasswordSecretRef)
+		if err != nil {
+			return err
+		}
+		alias := ks.JKS.Alias
+		if alias == "" {
+			alias = defaultJKSAlias
+		}
+		keystore, err := pki.EncodeJKSKeystore(privateKey, chain, alias, password)
+		if err != nil {
+			return err
+		}
+		data[JKSSecretKey] = keystore
+
+		if ks.JKS.Truststore && len(caCerts) > 0 {
+			truststore, err := pki.EncodeJKSTruststore(caCerts, password)
+			if err != nil {
+				return err
+			}
+			data[JKSTruststoreSecretKey] = truststore
+		}
+	}
+
+	return nil
+}
+
+// keystoresUpToDate returns false if the keystores present in secret do not
+// match the keystores configured on crt.
+func keystoresUpToDate(crt *v1alpha1.Certificate, secret *corev1.Secret) bool {
+	ks := crt.Spec.Keystores
+	if ks == nil {
+		ks = &v1alpha1.CertificateKeystores{}
+	}
+	wantTruststore := ks.JKS != nil && ks.JKS.Truststore && len(secret.Data[TLSCAKey]) > 0
+	return hasSecretKey(secret, CombinedPEMSecretKey) == crt.Spec.CombinedPEM &&
+		hasSecretKey(secret, PKCS12SecretKey) == (ks.PKCS12 != nil) &&
+		hasSecretKey(secret, JKSSecretKey) == (ks.JKS != nil) &&
+		hasSecretKey(secret, JKSTruststoreSecretKey) == wantTruststore
+}
+
+// combinedPEM returns the PEM encoded private key followed by the
+// certificate and its intermediates. It checks that the chain starts with
+// the certificate for key, and that each certificate is issued by the one
+// following it, as consumers of the bundle expect.
+func combinedPEM(cert, key []byte) ([]byte, error) {
+	privateKey, err := pki.DecodePrivateKeyBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := pki.DecodeX509CertificateChainBytes(cert)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := pki.PublicKeyMatchesCertificate(privateKey.Public(), chain[0])
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		return nil, fmt.Errorf("private key does not match the first certificate in the chain")
+	}
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return nil, fmt.Errorf("certificate chain is out of order: certificate %d is not issued by certificate %d: %v", i, i+1, err)
+		}
+	}
+
+	combined := append([]byte{}, key...)
+	if !bytes.HasSuffix(combined, []byte("\n")) {
+		combined = append(combined, '\n')
+	}
+	for _, c := range chain {
+		certPEM, err := pki.EncodeX509(c)
+		if err != nil {
+			return nil, err
+		}
+		combined = append(combined, certPEM...)
+	}
+	return combined, nil
+}
+
+func hasSecretKey(secret *corev1.Secret, key string) bool {
+	_, ok := secret.Data[key]
+	return ok
+}
+
+func (c *Controller) keystorePassword(namespace string, ref v1alpha1.SecretKeySelector) (string, error) {
+	secret, err := c.secretLister.Secrets(namespace).Get(ref.Name)
+	if err != nil {
+		return "", fmt.Errorf("error getting keystore password secret %q: %v", ref.Name, err)
+	}
+	password, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("no data for %q in keystore password secret %q", ref.Key, ref.Name)
+	}
+	return string(password), nil
+}
+
+func containsCertificate(chain []*x509.Certificate, raw []byte) bool {
+	for _, c := range chain {
+		if bytes.Equal(c.Raw, raw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/pem"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func generateTestCertificate(t *testing.T, crt *v1alpha1.Certificate) (certPEM, keyPEM []byte) {
+	key, err := pki.GeneratePrivateKeyForCertificate(crt)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	template, err := pki.GenerateTemplate(nil, crt)
+	if err != nil {
+		t.Fatalf("error generating template: %v", err)
+	}
+	certPEM, _, err = pki.SignCertificate(template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("error signing certificate: %v", err)
+	}
+	keyPEM, err = pki.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding private key: %v", err)
+	}
+	return certPEM, keyPEM
+}
+
+func newSecretTestController(t *testing.T, objects ...runtime.Object) (*Controller, *test.Builder) {
+	b := &test.Builder{KubeObjects: objects}
+	b.Start()
+	c := &Controller{
+		Context:      b.Context,
+		secretLister: b.KubeSharedInformerFactory.Core().V1().Secrets().Lister(),
+	}
+	b.Sync()
+	return c, b
+}
+
+func pkcs12Certificate() *v1alpha1.Certificate {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+	)
+	crt.Spec.Keystores = &v1alpha1.CertificateKeystores{
+		PKCS12: &v1alpha1.PKCS12Keystore{
+			PasswordSecretRef: v1alpha1.SecretKeySelector{
+				LocalObjectReference: v1alpha1.LocalObjectReference{Name: "keystore-password"},
+				Key:                  "password",
+			},
+		},
+	}
+	return crt
+}
+
+func jksCertificate() *v1alpha1.Certificate {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+	)
+	crt.Spec.Keystores = &v1alpha1.CertificateKeystores{
+		JKS: &v1alpha1.JKSKeystore{
+			PasswordSecretRef: v1alpha1.SecretKeySelector{
+				LocalObjectReference: v1alpha1.LocalObjectReference{Name: "keystore-password"},
+				Key:                  "password",
+			},
+			Truststore: true,
+		},
+	}
+	return crt
+}
+
+// jksOpensWithPassword verifies the integrity digest of a JKS keystore,
+// which is how Java checks the store password.
+func jksOpensWithPassword(data []byte, password string) bool {
+	if len(data) < sha1.Size {
+		return false
+	}
+	h := sha1.New()
+	for _, c := range password {
+		h.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(data[:len(data)-sha1.Size])
+	return bytes.Equal(h.Sum(nil), data[len(data)-sha1.Size:])
+}
+
+var keystorePasswordSecret = &corev1.Secret{
+	ObjectMeta: metav1.ObjectMeta{Name: "keystore-password", Namespace: gen.DefaultTestNamespace},
+	Data:       map[string][]byte{"password": []byte("p4ssw0rd")},
+}
+
+func TestUpdateSecretKeystores(t *testing.T) {
+	// the fake clientset does not set a self link, which updateSecret uses
+	// to decide between creating and updating the secret
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+	}
+	c, b := newSecretTestController(t, keystorePasswordSecret, existing)
+	defer b.Stop()
+	crt := pkcs12Certificate()
+
+	cert, key := generateTestCertificate(t, crt)
+	secret, err := c.updateSecret(crt, crt.Namespace, cert, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	keystore := secret.Data[PKCS12SecretKey]
+	if len(keystore) == 0 {
+		t.Fatalf("expected %s to be written to the secret", PKCS12SecretKey)
+	}
+
+	// a renewed certificate must regenerate the keystore
+	cert, key = generateTestCertificate(t, crt)
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if len(secret.Data[PKCS12SecretKey]) == 0 || string(secret.Data[PKCS12SecretKey]) == string(keystore) {
+		t.Errorf("expected %s to be regenerated on renewal", PKCS12SecretKey)
+	}
+
+	// a secret without a certificate cannot have a keystore
+	secret, err = c.updateSecret(crt, crt.Namespace, nil, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if _, ok := secret.Data[PKCS12SecretKey]; ok {
+		t.Errorf("expected %s to be removed when there is no certificate", PKCS12SecretKey)
+	}
+
+	// disabling the keystore removes it
+	crt.Spec.Keystores = nil
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if _, ok := secret.Data[PKCS12SecretKey]; ok {
+		t.Errorf("expected %s to be removed when keystores are disabled", PKCS12SecretKey)
+	}
+}
+
+func TestUpdateSecretJKSKeystores(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+	}
+	c, b := newSecretTestController(t, keystorePasswordSecret, existing)
+	defer b.Stop()
+	crt := jksCertificate()
+
+	cert, key := generateTestCertificate(t, crt)
+	secret, err := c.updateSecret(crt, crt.Namespace, cert, key, cert)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	keystore := secret.Data[JKSSecretKey]
+	for _, k := range []string{JKSSecretKey, JKSTruststoreSecretKey} {
+		if !jksOpensWithPassword(secret.Data[k], "p4ssw0rd") {
+			t.Errorf("expected %s to be written to the secret and open with the configured password", k)
+		}
+		if jksOpensWithPassword(secret.Data[k], "wrong") {
+			t.Errorf("expected %s not to open with the wrong password", k)
+		}
+	}
+
+	// a renewed certificate must regenerate the keystore
+	cert, key = generateTestCertificate(t, crt)
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, cert)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if !jksOpensWithPassword(secret.Data[JKSSecretKey], "p4ssw0rd") || bytes.Equal(secret.Data[JKSSecretKey], keystore) {
+		t.Errorf("expected %s to be regenerated on renewal", JKSSecretKey)
+	}
+
+	// the truststore is only written if there is a CA
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if _, ok := secret.Data[JKSTruststoreSecretKey]; ok {
+		t.Errorf("expected %s to be removed when there is no CA", JKSTruststoreSecretKey)
+	}
+
+	// disabling the truststore removes it
+	crt.Spec.Keystores.JKS.Truststore = false
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, cert)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if _, ok := secret.Data[JKSTruststoreSecretKey]; ok {
+		t.Errorf("expected %s to be removed when the truststore is disabled", JKSTruststoreSecretKey)
+	}
+	if len(secret.Data[JKSSecretKey]) == 0 {
+		t.Errorf("expected %s to be kept when the truststore is disabled", JKSSecretKey)
+	}
+
+	// removing the jks block removes the keystore
+	crt.Spec.Keystores.JKS = nil
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, cert)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if _, ok := secret.Data[JKSSecretKey]; ok {
+		t.Errorf("expected %s to be removed when the jks keystore is disabled", JKSSecretKey)
+	}
+}
+
+func TestUpdateSecretCombinedPEM(t *testing.T) {
+	_, root, rootKey := signTestCertificate(t, "root", true, nil, nil)
+	intermediatePEM, intermediate, intermediateKey := signTestCertificate(t, "intermediate", true, root, rootKey)
+	issue := func() (leafPEM, keyPEM []byte) {
+		leafPEM, _, leafKey := signTestCertificate(t, "example.com", false, intermediate, intermediateKey)
+		keyPEM, err := pki.EncodePrivateKey(leafKey)
+		if err != nil {
+			t.Fatalf("error encoding private key: %v", err)
+		}
+		return leafPEM, keyPEM
+	}
+
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+	}
+	c, b := newSecretTestController(t, existing)
+	defer b.Stop()
+	crt := gen.Certificate("test-crt", gen.SetCertificateSecretName("output"))
+	crt.Spec.CombinedPEM = true
+
+	for _, stage := range []string{"issuance", "renewal"} {
+		leafPEM, keyPEM := issue()
+		cert := append(append([]byte{}, leafPEM...), intermediatePEM...)
+		secret, err := c.updateSecret(crt, crt.Namespace, cert, keyPEM, nil)
+		if err != nil {
+			t.Fatalf("unexpected error updating secret on %s: %v", stage, err)
+		}
+		if !bytes.Equal(secret.Data[corev1.TLSCertKey], cert) || !bytes.Equal(secret.Data[corev1.TLSPrivateKeyKey], keyPEM) {
+			t.Errorf("expected the standard keys to be written on %s", stage)
+		}
+
+		// the bundle must parse into the key, leaf and intermediate in order
+		var blocks []*pem.Block
+		for rest := secret.Data[CombinedPEMSecretKey]; ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		keyBlock, _ := pem.Decode(keyPEM)
+		leafBlock, _ := pem.Decode(leafPEM)
+		intermediateBlock, _ := pem.Decode(intermediatePEM)
+		expected := []*pem.Block{keyBlock, leafBlock, intermediateBlock}
+		if len(blocks) != len(expected) {
+			t.Fatalf("expected %d PEM blocks in %s on %s but got %d", len(expected), CombinedPEMSecretKey, stage, len(blocks))
+		}
+		for i := range expected {
+			if blocks[i].Type != expected[i].Type || !bytes.Equal(blocks[i].Bytes, expected[i].Bytes) {
+				t.Errorf("PEM block %d in %s on %s does not match, got type %q", i, CombinedPEMSecretKey, stage, blocks[i].Type)
+			}
+		}
+	}
+
+	// an out of order chain is rejected
+	leafPEM, keyPEM := issue()
+	if _, err := c.updateSecret(crt, crt.Namespace, append(append([]byte{}, intermediatePEM...), leafPEM...), keyPEM, nil); err == nil {
+		t.Errorf("expected an error writing a combined bundle for an out of order chain")
+	}
+
+	// disabling the bundle removes it
+	crt.Spec.CombinedPEM = false
+	secret, err := c.updateSecret(crt, crt.Namespace, append(append([]byte{}, leafPEM...), intermediatePEM...), keyPEM, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if _, ok := secret.Data[CombinedPEMSecretKey]; ok {
+		t.Errorf("expected %s to be removed when disabled", CombinedPEMSecretKey)
+	}
+}
+
+func TestKeystoresUpToDate(t *testing.T) {
+	crt := jksCertificate()
+	tests := map[string]struct {
+		data     map[string][]byte
+		expected bool
+	}{
+		"keystore and truststore present": {
+			data:     map[string][]byte{TLSCAKey: []byte("ca"), JKSSecretKey: nil, JKSTruststoreSecretKey: nil},
+			expected: true,
+		},
+		"truststore missing": {
+			data:     map[string][]byte{TLSCAKey: []byte("ca"), JKSSecretKey: nil},
+			expected: false,
+		},
+		"truststore not needed without a ca": {
+			data:     map[string][]byte{JKSSecretKey: nil},
+			expected: true,
+		},
+		"keystore missing": {
+			data:     map[string][]byte{},
+			expected: false,
+		},
+		"unconfigured pkcs12 keystore present": {
+			data:     map[string][]byte{JKSSecretKey: nil, PKCS12SecretKey: nil},
+			expected: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := keystoresUpToDate(crt, &corev1.Secret{Data: test.data}); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpdateSecretKeystoresMissingPassword(t *testing.T) {
+	c, b := newSecretTestController(t)
+	defer b.Stop()
+	crt := pkcs12Certificate()
+
+	cert, key := generateTestCertificate(t, crt)
+	if _, err := c.updateSecret(crt, crt.Namespace, cert, key, nil); err == nil {
+		t.Errorf("expected an error when the keystore password secret does not exist")
+	}
+}
+
+func TestUpdateKeystores(t *testing.T) {
+	crt := pkcs12Certificate()
+	cert, key := generateTestCertificate(t, crt)
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: key,
+		},
+	}
+	c, b := newSecretTestController(t, keystorePasswordSecret, existing)
+	defer b.Stop()
+
+	if err := c.updateKeystores(crt); err != nil {
+		t.Fatalf("unexpected error updating keystores: %v", err)
+	}
+	secret, err := b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting secret: %v", err)
+	}
+	if len(secret.Data[PKCS12SecretKey]) == 0 {
+		t.Errorf("expected %s to be added to the existing secret", PKCS12SecretKey)
+	}
+	if string(secret.Data[corev1.TLSCertKey]) != string(cert) {
+		t.Errorf("expected the existing certificate to be kept")
+	}
+}
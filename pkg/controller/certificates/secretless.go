@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+// isSecretless returns true if the issued certificate of crt is stored in its
+// status rather than in a Secret.
+func isSecretless(crt *v1alpha1.Certificate) bool {
+	return crt.Spec.Output == v1alpha1.CertificateOutputSecretless
+}
+
+// certificateKeyPair returns the currently issued certificate chain and
+// private key of crt, from either its Secret or its status. As with
+// kube.SecretTLSKeyPair, errors caused by missing or invalid data satisfy
+// errors.IsInvalidData.
+func certificateKeyPair(secretLister corelisters.SecretLister, crt *v1alpha1.Certificate) ([]*x509.Certificate, crypto.Signer, error) {
+	if !isSecretless(crt) {
+		return kube.SecretTLSKeyPair(secretLister, crt.Namespace, crt.Spec.SecretName)
+	}
+
+	if len(crt.Status.PrivateKey) == 0 {
+		return nil, nil, errors.NewInvalidData("no private key in status of certificate '%s/%s'", crt.Namespace, crt.Name)
+	}
+	key, err := pki.DecodePrivateKeyBytes(crt.Status.PrivateKey)
+	if err != nil {
+		return nil, nil, errors.NewInvalidData("%v", err)
+	}
+
+	if len(crt.Status.Certificate) == 0 {
+		return nil, key, errors.NewInvalidData("no certificate in status of certificate '%s/%s'", crt.Namespace, crt.Name)
+	}
+	certs, err := pki.DecodeX509CertificateChainBytes(crt.Status.Certificate)
+	if err != nil {
+		return nil, key, errors.NewInvalidData("%v", err)
+	}
+
+	return certs, key, nil
+}
+
+// updateStatusOutput stores the issued certificate, private key and CA in the
+// status of crt. The caller is responsible for saving the status.
+func updateStatusOutput(crt *v1alpha1.Certificate, cert, key, ca []byte) error {
+	if crt.Spec.CAChain && len(cert) > 0 {
+		var err error
+		ca, err = caChain(cert, ca)
+		if err != nil {
+			return fmt.Errorf("error building CA chain: %v", err)
+		}
+	}
+
+	if size := len(cert) + len(key) + len(ca); size > v1alpha1.MaxCertificateOutputSize {
+		return fmt.Errorf("issued certificate, private key and CA are %d bytes, which is more than the %d bytes that may be stored in the status of a secretless certificate", size, v1alpha1.MaxCertificateOutputSize)
+	}
+
+	crt.Status.Certificate = cert
+	crt.Status.PrivateKey = key
+	crt.Status.CA = ca
+	return nil
+}
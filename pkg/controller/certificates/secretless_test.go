@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestSyncSecretless(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	crt.Spec.Output = v1alpha1.CertificateOutputSecretless
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	ctx := *b.Context
+	ctx.EnableSecretless = true
+	recorder := record.NewFakeRecorder(10)
+	ctx.Recorder = recorder
+	c := New(&ctx)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	for _, a := range b.FakeKubeClient().Actions() {
+		if a.GetResource().Resource == "secrets" && a.GetVerb() != "get" && a.GetVerb() != "list" && a.GetVerb() != "watch" {
+			t.Errorf("expected no secrets to be written, got %s %s", a.GetVerb(), a.GetResource().Resource)
+		}
+	}
+
+	issued, err := b.FakeCMClient().CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	certs, key, err := certificateKeyPair(nil, issued)
+	if err != nil {
+		t.Fatalf("expected the certificate and private key to be stored in the status: %v", err)
+	}
+	if certs[0].Subject.CommonName != "example.com" {
+		t.Errorf("expected certificate for example.com, got %q", certs[0].Subject.CommonName)
+	}
+	if matches, err := pki.PublicKeyMatchesCertificate(key.Public(), certs[0]); err != nil || !matches {
+		t.Errorf("expected private key in status to match the certificate: %v", err)
+	}
+	if !bytes.Equal(issued.Status.CA, issued.Status.Certificate) {
+		t.Errorf("expected the self signed certificate to be its own CA")
+	}
+
+	// syncing again should leave the issued certificate alone
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+	if err := c.Sync(context.Background(), issued); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, successCertificateIssued) {
+			t.Errorf("expected the certificate not to be issued again, got event %q", e)
+		}
+	}
+	resynced, err := b.FakeCMClient().CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	if !bytes.Equal(resynced.Status.Certificate, issued.Status.Certificate) {
+		t.Errorf("expected the certificate in the status to be unchanged")
+	}
+}
+
+func TestSyncSecretlessDisabled(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	crt.Spec.Output = v1alpha1.CertificateOutputSecretless
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	ctx := *b.Context
+	recorder := record.NewFakeRecorder(10)
+	ctx.Recorder = recorder
+	c := New(&ctx)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	synced, err := b.FakeCMClient().CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	if len(synced.Status.Certificate) > 0 || len(synced.Status.PrivateKey) > 0 {
+		t.Errorf("expected no certificate or private key to be stored in the status")
+	}
+	found := false
+	for len(recorder.Events) > 0 {
+		e := <-recorder.Events
+		if strings.Contains(e, successCertificateIssued) {
+			t.Errorf("expected the certificate not to be issued, got event %q", e)
+		}
+		if strings.Contains(e, errorSecretlessDisabled) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a %s event to be recorded", errorSecretlessDisabled)
+	}
+}
+
+func TestUpdateStatusOutputSizeLimit(t *testing.T) {
+	crt := gen.Certificate("test-crt", gen.SetCertificateCommonName("example.com"))
+	crt.Spec.Output = v1alpha1.CertificateOutputSecretless
+	certPEM, keyPEM := generateTestCertificate(t, crt)
+
+	if err := updateStatusOutput(crt, certPEM, keyPEM, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(crt.Status.Certificate, certPEM) || !bytes.Equal(crt.Status.PrivateKey, keyPEM) {
+		t.Errorf("expected the certificate and private key to be stored in the status")
+	}
+
+	large := bytes.Repeat(certPEM, v1alpha1.MaxCertificateOutputSize/len(certPEM)+1)
+	if err := updateStatusOutput(crt, large, keyPEM, nil); err == nil {
+		t.Errorf("expected an error storing more than %d bytes", v1alpha1.MaxCertificateOutputSize)
+	}
+	if !bytes.Equal(crt.Status.Certificate, certPEM) {
+		t.Errorf("expected the existing status to be left unchanged")
+	}
+}
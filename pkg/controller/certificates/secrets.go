@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// keystoreSecretKeys are the Secret data keys holding keystores. Keystores
+// are re-encoded with a random salt each time they are generated, so only
+// their presence is taken into account when hashing a Secret.
+var keystoreSecretKeys = map[string]bool{
+	PKCS12SecretKey:        true,
+	JKSSecretKey:           true,
+	JKSTruststoreSecretKey: true,
+}
+
+// secretDataHash returns a hash of the type, data, labels and annotations of
+// secret. The SecretDataHashAnnotationKey annotation itself is ignored.
+func secretDataHash(secret *corev1.Secret) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "type=%s\n", secret.Type)
+	for _, k := range sortedKeys(secret.Data) {
+		if keystoreSecretKeys[k] {
+			fmt.Fprintf(h, "data=%s\n", k)
+			continue
+		}
+		fmt.Fprintf(h, "data=%s:%x\n", k, secret.Data[k])
+	}
+	for _, k := range sortedStringKeys(secret.Labels) {
+		fmt.Fprintf(h, "label=%s:%q\n", k, secret.Labels[k])
+	}
+	for _, k := range sortedStringKeys(secret.Annotations) {
+		if k == v1alpha1.SecretDataHashAnnotationKey {
+			continue
+		}
+		fmt.Fprintf(h, "annotation=%s:%q\n", k, secret.Annotations[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// secretUpToDate returns true if existing was last written with the given
+// hash and its contents have not been modified since.
+func secretUpToDate(existing *corev1.Secret, hash string) bool {
+	if existing.Annotations[v1alpha1.SecretDataHashAnnotationKey] != hash {
+		return false
+	}
+	return secretDataHash(existing) == hash
+}
+
+// keyMaterialChanged returns true if the certificate or private key stored in
+// updated differ from those stored in existing.
+func keyMaterialChanged(existing, updated *corev1.Secret) bool {
+	return !bytes.Equal(existing.Data[corev1.TLSCertKey], updated.Data[corev1.TLSCertKey]) ||
+		!bytes.Equal(existing.Data[corev1.TLSPrivateKeyKey], updated.Data[corev1.TLSPrivateKeyKey])
+}
+
+// secretWriteTracker records when each Secret was last written by the
+// controller, in order to limit how often a Secret is rewritten.
+type secretWriteTracker struct {
+	lock       sync.Mutex
+	lastWrites map[string]time.Time
+}
+
+// waitTime returns how long to wait before the Secret identified by key may
+// be written again, given the minimum interval between writes.
+func (t *secretWriteTracker) waitTime(key string, minInterval time.Duration) time.Duration {
+	if minInterval <= 0 {
+		return 0
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	last, ok := t.lastWrites[key]
+	if !ok {
+		return 0
+	}
+	wait := minInterval - now().Sub(last)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// written records that the Secret identified by key was just written.
+func (t *secretWriteTracker) written(key string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.lastWrites == nil {
+		t.lastWrites = make(map[string]time.Time)
+	}
+	t.lastWrites[key] = now()
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
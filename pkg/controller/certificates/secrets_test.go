@@ -0,0 +1,229 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+// countSecretUpdates returns the number of update calls made for secrets.
+func countSecretUpdates(b *test.Builder) int {
+	n := 0
+	for _, a := range b.FakeKubeClient().Actions() {
+		if a.GetVerb() == "update" && a.GetResource().Resource == "secrets" {
+			n++
+		}
+	}
+	return n
+}
+
+func existingOutputSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+	}
+}
+
+func TestUpdateSecretSkipsNoopUpdates(t *testing.T) {
+	tests := map[string]*v1alpha1.Certificate{
+		"certificate without keystores": gen.Certificate("test-crt",
+			gen.SetCertificateSecretName("output"),
+			gen.SetCertificateCommonName("example.com"),
+		),
+		"certificate with a pkcs12 keystore": pkcs12Certificate(),
+	}
+	for name, crt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, b := newSecretTestController(t, keystorePasswordSecret, existingOutputSecret())
+			defer b.Stop()
+
+			cert, key := generateTestCertificate(t, crt)
+			secret, err := c.updateSecret(crt, crt.Namespace, cert, key, nil)
+			if err != nil {
+				t.Fatalf("unexpected error updating secret: %v", err)
+			}
+			if secret.Annotations[v1alpha1.SecretDataHashAnnotationKey] == "" {
+				t.Errorf("expected the secret data hash annotation to be set")
+			}
+			if n := countSecretUpdates(b); n != 1 {
+				t.Fatalf("expected 1 secret update but got %d", n)
+			}
+
+			for i := 0; i < 3; i++ {
+				if _, err := c.updateSecret(crt, crt.Namespace, cert, key, nil); err != nil {
+					t.Fatalf("unexpected error updating secret: %v", err)
+				}
+			}
+			if n := countSecretUpdates(b); n != 1 {
+				t.Errorf("expected no-op reconciles not to update the secret, but got %d updates", n)
+			}
+		})
+	}
+}
+
+func TestUpdateSecretRewritesModifiedSecret(t *testing.T) {
+	c, b := newSecretTestController(t, existingOutputSecret())
+	defer b.Stop()
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+	)
+
+	cert, key := generateTestCertificate(t, crt)
+	secret, err := c.updateSecret(crt, crt.Namespace, cert, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+
+	// modify the secret without updating the hash annotation
+	secret.Data[corev1.TLSCertKey] = []byte("modified")
+	if _, err := b.Client.CoreV1().Secrets(secret.Namespace).Update(secret); err != nil {
+		t.Fatalf("unexpected error modifying secret: %v", err)
+	}
+
+	secret, err = c.updateSecret(crt, crt.Namespace, cert, key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if string(secret.Data[corev1.TLSCertKey]) != string(cert) {
+		t.Errorf("expected the modified secret to be rewritten")
+	}
+	// one update by the controller, one by the test and one more by the
+	// controller to restore the secret
+	if n := countSecretUpdates(b); n != 3 {
+		t.Errorf("expected 3 secret updates but got %d", n)
+	}
+}
+
+func TestUpdateSecretMinInterval(t *testing.T) {
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	c, b := newSecretTestController(t, existingOutputSecret())
+	defer b.Stop()
+	c.CertificateOptions.SecretUpdateMinInterval = time.Hour
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "issuer-a"}),
+	)
+
+	cert, key := generateTestCertificate(t, crt)
+	if _, err := c.updateSecret(crt, crt.Namespace, cert, key, nil); err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+
+	// changing only the metadata of the secret is delayed
+	crt.Spec.IssuerRef.Name = "issuer-b"
+	if _, err := c.updateSecret(crt, crt.Namespace, cert, key, nil); err == nil {
+		t.Errorf("expected an error updating the secret within the minimum interval")
+	}
+	if n := countSecretUpdates(b); n != 1 {
+		t.Errorf("expected 1 secret update but got %d", n)
+	}
+
+	// a newly issued certificate is always written
+	newCert, newKey := generateTestCertificate(t, crt)
+	if _, err := c.updateSecret(crt, crt.Namespace, newCert, newKey, nil); err != nil {
+		t.Fatalf("unexpected error storing a new certificate: %v", err)
+	}
+	if n := countSecretUpdates(b); n != 2 {
+		t.Errorf("expected 2 secret updates but got %d", n)
+	}
+
+	// once the interval has passed, metadata changes are written again
+	crt.Spec.IssuerRef.Name = "issuer-c"
+	currentTime = currentTime.Add(time.Hour)
+	if _, err := c.updateSecret(crt, crt.Namespace, newCert, newKey, nil); err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+	if n := countSecretUpdates(b); n != 3 {
+		t.Errorf("expected 3 secret updates but got %d", n)
+	}
+}
+
+func TestUpdateSecretNamespaceOwnerRefOverride(t *testing.T) {
+	tests := map[string]struct {
+		enableOwnerRef bool
+		annotations    map[string]string
+		expectOwnerRef bool
+	}{
+		"enabled globally without an override": {
+			enableOwnerRef: true,
+			expectOwnerRef: true,
+		},
+		"disabled globally without an override": {
+			enableOwnerRef: false,
+			expectOwnerRef: false,
+		},
+		"disabled globally and enabled by the namespace": {
+			enableOwnerRef: false,
+			annotations:    map[string]string{certificateOwnerRefAnnotation: "true"},
+			expectOwnerRef: true,
+		},
+		"enabled globally and disabled by the namespace": {
+			enableOwnerRef: true,
+			annotations:    map[string]string{certificateOwnerRefAnnotation: "false"},
+			expectOwnerRef: false,
+		},
+		"invalid override is ignored": {
+			enableOwnerRef: true,
+			annotations:    map[string]string{certificateOwnerRefAnnotation: "sometimes"},
+			expectOwnerRef: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: gen.DefaultTestNamespace, Annotations: tt.annotations},
+			}
+			b := &test.Builder{KubeObjects: []runtime.Object{ns}}
+			b.Start()
+			defer b.Stop()
+			ctx := *b.Context
+			ctx.CertificateOptions.EnableOwnerRef = tt.enableOwnerRef
+			c := &Controller{
+				Context:         &ctx,
+				secretLister:    b.KubeSharedInformerFactory.Core().V1().Secrets().Lister(),
+				namespaceLister: b.KubeSharedInformerFactory.Core().V1().Namespaces().Lister(),
+			}
+			b.Sync()
+
+			crt := gen.Certificate("test-crt",
+				gen.SetCertificateSecretName("output"),
+				gen.SetCertificateCommonName("example.com"),
+			)
+			cert, key := generateTestCertificate(t, crt)
+			secret, err := c.updateSecret(crt, crt.Namespace, cert, key, nil)
+			if err != nil {
+				t.Fatalf("unexpected error updating secret: %v", err)
+			}
+			if hasOwnerRef := len(secret.OwnerReferences) > 0; hasOwnerRef != tt.expectOwnerRef {
+				t.Errorf("expected owner reference to be set: %t, got owner references %v", tt.expectOwnerRef, secret.OwnerReferences)
+			}
+		})
+	}
+}
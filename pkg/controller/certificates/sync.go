@@ -22,10 +22,10 @@ import (
 	"crypto/x509"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,10 +34,11 @@ import (
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/validation"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
-	"github.com/jetstack/cert-manager/pkg/util/kube"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
 )
 
@@ -45,16 +46,31 @@ const (
 	errorIssuerNotFound    = "IssuerNotFound"
 	errorIssuerNotReady    = "IssuerNotReady"
 	errorIssuerInit        = "IssuerInitError"
+	errorIssuerUnavailable = "IssuerUnavailable"
 	errorSavingCertificate = "SaveCertError"
 	errorConfig            = "ConfigError"
 
-	reasonIssuingCertificate  = "IssueCert"
-	reasonRenewingCertificate = "RenewCert"
+	errorPrivateKeyMismatch = "PrivateKeyMismatch"
+	errorInvalidDNSName     = "InvalidDNSName"
+	errorSecretlessDisabled = "SecretlessDisabled"
+
+	reasonIssuingCertificate   = "IssueCert"
+	reasonRenewingCertificate  = "RenewCert"
+	reasonReissuingCertificate = "ReissueCert"
+
+	reasonDryRunIssuingCertificate = "DryRunIssueCert"
+
+	reasonCommonNameNotInSANs = "CommonNameNotInSANs"
 
 	successCertificateIssued  = "CertIssued"
 	successCertificateRenewed = "CertRenewed"
 
 	messageErrorSavingCertificate = "Error saving TLS certificate: "
+
+	// certificateOwnerRefAnnotation can be set to "true" or "false" on a
+	// Namespace to override whether the Secrets of Certificates in that
+	// namespace are owned by their Certificate.
+	certificateOwnerRefAnnotation = "cert-manager.io/certificate-owner-ref"
 )
 
 const (
@@ -76,8 +92,13 @@ func (c *Controller) Sync(ctx context.Context, crt *v1alpha1.Certificate) (err e
 		}
 	}()
 
+	if crtCopy.DeletionTimestamp != nil {
+		return c.finalizeCertificate(crtCopy)
+	}
+	c.ensureFinalizer(crtCopy)
+
 	// grab existing certificate and validate private key
-	certs, key, err := kube.SecretTLSKeyPair(c.secretLister, crtCopy.Namespace, crtCopy.Spec.SecretName)
+	certs, key, err := certificateKeyPair(c.secretLister, crtCopy)
 	// if we don't have a certificate, we need to trigger a re-issue immediately
 	if err != nil && !(k8sErrors.IsNotFound(err) || errors.IsInvalidData(err)) {
 		return err
@@ -88,15 +109,42 @@ func (c *Controller) Sync(ctx context.Context, crt *v1alpha1.Certificate) (err e
 		cert = certs[0]
 	}
 
+	// compare the existing certificate against the spec once, and use the
+	// result both for the status and to decide whether to re-issue
+	var matchErrs []string
+	if key != nil && cert != nil {
+		_, matchErrs = c.certificateMatchesSpec(crtCopy, key, cert)
+	}
+
 	// update certificate expiry metric
 	defer c.metrics.UpdateCertificateExpiry(crtCopy, c.secretLister)
-	c.setCertificateStatus(crtCopy, key, cert)
+	c.setCertificateStatus(crtCopy, key, cert, matchErrs)
+	c.metrics.UpdateCertificateReadyStatus(crtCopy)
 
 	el := validation.ValidateCertificate(crtCopy)
 	if len(el) > 0 {
 		c.Recorder.Eventf(crtCopy, corev1.EventTypeWarning, "BadConfig", "Resource validation failed: %v", el.ToAggregate())
 		return nil
 	}
+	if _, err := normalizeDNSNames(crtCopy); err != nil {
+		c.Recorder.Eventf(crtCopy, corev1.EventTypeWarning, errorInvalidDNSName, "Certificate has invalid DNS names: %v", err)
+		return nil
+	}
+	c.checkCommonName(crtCopy)
+
+	if isSecretless(crtCopy) && !c.CertificateOptions.EnableSecretless {
+		c.Recorder.Event(crtCopy, corev1.EventTypeWarning, errorSecretlessDisabled, "The secretless output mode is not enabled on this cert-manager installation")
+		return nil
+	}
+
+	// With the 'Never' rotation policy the existing private key is re-used
+	// for every issuance, so it must match the requested key parameters.
+	if key != nil && crtCopy.Spec.PrivateKey != nil && crtCopy.Spec.PrivateKey.RotationPolicy == v1alpha1.RotationPolicyNever {
+		if err := pki.PrivateKeyMatchesSpec(key, crtCopy); err != nil {
+			c.Recorder.Eventf(crtCopy, corev1.EventTypeWarning, errorPrivateKeyMismatch, "Existing private key in secret %q cannot be re-used: %v", crtCopy.Spec.SecretName, err)
+			return nil
+		}
+	}
 
 	// step zero: check if the referenced issuer exists and is ready
 	issuerObj, err := c.getGenericIssuer(crtCopy)
@@ -115,8 +163,8 @@ func (c *Controller) Sync(ctx context.Context, crt *v1alpha1.Certificate) (err e
 	}
 
 	// If this is an ACME certificate, ensure the certificate.spec.acme field is
-	// non-nil
-	if issuerObj.GetSpec().ACME != nil && crtCopy.Spec.ACME == nil {
+	// non-nil unless the issuer configures its own solvers
+	if acme := issuerObj.GetSpec().ACME; acme != nil && len(acme.Solvers) == 0 && crtCopy.Spec.ACME == nil {
 		c.Recorder.Eventf(crtCopy, corev1.EventTypeWarning, "BadConfig", "spec.acme field must be set")
 		return nil
 	}
@@ -137,25 +185,47 @@ func (c *Controller) Sync(ctx context.Context, crt *v1alpha1.Certificate) (err e
 	}
 
 	if key == nil || cert == nil {
-		glog.V(4).Infof("Invoking issue function as existing certificate does not exist")
-		return c.issue(ctx, i, crtCopy)
+		logs.V(4).Infof("Invoking issue function as existing certificate does not exist")
+		return c.issue(ctx, issuerObj, i, crtCopy)
+	}
+
+	if !forceRenewRequested(crtCopy) {
+		c.forceRenewals.forget(crtCopy)
+	} else if c.forceRenewals.pending(crtCopy) {
+		logs.V(4).Infof("Invoking issue function as renewal was requested by the %s annotation", v1alpha1.ForceRenewAnnotationKey)
+		c.Recorder.Eventf(crtCopy, corev1.EventTypeNormal, reasonReissuingCertificate, "Re-issuing certificate as renewal was requested by the %s annotation", v1alpha1.ForceRenewAnnotationKey)
+		return c.issue(ctx, issuerObj, i, crtCopy)
+	} else {
+		// the certificate has already been re-issued for this version of
+		// the Certificate, so only the removal of the annotation is left
+		delete(crtCopy.Annotations, v1alpha1.ForceRenewAnnotationKey)
 	}
 
 	// begin checking if the TLS certificate is valid/needs a re-issue or renew
-	matches, matchErrs := c.certificateMatchesSpec(crtCopy, key, cert)
-	if !matches {
-		glog.V(4).Infof("Invoking issue function due to certificate not matching spec: %s", strings.Join(matchErrs, ", "))
-		return c.issue(ctx, i, crtCopy)
+	if len(matchErrs) > 0 {
+		s := strings.Join(matchErrs, ", ")
+		logs.V(4).Infof("Invoking issue function due to certificate not matching spec: %s", s)
+		c.Recorder.Eventf(crtCopy, corev1.EventTypeNormal, reasonReissuingCertificate, "Re-issuing certificate as existing certificate does not match spec: %s", s)
+		return c.issue(ctx, issuerObj, i, crtCopy)
 	}
 
 	// check if the certificate needs renewal
-	needsRenew := c.Context.IssuerOptions.CertificateNeedsRenew(cert, crt.Spec.RenewBefore)
+	needsRenew := c.Context.IssuerOptions.CertificateNeedsRenew(cert, crt)
 	if needsRenew {
-		glog.V(4).Infof("Invoking issue function due to certificate needing renewal")
-		return c.issue(ctx, i, crtCopy)
+		logs.V(4).Infof("Invoking issue function due to certificate needing renewal")
+		return c.issue(ctx, issuerObj, i, crtCopy)
 	}
 	// end checking if the TLS certificate is valid/needs a re-issue or renew
 
+	// Keystores may have been enabled or disabled since the certificate was
+	// issued, so bring them up to date without re-issuing the certificate.
+	if err := c.updateKeystores(crtCopy); err != nil {
+		s := messageErrorSavingCertificate + err.Error()
+		logs.Info(s)
+		c.Recorder.Event(crtCopy, corev1.EventTypeWarning, errorSavingCertificate, s)
+		return err
+	}
+
 	// If the Certificate is valid and up to date, we schedule a renewal in
 	// the future.
 	c.scheduleRenewal(crt)
@@ -163,9 +233,35 @@ func (c *Controller) Sync(ctx context.Context, crt *v1alpha1.Certificate) (err e
 	return nil
 }
 
+// checkCommonName emits a warning event if the common name of the
+// certificate is not listed in its dnsNames or ipAddresses. TLS clients
+// ignore the common name, so it is always added to the DNS names of the
+// issued certificate, but a certificate that lists its subject alternative
+// names explicitly has most likely omitted the common name by mistake.
+// If no common name is set, the first DNS name is used.
+func (c *Controller) checkCommonName(crt *v1alpha1.Certificate) {
+	cn := crt.Spec.CommonName
+	if cn == "" || (len(crt.Spec.DNSNames) == 0 && len(crt.Spec.IPAddresses) == 0) {
+		return
+	}
+	for _, name := range crt.Spec.DNSNames {
+		if strings.EqualFold(name, cn) {
+			return
+		}
+	}
+	for _, ip := range crt.Spec.IPAddresses {
+		if ip == cn {
+			return
+		}
+	}
+	c.Recorder.Eventf(crt, corev1.EventTypeWarning, reasonCommonNameNotInSANs, "Common name %q is not listed in spec.dnsNames or spec.ipAddresses, and will be added to the DNS names of the certificate", cn)
+}
+
 // setCertificateStatus will update the status subresource of the certificate.
+// matchErrs are the differences between the certificate and the spec, as
+// returned by certificateMatchesSpec.
 // It will not actually submit the resource to the apiserver.
-func (c *Controller) setCertificateStatus(crt *v1alpha1.Certificate, key crypto.Signer, cert *x509.Certificate) {
+func (c *Controller) setCertificateStatus(crt *v1alpha1.Certificate, key crypto.Signer, cert *x509.Certificate, matchErrs []string) {
 	if key == nil || cert == nil {
 		crt.UpdateStatusCondition(v1alpha1.CertificateConditionReady, v1alpha1.ConditionFalse, "NotFound", "Certificate does not exist", false)
 		return
@@ -173,13 +269,22 @@ func (c *Controller) setCertificateStatus(crt *v1alpha1.Certificate, key crypto.
 
 	metaNotAfter := metav1.NewTime(cert.NotAfter)
 	crt.Status.NotAfter = &metaNotAfter
+	metaRenewalTime := metav1.NewTime(c.renewalTime(cert, crt))
+	crt.Status.RenewalTime = &metaRenewalTime
 
 	// Derive & set 'Ready' condition on Certificate resource
-	matches, matchErrs := c.certificateMatchesSpec(crt, key, cert)
+	matches := len(matchErrs) == 0
 	reason := "Ready"
-	if cert.NotAfter.Before(now()) {
+	// allow for the controller's clock being out of step with the issuer's.
+	// Certificates are only reported as not yet valid when a tolerance has
+	// been configured, so that the default behaviour is unchanged.
+	tolerance := c.CertificateOptions.ClockSkewTolerance
+	if cert.NotAfter.Add(tolerance).Before(now()) {
 		reason = "Expired"
 		matchErrs = append(matchErrs, fmt.Sprintf("Certificate has expired"))
+	} else if tolerance > 0 && cert.NotBefore.After(now().Add(tolerance)) {
+		reason = "NotYetValid"
+		matchErrs = append(matchErrs, fmt.Sprintf("Certificate is not yet valid"))
 	}
 	if !matches {
 		reason = "DoesNotMatch"
@@ -194,12 +299,32 @@ func (c *Controller) setCertificateStatus(crt *v1alpha1.Certificate, key crypto.
 	return
 }
 
+// setIssuedCertificateStatus updates the status of the Certificate to reflect
+// a newly issued certificate, rather than waiting for the next sync to do so.
+func (c *Controller) setIssuedCertificateStatus(crt *v1alpha1.Certificate, certPEM, keyPEM []byte) {
+	cert, err := pki.DecodeX509CertificateBytes(certPEM)
+	if err != nil {
+		return
+	}
+	key, err := pki.DecodePrivateKeyBytes(keyPEM)
+	if err != nil {
+		return
+	}
+	_, matchErrs := c.certificateMatchesSpec(crt, key, cert)
+	c.setCertificateStatus(crt, key, cert, matchErrs)
+}
+
 func (c *Controller) certificateMatchesSpec(crt *v1alpha1.Certificate, key crypto.Signer, cert *x509.Certificate) (bool, []string) {
 	var errs []string
 
+	// certificates are issued for the normalized DNS names, so compare
+	// against those rather than the spec as written
+	if normalized, err := normalizeDNSNames(crt); err == nil {
+		crt = normalized
+	}
+
 	// TODO: add checks for KeySize, KeyAlgorithm fields
 	// TODO: add checks for Organization field
-	// TODO: add checks for IsCA field
 
 	// check if the private key is the corresponding pair to the certificate
 	matches, err := pki.PublicKeyMatchesCertificate(key.Public(), cert)
@@ -222,13 +347,79 @@ func (c *Controller) certificateMatchesSpec(crt *v1alpha1.Certificate, key crypt
 	}
 
 	// validate the ip addresses are correct
-	if !util.EqualUnsorted(pki.IPAddressesToString(cert.IPAddresses), crt.Spec.IPAddresses) {
+	// compare the canonical forms so that e.g. an upper case IPv6 address in
+	// the spec does not cause the certificate to be re-issued on every sync
+	if !util.EqualUnsorted(pki.IPAddressesToString(cert.IPAddresses), pki.IPAddressesToString(pki.IPAddressesForCertificate(crt))) {
 		errs = append(errs, fmt.Sprintf("IP addresses on TLS certificate not up to date: %q", pki.IPAddressesToString(cert.IPAddresses)))
 	}
 
+	if !util.EqualUnsorted(pki.URIsToString(cert.URIs), pki.URIsToString(pki.URIsForCertificate(crt))) {
+		errs = append(errs, fmt.Sprintf("URIs on TLS certificate not up to date: %q", pki.URIsToString(cert.URIs)))
+	}
+
+	// validate the key usages are correct, unless they have been replaced
+	// by a custom key usage extension
+	if !hasExtension(crt, oidKeyUsage) {
+		certSign := cert.KeyUsage&x509.KeyUsageCertSign != 0
+		if certSign != crt.Spec.IsCA {
+			errs = append(errs, fmt.Sprintf("Key usages on TLS certificate not up to date: cert sign usage is %t", certSign))
+		}
+	}
+
+	// explicitly requested usages must match exactly. They are only
+	// supported by issuers that set the usages they are asked for, so this
+	// cannot cause a certificate to be re-issued indefinitely.
+	if len(crt.Spec.Usages) > 0 {
+		ku, eku, err := pki.KeyUsagesForCertificate(crt)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			if !hasExtension(crt, oidKeyUsage) && cert.KeyUsage != ku {
+				errs = append(errs, fmt.Sprintf("Key usages on TLS certificate not up to date: %#x", cert.KeyUsage))
+			}
+			if !hasExtension(crt, oidExtKeyUsage) && !equalExtKeyUsages(cert.ExtKeyUsage, eku) {
+				errs = append(errs, fmt.Sprintf("Extended key usages on TLS certificate not up to date: %v", cert.ExtKeyUsage))
+			}
+		}
+	}
+
 	return len(errs) == 0, errs
 }
 
+// oidKeyUsage is the object identifier of the x509 key usage extension.
+const oidKeyUsage = "2.5.29.15"
+
+// oidExtKeyUsage is the object identifier of the x509 extended key usage
+// extension.
+const oidExtKeyUsage = "2.5.29.37"
+
+// equalExtKeyUsages returns true if a and b contain the same extended key
+// usages, in any order.
+func equalExtKeyUsages(a, b []x509.ExtKeyUsage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+Outer:
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				continue Outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func hasExtension(crt *v1alpha1.Certificate, oid string) bool {
+	for _, e := range crt.Spec.Extensions {
+		if e.OID == oid {
+			return true
+		}
+	}
+	return false
+}
+
 // TODO: replace with a call to controllerpkg.Helper.GetGenericIssuer
 func (c *Controller) getGenericIssuer(crt *v1alpha1.Certificate) (v1alpha1.GenericIssuer, error) {
 	switch crt.Spec.IssuerRef.Kind {
@@ -252,7 +443,7 @@ func (c *Controller) scheduleRenewal(crt *v1alpha1.Certificate) {
 		return
 	}
 
-	cert, err := kube.SecretTLSCert(c.secretLister, crt.Namespace, crt.Spec.SecretName)
+	certs, _, err := certificateKeyPair(c.secretLister, crt)
 
 	if err != nil {
 		if !errors.IsInvalidData(err) {
@@ -260,12 +451,13 @@ func (c *Controller) scheduleRenewal(crt *v1alpha1.Certificate) {
 		}
 		return
 	}
+	cert := certs[0]
 
 	renewIn := c.calculateDurationUntilRenew(cert, crt)
 
 	c.scheduledWorkQueue.Add(key, renewIn)
 
-	glog.Infof("Certificate %s/%s scheduled for renewal in %s", crt.Namespace, crt.Name, renewIn.String())
+	logs.Infof("Certificate %s/%s scheduled for renewal in %s", crt.Namespace, crt.Name, renewIn.String())
 }
 
 // issuerKind returns the kind of issuer for a certificate
@@ -288,11 +480,49 @@ func ownerRef(crt *v1alpha1.Certificate) metav1.OwnerReference {
 	}
 }
 
+// caChain returns the PEM encoded chain of CA certificates that issued the
+// leaf certificate in cert: the intermediates bundled after the leaf in
+// cert, followed by any certificates in ca that are not already included.
+func caChain(cert, ca []byte) ([]byte, error) {
+	certs, err := pki.DecodeX509CertificateChainBytes(cert)
+	if err != nil {
+		return nil, err
+	}
+	chain := certs[1:]
+	if len(ca) > 0 {
+		caCerts, err := pki.DecodeX509CertificateChainBytes(ca)
+		if err != nil {
+			return nil, err
+		}
+		for _, caCert := range caCerts {
+			if !containsCertificate(chain, caCert.Raw) {
+				chain = append(chain, caCert)
+			}
+		}
+	}
+	// pki.EncodeX509Chain is not used as it omits self signed certificates,
+	// and the root CA belongs in the chain
+	var chainPEM []byte
+	for _, c := range chain {
+		certPEM, err := pki.EncodeX509(c)
+		if err != nil {
+			return nil, err
+		}
+		chainPEM = append(chainPEM, certPEM...)
+	}
+	return chainPEM, nil
+}
+
 func (c *Controller) updateSecret(crt *v1alpha1.Certificate, namespace string, cert, key, ca []byte) (*corev1.Secret, error) {
 	secret, err := c.Client.CoreV1().Secrets(namespace).Get(crt.Spec.SecretName, metav1.GetOptions{})
 	if err != nil && !k8sErrors.IsNotFound(err) {
 		return nil, err
 	}
+	// existing is an unmodified copy of the current Secret, if it exists
+	var existing *corev1.Secret
+	if err == nil {
+		existing = secret.DeepCopy()
+	}
 	if k8sErrors.IsNotFound(err) {
 		secret = &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -307,9 +537,18 @@ func (c *Controller) updateSecret(crt *v1alpha1.Certificate, namespace string, c
 	if secret.Data == nil {
 		secret.Data = map[string][]byte{}
 	}
+	if crt.Spec.CAChain && len(cert) > 0 {
+		ca, err = caChain(cert, ca)
+		if err != nil {
+			return nil, fmt.Errorf("error building CA chain: %v", err)
+		}
+	}
 	secret.Data[corev1.TLSCertKey] = cert
 	secret.Data[corev1.TLSPrivateKeyKey] = key
 	secret.Data[TLSCAKey] = ca
+	if err := c.setKeystores(crt, secret.Data, cert, key, ca); err != nil {
+		return nil, fmt.Errorf("error writing keystores: %v", err)
+	}
 
 	if secret.Annotations == nil {
 		secret.Annotations = make(map[string]string)
@@ -328,6 +567,7 @@ func (c *Controller) updateSecret(crt *v1alpha1.Certificate, namespace string, c
 		secret.Annotations[v1alpha1.CommonNameAnnotationKey] = x509Cert.Subject.CommonName
 		secret.Annotations[v1alpha1.AltNamesAnnotationKey] = strings.Join(x509Cert.DNSNames, ",")
 		secret.Annotations[v1alpha1.IPSANAnnotationKey] = strings.Join(pki.IPAddressesToString(x509Cert.IPAddresses), ",")
+		secret.Annotations[v1alpha1.URISANAnnotationKey] = strings.Join(pki.URIsToString(x509Cert.URIs), ",")
 	}
 
 	// Always set the certificate name label on the target secret
@@ -336,10 +576,28 @@ func (c *Controller) updateSecret(crt *v1alpha1.Certificate, namespace string, c
 	}
 	secret.Labels[v1alpha1.CertificateNameKey] = crt.Name
 
+	hash := secretDataHash(secret)
+	secret.Annotations[v1alpha1.SecretDataHashAnnotationKey] = hash
+
+	secretKey := namespace + "/" + crt.Spec.SecretName
+	if existing != nil {
+		// skip the update if the Secret already contains what we would write
+		if secretUpToDate(existing, hash) {
+			logs.V(4).Infof("%s/%s: secret %q is up to date, not updating", crt.Namespace, crt.Name, crt.Spec.SecretName)
+			return existing, nil
+		}
+		// only newly issued certificates and keys are written straight away,
+		// other changes wait for the minimum interval between writes
+		if !keyMaterialChanged(existing, secret) {
+			if wait := c.secretWrites.waitTime(secretKey, c.CertificateOptions.SecretUpdateMinInterval); wait > 0 {
+				return nil, fmt.Errorf("secret %q was updated recently, waiting %s before updating it again", crt.Spec.SecretName, wait)
+			}
+		}
+	}
+
 	// if it is a new resource
 	if secret.SelfLink == "" {
-		enableOwner := c.CertificateOptions.EnableOwnerRef
-		if enableOwner {
+		if c.ownerRefEnabled(namespace) {
 			secret.SetOwnerReferences(append(secret.GetOwnerReferences(), ownerRef(crt)))
 		}
 		secret, err = c.Client.CoreV1().Secrets(namespace).Create(secret)
@@ -349,15 +607,95 @@ func (c *Controller) updateSecret(crt *v1alpha1.Certificate, namespace string, c
 	if err != nil {
 		return nil, err
 	}
+	c.secretWrites.written(secretKey)
 	return secret, nil
 }
 
+// ownerRefEnabled returns whether a new Secret in the given namespace should
+// be owned by its Certificate. The namespace may override the controller-wide
+// default using certificateOwnerRefAnnotation.
+func (c *Controller) ownerRefEnabled(namespace string) bool {
+	enabled := c.CertificateOptions.EnableOwnerRef
+	if c.namespaceLister == nil {
+		return enabled
+	}
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			logs.Warningf("Error getting namespace %q to look up its certificate owner reference setting: %v", namespace, err)
+		}
+		return enabled
+	}
+	value, ok := ns.Annotations[certificateOwnerRefAnnotation]
+	if !ok {
+		return enabled
+	}
+	override, err := strconv.ParseBool(value)
+	if err != nil {
+		logs.Warningf("Ignoring invalid value %q for annotation %s on namespace %q: must be true or false", value, certificateOwnerRefAnnotation, namespace)
+		return enabled
+	}
+	return override
+}
+
+// updateKeystores updates the keystores in the Certificate's Secret if they
+// do not match those configured on the Certificate.
+func (c *Controller) updateKeystores(crt *v1alpha1.Certificate) error {
+	if isSecretless(crt) {
+		return nil
+	}
+	secret, err := c.secretLister.Secrets(crt.Namespace).Get(crt.Spec.SecretName)
+	if err != nil {
+		return err
+	}
+	if keystoresUpToDate(crt, secret) {
+		return nil
+	}
+	if c.DryRun {
+		logs.Infof("%s/%s: Dry run: would have updated the keystores in secret %q", crt.Namespace, crt.Name, crt.Spec.SecretName)
+		return nil
+	}
+	_, err = c.updateSecret(crt, crt.Namespace, secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], secret.Data[TLSCAKey])
+	return err
+}
+
 // return an error on failure. If retrieval is succesful, the certificate data
 // and private key will be stored in the named secret
-func (c *Controller) issue(ctx context.Context, issuer issuer.Interface, crt *v1alpha1.Certificate) error {
-	resp, err := issuer.Issue(ctx, crt)
+func (c *Controller) issue(ctx context.Context, issuerObj v1alpha1.GenericIssuer, issuer issuer.Interface, crt *v1alpha1.Certificate) error {
+	if c.DryRun {
+		s := fmt.Sprintf("Dry run: would have issued a certificate and stored it in secret %q", crt.Spec.SecretName)
+		logs.Infof("%s/%s: %s", crt.Namespace, crt.Name, s)
+		c.Recorder.Event(crt, corev1.EventTypeNormal, reasonDryRunIssuingCertificate, s)
+		return nil
+	}
+
+	// if the issuer has failed too many times in a row, try again once its
+	// cooldown has passed
+	if ok, wait := c.IssuerBreaker.Allow(issuerObj); !ok {
+		c.Recorder.Eventf(crt, corev1.EventTypeWarning, errorIssuerUnavailable, "Issuer %s is temporarily unavailable after repeated failures, retrying in %s", issuerObj.GetObjectMeta().Name, wait)
+		key, err := keyFunc(crt)
+		if err != nil {
+			return err
+		}
+		c.scheduledWorkQueue.Add(key, wait)
+		return nil
+	}
+
+	// the issuer is sent normalized DNS names, leaving the spec untouched
+	issueCrt, err := normalizeDNSNames(crt)
 	if err != nil {
-		glog.Infof("Error issuing certificate for %s/%s: %v", crt.Namespace, crt.Name, err)
+		c.Recorder.Eventf(crt, corev1.EventTypeWarning, errorInvalidDNSName, "Certificate has invalid DNS names: %v", err)
+		return nil
+	}
+
+	resp, err := issuer.Issue(ctx, issueCrt)
+	// issuers record progress, such as a pending request, in the status and
+	// annotations of the certificate, which must be saved with the original
+	crt.Status = issueCrt.Status
+	crt.Annotations = issueCrt.Annotations
+	c.IssuerBreaker.Record(issuerObj, err)
+	if err != nil {
+		logs.Infof("Error issuing certificate for %s/%s: %v", crt.Namespace, crt.Name, err)
 		return err
 	}
 
@@ -365,15 +703,30 @@ func (c *Controller) issue(ctx context.Context, issuer issuer.Interface, crt *v1
 		return nil
 	}
 
-	if _, err := c.updateSecret(crt, crt.Namespace, resp.Certificate, resp.PrivateKey, resp.CA); err != nil {
+	if isSecretless(crt) {
+		if err := updateStatusOutput(crt, resp.Certificate, resp.PrivateKey, resp.CA); err != nil {
+			s := messageErrorSavingCertificate + err.Error()
+			logs.Info(s)
+			c.Recorder.Event(crt, corev1.EventTypeWarning, errorSavingCertificate, s)
+			// don't trigger a retry, as issuing again will not make the
+			// certificate fit in the status
+			return nil
+		}
+	} else if _, err := c.updateSecret(crt, crt.Namespace, resp.Certificate, resp.PrivateKey, resp.CA); err != nil {
 		s := messageErrorSavingCertificate + err.Error()
-		glog.Info(s)
+		logs.Info(s)
 		c.Recorder.Event(crt, corev1.EventTypeWarning, errorSavingCertificate, s)
 		return err
 	}
 
 	if len(resp.Certificate) > 0 {
 		c.Recorder.Event(crt, corev1.EventTypeNormal, successCertificateIssued, "Certificate issued successfully")
+		// a newly issued certificate fulfils any requested renewal
+		if forceRenewRequested(crt) {
+			c.forceRenewals.renewed(crt)
+			delete(crt.Annotations, v1alpha1.ForceRenewAnnotationKey)
+		}
+		c.setIssuedCertificateStatus(crt, resp.Certificate, resp.PrivateKey)
 		// as we have just written a certificate, we should schedule it for renewal
 		c.scheduleRenewal(crt)
 	}
@@ -382,7 +735,12 @@ func (c *Controller) issue(ctx context.Context, issuer issuer.Interface, crt *v1
 }
 
 func (c *Controller) updateCertificateStatus(old, new *v1alpha1.Certificate) (*v1alpha1.Certificate, error) {
-	if reflect.DeepEqual(old.Status, new.Status) {
+	if reflect.DeepEqual(old.Status, new.Status) && reflect.DeepEqual(old.Finalizers, new.Finalizers) &&
+		reflect.DeepEqual(old.Annotations, new.Annotations) {
+		return nil, nil
+	}
+	if c.DryRun {
+		logs.Infof("%s/%s: Dry run: would have updated the Certificate status", new.Namespace, new.Name)
 		return nil, nil
 	}
 	// TODO: replace Update call with UpdateStatus. This requires a custom API
@@ -402,31 +760,35 @@ func (c *Controller) calculateDurationUntilRenew(cert *x509.Certificate, crt *v1
 	certDuration := cert.NotAfter.Sub(cert.NotBefore)
 	if crt.Spec.Duration != nil && certDuration < crt.Spec.Duration.Duration {
 		s := fmt.Sprintf(messageCertificateDuration, certDuration, crt.Spec.Duration.Duration)
-		glog.Info(s)
+		logs.Info(s)
 		// TODO Use the message as the reason in a 'renewal status' condition
 	}
 
-	// renew is the duration before the certificate expiration that cert-manager
-	// will start to try renewing the certificate.
-	renewBefore := v1alpha1.DefaultRenewBefore
-	if crt.Spec.RenewBefore != nil {
-		renewBefore = crt.Spec.RenewBefore.Duration
-	}
-
 	// Verify that the renewBefore duration is inside the certificate validity duration.
 	// If not we notify with an event that we will renew the certificate
 	// before (certificate duration / 3) of its expiration duration.
-	if renewBefore > certDuration {
-		glog.Info(messageScheduleModified)
+	if controllerpkg.RenewBeforeDuration(cert, crt, c.IssuerOptions.RenewBeforeExpiryDuration) > certDuration {
+		logs.Info(messageScheduleModified)
 		// TODO Use the message as the reason in a 'renewal status' condition
-		// We will renew 1/3 before the expiration date.
-		renewBefore = certDuration / 3
 	}
 
-	// calculate the amount of time until expiry
-	durationUntilExpiry := cert.NotAfter.Sub(now())
 	// calculate how long until we should start attempting to renew the certificate
-	renewIn := durationUntilExpiry - renewBefore
+	return c.renewalTime(cert, crt).Sub(now())
+}
+
+// renewalTime returns the time at which cert-manager should start attempting
+// to renew the given certificate.
+func (c *Controller) renewalTime(cert *x509.Certificate, crt *v1alpha1.Certificate) time.Time {
+	// renew is the duration before the certificate expiration that cert-manager
+	// will start to try renewing the certificate.
+	renewBefore := controllerpkg.RenewBeforeDuration(cert, crt, c.IssuerOptions.RenewBeforeExpiryDuration)
+	certDuration := cert.NotAfter.Sub(cert.NotBefore)
+	if renewBefore > certDuration {
+		// We will renew 1/3 before the expiration date.
+		renewBefore = certDuration / 3
+	}
+	renewBefore += controllerpkg.RenewalJitter(cert, crt, renewBefore, c.IssuerOptions.RenewalJitter)
 
-	return renewIn
+	// allow for the controller's clock being ahead of the issuer's
+	return cert.NotAfter.Add(c.CertificateOptions.ClockSkewTolerance).Add(-renewBefore)
 }
@@ -17,16 +17,232 @@ limitations under the License.
 package certificates
 
 import (
+	"bytes"
+	"context"
+	"crypto"
 	"crypto/x509"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	cmfake "github.com/jetstack/cert-manager/pkg/client/clientset/versioned/fake"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	_ "github.com/jetstack/cert-manager/pkg/issuer/selfsigned"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/pkg/scheduler"
+	"github.com/jetstack/cert-manager/pkg/util"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
 )
 
+type fakeIssuer struct {
+	issueCalled bool
+	err         error
+	mutate      func(*v1alpha1.Certificate)
+}
+
+func (f *fakeIssuer) Setup(context.Context) error {
+	return nil
+}
+
+func (f *fakeIssuer) Issue(_ context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
+	f.issueCalled = true
+	if f.mutate != nil {
+		f.mutate(crt)
+	}
+	return nil, f.err
+}
+
+func TestIssueDryRun(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	c := &Controller{Context: &controllerpkg.Context{Recorder: recorder, DryRun: true}}
+	i := &fakeIssuer{}
+	crt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       v1alpha1.CertificateSpec{SecretName: "test-tls"},
+	}
+
+	if err := c.issue(context.Background(), gen.Issuer("test"), i, crt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.issueCalled {
+		t.Errorf("expected issuer not to be called in dry-run mode")
+	}
+	select {
+	case e := <-recorder.Events:
+		if !strings.Contains(e, reasonDryRunIssuingCertificate) {
+			t.Errorf("expected %s event, got %q", reasonDryRunIssuingCertificate, e)
+		}
+	default:
+		t.Errorf("expected an event to be recorded")
+	}
+}
+
+func TestUpdateCertificateStatusDryRun(t *testing.T) {
+	old := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+	}
+	cl := cmfake.NewSimpleClientset(old)
+	c := &Controller{Context: &controllerpkg.Context{CMClient: cl, DryRun: true}}
+
+	new := old.DeepCopy()
+	new.Finalizers = []string{"test"}
+	new.Status.Conditions = []v1alpha1.CertificateCondition{{Type: v1alpha1.CertificateConditionReady}}
+	if _, err := c.updateCertificateStatus(old, new); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actions := cl.Actions(); len(actions) != 0 {
+		t.Errorf("expected no API calls in dry-run mode, got %v", actions)
+	}
+}
+
+func TestIssueKeepsIssuerChanges(t *testing.T) {
+	c := &Controller{Context: &controllerpkg.Context{Recorder: record.NewFakeRecorder(1)}}
+	now := metav1.Now()
+	i := &fakeIssuer{
+		err: fmt.Errorf("certificate pending"),
+		mutate: func(crt *v1alpha1.Certificate) {
+			crt.Annotations = map[string]string{"pending": "request-id"}
+			crt.Status.LastFailureTime = &now
+		},
+	}
+	crt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1alpha1.CertificateSpec{
+			SecretName: "test-tls",
+			DNSNames:   []string{"EXAMPLE.com"},
+		},
+	}
+
+	if err := c.issue(context.Background(), gen.Issuer("test"), i, crt); err == nil {
+		t.Fatalf("expected the issuer error to be returned")
+	}
+	if crt.Annotations["pending"] != "request-id" {
+		t.Errorf("expected annotations set by the issuer to be kept, got %v", crt.Annotations)
+	}
+	if crt.Status.LastFailureTime == nil {
+		t.Errorf("expected status set by the issuer to be kept")
+	}
+	if crt.Spec.DNSNames[0] != "EXAMPLE.com" {
+		t.Errorf("expected the spec not to be modified, got %v", crt.Spec.DNSNames)
+	}
+}
+
+func TestIssueIssuerBreaker(t *testing.T) {
+	iss := gen.Issuer("test")
+	iss.Namespace = "default"
+	cl := cmfake.NewSimpleClientset(iss)
+	recorder := record.NewFakeRecorder(1)
+	c := &Controller{
+		Context: &controllerpkg.Context{
+			Recorder:      recorder,
+			IssuerBreaker: controllerpkg.NewIssuerBreaker(cl, 2, time.Hour, time.Hour),
+		},
+		scheduledWorkQueue: scheduler.NewScheduledWorkQueue(func(interface{}) {}),
+	}
+	i := &fakeIssuer{err: fmt.Errorf("backend unavailable")}
+	crt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       v1alpha1.CertificateSpec{SecretName: "test-tls"},
+	}
+
+	for n := 0; n < 2; n++ {
+		if err := c.issue(context.Background(), iss, i, crt); err == nil {
+			t.Fatalf("expected the issuer error to be returned")
+		}
+	}
+
+	i.issueCalled = false
+	if err := c.issue(context.Background(), iss, i, crt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.issueCalled {
+		t.Errorf("expected the issuer not to be called once its breaker has opened")
+	}
+	select {
+	case e := <-recorder.Events:
+		if !strings.Contains(e, errorIssuerUnavailable) {
+			t.Errorf("expected %s event, got %q", errorIssuerUnavailable, e)
+		}
+	default:
+		t.Errorf("expected an event to be recorded")
+	}
+}
+
+func TestCheckCommonName(t *testing.T) {
+	tests := map[string]struct {
+		commonName  string
+		dnsNames    []string
+		ipAddresses []string
+		expectEvent bool
+	}{
+		"no event if the common name is derived from the dns names": {
+			dnsNames: []string{"example.com", "www.example.com"},
+		},
+		"no event if only a common name is set": {
+			commonName: "example.com",
+		},
+		"no event if the common name is listed in the dns names": {
+			commonName: "Example.com",
+			dnsNames:   []string{"www.example.com", "example.com"},
+		},
+		"no event if the common name is listed in the ip addresses": {
+			commonName:  "10.0.0.1",
+			ipAddresses: []string{"10.0.0.1"},
+		},
+		"event if the common name is not listed in the dns names": {
+			commonName:  "example.com",
+			dnsNames:    []string{"www.example.com"},
+			expectEvent: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			c := &Controller{Context: &controllerpkg.Context{Recorder: recorder}}
+			crt := gen.Certificate("test",
+				gen.SetCertificateCommonName(test.commonName),
+				gen.SetCertificateDNSNames(test.dnsNames...),
+			)
+			crt.Spec.IPAddresses = test.ipAddresses
+
+			c.checkCommonName(crt)
+
+			select {
+			case e := <-recorder.Events:
+				if !test.expectEvent {
+					t.Errorf("expected no event, got %q", e)
+				} else if !strings.Contains(e, reasonCommonNameNotInSANs) {
+					t.Errorf("expected %s event, got %q", reasonCommonNameNotInSANs, e)
+				}
+			default:
+				if test.expectEvent {
+					t.Errorf("expected an event to be recorded")
+				}
+			}
+		})
+	}
+}
+
 func TestCalculateDurationUntilRenew(t *testing.T) {
-	c := &Controller{}
+	c := &Controller{
+		Context: &controllerpkg.Context{
+			IssuerOptions: controllerpkg.IssuerOptions{
+				RenewBeforeExpiryDuration: v1alpha1.DefaultRenewBefore,
+			},
+		},
+	}
 	currentTime := time.Now()
 	now = func() time.Time { return currentTime }
 	defer func() { now = time.Now }()
@@ -36,6 +252,7 @@ func TestCalculateDurationUntilRenew(t *testing.T) {
 		notAfter       time.Time
 		duration       *metav1.Duration
 		renewBefore    *metav1.Duration
+		percentage     *int32
 		expectedExpiry time.Duration
 	}{
 		{
@@ -86,12 +303,37 @@ func TestCalculateDurationUntilRenew(t *testing.T) {
 			renewBefore:    &metav1.Duration{time.Hour * 24 * 40},
 			expectedExpiry: time.Hour * 24 * 35 * 2 / 3,
 		},
+		{
+			desc:           "expiry at 2/3 of certificate duration when renewBeforePercentage is 33",
+			notBefore:      now(),
+			notAfter:       now().Add(time.Minute * 90),
+			duration:       &metav1.Duration{time.Minute * 90},
+			percentage:     int32Ptr(33),
+			expectedExpiry: (time.Minute * 90) - (time.Minute * 90 / 100 * 33),
+		},
+		{
+			desc:           "expiry at half of certificate duration when renewBeforePercentage is 50",
+			notBefore:      now(),
+			notAfter:       now().Add(time.Hour * 24 * 365),
+			duration:       &metav1.Duration{time.Hour * 24 * 365},
+			percentage:     int32Ptr(50),
+			expectedExpiry: time.Hour * 24 * 365 / 2,
+		},
+		{
+			desc:           "renewBeforePercentage is relative to the issued certificate's lifetime",
+			notBefore:      now().Add(-time.Hour * 24 * 10),
+			notAfter:       now().Add(time.Hour * 24 * 10),
+			duration:       nil,
+			percentage:     int32Ptr(25),
+			expectedExpiry: time.Hour * 24 * 5,
+		},
 	}
 	for k, v := range tests {
 		cert := &v1alpha1.Certificate{
 			Spec: v1alpha1.CertificateSpec{
-				Duration:    v.duration,
-				RenewBefore: v.renewBefore,
+				Duration:              v.duration,
+				RenewBefore:           v.renewBefore,
+				RenewBeforePercentage: v.percentage,
 			},
 		}
 		x509Cert := &x509.Certificate{NotBefore: v.notBefore, NotAfter: v.notAfter}
@@ -101,3 +343,520 @@ func TestCalculateDurationUntilRenew(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculateDurationUntilRenewJitter(t *testing.T) {
+	jitter := time.Hour * 12
+	c := &Controller{
+		Context: &controllerpkg.Context{
+			IssuerOptions: controllerpkg.IssuerOptions{
+				RenewBeforeExpiryDuration: v1alpha1.DefaultRenewBefore,
+				RenewalJitter:             jitter,
+			},
+		},
+	}
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	x509Cert := &x509.Certificate{NotBefore: now(), NotAfter: now().Add(time.Hour * 24 * 90)}
+	unjittered := time.Hour * 24 * 60
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		crt := &v1alpha1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("cert-%d", i)},
+		}
+		renewIn := c.calculateDurationUntilRenew(x509Cert, crt)
+		if renewIn > unjittered || renewIn <= unjittered-jitter {
+			t.Errorf("%s: renewal in %v is outside of the jitter window (%v, %v]", crt.Name, renewIn, unjittered-jitter, unjittered)
+		}
+		if again := c.calculateDurationUntilRenew(x509Cert, crt); again != renewIn {
+			t.Errorf("%s: expected renewal time to be stable, got %v then %v", crt.Name, renewIn, again)
+		}
+		seen[renewIn] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected renewal times to be spread over the jitter window, got %v", seen)
+	}
+}
+
+func TestCalculateDurationUntilRenewJitterBoundedByLifetime(t *testing.T) {
+	c := &Controller{
+		Context: &controllerpkg.Context{
+			IssuerOptions: controllerpkg.IssuerOptions{
+				RenewBeforeExpiryDuration: v1alpha1.DefaultRenewBefore,
+				RenewalJitter:             time.Hour * 24 * 365,
+			},
+		},
+	}
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	x509Cert := &x509.Certificate{NotBefore: now(), NotAfter: now().Add(time.Hour * 24 * 90)}
+	for i := 0; i < 50; i++ {
+		crt := &v1alpha1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("cert-%d", i)},
+		}
+		renewIn := c.calculateDurationUntilRenew(x509Cert, crt)
+		if renewIn <= 0 || renewIn > time.Hour*24*60 {
+			t.Errorf("%s: renewal in %v is outside of the certificate lifetime", crt.Name, renewIn)
+		}
+	}
+}
+
+func TestCalculateDurationUntilRenewClockSkew(t *testing.T) {
+	c := &Controller{
+		Context: &controllerpkg.Context{
+			IssuerOptions: controllerpkg.IssuerOptions{
+				RenewBeforeExpiryDuration: v1alpha1.DefaultRenewBefore,
+			},
+			CertificateOptions: controllerpkg.CertificateOptions{
+				ClockSkewTolerance: 5 * time.Minute,
+			},
+		},
+	}
+	issuedAt := time.Now()
+	x509Cert := &x509.Certificate{NotBefore: issuedAt, NotAfter: issuedAt.Add(time.Hour * 24 * 90)}
+	crt := &v1alpha1.Certificate{}
+	defer func() { now = time.Now }()
+
+	tests := map[string]struct {
+		skew     time.Duration
+		expected time.Duration
+	}{
+		"controller clock in step":     {skew: 0, expected: time.Hour*24*60 + 5*time.Minute},
+		"controller clock ahead":       {skew: 5 * time.Minute, expected: time.Hour * 24 * 60},
+		"controller clock behind":      {skew: -5 * time.Minute, expected: time.Hour*24*60 + 10*time.Minute},
+		"controller clock far ahead":   {skew: time.Hour * 24 * 61, expected: 5*time.Minute - time.Hour*24},
+		"controller clock far behind":  {skew: -time.Hour * 24, expected: time.Hour*24*61 + 5*time.Minute},
+		"controller clock just ahead":  {skew: time.Minute, expected: time.Hour*24*60 + 4*time.Minute},
+		"controller clock just behind": {skew: -time.Minute, expected: time.Hour*24*60 + 6*time.Minute},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			now = func() time.Time { return issuedAt.Add(test.skew) }
+			if renewIn := c.calculateDurationUntilRenew(x509Cert, crt); renewIn != test.expected {
+				t.Errorf("expected renewal in %v, got %v", test.expected, renewIn)
+			}
+		})
+	}
+}
+
+func TestSetCertificateStatusClockSkew(t *testing.T) {
+	key, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	issuedAt := time.Now()
+	x509Cert := &x509.Certificate{NotBefore: issuedAt, NotAfter: issuedAt.Add(time.Hour)}
+	defer func() { now = time.Now }()
+
+	tests := map[string]struct {
+		tolerance time.Duration
+		now       time.Time
+		reason    string
+	}{
+		"valid certificate": {
+			now:    issuedAt.Add(time.Minute),
+			reason: "Ready",
+		},
+		"expired without tolerance": {
+			now:    issuedAt.Add(time.Hour + time.Minute),
+			reason: "Expired",
+		},
+		"not yet valid is not reported without tolerance": {
+			now:    issuedAt.Add(-time.Minute),
+			reason: "Ready",
+		},
+		"controller clock ahead within tolerance": {
+			tolerance: 5 * time.Minute,
+			now:       issuedAt.Add(time.Hour + time.Minute),
+			reason:    "Ready",
+		},
+		"controller clock behind within tolerance": {
+			tolerance: 5 * time.Minute,
+			now:       issuedAt.Add(-time.Minute),
+			reason:    "Ready",
+		},
+		"controller clock ahead beyond tolerance": {
+			tolerance: 5 * time.Minute,
+			now:       issuedAt.Add(time.Hour + 6*time.Minute),
+			reason:    "Expired",
+		},
+		"controller clock behind beyond tolerance": {
+			tolerance: 5 * time.Minute,
+			now:       issuedAt.Add(-6 * time.Minute),
+			reason:    "NotYetValid",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{
+				Context: &controllerpkg.Context{
+					CertificateOptions: controllerpkg.CertificateOptions{
+						ClockSkewTolerance: test.tolerance,
+					},
+				},
+			}
+			now = func() time.Time { return test.now }
+			crt := &v1alpha1.Certificate{}
+			c.setCertificateStatus(crt, key, x509Cert, nil)
+			if len(crt.Status.Conditions) != 1 || crt.Status.Conditions[0].Reason != test.reason {
+				t.Errorf("expected a condition with reason %q, got %+v", test.reason, crt.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestSetCertificateStatusRecordsHistory(t *testing.T) {
+	key, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	issuedAt := time.Now()
+	x509Cert := &x509.Certificate{NotBefore: issuedAt, NotAfter: issuedAt.Add(time.Hour)}
+	defer func() { now = time.Now }()
+
+	c := &Controller{Context: &controllerpkg.Context{}}
+	crt := &v1alpha1.Certificate{}
+
+	c.setCertificateStatus(crt, nil, nil, nil)
+	now = func() time.Time { return issuedAt.Add(time.Minute) }
+	c.setCertificateStatus(crt, key, x509Cert, nil)
+	c.setCertificateStatus(crt, key, x509Cert, nil)
+	now = func() time.Time { return issuedAt.Add(2 * time.Hour) }
+	c.setCertificateStatus(crt, key, x509Cert, nil)
+
+	var reasons []string
+	for _, cond := range crt.Status.ConditionHistory {
+		reasons = append(reasons, cond.Reason)
+	}
+	if expected := []string{"NotFound", "Ready", "Expired"}; !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected transitions %v to be recorded, got %v", expected, reasons)
+	}
+}
+
+// signTestCertificate issues a certificate with the given common name,
+// signed by parent or self signed if parent is nil.
+func signTestCertificate(t *testing.T, commonName string, isCA bool, parent *x509.Certificate, parentKey crypto.Signer) ([]byte, *x509.Certificate, crypto.Signer) {
+	crt := gen.Certificate(commonName, gen.SetCertificateCommonName(commonName))
+	crt.Spec.IsCA = isCA
+	key, err := pki.GeneratePrivateKeyForCertificate(crt)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	template, err := pki.GenerateTemplate(nil, crt)
+	if err != nil {
+		t.Fatalf("error generating template: %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	certPEM, cert, err := pki.SignCertificate(template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("error signing certificate: %v", err)
+	}
+	return certPEM, cert, key
+}
+
+func TestUpdateSecretCAChain(t *testing.T) {
+	rootPEM, root, rootKey := signTestCertificate(t, "root", true, nil, nil)
+	intermediatePEM, intermediate, intermediateKey := signTestCertificate(t, "intermediate", true, root, rootKey)
+	leafPEM, _, leafKey := signTestCertificate(t, "example.com", false, intermediate, intermediateKey)
+	keyPEM, err := pki.EncodePrivateKey(leafKey)
+	if err != nil {
+		t.Fatalf("error encoding private key: %v", err)
+	}
+	join := func(pems ...[]byte) []byte { return bytes.Join(pems, nil) }
+
+	tests := map[string]struct {
+		// resp mimics the response of the named issuer
+		resp       issuer.IssueResponse
+		caChain    bool
+		expectedCA []byte
+	}{
+		"ca issuer without caChain writes the issuing CA": {
+			resp:       issuer.IssueResponse{Certificate: join(leafPEM, intermediatePEM, rootPEM), CA: intermediatePEM},
+			expectedCA: intermediatePEM,
+		},
+		"ca issuer with caChain writes the full chain": {
+			resp:       issuer.IssueResponse{Certificate: join(leafPEM, intermediatePEM, rootPEM), CA: intermediatePEM},
+			caChain:    true,
+			expectedCA: join(intermediatePEM, rootPEM),
+		},
+		"acme issuer without caChain writes no CA": {
+			resp: issuer.IssueResponse{Certificate: join(leafPEM, intermediatePEM)},
+		},
+		"acme issuer with caChain writes the intermediates": {
+			resp:       issuer.IssueResponse{Certificate: join(leafPEM, intermediatePEM)},
+			caChain:    true,
+			expectedCA: intermediatePEM,
+		},
+		"acme issuer with caChain and no intermediates writes no CA": {
+			resp:    issuer.IssueResponse{Certificate: leafPEM},
+			caChain: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, b := newSecretTestController(t)
+			defer b.Stop()
+			crt := gen.Certificate("test-crt", gen.SetCertificateSecretName("output"))
+			crt.Spec.CAChain = test.caChain
+
+			secret, err := c.updateSecret(crt, crt.Namespace, test.resp.Certificate, keyPEM, test.resp.CA)
+			if err != nil {
+				t.Fatalf("unexpected error updating secret: %v", err)
+			}
+			if !bytes.Equal(secret.Data[corev1.TLSCertKey], test.resp.Certificate) {
+				t.Errorf("expected %s to be left unmodified", corev1.TLSCertKey)
+			}
+			ca := secret.Data[TLSCAKey]
+			if !bytes.Equal(ca, test.expectedCA) {
+				t.Errorf("expected %s to be %q but got %q", TLSCAKey, test.expectedCA, ca)
+			}
+		})
+	}
+}
+
+func TestCertificateMatchesSpec(t *testing.T) {
+	issued := gen.Certificate("test-crt",
+		gen.SetCertificateCommonName("a.example.com"),
+		gen.SetCertificateDNSNames("a.example.com", "b.example.com"),
+		gen.SetCertificateIPAddresses("10.0.0.1", "fd00::1"),
+	)
+	certPEM, keyPEM := generateTestCertificate(t, issued)
+	cert, err := pki.DecodeX509CertificateBytes(certPEM)
+	if err != nil {
+		t.Fatalf("error decoding certificate: %v", err)
+	}
+	key, err := pki.DecodePrivateKeyBytes(keyPEM)
+	if err != nil {
+		t.Fatalf("error decoding private key: %v", err)
+	}
+
+	tests := map[string]struct {
+		crt          *v1alpha1.Certificate
+		expectedErrs []string
+	}{
+		"matching spec": {
+			crt: issued,
+		},
+		"dns name added": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateDNSNames("a.example.com", "b.example.com", "c.example.com")),
+			expectedErrs: []string{"DNS names on TLS certificate not up to date"},
+		},
+		"dns name removed": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateDNSNames("a.example.com")),
+			expectedErrs: []string{"DNS names on TLS certificate not up to date"},
+		},
+		"dns names reordered": {
+			crt: gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateDNSNames("b.example.com", "a.example.com")),
+		},
+		"ip address added": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateIPAddresses("10.0.0.1", "fd00::1", "10.0.0.2")),
+			expectedErrs: []string{"IP addresses on TLS certificate not up to date"},
+		},
+		"uri added": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateURIs("spiffe://example.org/service")),
+			expectedErrs: []string{"URIs on TLS certificate not up to date"},
+		},
+		"ip addresses in non-canonical form": {
+			crt: gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateIPAddresses("FD00:0::1", "10.0.0.1")),
+		},
+		"common name changed": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateCommonName("b.example.com")),
+			expectedErrs: []string{"Common name on TLS certificate not up to date"},
+		},
+		"key usages changed": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateIsCA(true)),
+			expectedErrs: []string{"Key usages on TLS certificate not up to date"},
+		},
+		"explicit usages matching the default key usages": {
+			crt: gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateUsages(v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageKeyEncipherment)),
+		},
+		"key usage removed": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateUsages(v1alpha1.KeyUsageDigitalSignature)),
+			expectedErrs: []string{"Key usages on TLS certificate not up to date"},
+		},
+		"extended key usage added": {
+			crt:          gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateUsages(v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageKeyEncipherment, v1alpha1.KeyUsageClientAuth)),
+			expectedErrs: []string{"Extended key usages on TLS certificate not up to date"},
+		},
+		"key usages overridden by an extension": {
+			crt: func() *v1alpha1.Certificate {
+				crt := gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateIsCA(true))
+				crt.Spec.Extensions = []v1alpha1.X509Extension{{OID: oidKeyUsage, Value: []byte{0x03, 0x02, 0x05, 0xa0}}}
+				return crt
+			}(),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{}
+			matches, errs := c.certificateMatchesSpec(test.crt, key, cert)
+			if matches != (len(test.expectedErrs) == 0) {
+				t.Errorf("expected matches to be %v but got %v: %v", len(test.expectedErrs) == 0, matches, errs)
+			}
+			if len(errs) != len(test.expectedErrs) {
+				t.Fatalf("expected errors %v but got %v", test.expectedErrs, errs)
+			}
+			for i, e := range test.expectedErrs {
+				if !strings.HasPrefix(errs[i], e) {
+					t.Errorf("expected error %q to start with %q", errs[i], e)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncReissuesOnDNSNamesChange(t *testing.T) {
+	issued := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("a.example.com"),
+		gen.SetCertificateDNSNames("a.example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	certPEM, keyPEM := generateTestCertificate(t, issued)
+	// the certificate is far from expiry, only the dns names have changed
+	crt := gen.CertificateFrom(issued.DeepCopy(), gen.SetCertificateDNSNames("a.example.com", "b.example.com"))
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		KubeObjects: []runtime.Object{&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace, SelfLink: "/secrets/output"},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       certPEM,
+				corev1.TLSPrivateKeyKey: keyPEM,
+			},
+		}},
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	// use a recorder that is not drained by the builder so the events can
+	// be inspected
+	ctx := *b.Context
+	recorder := record.NewFakeRecorder(10)
+	ctx.Recorder = recorder
+	c := New(&ctx)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	secret, err := b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting secret: %v", err)
+	}
+	cert, err := pki.DecodeX509CertificateBytes(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("error decoding certificate: %v", err)
+	}
+	if !util.EqualUnsorted(cert.DNSNames, crt.Spec.DNSNames) {
+		t.Errorf("expected certificate to be re-issued with dns names %v but got %v", crt.Spec.DNSNames, cert.DNSNames)
+	}
+
+	var reissueEvent string
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, reasonReissuingCertificate) {
+			reissueEvent = e
+		}
+	}
+	if !strings.Contains(reissueEvent, "DNS names on TLS certificate not up to date") {
+		t.Errorf("expected a %s event explaining the dns names changed, got %q", reasonReissuingCertificate, reissueEvent)
+	}
+}
+
+func TestSyncSetsStatusAfterIssuance(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("output"),
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIssuer(v1alpha1.ObjectReference{Name: "selfsigned"}),
+	)
+	iss := gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{Type: v1alpha1.IssuerConditionReady, Status: v1alpha1.ConditionTrue}}
+
+	b := &test.Builder{
+		CertManagerObjects: []runtime.Object{iss, crt},
+	}
+	b.Start()
+	defer b.Stop()
+	ctx := *b.Context
+	ctx.IssuerOptions.RenewBeforeExpiryDuration = time.Hour * 24 * 30
+	c := New(&ctx)
+	c.metrics = metrics.New()
+	b.Sync()
+
+	if err := c.Sync(context.Background(), crt); err != nil {
+		t.Fatalf("unexpected error syncing certificate: %v", err)
+	}
+
+	secret, err := b.Client.CoreV1().Secrets(gen.DefaultTestNamespace).Get("output", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting secret: %v", err)
+	}
+	cert, err := pki.DecodeX509CertificateBytes(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("error decoding certificate: %v", err)
+	}
+	issued, err := b.FakeCMClient().CertmanagerV1alpha1().Certificates(crt.Namespace).Get(crt.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+
+	if !issued.HasCondition(v1alpha1.CertificateCondition{Type: v1alpha1.CertificateConditionReady, Status: v1alpha1.ConditionTrue}) {
+		t.Errorf("expected the certificate to be Ready after issuance, got conditions %v", issued.Status.Conditions)
+	}
+	if issued.Status.NotAfter == nil || !issued.Status.NotAfter.Time.Equal(cert.NotAfter) {
+		t.Errorf("expected status.notAfter to be %v, got %v", cert.NotAfter, issued.Status.NotAfter)
+	}
+	expectedRenewal := c.renewalTime(cert, crt)
+	if issued.Status.RenewalTime == nil || !issued.Status.RenewalTime.Time.Equal(expectedRenewal) {
+		t.Errorf("expected status.renewalTime to be %v, got %v", expectedRenewal, issued.Status.RenewalTime)
+	}
+	if !issued.Status.RenewalTime.Time.Before(cert.NotAfter) {
+		t.Errorf("expected status.renewalTime %v to be before the certificate expires at %v", issued.Status.RenewalTime, cert.NotAfter)
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestCertificateMetrics(t *testing.T) {
+	certPEM, cert, _ := signTestCertificate(t, "example.com", false, nil, nil)
+	crt := gen.Certificate("test-crt", gen.SetCertificateSecretName("output"))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "output", Namespace: gen.DefaultTestNamespace},
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+	}
+	b := &test.Builder{KubeObjects: []runtime.Object{secret}}
+	b.Start()
+	defer b.Stop()
+	c := New(b.Context)
+	b.Sync()
+
+	labels := map[string]string{"name": crt.Name, "namespace": crt.Namespace}
+	readyLabels := map[string]string{"name": crt.Name, "namespace": crt.Namespace, "condition": string(v1alpha1.ConditionUnknown)}
+	c.metrics.UpdateCertificateExpiry(crt, c.secretLister)
+	c.metrics.UpdateCertificateReadyStatus(crt)
+	if v := testutil.ToFloat64(metrics.CertificateExpiryTimeSeconds.With(labels)); v != float64(cert.NotAfter.Unix()) {
+		t.Errorf("expected expiry metric to be %d, got %v", cert.NotAfter.Unix(), v)
+	}
+
+	// the Certificate does not exist in the lister, so processing it should
+	// behave as though it has been deleted
+	if err := c.processNextWorkItem(context.Background(), crt.Namespace+"/"+crt.Name); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metrics.CertificateExpiryTimeSeconds.Delete(labels) {
+		t.Errorf("expected expiry metric to be removed once the Certificate is deleted")
+	}
+	if metrics.CertificateReadyStatus.Delete(readyLabels) {
+		t.Errorf("expected ready status metric to be removed once the Certificate is deleted")
+	}
+}
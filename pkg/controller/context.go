@@ -19,9 +19,11 @@ package controller
 import (
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 
 	clientset "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
@@ -39,6 +41,9 @@ type Context struct {
 	CMClient clientset.Interface
 	// Recorder to record events to
 	Recorder record.EventRecorder
+	// RESTConfig is the config used to build the clients above. It can be
+	// used by controllers to build clients for types without a clientset.
+	RESTConfig *rest.Config
 
 	// KubeSharedInformerFactory can be used to obtain shared
 	// SharedIndexInformer instances for Kubernetes types
@@ -47,14 +52,26 @@ type Context struct {
 	// instances
 	SharedInformerFactory informers.SharedInformerFactory
 
+	// ResyncPeriod is the resync period of the informer factories above
+	ResyncPeriod time.Duration
+
 	// Namespace is the namespace to operate within.
 	// If unset, operates on all namespaces
 	Namespace string
 
+	// DryRun causes controllers to log and record an event for changes they
+	// would have made, instead of creating or updating resources.
+	DryRun bool
+
+	// IssuerBreaker pauses work for issuers that fail repeatedly. If nil,
+	// work is never paused.
+	IssuerBreaker *IssuerBreaker
+
 	IssuerOptions
 	ACMEOptions
 	IngressShimOptions
 	CertificateOptions
+	CertificateRequestOptions
 }
 
 func (c *Context) IssuerFactory() IssuerFactory {
@@ -79,6 +96,16 @@ type IssuerOptions struct {
 	// Once a certificate is within this duration until expiry, a new Certificate
 	// will be attempted to be issued.
 	RenewBeforeExpiryDuration time.Duration
+
+	// RenewalJitter is the maximum duration by which the renewal of a
+	// Certificate is brought forward, to avoid many certificates with the
+	// same expiry being renewed at once.
+	RenewalJitter time.Duration
+
+	// CredentialsDir is the directory that issuers with credentialsFrom set
+	// to 'file' read their credentials from. If empty, reading credentials
+	// from files is disabled.
+	CredentialsDir string
 }
 
 type ACMEOptions struct {
@@ -98,6 +125,34 @@ type ACMEOptions struct {
 	// HTTP01SolverResourceLimitsMemory defines the ACME pod's resource limits Memory size
 	HTTP01SolverResourceLimitsMemory resource.Quantity
 
+	// HTTP01SolverNodeSelector is the node selector applied to ACME HTTP01
+	// solver pods
+	HTTP01SolverNodeSelector map[string]string
+
+	// HTTP01SolverTolerations are the tolerations applied to ACME HTTP01
+	// solver pods
+	HTTP01SolverTolerations []corev1.Toleration
+
+	// HTTP01SolverImagePullSecrets are the names of the secrets used to pull
+	// the ACME HTTP01 solver image
+	HTTP01SolverImagePullSecrets []string
+
+	// HTTP01SolverRunAsNonRoot controls whether the ACME HTTP01 solver
+	// container must run as a non-root user
+	HTTP01SolverRunAsNonRoot bool
+
+	// HTTP01SolverRunAsUser is the UID the ACME HTTP01 solver container runs
+	// as. If 0, the image's default user is used.
+	HTTP01SolverRunAsUser int64
+
+	// HTTP01SolverReadOnlyRootFilesystem controls whether the ACME HTTP01
+	// solver container has a read-only root filesystem
+	HTTP01SolverReadOnlyRootFilesystem bool
+
+	// HTTP01SolverIngressClass is the ingress class set on ACME HTTP01
+	// solver ingresses when the challenge does not specify one
+	HTTP01SolverIngressClass string
+
 	// DNS01CheckAuthoritative is a flag for controlling if auth nss are used
 	// for checking propogation of an RR. This is the ideal scenario
 	DNS01CheckAuthoritative bool
@@ -105,6 +160,25 @@ type ACMEOptions struct {
 	// DNS01Nameservers is a list of nameservers to use when performing self-checks
 	// for ACME DNS01 validations.
 	DNS01Nameservers []string
+
+	// DNS01CheckTimeout is the timeout for each DNS query made while
+	// performing self-checks for ACME DNS01 validations.
+	DNS01CheckTimeout time.Duration
+
+	// DNS01CheckRetryInterval is the amount of time to wait before re-checking
+	// propagation of an ACME DNS01 validation record.
+	DNS01CheckRetryInterval time.Duration
+
+	// OrderPollInitialInterval is the amount of time to wait before first
+	// re-checking the status of an ACME order or authorization that is
+	// waiting on the ACME server. The interval doubles on each subsequent
+	// check.
+	OrderPollInitialInterval time.Duration
+
+	// OrderPollMaxInterval is the maximum amount of time to wait between
+	// checks of the status of an ACME order or authorization that is waiting
+	// on the ACME server.
+	OrderPollMaxInterval time.Duration
 }
 
 type IngressShimOptions struct {
@@ -114,10 +188,36 @@ type IngressShimOptions struct {
 	DefaultACMEIssuerChallengeType     string
 	DefaultACMEIssuerDNS01ProviderName string
 	DefaultAutoCertificateAnnotations  []string
+
+	// WatchedIngressClasses restricts ingress-shim to Ingresses with one of
+	// these ingress classes. If empty, all Ingresses are acted upon.
+	WatchedIngressClasses []string
 }
 
 type CertificateOptions struct {
 	// EnableOwnerRef controls wheter wheter the certificate is configured as an owner of
 	// secret where the effective TLS certificate is stored.
 	EnableOwnerRef bool
+
+	// SecretUpdateMinInterval is the minimum time between two writes to the
+	// same Secret that do not change the certificate or private key it
+	// contains. Writes storing a newly issued certificate are never delayed.
+	// A value of zero disables the limit.
+	SecretUpdateMinInterval time.Duration
+
+	// ClockSkewTolerance is how far a certificate's NotAfter and NotBefore
+	// times may be exceeded before it is treated as expired or not yet
+	// valid, to allow for clock drift between the controller and issuer.
+	ClockSkewTolerance time.Duration
+
+	// EnableSecretless allows Certificates to use the 'secretless' output
+	// mode. As this stores private keys in the status of Certificates, it
+	// is disabled by default.
+	EnableSecretless bool
+}
+
+type CertificateRequestOptions struct {
+	// RequireApproval causes CertificateRequests to only be signed once they
+	// have an Approved condition, set by an external approver.
+	RequireApproval bool
 }
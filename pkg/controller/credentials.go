@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// CredentialsLister returns the lister that the credentials referenced by
+// iss should be read through. This is secrets, unless the issuer reads its
+// credentials from files, in which case a lister backed by CredentialsDir is
+// returned.
+// Only credentials should be read through the returned lister. Resources
+// managed by cert-manager, such as Certificate Secrets, are always read
+// from the API server.
+func (o IssuerOptions) CredentialsLister(iss cmapi.GenericIssuer, secrets corelisters.SecretLister) (corelisters.SecretLister, error) {
+	switch src := iss.GetSpec().CredentialsFrom; src {
+	case cmapi.CredentialsSource(""), cmapi.CredentialsFromSecret:
+		return secrets, nil
+	case cmapi.CredentialsFromFile:
+		if o.CredentialsDir == "" {
+			return nil, fmt.Errorf("issuer %q reads credentials from files, but --issuer-credentials-dir is not set", iss.GetObjectMeta().Name)
+		}
+		return NewFileSecretLister(o.CredentialsDir), nil
+	default:
+		return nil, fmt.Errorf("unsupported credentials source %q", src)
+	}
+}
+
+// NewFileSecretLister returns a SecretLister that reads Secrets from files
+// beneath dir. The Secret with name 'name' in namespace 'ns' is read from the
+// directory dir/ns/name, with each file in that directory being one of the
+// Secret's keys. This is the layout of a secret volume, so Secrets mounted
+// by a CSI driver or projected volume can be made available per namespace.
+func NewFileSecretLister(dir string) corelisters.SecretLister {
+	return fileSecretLister(dir)
+}
+
+type fileSecretLister string
+
+var _ corelisters.SecretLister = fileSecretLister("")
+
+func (f fileSecretLister) List(selector labels.Selector) ([]*corev1.Secret, error) {
+	return nil, fmt.Errorf("listing secrets read from files is not supported")
+}
+
+func (f fileSecretLister) Secrets(namespace string) corelisters.SecretNamespaceLister {
+	return fileSecretNamespaceLister{dir: string(f), namespace: namespace}
+}
+
+type fileSecretNamespaceLister struct {
+	dir       string
+	namespace string
+}
+
+func (f fileSecretNamespaceLister) List(selector labels.Selector) ([]*corev1.Secret, error) {
+	return nil, fmt.Errorf("listing secrets read from files is not supported")
+}
+
+func (f fileSecretNamespaceLister) Get(name string) (*corev1.Secret, error) {
+	if !isPathSegment(f.namespace) || !isPathSegment(name) {
+		return nil, fmt.Errorf("invalid secret reference %q", f.namespace+"/"+name)
+	}
+
+	secretDir := filepath.Join(f.dir, f.namespace, name)
+	files, err := ioutil.ReadDir(secretDir)
+	if os.IsNotExist(err) {
+		return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret %q from %s: %v", f.namespace+"/"+name, secretDir, err)
+	}
+
+	data := make(map[string][]byte)
+	for _, file := range files {
+		// secret volumes store their data in hidden directories such as
+		// '..data', with each key being a symlink into them
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(secretDir, file.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading secret %q from %s: %v", f.namespace+"/"+name, secretDir, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		value, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading secret %q from %s: %v", f.namespace+"/"+name, secretDir, err)
+		}
+		data[file.Name()] = value
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: f.namespace,
+		},
+		Data: data,
+	}, nil
+}
+
+// isPathSegment returns true if s can be used as a single element of a file
+// path without referring to another directory.
+func isPathSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
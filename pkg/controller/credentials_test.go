@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+func writeCredentialFile(t *testing.T, path, data string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSecretLister(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-manager-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeCredentialFile(t, filepath.Join(dir, "team-a", "cloudflare", "api-token"), "token")
+	writeCredentialFile(t, filepath.Join(dir, "team-a", "cloudflare", "email"), "user@example.com")
+	// hidden entries and directories, such as those created by secret
+	// volumes, are not keys
+	writeCredentialFile(t, filepath.Join(dir, "team-a", "cloudflare", "..data", "api-token"), "token")
+	writeCredentialFile(t, filepath.Join(dir, "team-a", "cloudflare", "nested", "key"), "value")
+	writeCredentialFile(t, filepath.Join(dir, "team-b", "route53", "secret-access-key"), "secret")
+
+	lister := NewFileSecretLister(dir)
+
+	tests := map[string]struct {
+		namespace, name string
+		expected        *corev1.Secret
+		expectNotFound  bool
+		expectErr       bool
+	}{
+		"reads each file as a key": {
+			namespace: "team-a",
+			name:      "cloudflare",
+			expected: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cloudflare", Namespace: "team-a"},
+				Data: map[string][]byte{
+					"api-token": []byte("token"),
+					"email":     []byte("user@example.com"),
+				},
+			},
+		},
+		"does not read secrets from another namespace": {
+			namespace:      "team-a",
+			name:           "route53",
+			expectNotFound: true,
+		},
+		"rejects names that refer to another directory": {
+			namespace: "team-a",
+			name:      "../team-b",
+			expectErr: true,
+		},
+		"rejects namespaces that refer to another directory": {
+			namespace: "..",
+			name:      "team-b",
+			expectErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret, err := lister.Secrets(tt.namespace).Get(tt.name)
+			switch {
+			case tt.expectNotFound:
+				if !apierrors.IsNotFound(err) {
+					t.Fatalf("expected a not found error, got: %v", err)
+				}
+				return
+			case tt.expectErr:
+				if err == nil {
+					t.Fatalf("expected an error, got secret %v", secret)
+				}
+				return
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(secret, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, secret)
+			}
+		})
+	}
+}
+
+func TestCredentialsLister(t *testing.T) {
+	secrets := kubeinformers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Secrets().Lister()
+	issuer := func(src cmapi.CredentialsSource) cmapi.GenericIssuer {
+		return &cmapi.ClusterIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{CredentialsFrom: src},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		opts       IssuerOptions
+		src        cmapi.CredentialsSource
+		expectFile bool
+		expectErr  bool
+	}{
+		"uses secrets by default": {
+			opts: IssuerOptions{CredentialsDir: "/credentials"},
+		},
+		"uses secrets when requested": {
+			opts: IssuerOptions{CredentialsDir: "/credentials"},
+			src:  cmapi.CredentialsFromSecret,
+		},
+		"uses files when requested": {
+			opts:       IssuerOptions{CredentialsDir: "/credentials"},
+			src:        cmapi.CredentialsFromFile,
+			expectFile: true,
+		},
+		"fails if files are requested but no directory is configured": {
+			src:       cmapi.CredentialsFromFile,
+			expectErr: true,
+		},
+		"fails for unknown sources": {
+			opts:      IssuerOptions{CredentialsDir: "/credentials"},
+			src:       "vault",
+			expectErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			lister, err := tt.opts.CredentialsLister(issuer(tt.src), secrets)
+			if err != nil {
+				if !tt.expectErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if tt.expectErr {
+				t.Fatalf("expected an error")
+			}
+			_, isFile := lister.(fileSecretLister)
+			if isFile != tt.expectFile {
+				t.Errorf("expected file lister %t, got %T", tt.expectFile, lister)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+func (c *Controller) gatewaysForCertificate(crt *v1alpha1.Certificate) ([]*Gateway, error) {
+	objs, err := c.gatewayIndexer.ByIndex(cache.NamespaceIndex, crt.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []*Gateway
+	for _, obj := range objs {
+		gw, ok := obj.(*Gateway)
+		if !ok {
+			continue
+		}
+
+		if metav1.IsControlledBy(crt, gw) {
+			affected = append(affected, gw)
+		}
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	cmv1alpha1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	clientset "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions/certmanager/v1alpha1"
+	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util"
+)
+
+const (
+	ControllerName = "gateway-shim"
+)
+
+type defaults struct {
+	autoCertificateAnnotations  []string
+	issuerName, issuerKind      string
+	acmeIssuerDNS01ProviderName string
+}
+
+// Controller creates Certificate resources for the TLS listeners of Gateway
+// resources, in the same way as ingress-shim does for Ingresses.
+type Controller struct {
+	Client   kubernetes.Interface
+	CMClient clientset.Interface
+	Recorder record.EventRecorder
+
+	// To allow injection for testing.
+	syncHandler func(ctx context.Context, key string) error
+
+	gatewayIndexer      cache.Indexer
+	certificateLister   cmlisters.CertificateLister
+	issuerLister        cmlisters.IssuerLister
+	clusterIssuerLister cmlisters.ClusterIssuerLister
+
+	queue       workqueue.RateLimitingInterface
+	workerWg    sync.WaitGroup
+	syncedFuncs []cache.InformerSynced
+	informers   []cache.SharedIndexInformer
+	defaults    defaults
+
+	// dryRun causes Certificates to be logged rather than created or updated
+	dryRun bool
+}
+
+// New returns a new gateway-shim controller. It sets up the informer handler
+// functions for all the types it watches.
+func New(
+	certificatesInformer cminformers.CertificateInformer,
+	gatewayInformer cache.SharedIndexInformer,
+	issuerInformer cminformers.IssuerInformer,
+	clusterIssuerInformer cminformers.ClusterIssuerInformer,
+	client kubernetes.Interface,
+	cmClient clientset.Interface,
+	recorder record.EventRecorder,
+	defaults defaults,
+) *Controller {
+	ctrl := &Controller{Client: client, CMClient: cmClient, Recorder: recorder, defaults: defaults}
+	ctrl.syncHandler = ctrl.processNextWorkItem
+	ctrl.queue = workqueue.NewNamedRateLimitingQueue(controllerpkg.DefaultItemBasedRateLimiter(), ControllerName)
+
+	gatewayInformer.AddEventHandler(&controllerpkg.QueuingEventHandler{Queue: ctrl.queue})
+	ctrl.gatewayIndexer = gatewayInformer.GetIndexer()
+	ctrl.syncedFuncs = append(ctrl.syncedFuncs, gatewayInformer.HasSynced)
+	// the Gateway informer is not created by a shared informer factory, so
+	// it must be started by this controller
+	ctrl.informers = append(ctrl.informers, gatewayInformer)
+
+	certificatesInformer.Informer().AddEventHandler(&controllerpkg.BlockingEventHandler{WorkFunc: ctrl.certificateDeleted})
+	ctrl.certificateLister = certificatesInformer.Lister()
+	ctrl.syncedFuncs = append(ctrl.syncedFuncs, certificatesInformer.Informer().HasSynced)
+
+	ctrl.issuerLister = issuerInformer.Lister()
+	ctrl.syncedFuncs = append(ctrl.syncedFuncs, issuerInformer.Informer().HasSynced)
+
+	if clusterIssuerInformer != nil {
+		ctrl.clusterIssuerLister = clusterIssuerInformer.Lister()
+		ctrl.syncedFuncs = append(ctrl.syncedFuncs, clusterIssuerInformer.Informer().HasSynced)
+	}
+
+	return ctrl
+}
+
+// newGatewayInformer returns an informer watching Gateway resources in
+// namespace, or in all namespaces if namespace is empty.
+func newGatewayInformer(client cache.Getter, namespace string, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	lw := cache.NewListWatchFromClient(client, "gateways", namespace, fields.Everything())
+	return cache.NewSharedIndexInformer(lw, &Gateway{}, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+func (c *Controller) certificateDeleted(obj interface{}) {
+	crt, ok := obj.(*cmv1alpha1.Certificate)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("Object is not a certificate object %#v", obj))
+		return
+	}
+	gws, err := c.gatewaysForCertificate(crt)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("Error looking up gateway observing certificate: %s/%s", crt.Namespace, crt.Name))
+		return
+	}
+	for _, gw := range gws {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(gw)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		c.queue.Add(key)
+	}
+}
+
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
+	logs.V(4).Infof("Starting %s control loop", ControllerName)
+	for _, informer := range c.informers {
+		go informer.Run(stopCh)
+	}
+	// wait for all the informer caches we depend to sync
+	if !cache.WaitForCacheSync(stopCh, c.syncedFuncs...) {
+		return fmt.Errorf("error waiting for informer caches to sync")
+	}
+
+	logs.V(4).Infof("Synced all caches for %s control loop", ControllerName)
+
+	for i := 0; i < workers; i++ {
+		c.workerWg.Add(1)
+		go wait.Until(func() { c.worker(stopCh) }, time.Second, stopCh)
+	}
+	<-stopCh
+	logs.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
+	c.queue.ShutDown()
+	logs.V(4).Infof("Waiting for workers to exit...")
+	c.workerWg.Wait()
+	logs.V(4).Infof("Workers exited.")
+	return nil
+}
+
+func (c *Controller) worker(stopCh <-chan struct{}) {
+	defer c.workerWg.Done()
+	logs.V(4).Infof("Starting %q worker", ControllerName)
+	for {
+		obj, shutdown := c.queue.Get()
+		if shutdown {
+			break
+		}
+
+		var key string
+		// use an inlined function so we can use defer
+		func() {
+			defer c.queue.Done(obj)
+			var ok bool
+			if key, ok = obj.(string); !ok {
+				return
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctx = util.ContextWithStopCh(ctx, stopCh)
+			log := logs.WithValues("controller", ControllerName, "key", key)
+			log.Infof("syncing item")
+			if err := c.syncHandler(ctx, key); err != nil {
+				log.Errorf("re-queuing item due to error processing: %s", err.Error())
+				c.queue.AddRateLimited(obj)
+				return
+			}
+			log.Infof("finished processing work item")
+			c.queue.Forget(obj)
+		}()
+	}
+	logs.V(4).Infof("Exiting %q worker loop", ControllerName)
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	gw, err := c.getGateway(namespace, name)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			runtime.HandleError(fmt.Errorf("gateway '%s' in work queue no longer exists", key))
+			return nil
+		}
+
+		return err
+	}
+
+	return c.Sync(ctx, gw)
+}
+
+func (c *Controller) getGateway(namespace, name string) (*Gateway, error) {
+	obj, exists, err := c.gatewayIndexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, k8sErrors.NewNotFound(SchemeGroupVersion.WithResource("gateways").GroupResource(), name)
+	}
+	gw, ok := obj.(*Gateway)
+	if !ok {
+		return nil, fmt.Errorf("object %s/%s is not a gateway object %#v", namespace, name, obj)
+	}
+	return gw, nil
+}
+
+func init() {
+	controllerpkg.Register(ControllerName, func(ctx *controllerpkg.Context) controllerpkg.Interface {
+		gatewayClient, err := newGatewayClient(ctx.RESTConfig)
+		if err != nil {
+			return func(int, <-chan struct{}) error {
+				return fmt.Errorf("error creating gateway client: %v", err)
+			}
+		}
+		var clusterIssuerInformer cminformers.ClusterIssuerInformer
+		if ctx.Namespace == "" {
+			clusterIssuerInformer = ctx.SharedInformerFactory.Certmanager().V1alpha1().ClusterIssuers()
+		}
+		ctrl := New(
+			ctx.SharedInformerFactory.Certmanager().V1alpha1().Certificates(),
+			newGatewayInformer(gatewayClient, ctx.Namespace, ctx.ResyncPeriod),
+			ctx.SharedInformerFactory.Certmanager().V1alpha1().Issuers(),
+			clusterIssuerInformer,
+			ctx.Client,
+			ctx.CMClient,
+			ctx.Recorder,
+			defaults{ctx.DefaultAutoCertificateAnnotations, ctx.DefaultIssuerName, ctx.DefaultIssuerKind, ctx.DefaultACMEIssuerDNS01ProviderName},
+		)
+		ctrl.dryRun = ctx.DryRun
+		return ctrl.Run
+	})
+}
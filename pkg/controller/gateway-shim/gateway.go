@@ -0,0 +1,225 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+)
+
+// The types below are a minimal subset of the gateway.networking.k8s.io API,
+// containing only the fields read by the gateway-shim controller. They are
+// defined here rather than vendoring the Gateway API project.
+
+// GroupName is the API group of the Gateway resource
+const GroupName = "gateway.networking.k8s.io"
+
+// SchemeGroupVersion is the group version of the Gateway resources watched
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1beta1"}
+
+var gatewayGVK = SchemeGroupVersion.WithKind("Gateway")
+
+const (
+	// TLSModeTerminate terminates TLS at the Gateway using certificateRefs
+	TLSModeTerminate = "Terminate"
+	// TLSModePassthrough passes TLS through to the backend, so no
+	// Certificate is required
+	TLSModePassthrough = "Passthrough"
+)
+
+// Gateway represents an instance of a service-traffic handling
+// infrastructure.
+type Gateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GatewaySpec `json:"spec"`
+}
+
+// GatewayList contains a list of Gateways
+type GatewayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Gateway `json:"items"`
+}
+
+// GatewaySpec defines the desired state of a Gateway
+type GatewaySpec struct {
+	GatewayClassName string     `json:"gatewayClassName"`
+	Listeners        []Listener `json:"listeners"`
+}
+
+// Listener is a logical endpoint on which a Gateway accepts connections
+type Listener struct {
+	Name     string            `json:"name"`
+	Hostname *string           `json:"hostname,omitempty"`
+	Port     int32             `json:"port"`
+	Protocol string            `json:"protocol"`
+	TLS      *GatewayTLSConfig `json:"tls,omitempty"`
+}
+
+// GatewayTLSConfig describes the TLS configuration of a Listener
+type GatewayTLSConfig struct {
+	Mode            *string                 `json:"mode,omitempty"`
+	CertificateRefs []SecretObjectReference `json:"certificateRefs,omitempty"`
+}
+
+// SecretObjectReference identifies the Secret a Listener serves its
+// certificate from
+type SecretObjectReference struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+func (in *Gateway) DeepCopyInto(out *Gateway) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+func (in *Gateway) DeepCopy() *Gateway {
+	if in == nil {
+		return nil
+	}
+	out := new(Gateway)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Gateway) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *GatewayList) DeepCopyInto(out *GatewayList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Gateway, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func (in *GatewayList) DeepCopy() *GatewayList {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *GatewayList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
+	*out = *in
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = make([]Listener, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func (in *Listener) DeepCopyInto(out *Listener) {
+	*out = *in
+	if in.Hostname != nil {
+		in, out := &in.Hostname, &out.Hostname
+		*out = new(string)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(GatewayTLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *GatewayTLSConfig) DeepCopyInto(out *GatewayTLSConfig) {
+	*out = *in
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(string)
+		**out = **in
+	}
+	if in.CertificateRefs != nil {
+		in, out := &in.CertificateRefs, &out.CertificateRefs
+		*out = make([]SecretObjectReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func (in *SecretObjectReference) DeepCopyInto(out *SecretObjectReference) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+}
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	scheme.AddKnownTypes(SchemeGroupVersion, &Gateway{}, &GatewayList{})
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+}
+
+// newGatewayClient returns a REST client for the Gateway resources in
+// SchemeGroupVersion.
+func newGatewayClient(cfg *rest.Config) (*rest.RESTClient, error) {
+	config := *cfg
+	config.GroupVersion = &SchemeGroupVersion
+	config.APIPath = "/apis"
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(&config)
+}
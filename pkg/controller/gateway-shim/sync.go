@@ -0,0 +1,322 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+)
+
+const (
+	// issuerNameAnnotation can be used to override the issuer specified on the
+	// created Certificate resource.
+	issuerNameAnnotation = "certmanager.k8s.io/issuer"
+	// clusterIssuerNameAnnotation can be used to override the issuer specified on the
+	// created Certificate resource. The Certificate will reference the
+	// specified *ClusterIssuer* instead of normal issuer.
+	clusterIssuerNameAnnotation = "certmanager.k8s.io/cluster-issuer"
+	// acmeIssuerDNS01ProviderNameAnnotation can be used to override the default dns01 provider
+	// configured on the issuer. Certificates for Gateways are always validated
+	// using the dns01 challenge type.
+	acmeIssuerDNS01ProviderNameAnnotation = "certmanager.k8s.io/acme-dns01-provider"
+)
+
+// gatewayTLSSecret is a Secret referenced by the TLS listeners of a Gateway,
+// along with the hostnames of the listeners that reference it.
+type gatewayTLSSecret struct {
+	secretName string
+	dnsNames   []string
+}
+
+func (c *Controller) Sync(ctx context.Context, gw *Gateway) error {
+	if !shouldSync(gw, c.defaults.autoCertificateAnnotations) {
+		logs.Infof("Not syncing gateway %s/%s as it does not contain necessary annotations", gw.Namespace, gw.Name)
+		return nil
+	}
+
+	issuerName, issuerKind := c.issuerForGateway(gw)
+	if issuerName == "" {
+		c.Recorder.Eventf(gw, corev1.EventTypeWarning, "BadConfig", "Issuer name annotation is not set and a default issuer has not been configured")
+		return nil
+	}
+
+	issuer, err := c.getGenericIssuer(gw.Namespace, issuerName, issuerKind)
+	if apierrors.IsNotFound(err) {
+		c.Recorder.Eventf(gw, corev1.EventTypeWarning, "BadConfig", "%s resource %q not found", issuerKind, issuerName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if issuer.GetSpec().ACME != nil && c.dns01ProviderForGateway(gw) == "" {
+		c.Recorder.Eventf(gw, corev1.EventTypeWarning, "BadConfig", "No acme dns01 challenge provider specified. Set the %q annotation or configure a default provider", acmeIssuerDNS01ProviderNameAnnotation)
+		return nil
+	}
+
+	newCrts, updateCrts, err := c.buildCertificates(gw, issuer, issuerKind)
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		for _, crt := range newCrts {
+			logs.Infof("Dry run: would have created Certificate %s/%s for gateway %q", crt.Namespace, crt.Name, gw.Name)
+			c.Recorder.Eventf(gw, corev1.EventTypeNormal, "DryRunCreateCertificate", "Dry run: would have created Certificate %q", crt.Name)
+		}
+		for _, crt := range updateCrts {
+			logs.Infof("Dry run: would have updated Certificate %s/%s for gateway %q", crt.Namespace, crt.Name, gw.Name)
+			c.Recorder.Eventf(gw, corev1.EventTypeNormal, "DryRunUpdateCertificate", "Dry run: would have updated Certificate %q", crt.Name)
+		}
+		return nil
+	}
+
+	for _, crt := range newCrts {
+		_, err := c.CMClient.CertmanagerV1alpha1().Certificates(crt.Namespace).Create(crt)
+		if err != nil {
+			return err
+		}
+		c.Recorder.Eventf(gw, corev1.EventTypeNormal, "CreateCertificate", "Successfully created Certificate %q", crt.Name)
+	}
+
+	for _, crt := range updateCrts {
+		_, err := c.CMClient.CertmanagerV1alpha1().Certificates(crt.Namespace).Update(crt)
+		if err != nil {
+			return err
+		}
+		c.Recorder.Eventf(gw, corev1.EventTypeNormal, "UpdateCertificate", "Successfully updated Certificate %q", crt.Name)
+	}
+
+	return nil
+}
+
+func (c *Controller) buildCertificates(gw *Gateway, issuer v1alpha1.GenericIssuer, issuerKind string) (new, update []*v1alpha1.Certificate, _ error) {
+	var newCrts []*v1alpha1.Certificate
+	var updateCrts []*v1alpha1.Certificate
+	for _, secret := range tlsSecretsForGateway(gw) {
+		existingCrt, err := c.certificateLister.Certificates(gw.Namespace).Get(secret.secretName)
+		if !apierrors.IsNotFound(err) && err != nil {
+			return nil, nil, err
+		}
+
+		crt := &v1alpha1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            secret.secretName,
+				Namespace:       gw.Namespace,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(gw, gatewayGVK)},
+			},
+			Spec: v1alpha1.CertificateSpec{
+				DNSNames:   secret.dnsNames,
+				SecretName: secret.secretName,
+				IssuerRef: v1alpha1.ObjectReference{
+					Name: issuer.GetObjectMeta().Name,
+					Kind: issuerKind,
+				},
+			},
+		}
+		c.setIssuerSpecificConfig(crt, issuer, gw)
+
+		if existingCrt == nil {
+			newCrts = append(newCrts, crt)
+			continue
+		}
+
+		logs.Infof("Certificate %q for gateway %q already exists", secret.secretName, gw.Name)
+		if !certNeedsUpdate(existingCrt, crt) {
+			logs.Infof("Certificate %q for gateway %q is up to date", secret.secretName, gw.Name)
+			continue
+		}
+
+		updateCrt := existingCrt.DeepCopy()
+		updateCrt.Spec.DNSNames = crt.Spec.DNSNames
+		updateCrt.Spec.SecretName = crt.Spec.SecretName
+		updateCrt.Spec.IssuerRef = crt.Spec.IssuerRef
+		updateCrt.Spec.ACME = crt.Spec.ACME
+		updateCrts = append(updateCrts, updateCrt)
+	}
+	return newCrts, updateCrts, nil
+}
+
+// tlsSecretsForGateway returns the Secrets that the TLS listeners of gw
+// terminate TLS with, in the order they are first referenced. Listeners
+// without a hostname, listeners in Passthrough mode, and references to
+// objects other than Secrets in the Gateway's own namespace are skipped, as
+// no Certificate can be created for them.
+func tlsSecretsForGateway(gw *Gateway) []gatewayTLSSecret {
+	var secrets []gatewayTLSSecret
+	index := make(map[string]int)
+	for _, l := range gw.Spec.Listeners {
+		if l.TLS == nil || l.Hostname == nil {
+			continue
+		}
+		if l.TLS.Mode != nil && *l.TLS.Mode == TLSModePassthrough {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(*l.Hostname)), ".")
+		if host == "" {
+			continue
+		}
+		for _, ref := range l.TLS.CertificateRefs {
+			if !isLocalSecretRef(gw, ref) {
+				continue
+			}
+			i, ok := index[ref.Name]
+			if !ok {
+				i = len(secrets)
+				index[ref.Name] = i
+				secrets = append(secrets, gatewayTLSSecret{secretName: ref.Name})
+			}
+			if !containsString(secrets[i].dnsNames, host) {
+				secrets[i].dnsNames = append(secrets[i].dnsNames, host)
+			}
+		}
+	}
+	return secrets
+}
+
+// isLocalSecretRef returns true if ref refers to a Secret in the namespace of
+// gw.
+func isLocalSecretRef(gw *Gateway, ref SecretObjectReference) bool {
+	if ref.Name == "" {
+		return false
+	}
+	if ref.Group != nil && *ref.Group != "" {
+		return false
+	}
+	if ref.Kind != nil && *ref.Kind != "Secret" {
+		return false
+	}
+	if ref.Namespace != nil && *ref.Namespace != gw.Namespace {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// certNeedsUpdate returns true if the fields managed by gateway-shim on a
+// differ from those of b
+func certNeedsUpdate(a, b *v1alpha1.Certificate) bool {
+	if !reflect.DeepEqual(a.Spec.DNSNames, b.Spec.DNSNames) {
+		return true
+	}
+
+	if a.Spec.SecretName != b.Spec.SecretName {
+		return true
+	}
+
+	if a.Spec.IssuerRef.Name != b.Spec.IssuerRef.Name || a.Spec.IssuerRef.Kind != b.Spec.IssuerRef.Kind {
+		return true
+	}
+
+	return !reflect.DeepEqual(a.Spec.ACME, b.Spec.ACME)
+}
+
+// setIssuerSpecificConfig configures ACME Certificates to be validated
+// using the dns01 challenge type, as the http01 solver only supports
+// Ingress resources.
+func (c *Controller) setIssuerSpecificConfig(crt *v1alpha1.Certificate, issuer v1alpha1.GenericIssuer, gw *Gateway) {
+	if issuer.GetSpec().ACME == nil {
+		return
+	}
+	crt.Spec.ACME = &v1alpha1.ACMECertificateConfig{Config: []v1alpha1.DomainSolverConfig{
+		{
+			Domains: crt.Spec.DNSNames,
+			SolverConfig: v1alpha1.SolverConfig{
+				DNS01: &v1alpha1.DNS01SolverConfig{Provider: c.dns01ProviderForGateway(gw)},
+			},
+		},
+	}}
+}
+
+func (c *Controller) dns01ProviderForGateway(gw *Gateway) string {
+	if provider, ok := gw.Annotations[acmeIssuerDNS01ProviderNameAnnotation]; ok {
+		return provider
+	}
+	return c.defaults.acmeIssuerDNS01ProviderName
+}
+
+// shouldSync returns true if this gateway should have Certificate resources
+// created for it
+func shouldSync(gw *Gateway, autoCertificateAnnotations []string) bool {
+	annotations := gw.Annotations
+	if _, ok := annotations[issuerNameAnnotation]; ok {
+		return true
+	}
+	if _, ok := annotations[clusterIssuerNameAnnotation]; ok {
+		return true
+	}
+	for _, x := range autoCertificateAnnotations {
+		if s, ok := annotations[x]; ok {
+			if b, _ := strconv.ParseBool(s); b {
+				return true
+			}
+		}
+	}
+	if _, ok := annotations[acmeIssuerDNS01ProviderNameAnnotation]; ok {
+		return true
+	}
+	return false
+}
+
+// issuerForGateway will determine the issuer that should be specified on a
+// Certificate created for the given Gateway resource. If one is not set, the
+// default issuer given to the controller will be used.
+func (c *Controller) issuerForGateway(gw *Gateway) (name string, kind string) {
+	name = c.defaults.issuerName
+	kind = c.defaults.issuerKind
+	if issuerName, ok := gw.Annotations[issuerNameAnnotation]; ok {
+		name = issuerName
+		kind = v1alpha1.IssuerKind
+	}
+	if issuerName, ok := gw.Annotations[clusterIssuerNameAnnotation]; ok {
+		name = issuerName
+		kind = v1alpha1.ClusterIssuerKind
+	}
+	return name, kind
+}
+
+func (c *Controller) getGenericIssuer(namespace, name, kind string) (v1alpha1.GenericIssuer, error) {
+	switch kind {
+	case v1alpha1.IssuerKind:
+		return c.issuerLister.Issuers(namespace).Get(name)
+	case v1alpha1.ClusterIssuerKind:
+		if c.clusterIssuerLister == nil {
+			return nil, fmt.Errorf("cannot get ClusterIssuer for %q as gateway-shim is scoped to a single namespace", name)
+		}
+		return c.clusterIssuerLister.Get(name)
+	default:
+		return nil, fmt.Errorf(`invalid value %q for issuer kind. Must be empty, %q or %q`, kind, v1alpha1.IssuerKind, v1alpha1.ClusterIssuerKind)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	cmfake "github.com/jetstack/cert-manager/pkg/client/clientset/versioned/fake"
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestShouldSync(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		shouldSync  bool
+	}{
+		"issuer annotation":          {annotations: map[string]string{issuerNameAnnotation: ""}, shouldSync: true},
+		"cluster issuer annotation":  {annotations: map[string]string{clusterIssuerNameAnnotation: ""}, shouldSync: true},
+		"auto certificate true":      {annotations: map[string]string{"kubernetes.io/tls-acme": "true"}, shouldSync: true},
+		"auto certificate false":     {annotations: map[string]string{"kubernetes.io/tls-acme": "false"}},
+		"dns01 provider annotation":  {annotations: map[string]string{acmeIssuerDNS01ProviderNameAnnotation: ""}, shouldSync: true},
+		"no annotations":             {},
+		"unrelated annotations only": {annotations: map[string]string{"example.com/foo": "bar"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gw := buildGateway("gw", gen.DefaultTestNamespace, test.annotations)
+			if got := shouldSync(gw, []string{"kubernetes.io/tls-acme"}); got != test.shouldSync {
+				t.Errorf("expected shouldSync=%v, got %v", test.shouldSync, got)
+			}
+		})
+	}
+}
+
+func TestTLSSecretsForGateway(t *testing.T) {
+	tests := map[string]struct {
+		listeners []Listener
+		expected  []gatewayTLSSecret
+	}{
+		"single TLS listener": {
+			listeners: []Listener{
+				tlsListener("https", "example.com", SecretObjectReference{Name: "example-com-tls"}),
+			},
+			expected: []gatewayTLSSecret{{secretName: "example-com-tls", dnsNames: []string{"example.com"}}},
+		},
+		"listeners sharing a secret are combined": {
+			listeners: []Listener{
+				tlsListener("a", "a.example.com", SecretObjectReference{Name: "example-tls"}),
+				tlsListener("b", "B.example.com.", SecretObjectReference{Name: "example-tls"}),
+				tlsListener("c", "a.example.com", SecretObjectReference{Name: "example-tls"}),
+			},
+			expected: []gatewayTLSSecret{{secretName: "example-tls", dnsNames: []string{"a.example.com", "b.example.com"}}},
+		},
+		"wildcard hostname": {
+			listeners: []Listener{
+				tlsListener("wildcard", "*.example.com", SecretObjectReference{Name: "wildcard-tls"}),
+			},
+			expected: []gatewayTLSSecret{{secretName: "wildcard-tls", dnsNames: []string{"*.example.com"}}},
+		},
+		"multiple certificate refs on one listener": {
+			listeners: []Listener{
+				tlsListener("https", "example.com", SecretObjectReference{Name: "rsa-tls"}, SecretObjectReference{Name: "ecdsa-tls", Kind: strPtr("Secret"), Group: strPtr("")}),
+			},
+			expected: []gatewayTLSSecret{
+				{secretName: "rsa-tls", dnsNames: []string{"example.com"}},
+				{secretName: "ecdsa-tls", dnsNames: []string{"example.com"}},
+			},
+		},
+		"plain HTTP listener is skipped": {
+			listeners: []Listener{{Name: "http", Hostname: strPtr("example.com"), Port: 80, Protocol: "HTTP"}},
+		},
+		"listener without a hostname is skipped": {
+			listeners: []Listener{
+				tlsListener("https", "", SecretObjectReference{Name: "example-tls"}),
+				{Name: "nil-hostname", Port: 443, Protocol: "HTTPS", TLS: &GatewayTLSConfig{CertificateRefs: []SecretObjectReference{{Name: "example-tls"}}}},
+			},
+		},
+		"passthrough listener is skipped": {
+			listeners: []Listener{
+				{Name: "tls", Hostname: strPtr("example.com"), Port: 443, Protocol: "TLS", TLS: &GatewayTLSConfig{Mode: strPtr(TLSModePassthrough)}},
+			},
+		},
+		"explicit terminate mode": {
+			listeners: []Listener{
+				{Name: "tls", Hostname: strPtr("example.com"), Port: 443, Protocol: "TLS", TLS: &GatewayTLSConfig{Mode: strPtr(TLSModeTerminate), CertificateRefs: []SecretObjectReference{{Name: "example-tls"}}}},
+			},
+			expected: []gatewayTLSSecret{{secretName: "example-tls", dnsNames: []string{"example.com"}}},
+		},
+		"non-Secret and cross namespace refs are skipped": {
+			listeners: []Listener{
+				tlsListener("https", "example.com",
+					SecretObjectReference{Name: "other-kind", Kind: strPtr("ConfigMap")},
+					SecretObjectReference{Name: "other-group", Group: strPtr("example.com")},
+					SecretObjectReference{Name: "other-ns", Namespace: strPtr("other")},
+					SecretObjectReference{Name: "same-ns", Namespace: strPtr(gen.DefaultTestNamespace)},
+				),
+			},
+			expected: []gatewayTLSSecret{{secretName: "same-ns", dnsNames: []string{"example.com"}}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gw := buildGateway("gw", gen.DefaultTestNamespace, nil)
+			gw.Spec.Listeners = test.listeners
+			if got := tlsSecretsForGateway(gw); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildCertificates(t *testing.T) {
+	gw := buildGateway("gw", gen.DefaultTestNamespace, map[string]string{issuerNameAnnotation: "issuer"})
+	gw.Spec.Listeners = []Listener{
+		tlsListener("https", "example.com", SecretObjectReference{Name: "example-com-tls"}),
+	}
+	ownerRefs := []metav1.OwnerReference{*metav1.NewControllerRef(gw, gatewayGVK)}
+	expectedCrt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com-tls", Namespace: gen.DefaultTestNamespace, OwnerReferences: ownerRefs},
+		Spec: v1alpha1.CertificateSpec{
+			DNSNames:   []string{"example.com"},
+			SecretName: "example-com-tls",
+			IssuerRef:  v1alpha1.ObjectReference{Name: "issuer", Kind: v1alpha1.IssuerKind},
+		},
+	}
+	expectedACMECrt := expectedCrt.DeepCopy()
+	expectedACMECrt.Spec.ACME = &v1alpha1.ACMECertificateConfig{Config: []v1alpha1.DomainSolverConfig{
+		{
+			Domains:      []string{"example.com"},
+			SolverConfig: v1alpha1.SolverConfig{DNS01: &v1alpha1.DNS01SolverConfig{Provider: "default-provider"}},
+		},
+	}}
+	outdatedCrt := expectedCrt.DeepCopy()
+	outdatedCrt.OwnerReferences = nil
+	outdatedCrt.Spec.DNSNames = []string{"old.example.com"}
+	expectedUpdatedCrt := outdatedCrt.DeepCopy()
+	expectedUpdatedCrt.Spec.DNSNames = []string{"example.com"}
+
+	tests := map[string]struct {
+		issuer         v1alpha1.GenericIssuer
+		existing       []*v1alpha1.Certificate
+		expectedCreate []*v1alpha1.Certificate
+		expectedUpdate []*v1alpha1.Certificate
+	}{
+		"creates a Certificate for a TLS listener": {
+			issuer:         gen.Issuer("issuer"),
+			expectedCreate: []*v1alpha1.Certificate{expectedCrt},
+		},
+		"configures dns01 for ACME issuers": {
+			issuer:         buildACMEIssuer("issuer", gen.DefaultTestNamespace),
+			expectedCreate: []*v1alpha1.Certificate{expectedACMECrt},
+		},
+		"updates a Certificate whose hostnames changed": {
+			issuer:         gen.Issuer("issuer"),
+			existing:       []*v1alpha1.Certificate{outdatedCrt},
+			expectedUpdate: []*v1alpha1.Certificate{expectedUpdatedCrt},
+		},
+		"leaves an up to date Certificate alone": {
+			issuer:   gen.Issuer("issuer"),
+			existing: []*v1alpha1.Certificate{expectedCrt},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmClient := cmfake.NewSimpleClientset()
+			factory := cminformers.NewSharedInformerFactory(cmClient, 0)
+			certificatesInformer := factory.Certmanager().V1alpha1().Certificates()
+			for _, crt := range test.existing {
+				certificatesInformer.Informer().GetIndexer().Add(crt)
+			}
+			c := &Controller{
+				certificateLister: certificatesInformer.Lister(),
+				defaults:          defaults{acmeIssuerDNS01ProviderName: "default-provider"},
+			}
+
+			createCrts, updateCrts, err := c.buildCertificates(gw, test.issuer, v1alpha1.IssuerKind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(createCrts, test.expectedCreate) {
+				t.Errorf("expected created Certificates %+v, got %+v", test.expectedCreate, createCrts)
+			}
+			if !reflect.DeepEqual(updateCrts, test.expectedUpdate) {
+				t.Errorf("expected updated Certificates %+v, got %+v", test.expectedUpdate, updateCrts)
+			}
+		})
+	}
+}
+
+func TestDNS01ProviderForGateway(t *testing.T) {
+	c := &Controller{defaults: defaults{acmeIssuerDNS01ProviderName: "default-provider"}}
+	if got := c.dns01ProviderForGateway(buildGateway("gw", gen.DefaultTestNamespace, nil)); got != "default-provider" {
+		t.Errorf("expected the default provider, got %q", got)
+	}
+	gw := buildGateway("gw", gen.DefaultTestNamespace, map[string]string{acmeIssuerDNS01ProviderNameAnnotation: "annotated"})
+	if got := c.dns01ProviderForGateway(gw); got != "annotated" {
+		t.Errorf("expected the annotated provider, got %q", got)
+	}
+}
+
+func buildACMEIssuer(name, namespace string) *v1alpha1.Issuer {
+	return &v1alpha1.Issuer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.IssuerSpec{
+			IssuerConfig: v1alpha1.IssuerConfig{
+				ACME: &v1alpha1.ACMEIssuer{},
+			},
+		},
+	}
+}
+
+func buildGateway(name, namespace string, annotations map[string]string) *Gateway {
+	return &Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+	}
+}
+
+func tlsListener(name, hostname string, refs ...SecretObjectReference) Listener {
+	return Listener{
+		Name:     name,
+		Hostname: strPtr(hostname),
+		Port:     443,
+		Protocol: "HTTPS",
+		TLS:      &GatewayTLSConfig{CertificateRefs: refs},
+	}
+}
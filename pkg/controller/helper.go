@@ -19,11 +19,12 @@ package controller
 import (
 	"crypto/x509"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"time"
 
 	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Helper interface {
@@ -91,11 +92,9 @@ func (o IssuerOptions) CanUseAmbientCredentials(iss cmapi.GenericIssuer) bool {
 	return false
 }
 
-func (o IssuerOptions) CertificateNeedsRenew(cert *x509.Certificate, renewBefore *metav1.Duration) bool {
-	renewBeforeDuration := o.RenewBeforeExpiryDuration
-	if renewBefore != nil {
-		renewBeforeDuration = renewBefore.Duration
-	}
+func (o IssuerOptions) CertificateNeedsRenew(cert *x509.Certificate, crt *cmapi.Certificate) bool {
+	renewBeforeDuration := RenewBeforeDuration(cert, crt, o.RenewBeforeExpiryDuration)
+	renewBeforeDuration += RenewalJitter(cert, crt, renewBeforeDuration, o.RenewalJitter)
 
 	// calculate the amount of time until expiry
 	durationUntilExpiry := cert.NotAfter.Sub(time.Now())
@@ -108,3 +107,40 @@ func (o IssuerOptions) CertificateNeedsRenew(cert *x509.Certificate, renewBefore
 	}
 	return false
 }
+
+// RenewBeforeDuration returns how long before cert expires that renewal of
+// crt should begin. If the Certificate sets renewBeforePercentage, this is
+// that percentage of the lifetime of cert. Otherwise the Certificate's
+// renewBefore is used, falling back to defaultRenewBefore.
+func RenewBeforeDuration(cert *x509.Certificate, crt *cmapi.Certificate, defaultRenewBefore time.Duration) time.Duration {
+	if crt.Spec.RenewBeforePercentage != nil {
+		lifetime := cert.NotAfter.Sub(cert.NotBefore)
+		// divide first so that long lifetimes cannot overflow
+		return lifetime / 100 * time.Duration(*crt.Spec.RenewBeforePercentage)
+	}
+	if crt.Spec.RenewBefore != nil {
+		return crt.Spec.RenewBefore.Duration
+	}
+	return defaultRenewBefore
+}
+
+// RenewalJitter returns how much earlier than renewBefore renewal of crt
+// should begin, in order to spread out the renewal of certificates that
+// share the same expiry. The returned value is in [0, maxJitter) and is
+// derived from the namespace and name of crt, so that it stays the same
+// across reconciles. It is bounded by the time between the issuance of cert
+// and renewBefore, so that a certificate is never due for renewal as soon as
+// it is issued.
+func RenewalJitter(cert *x509.Certificate, crt *cmapi.Certificate, renewBefore, maxJitter time.Duration) time.Duration {
+	window := cert.NotAfter.Sub(cert.NotBefore) - renewBefore
+	if maxJitter < window {
+		window = maxJitter
+	}
+	if window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(crt.Namespace + "/" + crt.Name))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	return time.Duration(r.Int63n(int64(window)))
+}
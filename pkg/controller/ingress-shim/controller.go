@@ -22,11 +22,14 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	extlisters "k8s.io/client-go/listers/extensions/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -37,6 +40,7 @@ import (
 	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions/certmanager/v1alpha1"
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
 	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util"
 	extinformers "k8s.io/client-go/informers/extensions/v1beta1"
 )
@@ -50,6 +54,7 @@ type defaults struct {
 	issuerName, issuerKind      string
 	acmeIssuerChallengeType     string
 	acmeIssuerDNS01ProviderName string
+	watchedIngressClasses       []string
 }
 
 type Controller struct {
@@ -64,11 +69,17 @@ type Controller struct {
 	certificateLister   cmlisters.CertificateLister
 	issuerLister        cmlisters.IssuerLister
 	clusterIssuerLister cmlisters.ClusterIssuerLister
+	// namespaceLister is used to read per-namespace default issuers. It is
+	// nil if ingress-shim is scoped to a single namespace.
+	namespaceLister corelisters.NamespaceLister
 
 	queue       workqueue.RateLimitingInterface
 	workerWg    sync.WaitGroup
 	syncedFuncs []cache.InformerSynced
 	defaults    defaults
+
+	// dryRun causes Certificates to be logged rather than created or updated
+	dryRun bool
 }
 
 // New returns a new Certificates controller. It sets up the informer handler
@@ -78,6 +89,7 @@ func New(
 	ingressInformer extinformers.IngressInformer,
 	issuerInformer cminformers.IssuerInformer,
 	clusterIssuerInformer cminformers.ClusterIssuerInformer,
+	namespaceInformer coreinformers.NamespaceInformer,
 	client kubernetes.Interface,
 	cmClient clientset.Interface,
 	recorder record.EventRecorder,
@@ -85,7 +97,7 @@ func New(
 ) *Controller {
 	ctrl := &Controller{Client: client, CMClient: cmClient, Recorder: recorder, defaults: defaults}
 	ctrl.syncHandler = ctrl.processNextWorkItem
-	ctrl.queue = workqueue.NewNamedRateLimitingQueue(controllerpkg.DefaultItemBasedRateLimiter(), "ingresses")
+	ctrl.queue = workqueue.NewNamedRateLimitingQueue(controllerpkg.DefaultItemBasedRateLimiter(), ControllerName)
 
 	ingressInformer.Informer().AddEventHandler(&controllerpkg.QueuingEventHandler{Queue: ctrl.queue})
 	ctrl.ingressLister = ingressInformer.Lister()
@@ -103,9 +115,49 @@ func New(
 		ctrl.syncedFuncs = append(ctrl.syncedFuncs, clusterIssuerInformer.Informer().HasSynced)
 	}
 
+	if namespaceInformer != nil {
+		namespaceInformer.Informer().AddEventHandler(&controllerpkg.BlockingEventHandler{WorkFunc: ctrl.namespaceChanged})
+		ctrl.namespaceLister = namespaceInformer.Lister()
+		ctrl.syncedFuncs = append(ctrl.syncedFuncs, namespaceInformer.Informer().HasSynced)
+	}
+
 	return ctrl
 }
 
+// namespaceChanged queues all ingresses in a namespace, so that changes to
+// the namespace's default issuer are applied to them.
+func (c *Controller) namespaceChanged(obj interface{}) {
+	ns, err := namespaceName(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	ings, err := c.ingressLister.Ingresses(ns).List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("Error listing ingresses in namespace %q: %v", ns, err))
+		return
+	}
+	for _, ing := range ings {
+		key, err := keyFunc(ing)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		c.queue.Add(key)
+	}
+}
+
+func namespaceName(obj interface{}) (string, error) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return "", fmt.Errorf("Object is not a namespace object %#v", obj)
+	}
+	return ns.Name, nil
+}
+
 func (c *Controller) certificateDeleted(obj interface{}) {
 	crt, ok := obj.(*cmv1alpha1.Certificate)
 	if !ok {
@@ -128,13 +180,13 @@ func (c *Controller) certificateDeleted(obj interface{}) {
 }
 
 func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
-	glog.V(4).Infof("Starting %s control loop", ControllerName)
+	logs.V(4).Infof("Starting %s control loop", ControllerName)
 	// wait for all the informer caches we depend to sync
 	if !cache.WaitForCacheSync(stopCh, c.syncedFuncs...) {
 		return fmt.Errorf("error waiting for informer caches to sync")
 	}
 
-	glog.V(4).Infof("Synced all caches for %s control loop", ControllerName)
+	logs.V(4).Infof("Synced all caches for %s control loop", ControllerName)
 
 	for i := 0; i < workers; i++ {
 		c.workerWg.Add(1)
@@ -142,17 +194,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
 		go wait.Until(func() { c.worker(stopCh) }, time.Second, stopCh)
 	}
 	<-stopCh
-	glog.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
+	logs.V(4).Infof("Shutting down queue as workqueue signaled shutdown")
 	c.queue.ShutDown()
-	glog.V(4).Infof("Waiting for workers to exit...")
+	logs.V(4).Infof("Waiting for workers to exit...")
 	c.workerWg.Wait()
-	glog.V(4).Infof("Workers exited.")
+	logs.V(4).Infof("Workers exited.")
 	return nil
 }
 
 func (c *Controller) worker(stopCh <-chan struct{}) {
 	defer c.workerWg.Done()
-	glog.V(4).Infof("Starting %q worker", ControllerName)
+	logs.V(4).Infof("Starting %q worker", ControllerName)
 	for {
 		obj, shutdown := c.queue.Get()
 		if shutdown {
@@ -170,17 +222,18 @@ func (c *Controller) worker(stopCh <-chan struct{}) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			ctx = util.ContextWithStopCh(ctx, stopCh)
-			glog.Infof("%s controller: syncing item '%s'", ControllerName, key)
+			log := logs.WithValues("controller", ControllerName, "key", key)
+			log.Infof("syncing item")
 			if err := c.syncHandler(ctx, key); err != nil {
-				glog.Errorf("%s controller: Re-queuing item %q due to error processing: %s", ControllerName, key, err.Error())
+				log.Errorf("re-queuing item due to error processing: %s", err.Error())
 				c.queue.AddRateLimited(obj)
 				return
 			}
-			glog.Infof("%s controller: Finished processing work item %q", ControllerName, key)
+			log.Infof("finished processing work item")
 			c.queue.Forget(obj)
 		}()
 	}
-	glog.V(4).Infof("Exiting %q worker loop", ControllerName)
+	logs.V(4).Infof("Exiting %q worker loop", ControllerName)
 }
 
 func (c *Controller) processNextWorkItem(ctx context.Context, key string) error {
@@ -209,18 +262,23 @@ var keyFunc = controllerpkg.KeyFunc
 func init() {
 	controllerpkg.Register(ControllerName, func(ctx *controllerpkg.Context) controllerpkg.Interface {
 		var clusterIssuerInformer cminformers.ClusterIssuerInformer
+		var namespaceInformer coreinformers.NamespaceInformer
 		if ctx.Namespace == "" {
 			clusterIssuerInformer = ctx.SharedInformerFactory.Certmanager().V1alpha1().ClusterIssuers()
+			namespaceInformer = ctx.KubeSharedInformerFactory.Core().V1().Namespaces()
 		}
-		return New(
+		ctrl := New(
 			ctx.SharedInformerFactory.Certmanager().V1alpha1().Certificates(),
 			ctx.KubeSharedInformerFactory.Extensions().V1beta1().Ingresses(),
 			ctx.SharedInformerFactory.Certmanager().V1alpha1().Issuers(),
 			clusterIssuerInformer,
+			namespaceInformer,
 			ctx.Client,
 			ctx.CMClient,
 			ctx.Recorder,
-			defaults{ctx.DefaultAutoCertificateAnnotations, ctx.DefaultIssuerName, ctx.DefaultIssuerKind, ctx.DefaultACMEIssuerChallengeType, ctx.DefaultACMEIssuerDNS01ProviderName},
-		).Run
+			defaults{ctx.DefaultAutoCertificateAnnotations, ctx.DefaultIssuerName, ctx.DefaultIssuerKind, ctx.DefaultACMEIssuerChallengeType, ctx.DefaultACMEIssuerDNS01ProviderName, ctx.WatchedIngressClasses},
+		)
+		ctrl.dryRun = ctx.DryRun
+		return ctrl.Run
 	})
 }
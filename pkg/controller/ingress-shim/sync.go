@@ -21,8 +21,9 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +32,7 @@ import (
 	"k8s.io/ingress/core/pkg/ingress/annotations/class"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 const (
@@ -53,6 +55,16 @@ const (
 	// acmeIssuerHTTP01IngressClassAnnotation can be used to override the http01 ingressClass
 	// if the challenge type is set to http01
 	acmeIssuerHTTP01IngressClassAnnotation = "certmanager.k8s.io/acme-http01-ingress-class"
+	// renewBeforeAnnotation can be used to set the renewBefore duration of the
+	// created Certificate resource.
+	renewBeforeAnnotation = "cert-manager.io/renew-before"
+	// defaultIssuerNameAnnotation can be set on a Namespace to override the
+	// default issuer used for Ingresses in that namespace that do not
+	// specify an issuer.
+	defaultIssuerNameAnnotation = "cert-manager.io/default-issuer-name"
+	// defaultIssuerKindAnnotation sets the kind of the issuer named by
+	// defaultIssuerNameAnnotation. It defaults to Issuer.
+	defaultIssuerKindAnnotation = "cert-manager.io/default-issuer-kind"
 
 	ingressClassAnnotation = class.IngressKey
 )
@@ -60,8 +72,13 @@ const (
 var ingressGVK = extv1beta1.SchemeGroupVersion.WithKind("Ingress")
 
 func (c *Controller) Sync(ctx context.Context, ing *extv1beta1.Ingress) error {
+	if !watchesIngressClass(ing, c.defaults.watchedIngressClasses) {
+		logs.Infof("Not syncing ingress %s/%s as its ingress class is not watched", ing.Namespace, ing.Name)
+		return nil
+	}
+
 	if !shouldSync(ing, c.defaults.autoCertificateAnnotations) {
-		glog.Infof("Not syncing ingress %s/%s as it does not contain necessary annotations", ing.Namespace, ing.Name)
+		logs.Infof("Not syncing ingress %s/%s as it does not contain necessary annotations", ing.Namespace, ing.Name)
 		return nil
 	}
 
@@ -95,6 +112,24 @@ func (c *Controller) Sync(ctx context.Context, ing *extv1beta1.Ingress) error {
 		return err
 	}
 
+	for _, crt := range append(newCrts, updateCrts...) {
+		if names := http01WildcardDNSNames(crt); len(names) > 0 {
+			c.Recorder.Eventf(ing, corev1.EventTypeWarning, "BadConfig", "Certificate %q requests wildcard names %v, which cannot be validated using the http01 challenge type. Set the %q annotation to dns01 instead", crt.Name, names, acmeIssuerChallengeTypeAnnotation)
+		}
+	}
+
+	if c.dryRun {
+		for _, crt := range newCrts {
+			logs.Infof("Dry run: would have created Certificate %s/%s for ingress %q", crt.Namespace, crt.Name, ing.Name)
+			c.Recorder.Eventf(ing, corev1.EventTypeNormal, "DryRunCreateCertificate", "Dry run: would have created Certificate %q", crt.Name)
+		}
+		for _, crt := range updateCrts {
+			logs.Infof("Dry run: would have updated Certificate %s/%s for ingress %q", crt.Namespace, crt.Name, ing.Name)
+			c.Recorder.Eventf(ing, corev1.EventTypeNormal, "DryRunUpdateCertificate", "Dry run: would have updated Certificate %q", crt.Name)
+		}
+		return nil
+	}
+
 	for _, crt := range newCrts {
 		_, err := c.CMClient.CertmanagerV1alpha1().Certificates(crt.Namespace).Create(crt)
 		if err != nil {
@@ -128,6 +163,9 @@ func (c *Controller) validateIngress(ing *extv1beta1.Ingress) []error {
 		default:
 			errs = append(errs, fmt.Errorf("Invalid acme challenge type specified %q", challengeType))
 		}
+		if _, err := renewBeforeForIngress(ing); err != nil {
+			errs = append(errs, err)
+		}
 	}
 	for i, tls := range ing.Spec.TLS {
 		// validate the ingress TLS block
@@ -137,6 +175,11 @@ func (c *Controller) validateIngress(ing *extv1beta1.Ingress) []error {
 		if tls.SecretName == "" {
 			errs = append(errs, fmt.Errorf("TLS entry %d for hosts %v must specify a secretName", i, tls.Hosts))
 		}
+		for _, host := range tls.Hosts {
+			if strings.Contains(host, "*") && !isValidWildcard(host) {
+				errs = append(errs, fmt.Errorf("TLS entry %d has invalid wildcard host %q, wildcards must be of the form '*.example.com'", i, host))
+			}
+		}
 	}
 	return errs
 }
@@ -150,6 +193,7 @@ func (c *Controller) buildCertificates(ing *extv1beta1.Ingress, issuer v1alpha1.
 			return nil, nil, err
 		}
 
+		dnsNames := dnsNamesForTLS(tls)
 		crt := &v1alpha1.Certificate{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            tls.SecretName,
@@ -157,7 +201,7 @@ func (c *Controller) buildCertificates(ing *extv1beta1.Ingress, issuer v1alpha1.
 				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ing, ingressGVK)},
 			},
 			Spec: v1alpha1.CertificateSpec{
-				DNSNames:   tls.Hosts,
+				DNSNames:   dnsNames,
 				SecretName: tls.SecretName,
 				IssuerRef: v1alpha1.ObjectReference{
 					Name: issuer.GetObjectMeta().Name,
@@ -166,6 +210,11 @@ func (c *Controller) buildCertificates(ing *extv1beta1.Ingress, issuer v1alpha1.
 			},
 		}
 
+		crt.Spec.RenewBefore, err = renewBeforeForIngress(ing)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		err = c.setIssuerSpecificConfig(crt, issuer, ing, tls)
 		if err != nil {
 			return nil, nil, err
@@ -174,19 +223,22 @@ func (c *Controller) buildCertificates(ing *extv1beta1.Ingress, issuer v1alpha1.
 		// check if a Certificate for this TLS entry already exists, and if it
 		// does then skip this entry
 		if existingCrt != nil {
-			glog.Infof("Certificate %q for ingress %q already exists", tls.SecretName, ing.Name)
+			logs.Infof("Certificate %q for ingress %q already exists", tls.SecretName, ing.Name)
 
 			if !certNeedsUpdate(existingCrt, crt) {
-				glog.Infof("Certificate %q for ingress %q is up to date", tls.SecretName, ing.Name)
+				logs.Infof("Certificate %q for ingress %q is up to date", tls.SecretName, ing.Name)
 				continue
 			}
 
 			updateCrt := existingCrt.DeepCopy()
 
-			updateCrt.Spec.DNSNames = tls.Hosts
+			updateCrt.Spec.DNSNames = dnsNames
 			updateCrt.Spec.SecretName = tls.SecretName
 			updateCrt.Spec.IssuerRef.Name = issuer.GetObjectMeta().Name
 			updateCrt.Spec.IssuerRef.Kind = issuerKind
+			if crt.Spec.RenewBefore != nil {
+				updateCrt.Spec.RenewBefore = crt.Spec.RenewBefore
+			}
 			err = c.setIssuerSpecificConfig(updateCrt, issuer, ing, tls)
 			if err != nil {
 				return nil, nil, err
@@ -199,6 +251,68 @@ func (c *Controller) buildCertificates(ing *extv1beta1.Ingress, issuer v1alpha1.
 	return newCrts, updateCrts, nil
 }
 
+// dnsNamesForTLS returns the DNS names to request on the Certificate for an
+// ingress TLS entry. Hosts are lower cased and de-duplicated, and wildcard
+// hosts such as '*.example.com' are kept so that a wildcard certificate is
+// requested.
+func dnsNamesForTLS(tls extv1beta1.IngressTLS) []string {
+	var dnsNames []string
+	seen := make(map[string]bool)
+	for _, host := range tls.Hosts {
+		host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		dnsNames = append(dnsNames, host)
+	}
+	return dnsNames
+}
+
+// renewBeforeForIngress returns the renewBefore duration set by the
+// renewBeforeAnnotation on ing, or nil if it is not set.
+func renewBeforeForIngress(ing *extv1beta1.Ingress) (*metav1.Duration, error) {
+	value, ok := ing.Annotations[renewBeforeAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s annotation %q: %v", renewBeforeAnnotation, value, err)
+	}
+	if d < v1alpha1.MinimumRenewBefore {
+		return nil, fmt.Errorf("Invalid %s annotation %q: must be at least %s", renewBeforeAnnotation, value, v1alpha1.MinimumRenewBefore)
+	}
+	return &metav1.Duration{Duration: d}, nil
+}
+
+// isValidWildcard returns true if host is a wildcard of the form
+// '*.example.com', where the wildcard is the entire left-most label.
+func isValidWildcard(host string) bool {
+	return len(host) > 2 && strings.HasPrefix(host, "*.") && !strings.Contains(host[2:], "*")
+}
+
+// http01WildcardDNSNames returns the wildcard DNS names on crt that are
+// configured to be validated using the HTTP01 challenge type, which cannot
+// validate wildcards.
+func http01WildcardDNSNames(crt *v1alpha1.Certificate) []string {
+	if crt.Spec.ACME == nil {
+		return nil
+	}
+	var names []string
+	for _, cfg := range crt.Spec.ACME.Config {
+		if cfg.HTTP01 == nil {
+			continue
+		}
+		for _, d := range cfg.Domains {
+			if strings.HasPrefix(d, "*.") {
+				names = append(names, d)
+			}
+		}
+	}
+	return names
+}
+
 // certNeedsUpdate checks and returns true if two Certificates are equal
 func certNeedsUpdate(a, b *v1alpha1.Certificate) bool {
 	if a.Name != b.Name {
@@ -227,6 +341,12 @@ func certNeedsUpdate(a, b *v1alpha1.Certificate) bool {
 		return true
 	}
 
+	// renewBefore is only managed by ingress-shim if the annotation is set,
+	// so a value set directly on the Certificate is otherwise left alone
+	if b.Spec.RenewBefore != nil && (a.Spec.RenewBefore == nil || a.Spec.RenewBefore.Duration != b.Spec.RenewBefore.Duration) {
+		return true
+	}
+
 	var configA, configB []v1alpha1.DomainSolverConfig
 
 	if a.Spec.ACME != nil {
@@ -256,7 +376,7 @@ func (c *Controller) setIssuerSpecificConfig(crt *v1alpha1.Certificate, issuer v
 			challengeType = c.defaults.acmeIssuerChallengeType
 		}
 		domainCfg := v1alpha1.DomainSolverConfig{
-			Domains: tls.Hosts,
+			Domains: crt.Spec.DNSNames,
 		}
 		switch challengeType {
 		case "http01":
@@ -322,12 +442,35 @@ func shouldSync(ing *extv1beta1.Ingress, autoCertificateAnnotations []string) bo
 	return false
 }
 
+// watchesIngressClass returns true if the ingress class of ing is one of
+// watchedClasses, or if watchedClasses is empty.
+func watchesIngressClass(ing *extv1beta1.Ingress, watchedClasses []string) bool {
+	if len(watchedClasses) == 0 {
+		return true
+	}
+	ingressClass, ok := ing.Annotations[ingressClassAnnotation]
+	if !ok {
+		return false
+	}
+	for _, c := range watchedClasses {
+		if c == ingressClass {
+			return true
+		}
+	}
+	return false
+}
+
 // issuerForIngress will determine the issuer that should be specified on a
 // Certificate created for the given Ingress resource. If one is not set, the
-// default issuer given to the controller will be used.
+// default issuer of the Ingress's namespace is used, falling back to the
+// default issuer given to the controller.
 func (c *Controller) issuerForIngress(ing *extv1beta1.Ingress) (name string, kind string) {
 	name = c.defaults.issuerName
 	kind = c.defaults.issuerKind
+	if nsName, nsKind, ok := c.namespaceDefaultIssuer(ing.Namespace); ok {
+		name = nsName
+		kind = nsKind
+	}
 	annotations := ing.Annotations
 	if annotations == nil {
 		annotations = map[string]string{}
@@ -343,6 +486,30 @@ func (c *Controller) issuerForIngress(ing *extv1beta1.Ingress) (name string, kin
 	return name, kind
 }
 
+// namespaceDefaultIssuer returns the default issuer set on the given
+// namespace using defaultIssuerNameAnnotation, if there is one.
+func (c *Controller) namespaceDefaultIssuer(namespace string) (name string, kind string, ok bool) {
+	if c.namespaceLister == nil {
+		return "", "", false
+	}
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logs.Warningf("Error getting namespace %q to look up its default issuer: %v", namespace, err)
+		}
+		return "", "", false
+	}
+	name, ok = ns.Annotations[defaultIssuerNameAnnotation]
+	if !ok || name == "" {
+		return "", "", false
+	}
+	kind = ns.Annotations[defaultIssuerKindAnnotation]
+	if kind == "" {
+		kind = v1alpha1.IssuerKind
+	}
+	return name, kind, true
+}
+
 func (c *Controller) getGenericIssuer(namespace, name, kind string) (v1alpha1.GenericIssuer, error) {
 	switch kind {
 	case v1alpha1.IssuerKind:
@@ -19,10 +19,14 @@ package controller
 import (
 	"reflect"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	cmfake "github.com/jetstack/cert-manager/pkg/client/clientset/versioned/fake"
@@ -838,6 +842,59 @@ func TestBuildCertificates(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:   "return a wildcard DNS01 Certificate for an ingress with wildcard TLS hosts",
+			Issuer: acmeClusterIssuer,
+			Ingress: &extv1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ingress-name",
+					Namespace: gen.DefaultTestNamespace,
+					Annotations: map[string]string{
+						clusterIssuerNameAnnotation:           "issuer-name",
+						acmeIssuerChallengeTypeAnnotation:     "dns01",
+						acmeIssuerDNS01ProviderNameAnnotation: "fake-dns",
+					},
+				},
+				Spec: extv1beta1.IngressSpec{
+					TLS: []extv1beta1.IngressTLS{
+						{
+							Hosts:      []string{"*.Example.com", "example.com", "*.example.com"},
+							SecretName: "example-com-tls",
+						},
+					},
+				},
+			},
+			ClusterIssuerLister: []*v1alpha1.ClusterIssuer{acmeClusterIssuer},
+			ExpectedCreate: []*v1alpha1.Certificate{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "example-com-tls",
+						Namespace:       gen.DefaultTestNamespace,
+						OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(buildIngress("ingress-name", gen.DefaultTestNamespace, nil), ingressGVK)},
+					},
+					Spec: v1alpha1.CertificateSpec{
+						DNSNames:   []string{"*.example.com", "example.com"},
+						SecretName: "example-com-tls",
+						IssuerRef: v1alpha1.ObjectReference{
+							Name: "issuer-name",
+							Kind: "ClusterIssuer",
+						},
+						ACME: &v1alpha1.ACMECertificateConfig{
+							Config: []v1alpha1.DomainSolverConfig{
+								{
+									Domains: []string{"*.example.com", "example.com"},
+									SolverConfig: v1alpha1.SolverConfig{
+										DNS01: &v1alpha1.DNS01SolverConfig{
+											Provider: "fake-dns",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	testFn := func(test testT) func(t *testing.T) {
 		return func(t *testing.T) {
@@ -886,6 +943,250 @@ func TestBuildCertificates(t *testing.T) {
 	}
 }
 
+func TestDNSNamesForTLS(t *testing.T) {
+	tests := map[string]struct {
+		hosts    []string
+		expected []string
+	}{
+		"plain hosts": {
+			hosts:    []string{"example.com", "www.example.com"},
+			expected: []string{"example.com", "www.example.com"},
+		},
+		"wildcard host": {
+			hosts:    []string{"*.example.com"},
+			expected: []string{"*.example.com"},
+		},
+		"wildcard and apex hosts": {
+			hosts:    []string{"*.example.com", "example.com"},
+			expected: []string{"*.example.com", "example.com"},
+		},
+		"mixed case and duplicate wildcards": {
+			hosts:    []string{"*.EXAMPLE.com", " *.example.com", "*.example.com."},
+			expected: []string{"*.example.com"},
+		},
+		"empty hosts": {
+			hosts: []string{"", " "},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := dnsNamesForTLS(extv1beta1.IngressTLS{Hosts: test.hosts})
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateIngressWildcardHosts(t *testing.T) {
+	tests := map[string]bool{
+		"*.example.com":     true,
+		"*.sub.example.com": true,
+		"*":                 false,
+		"*.":                false,
+		"foo*.example.com":  false,
+		"*.*.example.com":   false,
+		"www.*.example.com": false,
+	}
+	for host, valid := range tests {
+		t.Run(host, func(t *testing.T) {
+			ing := &extv1beta1.Ingress{
+				Spec: extv1beta1.IngressSpec{
+					TLS: []extv1beta1.IngressTLS{{Hosts: []string{host}, SecretName: "example-com-tls"}},
+				},
+			}
+			errs := (&Controller{}).validateIngress(ing)
+			if valid && len(errs) > 0 {
+				t.Errorf("expected no errors but got %v", errs)
+			}
+			if !valid && len(errs) != 1 {
+				t.Errorf("expected one error but got %v", errs)
+			}
+		})
+	}
+}
+
+func TestHTTP01WildcardDNSNames(t *testing.T) {
+	crt := &v1alpha1.Certificate{
+		Spec: v1alpha1.CertificateSpec{
+			ACME: &v1alpha1.ACMECertificateConfig{
+				Config: []v1alpha1.DomainSolverConfig{
+					{
+						Domains:      []string{"*.example.com", "example.com"},
+						SolverConfig: v1alpha1.SolverConfig{HTTP01: &v1alpha1.HTTP01SolverConfig{}},
+					},
+					{
+						Domains:      []string{"*.example.org"},
+						SolverConfig: v1alpha1.SolverConfig{DNS01: &v1alpha1.DNS01SolverConfig{Provider: "fake-dns"}},
+					},
+				},
+			},
+		},
+	}
+	if names := http01WildcardDNSNames(crt); !reflect.DeepEqual(names, []string{"*.example.com"}) {
+		t.Errorf("expected only the http01 wildcard name to be returned but got %v", names)
+	}
+	if names := http01WildcardDNSNames(&v1alpha1.Certificate{}); len(names) != 0 {
+		t.Errorf("expected no names for a non-ACME certificate but got %v", names)
+	}
+}
+
+func TestRenewBeforeForIngress(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		expected    *metav1.Duration
+		err         bool
+	}{
+		"annotation not set": {},
+		"valid duration": {
+			annotations: map[string]string{renewBeforeAnnotation: "720h"},
+			expected:    &metav1.Duration{Duration: 720 * time.Hour},
+		},
+		"invalid duration": {
+			annotations: map[string]string{renewBeforeAnnotation: "30 days"},
+			err:         true,
+		},
+		"duration below the minimum": {
+			annotations: map[string]string{renewBeforeAnnotation: "1m"},
+			err:         true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ing := buildIngress("ingress-name", gen.DefaultTestNamespace, test.annotations)
+			actual, err := renewBeforeForIngress(ing)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %v but got %v", test.err, err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+
+			errs := (&Controller{}).validateIngress(ing)
+			if (len(errs) > 0) != test.err {
+				t.Errorf("expected validation error %v but got %v", test.err, errs)
+			}
+		})
+	}
+}
+
+func TestBuildCertificatesRenewBefore(t *testing.T) {
+	issuer := gen.Issuer("issuer-name")
+	ing := buildIngress("ingress-name", gen.DefaultTestNamespace, map[string]string{
+		issuerNameAnnotation:  "issuer-name",
+		renewBeforeAnnotation: "720h",
+	})
+	ing.Spec.TLS = []extv1beta1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com-tls"}}
+	existing := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com-tls", Namespace: gen.DefaultTestNamespace},
+		Spec: v1alpha1.CertificateSpec{
+			DNSNames:    []string{"example.com"},
+			SecretName:  "example-com-tls",
+			IssuerRef:   v1alpha1.ObjectReference{Name: "issuer-name", Kind: "Issuer"},
+			RenewBefore: &metav1.Duration{Duration: 48 * time.Hour},
+		},
+	}
+
+	for name, test := range map[string]struct {
+		annotation string
+		existing   []*v1alpha1.Certificate
+		// expected is the renewBefore on the created or updated Certificate,
+		// or nil if none should be updated
+		expected *metav1.Duration
+		created  bool
+	}{
+		"created with the annotation": {
+			annotation: "720h",
+			expected:   &metav1.Duration{Duration: 720 * time.Hour},
+			created:    true,
+		},
+		"updated when the annotation changes": {
+			annotation: "720h",
+			existing:   []*v1alpha1.Certificate{existing},
+			expected:   &metav1.Duration{Duration: 720 * time.Hour},
+		},
+		"up to date when the annotation matches": {
+			annotation: "48h",
+			existing:   []*v1alpha1.Certificate{existing},
+		},
+		"value on the Certificate kept without the annotation": {
+			existing: []*v1alpha1.Certificate{existing},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ing := ing.DeepCopy()
+			delete(ing.Annotations, renewBeforeAnnotation)
+			if test.annotation != "" {
+				ing.Annotations[renewBeforeAnnotation] = test.annotation
+			}
+			cmClient := cmfake.NewSimpleClientset()
+			factory := cminformers.NewSharedInformerFactory(cmClient, 0)
+			certificatesInformer := factory.Certmanager().V1alpha1().Certificates()
+			for _, crt := range test.existing {
+				certificatesInformer.Informer().GetIndexer().Add(crt)
+			}
+			c := &Controller{certificateLister: certificatesInformer.Lister()}
+
+			createCrts, updateCrts, err := c.buildCertificates(ing, issuer, "Issuer")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			crts := updateCrts
+			if test.created {
+				crts = createCrts
+			}
+			if test.expected == nil {
+				if len(createCrts)+len(updateCrts) > 0 {
+					t.Errorf("expected no Certificates to be created or updated, got %v %v", createCrts, updateCrts)
+				}
+				return
+			}
+			if len(crts) != 1 || !reflect.DeepEqual(crts[0].Spec.RenewBefore, test.expected) {
+				t.Errorf("expected a Certificate with renewBefore %v, got %v", test.expected, crts)
+			}
+		})
+	}
+}
+
+func TestWatchesIngressClass(t *testing.T) {
+	tests := map[string]struct {
+		annotations    map[string]string
+		watchedClasses []string
+		expected       bool
+	}{
+		"no watched classes with a class": {
+			annotations: map[string]string{ingressClassAnnotation: "nginx"},
+			expected:    true,
+		},
+		"no watched classes without a class": {
+			expected: true,
+		},
+		"class is watched": {
+			annotations:    map[string]string{ingressClassAnnotation: "nginx"},
+			watchedClasses: []string{"traefik", "nginx"},
+			expected:       true,
+		},
+		"class is not watched": {
+			annotations:    map[string]string{ingressClassAnnotation: "nginx-internal"},
+			watchedClasses: []string{"traefik", "nginx"},
+			expected:       false,
+		},
+		"no class with watched classes": {
+			annotations:    map[string]string{issuerNameAnnotation: "issuer-name"},
+			watchedClasses: []string{"nginx"},
+			expected:       false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ing := buildIngress("ingress-name", gen.DefaultTestNamespace, test.annotations)
+			if actual := watchesIngressClass(ing, test.watchedClasses); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
 func TestIssuerForIngress(t *testing.T) {
 	type testT struct {
 		Ingress      *extv1beta1.Ingress
@@ -939,6 +1240,88 @@ func TestIssuerForIngress(t *testing.T) {
 	}
 }
 
+func TestIssuerForIngressNamespaceDefault(t *testing.T) {
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "no-default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "default-issuer", Annotations: map[string]string{
+			defaultIssuerNameAnnotation: "team-issuer",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "default-cluster-issuer", Annotations: map[string]string{
+			defaultIssuerNameAnnotation: "team-cluster-issuer",
+			defaultIssuerKindAnnotation: "ClusterIssuer",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty-default", Annotations: map[string]string{
+			defaultIssuerNameAnnotation: "",
+		}}},
+	} {
+		if err := namespaces.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]struct {
+		ingress      *extv1beta1.Ingress
+		expectedName string
+		expectedKind string
+	}{
+		"uses the global default if the namespace has no default": {
+			ingress:      buildIngress("name", "no-default", map[string]string{testAcmeTLSAnnotation: "true"}),
+			expectedName: "global-issuer",
+			expectedKind: "ClusterIssuer",
+		},
+		"uses the global default if the namespace does not exist": {
+			ingress:      buildIngress("name", "missing", map[string]string{testAcmeTLSAnnotation: "true"}),
+			expectedName: "global-issuer",
+			expectedKind: "ClusterIssuer",
+		},
+		"uses the global default if the namespace default is empty": {
+			ingress:      buildIngress("name", "empty-default", map[string]string{testAcmeTLSAnnotation: "true"}),
+			expectedName: "global-issuer",
+			expectedKind: "ClusterIssuer",
+		},
+		"namespace default issuer overrides the global default": {
+			ingress:      buildIngress("name", "default-issuer", map[string]string{testAcmeTLSAnnotation: "true"}),
+			expectedName: "team-issuer",
+			expectedKind: "Issuer",
+		},
+		"namespace default issuer kind can be set": {
+			ingress:      buildIngress("name", "default-cluster-issuer", map[string]string{testAcmeTLSAnnotation: "true"}),
+			expectedName: "team-cluster-issuer",
+			expectedKind: "ClusterIssuer",
+		},
+		"ingress issuer annotation overrides the namespace default": {
+			ingress:      buildIngress("name", "default-cluster-issuer", map[string]string{issuerNameAnnotation: "ingress-issuer"}),
+			expectedName: "ingress-issuer",
+			expectedKind: "Issuer",
+		},
+		"ingress cluster issuer annotation overrides the namespace default": {
+			ingress:      buildIngress("name", "default-issuer", map[string]string{clusterIssuerNameAnnotation: "ingress-cluster-issuer"}),
+			expectedName: "ingress-cluster-issuer",
+			expectedKind: "ClusterIssuer",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{
+				defaults: defaults{
+					issuerName: "global-issuer",
+					issuerKind: "ClusterIssuer",
+				},
+				namespaceLister: corelisters.NewNamespaceLister(namespaces),
+			}
+			name, kind := c.issuerForIngress(test.ingress)
+			if name != test.expectedName {
+				t.Errorf("expected name to be %q but got %q", test.expectedName, name)
+			}
+			if kind != test.expectedKind {
+				t.Errorf("expected kind to be %q but got %q", test.expectedKind, kind)
+			}
+		})
+	}
+}
+
 func TestGetGenericIssuer(t *testing.T) {
 	var nilIssuer *v1alpha1.Issuer
 	var nilClusterIssuer *v1alpha1.ClusterIssuer
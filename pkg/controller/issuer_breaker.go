@@ -0,0 +1,216 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/clock"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	clientset "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+)
+
+const (
+	reasonIssuerTemporarilyUnavailable = "IssuerTemporarilyUnavailable"
+	reasonIssuerAvailable              = "IssuerAvailable"
+)
+
+// IssuerBreaker is a circuit breaker that pauses all work for an issuer after
+// it has failed too many times in a row, so that a misconfigured issuer does
+// not send a constant stream of requests to its backing service.
+// It is shared by all controllers that call into issuers. A nil
+// IssuerBreaker never opens.
+type IssuerBreaker struct {
+	cmClient clientset.Interface
+	clock    clock.Clock
+
+	// threshold is the number of consecutive failures within window after
+	// which the breaker for an issuer opens.
+	threshold int
+	window    time.Duration
+	// cooldown is how long the breaker stays open before work for the
+	// issuer is attempted again.
+	cooldown time.Duration
+
+	lock   sync.Mutex
+	states map[string]*breakerState
+}
+
+type breakerState struct {
+	failures     int
+	firstFailure time.Time
+	// openUntil is the end of the cooldown if the breaker has opened.
+	openUntil time.Time
+}
+
+// NewIssuerBreaker returns an IssuerBreaker that opens after threshold
+// consecutive failures of an issuer within window, and then pauses work for
+// that issuer for cooldown. The TemporarilyUnavailable condition of the
+// issuer is updated using cmClient. If threshold is zero, nil is returned
+// and the breaker is disabled.
+func NewIssuerBreaker(cmClient clientset.Interface, threshold int, window, cooldown time.Duration) *IssuerBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &IssuerBreaker{
+		cmClient:  cmClient,
+		clock:     clock.RealClock{},
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		states:    make(map[string]*breakerState),
+	}
+}
+
+// Allow returns true if work for the given issuer may proceed. Otherwise,
+// the breaker is open and the remaining cooldown is returned.
+// Once the cooldown has passed, work is allowed again, but a single failure
+// opens the breaker for another cooldown.
+func (b *IssuerBreaker) Allow(iss v1alpha1.GenericIssuer) (bool, time.Duration) {
+	if b == nil {
+		return true, 0
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	state, ok := b.states[breakerKey(iss)]
+	if !ok {
+		return true, 0
+	}
+	if remaining := state.openUntil.Sub(b.clock.Now()); remaining > 0 {
+		return false, remaining
+	}
+	return true, 0
+}
+
+// Record records the result of a call to the given issuer, opening the
+// breaker if err is one failure too many, and closing it if err is nil.
+// The TemporarilyUnavailable condition of the issuer is updated when the
+// breaker opens or closes.
+// Errors caused by a single resource rather than by the issuer, such as
+// invalid data or a back-off after a failed ACME order, are ignored.
+func (b *IssuerBreaker) Record(iss v1alpha1.GenericIssuer, err error) {
+	if b == nil || errors.IsInvalidData(err) || errors.IsBackoff(err) {
+		return
+	}
+	key := breakerKey(iss)
+
+	if err == nil {
+		// the condition may also have been set before cert-manager restarted
+		wasUnavailable := iss.HasCondition(v1alpha1.IssuerCondition{
+			Type:   v1alpha1.IssuerConditionTemporarilyUnavailable,
+			Status: v1alpha1.ConditionTrue,
+		})
+		if b.close(key) || wasUnavailable {
+			logs.Infof("Closing circuit breaker for issuer %q after a successful request", key)
+			b.setCondition(iss, v1alpha1.ConditionFalse, reasonIssuerAvailable, "Issuer succeeded after a cooldown")
+		}
+		return
+	}
+
+	if failures, opened := b.fail(key); opened {
+		s := fmt.Sprintf("Pausing work for %s after %d consecutive failures, last error: %v", b.cooldown, failures, err)
+		logs.Infof("Opening circuit breaker for issuer %q: %s", key, s)
+		b.setCondition(iss, v1alpha1.ConditionTrue, reasonIssuerTemporarilyUnavailable, s)
+	}
+}
+
+// close forgets the failures of the issuer with the given key, and returns
+// true if its breaker had opened.
+func (b *IssuerBreaker) close(key string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	state, ok := b.states[key]
+	if !ok {
+		return false
+	}
+	delete(b.states, key)
+	return !state.openUntil.IsZero()
+}
+
+// fail records a failure of the issuer with the given key. It returns the
+// number of consecutive failures, and true if the breaker has just opened.
+func (b *IssuerBreaker) fail(key string) (int, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.clock.Now()
+	state, ok := b.states[key]
+	if !ok {
+		state = &breakerState{}
+		b.states[key] = state
+	}
+
+	switch {
+	case now.Before(state.openUntil):
+		// a request that started before the breaker opened
+		return state.failures, false
+	case !state.openUntil.IsZero():
+		// the breaker has opened before, so the issuer is only given one
+		// more chance once the cooldown has passed
+		state.failures = b.threshold
+	case state.failures == 0 || now.Sub(state.firstFailure) > b.window:
+		state.failures = 1
+		state.firstFailure = now
+	default:
+		state.failures++
+	}
+	if state.failures < b.threshold {
+		return state.failures, false
+	}
+
+	state.openUntil = now.Add(b.cooldown)
+	return state.failures, true
+}
+
+// setCondition sets the TemporarilyUnavailable condition on a copy of the
+// given issuer and saves it.
+func (b *IssuerBreaker) setCondition(iss v1alpha1.GenericIssuer, status v1alpha1.ConditionStatus, reason, message string) {
+	var err error
+	switch iss := iss.(type) {
+	case *v1alpha1.Issuer:
+		issCopy := iss.DeepCopy()
+		issCopy.UpdateStatusCondition(v1alpha1.IssuerConditionTemporarilyUnavailable, status, reason, message)
+		_, err = b.cmClient.CertmanagerV1alpha1().Issuers(issCopy.Namespace).Update(issCopy)
+	case *v1alpha1.ClusterIssuer:
+		issCopy := iss.DeepCopy()
+		issCopy.UpdateStatusCondition(v1alpha1.IssuerConditionTemporarilyUnavailable, status, reason, message)
+		_, err = b.cmClient.CertmanagerV1alpha1().ClusterIssuers().Update(issCopy)
+	default:
+		err = fmt.Errorf("unrecognised issuer type %T", iss)
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error updating condition of issuer %q: %v", breakerKey(iss), err))
+	}
+}
+
+// breakerKey returns the key of the given issuer. ClusterIssuers have no
+// namespace, so their keys are distinct from those of any Issuer.
+func breakerKey(iss v1alpha1.GenericIssuer) string {
+	meta := iss.GetObjectMeta()
+	if meta.Namespace == "" {
+		return meta.Name
+	}
+	return meta.Namespace + "/" + meta.Name
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclock "k8s.io/utils/clock/testing"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	cmfake "github.com/jetstack/cert-manager/pkg/client/clientset/versioned/fake"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+)
+
+func newTestIssuerBreaker(t *testing.T, objects ...*v1alpha1.Issuer) (*IssuerBreaker, *fakeclock.FakeClock, *cmfake.Clientset) {
+	cl := cmfake.NewSimpleClientset()
+	for _, iss := range objects {
+		if _, err := cl.CertmanagerV1alpha1().Issuers(iss.Namespace).Create(iss); err != nil {
+			t.Fatalf("error creating issuer: %v", err)
+		}
+	}
+	b := NewIssuerBreaker(cl, 3, time.Minute, 5*time.Minute)
+	clock := fakeclock.NewFakeClock(time.Now())
+	b.clock = clock
+	return b, clock, cl
+}
+
+func testIssuer(name string) *v1alpha1.Issuer {
+	return &v1alpha1.Issuer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+}
+
+func unavailableCondition(t *testing.T, cl *cmfake.Clientset, name string) *v1alpha1.IssuerCondition {
+	iss, err := cl.CertmanagerV1alpha1().Issuers("default").Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting issuer: %v", err)
+	}
+	for _, c := range iss.Status.Conditions {
+		if c.Type == v1alpha1.IssuerConditionTemporarilyUnavailable {
+			return &c
+		}
+	}
+	return nil
+}
+
+func TestIssuerBreakerOpens(t *testing.T) {
+	iss := testIssuer("test")
+	b, clock, cl := newTestIssuerBreaker(t, iss)
+	errFailed := fmt.Errorf("backend unavailable")
+
+	for i := 0; i < 2; i++ {
+		b.Record(iss, errFailed)
+		if ok, _ := b.Allow(iss); !ok {
+			t.Fatalf("expected the breaker to be closed after %d failures", i+1)
+		}
+		clock.Step(10 * time.Second)
+	}
+
+	b.Record(iss, errFailed)
+	ok, wait := b.Allow(iss)
+	if ok {
+		t.Fatalf("expected the breaker to be open after 3 failures")
+	}
+	if wait != 5*time.Minute {
+		t.Errorf("expected a cooldown of %s, got %s", 5*time.Minute, wait)
+	}
+	cond := unavailableCondition(t, cl, "test")
+	if cond == nil || cond.Status != v1alpha1.ConditionTrue || cond.Reason != reasonIssuerTemporarilyUnavailable {
+		t.Errorf("expected the TemporarilyUnavailable condition to be true, got %+v", cond)
+	}
+
+	// other issuers are not affected
+	if ok, _ := b.Allow(testIssuer("other")); !ok {
+		t.Errorf("expected the breaker of another issuer to be closed")
+	}
+	if ok, _ := b.Allow(&v1alpha1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test"}}); !ok {
+		t.Errorf("expected the breaker of a ClusterIssuer with the same name to be closed")
+	}
+}
+
+func TestIssuerBreakerCountsConsecutiveFailures(t *testing.T) {
+	errFailed := fmt.Errorf("backend unavailable")
+
+	tests := map[string]func(b *IssuerBreaker, clock *fakeclock.FakeClock, iss *v1alpha1.Issuer){
+		"a success resets the failures": func(b *IssuerBreaker, clock *fakeclock.FakeClock, iss *v1alpha1.Issuer) {
+			b.Record(iss, errFailed)
+			b.Record(iss, errFailed)
+			b.Record(iss, nil)
+			b.Record(iss, errFailed)
+			b.Record(iss, errFailed)
+		},
+		"failures outside of the window are not counted": func(b *IssuerBreaker, clock *fakeclock.FakeClock, iss *v1alpha1.Issuer) {
+			b.Record(iss, errFailed)
+			b.Record(iss, errFailed)
+			clock.Step(2 * time.Minute)
+			b.Record(iss, errFailed)
+			b.Record(iss, errFailed)
+		},
+	}
+
+	for name, record := range tests {
+		t.Run(name, func(t *testing.T) {
+			iss := testIssuer("test")
+			b, clock, cl := newTestIssuerBreaker(t, iss)
+			record(b, clock, iss)
+			if ok, _ := b.Allow(iss); !ok {
+				t.Errorf("expected the breaker to be closed")
+			}
+			if cond := unavailableCondition(t, cl, "test"); cond != nil {
+				t.Errorf("expected no TemporarilyUnavailable condition, got %+v", cond)
+			}
+		})
+	}
+}
+
+func TestIssuerBreakerIgnoresResourceErrors(t *testing.T) {
+	iss := testIssuer("test")
+	b, _, cl := newTestIssuerBreaker(t, iss)
+
+	for i := 0; i < 5; i++ {
+		b.Record(iss, errors.NewInvalidData("invalid certificate request"))
+		b.Record(iss, errors.NewBackoff("applying acme order back-off"))
+	}
+	if ok, _ := b.Allow(iss); !ok {
+		t.Errorf("expected the breaker to be closed")
+	}
+	if cond := unavailableCondition(t, cl, "test"); cond != nil {
+		t.Errorf("expected no TemporarilyUnavailable condition, got %+v", cond)
+	}
+}
+
+func TestIssuerBreakerCloses(t *testing.T) {
+	iss := testIssuer("test")
+	b, clock, cl := newTestIssuerBreaker(t, iss)
+	errFailed := fmt.Errorf("backend unavailable")
+
+	for i := 0; i < 3; i++ {
+		b.Record(iss, errFailed)
+	}
+	// a request started before the breaker opened does not extend it
+	clock.Step(time.Minute)
+	b.Record(iss, errFailed)
+	if _, wait := b.Allow(iss); wait != 4*time.Minute {
+		t.Errorf("expected a remaining cooldown of %s, got %s", 4*time.Minute, wait)
+	}
+
+	// once the cooldown has passed, a single failure opens the breaker again
+	clock.Step(4 * time.Minute)
+	if ok, _ := b.Allow(iss); !ok {
+		t.Fatalf("expected work to be allowed after the cooldown")
+	}
+	b.Record(iss, errFailed)
+	if ok, _ := b.Allow(iss); ok {
+		t.Fatalf("expected the breaker to open again after a failure following the cooldown")
+	}
+
+	// a success after the cooldown closes the breaker
+	clock.Step(5 * time.Minute)
+	b.Record(iss, nil)
+	if ok, _ := b.Allow(iss); !ok {
+		t.Errorf("expected the breaker to be closed after a success")
+	}
+	cond := unavailableCondition(t, cl, "test")
+	if cond == nil || cond.Status != v1alpha1.ConditionFalse || cond.Reason != reasonIssuerAvailable {
+		t.Errorf("expected the TemporarilyUnavailable condition to be false, got %+v", cond)
+	}
+
+	// and the issuer needs to fail the full number of times to open it again
+	b.Record(iss, errFailed)
+	if ok, _ := b.Allow(iss); !ok {
+		t.Errorf("expected the breaker to stay closed after a single failure")
+	}
+}
+
+func TestIssuerBreakerClearsConditionAfterRestart(t *testing.T) {
+	iss := testIssuer("test")
+	iss.Status.Conditions = []v1alpha1.IssuerCondition{{
+		Type:   v1alpha1.IssuerConditionTemporarilyUnavailable,
+		Status: v1alpha1.ConditionTrue,
+		Reason: reasonIssuerTemporarilyUnavailable,
+	}}
+	b, _, cl := newTestIssuerBreaker(t, iss)
+
+	b.Record(iss, nil)
+	cond := unavailableCondition(t, cl, "test")
+	if cond == nil || cond.Status != v1alpha1.ConditionFalse {
+		t.Errorf("expected the TemporarilyUnavailable condition to be false, got %+v", cond)
+	}
+}
+
+func TestIssuerBreakerDisabled(t *testing.T) {
+	b := NewIssuerBreaker(cmfake.NewSimpleClientset(), 0, time.Minute, time.Minute)
+	if b != nil {
+		t.Fatalf("expected no breaker to be returned for a threshold of 0")
+	}
+	iss := testIssuer("test")
+	for i := 0; i < 10; i++ {
+		b.Record(iss, fmt.Errorf("backend unavailable"))
+	}
+	if ok, _ := b.Allow(iss); !ok {
+		t.Errorf("expected a nil breaker to always allow work")
+	}
+}
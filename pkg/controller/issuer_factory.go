@@ -32,6 +32,10 @@ const (
 	IssuerVault string = "vault"
 	// IssuerSelfSigned is a self signing issuer
 	IssuerSelfSigned string = "selfsigned"
+	// IssuerVenafi uses Venafi Trust Protection Platform and Venafi Cloud
+	IssuerVenafi string = "venafi"
+	// IssuerExternal delegates signing to an external webhook
+	IssuerExternal string = "external"
 )
 
 // IssuerFactory is an interface that can be used to obtain Issuer implementations.
@@ -84,6 +88,10 @@ func NameForIssuer(i v1alpha1.GenericIssuer) (string, error) {
 		return IssuerVault, nil
 	case i.GetSpec().SelfSigned != nil:
 		return IssuerSelfSigned, nil
+	case i.GetSpec().Venafi != nil:
+		return IssuerVenafi, nil
+	case i.GetSpec().External != nil:
+		return IssuerExternal, nil
 	}
 	return "", fmt.Errorf("no issuer specified for Issuer '%s/%s'", i.GetObjectMeta().Namespace, i.GetObjectMeta().Name)
 }
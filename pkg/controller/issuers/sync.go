@@ -21,19 +21,26 @@ import (
 	"fmt"
 	"reflect"
 
-	"github.com/golang/glog"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/validation"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 )
 
 const (
-	errorInitIssuer = "ErrInitIssuer"
-	errorConfig     = "ConfigError"
+	errorInitIssuer           = "ErrInitIssuer"
+	errorConfig               = "ConfigError"
+	errorConfigurationInvalid = "ConfigurationInvalid"
 
-	messageErrorInitIssuer = "Error initializing issuer: "
+	successConfigurationValid = "ConfigurationValid"
+
+	messageErrorInitIssuer      = "Error initializing issuer: "
+	messageConfigurationInvalid = "Failed to validate issuer configuration: "
+	messageConfigurationValid   = "Issuer configuration was validated"
 )
 
 func (c *Controller) Sync(ctx context.Context, iss *v1alpha1.Issuer) (err error) {
@@ -69,11 +76,29 @@ func (c *Controller) Sync(ctx context.Context, iss *v1alpha1.Issuer) (err error)
 	err = i.Setup(ctx)
 	if err != nil {
 		s := messageErrorInitIssuer + err.Error()
-		glog.Info(s)
+		logs.Info(s)
 		c.Recorder.Event(issuerCopy, v1.EventTypeWarning, errorInitIssuer, s)
 		return err
 	}
 
+	// check the configuration of issuers that support it without attempting
+	// issuance, so that problems are reported before the first certificate
+	// is requested.
+	if v, ok := i.(issuer.Validator); ok {
+		if err := v.Validate(ctx); err != nil {
+			s := messageConfigurationInvalid + err.Error()
+			reason := errorConfigurationInvalid
+			if r, ok := cmerrors.SecretErrorReason(err); ok {
+				reason = r
+			}
+			logs.Info(s)
+			c.Recorder.Event(issuerCopy, v1.EventTypeWarning, reason, s)
+			issuerCopy.UpdateStatusCondition(v1alpha1.IssuerConditionConfigurationValid, v1alpha1.ConditionFalse, reason, s)
+			return err
+		}
+		issuerCopy.UpdateStatusCondition(v1alpha1.IssuerConditionConfigurationValid, v1alpha1.ConditionTrue, successConfigurationValid, messageConfigurationValid)
+	}
+
 	return nil
 }
 
@@ -27,6 +27,7 @@ import (
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns"
 )
 
 // Acme is an issuer for an ACME server. It can be used to register and obtain
@@ -40,10 +41,19 @@ type Acme struct {
 	secretsLister corelisters.SecretLister
 	orderLister   cmlisters.OrderLister
 
+	// dnsProviders is used to check the DNS01 providers configured on the
+	// issuer when it is validated
+	dnsProviders dnsProviderChecker
+
 	// used for testing
 	clock clock.Clock
 }
 
+// dnsProviderChecker instantiates the DNS01 providers of an issuer.
+type dnsProviderChecker interface {
+	CheckProvider(issuer v1alpha1.GenericIssuer, providerName string) error
+}
+
 // New returns a new ACME issuer interface for the given issuer.
 func New(ctx *controller.Context, issuer v1alpha1.GenericIssuer) (issuer.Interface, error) {
 	if issuer.GetSpec().ACME == nil {
@@ -63,6 +73,7 @@ func New(ctx *controller.Context, issuer v1alpha1.GenericIssuer) (issuer.Interfa
 
 		secretsLister: secretsLister,
 		orderLister:   orderLister,
+		dnsProviders:  dns.NewSolver(ctx),
 		clock:         clock.RealClock{},
 	}
 
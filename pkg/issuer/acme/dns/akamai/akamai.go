@@ -28,9 +28,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
-
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	pkgutil "github.com/jetstack/cert-manager/pkg/util"
 	"github.com/pkg/errors"
 )
@@ -43,17 +42,24 @@ type DNSProvider struct {
 	serviceConsumerDomain string
 
 	auth *EdgeGridAuth
+	ttl  int
 
 	transport              http.RoundTripper
 	findHostedDomainByFqdn func(string, []string) (string, error)
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for Akamai.
-func NewDNSProvider(serviceConsumerDomain, clientToken, clientSecret, accessToken string, dns01Nameservers []string) (*DNSProvider, error) {
+// If ttl is zero, a default TTL of 60 seconds will be used for TXT records.
+func NewDNSProvider(serviceConsumerDomain, clientToken, clientSecret, accessToken string, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
+	if ttl == 0 {
+		ttl = util.DefaultTTL
+	}
+
 	return &DNSProvider{
 		dns01Nameservers,
 		serviceConsumerDomain,
 		NewEdgeGridAuth(clientToken, clientSecret, accessToken),
+		ttl,
 		http.DefaultTransport,
 		findHostedDomainByFqdn,
 	}, nil
@@ -70,12 +76,14 @@ func findHostedDomainByFqdn(fqdn string, ns []string) (string, error) {
 
 // Present creates a TXT record to fulfil the dns-01 challenge
 func (a *DNSProvider) Present(domain, fqdn, value string) error {
-	return a.setTxtRecord(fqdn, &dns01Record{value, 60})
+	return a.setTxtRecord(fqdn, &dns01Record{value, a.ttl}, false)
 }
 
-// CleanUp removes the TXT record matching the specified parameters
+// CleanUp removes the TXT record matching the specified parameters. Other
+// TXT records with the same name (e.g. for the other of a domain and its
+// wildcard) are left in place.
 func (a *DNSProvider) CleanUp(domain, fqdn, value string) error {
-	return a.setTxtRecord(fqdn, nil)
+	return a.setTxtRecord(fqdn, &dns01Record{value, a.ttl}, true)
 }
 
 type dns01Record struct {
@@ -83,7 +91,7 @@ type dns01Record struct {
 	ttl   int
 }
 
-func (a *DNSProvider) setTxtRecord(fqdn string, dns01Record *dns01Record) error {
+func (a *DNSProvider) setTxtRecord(fqdn string, dns01Record *dns01Record, remove bool) error {
 	hostedDomain, err := a.findHostedDomainByFqdn(fqdn, a.dns01Nameservers)
 	if err != nil {
 		return errors.Wrapf(err, "failed to determine hosted domain for %q", fqdn)
@@ -99,7 +107,7 @@ func (a *DNSProvider) setTxtRecord(fqdn string, dns01Record *dns01Record) error
 		return errors.Wrapf(err, "failed to create TXT record name")
 	}
 
-	if updated, err := zoneData.setTxtRecord(recordName, dns01Record); !updated || err != nil {
+	if updated, err := zoneData.setTxtRecord(recordName, dns01Record, remove); !updated || err != nil {
 		if err != nil {
 			return errors.Wrapf(err, "failed to set TXT record in %q", hostedDomain)
 		}
@@ -116,7 +124,7 @@ func (a *DNSProvider) setTxtRecord(fqdn string, dns01Record *dns01Record) error
 		return errors.Wrapf(err, "failed to save zone data for %q", hostedDomain)
 	}
 
-	glog.V(4).Infof("Updated Akamai TXT record for %q on %q using SOA serial of %d", recordName, hostedDomain, newSerial)
+	logs.V(4).Infof("Updated Akamai TXT record for %q on %q using SOA serial of %d", recordName, hostedDomain, newSerial)
 
 	return nil
 }
@@ -212,7 +220,7 @@ func (a *DNSProvider) makeRequest(req *http.Request) ([]byte, error) {
 
 type zoneData map[string]interface{}
 
-func (z zoneData) setTxtRecord(name string, dns01Record *dns01Record) (bool, error) {
+func (z zoneData) setTxtRecord(name string, dns01Record *dns01Record, remove bool) (bool, error) {
 	zone, ok := z["zone"].(map[string]interface{})
 	if !ok {
 		return false, errors.New("failed to retrieve zone from zone data")
@@ -225,12 +233,12 @@ func (z zoneData) setTxtRecord(name string, dns01Record *dns01Record) (bool, err
 		}
 	}
 
-	if dns01Record == nil {
-		if txtRecords = deleteRecord(txtRecords, name); txtRecords == nil {
+	if remove {
+		if txtRecords = deleteRecord(txtRecords, name, dns01Record.value); txtRecords == nil {
 			return false, nil
 		}
 	} else {
-		txtRecords = updateRecord(txtRecords, name, map[string]interface{}{
+		txtRecords = updateRecord(txtRecords, name, dns01Record.value, map[string]interface{}{
 			"name":   name,
 			"ttl":    dns01Record.ttl,
 			"active": true,
@@ -263,9 +271,11 @@ func (z zoneData) incSoaSerial() (uint64, error) {
 	return newSerial, nil
 }
 
-func deleteRecord(records []interface{}, name string) []interface{} {
+// deleteRecord removes the record with the given name and target, returning
+// nil if no such record exists.
+func deleteRecord(records []interface{}, name, target string) []interface{} {
 	for pos := range records {
-		if recordName, ok := records[pos].(map[string]interface{})["name"]; ok && recordName == name {
+		if recordMatches(records[pos], name, target) {
 			return append(records[:pos], records[pos+1:]...)
 		}
 	}
@@ -273,9 +283,12 @@ func deleteRecord(records []interface{}, name string) []interface{} {
 	return nil
 }
 
-func updateRecord(records []interface{}, name string, record map[string]interface{}) []interface{} {
+// updateRecord replaces the record with the given name and target, or
+// appends it if there is no such record. Records with the same name but a
+// different target are left in place.
+func updateRecord(records []interface{}, name, target string, record map[string]interface{}) []interface{} {
 	for pos := range records {
-		if records[pos].(map[string]interface{})["name"] == name {
+		if recordMatches(records[pos], name, target) {
 			records[pos] = record
 			return records
 		}
@@ -283,3 +296,8 @@ func updateRecord(records []interface{}, name string, record map[string]interfac
 
 	return append(records, record)
 }
+
+func recordMatches(record interface{}, name, target string) bool {
+	r, ok := record.(map[string]interface{})
+	return ok && r["name"] == name && r["target"] == target
+}
@@ -105,7 +105,7 @@ func (r httpResponder) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 func TestPresent(t *testing.T) {
-	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", util.RecursiveNameservers)
+	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	var response []byte
@@ -119,8 +119,24 @@ func TestPresent(t *testing.T) {
 	assert.EqualValues(t, expected, actual)
 }
 
+func TestPresentTTL(t *testing.T) {
+	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", 300, util.RecursiveNameservers)
+	assert.NoError(t, err)
+
+	var response []byte
+	mockTransport(t, akamai, "example.com", sampleZoneData, &response)
+
+	assert.NoError(t, akamai.Present("test.example.com", "_acme-challenge.test.example.com.", "dns01-key"))
+
+	var expected, actual map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(sampleZoneDataWithTxt), &expected))
+	expected["zone"].(map[string]interface{})["txt"].([]interface{})[0].(map[string]interface{})["ttl"] = 300.
+	assert.NoError(t, json.Unmarshal(response, &actual))
+	assert.EqualValues(t, expected, actual)
+}
+
 func TestCleanUp(t *testing.T) {
-	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", util.RecursiveNameservers)
+	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	var response []byte
@@ -135,6 +151,59 @@ func TestCleanUp(t *testing.T) {
 	assert.EqualValues(t, expected, actual)
 }
 
+func TestPresentOverlapping(t *testing.T) {
+	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", 0, util.RecursiveNameservers)
+	assert.NoError(t, err)
+
+	var response []byte
+	mockTransport(t, akamai, "example.com", sampleZoneDataWithTxt, &response)
+
+	// the wildcard challenge uses the same record name as the existing one
+	assert.NoError(t, akamai.Present("test.example.com", "_acme-challenge.test.example.com.", "wildcard-key"))
+
+	var actual map[string]interface{}
+	assert.NoError(t, json.Unmarshal(response, &actual))
+	assert.Equal(t, []string{"dns01-key", "wildcard-key"}, txtTargets(actual, "_acme-challenge.test"))
+}
+
+func TestCleanUpOverlapping(t *testing.T) {
+	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", 0, util.RecursiveNameservers)
+	assert.NoError(t, err)
+
+	var data map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(sampleZoneDataWithTxt), &data))
+	zone := data["zone"].(map[string]interface{})
+	zone["txt"] = append(zone["txt"].([]interface{}), map[string]interface{}{
+		"active": true,
+		"name":   "_acme-challenge.test",
+		"target": "wildcard-key",
+		"ttl":    60,
+	})
+	dataBytes, err := json.Marshal(data)
+	assert.NoError(t, err)
+
+	var response []byte
+	mockTransport(t, akamai, "example.com", string(dataBytes), &response)
+
+	assert.NoError(t, akamai.CleanUp("test.example.com", "_acme-challenge.test.example.com.", "dns01-key"))
+
+	var actual map[string]interface{}
+	assert.NoError(t, json.Unmarshal(response, &actual))
+	assert.Equal(t, []string{"wildcard-key"}, txtTargets(actual, "_acme-challenge.test"))
+}
+
+// txtTargets returns the targets of the TXT records with the given name
+func txtTargets(data map[string]interface{}, name string) []string {
+	var targets []string
+	records, _ := data["zone"].(map[string]interface{})["txt"].([]interface{})
+	for _, r := range records {
+		if rec := r.(map[string]interface{}); rec["name"] == name {
+			targets = append(targets, rec["target"].(string))
+		}
+	}
+	return targets
+}
+
 func mockTransport(t *testing.T, akamai *DNSProvider, domain, data string, response *[]byte) {
 	akamai.transport = httpResponder(func(req *http.Request) (*http.Response, error) {
 		defer req.Body.Close()
@@ -12,17 +12,17 @@ package azuredns
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
-	"github.com/golang/glog"
-
 	"github.com/Azure/azure-sdk-for-go/arm/dns"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 // DNSProvider implements the util.ChallengeProvider interface
@@ -32,6 +32,7 @@ type DNSProvider struct {
 	zoneClient        dns.ZonesClient
 	resourceGroupName string
 	zoneName          string
+	ttl               int
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for the Azure
@@ -46,12 +47,13 @@ func NewDNSProvider(dns01Nameservers []string) (*DNSProvider, error) {
 	resourceGroupName := ("AZURE_RESOURCE_GROUP")
 	zoneName := ("AZURE_ZONE_NAME")
 
-	return NewDNSProviderCredentials(clientID, clientSecret, subscriptionID, tenantID, resourceGroupName, zoneName, dns01Nameservers)
+	return NewDNSProviderCredentials(clientID, clientSecret, subscriptionID, tenantID, resourceGroupName, zoneName, 0, dns01Nameservers)
 }
 
 // NewDNSProviderCredentials returns a DNSProvider instance configured for the Azure
-// DNS service using static credentials from its parameters
-func NewDNSProviderCredentials(clientID, clientSecret, subscriptionID, tenantID, resourceGroupName, zoneName string, dns01Nameservers []string) (*DNSProvider, error) {
+// DNS service using static credentials from its parameters.
+// If ttl is zero, a default TTL of 60 seconds will be used for TXT records.
+func NewDNSProviderCredentials(clientID, clientSecret, subscriptionID, tenantID, resourceGroupName, zoneName string, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
 	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, tenantID)
 	if err != nil {
 		return nil, err
@@ -62,11 +64,30 @@ func NewDNSProviderCredentials(clientID, clientSecret, subscriptionID, tenantID,
 		return nil, err
 	}
 
+	return newDNSProvider(spt, subscriptionID, resourceGroupName, zoneName, ttl, dns01Nameservers), nil
+}
+
+// NewDNSProviderManagedIdentity returns a DNSProvider instance configured for
+// the Azure DNS service that authenticates using the managed identity of the
+// host, as exposed by the Azure Instance Metadata Service.
+// If identityClientID is empty the system-assigned identity is used,
+// otherwise the user-assigned identity with that client ID.
+// If ttl is zero, a default TTL of 60 seconds will be used for TXT records.
+func NewDNSProviderManagedIdentity(identityClientID, subscriptionID, resourceGroupName, zoneName string, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
+	token := newManagedIdentityToken(identityClientID, azure.PublicCloud.ResourceManagerEndpoint)
+	return newDNSProvider(token, subscriptionID, resourceGroupName, zoneName, ttl, dns01Nameservers), nil
+}
+
+func newDNSProvider(tokenProvider adal.OAuthTokenProvider, subscriptionID, resourceGroupName, zoneName string, ttl int, dns01Nameservers []string) *DNSProvider {
+	if ttl == 0 {
+		ttl = util.DefaultTTL
+	}
+
 	rc := dns.NewRecordSetsClient(subscriptionID)
-	rc.Authorizer = autorest.NewBearerAuthorizer(spt)
+	rc.Authorizer = autorest.NewBearerAuthorizer(tokenProvider)
 
 	zc := dns.NewZonesClient(subscriptionID)
-	zc.Authorizer = autorest.NewBearerAuthorizer(spt)
+	zc.Authorizer = autorest.NewBearerAuthorizer(tokenProvider)
 
 	return &DNSProvider{
 		dns01Nameservers:  dns01Nameservers,
@@ -74,22 +95,47 @@ func NewDNSProviderCredentials(clientID, clientSecret, subscriptionID, tenantID,
 		zoneClient:        zc,
 		resourceGroupName: resourceGroupName,
 		zoneName:          zoneName,
-	}, nil
+		ttl:               ttl,
+	}
 }
 
-// Present creates a TXT record using the specified parameters
+// Present creates a TXT record using the specified parameters. If a TXT
+// record set already exists for fqdn (e.g. when solving challenges for both
+// a domain and its wildcard), the value is added to it rather than replacing
+// it.
 func (c *DNSProvider) Present(domain, fqdn, value string) error {
-	return c.createRecord(fqdn, value, 60)
+	return c.createRecord(fqdn, value, c.ttl)
 }
 
-// CleanUp removes the TXT record matching the specified parameters
+// CleanUp removes the TXT record matching the specified parameters. Other
+// values in the same record set are left in place.
 func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	z, err := c.getHostedZoneName(fqdn)
 	if err != nil {
-		glog.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
+		logs.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
+		return err
+	}
+
+	records, err := c.getTXTRecords(z, c.trimFqdn(fqdn))
+	if err != nil {
 		return err
 	}
 
+	var remaining []dns.TxtRecord
+	for _, r := range records {
+		if !txtRecordMatches(r, value) {
+			remaining = append(remaining, r)
+		}
+	}
+	if len(remaining) == len(records) {
+		// our value is not present, so there is nothing to clean up
+		return nil
+	}
+
+	if len(remaining) > 0 {
+		return c.updateRecords(z, fqdn, remaining, c.ttl)
+	}
+
 	_, err = c.recordClient.Delete(
 		c.resourceGroupName,
 		z,
@@ -103,22 +149,35 @@ func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 }
 
 func (c *DNSProvider) createRecord(fqdn, value string, ttl int) error {
-	rparams := &dns.RecordSet{
-		RecordSetProperties: &dns.RecordSetProperties{
-			TTL: to.Int64Ptr(int64(ttl)),
-			TxtRecords: &[]dns.TxtRecord{
-				{Value: &[]string{value}},
-			},
-		},
+	z, err := c.getHostedZoneName(fqdn)
+	if err != nil {
+		logs.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
+		return err
 	}
 
-	z, err := c.getHostedZoneName(fqdn)
+	records, err := c.getTXTRecords(z, c.trimFqdn(fqdn))
 	if err != nil {
-		glog.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
 		return err
 	}
+	for _, r := range records {
+		if txtRecordMatches(r, value) {
+			return nil
+		}
+	}
+	records = append(records, dns.TxtRecord{Value: &[]string{value}})
+
+	return c.updateRecords(z, fqdn, records, ttl)
+}
+
+func (c *DNSProvider) updateRecords(z, fqdn string, records []dns.TxtRecord, ttl int) error {
+	rparams := &dns.RecordSet{
+		RecordSetProperties: &dns.RecordSetProperties{
+			TTL:        to.Int64Ptr(int64(ttl)),
+			TxtRecords: &records,
+		},
+	}
 
-	_, err = c.recordClient.CreateOrUpdate(
+	_, err := c.recordClient.CreateOrUpdate(
 		c.resourceGroupName,
 		z,
 		c.trimFqdn(fqdn),
@@ -126,12 +185,32 @@ func (c *DNSProvider) createRecord(fqdn, value string, ttl int) error {
 		*rparams, "", "")
 
 	if err != nil {
-		glog.Infof("Error creating TXT: %s, %v", c.zoneName, err)
+		logs.Infof("Error creating TXT: %s, %v", c.zoneName, err)
 		return err
 	}
 	return nil
 }
 
+// getTXTRecords returns the records in the named TXT record set, or nil if
+// the record set does not exist.
+func (c *DNSProvider) getTXTRecords(z, name string) ([]dns.TxtRecord, error) {
+	rs, err := c.recordClient.Get(c.resourceGroupName, z, name, dns.TXT)
+	if err != nil {
+		if rs.Response.Response != nil && rs.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if rs.RecordSetProperties == nil || rs.TxtRecords == nil {
+		return nil, nil
+	}
+	return *rs.TxtRecords, nil
+}
+
+func txtRecordMatches(r dns.TxtRecord, value string) bool {
+	return r.Value != nil && strings.Join(*r.Value, "") == value
+}
+
 func (c *DNSProvider) getHostedZoneName(fqdn string) (string, error) {
 	if c.zoneName != "" {
 		return c.zoneName, nil
@@ -9,10 +9,16 @@ this directory.
 package azuredns
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/arm/dns"
+	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
 	"github.com/stretchr/testify/assert"
 )
@@ -45,13 +51,104 @@ func TestLiveAzureDnsPresent(t *testing.T) {
 	if !azureLiveTest {
 		t.Skip("skipping live test")
 	}
-	provider, err := NewDNSProviderCredentials(azureClientID, azureClientSecret, azuresubscriptionID, azureTenantID, azureResourceGroupName, azureHostedZoneName, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(azureClientID, azureClientSecret, azuresubscriptionID, azureTenantID, azureResourceGroupName, azureHostedZoneName, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	err = provider.Present(azureDomain, "_acme-challenge."+azureDomain+".", "123d==")
 	assert.NoError(t, err)
 }
 
+func TestAzureDnsPresentTTL(t *testing.T) {
+	tests := map[string]struct {
+		ttl         int
+		expectedTTL string
+	}{
+		"uses the default TTL if none is configured": {
+			expectedTTL: `"TTL":60`,
+		},
+		"uses the configured TTL": {
+			ttl:         300,
+			expectedTTL: `"TTL":300`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			provider := newDNSProvider(&adal.Token{AccessToken: "a-token"}, "a-subscription", "a-resource-group", "example.com", tt.ttl, nil)
+			provider.recordClient.BaseURI = server.URL
+
+			err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+			assert.NoError(t, err)
+			assert.Contains(t, body, tt.expectedTTL)
+		})
+	}
+}
+
+func TestAzureDnsOverlappingChallenges(t *testing.T) {
+	// values holds the TXT record set stored by the fake server, which
+	// only serves the _acme-challenge record set
+	var values []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			if values == nil {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code": "NotFound"}`))
+				return
+			}
+			var records []dns.TxtRecord
+			for _, v := range values {
+				records = append(records, dns.TxtRecord{Value: &[]string{v}})
+			}
+			json.NewEncoder(w).Encode(dns.RecordSet{RecordSetProperties: &dns.RecordSetProperties{TxtRecords: &records}})
+		case http.MethodPut:
+			rs := dns.RecordSet{}
+			if err := json.NewDecoder(r.Body).Decode(&rs); err != nil {
+				t.Errorf("error decoding record set: %v", err)
+			}
+			values = []string{}
+			for _, r := range *rs.TxtRecords {
+				values = append(values, (*r.Value)...)
+			}
+			w.Write([]byte(`{}`))
+		case http.MethodDelete:
+			values = nil
+		default:
+			t.Errorf("unexpected request %s %q", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	provider := newDNSProvider(&adal.Token{AccessToken: "a-token"}, "a-subscription", "a-resource-group", "example.com", 0, nil)
+	provider.recordClient.BaseURI = server.URL
+
+	// both the apex and wildcard challenges use the same record name
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "apex"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "wildcard"))
+	assert.Equal(t, []string{"apex", "wildcard"}, values)
+
+	// presenting the same value again should not duplicate it
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "wildcard"))
+	assert.Equal(t, []string{"apex", "wildcard"}, values)
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "apex"))
+	assert.Equal(t, []string{"wildcard"}, values)
+
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "wildcard"))
+	assert.Nil(t, values)
+}
+
 func TestLiveAzureDnsCleanUp(t *testing.T) {
 	if !azureLiveTest {
 		t.Skip("skipping live test")
@@ -59,7 +156,7 @@ func TestLiveAzureDnsCleanUp(t *testing.T) {
 
 	time.Sleep(time.Second * 5)
 
-	provider, err := NewDNSProviderCredentials(azureClientID, azureClientSecret, azuresubscriptionID, azureTenantID, azureResourceGroupName, azureHostedZoneName, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(azureClientID, azureClientSecret, azuresubscriptionID, azureTenantID, azureResourceGroupName, azureHostedZoneName, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	err = provider.CleanUp(azureDomain, "_acme-challenge."+azureDomain+".", "123d==")
@@ -0,0 +1,139 @@
+// +skip_license_check
+
+/*
+This file contains portions of code directly taken from the 'xenolf/lego' project.
+A copy of the license for this code can be found in the file named LICENSE in
+this directory.
+*/
+
+package azuredns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/adal"
+)
+
+// managedIdentityEndpoint is the Azure Instance Metadata Service endpoint
+// used to obtain tokens for the managed identity of the host.
+// It is a variable so that it can be overridden in tests.
+var managedIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+const (
+	managedIdentityAPIVersion = "2018-02-01"
+
+	// managedIdentityRefreshWithin is how long before its expiry a token is
+	// refreshed.
+	managedIdentityRefreshWithin = 5 * time.Minute
+)
+
+// managedIdentityToken retrieves and caches access tokens for a managed
+// identity from the Instance Metadata Service. It implements both
+// adal.OAuthTokenProvider and adal.Refresher, so it can be used with an
+// autorest.BearerAuthorizer.
+type managedIdentityToken struct {
+	// clientID is the client ID of a user-assigned identity. If empty, the
+	// system-assigned identity is used.
+	clientID string
+	resource string
+	client   *http.Client
+
+	lock  sync.RWMutex
+	token adal.Token
+}
+
+var _ adal.OAuthTokenProvider = &managedIdentityToken{}
+var _ adal.Refresher = &managedIdentityToken{}
+
+func newManagedIdentityToken(clientID, resource string) *managedIdentityToken {
+	return &managedIdentityToken{
+		clientID: clientID,
+		resource: resource,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OAuthToken returns the current access token.
+func (m *managedIdentityToken) OAuthToken() string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.token.AccessToken
+}
+
+// EnsureFresh retrieves a new token if the current one is missing or will
+// expire soon.
+func (m *managedIdentityToken) EnsureFresh() error {
+	m.lock.RLock()
+	fresh := !m.token.IsZero() && !m.token.WillExpireIn(managedIdentityRefreshWithin)
+	m.lock.RUnlock()
+	if fresh {
+		return nil
+	}
+	return m.refresh(m.resource)
+}
+
+// Refresh unconditionally retrieves a new token.
+func (m *managedIdentityToken) Refresh() error {
+	return m.refresh(m.resource)
+}
+
+// RefreshExchange retrieves a new token for the given resource.
+func (m *managedIdentityToken) RefreshExchange(resource string) error {
+	m.lock.Lock()
+	m.resource = resource
+	m.lock.Unlock()
+	return m.refresh(resource)
+}
+
+func (m *managedIdentityToken) refresh(resource string) error {
+	u, err := url.Parse(managedIdentityEndpoint)
+	if err != nil {
+		return fmt.Errorf("invalid managed identity endpoint: %v", err)
+	}
+	q := u.Query()
+	q.Set("api-version", managedIdentityAPIVersion)
+	q.Set("resource", resource)
+	if m.clientID != "" {
+		q.Set("client_id", m.clientID)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting managed identity token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading managed identity token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error requesting managed identity token: unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token adal.Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("error decoding managed identity token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("managed identity token response did not contain an access token")
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.token = token
+	return nil
+}
@@ -0,0 +1,147 @@
+// +skip_license_check
+
+/*
+This file contains portions of code directly taken from the 'xenolf/lego' project.
+A copy of the license for this code can be found in the file named LICENSE in
+this directory.
+*/
+
+package azuredns
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIMDS starts a fake Instance Metadata Service and points the
+// managed identity endpoint at it. The returned func restores the endpoint
+// and stops the server.
+func fakeIMDS(handler func(w http.ResponseWriter, r *http.Request)) (*int, func()) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		handler(w, r)
+	}))
+	oldEndpoint := managedIdentityEndpoint
+	managedIdentityEndpoint = server.URL + "/metadata/identity/oauth2/token"
+	return &requests, func() {
+		managedIdentityEndpoint = oldEndpoint
+		server.Close()
+	}
+}
+
+func writeIMDSToken(w http.ResponseWriter, accessToken string, expiresIn time.Duration) {
+	expiresOn := time.Now().Add(expiresIn).Unix()
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"access_token":%q,"expires_in":"%d","expires_on":"%s","resource":%q,"token_type":"Bearer"}`,
+		accessToken, int(expiresIn.Seconds()), strconv.FormatInt(expiresOn, 10), azure.PublicCloud.ResourceManagerEndpoint)
+}
+
+func TestManagedIdentityTokenRequest(t *testing.T) {
+	tests := map[string]struct {
+		clientID string
+	}{
+		"system-assigned identity": {},
+		"user-assigned identity": {
+			clientID: "an-identity-client-id",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, restore := fakeIMDS(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, "/metadata/identity/oauth2/token", r.URL.Path)
+				assert.Equal(t, "true", r.Header.Get("Metadata"))
+				q := r.URL.Query()
+				assert.Equal(t, managedIdentityAPIVersion, q.Get("api-version"))
+				assert.Equal(t, azure.PublicCloud.ResourceManagerEndpoint, q.Get("resource"))
+				assert.Equal(t, tt.clientID, q.Get("client_id"))
+				if tt.clientID == "" {
+					_, ok := q["client_id"]
+					assert.False(t, ok, "client_id should not be sent for the system-assigned identity")
+				}
+				writeIMDSToken(w, "a-token", time.Hour)
+			})
+			defer restore()
+
+			token := newManagedIdentityToken(tt.clientID, azure.PublicCloud.ResourceManagerEndpoint)
+			assert.NoError(t, token.EnsureFresh())
+			assert.Equal(t, "a-token", token.OAuthToken())
+		})
+	}
+}
+
+func TestManagedIdentityTokenError(t *testing.T) {
+	_, restore := fakeIMDS(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_request","error_description":"Identity not found"}`)
+	})
+	defer restore()
+
+	token := newManagedIdentityToken("unknown", azure.PublicCloud.ResourceManagerEndpoint)
+	err := token.EnsureFresh()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Identity not found")
+	assert.Equal(t, "", token.OAuthToken())
+}
+
+func TestManagedIdentityTokenCaching(t *testing.T) {
+	tests := map[string]struct {
+		expiresIn        time.Duration
+		expectedRequests int
+	}{
+		"reuses a token that is still valid": {
+			expiresIn:        time.Hour,
+			expectedRequests: 1,
+		},
+		"refreshes a token that is about to expire": {
+			expiresIn:        time.Minute,
+			expectedRequests: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			requests, restore := fakeIMDS(func(w http.ResponseWriter, r *http.Request) {
+				writeIMDSToken(w, "a-token", tt.expiresIn)
+			})
+			defer restore()
+
+			token := newManagedIdentityToken("", azure.PublicCloud.ResourceManagerEndpoint)
+			assert.NoError(t, token.EnsureFresh())
+			assert.NoError(t, token.EnsureFresh())
+			assert.Equal(t, tt.expectedRequests, *requests)
+		})
+	}
+}
+
+func TestNewDNSProviderManagedIdentity(t *testing.T) {
+	_, restore := fakeIMDS(func(w http.ResponseWriter, r *http.Request) {
+		writeIMDSToken(w, "a-token", time.Hour)
+	})
+	defer restore()
+
+	var authHeader string
+	arm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name":"example.com"}`)
+	}))
+	defer arm.Close()
+
+	provider, err := NewDNSProviderManagedIdentity("", "a-subscription", "a-resource-group", "", 0, nil)
+	assert.NoError(t, err)
+
+	provider.zoneClient.BaseURI = arm.URL
+	_, err = provider.zoneClient.Get("a-resource-group", "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer a-token", authHeader)
+}
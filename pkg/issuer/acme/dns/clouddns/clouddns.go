@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/net/context"
@@ -24,14 +25,33 @@ import (
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
 )
 
+// defaultTokenSource returns a token source for the Application Default
+// Credentials, such as a GKE Workload Identity bound to the pod. It is a
+// variable so that it can be replaced in tests.
+var defaultTokenSource = google.DefaultTokenSource
+
 // DNSProvider is an implementation of the DNSProvider interface.
 type DNSProvider struct {
 	dns01Nameservers []string
 	project          string
 	client           *dns.Service
+	ttl              int
 }
 
-func NewDNSProvider(project string, saBytes []byte, dns01Nameservers []string, ambient bool) (*DNSProvider, error) {
+// NewDNSProvider returns a DNSProvider instance configured for Google Cloud
+// DNS using the given service account, or ambient credentials if saBytes is
+// empty and ambient is true. If ttl is zero, a default TTL of 60 seconds will
+// be used for TXT records.
+func NewDNSProvider(project string, saBytes []byte, ttl int, dns01Nameservers []string, ambient bool) (*DNSProvider, error) {
+	p, err := newDNSProvider(project, saBytes, dns01Nameservers, ambient)
+	if err != nil {
+		return nil, err
+	}
+	p.ttl = ttl
+	return p, nil
+}
+
+func newDNSProvider(project string, saBytes []byte, dns01Nameservers []string, ambient bool) (*DNSProvider, error) {
 	// project is a required field
 	if project == "" {
 		return nil, fmt.Errorf("Google Cloud project name missing")
@@ -63,18 +83,19 @@ func NewDNSProviderEnvironment(dns01Nameservers []string) (*DNSProvider, error)
 	return NewDNSProviderCredentials(project, dns01Nameservers)
 }
 
-// NewDNSProviderCredentials uses the supplied credentials to return a
-// DNSProvider instance configured for Google Cloud DNS.
+// NewDNSProviderCredentials uses the Application Default Credentials to
+// return a DNSProvider instance configured for Google Cloud DNS.
 func NewDNSProviderCredentials(project string, dns01Nameservers []string) (*DNSProvider, error) {
 	if project == "" {
 		return nil, fmt.Errorf("Google Cloud project name missing")
 	}
 
-	client, err := google.DefaultClient(context.Background(), dns.NdevClouddnsReadwriteScope)
+	ctx := context.Background()
+	ts, err := defaultTokenSource(ctx, dns.NdevClouddnsReadwriteScope)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to get Google Cloud client: %v", err)
 	}
-	svc, err := dns.New(client)
+	svc, err := dns.New(oauth2.NewClient(ctx, ts))
 	if err != nil {
 		return nil, fmt.Errorf("Unable to create Google Cloud DNS service: %v", err)
 	}
@@ -129,17 +150,32 @@ func NewDNSProviderServiceAccountBytes(project string, saBytes []byte, dns01Name
 	}, nil
 }
 
-// Present creates a TXT record to fulfil the dns-01 challenge.
+// recordTTL returns the TTL to use for TXT records.
+func (c *DNSProvider) recordTTL() int {
+	if c.ttl == 0 {
+		return util.DefaultTTL
+	}
+	return c.ttl
+}
+
+// Present creates a TXT record to fulfil the dns-01 challenge. If a TXT
+// record set already exists for fqdn (e.g. when solving challenges for both
+// a domain and its wildcard), the value is added to it rather than replacing
+// it.
 func (c *DNSProvider) Present(domain, fqdn, value string) error {
 	zone, err := c.getHostedZone(fqdn)
 	if err != nil {
 		return err
 	}
 
+	return c.addTXTValue(zone, fqdn, value)
+}
+
+func (c *DNSProvider) addTXTValue(zone, fqdn, value string) error {
 	rec := &dns.ResourceRecordSet{
 		Name:    fqdn,
 		Rrdatas: []string{value},
-		Ttl:     int64(60),
+		Ttl:     int64(c.recordTTL()),
 		Type:    "TXT",
 	}
 	change := &dns.Change{
@@ -147,54 +183,94 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 	}
 
 	// Look for existing records.
-	list, err := c.client.ResourceRecordSets.List(c.project, zone).Name(fqdn).Type("TXT").Do()
-	if err != nil {
-		return err
-	}
-	if len(list.Rrsets) > 0 {
-		// Attempt to delete the existing records when adding our new one.
-		change.Deletions = list.Rrsets
-	}
-
-	chg, err := c.client.Changes.Create(c.project, zone, change).Do()
+	records, err := c.findTxtRecords(zone, fqdn)
 	if err != nil {
 		return err
 	}
-
-	// wait for change to be acknowledged
-	for chg.Status == "pending" {
-		time.Sleep(time.Second)
-
-		chg, err = c.client.Changes.Get(c.project, zone, chg.Id).Do()
-		if err != nil {
-			return err
+	if len(records) > 0 {
+		var existing []string
+		for _, r := range records {
+			for _, d := range r.Rrdatas {
+				if rrdataMatches(d, value) {
+					return nil
+				}
+				existing = append(existing, d)
+			}
 		}
+		// Replace the existing record set with one containing both the
+		// existing values and our new one.
+		rec.Rrdatas = append(existing, value)
+		change.Deletions = records
 	}
 
-	return nil
+	return c.applyChange(zone, change)
 }
 
-// CleanUp removes the TXT record matching the specified parameters.
+// CleanUp removes the TXT record matching the specified parameters. Other
+// values in the same record set are left in place.
 func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	zone, err := c.getHostedZone(fqdn)
 	if err != nil {
 		return err
 	}
 
+	return c.removeTXTValue(zone, fqdn, value)
+}
+
+func (c *DNSProvider) removeTXTValue(zone, fqdn, value string) error {
 	records, err := c.findTxtRecords(zone, fqdn)
 	if err != nil {
 		return err
 	}
 
 	for _, rec := range records {
+		var remaining []string
+		for _, d := range rec.Rrdatas {
+			if !rrdataMatches(d, value) {
+				remaining = append(remaining, d)
+			}
+		}
+		if len(remaining) == len(rec.Rrdatas) {
+			continue
+		}
+
 		change := &dns.Change{
 			Deletions: []*dns.ResourceRecordSet{rec},
 		}
-		_, err = c.client.Changes.Create(c.project, zone, change).Do()
+		if len(remaining) > 0 {
+			change.Additions = []*dns.ResourceRecordSet{
+				{
+					Name:    rec.Name,
+					Rrdatas: remaining,
+					Ttl:     rec.Ttl,
+					Type:    rec.Type,
+				},
+			}
+		}
+		if err := c.applyChange(zone, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyChange creates the given change and waits for it to be acknowledged.
+func (c *DNSProvider) applyChange(zone string, change *dns.Change) error {
+	chg, err := c.client.Changes.Create(c.project, zone, change).Do()
+	if err != nil {
+		return err
+	}
+
+	// wait for change to be acknowledged
+	for chg.Status == "pending" {
+		time.Sleep(time.Second)
+
+		chg, err = c.client.Changes.Get(c.project, zone, chg.Id).Do()
 		if err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
@@ -222,7 +298,7 @@ func (c *DNSProvider) getHostedZone(domain string) (string, error) {
 
 func (c *DNSProvider) findTxtRecords(zone, fqdn string) ([]*dns.ResourceRecordSet, error) {
 
-	recs, err := c.client.ResourceRecordSets.List(c.project, zone).Do()
+	recs, err := c.client.ResourceRecordSets.List(c.project, zone).Name(fqdn).Type("TXT").Do()
 	if err != nil {
 		return nil, err
 	}
@@ -236,3 +312,9 @@ func (c *DNSProvider) findTxtRecords(zone, fqdn string) ([]*dns.ResourceRecordSe
 
 	return found, nil
 }
+
+// rrdataMatches returns true if the TXT record data matches value. Google
+// Cloud DNS returns TXT record data surrounded by quotes.
+func rrdataMatches(rrdata, value string) bool {
+	return strings.Trim(rrdata, `"`) == strings.Trim(value, `"`)
+}
@@ -9,11 +9,17 @@ this directory.
 package clouddns
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/dns/v1"
 
@@ -106,3 +112,139 @@ func TestLiveGoogleCloudCleanUp(t *testing.T) {
 	err = provider.CleanUp(gcloudDomain, "_acme-challenge."+gcloudDomain+".", "123d==")
 	assert.NoError(t, err)
 }
+
+// withDefaultTokenSource replaces the Application Default Credentials with
+// a static token, and records whether they were used. The returned function
+// restores the original token source.
+func withDefaultTokenSource(token string) (*bool, func()) {
+	used := false
+	orig := defaultTokenSource
+	defaultTokenSource = func(ctx context.Context, scope ...string) (oauth2.TokenSource, error) {
+		used = true
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+	}
+	return &used, func() { defaultTokenSource = orig }
+}
+
+func TestNewDNSProviderAmbientCredentials(t *testing.T) {
+	used, restore := withDefaultTokenSource("workload-identity-token")
+	defer restore()
+
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"rrsets": [{"name": "_acme-challenge.example.com.", "type": "TXT"}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewDNSProvider("my-project", nil, 0, util.RecursiveNameservers, true)
+	assert.NoError(t, err)
+	assert.True(t, *used, "expected application default credentials to be used")
+
+	provider.client.BasePath = server.URL + "/"
+	records, err := provider.findTxtRecords("my-zone", "_acme-challenge.example.com.")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "Bearer workload-identity-token", authHeader)
+}
+
+func TestNewDNSProviderAmbientCredentialsDisabled(t *testing.T) {
+	used, restore := withDefaultTokenSource("workload-identity-token")
+	defer restore()
+
+	_, err := NewDNSProvider("my-project", nil, 0, util.RecursiveNameservers, false)
+	assert.Error(t, err)
+	assert.False(t, *used, "expected application default credentials not to be used")
+}
+
+func TestNewDNSProviderServiceAccountSkipsAmbientCredentials(t *testing.T) {
+	used, restore := withDefaultTokenSource("workload-identity-token")
+	defer restore()
+
+	saBytes := []byte(`{"type": "service_account", "client_email": "cert-manager@my-project.iam.gserviceaccount.com", "private_key": "unused", "token_uri": "https://oauth2.googleapis.com/token"}`)
+	_, err := NewDNSProvider("my-project", saBytes, 0, util.RecursiveNameservers, true)
+	assert.NoError(t, err)
+	assert.False(t, *used, "expected the service account key to be used instead of application default credentials")
+}
+
+// newFakeCloudDNS returns a server implementing the parts of the Google Cloud
+// DNS API used to manage TXT records, storing record sets in rrsets.
+func newFakeCloudDNS(t *testing.T, rrsets map[string]*dns.ResourceRecordSet) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/my-project/managedZones/my-zone/rrsets":
+			resp := &dns.ResourceRecordSetsListResponse{}
+			if rec, ok := rrsets[r.URL.Query().Get("name")]; ok {
+				resp.Rrsets = append(resp.Rrsets, rec)
+			}
+			json.NewEncoder(w).Encode(resp)
+		case r.Method == http.MethodPost && r.URL.Path == "/my-project/managedZones/my-zone/changes":
+			change := &dns.Change{}
+			if err := json.NewDecoder(r.Body).Decode(change); err != nil {
+				t.Errorf("error decoding change: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			for _, rec := range change.Deletions {
+				if _, ok := rrsets[rec.Name]; !ok {
+					t.Errorf("deleted record set %q does not exist", rec.Name)
+				}
+				delete(rrsets, rec.Name)
+			}
+			for _, rec := range change.Additions {
+				if _, ok := rrsets[rec.Name]; ok {
+					t.Errorf("added record set %q already exists", rec.Name)
+				}
+				// Cloud DNS returns TXT record data quoted
+				quoted := &dns.ResourceRecordSet{Name: rec.Name, Type: rec.Type, Ttl: rec.Ttl}
+				for _, d := range rec.Rrdatas {
+					quoted.Rrdatas = append(quoted.Rrdatas, `"`+strings.Trim(d, `"`)+`"`)
+				}
+				rrsets[rec.Name] = quoted
+			}
+			change.Id = "1"
+			change.Status = "done"
+			json.NewEncoder(w).Encode(change)
+		default:
+			t.Errorf("unexpected request %s %q", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestOverlappingChallenges(t *testing.T) {
+	_, restore := withDefaultTokenSource("token")
+	defer restore()
+
+	rrsets := map[string]*dns.ResourceRecordSet{}
+	server := newFakeCloudDNS(t, rrsets)
+	defer server.Close()
+
+	provider, err := NewDNSProvider("my-project", nil, 0, util.RecursiveNameservers, true)
+	assert.NoError(t, err)
+	provider.client.BasePath = server.URL + "/"
+
+	// both the apex and wildcard challenges use the same record name
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.addTXTValue("my-zone", fqdn, "apex"))
+	assert.NoError(t, provider.addTXTValue("my-zone", fqdn, "wildcard"))
+	if assert.Contains(t, rrsets, fqdn) {
+		assert.Equal(t, []string{`"apex"`, `"wildcard"`}, rrsets[fqdn].Rrdatas)
+	}
+
+	// presenting the same value again should not duplicate it
+	assert.NoError(t, provider.addTXTValue("my-zone", fqdn, "wildcard"))
+	if assert.Contains(t, rrsets, fqdn) {
+		assert.Equal(t, []string{`"apex"`, `"wildcard"`}, rrsets[fqdn].Rrdatas)
+	}
+
+	assert.NoError(t, provider.removeTXTValue("my-zone", fqdn, "apex"))
+	if assert.Contains(t, rrsets, fqdn) {
+		assert.Equal(t, []string{`"wildcard"`}, rrsets[fqdn].Rrdatas)
+	}
+
+	assert.NoError(t, provider.removeTXTValue("my-zone", fqdn, "wildcard"))
+	assert.NotContains(t, rrsets, fqdn)
+}
@@ -13,7 +13,6 @@ package cloudflare
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,65 +27,83 @@ import (
 // TODO: Unexport?
 const CloudFlareAPIURL = "https://api.cloudflare.com/client/v4"
 
+// defaultTTL is the TTL used for TXT records if none is configured.
+const defaultTTL = 120
+
 // DNSProvider is an implementation of the acme.ChallengeProvider interface
 type DNSProvider struct {
 	dns01Nameservers []string
 	authEmail        string
 	authKey          string
+	authToken        string
+	ttl              int
+
+	// baseURL and findZoneByFqdn may be overridden in tests
+	baseURL        string
+	findZoneByFqdn func(fqdn string, nameservers []string) (string, error)
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for cloudflare.
 // Credentials must be passed in the environment variables: CLOUDFLARE_EMAIL
-// and CLOUDFLARE_API_KEY.
+// and CLOUDFLARE_API_KEY, or CLOUDFLARE_API_TOKEN.
 func NewDNSProvider(dns01Nameservers []string) (*DNSProvider, error) {
 	email := os.Getenv("CLOUDFLARE_EMAIL")
 	key := os.Getenv("CLOUDFLARE_API_KEY")
-	return NewDNSProviderCredentials(email, key, dns01Nameservers)
+	token := os.Getenv("CLOUDFLARE_API_TOKEN")
+	return NewDNSProviderCredentials(email, key, token, 0, dns01Nameservers)
 }
 
 // NewDNSProviderCredentials uses the supplied credentials to return a
-// DNSProvider instance configured for cloudflare.
-func NewDNSProviderCredentials(email, key string, dns01Nameservers []string) (*DNSProvider, error) {
-	if email == "" || key == "" {
+// DNSProvider instance configured for cloudflare. Either an API token, or a
+// global API key and the email address of its account, must be given.
+// If ttl is zero, a default TTL of 120 seconds will be used for TXT records.
+func NewDNSProviderCredentials(email, key, token string, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
+	if token != "" && key != "" {
+		return nil, fmt.Errorf("CloudFlare API token and API key may not both be set")
+	}
+	if token == "" && (email == "" || key == "") {
 		return nil, fmt.Errorf("CloudFlare credentials missing")
 	}
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
 
 	return &DNSProvider{
 		authEmail:        email,
 		authKey:          key,
+		authToken:        token,
+		ttl:              ttl,
 		dns01Nameservers: dns01Nameservers,
+		baseURL:          CloudFlareAPIURL,
+		findZoneByFqdn:   util.FindZoneByFqdn,
 	}, nil
 }
 
-// Present creates a TXT record to fulfil the dns-01 challenge
+// Present creates a TXT record to fulfil the dns-01 challenge. Existing TXT
+// records with the same name but a different value (e.g. for the other of a
+// domain and its wildcard) are left in place.
 func (c *DNSProvider) Present(domain, fqdn, value string) error {
 	zoneID, err := c.getHostedZoneID(fqdn)
 	if err != nil {
 		return err
 	}
 
-	record, err := c.findTxtRecord(fqdn)
-	if err != nil && err != errNoExistingRecord {
-		// this is a real error
+	records, err := c.findTxtRecords(fqdn)
+	if err != nil {
 		return err
 	}
-	if record != nil {
+	for _, record := range records {
 		if record.Content == value {
 			// the record is already set to the desired value
 			return nil
 		}
-
-		_, err = c.makeRequest("DELETE", fmt.Sprintf("/zones/%s/dns_records/%s", record.ZoneID, record.ID), nil)
-		if err != nil {
-			return err
-		}
 	}
 
 	rec := cloudFlareRecord{
 		Type:    "TXT",
 		Name:    util.UnFqdn(fqdn),
 		Content: value,
-		TTL:     120,
+		TTL:     c.ttl,
 	}
 
 	body, err := json.Marshal(rec)
@@ -104,18 +121,19 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 
 // CleanUp removes the TXT record matching the specified parameters
 func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
-	record, err := c.findTxtRecord(fqdn)
-	// Nothing to cleanup
-	if err == errNoExistingRecord {
-		return nil
-	}
+	records, err := c.findTxtRecords(fqdn)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.makeRequest("DELETE", fmt.Sprintf("/zones/%s/dns_records/%s", record.ZoneID, record.ID), nil)
-	if err != nil {
-		return err
+	for _, record := range records {
+		if record.Content != value {
+			continue
+		}
+		_, err = c.makeRequest("DELETE", fmt.Sprintf("/zones/%s/dns_records/%s", record.ZoneID, record.ID), nil)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -128,7 +146,7 @@ func (c *DNSProvider) getHostedZoneID(fqdn string) (string, error) {
 		Name string `json:"name"`
 	}
 
-	authZone, err := util.FindZoneByFqdn(fqdn, c.dns01Nameservers)
+	authZone, err := c.findZoneByFqdn(fqdn, c.dns01Nameservers)
 	if err != nil {
 		return "", err
 	}
@@ -151,9 +169,7 @@ func (c *DNSProvider) getHostedZoneID(fqdn string) (string, error) {
 	return hostedZone[0].ID, nil
 }
 
-var errNoExistingRecord = errors.New("No existing record found")
-
-func (c *DNSProvider) findTxtRecord(fqdn string) (*cloudFlareRecord, error) {
+func (c *DNSProvider) findTxtRecords(fqdn string) ([]cloudFlareRecord, error) {
 	zoneID, err := c.getHostedZoneID(fqdn)
 	if err != nil {
 		return nil, err
@@ -174,13 +190,14 @@ func (c *DNSProvider) findTxtRecord(fqdn string) (*cloudFlareRecord, error) {
 		return nil, err
 	}
 
+	var found []cloudFlareRecord
 	for _, rec := range records {
 		if rec.Name == util.UnFqdn(fqdn) {
-			return &rec, nil
+			found = append(found, rec)
 		}
 	}
 
-	return nil, errNoExistingRecord
+	return found, nil
 }
 
 func (c *DNSProvider) makeRequest(method, uri string, body io.Reader) (json.RawMessage, error) {
@@ -198,13 +215,17 @@ func (c *DNSProvider) makeRequest(method, uri string, body io.Reader) (json.RawM
 		Result  json.RawMessage `json:"result"`
 	}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", CloudFlareAPIURL, uri), body)
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.baseURL, uri), body)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("X-Auth-Email", c.authEmail)
-	req.Header.Set("X-Auth-Key", c.authKey)
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	} else {
+		req.Header.Set("X-Auth-Email", c.authEmail)
+		req.Header.Set("X-Auth-Key", c.authKey)
+	}
 	req.Header.Set("User-Agent", pkgutil.CertManagerUserAgent)
 
 	client := http.Client{
@@ -9,7 +9,12 @@ this directory.
 package cloudflare
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,12 +26,14 @@ var (
 	cflareLiveTest bool
 	cflareEmail    string
 	cflareAPIKey   string
+	cflareAPIToken string
 	cflareDomain   string
 )
 
 func init() {
 	cflareEmail = os.Getenv("CLOUDFLARE_EMAIL")
 	cflareAPIKey = os.Getenv("CLOUDFLARE_API_KEY")
+	cflareAPIToken = os.Getenv("CLOUDFLARE_API_TOKEN")
 	cflareDomain = os.Getenv("CLOUDFLARE_DOMAIN")
 	if len(cflareEmail) > 0 && len(cflareAPIKey) > 0 && len(cflareDomain) > 0 {
 		cflareLiveTest = true
@@ -36,12 +43,13 @@ func init() {
 func restoreCloudFlareEnv() {
 	os.Setenv("CLOUDFLARE_EMAIL", cflareEmail)
 	os.Setenv("CLOUDFLARE_API_KEY", cflareAPIKey)
+	os.Setenv("CLOUDFLARE_API_TOKEN", cflareAPIToken)
 }
 
 func TestNewDNSProviderValid(t *testing.T) {
 	os.Setenv("CLOUDFLARE_EMAIL", "")
 	os.Setenv("CLOUDFLARE_API_KEY", "")
-	_, err := NewDNSProviderCredentials("123", "123", util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("123", "123", "", 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 	restoreCloudFlareEnv()
 }
@@ -49,6 +57,7 @@ func TestNewDNSProviderValid(t *testing.T) {
 func TestNewDNSProviderValidEnv(t *testing.T) {
 	os.Setenv("CLOUDFLARE_EMAIL", "test@example.com")
 	os.Setenv("CLOUDFLARE_API_KEY", "123")
+	os.Setenv("CLOUDFLARE_API_TOKEN", "")
 	_, err := NewDNSProvider(util.RecursiveNameservers)
 	assert.NoError(t, err)
 	restoreCloudFlareEnv()
@@ -57,17 +66,212 @@ func TestNewDNSProviderValidEnv(t *testing.T) {
 func TestNewDNSProviderMissingCredErr(t *testing.T) {
 	os.Setenv("CLOUDFLARE_EMAIL", "")
 	os.Setenv("CLOUDFLARE_API_KEY", "")
+	os.Setenv("CLOUDFLARE_API_TOKEN", "")
 	_, err := NewDNSProvider(util.RecursiveNameservers)
 	assert.EqualError(t, err, "CloudFlare credentials missing")
 	restoreCloudFlareEnv()
 }
 
+func TestNewDNSProviderToken(t *testing.T) {
+	_, err := NewDNSProviderCredentials("", "", "123", 0, util.RecursiveNameservers)
+	assert.NoError(t, err)
+}
+
+func TestNewDNSProviderTokenAndKeyErr(t *testing.T) {
+	_, err := NewDNSProviderCredentials("test@example.com", "123", "123", 0, util.RecursiveNameservers)
+	assert.EqualError(t, err, "CloudFlare API token and API key may not both be set")
+}
+
+func TestNewDNSProviderTokenFromEnv(t *testing.T) {
+	os.Setenv("CLOUDFLARE_EMAIL", "")
+	os.Setenv("CLOUDFLARE_API_KEY", "")
+	os.Setenv("CLOUDFLARE_API_TOKEN", "123")
+	provider, err := NewDNSProvider(util.RecursiveNameservers)
+	assert.NoError(t, err)
+	assert.Equal(t, "123", provider.authToken)
+	restoreCloudFlareEnv()
+}
+
+// newMockCloudFlareAPI returns a server implementing the parts of the
+// CloudFlare API used by the provider. Every request must be authenticated
+// using the given API token. Created records are appended to records, and
+// deleted records are removed from it.
+func newMockCloudFlareAPI(t *testing.T, token string, records *[]cloudFlareRecord) *httptest.Server {
+	nextID := 0
+	writeResult := func(w http.ResponseWriter, result interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"result":  result,
+		})
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"errors":  []map[string]interface{}{{"code": 9109, "message": "Invalid access token"}},
+			})
+			return
+		}
+		if r.Header.Get("X-Auth-Key") != "" || r.Header.Get("X-Auth-Email") != "" {
+			t.Errorf("expected no API key headers to be sent when using an API token")
+		}
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/zones":
+			if name := r.URL.Query().Get("name"); name != "example.com" {
+				writeResult(w, []interface{}{})
+				return
+			}
+			writeResult(w, []map[string]string{{"id": "zone-id", "name": "example.com"}})
+		case r.Method == "GET" && r.URL.Path == "/zones/zone-id/dns_records":
+			writeResult(w, *records)
+		case r.Method == "POST" && r.URL.Path == "/zones/zone-id/dns_records":
+			var rec cloudFlareRecord
+			if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+				t.Errorf("error decoding record: %v", err)
+			}
+			nextID++
+			rec.ID = fmt.Sprintf("record-id-%d", nextID)
+			rec.ZoneID = "zone-id"
+			*records = append(*records, rec)
+			writeResult(w, rec)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/zones/zone-id/dns_records/"):
+			id := strings.TrimPrefix(r.URL.Path, "/zones/zone-id/dns_records/")
+			for i, rec := range *records {
+				if rec.ID == id {
+					*records = append((*records)[:i], (*records)[i+1:]...)
+					writeResult(w, map[string]string{"id": id})
+					return
+				}
+			}
+			t.Errorf("deleted record %q does not exist", id)
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTokenTestProvider(t *testing.T, server *httptest.Server, token string) *DNSProvider {
+	provider, err := NewDNSProviderCredentials("", "", token, 0, util.RecursiveNameservers)
+	assert.NoError(t, err)
+	provider.baseURL = server.URL
+	provider.findZoneByFqdn = func(fqdn string, nameservers []string) (string, error) {
+		return "example.com.", nil
+	}
+	return provider
+}
+
+func TestCloudFlareTokenZoneLookup(t *testing.T) {
+	var records []cloudFlareRecord
+	server := newMockCloudFlareAPI(t, "token", &records)
+	defer server.Close()
+
+	provider := newTokenTestProvider(t, server, "token")
+	zoneID, err := provider.getHostedZoneID("_acme-challenge.example.com.")
+	assert.NoError(t, err)
+	assert.Equal(t, "zone-id", zoneID)
+}
+
+func TestCloudFlareTokenPresent(t *testing.T) {
+	var records []cloudFlareRecord
+	server := newMockCloudFlareAPI(t, "token", &records)
+	defer server.Close()
+
+	provider := newTokenTestProvider(t, server, "token")
+	err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.NoError(t, err)
+
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "TXT", records[0].Type)
+		assert.Equal(t, "_acme-challenge.example.com", records[0].Name)
+		assert.Equal(t, "123d==", records[0].Content)
+	}
+}
+
+func TestCloudFlarePresentTTL(t *testing.T) {
+	tests := map[string]struct {
+		ttl         int
+		expectedTTL int
+	}{
+		"uses the default TTL if none is configured": {
+			expectedTTL: 120,
+		},
+		"uses the configured TTL": {
+			ttl:         300,
+			expectedTTL: 300,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var records []cloudFlareRecord
+			server := newMockCloudFlareAPI(t, "token", &records)
+			defer server.Close()
+
+			provider, err := NewDNSProviderCredentials("", "", "token", tt.ttl, util.RecursiveNameservers)
+			assert.NoError(t, err)
+			provider.baseURL = server.URL
+			provider.findZoneByFqdn = func(fqdn string, nameservers []string) (string, error) {
+				return "example.com.", nil
+			}
+
+			err = provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+			assert.NoError(t, err)
+			if assert.Len(t, records, 1) {
+				assert.Equal(t, tt.expectedTTL, records[0].TTL)
+			}
+		})
+	}
+}
+
+func TestCloudFlareOverlappingChallenges(t *testing.T) {
+	var records []cloudFlareRecord
+	server := newMockCloudFlareAPI(t, "token", &records)
+	defer server.Close()
+
+	provider := newTokenTestProvider(t, server, "token")
+
+	// both the apex and wildcard challenges use the same record name
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "apex"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "wildcard"))
+	// presenting the same value again should not duplicate it
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "wildcard"))
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "apex", records[0].Content)
+		assert.Equal(t, "wildcard", records[1].Content)
+	}
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "apex"))
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "wildcard", records[0].Content)
+	}
+
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "wildcard"))
+	assert.Len(t, records, 0)
+}
+
+func TestCloudFlareInvalidToken(t *testing.T) {
+	var records []cloudFlareRecord
+	server := newMockCloudFlareAPI(t, "token", &records)
+	defer server.Close()
+
+	provider := newTokenTestProvider(t, server, "wrong-token")
+	err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.Error(t, err)
+	assert.Len(t, records, 0)
+}
+
 func TestCloudFlarePresent(t *testing.T) {
 	if !cflareLiveTest {
 		t.Skip("skipping live test")
 	}
 
-	provider, err := NewDNSProviderCredentials(cflareEmail, cflareAPIKey, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(cflareEmail, cflareAPIKey, "", 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	err = provider.Present(cflareDomain, "_acme-challenge."+cflareDomain+".", "123d==")
@@ -81,7 +285,7 @@ func TestCloudFlareCleanUp(t *testing.T) {
 
 	time.Sleep(time.Second * 2)
 
-	provider, err := NewDNSProviderCredentials(cflareEmail, cflareAPIKey, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(cflareEmail, cflareAPIKey, "", 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	err = provider.CleanUp(cflareDomain, "_acme-challenge."+cflareDomain+".", "123d==")
@@ -33,21 +33,26 @@ import (
 type DNSProvider struct {
 	dns01Nameservers []string
 	client           *godo.Client
+	ttl              int
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for digitalocean.
 // The access token must be passed in the environment variable DIGITALOCEAN_TOKEN
 func NewDNSProvider(dns01Nameservers []string) (*DNSProvider, error) {
 	token := os.Getenv("DIGITALOCEAN_TOKEN")
-	return NewDNSProviderCredentials(token, dns01Nameservers)
+	return NewDNSProviderCredentials(token, 0, dns01Nameservers)
 }
 
 // NewDNSProviderCredentials uses the supplied credentials to return a
-// DNSProvider instance configured for digitalocean.
-func NewDNSProviderCredentials(token string, dns01Nameservers []string) (*DNSProvider, error) {
+// DNSProvider instance configured for digitalocean. If ttl is zero, a default
+// TTL of 60 seconds will be used for TXT records.
+func NewDNSProviderCredentials(token string, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
 	if token == "" {
 		return nil, fmt.Errorf("DigitalOcean token missing")
 	}
+	if ttl == 0 {
+		ttl = util.DefaultTTL
+	}
 
 	c := oauth2.NewClient(
 		context.Background(),
@@ -57,6 +62,7 @@ func NewDNSProviderCredentials(token string, dns01Nameservers []string) (*DNSPro
 	return &DNSProvider{
 		dns01Nameservers: dns01Nameservers,
 		client:           godo.NewClient(c),
+		ttl:              ttl,
 	}, nil
 }
 
@@ -81,7 +87,7 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 		Type: "TXT",
 		Name: fqdn,
 		Data: value,
-		TTL:  60,
+		TTL:  c.ttl,
 	}
 
 	_, _, err = c.client.Domains.CreateRecord(
@@ -97,7 +103,9 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 	return nil
 }
 
-// CleanUp removes the TXT record matching the specified parameters
+// CleanUp removes the TXT record matching the specified parameters. Other
+// records with the same name (e.g. for the other of a domain and its
+// wildcard) are left in place.
 func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	zoneName, err := util.FindZoneByFqdn(fqdn, c.dns01Nameservers)
 
@@ -107,6 +115,9 @@ func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	}
 
 	for _, record := range records {
+		if record.Type != "TXT" || record.Data != value {
+			continue
+		}
 		_, err = c.client.Domains.DeleteRecord(context.Background(), util.UnFqdn(zoneName), record.ID)
 
 		if err != nil {
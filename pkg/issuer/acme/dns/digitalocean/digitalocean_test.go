@@ -45,11 +45,21 @@ func restoreEnv() {
 
 func TestNewDNSProviderValid(t *testing.T) {
 	os.Setenv("DIGITALOCEAN_TOKEN", "")
-	_, err := NewDNSProviderCredentials("123", util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("123", 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 	restoreEnv()
 }
 
+func TestNewDNSProviderTTL(t *testing.T) {
+	provider, err := NewDNSProviderCredentials("123", 0, util.RecursiveNameservers)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, provider.ttl, "expected the default TTL to be used")
+
+	provider, err = NewDNSProviderCredentials("123", 300, util.RecursiveNameservers)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, provider.ttl)
+}
+
 func TestNewDNSProviderValidEnv(t *testing.T) {
 	os.Setenv("DIGITALOCEAN_TOKEN", "123")
 	_, err := NewDNSProvider(util.RecursiveNameservers)
@@ -69,7 +79,7 @@ func TestDigitalOceanPresent(t *testing.T) {
 		t.Skip("skipping live test")
 	}
 
-	provider, err := NewDNSProviderCredentials(doToken, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(doToken, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	err = provider.Present(doDomain, "_acme-challenge."+doDomain+".", "123d==")
@@ -83,7 +93,7 @@ func TestDigitalOceanCleanUp(t *testing.T) {
 
 	time.Sleep(time.Second * 2)
 
-	provider, err := NewDNSProviderCredentials(doToken, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(doToken, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	err = provider.CleanUp(doDomain, "_acme-challenge."+doDomain+".", "123d==")
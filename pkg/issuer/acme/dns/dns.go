@@ -22,9 +22,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/controller"
@@ -34,9 +35,12 @@ import (
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/clouddns"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/cloudflare"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/digitalocean"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/hetzner"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/rfc2136"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/route53"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/webhook"
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 const (
@@ -52,13 +56,16 @@ type solver interface {
 // It is useful for mocking out a given provider since an alternate set of
 // constructors may be set.
 type dnsProviderConstructors struct {
-	cloudDNS     func(project string, serviceAccount []byte, dns01Nameservers []string, ambient bool) (*clouddns.DNSProvider, error)
-	cloudFlare   func(email, apikey string, dns01Nameservers []string) (*cloudflare.DNSProvider, error)
-	route53      func(accessKey, secretKey, hostedZoneID, region string, ambient bool, dns01Nameservers []string) (*route53.DNSProvider, error)
-	azureDNS     func(clientID, clientSecret, subscriptionID, tenentID, resourceGroupName, hostedZoneName string, dns01Nameservers []string) (*azuredns.DNSProvider, error)
-	acmeDNS      func(host string, accountJson []byte, dns01Nameservers []string) (*acmedns.DNSProvider, error)
-	rfc2136      func(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret string, dns01Nameservers []string) (*rfc2136.DNSProvider, error)
-	digitalOcean func(token string, dns01Nameservers []string) (*digitalocean.DNSProvider, error)
+	cloudDNS                func(project string, serviceAccount []byte, ttl int, dns01Nameservers []string, ambient bool) (*clouddns.DNSProvider, error)
+	cloudFlare              func(email, apikey, apitoken string, ttl int, dns01Nameservers []string) (*cloudflare.DNSProvider, error)
+	route53                 func(accessKey, secretKey, hostedZoneID, region, role, externalID string, ambient bool, ttl int, dns01Nameservers []string) (*route53.DNSProvider, error)
+	azureDNS                func(clientID, clientSecret, subscriptionID, tenentID, resourceGroupName, hostedZoneName string, ttl int, dns01Nameservers []string) (*azuredns.DNSProvider, error)
+	azureDNSManagedIdentity func(identityClientID, subscriptionID, resourceGroupName, hostedZoneName string, ttl int, dns01Nameservers []string) (*azuredns.DNSProvider, error)
+	acmeDNS                 func(host string, accountJson []byte, dns01Nameservers []string) (*acmedns.DNSProvider, error)
+	rfc2136                 func(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret string, ttl int, dns01Nameservers []string) (*rfc2136.DNSProvider, error)
+	digitalOcean            func(token string, ttl int, dns01Nameservers []string) (*digitalocean.DNSProvider, error)
+	hetzner                 func(token string, ttl int) (*hetzner.DNSProvider, error)
+	webhook                 func(restConfig *rest.Config, groupName, solverName string, config *apiext.JSON, resourceNamespace string, ambient bool, ttl int, dns01Nameservers []string) (*webhook.DNSProvider, error)
 }
 
 // Solver is a solver for the acme dns01 challenge.
@@ -81,27 +88,47 @@ func (s *Solver) Present(ctx context.Context, issuer v1alpha1.GenericIssuer, ch
 		return err
 	}
 
-	fqdn, value, _, err := util.DNS01Record(ch.Spec.DNSName, ch.Spec.Key, s.DNS01Nameservers, followCNAME(providerConfig.CNAMEStrategy))
+	nameservers := s.nameserversFor(issuer)
+	fqdn, value, _, err := util.DNS01Record(ch.Spec.DNSName, ch.Spec.Key, nameservers, followCNAME(providerConfig.CNAMEStrategy), s.dnsTimeout())
 	if err != nil {
 		return err
 	}
 
-	glog.Infof("Presenting DNS01 challenge for domain %q", ch.Spec.DNSName)
+	logs.Infof("Presenting DNS01 challenge for domain %q", ch.Spec.DNSName)
 	return slv.Present(ch.Spec.DNSName, fqdn, value)
 }
 
 // Check verifies that the DNS records for the ACME challenge have propagated.
 func (s *Solver) Check(ctx context.Context, issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) error {
+	if acme := issuer.GetSpec().ACME; acme != nil && acme.SkipDNS01PropagationCheck {
+		logs.Infof("Skipping DNS01 propagation check for %q as it is disabled on issuer %q", ch.Spec.DNSName, issuer.GetObjectMeta().Name)
+		return nil
+	}
 
-	fqdn, value, ttl, err := util.DNS01Record(ch.Spec.DNSName, ch.Spec.Key, s.DNS01Nameservers, false)
+	if ch.Spec.Config.DNS01 == nil {
+		return fmt.Errorf("challenge dns config must be specified")
+	}
+
+	providerConfig, err := issuer.GetSpec().ACME.DNS01.Provider(ch.Spec.Config.DNS01.Provider)
 	if err != nil {
 		return err
 	}
 
-	glog.Infof("Checking DNS propagation for %q using name servers: %v", ch.Spec.DNSName, s.Context.DNS01Nameservers)
+	// when following CNAMEs, the TXT record is checked at the end of the
+	// CNAME chain, which is where it has been presented
+	nameservers := s.nameserversFor(issuer)
+	fqdn, value, ttl, err := util.DNS01Record(ch.Spec.DNSName, ch.Spec.Key, nameservers, followCNAME(providerConfig.CNAMEStrategy), s.dnsTimeout())
+	if err != nil {
+		return err
+	}
+	if providerConfig.TTL > 0 {
+		ttl = providerConfig.TTL
+	}
+
+	logs.Infof("Checking DNS propagation for %q using name servers: %v", ch.Spec.DNSName, nameservers)
 
-	ok, err := util.PreCheckDNS(fqdn, value, s.Context.DNS01Nameservers,
-		s.Context.DNS01CheckAuthoritative)
+	ok, err := util.PreCheckDNS(fqdn, value, nameservers,
+		s.Context.DNS01CheckAuthoritative, s.dnsTimeout())
 	if err != nil {
 		return err
 	}
@@ -109,9 +136,9 @@ func (s *Solver) Check(ctx context.Context, issuer v1alpha1.GenericIssuer, ch *v
 		return fmt.Errorf("DNS record for %q not yet propagated", ch.Spec.DNSName)
 	}
 
-	glog.Infof("Waiting DNS record TTL (%ds) to allow propagation of DNS record for domain %q", ttl, fqdn)
+	logs.Infof("Waiting DNS record TTL (%ds) to allow propagation of DNS record for domain %q", ttl, fqdn)
 	time.Sleep(time.Second * time.Duration(ttl))
-	glog.Infof("ACME DNS01 validation record propagated for %q", fqdn)
+	logs.Infof("ACME DNS01 validation record propagated for %q", fqdn)
 
 	return nil
 }
@@ -128,7 +155,8 @@ func (s *Solver) CleanUp(ctx context.Context, issuer v1alpha1.GenericIssuer, ch
 		return err
 	}
 
-	fqdn, value, _, err := util.DNS01Record(ch.Spec.DNSName, ch.Spec.Key, s.DNS01Nameservers, followCNAME(providerConfig.CNAMEStrategy))
+	nameservers := s.nameserversFor(issuer)
+	fqdn, value, _, err := util.DNS01Record(ch.Spec.DNSName, ch.Spec.Key, nameservers, followCNAME(providerConfig.CNAMEStrategy), s.dnsTimeout())
 	if err != nil {
 		return err
 	}
@@ -136,6 +164,25 @@ func (s *Solver) CleanUp(ctx context.Context, issuer v1alpha1.GenericIssuer, ch
 	return slv.CleanUp(ch.Spec.DNSName, fqdn, value)
 }
 
+// nameserversFor returns the nameservers to use when performing DNS lookups
+// on behalf of the given issuer. The issuer's own list of recursive
+// nameservers is used if set, otherwise the controller wide list is used.
+func (s *Solver) nameserversFor(issuer v1alpha1.GenericIssuer) []string {
+	if acme := issuer.GetSpec().ACME; acme != nil && len(acme.DNS01RecursiveNameservers) > 0 {
+		return acme.DNS01RecursiveNameservers
+	}
+	return s.DNS01Nameservers
+}
+
+// dnsTimeout returns the timeout of each DNS query made while presenting and
+// checking challenges.
+func (s *Solver) dnsTimeout() time.Duration {
+	if s.DNS01CheckTimeout > 0 {
+		return s.DNS01CheckTimeout
+	}
+	return util.DefaultDNSTimeout
+}
+
 func followCNAME(strategy v1alpha1.CNAMEStrategy) bool {
 	if strategy == v1alpha1.FollowStrategy {
 		return true
@@ -143,14 +190,34 @@ func followCNAME(strategy v1alpha1.CNAMEStrategy) bool {
 	return false
 }
 
-// solverForChallenge returns a Solver for the given providerName.
+// CheckProvider instantiates the named DNS01 provider of the given issuer,
+// reading any credentials it references, without presenting a record. It is
+// used to verify the configuration of a provider before it is needed to
+// solve a challenge.
+func (s *Solver) CheckProvider(issuer v1alpha1.GenericIssuer, providerName string) error {
+	_, _, err := s.solverForProvider(issuer, providerName)
+	return err
+}
+
+// solverForChallenge returns a Solver for the DNS01 provider named in the
+// configuration of the given challenge.
+func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) (solver, *v1alpha1.ACMEIssuerDNS01Provider, error) {
+	return s.solverForProvider(issuer, ch.Spec.Config.DNS01.Provider)
+}
+
+// solverForProvider returns a Solver for the given providerName.
 // The providerName is the name of an ACME DNS-01 challenge provider as
 // specified on the Issuer resource for the Solver.
-func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) (solver, *v1alpha1.ACMEIssuerDNS01Provider, error) {
+func (s *Solver) solverForProvider(issuer v1alpha1.GenericIssuer, providerName string) (solver, *v1alpha1.ACMEIssuerDNS01Provider, error) {
 	resourceNamespace := s.ResourceNamespace(issuer)
 	canUseAmbientCredentials := s.CanUseAmbientCredentials(issuer)
+	nameservers := s.nameserversFor(issuer)
+
+	secrets, err := s.CredentialsLister(issuer, s.secretLister)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	providerName := ch.Spec.Config.DNS01.Provider
 	if providerName == "" {
 		return nil, nil, fmt.Errorf("dns01 challenge provider name must be set")
 	}
@@ -163,17 +230,17 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 	var impl solver
 	switch {
 	case providerConfig.Akamai != nil:
-		clientToken, err := s.loadSecretData(&providerConfig.Akamai.ClientToken, resourceNamespace)
+		clientToken, err := loadSecretData(secrets, &providerConfig.Akamai.ClientToken, resourceNamespace)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "error getting akamai client token")
 		}
 
-		clientSecret, err := s.loadSecretData(&providerConfig.Akamai.ClientSecret, resourceNamespace)
+		clientSecret, err := loadSecretData(secrets, &providerConfig.Akamai.ClientSecret, resourceNamespace)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "error getting akamai client secret")
 		}
 
-		accessToken, err := s.loadSecretData(&providerConfig.Akamai.AccessToken, resourceNamespace)
+		accessToken, err := loadSecretData(secrets, &providerConfig.Akamai.AccessToken, resourceNamespace)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "error getting akamai access token")
 		}
@@ -183,7 +250,8 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 			string(clientToken),
 			string(clientSecret),
 			string(accessToken),
-			s.DNS01Nameservers)
+			providerConfig.TTL,
+			nameservers)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "error instantiating akamai challenge solver")
 		}
@@ -195,7 +263,7 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 		// If it is not set, we will attempt to instantiate the provider using
 		// ambient credentials (if enabled).
 		if providerConfig.CloudDNS.ServiceAccount.Name != "" {
-			saSecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.CloudDNS.ServiceAccount.Name)
+			saSecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.CloudDNS.ServiceAccount.Name)
 			if err != nil {
 				return nil, nil, fmt.Errorf("error getting clouddns service account: %s", err)
 			}
@@ -208,39 +276,59 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 		}
 
 		// attempt to construct the cloud dns provider
-		impl, err = s.dnsProviderConstructors.cloudDNS(providerConfig.CloudDNS.Project, keyData, s.DNS01Nameservers, s.CanUseAmbientCredentials(issuer))
+		impl, err = s.dnsProviderConstructors.cloudDNS(providerConfig.CloudDNS.Project, keyData, providerConfig.TTL, nameservers, s.CanUseAmbientCredentials(issuer))
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating google clouddns challenge solver: %s", err)
 		}
 	case providerConfig.Cloudflare != nil:
-		apiKeySecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.Cloudflare.APIKey.Name)
-		if err != nil {
-			return nil, nil, fmt.Errorf("error getting cloudflare service account: %s", err)
+		var apiKey, apiToken string
+		if providerConfig.Cloudflare.APIToken != nil {
+			apiTokenBytes, err := loadSecretData(secrets, providerConfig.Cloudflare.APIToken, resourceNamespace)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "error getting cloudflare api token")
+			}
+			apiToken = strings.TrimSpace(string(apiTokenBytes))
+		}
+		if providerConfig.Cloudflare.APIKey.Name != "" {
+			apiKeySecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.Cloudflare.APIKey.Name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error getting cloudflare service account: %s", err)
+			}
+			apiKey = string(apiKeySecret.Data[providerConfig.Cloudflare.APIKey.Key])
 		}
 
 		email := providerConfig.Cloudflare.Email
-		apiKey := string(apiKeySecret.Data[providerConfig.Cloudflare.APIKey.Key])
 
-		impl, err = s.dnsProviderConstructors.cloudFlare(email, apiKey, s.DNS01Nameservers)
+		impl, err = s.dnsProviderConstructors.cloudFlare(email, apiKey, apiToken, providerConfig.TTL, nameservers)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating cloudflare challenge solver: %s", err)
 		}
 	case providerConfig.DigitalOcean != nil:
-		apiTokenSecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.DigitalOcean.Token.Name)
+		apiTokenSecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.DigitalOcean.Token.Name)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error getting digitalocean token: %s", err)
 		}
 
 		apiToken := string(apiTokenSecret.Data[providerConfig.DigitalOcean.Token.Key])
 
-		impl, err = s.dnsProviderConstructors.digitalOcean(strings.TrimSpace(apiToken), s.DNS01Nameservers)
+		impl, err = s.dnsProviderConstructors.digitalOcean(strings.TrimSpace(apiToken), providerConfig.TTL, nameservers)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating digitalocean challenge solver: %s", err.Error())
 		}
+	case providerConfig.Hetzner != nil:
+		apiToken, err := loadSecretData(secrets, &providerConfig.Hetzner.APIToken, resourceNamespace)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error getting hetzner api token")
+		}
+
+		impl, err = s.dnsProviderConstructors.hetzner(strings.TrimSpace(string(apiToken)), providerConfig.TTL)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error instantiating hetzner challenge solver")
+		}
 	case providerConfig.Route53 != nil:
 		secretAccessKey := ""
 		if providerConfig.Route53.SecretAccessKey.Name != "" {
-			secretAccessKeySecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.Route53.SecretAccessKey.Name)
+			secretAccessKeySecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.Route53.SecretAccessKey.Name)
 			if err != nil {
 				return nil, nil, fmt.Errorf("error getting route53 secret access key: %s", err)
 			}
@@ -257,14 +345,38 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 			strings.TrimSpace(secretAccessKey),
 			providerConfig.Route53.HostedZoneID,
 			providerConfig.Route53.Region,
+			providerConfig.Route53.Role,
+			providerConfig.Route53.ExternalID,
 			canUseAmbientCredentials,
-			s.DNS01Nameservers,
+			providerConfig.TTL,
+			nameservers,
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating route53 challenge solver: %s", err)
 		}
+	case providerConfig.AzureDNS != nil && providerConfig.AzureDNS.ClientSecret.Name == "":
+		if !canUseAmbientCredentials {
+			return nil, nil, fmt.Errorf("unable to construct azuredns provider: no client secret specified and ambient credentials are disabled; perhaps you meant to enable ambient credentials?")
+		}
+
+		var identityClientID string
+		if providerConfig.AzureDNS.ManagedIdentity != nil {
+			identityClientID = providerConfig.AzureDNS.ManagedIdentity.ClientID
+		}
+
+		impl, err = s.dnsProviderConstructors.azureDNSManagedIdentity(
+			identityClientID,
+			providerConfig.AzureDNS.SubscriptionID,
+			providerConfig.AzureDNS.ResourceGroupName,
+			providerConfig.AzureDNS.HostedZoneName,
+			providerConfig.TTL,
+			nameservers,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error instantiating azuredns challenge solver: %s", err)
+		}
 	case providerConfig.AzureDNS != nil:
-		clientSecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.AzureDNS.ClientSecret.Name)
+		clientSecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.AzureDNS.ClientSecret.Name)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error getting azuredns client secret: %s", err)
 		}
@@ -281,13 +393,14 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 			providerConfig.AzureDNS.TenantID,
 			providerConfig.AzureDNS.ResourceGroupName,
 			providerConfig.AzureDNS.HostedZoneName,
-			s.DNS01Nameservers,
+			providerConfig.TTL,
+			nameservers,
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating azuredns challenge solver: %s", err)
 		}
 	case providerConfig.AcmeDNS != nil:
-		accountSecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.AcmeDNS.AccountSecret.Name)
+		accountSecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.AcmeDNS.AccountSecret.Name)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error getting acmedns accounts secret: %s", err)
 		}
@@ -300,7 +413,7 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 		impl, err = s.dnsProviderConstructors.acmeDNS(
 			providerConfig.AcmeDNS.Host,
 			accountSecretBytes,
-			s.DNS01Nameservers,
+			nameservers,
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating acmedns challenge solver: %s", err)
@@ -308,7 +421,7 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 	case providerConfig.RFC2136 != nil:
 		var secret string
 		if len(providerConfig.RFC2136.TSIGSecret.Name) > 0 {
-			tsigSecret, err := s.secretLister.Secrets(resourceNamespace).Get(providerConfig.RFC2136.TSIGSecret.Name)
+			tsigSecret, err := secrets.Secrets(resourceNamespace).Get(providerConfig.RFC2136.TSIGSecret.Name)
 			if err != nil {
 				return nil, nil, fmt.Errorf("error getting rfc2136 service account: %s", err.Error())
 			}
@@ -324,11 +437,26 @@ func (s *Solver) solverForChallenge(issuer v1alpha1.GenericIssuer, ch *v1alpha1.
 			string(providerConfig.RFC2136.TSIGAlgorithm),
 			providerConfig.RFC2136.TSIGKeyName,
 			secret,
-			s.DNS01Nameservers,
+			providerConfig.TTL,
+			nameservers,
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error instantiating rfc2136 challenge solver: %s", err.Error())
 		}
+	case providerConfig.Webhook != nil:
+		impl, err = s.dnsProviderConstructors.webhook(
+			s.RESTConfig,
+			providerConfig.Webhook.GroupName,
+			providerConfig.Webhook.SolverName,
+			providerConfig.Webhook.Config,
+			resourceNamespace,
+			canUseAmbientCredentials,
+			providerConfig.TTL,
+			nameservers,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error instantiating webhook challenge solver: %s", err)
+		}
 	default:
 		return nil, nil, fmt.Errorf("no dns provider config specified for provider %q", providerName)
 	}
@@ -347,15 +475,18 @@ func NewSolver(ctx *controller.Context) *Solver {
 			cloudflare.NewDNSProviderCredentials,
 			route53.NewDNSProvider,
 			azuredns.NewDNSProviderCredentials,
+			azuredns.NewDNSProviderManagedIdentity,
 			acmedns.NewDNSProviderHostBytes,
 			rfc2136.NewDNSProviderCredentials,
 			digitalocean.NewDNSProviderCredentials,
+			hetzner.NewDNSProviderCredentials,
+			webhook.NewDNSProvider,
 		},
 	}
 }
 
-func (s *Solver) loadSecretData(selector *v1alpha1.SecretKeySelector, ns string) ([]byte, error) {
-	secret, err := s.secretLister.Secrets(ns).Get(selector.Name)
+func loadSecretData(secrets corev1listers.SecretLister, selector *v1alpha1.SecretKeySelector, ns string) ([]byte, error) {
+	secret, err := secrets.Secrets(ns).Get(selector.Name)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to load secret %q", ns+"/"+selector.Name)
 	}
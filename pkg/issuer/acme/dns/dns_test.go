@@ -17,10 +17,12 @@ limitations under the License.
 package dns
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -310,7 +312,7 @@ func TestSolveForDigitalOcean(t *testing.T) {
 	expectedDOCall := []fakeDNSProviderCall{
 		{
 			name: "digitalocean",
-			args: []interface{}{"FAKE-TOKEN", util.RecursiveNameservers},
+			args: []interface{}{"FAKE-TOKEN", 0, util.RecursiveNameservers},
 		},
 	}
 
@@ -320,6 +322,197 @@ func TestSolveForDigitalOcean(t *testing.T) {
 
 }
 
+func TestSolveForHetzner(t *testing.T) {
+	f := &solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				newSecret("hetzner", "default", map[string][]byte{
+					"api-token": []byte("FAKE-TOKEN\n"),
+				}),
+			},
+		},
+		Issuer: newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{
+			{
+				Name: "fake-hetzner",
+				TTL:  300,
+				Hetzner: &v1alpha1.ACMEIssuerDNS01ProviderHetzner{
+					APIToken: v1alpha1.SecretKeySelector{
+						LocalObjectReference: v1alpha1.LocalObjectReference{
+							Name: "hetzner",
+						},
+						Key: "api-token",
+					},
+				},
+			},
+		}),
+		Challenge: &v1alpha1.Challenge{
+			Spec: v1alpha1.ChallengeSpec{
+				Config: v1alpha1.SolverConfig{
+					DNS01: &v1alpha1.DNS01SolverConfig{
+						Provider: "fake-hetzner",
+					},
+				},
+			},
+		},
+		dnsProviders: newFakeDNSProviders(),
+	}
+
+	f.Setup(t)
+	defer f.Finish(t)
+
+	s := f.Solver
+	_, _, err := s.solverForChallenge(f.Issuer, f.Challenge)
+	if err != nil {
+		t.Fatalf("expected solverFor to not error, but got: %s", err)
+	}
+
+	expectedCall := []fakeDNSProviderCall{
+		{
+			name: "hetzner",
+			args: []interface{}{"FAKE-TOKEN", 300},
+		},
+	}
+
+	if !reflect.DeepEqual(expectedCall, f.dnsProviders.calls) {
+		t.Fatalf("expected %+v == %+v", expectedCall, f.dnsProviders.calls)
+	}
+}
+
+func TestSolveForCloudFlareAPIToken(t *testing.T) {
+	f := &solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				newSecret("cloudflare", "default", map[string][]byte{
+					"api-token": []byte("FAKE-TOKEN\n"),
+				}),
+			},
+		},
+		Issuer: newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{
+			{
+				Name: "fake-cloudflare",
+				Cloudflare: &v1alpha1.ACMEIssuerDNS01ProviderCloudflare{
+					APIToken: &v1alpha1.SecretKeySelector{
+						LocalObjectReference: v1alpha1.LocalObjectReference{
+							Name: "cloudflare",
+						},
+						Key: "api-token",
+					},
+				},
+			},
+		}),
+		Challenge: &v1alpha1.Challenge{
+			Spec: v1alpha1.ChallengeSpec{
+				Config: v1alpha1.SolverConfig{
+					DNS01: &v1alpha1.DNS01SolverConfig{
+						Provider: "fake-cloudflare",
+					},
+				},
+			},
+		},
+		dnsProviders: newFakeDNSProviders(),
+	}
+
+	f.Setup(t)
+	defer f.Finish(t)
+
+	s := f.Solver
+	_, _, err := s.solverForChallenge(f.Issuer, f.Challenge)
+	if err != nil {
+		t.Fatalf("expected solverFor to not error, but got: %s", err)
+	}
+
+	expectedCall := []fakeDNSProviderCall{
+		{
+			name: "cloudflare",
+			args: []interface{}{"", "", "FAKE-TOKEN", 0, util.RecursiveNameservers},
+		},
+	}
+
+	if !reflect.DeepEqual(expectedCall, f.dnsProviders.calls) {
+		t.Fatalf("expected %+v == %+v", expectedCall, f.dnsProviders.calls)
+	}
+}
+
+func TestSolverProviderTTL(t *testing.T) {
+	digitalOcean := &v1alpha1.ACMEIssuerDNS01ProviderDigitalOcean{
+		Token: v1alpha1.SecretKeySelector{
+			LocalObjectReference: v1alpha1.LocalObjectReference{Name: "dns"},
+			Key:                  "token",
+		},
+	}
+	hetzner := &v1alpha1.ACMEIssuerDNS01ProviderHetzner{
+		APIToken: v1alpha1.SecretKeySelector{
+			LocalObjectReference: v1alpha1.LocalObjectReference{Name: "dns"},
+			Key:                  "token",
+		},
+	}
+
+	tests := map[string]struct {
+		provider     v1alpha1.ACMEIssuerDNS01Provider
+		expectedCall fakeDNSProviderCall
+	}{
+		"passes the provider ttl to the provider": {
+			provider: v1alpha1.ACMEIssuerDNS01Provider{
+				TTL:          30,
+				DigitalOcean: digitalOcean,
+			},
+			expectedCall: fakeDNSProviderCall{
+				name: "digitalocean",
+				args: []interface{}{"FAKE-TOKEN", 30, util.RecursiveNameservers},
+			},
+		},
+		"passes the provider ttl to hetzner": {
+			provider: v1alpha1.ACMEIssuerDNS01Provider{
+				TTL:     30,
+				Hetzner: hetzner,
+			},
+			expectedCall: fakeDNSProviderCall{
+				name: "hetzner",
+				args: []interface{}{"FAKE-TOKEN", 30},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.provider.Name = "fake-provider"
+			f := &solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						newSecret("dns", "default", map[string][]byte{
+							"token": []byte("FAKE-TOKEN"),
+						}),
+					},
+				},
+				Issuer: newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{tt.provider}),
+				Challenge: &v1alpha1.Challenge{
+					Spec: v1alpha1.ChallengeSpec{
+						Config: v1alpha1.SolverConfig{
+							DNS01: &v1alpha1.DNS01SolverConfig{
+								Provider: "fake-provider",
+							},
+						},
+					},
+				},
+				dnsProviders: newFakeDNSProviders(),
+			}
+
+			f.Setup(t)
+			defer f.Finish(t)
+
+			_, _, err := f.Solver.solverForChallenge(f.Issuer, f.Challenge)
+			if err != nil {
+				t.Fatalf("expected solverFor to not error, but got: %s", err)
+			}
+
+			expectedCalls := []fakeDNSProviderCall{tt.expectedCall}
+			if !reflect.DeepEqual(expectedCalls, f.dnsProviders.calls) {
+				t.Fatalf("expected %+v == %+v", expectedCalls, f.dnsProviders.calls)
+			}
+		})
+	}
+}
+
 func TestRoute53TrimCreds(t *testing.T) {
 	f := &solverFixture{
 		Builder: &test.Builder{
@@ -368,7 +561,59 @@ func TestRoute53TrimCreds(t *testing.T) {
 	expectedR53Call := []fakeDNSProviderCall{
 		{
 			name: "route53",
-			args: []interface{}{"test_with_spaces", "AKIENDINNEWLINE", "", "us-west-2", false, util.RecursiveNameservers},
+			args: []interface{}{"test_with_spaces", "AKIENDINNEWLINE", "", "us-west-2", "", "", false, 0, util.RecursiveNameservers},
+		},
+	}
+
+	if !reflect.DeepEqual(expectedR53Call, f.dnsProviders.calls) {
+		t.Fatalf("expected %+v == %+v", expectedR53Call, f.dnsProviders.calls)
+	}
+}
+
+func TestRoute53AssumeRole(t *testing.T) {
+	f := &solverFixture{
+		Builder: &test.Builder{
+			Context: &controller.Context{
+				IssuerOptions: controller.IssuerOptions{
+					IssuerAmbientCredentials: true,
+				},
+			},
+		},
+		Issuer: newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{
+			{
+				Name: "fake-route53",
+				Route53: &v1alpha1.ACMEIssuerDNS01ProviderRoute53{
+					Region:     "us-west-2",
+					Role:       "arn:aws:iam::123456789012:role/dns",
+					ExternalID: "my-external-id",
+				},
+			},
+		}),
+		Challenge: &v1alpha1.Challenge{
+			Spec: v1alpha1.ChallengeSpec{
+				Config: v1alpha1.SolverConfig{
+					DNS01: &v1alpha1.DNS01SolverConfig{
+						Provider: "fake-route53",
+					},
+				},
+			},
+		},
+		dnsProviders: newFakeDNSProviders(),
+	}
+
+	f.Setup(t)
+	defer f.Finish(t)
+
+	s := f.Solver
+	_, _, err := s.solverForChallenge(f.Issuer, f.Challenge)
+	if err != nil {
+		t.Fatalf("expected solverFor to not error, but got: %s", err)
+	}
+
+	expectedR53Call := []fakeDNSProviderCall{
+		{
+			name: "route53",
+			args: []interface{}{"", "", "", "us-west-2", "arn:aws:iam::123456789012:role/dns", "my-external-id", true, 0, util.RecursiveNameservers},
 		},
 	}
 
@@ -418,7 +663,7 @@ func TestRoute53AmbientCreds(t *testing.T) {
 			result{
 				expectedCall: &fakeDNSProviderCall{
 					name: "route53",
-					args: []interface{}{"", "", "", "us-west-2", true, util.RecursiveNameservers},
+					args: []interface{}{"", "", "", "us-west-2", "", "", true, 0, util.RecursiveNameservers},
 				},
 			},
 		},
@@ -453,7 +698,7 @@ func TestRoute53AmbientCreds(t *testing.T) {
 			result{
 				expectedCall: &fakeDNSProviderCall{
 					name: "route53",
-					args: []interface{}{"", "", "", "us-west-2", false, util.RecursiveNameservers},
+					args: []interface{}{"", "", "", "us-west-2", "", "", false, 0, util.RecursiveNameservers},
 				},
 			},
 		},
@@ -476,3 +721,237 @@ func TestRoute53AmbientCreds(t *testing.T) {
 		}
 	}
 }
+
+func TestAzureDNSManagedIdentity(t *testing.T) {
+	tests := map[string]struct {
+		ambient      bool
+		expectedCall *fakeDNSProviderCall
+		expectErr    bool
+	}{
+		"uses the managed identity if ambient credentials are enabled": {
+			ambient: true,
+			expectedCall: &fakeDNSProviderCall{
+				name: "azurednsmanagedidentity",
+				args: []interface{}{"an-identity-client-id", "a-subscription", "a-resource-group", "example.com", 0, util.RecursiveNameservers},
+			},
+		},
+		"fails if ambient credentials are disabled": {
+			ambient:   false,
+			expectErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &solverFixture{
+				Builder: &test.Builder{
+					Context: &controller.Context{
+						IssuerOptions: controller.IssuerOptions{
+							IssuerAmbientCredentials: tt.ambient,
+						},
+					},
+				},
+				Issuer: newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{
+					{
+						Name: "fake-azuredns",
+						AzureDNS: &v1alpha1.ACMEIssuerDNS01ProviderAzureDNS{
+							SubscriptionID:    "a-subscription",
+							ResourceGroupName: "a-resource-group",
+							HostedZoneName:    "example.com",
+							ManagedIdentity: &v1alpha1.AzureManagedIdentity{
+								ClientID: "an-identity-client-id",
+							},
+						},
+					},
+				}),
+				dnsProviders: newFakeDNSProviders(),
+				Challenge: &v1alpha1.Challenge{
+					Spec: v1alpha1.ChallengeSpec{
+						Config: v1alpha1.SolverConfig{
+							DNS01: &v1alpha1.DNS01SolverConfig{
+								Provider: "fake-azuredns",
+							},
+						},
+					},
+				},
+			}
+
+			f.Setup(t)
+			defer f.Finish(t)
+
+			_, _, err := f.Solver.solverForChallenge(f.Issuer, f.Challenge)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error=%v, got: %v", tt.expectErr, err)
+			}
+
+			if tt.expectedCall == nil {
+				if len(f.dnsProviders.calls) > 0 {
+					t.Fatalf("expected no provider to be constructed, got %+v", f.dnsProviders.calls)
+				}
+				return
+			}
+			if !reflect.DeepEqual([]fakeDNSProviderCall{*tt.expectedCall}, f.dnsProviders.calls) {
+				t.Fatalf("expected %+v == %+v", []fakeDNSProviderCall{*tt.expectedCall}, f.dnsProviders.calls)
+			}
+		})
+	}
+}
+
+func TestWebhookProvider(t *testing.T) {
+	config := &apiext.JSON{Raw: []byte(`{"apiKey":"abc"}`)}
+	f := &solverFixture{
+		Builder: &test.Builder{
+			Context: &controller.Context{
+				IssuerOptions: controller.IssuerOptions{
+					IssuerAmbientCredentials: true,
+				},
+			},
+		},
+		Issuer: newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{
+			{
+				Name: "fake-webhook",
+				Webhook: &v1alpha1.ACMEIssuerDNS01ProviderWebhook{
+					GroupName:  "acme.example.com",
+					SolverName: "example",
+					Config:     config,
+				},
+			},
+		}),
+		dnsProviders: newFakeDNSProviders(),
+		Challenge: &v1alpha1.Challenge{
+			Spec: v1alpha1.ChallengeSpec{
+				Config: v1alpha1.SolverConfig{
+					DNS01: &v1alpha1.DNS01SolverConfig{
+						Provider: "fake-webhook",
+					},
+				},
+			},
+		},
+	}
+
+	f.Setup(t)
+	defer f.Finish(t)
+
+	_, _, err := f.Solver.solverForChallenge(f.Issuer, f.Challenge)
+	if err != nil {
+		t.Fatalf("expected solverFor to not error, but got: %s", err)
+	}
+
+	expectedCalls := []fakeDNSProviderCall{
+		{
+			name: "webhook",
+			args: []interface{}{"acme.example.com", "example", config, "default", true, 0, util.RecursiveNameservers},
+		},
+	}
+	if !reflect.DeepEqual(expectedCalls, f.dnsProviders.calls) {
+		t.Fatalf("expected %+v == %+v", expectedCalls, f.dnsProviders.calls)
+	}
+}
+
+func TestSolverIssuerRecursiveNameservers(t *testing.T) {
+	globalNameservers := []string{"8.8.8.8:53"}
+	issuerNameservers := []string{"10.0.0.53:53"}
+
+	tests := map[string]struct {
+		issuerNameservers []string
+		expected          []string
+	}{
+		"uses the global nameservers if the issuer does not set any": {
+			expected: globalNameservers,
+		},
+		"uses the issuer nameservers if set": {
+			issuerNameservers: issuerNameservers,
+			expected:          issuerNameservers,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			issuer := newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{
+				{
+					Name: "fake-acmedns",
+					AcmeDNS: &v1alpha1.ACMEIssuerDNS01ProviderAcmeDNS{
+						Host: "http://127.0.0.1/",
+						AccountSecret: v1alpha1.SecretKeySelector{
+							LocalObjectReference: v1alpha1.LocalObjectReference{
+								Name: "acmedns",
+							},
+							Key: "acmedns.json",
+						},
+					},
+				},
+			})
+			issuer.Spec.ACME.DNS01RecursiveNameservers = tt.issuerNameservers
+
+			f := &solverFixture{
+				Builder: &test.Builder{
+					Context: &controller.Context{
+						ACMEOptions: controller.ACMEOptions{
+							DNS01Nameservers: globalNameservers,
+						},
+					},
+					KubeObjects: []runtime.Object{
+						newSecret("acmedns", "default", map[string][]byte{
+							"acmedns.json": []byte("{}"),
+						}),
+					},
+				},
+				Issuer: issuer,
+				Challenge: &v1alpha1.Challenge{
+					Spec: v1alpha1.ChallengeSpec{
+						Config: v1alpha1.SolverConfig{
+							DNS01: &v1alpha1.DNS01SolverConfig{
+								Provider: "fake-acmedns",
+							},
+						},
+					},
+				},
+				dnsProviders: newFakeDNSProviders(),
+			}
+
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if _, _, err := f.Solver.solverForChallenge(f.Issuer, f.Challenge); err != nil {
+				t.Fatalf("expected solverFor to not error, but got: %s", err)
+			}
+
+			expectedCall := []fakeDNSProviderCall{
+				{
+					name: "acmedns",
+					args: []interface{}{"http://127.0.0.1/", []byte("{}"), tt.expected},
+				},
+			}
+			if !reflect.DeepEqual(expectedCall, f.dnsProviders.calls) {
+				t.Fatalf("expected %+v == %+v", expectedCall, f.dnsProviders.calls)
+			}
+		})
+	}
+}
+
+func TestCheckSkipDNS01PropagationCheck(t *testing.T) {
+	issuer := newIssuer("test", "default", []v1alpha1.ACMEIssuerDNS01Provider{})
+	// point the self check at an address nothing listens on, so that the
+	// check would fail if it were actually performed
+	issuer.Spec.ACME.DNS01RecursiveNameservers = []string{"127.0.0.1:1"}
+	issuer.Spec.ACME.SkipDNS01PropagationCheck = true
+
+	f := &solverFixture{
+		Builder:      &test.Builder{},
+		Issuer:       issuer,
+		dnsProviders: newFakeDNSProviders(),
+	}
+
+	f.Setup(t)
+	defer f.Finish(t)
+
+	ch := &v1alpha1.Challenge{
+		Spec: v1alpha1.ChallengeSpec{
+			DNSName: "example.com",
+			Key:     "key",
+		},
+	}
+	if err := f.Solver.Check(context.TODO(), f.Issuer, ch); err != nil {
+		t.Fatalf("expected Check to succeed when the propagation check is skipped, but got: %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hetzner implements a DNS provider for solving the DNS-01
+// challenge using Hetzner DNS.
+package hetzner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	pkgutil "github.com/jetstack/cert-manager/pkg/util"
+)
+
+// HetznerAPIURL represents the API endpoint to call.
+const HetznerAPIURL = "https://dns.hetzner.com/api/v1"
+
+// recordsPerPage is the number of records requested per page when listing
+// the records of a zone.
+const recordsPerPage = 100
+
+// DNSProvider is an implementation of the acme.ChallengeProvider interface
+type DNSProvider struct {
+	apiToken string
+	ttl      int
+
+	// baseURL and client may be overridden in tests
+	baseURL string
+	client  *http.Client
+}
+
+// NewDNSProvider returns a DNSProvider instance configured for Hetzner DNS.
+// The API token must be passed in the environment variable HETZNER_API_TOKEN.
+func NewDNSProvider() (*DNSProvider, error) {
+	token := os.Getenv("HETZNER_API_TOKEN")
+	return NewDNSProviderCredentials(token, 0)
+}
+
+// NewDNSProviderCredentials uses the supplied credentials to return a
+// DNSProvider instance configured for Hetzner DNS. If ttl is zero, a default
+// TTL of 60 seconds will be used for TXT records.
+func NewDNSProviderCredentials(token string, ttl int) (*DNSProvider, error) {
+	if token == "" {
+		return nil, fmt.Errorf("Hetzner API token missing")
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("invalid Hetzner TTL: %d", ttl)
+	}
+	if ttl == 0 {
+		ttl = util.DefaultTTL
+	}
+
+	return &DNSProvider{
+		apiToken: token,
+		ttl:      ttl,
+		baseURL:  HetznerAPIURL,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// Present creates a TXT record to fulfil the dns-01 challenge
+func (c *DNSProvider) Present(domain, fqdn, value string) error {
+	zone, err := c.findZone(fqdn)
+	if err != nil {
+		return err
+	}
+
+	name := recordName(fqdn, zone.Name)
+	records, err := c.findTxtRecords(zone.ID, name)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if record.Value == value {
+			// the record is already set to the desired value
+			return nil
+		}
+	}
+
+	rec := hetznerRecord{
+		Type:   "TXT",
+		Name:   name,
+		Value:  value,
+		TTL:    c.ttl,
+		ZoneID: zone.ID,
+	}
+
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.makeRequest("POST", "/records", bytes.NewReader(body))
+	return err
+}
+
+// CleanUp removes the TXT record matching the specified parameters
+func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
+	zone, err := c.findZone(fqdn)
+	if err != nil {
+		return err
+	}
+
+	records, err := c.findTxtRecords(zone.ID, recordName(fqdn, zone.Name))
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.Value != value {
+			continue
+		}
+		_, err = c.makeRequest("DELETE", "/records/"+url.PathEscape(record.ID), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var errZoneNotFound = errors.New("zone not found")
+
+// findZone determines the Hetzner DNS zone that the given fqdn belongs to by
+// querying the Hetzner API for each parent domain of fqdn in turn, starting
+// with the longest.
+func (c *DNSProvider) findZone(fqdn string) (*hetznerZone, error) {
+	name := util.UnFqdn(fqdn)
+	for _, index := range dns.Split(name) {
+		candidate := name[index:]
+		zone, err := c.getZone(candidate)
+		if err == errZoneNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return zone, nil
+	}
+
+	return nil, fmt.Errorf("Zone for domain %s not found in Hetzner DNS", fqdn)
+}
+
+func (c *DNSProvider) getZone(name string) (*hetznerZone, error) {
+	result, err := c.makeRequest("GET", "/zones?name="+url.QueryEscape(name), nil)
+	// the Hetzner API responds with a 404 when filtering zones by a name
+	// that does not exist
+	if apiErr, ok := err.(*apiError); ok && apiErr.statusCode == http.StatusNotFound {
+		return nil, errZoneNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Zones []hetznerZone `json:"zones"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, zone := range resp.Zones {
+		if zone.Name == name {
+			return &zone, nil
+		}
+	}
+
+	return nil, errZoneNotFound
+}
+
+// findTxtRecords returns the TXT records with the given name in a zone. The
+// Hetzner API cannot filter records by name, so every page of the zone's
+// records is fetched and filtered here.
+func (c *DNSProvider) findTxtRecords(zoneID, name string) ([]hetznerRecord, error) {
+	var records []hetznerRecord
+	for page := 1; ; page++ {
+		uri := fmt.Sprintf("/records?zone_id=%s&page=%d&per_page=%d", url.QueryEscape(zoneID), page, recordsPerPage)
+		result, err := c.makeRequest("GET", uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp struct {
+			Records []hetznerRecord `json:"records"`
+			Meta    struct {
+				Pagination struct {
+					LastPage int `json:"last_page"`
+				} `json:"pagination"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(result, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, rec := range resp.Records {
+			if rec.Type == "TXT" && rec.Name == name {
+				records = append(records, rec)
+			}
+		}
+
+		if page >= resp.Meta.Pagination.LastPage || len(resp.Records) == 0 {
+			return records, nil
+		}
+	}
+}
+
+func (c *DNSProvider) makeRequest(method, uri string, body io.Reader) (json.RawMessage, error) {
+	req, err := http.NewRequest(method, c.baseURL+uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Auth-API-Token", c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", pkgutil.CertManagerUserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying Hetzner API -> %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var r struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		// the error body is informational only, so failures to decode it
+		// are ignored
+		json.Unmarshal(data, &r)
+		return nil, &apiError{statusCode: resp.StatusCode, message: r.Error.Message}
+	}
+
+	return data, nil
+}
+
+// apiError is returned when the Hetzner API responds with a non-2xx status
+type apiError struct {
+	statusCode int
+	message    string
+}
+
+func (e *apiError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("Hetzner API error: unexpected status code %d", e.statusCode)
+	}
+	return fmt.Sprintf("Hetzner API error: %d: %s", e.statusCode, e.message)
+}
+
+// recordName returns the name of the record for fqdn relative to zone, as
+// expected by the Hetzner API.
+func recordName(fqdn, zone string) string {
+	name := util.UnFqdn(fqdn)
+	if name == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// hetznerZone represents a Hetzner DNS zone
+type hetznerZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// hetznerRecord represents a Hetzner DNS record
+type hetznerRecord struct {
+	ID     string `json:"id,omitempty"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	TTL    int    `json:"ttl,omitempty"`
+	ZoneID string `json:"zone_id"`
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+)
+
+// fakeHetznerAPI is a minimal in-memory implementation of the Hetzner DNS API
+type fakeHetznerAPI struct {
+	lock    sync.Mutex
+	token   string
+	zones   []hetznerZone
+	records []hetznerRecord
+	nextID  int
+}
+
+func (f *fakeHetznerAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Header.Get("Auth-API-Token") != f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"invalid token","code":401}}`))
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/zones":
+		name := r.URL.Query().Get("name")
+		for _, z := range f.zones {
+			if z.Name == name {
+				json.NewEncoder(w).Encode(map[string][]hetznerZone{"zones": {z}})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"message":"zone not found","code":404}}`))
+	case r.Method == "GET" && r.URL.Path == "/records":
+		zoneID := r.URL.Query().Get("zone_id")
+		records := []hetznerRecord{}
+		for _, rec := range f.records {
+			if rec.ZoneID == zoneID {
+				records = append(records, rec)
+			}
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if page < 1 || perPage < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lastPage := (len(records) + perPage - 1) / perPage
+		if lastPage == 0 {
+			lastPage = 1
+		}
+		start := (page - 1) * perPage
+		if start > len(records) {
+			start = len(records)
+		}
+		end := start + perPage
+		if end > len(records) {
+			end = len(records)
+		}
+		resp := map[string]interface{}{
+			"records": records[start:end],
+			"meta": map[string]interface{}{
+				"pagination": map[string]int{
+					"page":          page,
+					"per_page":      perPage,
+					"last_page":     lastPage,
+					"total_entries": len(records),
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	case r.Method == "POST" && r.URL.Path == "/records":
+		var rec hetznerRecord
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		f.nextID++
+		rec.ID = fmt.Sprintf("record-%d", f.nextID)
+		f.records = append(f.records, rec)
+		json.NewEncoder(w).Encode(map[string]hetznerRecord{"record": rec})
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/records/"):
+		id := strings.TrimPrefix(r.URL.Path, "/records/")
+		for i, rec := range f.records {
+			if rec.ID == id {
+				f.records = append(f.records[:i], f.records[i+1:]...)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestProvider(t *testing.T, api *fakeHetznerAPI, token string, ttl int) (*DNSProvider, func()) {
+	server := httptest.NewServer(api)
+	provider, err := NewDNSProviderCredentials(token, ttl)
+	if err != nil {
+		t.Fatalf("error creating provider: %v", err)
+	}
+	provider.baseURL = server.URL
+	provider.client = server.Client()
+	return provider, server.Close
+}
+
+func TestNewDNSProviderMissingCredErr(t *testing.T) {
+	_, err := NewDNSProviderCredentials("", 0)
+	assert.EqualError(t, err, "Hetzner API token missing")
+}
+
+func TestNewDNSProviderDefaultTTL(t *testing.T) {
+	provider, err := NewDNSProviderCredentials("123", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, util.DefaultTTL, provider.ttl)
+}
+
+func TestNewDNSProviderInvalidTTL(t *testing.T) {
+	_, err := NewDNSProviderCredentials("123", -1)
+	assert.Error(t, err)
+}
+
+func TestHetznerPresentAndCleanUp(t *testing.T) {
+	api := &fakeHetznerAPI{
+		token: "token",
+		zones: []hetznerZone{{ID: "zone-1", Name: "example.com"}},
+	}
+	provider, stop := newTestProvider(t, api, "token", 300)
+	defer stop()
+
+	err := provider.Present("sub.example.com", "_acme-challenge.sub.example.com.", "123d==")
+	assert.NoError(t, err)
+
+	if assert.Len(t, api.records, 1) {
+		rec := api.records[0]
+		assert.Equal(t, "TXT", rec.Type)
+		assert.Equal(t, "_acme-challenge.sub", rec.Name)
+		assert.Equal(t, "123d==", rec.Value)
+		assert.Equal(t, 300, rec.TTL)
+		assert.Equal(t, "zone-1", rec.ZoneID)
+	}
+
+	// presenting the same record again should not create a duplicate
+	err = provider.Present("sub.example.com", "_acme-challenge.sub.example.com.", "123d==")
+	assert.NoError(t, err)
+	assert.Len(t, api.records, 1)
+
+	err = provider.CleanUp("sub.example.com", "_acme-challenge.sub.example.com.", "123d==")
+	assert.NoError(t, err)
+	assert.Len(t, api.records, 0)
+}
+
+func TestHetznerCleanUpOnlyRemovesMatchingValue(t *testing.T) {
+	api := &fakeHetznerAPI{
+		token: "token",
+		zones: []hetznerZone{{ID: "zone-1", Name: "example.com"}},
+		records: []hetznerRecord{
+			{ID: "other", Type: "TXT", Name: "_acme-challenge", Value: "other", ZoneID: "zone-1"},
+		},
+	}
+	provider, stop := newTestProvider(t, api, "token", 0)
+	defer stop()
+
+	err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.NoError(t, err)
+	assert.Len(t, api.records, 2)
+
+	err = provider.CleanUp("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.NoError(t, err)
+	if assert.Len(t, api.records, 1) {
+		assert.Equal(t, "other", api.records[0].ID)
+	}
+}
+
+func TestHetznerCleanUpFindsRecordsOnLaterPages(t *testing.T) {
+	api := &fakeHetznerAPI{
+		token: "token",
+		zones: []hetznerZone{{ID: "zone-1", Name: "example.com"}},
+	}
+	for i := 0; i < 2*recordsPerPage; i++ {
+		api.records = append(api.records, hetznerRecord{
+			ID:     fmt.Sprintf("other-%d", i),
+			Type:   "A",
+			Name:   fmt.Sprintf("host-%d", i),
+			Value:  "1.2.3.4",
+			ZoneID: "zone-1",
+		})
+	}
+	provider, stop := newTestProvider(t, api, "token", 0)
+	defer stop()
+
+	err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.NoError(t, err)
+	assert.Len(t, api.records, 2*recordsPerPage+1)
+
+	// presenting the same record again should find it on the last page
+	err = provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.NoError(t, err)
+	assert.Len(t, api.records, 2*recordsPerPage+1)
+
+	err = provider.CleanUp("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.NoError(t, err)
+	assert.Len(t, api.records, 2*recordsPerPage)
+}
+
+func TestHetznerZoneNotFound(t *testing.T) {
+	api := &fakeHetznerAPI{
+		token: "token",
+		zones: []hetznerZone{{ID: "zone-1", Name: "example.com"}},
+	}
+	provider, stop := newTestProvider(t, api, "token", 0)
+	defer stop()
+
+	err := provider.Present("example.org", "_acme-challenge.example.org.", "123d==")
+	assert.Error(t, err)
+	assert.Len(t, api.records, 0)
+}
+
+func TestHetznerInvalidToken(t *testing.T) {
+	api := &fakeHetznerAPI{
+		token: "token",
+		zones: []hetznerZone{{ID: "zone-1", Name: "example.com"}},
+	}
+	provider, stop := newTestProvider(t, api, "wrong", 0)
+	defer stop()
+
+	err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+	assert.EqualError(t, err, "Hetzner API error: 401: invalid token")
+}
+
+func TestRecordName(t *testing.T) {
+	assert.Equal(t, "_acme-challenge", recordName("_acme-challenge.example.com.", "example.com"))
+	assert.Equal(t, "_acme-challenge.a.b", recordName("_acme-challenge.a.b.example.com.", "example.com"))
+	assert.Equal(t, "@", recordName("example.com.", "example.com"))
+}
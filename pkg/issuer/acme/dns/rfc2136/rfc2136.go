@@ -43,6 +43,13 @@ var supportedAlgorithms = map[string]string{
 	"HMACSHA512": dns.HmacSHA512,
 }
 
+// unsupportedAlgorithms are TSIG algorithms listed in RFC 4635 that cannot be
+// used as they are not implemented by miekg/dns.
+var unsupportedAlgorithms = map[string]bool{
+	"HMACSHA224": true,
+	"HMACSHA384": true,
+}
+
 // Returns a slice of all the supported algorithms
 // It should contain all listed in https://tools.ietf.org/html/rfc4635#section-2
 // but miekd/dns supports only supportedAlgorithms(keys)
@@ -56,6 +63,29 @@ func GetSupportedAlgorithms() []string {
 	return strkeys
 }
 
+// TSIGAlgorithm returns the miekg/dns name of the given TSIG algorithm.
+// Algorithms may be given either as one of the values returned by
+// GetSupportedAlgorithms, or by their RFC 4635 name (e.g. hmac-sha256).
+// Names are matched case-insensitively.
+func TSIGAlgorithm(algorithm string) (string, error) {
+	name := strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(algorithm), "."))
+	if name == strings.ToUpper(strings.TrimSuffix(dns.HmacMD5, ".")) {
+		return dns.HmacMD5, nil
+	}
+
+	key := strings.Replace(name, "-", "", -1)
+	if value, ok := supportedAlgorithms[key]; ok {
+		return value, nil
+	}
+	if unsupportedAlgorithms[key] {
+		return "", fmt.Errorf("The TSIG algorithm '%v' is not supported by this version of cert-manager. Supported values are: %s",
+			algorithm, strings.Join(GetSupportedAlgorithms(), ", "))
+	}
+
+	return "", fmt.Errorf("The TSIG algorithm '%v' is not a known algorithm. Supported values are: %s",
+		algorithm, strings.Join(GetSupportedAlgorithms(), ", "))
+}
+
 // This function make a valid nameserver as per RFC2136
 func ValidNameserver(nameserver string) (string, error) {
 
@@ -104,6 +134,7 @@ type DNSProvider struct {
 	tsigAlgorithm    string
 	tsigKeyName      string
 	tsigSecret       string
+	ttl              int
 	dns01Nameservers []string
 }
 
@@ -120,16 +151,20 @@ func NewDNSProvider(dns01Nameservers []string) (*DNSProvider, error) {
 	tsigAlgorithm := os.Getenv("RFC2136_TSIG_ALGORITHM")
 	tsigKeyName := os.Getenv("RFC2136_TSIG_KEY_NAME")
 	tsigSecret := os.Getenv("RFC2136_TSIG_SECRET")
-	return NewDNSProviderCredentials(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret, dns01Nameservers)
+	return NewDNSProviderCredentials(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret, 0, dns01Nameservers)
 }
 
 // NewDNSProviderCredentials uses the supplied credentials to return a
 // DNSProvider instance configured for rfc2136 dynamic update. To disable TSIG
 // authentication, leave the TSIG parameters as empty strings.
 // nameserver must be a network address in the form "IP" or "IP:port".
-func NewDNSProviderCredentials(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret string, dns01Nameservers []string) (*DNSProvider, error) {
+// If ttl is zero, a default TTL of 60 seconds will be used for TXT records.
+func NewDNSProviderCredentials(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret string, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
 
-	d := &DNSProvider{}
+	d := &DNSProvider{ttl: ttl}
+	if d.ttl == 0 {
+		d.ttl = util.DefaultTTL
+	}
 
 	if validNameserver, err := ValidNameserver(nameserver); err != nil {
 		return nil, err
@@ -145,12 +180,11 @@ func NewDNSProviderCredentials(nameserver, tsigAlgorithm, tsigKeyName, tsigSecre
 	if tsigAlgorithm == "" {
 		tsigAlgorithm = dns.HmacMD5
 	} else {
-		if value, ok := supportedAlgorithms[strings.ToUpper(tsigAlgorithm)]; ok {
-			tsigAlgorithm = value
-		} else {
-			return nil, fmt.Errorf("The algorithm '%v' is not supported", tsigAlgorithm)
-
+		value, err := TSIGAlgorithm(tsigAlgorithm)
+		if err != nil {
+			return nil, err
 		}
+		tsigAlgorithm = value
 	}
 	d.tsigAlgorithm = tsigAlgorithm
 
@@ -166,12 +200,12 @@ func (r *DNSProvider) Timeout() (timeout, interval time.Duration) {
 
 // Present creates a TXT record using the specified parameters
 func (r *DNSProvider) Present(domain, fqdn, value string) error {
-	return r.changeRecord("INSERT", fqdn, value, 60)
+	return r.changeRecord("INSERT", fqdn, value, r.ttl)
 }
 
 // CleanUp removes the TXT record matching the specified parameters
 func (r *DNSProvider) CleanUp(domain, fqdn, value string) error {
-	return r.changeRecord("REMOVE", fqdn, value, 60)
+	return r.changeRecord("REMOVE", fqdn, value, r.ttl)
 }
 
 func (r *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
@@ -192,10 +226,12 @@ func (r *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 	m.SetUpdate(zone)
 	switch action {
 	case "INSERT":
-		// Always remove old challenge left over from who knows what.
-		m.RemoveRRset(rrs)
+		// Other challenges for the same name (e.g. for both a domain and
+		// its wildcard) may be in progress, so only add our own value to
+		// the RRset rather than replacing it.
 		m.Insert(rrs)
 	case "REMOVE":
+		// Only remove the record with our value, leaving any others in place.
 		m.Remove(rrs)
 	default:
 		return fmt.Errorf("Unexpected action: %s", action)
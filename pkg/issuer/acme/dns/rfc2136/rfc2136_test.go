@@ -81,7 +81,7 @@ func TestRFC2136ServerSuccess(t *testing.T) {
 	}
 	defer server.Shutdown()
 
-	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", 0, util.RecursiveNameservers)
 	if err != nil {
 		t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
 	}
@@ -100,7 +100,7 @@ func TestRFC2136ServerError(t *testing.T) {
 	}
 	defer server.Shutdown()
 
-	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", 0, util.RecursiveNameservers)
 	if err != nil {
 		t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
 	}
@@ -121,7 +121,7 @@ func TestRFC2136TsigClient(t *testing.T) {
 	}
 	defer server.Shutdown()
 
-	provider, err := NewDNSProviderCredentials(addrstr, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(addrstr, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	if err != nil {
 		t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
 	}
@@ -131,23 +131,23 @@ func TestRFC2136TsigClient(t *testing.T) {
 }
 
 func TestRFC2136InvalidNameserverFQDN(t *testing.T) {
-	_, err := NewDNSProviderCredentials("nameserver.com", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("nameserver.com", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
 func TestRFC2136InvalidNameserverFQDNWithPort(t *testing.T) {
-	_, err := NewDNSProviderCredentials("nameserver.com:53", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("nameserver.com:53", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
 func TestRFC2136InvalidNameserverFQDNWithPort2(t *testing.T) {
-	_, err := NewDNSProviderCredentials("nameserver.com:", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("nameserver.com:", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
 func TestRFC2136NamserverWithoutPort(t *testing.T) {
 	nameserver := "127.0.0.1"
-	dnsProvider, err := NewDNSProviderCredentials(nameserver, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	dnsProvider, err := NewDNSProviderCredentials(nameserver, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	if dnsProvider.nameserver != nameserver+":"+defaultPort {
@@ -158,7 +158,7 @@ func TestRFC2136NamserverWithoutPort(t *testing.T) {
 
 func TestRFC2136NamserverWithoutPort2(t *testing.T) {
 	nameserver := "127.0.0.1:"
-	dnsProvider, err := NewDNSProviderCredentials(nameserver, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	dnsProvider, err := NewDNSProviderCredentials(nameserver, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	if dnsProvider.nameserver != nameserver+defaultPort {
@@ -168,7 +168,7 @@ func TestRFC2136NamserverWithoutPort2(t *testing.T) {
 
 func TestRFC2136NamserverWithPort(t *testing.T) {
 	nameserver := "127.0.0.1:12345"
-	dnsProvider, err := NewDNSProviderCredentials(nameserver, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	dnsProvider, err := NewDNSProviderCredentials(nameserver, "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.NoError(t, err)
 
 	if dnsProvider.nameserver != nameserver {
@@ -177,36 +177,252 @@ func TestRFC2136NamserverWithPort(t *testing.T) {
 }
 
 func TestRFC2136NamserverWithPortNoIP(t *testing.T) {
-	_, err := NewDNSProviderCredentials(":53", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials(":53", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
 func TestRFC2136NamserverEmpty(t *testing.T) {
-	_, err := NewDNSProviderCredentials("", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
 func TestRFC2136NamserverIPInvalid(t *testing.T) {
-	_, err := NewDNSProviderCredentials("900.65.3.64", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("900.65.3.64", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
 func TestRFC2136NamserverIPInvalid2(t *testing.T) {
-	_, err := NewDNSProviderCredentials(":", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials(":", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 func TestRFC2136DefaultTSIGAlgorithm(t *testing.T) {
-	provider, err := NewDNSProviderCredentials("127.0.0.1:0", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials("127.0.0.1:0", "", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	if err != nil {
 		assert.Equal(t, provider.tsigAlgorithm, dns.HmacMD5, "Default TSIG must match")
 	}
 }
 
 func TestRFC2136InvalidTSIGAlgorithm(t *testing.T) {
-	_, err := NewDNSProviderCredentials("127.0.0.1:0", "HAMMOCK", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, util.RecursiveNameservers)
+	_, err := NewDNSProviderCredentials("127.0.0.1:0", "HAMMOCK", rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, util.RecursiveNameservers)
 	assert.Error(t, err)
 }
 
+func TestRFC2136TSIGAlgorithm(t *testing.T) {
+	tests := map[string]string{
+		"HMACMD5":                  dns.HmacMD5,
+		"hmac-md5":                 dns.HmacMD5,
+		"hmac-md5.sig-alg.reg.int": dns.HmacMD5,
+		"HMACSHA1":                 dns.HmacSHA1,
+		"hmac-sha1":                dns.HmacSHA1,
+		"HMACSHA256":               dns.HmacSHA256,
+		"hmac-sha256":              dns.HmacSHA256,
+		"HMAC-SHA256.":             dns.HmacSHA256,
+		"HMACSHA512":               dns.HmacSHA512,
+		"hmac-sha512":              dns.HmacSHA512,
+	}
+	for algorithm, expected := range tests {
+		t.Run(algorithm, func(t *testing.T) {
+			actual, err := TSIGAlgorithm(algorithm)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestRFC2136TSIGAlgorithmErrors(t *testing.T) {
+	_, err := TSIGAlgorithm("hmac-sha384")
+	assert.EqualError(t, err, "The TSIG algorithm 'hmac-sha384' is not supported by this version of cert-manager. "+
+		"Supported values are: HMACMD5, HMACSHA1, HMACSHA256, HMACSHA512")
+
+	_, err = TSIGAlgorithm("HAMMOCK")
+	assert.EqualError(t, err, "The TSIG algorithm 'HAMMOCK' is not a known algorithm. "+
+		"Supported values are: HMACMD5, HMACSHA1, HMACSHA256, HMACSHA512")
+}
+
+func TestRFC2136TsigClientAlgorithms(t *testing.T) {
+	algorithms := []string{"hmac-md5", "hmac-sha1", "hmac-sha256", "hmac-sha512"}
+	for _, algorithm := range algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			expected, err := TSIGAlgorithm(algorithm)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var received []string
+			var lock sync.Mutex
+			dns.HandleFunc(rfc2136TestZone, func(w dns.ResponseWriter, req *dns.Msg) {
+				m := new(dns.Msg)
+				m.SetReply(req)
+				if req.Opcode == dns.OpcodeQuery && req.Question[0].Qtype == dns.TypeSOA {
+					soaRR, _ := dns.NewRR(fmt.Sprintf("%s %d IN SOA ns1.%s admin.%s 2016022801 28800 7200 2419200 1200", rfc2136TestZone, rfc2136TestTTL, rfc2136TestZone, rfc2136TestZone))
+					m.Answer = []dns.RR{soaRR}
+				}
+				if tsig := req.IsTsig(); tsig != nil {
+					if w.TsigStatus() != nil {
+						m.SetRcode(req, dns.RcodeNotAuth)
+					} else {
+						lock.Lock()
+						received = append(received, tsig.Algorithm)
+						lock.Unlock()
+						m.SetTsig(rfc2136TestTsigKeyName, tsig.Algorithm, 300, time.Now().Unix())
+					}
+				}
+				w.WriteMsg(m)
+			})
+			defer dns.HandleRemove(rfc2136TestZone)
+
+			server, addrstr, err := runLocalDNSTestServer("127.0.0.1:0", true)
+			if err != nil {
+				t.Fatalf("Failed to start test server: %v", err)
+			}
+			defer server.Shutdown()
+
+			provider, err := NewDNSProviderCredentials(addrstr, algorithm, rfc2136TestTsigKeyName, rfc2136TestTsigSecret, 0, []string{addrstr})
+			if err != nil {
+				t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
+			}
+			if err := provider.Present(rfc2136TestDomain, rfc2136TestFqdn, rfc2136TestKeyAuth); err != nil {
+				t.Fatalf("Expected Present() to return no error but the error was -> %v", err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if len(received) != 1 || received[0] != expected {
+				t.Errorf("Expected server to receive an update signed with %q, but got %v", expected, received)
+			}
+		})
+	}
+}
+
+func TestRFC2136TTL(t *testing.T) {
+	tests := map[string]struct {
+		ttl         int
+		expectedTTL uint32
+	}{
+		"uses the default TTL if none is configured": {
+			expectedTTL: 60,
+		},
+		"uses the configured TTL": {
+			ttl:         300,
+			expectedTTL: 300,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var received []uint32
+			var lock sync.Mutex
+			dns.HandleFunc(rfc2136TestZone, func(w dns.ResponseWriter, req *dns.Msg) {
+				m := new(dns.Msg)
+				m.SetReply(req)
+				if req.Opcode == dns.OpcodeQuery && req.Question[0].Qtype == dns.TypeSOA {
+					soaRR, _ := dns.NewRR(fmt.Sprintf("%s %d IN SOA ns1.%s admin.%s 2016022801 28800 7200 2419200 1200", rfc2136TestZone, rfc2136TestTTL, rfc2136TestZone, rfc2136TestZone))
+					m.Answer = []dns.RR{soaRR}
+				}
+				if req.Opcode == dns.OpcodeUpdate {
+					lock.Lock()
+					for _, rr := range req.Ns {
+						// records being inserted have the class INET,
+						// whereas removed record sets have the class ANY
+						if rr.Header().Rrtype == dns.TypeTXT && rr.Header().Class == dns.ClassINET {
+							received = append(received, rr.Header().Ttl)
+						}
+					}
+					lock.Unlock()
+				}
+				w.WriteMsg(m)
+			})
+			defer dns.HandleRemove(rfc2136TestZone)
+
+			server, addrstr, err := runLocalDNSTestServer("127.0.0.1:0", false)
+			if err != nil {
+				t.Fatalf("Failed to start test server: %v", err)
+			}
+			defer server.Shutdown()
+
+			provider, err := NewDNSProviderCredentials(addrstr, "", "", "", tt.ttl, []string{addrstr})
+			if err != nil {
+				t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
+			}
+			if err := provider.Present(rfc2136TestDomain, rfc2136TestFqdn, rfc2136TestKeyAuth); err != nil {
+				t.Fatalf("Expected Present() to return no error but the error was -> %v", err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if len(received) != 1 || received[0] != tt.expectedTTL {
+				t.Errorf("Expected server to receive a TXT record with TTL %d, but got %v", tt.expectedTTL, received)
+			}
+		})
+	}
+}
+
+func TestRFC2136OverlappingChallenges(t *testing.T) {
+	// records holds the TXT values stored by the test server
+	records := map[string]bool{}
+	var lock sync.Mutex
+	dns.HandleFunc(rfc2136TestZone, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if req.Opcode == dns.OpcodeQuery && req.Question[0].Qtype == dns.TypeSOA {
+			soaRR, _ := dns.NewRR(fmt.Sprintf("%s %d IN SOA ns1.%s admin.%s 2016022801 28800 7200 2419200 1200", rfc2136TestZone, rfc2136TestTTL, rfc2136TestZone, rfc2136TestZone))
+			m.Answer = []dns.RR{soaRR}
+		}
+		if req.Opcode == dns.OpcodeUpdate {
+			lock.Lock()
+			for _, rr := range req.Ns {
+				if rr.Header().Rrtype != dns.TypeTXT {
+					continue
+				}
+				switch rr.Header().Class {
+				case dns.ClassINET:
+					records[strings.Join(rr.(*dns.TXT).Txt, "")] = true
+				case dns.ClassNONE:
+					delete(records, strings.Join(rr.(*dns.TXT).Txt, ""))
+				case dns.ClassANY:
+					records = map[string]bool{}
+				}
+			}
+			lock.Unlock()
+		}
+		w.WriteMsg(m)
+	})
+	defer dns.HandleRemove(rfc2136TestZone)
+
+	server, addrstr, err := runLocalDNSTestServer("127.0.0.1:0", false)
+	if err != nil {
+		t.Fatalf("Failed to start test server: %v", err)
+	}
+	defer server.Shutdown()
+
+	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", 0, []string{addrstr})
+	if err != nil {
+		t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
+	}
+
+	// both the apex and wildcard challenges use the same record name
+	domain := "www.example.com"
+	fqdn := "_acme-challenge.www.example.com."
+	if err := provider.Present(domain, fqdn, "apex-value"); err != nil {
+		t.Fatalf("Expected Present() to return no error but the error was -> %v", err)
+	}
+	if err := provider.Present("*."+domain, fqdn, "wildcard-value"); err != nil {
+		t.Fatalf("Expected Present() to return no error but the error was -> %v", err)
+	}
+
+	lock.Lock()
+	assert.Equal(t, map[string]bool{"apex-value": true, "wildcard-value": true}, records)
+	lock.Unlock()
+
+	if err := provider.CleanUp(domain, fqdn, "apex-value"); err != nil {
+		t.Fatalf("Expected CleanUp() to return no error but the error was -> %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, map[string]bool{"wildcard-value": true}, records)
+}
+
 func TestRFC2136ValidUpdatePacket(t *testing.T) {
 	dns.HandleFunc(rfc2136TestZone, serverHandlerPassBackRequest)
 	defer dns.HandleRemove(rfc2136TestZone)
@@ -221,7 +437,6 @@ func TestRFC2136ValidUpdatePacket(t *testing.T) {
 	rrs := []dns.RR{txtRR}
 	m := new(dns.Msg)
 	m.SetUpdate(rfc2136TestZone)
-	m.RemoveRRset(rrs)
 	m.Insert(rrs)
 	//expectstr := m.String()
 	//expect, err := m.Pack()
@@ -229,7 +444,7 @@ func TestRFC2136ValidUpdatePacket(t *testing.T) {
 		t.Fatalf("Error packing expect msg: %v", err)
 	}
 
-	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", util.RecursiveNameservers)
+	provider, err := NewDNSProviderCredentials(addrstr, "", "", "", 0, util.RecursiveNameservers)
 	if err != nil {
 		t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
 	}
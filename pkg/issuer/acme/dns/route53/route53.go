@@ -20,18 +20,22 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
-	"github.com/golang/glog"
+	"github.com/aws/aws-sdk-go/service/sts"
 
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	pkgutil "github.com/jetstack/cert-manager/pkg/util"
 )
 
 const (
 	maxRetries = 5
-	route53TTL = 10
+
+	// defaultTTL is the TTL used for TXT records if none is configured.
+	defaultTTL = 10
 )
 
 // DNSProvider implements the util.ChallengeProvider interface
@@ -39,6 +43,7 @@ type DNSProvider struct {
 	dns01Nameservers []string
 	client           *route53.Route53
 	hostedZoneID     string
+	ttl              int
 }
 
 // customRetryer implements the client.Retryer interface by composing the
@@ -63,10 +68,19 @@ func (d customRetryer) RetryRules(r *request.Request) time.Duration {
 	return time.Duration(delay) * time.Millisecond
 }
 
+// newSTSClient constructs the STS client used to assume roles. It may be
+// overridden in tests.
+var newSTSClient = func(p client.ConfigProvider, cfgs ...*aws.Config) stscreds.AssumeRoler {
+	return sts.New(p, cfgs...)
+}
+
 // NewDNSProvider returns a DNSProvider instance configured for the AWS
 // Route 53 service using static credentials from its parameters or, if they're
 // unset and the 'ambient' option is set, credentials from the environment.
-func NewDNSProvider(accessKeyID, secretAccessKey, hostedZoneID, region string, ambient bool, dns01Nameservers []string) (*DNSProvider, error) {
+// If role is set, those credentials are used to assume the given role (with
+// the optional externalID) and the role's credentials are used for Route 53.
+// If ttl is zero, a default TTL of 10 seconds will be used for TXT records.
+func NewDNSProvider(accessKeyID, secretAccessKey, hostedZoneID, region, role, externalID string, ambient bool, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
 	if accessKeyID == "" && secretAccessKey == "" {
 		if !ambient {
 			return nil, fmt.Errorf("unable to construct route53 provider: empty credentials; perhaps you meant to enable ambient credentials?")
@@ -75,6 +89,13 @@ func NewDNSProvider(accessKeyID, secretAccessKey, hostedZoneID, region string, a
 		// It's always an error to set one of those but not the other
 		return nil, fmt.Errorf("unable to construct route53 provider: only one of access and secret key was provided")
 	}
+	if externalID != "" && role == "" {
+		return nil, fmt.Errorf("unable to construct route53 provider: an external ID may only be used when assuming a role")
+	}
+
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
 
 	useAmbientCredentials := ambient && (accessKeyID == "" && secretAccessKey == "")
 
@@ -84,12 +105,12 @@ func NewDNSProvider(accessKeyID, secretAccessKey, hostedZoneID, region string, a
 	sessionOpts := session.Options{}
 
 	if useAmbientCredentials {
-		glog.V(5).Infof("using ambient credentials")
+		logs.V(5).Infof("using ambient credentials")
 		// Leaving credentials unset results in a default credential chain being
 		// used; this chain is a reasonable default for getting ambient creds.
 		// https://docs.aws.amazon.com/sdk-for-go/v1/developer-guide/configuring-sdk.html#specifying-credentials
 	} else {
-		glog.V(5).Infof("not using ambient credentials")
+		logs.V(5).Infof("not using ambient credentials")
 		config.WithCredentials(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""))
 		// also disable 'ambient' region sources
 		sessionOpts.SharedConfigState = session.SharedConfigDisable
@@ -105,34 +126,115 @@ func NewDNSProvider(accessKeyID, secretAccessKey, hostedZoneID, region string, a
 		return nil, fmt.Errorf("unable to create aws session: %s", err)
 	}
 	sess.Handlers.Build.PushBack(request.WithAppendUserAgent(pkgutil.CertManagerUserAgent))
+
+	if role != "" {
+		logs.V(5).Infof("assuming role %q", role)
+		// the STS client uses the base credentials configured above
+		stsClient := newSTSClient(sess, config.Copy())
+		config.WithCredentials(stscreds.NewCredentialsWithClient(stsClient, role, func(p *stscreds.AssumeRoleProvider) {
+			if externalID != "" {
+				p.ExternalID = aws.String(externalID)
+			}
+		}))
+	}
+
 	client := route53.New(sess, config)
 
 	return &DNSProvider{
 		client:           client,
 		hostedZoneID:     hostedZoneID,
+		ttl:              ttl,
 		dns01Nameservers: dns01Nameservers,
 	}, nil
 }
 
-// Present creates a TXT record using the specified parameters
+// Present creates a TXT record using the specified parameters. If a TXT
+// record set already exists for fqdn (e.g. when solving challenges for both
+// a domain and its wildcard), the value is added to it rather than replacing
+// it.
 func (r *DNSProvider) Present(domain, fqdn, value string) error {
 	value = `"` + value + `"`
-	return r.changeRecord(route53.ChangeActionUpsert, fqdn, value, route53TTL)
+
+	hostedZoneID, err := r.getHostedZoneID(fqdn)
+	if err != nil {
+		return fmt.Errorf("Failed to determine Route 53 hosted zone ID: %v", err)
+	}
+
+	existing, err := r.findTXTRecordSet(hostedZoneID, fqdn)
+	if err != nil {
+		return err
+	}
+
+	values := []string{value}
+	if existing != nil {
+		values = recordValues(existing)
+		if containsValue(values, value) {
+			logs.V(5).Infof("TXT record %q already contains value %s", fqdn, value)
+			return nil
+		}
+		values = append(values, value)
+	}
+
+	return r.changeRecord(hostedZoneID, route53.ChangeActionUpsert, newTXTRecordSet(fqdn, values, r.ttl))
 }
 
-// CleanUp removes the TXT record matching the specified parameters
+// CleanUp removes the TXT record matching the specified parameters. Other
+// values in the same record set are left in place.
 func (r *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	value = `"` + value + `"`
-	return r.changeRecord(route53.ChangeActionDelete, fqdn, value, route53TTL)
-}
 
-func (r *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 	hostedZoneID, err := r.getHostedZoneID(fqdn)
 	if err != nil {
 		return fmt.Errorf("Failed to determine Route 53 hosted zone ID: %v", err)
 	}
 
-	recordSet := newTXTRecordSet(fqdn, value, ttl)
+	existing, err := r.findTXTRecordSet(hostedZoneID, fqdn)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		logs.V(5).Infof("TXT record %q not found, nothing to clean up", fqdn)
+		return nil
+	}
+
+	var remaining []string
+	for _, v := range recordValues(existing) {
+		if v != value {
+			remaining = append(remaining, v)
+		}
+	}
+
+	if len(remaining) == 0 {
+		// the record set being deleted must exactly match the existing one
+		return r.changeRecord(hostedZoneID, route53.ChangeActionDelete, existing)
+	}
+
+	return r.changeRecord(hostedZoneID, route53.ChangeActionUpsert, newTXTRecordSet(fqdn, remaining, int(aws.Int64Value(existing.TTL))))
+}
+
+// findTXTRecordSet returns the TXT record set with the given name, or nil if
+// no such record set exists.
+func (r *DNSProvider) findTXTRecordSet(hostedZoneID, fqdn string) (*route53.ResourceRecordSet, error) {
+	resp, err := r.client.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(fqdn),
+		StartRecordType: aws.String(route53.RRTypeTxt),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list Route 53 record sets: %v", err)
+	}
+
+	for _, rs := range resp.ResourceRecordSets {
+		if aws.StringValue(rs.Type) == route53.RRTypeTxt && strings.EqualFold(util.ToFqdn(aws.StringValue(rs.Name)), util.ToFqdn(fqdn)) {
+			return rs, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *DNSProvider) changeRecord(hostedZoneID, action string, recordSet *route53.ResourceRecordSet) error {
 	reqParams := &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: aws.String(hostedZoneID),
 		ChangeBatch: &route53.ChangeBatch{
@@ -150,7 +252,7 @@ func (r *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 	if err != nil {
 		if awserr, ok := err.(awserr.Error); ok {
 			if action == route53.ChangeActionDelete && awserr.Code() == route53.ErrCodeInvalidChangeBatch {
-				glog.V(5).Infof("ignoring InvalidChangeBatch error: %v", err)
+				logs.V(5).Infof("ignoring InvalidChangeBatch error: %v", err)
 				// If we try to delete something and get a 'InvalidChangeBatch' that
 				// means it's already deleted, no need to consider it an error.
 				return nil
@@ -216,13 +318,32 @@ func (r *DNSProvider) getHostedZoneID(fqdn string) (string, error) {
 	return hostedZoneID, nil
 }
 
-func newTXTRecordSet(fqdn, value string, ttl int) *route53.ResourceRecordSet {
+func newTXTRecordSet(fqdn string, values []string, ttl int) *route53.ResourceRecordSet {
+	records := make([]*route53.ResourceRecord, len(values))
+	for i, v := range values {
+		records[i] = &route53.ResourceRecord{Value: aws.String(v)}
+	}
 	return &route53.ResourceRecordSet{
-		Name: aws.String(fqdn),
-		Type: aws.String(route53.RRTypeTxt),
-		TTL:  aws.Int64(int64(ttl)),
-		ResourceRecords: []*route53.ResourceRecord{
-			{Value: aws.String(value)},
-		},
+		Name:            aws.String(fqdn),
+		Type:            aws.String(route53.RRTypeTxt),
+		TTL:             aws.Int64(int64(ttl)),
+		ResourceRecords: records,
+	}
+}
+
+func recordValues(rs *route53.ResourceRecordSet) []string {
+	values := make([]string, 0, len(rs.ResourceRecords))
+	for _, rr := range rs.ResourceRecords {
+		values = append(values, aws.StringValue(rr.Value))
+	}
+	return values
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+	return false
 }
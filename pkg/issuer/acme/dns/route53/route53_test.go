@@ -9,14 +9,19 @@ this directory.
 package route53
 
 import (
+	"errors"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
@@ -50,7 +55,7 @@ func makeRoute53Provider(ts *httptest.Server) *DNSProvider {
 	}
 
 	client := route53.New(session.New(config))
-	return &DNSProvider{client: client, dns01Nameservers: util.RecursiveNameservers}
+	return &DNSProvider{client: client, ttl: defaultTTL, dns01Nameservers: util.RecursiveNameservers}
 }
 
 func TestAmbientCredentialsFromEnv(t *testing.T) {
@@ -59,7 +64,7 @@ func TestAmbientCredentialsFromEnv(t *testing.T) {
 	os.Setenv("AWS_REGION", "us-east-1")
 	defer restoreRoute53Env()
 
-	provider, err := NewDNSProvider("", "", "", "", true, util.RecursiveNameservers)
+	provider, err := NewDNSProvider("", "", "", "", "", "", true, 0, util.RecursiveNameservers)
 	assert.NoError(t, err, "Expected no error constructing DNSProvider")
 
 	_, err = provider.client.Config.Credentials.Get()
@@ -73,7 +78,7 @@ func TestNoCredentialsFromEnv(t *testing.T) {
 	os.Setenv("AWS_REGION", "us-east-1")
 	defer restoreRoute53Env()
 
-	_, err := NewDNSProvider("", "", "", "", false, util.RecursiveNameservers)
+	_, err := NewDNSProvider("", "", "", "", "", "", false, 0, util.RecursiveNameservers)
 	assert.Error(t, err, "Expected error constructing DNSProvider with no credentials and not ambient")
 }
 
@@ -81,7 +86,7 @@ func TestAmbientRegionFromEnv(t *testing.T) {
 	os.Setenv("AWS_REGION", "us-east-1")
 	defer restoreRoute53Env()
 
-	provider, err := NewDNSProvider("", "", "", "", true, util.RecursiveNameservers)
+	provider, err := NewDNSProvider("", "", "", "", "", "", true, 0, util.RecursiveNameservers)
 	assert.NoError(t, err, "Expected no error constructing DNSProvider")
 
 	assert.Equal(t, "us-east-1", *provider.client.Config.Region, "Expected Region to be set from environment")
@@ -91,15 +96,201 @@ func TestNoRegionFromEnv(t *testing.T) {
 	os.Setenv("AWS_REGION", "us-east-1")
 	defer restoreRoute53Env()
 
-	provider, err := NewDNSProvider("marx", "swordfish", "", "", false, util.RecursiveNameservers)
+	provider, err := NewDNSProvider("marx", "swordfish", "", "", "", "", false, 0, util.RecursiveNameservers)
 	assert.NoError(t, err, "Expected no error constructing DNSProvider")
 
 	assert.Equal(t, "", *provider.client.Config.Region, "Expected Region to not be set from environment")
 }
 
+// fakeSTS is a fake implementation of the STS AssumeRole API
+type fakeSTS struct {
+	err    error
+	inputs []*sts.AssumeRoleInput
+	// baseCredentials are the credentials the STS client was constructed with
+	baseCredentials *credentials.Credentials
+}
+
+func (f *fakeSTS) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	f.inputs = append(f.inputs, input)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sts.AssumeRoleOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("assumed-key"),
+			SecretAccessKey: aws.String("assumed-secret"),
+			SessionToken:    aws.String("assumed-token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
+
+func withFakeSTS(f *fakeSTS) func() {
+	old := newSTSClient
+	newSTSClient = func(p client.ConfigProvider, cfgs ...*aws.Config) stscreds.AssumeRoler {
+		for _, cfg := range cfgs {
+			if cfg.Credentials != nil {
+				f.baseCredentials = cfg.Credentials
+			}
+		}
+		return f
+	}
+	return func() { newSTSClient = old }
+}
+
+func TestAssumeRole(t *testing.T) {
+	f := &fakeSTS{}
+	defer withFakeSTS(f)()
+
+	provider, err := NewDNSProvider("marx", "swordfish", "", "us-east-1", "arn:aws:iam::123456789012:role/dns", "my-external-id", false, 0, util.RecursiveNameservers)
+	assert.NoError(t, err, "Expected no error constructing DNSProvider")
+
+	creds, err := provider.client.Config.Credentials.Get()
+	assert.NoError(t, err, "Expected credentials to be retrieved from STS")
+	assert.Equal(t, "assumed-key", creds.AccessKeyID)
+	assert.Equal(t, "assumed-secret", creds.SecretAccessKey)
+	assert.Equal(t, "assumed-token", creds.SessionToken)
+
+	if assert.Len(t, f.inputs, 1) {
+		assert.Equal(t, "arn:aws:iam::123456789012:role/dns", *f.inputs[0].RoleArn)
+		assert.Equal(t, "my-external-id", *f.inputs[0].ExternalId)
+	}
+
+	// the static credentials should be used to call STS
+	if assert.NotNil(t, f.baseCredentials) {
+		base, err := f.baseCredentials.Get()
+		assert.NoError(t, err)
+		assert.Equal(t, "marx", base.AccessKeyID)
+		assert.Equal(t, "swordfish", base.SecretAccessKey)
+	}
+}
+
+func TestAssumeRoleWithoutExternalID(t *testing.T) {
+	f := &fakeSTS{}
+	defer withFakeSTS(f)()
+
+	provider, err := NewDNSProvider("marx", "swordfish", "", "us-east-1", "arn:aws:iam::123456789012:role/dns", "", false, 0, util.RecursiveNameservers)
+	assert.NoError(t, err, "Expected no error constructing DNSProvider")
+
+	_, err = provider.client.Config.Credentials.Get()
+	assert.NoError(t, err)
+	if assert.Len(t, f.inputs, 1) {
+		assert.Nil(t, f.inputs[0].ExternalId)
+	}
+}
+
+func TestAssumeRoleError(t *testing.T) {
+	f := &fakeSTS{err: errors.New("access denied")}
+	defer withFakeSTS(f)()
+
+	provider, err := NewDNSProvider("marx", "swordfish", "", "us-east-1", "arn:aws:iam::123456789012:role/dns", "", false, 0, util.RecursiveNameservers)
+	assert.NoError(t, err, "Expected no error constructing DNSProvider")
+
+	_, err = provider.client.Config.Credentials.Get()
+	assert.Error(t, err, "Expected an error if the role cannot be assumed")
+}
+
+func TestExternalIDWithoutRole(t *testing.T) {
+	_, err := NewDNSProvider("marx", "swordfish", "", "us-east-1", "", "my-external-id", false, 0, util.RecursiveNameservers)
+	assert.Error(t, err, "Expected an error if an external ID is set without a role")
+}
+
+func TestAssumeRolePresent(t *testing.T) {
+	f := &fakeSTS{}
+	defer withFakeSTS(f)()
+
+	mockResponses := MockResponseMap{
+		"/2013-04-01/hostedzone/ABCDEFG/rrset":  MockResponse{StatusCode: 200, Body: ListResourceRecordSetsResponse},
+		"/2013-04-01/hostedzone/ABCDEFG/rrset/": MockResponse{StatusCode: 200, Body: ChangeResourceRecordSetsResponse},
+		"/2013-04-01/change/123456":             MockResponse{StatusCode: 200, Body: GetChangeResponse},
+	}
+
+	ts := newMockServer(t, mockResponses)
+	defer ts.Close()
+
+	// the hosted zone ID is set to avoid looking up the zone in DNS
+	provider, err := NewDNSProvider("marx", "swordfish", "ABCDEFG", "mock-region", "arn:aws:iam::123456789012:role/dns", "", false, 0, util.RecursiveNameservers)
+	assert.NoError(t, err, "Expected no error constructing DNSProvider")
+	provider.client.Config.Endpoint = aws.String(ts.URL)
+	provider.client.Endpoint = ts.URL
+
+	domain := "example.com"
+	keyAuth := "123456d=="
+
+	err = provider.Present(domain, "_acme-challenge."+domain+".", keyAuth)
+	assert.NoError(t, err, "Expected Present to return no error")
+	assert.Len(t, f.inputs, 1, "Expected role to be assumed before calling Route 53")
+}
+
+func TestRoute53TTL(t *testing.T) {
+	tests := map[string]struct {
+		ttl         int
+		expectedTTL string
+	}{
+		"uses the default TTL if none is configured": {
+			expectedTTL: "<TTL>10</TTL>",
+		},
+		"uses the configured TTL": {
+			ttl:         300,
+			expectedTTL: "<TTL>300</TTL>",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeRoute53(t)
+			ts := httptest.NewServer(fake)
+			defer ts.Close()
+
+			provider, err := NewDNSProvider("marx", "swordfish", "ABCDEFG", "mock-region", "", "", false, tt.ttl, util.RecursiveNameservers)
+			assert.NoError(t, err)
+			provider.client.Endpoint = ts.URL
+
+			assert.NoError(t, provider.Present("example.com", "_acme-challenge.example.com.", "123d=="))
+			assert.NoError(t, provider.CleanUp("example.com", "_acme-challenge.example.com.", "123d=="))
+
+			// the TTL of the deleted record must match the one created
+			if assert.Len(t, fake.changes, 2) {
+				assert.Contains(t, fake.changes[0], tt.expectedTTL)
+				assert.Contains(t, fake.changes[1], tt.expectedTTL)
+			}
+		})
+	}
+}
+
+func TestRoute53OverlappingChallenges(t *testing.T) {
+	fake := newFakeRoute53(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	provider, err := NewDNSProvider("marx", "swordfish", "ABCDEFG", "mock-region", "", "", false, 0, util.RecursiveNameservers)
+	assert.NoError(t, err)
+	provider.client.Endpoint = ts.URL
+
+	// both the apex and wildcard challenges use the same record name
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "apex"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "wildcard"))
+	assert.Equal(t, []string{`"apex"`, `"wildcard"`}, fake.records[fqdn].Values)
+
+	// presenting the same value again should not duplicate it
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "wildcard"))
+	assert.Equal(t, []string{`"apex"`, `"wildcard"`}, fake.records[fqdn].Values)
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "apex"))
+	assert.Equal(t, []string{`"wildcard"`}, fake.records[fqdn].Values)
+
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "wildcard"))
+	assert.NotContains(t, fake.records, fqdn)
+
+	// cleaning up a record that no longer exists is not an error
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "wildcard"))
+}
+
 func TestRoute53Present(t *testing.T) {
 	mockResponses := MockResponseMap{
 		"/2013-04-01/hostedzonesbyname":         MockResponse{StatusCode: 200, Body: ListHostedZonesByNameResponse},
+		"/2013-04-01/hostedzone/ABCDEFG/rrset":  MockResponse{StatusCode: 200, Body: ListResourceRecordSetsResponse},
 		"/2013-04-01/hostedzone/ABCDEFG/rrset/": MockResponse{StatusCode: 200, Body: ChangeResourceRecordSetsResponse},
 		"/2013-04-01/change/123456":             MockResponse{StatusCode: 200, Body: GetChangeResponse},
 	}
@@ -9,9 +9,13 @@ this directory.
 package route53
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -44,3 +48,103 @@ func newMockServer(t *testing.T, responses MockResponseMap) *httptest.Server {
 	time.Sleep(100 * time.Millisecond)
 	return ts
 }
+
+// fakeRecordSet is a TXT record set stored by fakeRoute53
+type fakeRecordSet struct {
+	TTL    int64
+	Values []string
+}
+
+// fakeRoute53 is a minimal in-memory implementation of the Route 53 API for
+// the ABCDEFG hosted zone, supporting listing and changing TXT record sets.
+type fakeRoute53 struct {
+	t *testing.T
+
+	lock    sync.Mutex
+	records map[string]fakeRecordSet
+	// changes contains the body of every change request received
+	changes []string
+}
+
+type changeResourceRecordSetsRequest struct {
+	Changes []struct {
+		Action            string `xml:"Action"`
+		ResourceRecordSet struct {
+			Name   string   `xml:"Name"`
+			TTL    int64    `xml:"TTL"`
+			Values []string `xml:"ResourceRecords>ResourceRecord>Value"`
+		} `xml:"ResourceRecordSet"`
+	} `xml:"ChangeBatch>Changes>Change"`
+}
+
+func newFakeRoute53(t *testing.T) *fakeRoute53 {
+	return &fakeRoute53{t: t, records: map[string]fakeRecordSet{}}
+}
+
+func (f *fakeRoute53) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/xml")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzone/ABCDEFG/rrset":
+		f.listRecordSets(w, r.URL.Query().Get("name"))
+	case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/hostedzone/ABCDEFG/rrset/":
+		f.changeRecordSets(w, r)
+	case r.URL.Path == "/2013-04-01/change/123456":
+		w.Write([]byte(GetChangeResponse))
+	default:
+		f.t.Errorf("unexpected request %s %q", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeRoute53) listRecordSets(w http.ResponseWriter, name string) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><ResourceRecordSets>`)
+	if rs, ok := f.records[name]; ok {
+		fmt.Fprintf(&buf, "<ResourceRecordSet><Name>%s</Name><Type>TXT</Type><TTL>%d</TTL><ResourceRecords>", name, rs.TTL)
+		for _, v := range rs.Values {
+			buf.WriteString("<ResourceRecord><Value>")
+			xml.EscapeText(&buf, []byte(v))
+			buf.WriteString("</Value></ResourceRecord>")
+		}
+		buf.WriteString("</ResourceRecords></ResourceRecordSet>")
+	}
+	buf.WriteString(`</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>1</MaxItems></ListResourceRecordSetsResponse>`)
+	w.Write(buf.Bytes())
+}
+
+func (f *fakeRoute53) changeRecordSets(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	f.changes = append(f.changes, string(body))
+
+	var req changeResourceRecordSetsRequest
+	if err := xml.Unmarshal(body, &req); err != nil {
+		f.t.Errorf("error decoding change request: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, c := range req.Changes {
+		rs := c.ResourceRecordSet
+		switch c.Action {
+		case "UPSERT":
+			f.records[rs.Name] = fakeRecordSet{TTL: rs.TTL, Values: rs.Values}
+		case "DELETE":
+			// Route 53 requires deleted record sets to match exactly
+			existing, ok := f.records[rs.Name]
+			if !ok || existing.TTL != rs.TTL || fmt.Sprint(existing.Values) != fmt.Sprint(rs.Values) {
+				f.t.Errorf("deleted record set %+v does not match existing record set %+v", rs, existing)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			delete(f.records, rs.Name)
+		default:
+			f.t.Errorf("unexpected change action %q", c.Action)
+		}
+	}
+
+	w.Write([]byte(ChangeResourceRecordSetsResponse))
+}
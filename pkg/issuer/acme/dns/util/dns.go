@@ -10,25 +10,58 @@ package util
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 )
 
-// DNS01Record returns a DNS record which will fulfill the `dns-01` challenge
+// DefaultTTL is the TTL, in seconds, of the TXT records created to solve
+// challenges by providers that do not define their own default.
+const DefaultTTL = 60
+
+// DNS01Record returns a DNS record which will fulfill the `dns-01` challenge.
+// CNAME records are looked up with the given timeout for each DNS query.
 // TODO: move this into a non-generic place by resolving import cycle in dns package
-func DNS01Record(domain, value string, nameservers []string, followCNAME bool) (string, string, int, error) {
+func DNS01Record(domain, value string, nameservers []string, followCNAME bool, timeout time.Duration) (string, string, int, error) {
 	fqdn := fmt.Sprintf("_acme-challenge.%s.", domain)
 
 	// Check if the domain has CNAME then return that
 	if followCNAME {
-		r, err := dnsQuery(fqdn, dns.TypeCNAME, nameservers, true)
-		if err == nil && r.Rcode == dns.RcodeSuccess {
-			fqdn = updateDomainWithCName(r, fqdn)
-		}
+		var err error
+		fqdn, err = followCNAMEs(fqdn, nameservers, timeout)
 		if err != nil {
 			return "", "", 0, err
 		}
 	}
 
-	return fqdn, value, 60, nil
+	return fqdn, value, DefaultTTL, nil
+}
+
+// followCNAMEs resolves the chain of CNAME records starting at fqdn and
+// returns the name at the end of the chain. If fqdn is not a CNAME it is
+// returned unchanged.
+func followCNAMEs(fqdn string, nameservers []string, timeout time.Duration) (string, error) {
+	visited := map[string]bool{strings.ToLower(fqdn): true}
+	for i := 0; i < maxCNAMEHops; i++ {
+		r, err := dnsQuery(fqdn, dns.TypeCNAME, nameservers, true, timeout)
+		if err != nil {
+			return "", err
+		}
+		if r.Rcode != dns.RcodeSuccess {
+			return fqdn, nil
+		}
+
+		target := updateDomainWithCName(r, fqdn)
+		if target == fqdn {
+			return fqdn, nil
+		}
+		if visited[strings.ToLower(target)] {
+			return "", fmt.Errorf("CNAME loop detected while resolving %q", fqdn)
+		}
+		visited[strings.ToLower(target)] = true
+		fqdn = target
+	}
+
+	return "", fmt.Errorf("CNAME chain for %q is longer than %d records", fqdn, maxCNAMEHops)
 }
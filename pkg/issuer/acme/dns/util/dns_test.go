@@ -0,0 +1,195 @@
+// +skip_license_check
+
+/*
+This file contains portions of code directly taken from the 'xenolf/lego' project.
+A copy of the license for this code can be found in the file named LICENSE in
+this directory.
+*/
+
+package util
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// fakeResolver is a recursive resolver that answers from a static set of
+// CNAME and TXT records. TXT queries for a CNAME are answered with the whole
+// CNAME chain, as a real recursive resolver would. zones maps the apex of
+// each zone to the host name of its authoritative nameserver, and is used
+// to answer SOA and NS queries.
+type fakeResolver struct {
+	cnames map[string]string
+	txts   map[string]string
+	zones  map[string]string
+}
+
+func (f *fakeResolver) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(req)
+
+	name := req.Question[0].Name
+	switch req.Question[0].Qtype {
+	case dns.TypeSOA:
+		if ns, ok := f.zones[name]; ok {
+			m.Answer = append(m.Answer, &dns.SOA{
+				Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 60},
+				Ns:     ns,
+				Mbox:   "hostmaster." + name,
+				Serial: 1,
+			})
+		}
+	case dns.TypeNS:
+		if ns, ok := f.zones[name]; ok {
+			m.Answer = append(m.Answer, &dns.NS{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 60},
+				Ns:  ns,
+			})
+		}
+	case dns.TypeCNAME:
+		if target, ok := f.cnames[name]; ok {
+			m.Answer = append(m.Answer, cnameRR(name, target))
+		}
+	case dns.TypeTXT:
+		for i := 0; i < maxCNAMEHops; i++ {
+			target, ok := f.cnames[name]
+			if !ok {
+				break
+			}
+			m.Answer = append(m.Answer, cnameRR(name, target))
+			name = target
+		}
+		if value, ok := f.txts[name]; ok {
+			m.Answer = append(m.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+				Txt: []string{value},
+			})
+		}
+	}
+	if len(m.Answer) == 0 {
+		m.SetRcode(req, dns.RcodeNameError)
+	}
+
+	w.WriteMsg(m)
+}
+
+func cnameRR(name, target string) dns.RR {
+	return &dns.CNAME{
+		Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60},
+		Target: target,
+	}
+}
+
+func runFakeResolver(t *testing.T, r *fakeResolver) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake resolver: %v", err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: r, ReadTimeout: time.Hour, WriteTimeout: time.Hour}
+
+	waitLock := sync.Mutex{}
+	waitLock.Lock()
+	server.NotifyStartedFunc = waitLock.Unlock
+
+	go func() {
+		server.ActivateAndServe()
+		pc.Close()
+	}()
+
+	waitLock.Lock()
+	return pc.LocalAddr().String(), func() { server.Shutdown() }
+}
+
+var delegatedChain = &fakeResolver{
+	cnames: map[string]string{
+		"_acme-challenge.example.com.": "_acme-challenge.example.net.",
+		"_acme-challenge.example.net.": "example-com.acme.example.org.",
+		"_acme-challenge.loop.com.":    "_acme-challenge.loop.net.",
+		"_acme-challenge.loop.net.":    "_acme-challenge.loop.com.",
+	},
+	txts: map[string]string{
+		"example-com.acme.example.org.": "token",
+	},
+}
+
+func TestDNS01RecordFollowCNAME(t *testing.T) {
+	tests := map[string]struct {
+		domain      string
+		followCNAME bool
+		fqdn        string
+		expectErr   bool
+	}{
+		"does not follow CNAMEs when disabled": {
+			domain: "example.com",
+			fqdn:   "_acme-challenge.example.com.",
+		},
+		"follows a chain of CNAMEs to its target": {
+			domain:      "example.com",
+			followCNAME: true,
+			fqdn:        "example-com.acme.example.org.",
+		},
+		"returns the record name if it is not a CNAME": {
+			domain:      "example.io",
+			followCNAME: true,
+			fqdn:        "_acme-challenge.example.io.",
+		},
+		"fails on a CNAME loop": {
+			domain:      "loop.com",
+			followCNAME: true,
+			expectErr:   true,
+		},
+	}
+
+	ns, stop := runFakeResolver(t, delegatedChain)
+	defer stop()
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fqdn, value, _, err := DNS01Record(tt.domain, "token", []string{ns}, tt.followCNAME, DefaultDNSTimeout)
+			if err != nil {
+				if !tt.expectErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.Contains(err.Error(), "loop") {
+					t.Errorf("expected a CNAME loop error, got: %v", err)
+				}
+				return
+			}
+			if tt.expectErr {
+				t.Fatalf("expected an error, got fqdn %q", fqdn)
+			}
+			if fqdn != tt.fqdn {
+				t.Errorf("expected fqdn %q, got %q", tt.fqdn, fqdn)
+			}
+			if value != "token" {
+				t.Errorf("expected value %q, got %q", "token", value)
+			}
+		})
+	}
+}
+
+func TestCheckDNSPropagationFollowsCNAMEChain(t *testing.T) {
+	ns, stop := runFakeResolver(t, delegatedChain)
+	defer stop()
+
+	ok, err := checkDNSPropagation("_acme-challenge.example.com.", "token", []string{ns}, false, DefaultDNSTimeout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("expected the TXT record at the end of the CNAME chain to be found")
+	}
+
+	ok, err = checkDNSPropagation("_acme-challenge.example.com.", "other", []string{ns}, false, DefaultDNSTimeout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Errorf("expected a TXT record with a different value not to be found")
+	}
+}
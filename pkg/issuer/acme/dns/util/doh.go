@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const dohMediaType = "application/dns-message"
+
+// dohClient is the HTTP client used to perform DNS-over-HTTPS queries.
+// It is a variable to allow it to be overridden in tests. Requests made with
+// it are bounded by the timeout passed to dohExchange.
+var dohClient = &http.Client{}
+
+// IsDoHNameserver returns true if the given nameserver is a DNS-over-HTTPS
+// endpoint, such as https://1.1.1.1/dns-query, rather than a host:port pair.
+func IsDoHNameserver(ns string) bool {
+	return strings.HasPrefix(ns, "https://")
+}
+
+// dohExchange sends the DNS query m to the DNS-over-HTTPS endpoint at url
+// using the wire format described in RFC 8484.
+func dohExchange(m *dns.Msg, url string, timeout time.Duration) (*dns.Msg, error) {
+	// the message ID should be zero when using DoH to allow responses to be
+	// cached by HTTP caches
+	m = m.Copy()
+	m.Id = 0
+
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("error packing DNS message: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dohMediaType)
+	req.Header.Set("Accept", dohMediaType)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := dohClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS server %s returned status %d", url, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DNS-over-HTTPS response from %s: %v", url, err)
+	}
+
+	in := new(dns.Msg)
+	if err := in.Unpack(body); err != nil {
+		return nil, fmt.Errorf("error unpacking DNS-over-HTTPS response from %s: %v", url, err)
+	}
+	return in, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestIsDoHNameserver(t *testing.T) {
+	tests := map[string]bool{
+		"https://1.1.1.1/dns-query": true,
+		"8.8.8.8:53":                false,
+		"[2001:4860:4860::8844]:53": false,
+		"http://1.1.1.1/dns-query":  false,
+	}
+	for ns, expected := range tests {
+		if actual := IsDoHNameserver(ns); actual != expected {
+			t.Errorf("expected IsDoHNameserver(%q) to be %t but got %t", ns, expected, actual)
+		}
+	}
+}
+
+func TestDNSQueryDoH(t *testing.T) {
+	const fqdn = "_acme-challenge.example.com."
+	const value = "token"
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dohMediaType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req := new(dns.Msg)
+		if err := req.Unpack(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		resp.Answer = append(resp.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+			Txt: []string{value},
+		})
+		packed, err := resp.Pack()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", dohMediaType)
+		w.Write(packed)
+	}))
+	defer server.Close()
+
+	oldClient := dohClient
+	dohClient = server.Client()
+	defer func() { dohClient = oldClient }()
+
+	ok, err := checkAuthoritativeNss(fqdn, value, []string{server.URL + "/dns-query"}, DefaultDNSTimeout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("expected TXT record to be found using DNS-over-HTTPS")
+	}
+}
@@ -15,12 +15,13 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/miekg/dns"
+
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 type preCheckDNSFunc func(fqdn, value string, nameservers []string,
-	useAuthoritative bool) (bool, error)
+	useAuthoritative bool, timeout time.Duration) (bool, error)
 
 var (
 	// PreCheckDNS checks DNS propagation before notifying ACME that
@@ -40,8 +41,16 @@ var defaultNameservers = []string{
 
 var RecursiveNameservers = getNameservers(defaultResolvConf, defaultNameservers)
 
-// DNSTimeout is used to override the default DNS timeout of 10 seconds.
-var DNSTimeout = 10 * time.Second
+// authoritativeNameserverAddr returns the address the authoritative
+// nameserver with the given host name is queried at. It is a variable so
+// that tests can use a local nameserver.
+var authoritativeNameserverAddr = func(ns string) string {
+	return net.JoinHostPort(ns, "53")
+}
+
+// DefaultDNSTimeout is the timeout of each DNS query made to look up the zone
+// of a record, and of DNS01 self checks when no other timeout is configured.
+const DefaultDNSTimeout = 10 * time.Second
 
 // getNameservers attempts to get systems nameservers before falling back to the defaults
 func getNameservers(path string, defaults []string) []string {
@@ -62,26 +71,58 @@ func getNameservers(path string, defaults []string) []string {
 	return systemNameservers
 }
 
-// Update FQDN with CNAME if any
+// maxCNAMEHops is the maximum number of CNAME records that will be followed
+// when resolving the target of a DNS01 challenge record.
+const maxCNAMEHops = 10
+
+// Update FQDN with CNAME if any. Resolvers may return a whole chain of CNAME
+// records in the answer section, in which case the chain is followed to its
+// final target.
 func updateDomainWithCName(r *dns.Msg, fqdn string) string {
+	for i := 0; i < maxCNAMEHops; i++ {
+		target, ok := cnameTarget(r, fqdn)
+		if !ok {
+			break
+		}
+		logs.Infof("Updating FQDN: %s with it's CNAME: %s", fqdn, target)
+		fqdn = target
+	}
+
+	return fqdn
+}
+
+// cnameTarget returns the target of the CNAME record for fqdn in the answer
+// section of r, if there is one.
+func cnameTarget(r *dns.Msg, fqdn string) (string, bool) {
 	for _, rr := range r.Answer {
 		if cn, ok := rr.(*dns.CNAME); ok {
-			if cn.Hdr.Name == fqdn {
-				glog.Infof("Updating FQDN: %s with it's CNAME: %s", fqdn, cn.Target)
-				fqdn = cn.Target
-				break
+			if strings.EqualFold(cn.Hdr.Name, fqdn) {
+				return cn.Target, true
 			}
 		}
 	}
-
-	return fqdn
+	return "", false
 }
 
 // checkDNSPropagation checks if the expected TXT record has been propagated to all authoritative nameservers.
+// If useAuthoritative is true, the record is looked up at the authoritative
+// nameservers directly, so that a negatively cached answer held by the
+// recursive nameservers does not delay the check. The recursive nameservers
+// are only used to discover the authoritative nameservers, and are checked
+// instead if the authoritative nameservers cannot be queried. Each DNS query
+// made is bounded by timeout.
 func checkDNSPropagation(fqdn, value string, nameservers []string,
-	useAuthoritative bool) (bool, error) {
+	useAuthoritative bool, timeout time.Duration) (bool, error) {
+	if useAuthoritative {
+		ok, err := checkAuthoritativePropagation(fqdn, value, nameservers, timeout)
+		if err == nil {
+			return ok, nil
+		}
+		logs.Infof("Could not check %q using its authoritative nameservers, falling back to recursive nameservers %v: %v", fqdn, nameservers, err)
+	}
+
 	// Initial attempt to resolve at the recursive NS
-	r, err := dnsQuery(fqdn, dns.TypeTXT, nameservers, true)
+	r, err := dnsQuery(fqdn, dns.TypeTXT, nameservers, true, timeout)
 	if err != nil {
 		return false, err
 	}
@@ -89,25 +130,41 @@ func checkDNSPropagation(fqdn, value string, nameservers []string,
 		fqdn = updateDomainWithCName(r, fqdn)
 	}
 
-	if !useAuthoritative {
-		return checkAuthoritativeNss(fqdn, value, nameservers)
-	}
+	return checkAuthoritativeNss(fqdn, value, nameservers, timeout)
+}
 
-	authoritativeNss, err := lookupNameservers(fqdn, nameservers)
-	if err != nil {
-		return false, err
-	}
+// checkAuthoritativePropagation checks if the expected TXT record has been
+// propagated to all authoritative nameservers of the zone containing fqdn.
+// CNAME records are followed using the authoritative nameservers of each
+// zone in the chain.
+func checkAuthoritativePropagation(fqdn, value string, nameservers []string, timeout time.Duration) (bool, error) {
+	for hop := 0; hop <= maxCNAMEHops; hop++ {
+		authoritativeNss, err := lookupNameservers(fqdn, nameservers, timeout)
+		if err != nil {
+			return false, err
+		}
+
+		for i, ans := range authoritativeNss {
+			authoritativeNss[i] = authoritativeNameserverAddr(ans)
+		}
 
-	for i, ans := range authoritativeNss {
-		authoritativeNss[i] = net.JoinHostPort(ans, "53")
+		r, err := dnsQuery(fqdn, dns.TypeTXT, authoritativeNss, false, timeout)
+		if err != nil {
+			return false, err
+		}
+		if _, ok := cnameTarget(r, fqdn); !ok {
+			return checkAuthoritativeNss(fqdn, value, authoritativeNss, timeout)
+		}
+		fqdn = updateDomainWithCName(r, fqdn)
 	}
-	return checkAuthoritativeNss(fqdn, value, authoritativeNss)
+
+	return false, fmt.Errorf("Exceeded %d CNAME hops while resolving %q", maxCNAMEHops, fqdn)
 }
 
 // checkAuthoritativeNss queries each of the given nameservers for the expected TXT record.
-func checkAuthoritativeNss(fqdn, value string, nameservers []string) (bool, error) {
+func checkAuthoritativeNss(fqdn, value string, nameservers []string, timeout time.Duration) (bool, error) {
 	for _, ns := range nameservers {
-		r, err := dnsQuery(fqdn, dns.TypeTXT, []string{ns}, true)
+		r, err := dnsQuery(fqdn, dns.TypeTXT, []string{ns}, true, timeout)
 		if err != nil {
 			return false, err
 		}
@@ -117,7 +174,7 @@ func checkAuthoritativeNss(fqdn, value string, nameservers []string) (bool, erro
 			return false, fmt.Errorf("NS %s returned %s for %s", ns, dns.RcodeToString[r.Rcode], fqdn)
 		}
 
-		glog.V(6).Infof("Looking up TXT records for %q", fqdn)
+		logs.V(6).Infof("Looking up TXT records for %q", fqdn)
 		var found bool
 		for _, rr := range r.Answer {
 			if txt, ok := rr.(*dns.TXT); ok {
@@ -138,7 +195,9 @@ func checkAuthoritativeNss(fqdn, value string, nameservers []string) (bool, erro
 
 // dnsQuery will query a nameserver, iterating through the supplied servers as it retries
 // The nameserver should include a port, to facilitate testing where we talk to a mock dns server.
-func dnsQuery(fqdn string, rtype uint16, nameservers []string, recursive bool) (in *dns.Msg, err error) {
+// Nameservers given as https:// URLs are queried using DNS-over-HTTPS. Each
+// attempt is bounded by timeout.
+func dnsQuery(fqdn string, rtype uint16, nameservers []string, recursive bool, timeout time.Duration) (in *dns.Msg, err error) {
 	m := new(dns.Msg)
 	m.SetQuestion(fqdn, rtype)
 	m.SetEdns0(4096, false)
@@ -150,13 +209,22 @@ func dnsQuery(fqdn string, rtype uint16, nameservers []string, recursive bool) (
 	// Will retry the request based on the number of servers (n+1)
 	for i := 1; i <= len(nameservers)+1; i++ {
 		ns := nameservers[i%len(nameservers)]
-		udp := &dns.Client{Net: "udp", Timeout: DNSTimeout}
+		if IsDoHNameserver(ns) {
+			in, err = dohExchange(m, ns, timeout)
+			if err == nil {
+				break
+			}
+			logs.V(6).Infof("DNS-over-HTTPS lookup failed: %v", err)
+			continue
+		}
+
+		udp := &dns.Client{Net: "udp", Timeout: timeout}
 		in, _, err = udp.Exchange(m, ns)
 
 		if err == dns.ErrTruncated ||
 			(err != nil && strings.HasPrefix(err.Error(), "read udp") && strings.HasSuffix(err.Error(), "i/o timeout")) {
-			glog.V(6).Infof("UDP dns lookup failed, retrying with TCP: %v", err)
-			tcp := &dns.Client{Net: "tcp", Timeout: DNSTimeout}
+			logs.V(6).Infof("UDP dns lookup failed, retrying with TCP: %v", err)
+			tcp := &dns.Client{Net: "tcp", Timeout: timeout}
 			// If the TCP request succeeds, the err will reset to nil
 			in, _, err = tcp.Exchange(m, ns)
 		}
@@ -169,15 +237,15 @@ func dnsQuery(fqdn string, rtype uint16, nameservers []string, recursive bool) (
 }
 
 // lookupNameservers returns the authoritative nameservers for the given fqdn.
-func lookupNameservers(fqdn string, nameservers []string) ([]string, error) {
+func lookupNameservers(fqdn string, nameservers []string, timeout time.Duration) ([]string, error) {
 	var authoritativeNss []string
 
-	zone, err := FindZoneByFqdn(fqdn, nameservers)
+	zone, err := findZoneByFqdn(fqdn, nameservers, timeout)
 	if err != nil {
 		return nil, fmt.Errorf("Could not determine the zone: %v", err)
 	}
 
-	r, err := dnsQuery(zone, dns.TypeNS, nameservers, true)
+	r, err := dnsQuery(zone, dns.TypeNS, nameservers, true, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -197,6 +265,10 @@ func lookupNameservers(fqdn string, nameservers []string) ([]string, error) {
 // FindZoneByFqdn determines the zone apex for the given fqdn by recursing up the
 // domain labels until the nameserver returns a SOA record in the answer section.
 func FindZoneByFqdn(fqdn string, nameservers []string) (string, error) {
+	return findZoneByFqdn(fqdn, nameservers, DefaultDNSTimeout)
+}
+
+func findZoneByFqdn(fqdn string, nameservers []string, timeout time.Duration) (string, error) {
 	fqdnToZoneLock.RLock()
 	// Do we have it cached?
 	if zone, ok := fqdnToZone[fqdn]; ok {
@@ -209,7 +281,7 @@ func FindZoneByFqdn(fqdn string, nameservers []string) (string, error) {
 	for _, index := range labelIndexes {
 		domain := fqdn[index:]
 
-		in, err := dnsQuery(domain, dns.TypeSOA, nameservers, true)
+		in, err := dnsQuery(domain, dns.TypeSOA, nameservers, true, timeout)
 		if err != nil {
 			return "", err
 		}
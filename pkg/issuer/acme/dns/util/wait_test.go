@@ -13,6 +13,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 var lookupNameserversTestsOK = []struct {
@@ -88,7 +89,7 @@ var checkResolvConfServersTests = []struct {
 
 func TestPreCheckDNS(t *testing.T) {
 	// TODO: find a better TXT record to use in tests
-	ok, err := PreCheckDNS("google.com.", "v=spf1 include:_spf.google.com ~all", []string{"8.8.8.8:53"}, true)
+	ok, err := PreCheckDNS("google.com.", "v=spf1 include:_spf.google.com ~all", []string{"8.8.8.8:53"}, true, DefaultDNSTimeout)
 	if err != nil || !ok {
 		t.Errorf("preCheckDNS failed for acme-staging.api.letsencrypt.org: %s", err.Error())
 	}
@@ -96,7 +97,7 @@ func TestPreCheckDNS(t *testing.T) {
 
 func TestPreCheckDNSNonAuthoritative(t *testing.T) {
 	// TODO: find a better TXT record to use in tests
-	ok, err := PreCheckDNS("google.com.", "v=spf1 include:_spf.google.com ~all", []string{"1.1.1.1:53"}, false)
+	ok, err := PreCheckDNS("google.com.", "v=spf1 include:_spf.google.com ~all", []string{"1.1.1.1:53"}, false, DefaultDNSTimeout)
 	if err != nil || !ok {
 		t.Errorf("preCheckDNS failed for acme-staging.api.letsencrypt.org: %s", err.Error())
 	}
@@ -104,7 +105,7 @@ func TestPreCheckDNSNonAuthoritative(t *testing.T) {
 
 func TestLookupNameserversOK(t *testing.T) {
 	for _, tt := range lookupNameserversTestsOK {
-		nss, err := lookupNameservers(tt.fqdn, RecursiveNameservers)
+		nss, err := lookupNameservers(tt.fqdn, RecursiveNameservers, DefaultDNSTimeout)
 		if err != nil {
 			t.Fatalf("#%s: got %q; want nil", tt.fqdn, err)
 		}
@@ -120,7 +121,7 @@ func TestLookupNameserversOK(t *testing.T) {
 
 func TestLookupNameserversErr(t *testing.T) {
 	for _, tt := range lookupNameserversTestsErr {
-		_, err := lookupNameservers(tt.fqdn, RecursiveNameservers)
+		_, err := lookupNameservers(tt.fqdn, RecursiveNameservers, DefaultDNSTimeout)
 		if err == nil {
 			t.Fatalf("#%s: expected %q (error); got <nil>", tt.fqdn, tt.error)
 		}
@@ -146,7 +147,7 @@ func TestFindZoneByFqdn(t *testing.T) {
 
 func TestCheckAuthoritativeNss(t *testing.T) {
 	for _, tt := range checkAuthoritativeNssTests {
-		ok, _ := checkAuthoritativeNss(tt.fqdn, tt.value, tt.ns)
+		ok, _ := checkAuthoritativeNss(tt.fqdn, tt.value, tt.ns, DefaultDNSTimeout)
 		if ok != tt.ok {
 			t.Errorf("%s: got %t; want %t", tt.fqdn, ok, tt.ok)
 		}
@@ -155,7 +156,7 @@ func TestCheckAuthoritativeNss(t *testing.T) {
 
 func TestCheckAuthoritativeNssErr(t *testing.T) {
 	for _, tt := range checkAuthoritativeNssTestsErr {
-		_, err := checkAuthoritativeNss(tt.fqdn, tt.value, tt.ns)
+		_, err := checkAuthoritativeNss(tt.fqdn, tt.value, tt.ns, DefaultDNSTimeout)
 		if err == nil {
 			t.Fatalf("#%s: expected %q (error); got <nil>", tt.fqdn, tt.error)
 		}
@@ -177,3 +178,72 @@ func TestResolveConfServers(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckDNSPropagationAuthoritative(t *testing.T) {
+	const fqdn = "_acme-challenge.negative.example."
+
+	tests := map[string]struct {
+		recursiveTxts     map[string]string
+		authoritativeDown bool
+		useAuthoritative  bool
+		ok                bool
+	}{
+		"finds the record at the authoritative nameservers when recursive nameservers have cached its absence": {
+			useAuthoritative: true,
+			ok:               true,
+		},
+		"does not query the authoritative nameservers when disabled": {
+			useAuthoritative: false,
+			ok:               false,
+		},
+		"falls back to the recursive nameservers if the authoritative nameservers cannot be queried": {
+			recursiveTxts:     map[string]string{fqdn: "token"},
+			authoritativeDown: true,
+			useAuthoritative:  true,
+			ok:                true,
+		},
+		"reports the record as not propagated if neither has it": {
+			authoritativeDown: true,
+			useAuthoritative:  true,
+			ok:                false,
+		},
+	}
+
+	defer func(addr func(string) string) {
+		authoritativeNameserverAddr = addr
+	}(authoritativeNameserverAddr)
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			recursiveNs, stopRecursive := runFakeResolver(t, &fakeResolver{
+				txts:  tt.recursiveTxts,
+				zones: map[string]string{"negative.example.": "ns1.negative.example."},
+			})
+			defer stopRecursive()
+
+			authoritativeNs, stopAuthoritative := runFakeResolver(t, &fakeResolver{
+				txts: map[string]string{fqdn: "token"},
+			})
+			if tt.authoritativeDown {
+				stopAuthoritative()
+			} else {
+				defer stopAuthoritative()
+			}
+
+			authoritativeNameserverAddr = func(ns string) string {
+				if ns != "ns1.negative.example." {
+					t.Errorf("unexpected authoritative nameserver %q", ns)
+				}
+				return authoritativeNs
+			}
+
+			ok, err := checkDNSPropagation(fqdn, "token", []string{recursiveNs}, tt.useAuthoritative, time.Second)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.ok {
+				t.Errorf("expected ok to be %t, got %t", tt.ok, ok)
+			}
+		})
+	}
+}
@@ -20,6 +20,9 @@ import (
 	"errors"
 	"testing"
 
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/client-go/rest"
+
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/digitalocean"
 
 	"github.com/jetstack/cert-manager/test/util/generate"
@@ -30,9 +33,11 @@ import (
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/azuredns"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/clouddns"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/cloudflare"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/hetzner"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/rfc2136"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/route53"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/webhook"
 )
 
 const (
@@ -137,35 +142,47 @@ func newFakeDNSProviders() *fakeDNSProviders {
 		calls: []fakeDNSProviderCall{},
 	}
 	f.constructors = dnsProviderConstructors{
-		cloudDNS: func(project string, serviceAccount []byte, dns01Nameservers []string, ambient bool) (*clouddns.DNSProvider, error) {
-			f.call("clouddns", project, serviceAccount, util.RecursiveNameservers, ambient)
+		cloudDNS: func(project string, serviceAccount []byte, ttl int, dns01Nameservers []string, ambient bool) (*clouddns.DNSProvider, error) {
+			f.call("clouddns", project, serviceAccount, ttl, util.RecursiveNameservers, ambient)
 			return nil, nil
 		},
-		cloudFlare: func(email, apikey string, dns01Nameservers []string) (*cloudflare.DNSProvider, error) {
-			f.call("cloudflare", email, apikey, util.RecursiveNameservers)
-			if email == "" || apikey == "" {
+		cloudFlare: func(email, apikey, apitoken string, ttl int, dns01Nameservers []string) (*cloudflare.DNSProvider, error) {
+			f.call("cloudflare", email, apikey, apitoken, ttl, util.RecursiveNameservers)
+			if apitoken == "" && (email == "" || apikey == "") {
 				return nil, errors.New("invalid email or apikey")
 			}
 			return nil, nil
 		},
-		route53: func(accessKey, secretKey, hostedZoneID, region string, ambient bool, dns01Nameservers []string) (*route53.DNSProvider, error) {
-			f.call("route53", accessKey, secretKey, hostedZoneID, region, ambient, util.RecursiveNameservers)
+		route53: func(accessKey, secretKey, hostedZoneID, region, role, externalID string, ambient bool, ttl int, dns01Nameservers []string) (*route53.DNSProvider, error) {
+			f.call("route53", accessKey, secretKey, hostedZoneID, region, role, externalID, ambient, ttl, util.RecursiveNameservers)
+			return nil, nil
+		},
+		azureDNS: func(clientID, clientSecret, subscriptionID, tenentID, resourceGroupName, hostedZoneName string, ttl int, dns01Nameservers []string) (*azuredns.DNSProvider, error) {
+			f.call("azuredns", clientID, clientSecret, subscriptionID, tenentID, resourceGroupName, hostedZoneName, ttl, util.RecursiveNameservers)
 			return nil, nil
 		},
-		azureDNS: func(clientID, clientSecret, subscriptionID, tenentID, resourceGroupName, hostedZoneName string, dns01Nameservers []string) (*azuredns.DNSProvider, error) {
-			f.call("azuredns", clientID, clientSecret, subscriptionID, tenentID, resourceGroupName, hostedZoneName, util.RecursiveNameservers)
+		azureDNSManagedIdentity: func(identityClientID, subscriptionID, resourceGroupName, hostedZoneName string, ttl int, dns01Nameservers []string) (*azuredns.DNSProvider, error) {
+			f.call("azurednsmanagedidentity", identityClientID, subscriptionID, resourceGroupName, hostedZoneName, ttl, util.RecursiveNameservers)
 			return nil, nil
 		},
 		acmeDNS: func(host string, accountJson []byte, dns01Nameservers []string) (*acmedns.DNSProvider, error) {
 			f.call("acmedns", host, accountJson, dns01Nameservers)
 			return nil, nil
 		},
-		rfc2136: func(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret string, dns01Nameservers []string) (*rfc2136.DNSProvider, error) {
-			f.call("rfc2136", nameserver, tsigAlgorithm, tsigKeyName, tsigSecret, util.RecursiveNameservers)
+		rfc2136: func(nameserver, tsigAlgorithm, tsigKeyName, tsigSecret string, ttl int, dns01Nameservers []string) (*rfc2136.DNSProvider, error) {
+			f.call("rfc2136", nameserver, tsigAlgorithm, tsigKeyName, tsigSecret, ttl, util.RecursiveNameservers)
+			return nil, nil
+		},
+		digitalOcean: func(token string, ttl int, dns01Nameservers []string) (*digitalocean.DNSProvider, error) {
+			f.call("digitalocean", token, ttl, util.RecursiveNameservers)
+			return nil, nil
+		},
+		hetzner: func(token string, ttl int) (*hetzner.DNSProvider, error) {
+			f.call("hetzner", token, ttl)
 			return nil, nil
 		},
-		digitalOcean: func(token string, dns01Nameservers []string) (*digitalocean.DNSProvider, error) {
-			f.call("digitalocean", token, util.RecursiveNameservers)
+		webhook: func(restConfig *rest.Config, groupName, solverName string, config *apiext.JSON, resourceNamespace string, ambient bool, ttl int, dns01Nameservers []string) (*webhook.DNSProvider, error) {
+			f.call("webhook", groupName, solverName, config, resourceNamespace, ambient, ttl, util.RecursiveNameservers)
 			return nil, nil
 		},
 	}
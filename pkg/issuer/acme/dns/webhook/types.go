@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// This file defines the protocol spoken between cert-manager and DNS01 solver
+// webhooks.
+//
+// A solver webhook is served as an aggregated API by the Kubernetes API
+// server. To present or clean up a record, cert-manager POSTs a
+// ChallengePayload containing a Request to
+// /apis/<groupName>/<ProtocolVersion>/<solverName>. The webhook replies with
+// the same ChallengePayload, with Response set.
+
+const (
+	// ProtocolVersion is the API version of the solver webhook protocol. It
+	// is used as the version of the webhook's API group.
+	ProtocolVersion = "v1alpha1"
+
+	// ChallengePayloadKind is the kind of the objects exchanged with solver
+	// webhooks.
+	ChallengePayloadKind = "ChallengePayload"
+)
+
+// ChallengeAction is the action a solver webhook is asked to perform.
+type ChallengeAction string
+
+const (
+	// ChallengeActionPresent asks the webhook to create the TXT record
+	// described by the request.
+	ChallengeActionPresent ChallengeAction = "Present"
+
+	// ChallengeActionCleanUp asks the webhook to delete the TXT record
+	// described by the request. Other TXT records with the same name must be
+	// left in place.
+	ChallengeActionCleanUp ChallengeAction = "CleanUp"
+)
+
+// ChallengePayload is the object sent to and returned by solver webhooks.
+type ChallengePayload struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Request is set by cert-manager when calling the webhook.
+	// +optional
+	Request *ChallengeRequest `json:"request,omitempty"`
+
+	// Response is set by the webhook when replying.
+	// +optional
+	Response *ChallengeResponse `json:"response,omitempty"`
+}
+
+// ChallengeRequest describes a DNS01 challenge record to present or clean up.
+type ChallengeRequest struct {
+	// UID identifies this request. The webhook must copy it into its
+	// response.
+	UID types.UID `json:"uid"`
+
+	// Action is the action to perform.
+	Action ChallengeAction `json:"action"`
+
+	// DNSName is the name of the domain being validated, e.g. example.com.
+	DNSName string `json:"dnsName"`
+
+	// Key is the value of the TXT record.
+	Key string `json:"key"`
+
+	// ResolvedFQDN is the fully qualified name of the TXT record, e.g.
+	// _acme-challenge.example.com., after following any CNAMEs if the
+	// provider's cnameStrategy is Follow.
+	ResolvedFQDN string `json:"resolvedFQDN"`
+
+	// ResolvedZone is the zone ResolvedFQDN belongs to, e.g. example.com.
+	ResolvedZone string `json:"resolvedZone"`
+
+	// TTL is the time to live, in seconds, configured for the record on the
+	// issuer. If zero, the webhook should choose a default.
+	// +optional
+	TTL int `json:"ttl,omitempty"`
+
+	// ResourceNamespace is the namespace the webhook should read any
+	// referenced credentials from. It is the namespace of an Issuer, or the
+	// cluster resource namespace for a ClusterIssuer.
+	ResourceNamespace string `json:"resourceNamespace"`
+
+	// AllowAmbientCredentials is true if the webhook may use credentials
+	// from its environment rather than ones referenced by its config.
+	AllowAmbientCredentials bool `json:"allowAmbientCredentials"`
+
+	// Config is the solver configuration from the issuer, passed unmodified.
+	// +optional
+	Config *apiext.JSON `json:"config,omitempty"`
+}
+
+// ChallengeResponse is the result of a ChallengeRequest.
+type ChallengeResponse struct {
+	// UID is the UID of the request this is a response to.
+	UID types.UID `json:"uid"`
+
+	// Success is true if the action was performed.
+	Success bool `json:"success"`
+
+	// Result describes why the action failed. It is only read if Success is
+	// false.
+	// +optional
+	Result *metav1.Status `json:"status,omitempty"`
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook implements a DNS provider for solving the DNS-01
+// challenge by delegating to an external solver webhook, so that DNS
+// providers can be added without rebuilding cert-manager.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+)
+
+// findZoneByFqdn is used to determine the zone of a record. It is a variable
+// so that it can be overridden in tests.
+var findZoneByFqdn = util.FindZoneByFqdn
+
+// DNSProvider implements the util.ChallengeProvider interface by calling a
+// solver webhook.
+type DNSProvider struct {
+	client            rest.Interface
+	solverName        string
+	config            *apiext.JSON
+	resourceNamespace string
+	ambient           bool
+	ttl               int
+	dns01Nameservers  []string
+}
+
+// NewDNSProvider returns a DNSProvider that calls the solver named solverName
+// served by the webhook registered for groupName, using restConfig to
+// connect to the Kubernetes API server.
+// config and ttl are passed to the webhook with every request.
+func NewDNSProvider(restConfig *rest.Config, groupName, solverName string, config *apiext.JSON, resourceNamespace string, ambient bool, ttl int, dns01Nameservers []string) (*DNSProvider, error) {
+	if restConfig == nil {
+		return nil, fmt.Errorf("no Kubernetes client configuration available to call webhook %q", groupName)
+	}
+
+	client, err := newWebhookClient(restConfig, groupName)
+	if err != nil {
+		return nil, fmt.Errorf("error creating client for webhook %q: %v", groupName, err)
+	}
+
+	return &DNSProvider{
+		client:            client,
+		solverName:        solverName,
+		config:            config,
+		resourceNamespace: resourceNamespace,
+		ambient:           ambient,
+		ttl:               ttl,
+		dns01Nameservers:  dns01Nameservers,
+	}, nil
+}
+
+// newWebhookClient returns a REST client for the API group served by a
+// solver webhook.
+func newWebhookClient(cfg *rest.Config, groupName string) (*rest.RESTClient, error) {
+	config := *cfg
+	config.GroupVersion = &schema.GroupVersion{Group: groupName, Version: ProtocolVersion}
+	config.APIPath = "/apis"
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: serializer.NewCodecFactory(runtime.NewScheme())}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(&config)
+}
+
+// Present asks the webhook to create a TXT record using the specified
+// parameters.
+func (c *DNSProvider) Present(domain, fqdn, value string) error {
+	return c.call(ChallengeActionPresent, domain, fqdn, value)
+}
+
+// CleanUp asks the webhook to remove the TXT record matching the specified
+// parameters.
+func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
+	return c.call(ChallengeActionCleanUp, domain, fqdn, value)
+}
+
+func (c *DNSProvider) call(action ChallengeAction, domain, fqdn, value string) error {
+	zone, err := findZoneByFqdn(fqdn, c.dns01Nameservers)
+	if err != nil {
+		return err
+	}
+
+	req := &ChallengeRequest{
+		UID:                     uuid.NewUUID(),
+		Action:                  action,
+		DNSName:                 domain,
+		Key:                     value,
+		ResolvedFQDN:            fqdn,
+		ResolvedZone:            zone,
+		TTL:                     c.ttl,
+		ResourceNamespace:       c.resourceNamespace,
+		AllowAmbientCredentials: c.ambient,
+		Config:                  c.config,
+	}
+
+	body, err := json.Marshal(&ChallengePayload{
+		TypeMeta: c.typeMeta(),
+		Request:  req,
+	})
+	if err != nil {
+		return err
+	}
+
+	raw, err := c.client.Post().Resource(c.solverName).Body(body).DoRaw()
+	if err != nil {
+		return fmt.Errorf("error calling webhook solver %q: %v", c.solverName, err)
+	}
+
+	var payload ChallengePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("error decoding response from webhook solver %q: %v", c.solverName, err)
+	}
+
+	resp := payload.Response
+	if resp == nil {
+		return fmt.Errorf("webhook solver %q returned no response", c.solverName)
+	}
+	if resp.UID != req.UID {
+		return fmt.Errorf("webhook solver %q returned a response for request %q, expected %q", c.solverName, resp.UID, req.UID)
+	}
+	if !resp.Success {
+		msg := "unknown error"
+		if resp.Result != nil && resp.Result.Message != "" {
+			msg = resp.Result.Message
+		}
+		return fmt.Errorf("webhook solver %q failed %s for record %q: %s", c.solverName, action, fqdn, msg)
+	}
+
+	return nil
+}
+
+func (c *DNSProvider) typeMeta() metav1.TypeMeta {
+	gv := c.client.APIVersion()
+	return metav1.TypeMeta{
+		APIVersion: gv.String(),
+		Kind:       ChallengePayloadKind,
+	}
+}
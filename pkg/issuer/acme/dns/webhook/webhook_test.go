@@ -0,0 +1,213 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// stubWebhook serves a solver webhook that records the requests it receives
+// and replies using respond.
+type stubWebhook struct {
+	t        *testing.T
+	requests []*ChallengeRequest
+	respond  func(w http.ResponseWriter, req *ChallengeRequest)
+}
+
+func (s *stubWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.t.Errorf("expected a POST request, got %s", r.Method)
+	}
+	if r.URL.Path != "/apis/acme.example.com/v1alpha1/example" {
+		s.t.Errorf("unexpected request path %q", r.URL.Path)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.t.Fatalf("error reading request body: %v", err)
+	}
+	var payload ChallengePayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		s.t.Fatalf("error decoding request body: %v", err)
+	}
+	if payload.APIVersion != "acme.example.com/v1alpha1" || payload.Kind != ChallengePayloadKind {
+		s.t.Errorf("unexpected type of request payload: %s, %s", payload.APIVersion, payload.Kind)
+	}
+	if payload.Request == nil {
+		s.t.Fatalf("request payload did not contain a request")
+	}
+
+	s.requests = append(s.requests, payload.Request)
+	s.respond(w, payload.Request)
+}
+
+func writeResponse(w http.ResponseWriter, resp *ChallengeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&ChallengePayload{
+		TypeMeta: metav1.TypeMeta{APIVersion: "acme.example.com/v1alpha1", Kind: ChallengePayloadKind},
+		Response: resp,
+	})
+}
+
+func succeed(w http.ResponseWriter, req *ChallengeRequest) {
+	writeResponse(w, &ChallengeResponse{UID: req.UID, Success: true})
+}
+
+func newTestProvider(t *testing.T, respond func(w http.ResponseWriter, req *ChallengeRequest)) (*DNSProvider, *stubWebhook, func()) {
+	oldFindZoneByFqdn := findZoneByFqdn
+	findZoneByFqdn = func(fqdn string, nameservers []string) (string, error) {
+		return "example.com.", nil
+	}
+
+	stub := &stubWebhook{t: t, respond: respond}
+	server := httptest.NewServer(stub)
+
+	config := &apiext.JSON{Raw: []byte(`{"apiKey":"abc"}`)}
+	provider, err := NewDNSProvider(&rest.Config{Host: server.URL}, "acme.example.com", "example", config, "cert-manager", true, 30, []string{"8.8.8.8:53"})
+	if err != nil {
+		t.Fatalf("error creating provider: %v", err)
+	}
+
+	return provider, stub, func() {
+		server.Close()
+		findZoneByFqdn = oldFindZoneByFqdn
+	}
+}
+
+func TestWebhookRequests(t *testing.T) {
+	tests := map[string]struct {
+		call   func(p *DNSProvider) error
+		action ChallengeAction
+	}{
+		"present": {
+			call: func(p *DNSProvider) error {
+				return p.Present("example.com", "_acme-challenge.example.com.", "123d==")
+			},
+			action: ChallengeActionPresent,
+		},
+		"clean up": {
+			call: func(p *DNSProvider) error {
+				return p.CleanUp("example.com", "_acme-challenge.example.com.", "123d==")
+			},
+			action: ChallengeActionCleanUp,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider, stub, cleanup := newTestProvider(t, succeed)
+			defer cleanup()
+
+			if err := tt.call(provider); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(stub.requests) != 1 {
+				t.Fatalf("expected 1 request to the webhook, got %d", len(stub.requests))
+			}
+			req := stub.requests[0]
+			if req.UID == "" {
+				t.Errorf("expected the request to have a UID")
+			}
+			if req.Action != tt.action {
+				t.Errorf("expected action %q, got %q", tt.action, req.Action)
+			}
+			if req.DNSName != "example.com" || req.Key != "123d==" {
+				t.Errorf("unexpected dnsName or key: %q, %q", req.DNSName, req.Key)
+			}
+			if req.ResolvedFQDN != "_acme-challenge.example.com." || req.ResolvedZone != "example.com." {
+				t.Errorf("unexpected resolvedFQDN or resolvedZone: %q, %q", req.ResolvedFQDN, req.ResolvedZone)
+			}
+			if req.TTL != 30 {
+				t.Errorf("expected the configured TTL to be passed, got %d", req.TTL)
+			}
+			if req.ResourceNamespace != "cert-manager" || !req.AllowAmbientCredentials {
+				t.Errorf("unexpected resourceNamespace or allowAmbientCredentials: %q, %v", req.ResourceNamespace, req.AllowAmbientCredentials)
+			}
+			if req.Config == nil || string(req.Config.Raw) != `{"apiKey":"abc"}` {
+				t.Errorf("expected the issuer config to be passed unmodified, got %v", req.Config)
+			}
+		})
+	}
+}
+
+func TestWebhookErrors(t *testing.T) {
+	tests := map[string]struct {
+		respond     func(w http.ResponseWriter, req *ChallengeRequest)
+		expectedErr string
+	}{
+		"webhook reports a failure": {
+			respond: func(w http.ResponseWriter, req *ChallengeRequest) {
+				writeResponse(w, &ChallengeResponse{
+					UID:     req.UID,
+					Success: false,
+					Result:  &metav1.Status{Message: "zone is read only"},
+				})
+			},
+			expectedErr: "zone is read only",
+		},
+		"webhook responds to a different request": {
+			respond: func(w http.ResponseWriter, req *ChallengeRequest) {
+				writeResponse(w, &ChallengeResponse{UID: "another-uid", Success: true})
+			},
+			expectedErr: "another-uid",
+		},
+		"webhook returns no response": {
+			respond: func(w http.ResponseWriter, req *ChallengeRequest) {
+				writeResponse(w, nil)
+			},
+			expectedErr: "returned no response",
+		},
+		"webhook returns an error status": {
+			respond: func(w http.ResponseWriter, req *ChallengeRequest) {
+				http.Error(w, "solver unavailable", http.StatusServiceUnavailable)
+			},
+			expectedErr: "error calling webhook solver",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider, _, cleanup := newTestProvider(t, tt.respond)
+			defer cleanup()
+
+			err := provider.Present("example.com", "_acme-challenge.example.com.", "123d==")
+			if err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error to contain %q, got: %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNewDNSProviderWithoutRESTConfig(t *testing.T) {
+	_, err := NewDNSProvider(nil, "acme.example.com", "example", nil, "cert-manager", false, 0, nil)
+	if err == nil {
+		t.Errorf("expected an error when no REST config is available")
+	}
+}
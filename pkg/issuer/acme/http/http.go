@@ -25,6 +25,7 @@ import (
 	"net/url"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	extv1beta1listers "k8s.io/client-go/listers/extensions/v1beta1"
@@ -80,11 +81,15 @@ func NewSolver(ctx *controller.Context) *Solver {
 // challenge validation in the apiserver. If those resources already exist, it
 // will return nil (i.e. this function is idempotent).
 func (s *Solver) Present(ctx context.Context, issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) error {
-	_, podErr := s.ensurePod(ch)
+	_, podErr := s.ensurePod(issuer, ch)
 	svc, svcErr := s.ensureService(issuer, ch)
 	if svcErr != nil {
 		return utilerrors.NewAggregate([]error{podErr, svcErr})
 	}
+	// LoadBalancer services are exposed directly, so no ingress is needed
+	if serviceType(issuer) == corev1.ServiceTypeLoadBalancer {
+		return podErr
+	}
 	_, ingressErr := s.ensureIngress(ch, svc.Name)
 	return utilerrors.NewAggregate([]error{podErr, svcErr, ingressErr})
 }
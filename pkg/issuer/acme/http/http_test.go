@@ -22,7 +22,13 @@ import (
 	"net/url"
 	"testing"
 
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/test/util/generate"
 )
 
 // countReachabilityTestCalls is a wrapper function that allows us to count the number
@@ -87,3 +93,109 @@ func TestCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestPresentLoadBalancerSkipsIngress(t *testing.T) {
+	f := &solverFixture{
+		Issuer: generate.Issuer(generate.IssuerConfig{
+			Name:      defaultTestIssuerName,
+			Namespace: defaultTestNamespace,
+			HTTP01: &v1alpha1.ACMEIssuerHTTP01Config{
+				ServiceType: v1.ServiceTypeLoadBalancer,
+			},
+		}),
+		Challenge: &v1alpha1.Challenge{
+			Spec: v1alpha1.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "token",
+				Key:     "key",
+				Config: v1alpha1.SolverConfig{
+					HTTP01: &v1alpha1.HTTP01SolverConfig{},
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.Present(context.TODO(), f.Issuer, f.Challenge); err != nil {
+		t.Fatalf("expected Present to not error, but got: %v", err)
+	}
+	f.Builder.Sync()
+
+	services, err := f.Solver.serviceLister.List(labels.NewSelector())
+	if err != nil {
+		t.Fatalf("error listing services: %v", err)
+	}
+	if len(services) != 1 || services[0].Spec.Type != v1.ServiceTypeLoadBalancer {
+		t.Errorf("expected a single LoadBalancer service, got %+v", services)
+	}
+	ingresses, err := f.Solver.ingressLister.List(labels.NewSelector())
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(ingresses) != 0 {
+		t.Errorf("expected no ingresses to be created, got %d", len(ingresses))
+	}
+}
+
+func TestPresentSetsOwnerReferences(t *testing.T) {
+	f := &solverFixture{
+		Challenge: &v1alpha1.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-challenge",
+				Namespace: defaultTestNamespace,
+				UID:       types.UID("test-challenge-uid"),
+			},
+			Spec: v1alpha1.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "token",
+				Key:     "key",
+				Config: v1alpha1.SolverConfig{
+					HTTP01: &v1alpha1.HTTP01SolverConfig{},
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.Present(context.TODO(), f.Issuer, f.Challenge); err != nil {
+		t.Fatalf("expected Present to not error, but got: %v", err)
+	}
+
+	assertOwnedByChallenge := func(kind string, obj metav1.Object) {
+		refs := obj.GetOwnerReferences()
+		if len(refs) != 1 {
+			t.Errorf("expected %s %q to have one owner reference, got %v", kind, obj.GetName(), refs)
+			return
+		}
+		ref := refs[0]
+		if ref.Kind != "Challenge" || ref.APIVersion != v1alpha1.SchemeGroupVersion.String() ||
+			ref.Name != f.Challenge.Name || ref.UID != f.Challenge.UID {
+			t.Errorf("expected %s %q to be owned by challenge %q, got %+v", kind, obj.GetName(), f.Challenge.Name, ref)
+		}
+		if ref.Controller == nil || !*ref.Controller {
+			t.Errorf("expected the owner reference on %s %q to be a controller reference", kind, obj.GetName())
+		}
+	}
+
+	pods, err := f.Builder.Client.CoreV1().Pods(defaultTestNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing pods: %v", err)
+	}
+	services, err := f.Builder.Client.CoreV1().Services(defaultTestNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing services: %v", err)
+	}
+	ingresses, err := f.Builder.Client.ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(pods.Items) != 1 || len(services.Items) != 1 || len(ingresses.Items) != 1 {
+		t.Fatalf("expected one pod, service and ingress to be created, got %d, %d and %d",
+			len(pods.Items), len(services.Items), len(ingresses.Items))
+	}
+	assertOwnedByChallenge("pod", &pods.Items[0])
+	assertOwnedByChallenge("service", &services.Items[0])
+	assertOwnedByChallenge("ingress", &ingresses.Items[0])
+}
@@ -28,9 +28,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/ingress/core/pkg/ingress/annotations/class"
 
-	"github.com/golang/glog"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 // getIngressesForChallenge returns a list of Ingresses that were created to solve
@@ -46,7 +46,7 @@ func (s *Solver) getIngressesForChallenge(ch *v1alpha1.Challenge) ([]*extv1beta1
 		selector = selector.Add(*req)
 	}
 
-	glog.Infof("Looking up Ingresses for selector %v", selector)
+	logs.Infof("Looking up Ingresses for selector %v", selector)
 	ingressList, err := s.ingressLister.Ingresses(ch.Namespace).List(selector)
 	if err != nil {
 		return nil, err
@@ -55,7 +55,7 @@ func (s *Solver) getIngressesForChallenge(ch *v1alpha1.Challenge) ([]*extv1beta1
 	var relevantIngresses []*extv1beta1.Ingress
 	for _, ingress := range ingressList {
 		if !metav1.IsControlledBy(ingress, ch) {
-			glog.Infof("Found ingress %q with acme-order-url annotation set to that of Challenge %q "+
+			logs.Infof("Found ingress %q with acme-order-url annotation set to that of Challenge %q "+
 				"but it is not owned by the Challenge resource, so skipping it.", ingress.Namespace+"/"+ingress.Name, ch.Namespace+"/"+ch.Name)
 			continue
 		}
@@ -87,7 +87,7 @@ func (s *Solver) ensureIngress(ch *v1alpha1.Challenge, svcName string) (ing *ext
 	}
 	if len(existingIngresses) > 1 {
 		errMsg := fmt.Sprintf("multiple challenge solver ingresses found for Challenge '%s/%s'. Cleaning up existing pods.", ch.Namespace, ch.Name)
-		glog.Infof(errMsg)
+		logs.Infof(errMsg)
 		err := s.cleanupIngresses(ch)
 		if err != nil {
 			return nil, err
@@ -95,21 +95,27 @@ func (s *Solver) ensureIngress(ch *v1alpha1.Challenge, svcName string) (ing *ext
 		return nil, fmt.Errorf(errMsg)
 	}
 
-	glog.Infof("No existing HTTP01 challenge solver ingress found for Challenge %q. One will be created.", ch.Namespace+"/"+ch.Name)
+	logs.Infof("No existing HTTP01 challenge solver ingress found for Challenge %q. One will be created.", ch.Namespace+"/"+ch.Name)
 	return s.createIngress(ch, svcName)
 }
 
 // createIngress will create a challenge solving pod for the given certificate,
 // domain, token and key.
 func (s *Solver) createIngress(ch *v1alpha1.Challenge, svcName string) (*extv1beta1.Ingress, error) {
-	return s.Client.ExtensionsV1beta1().Ingresses(ch.Namespace).Create(buildIngressResource(ch, svcName))
+	return s.Client.ExtensionsV1beta1().Ingresses(ch.Namespace).Create(s.buildIngressResource(ch, svcName))
 }
 
-func buildIngressResource(ch *v1alpha1.Challenge, svcName string) *extv1beta1.Ingress {
+func (s *Solver) buildIngressResource(ch *v1alpha1.Challenge, svcName string) *extv1beta1.Ingress {
 	var ingClass *string
 	if ch.Spec.Config.HTTP01 != nil {
 		ingClass = ch.Spec.Config.HTTP01.IngressClass
 	}
+	// fall back to the controller wide default class if the challenge does
+	// not specify one
+	if ingClass == nil && s.ACMEOptions.HTTP01SolverIngressClass != "" {
+		defaultClass := s.ACMEOptions.HTTP01SolverIngressClass
+		ingClass = &defaultClass
+	}
 
 	podLabels := podLabels(ch)
 	// TODO: add additional annotations to help workaround problematic ingress controller behaviours
@@ -207,7 +213,7 @@ func (s *Solver) cleanupIngresses(ch *v1alpha1.Challenge) error {
 		if err != nil {
 			return err
 		}
-		glog.V(4).Infof("Found %d ingresses to clean up for certificate %q", len(ingresses), ch.Namespace+"/"+ch.Name)
+		logs.V(4).Infof("Found %d ingresses to clean up for certificate %q", len(ingresses), ch.Namespace+"/"+ch.Name)
 		var errs []error
 		for _, ingress := range ingresses {
 			// TODO: should we call DeleteCollection here? We'd need to somehow
@@ -223,7 +229,7 @@ func (s *Solver) cleanupIngresses(ch *v1alpha1.Challenge) error {
 	// otherwise, we need to remove any cert-manager added rules from the ingress resource
 	ing, err := s.Client.ExtensionsV1beta1().Ingresses(ch.Namespace).Get(existingIngressName, metav1.GetOptions{})
 	if k8sErrors.IsNotFound(err) {
-		glog.Infof("attempt to cleanup Ingress %q of ACME challenge path failed: %v", ch.Namespace+"/"+existingIngressName, err)
+		logs.Infof("attempt to cleanup Ingress %q of ACME challenge path failed: %v", ch.Namespace+"/"+existingIngressName, err)
 		return nil
 	}
 	if err != nil {
@@ -28,8 +28,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	coretesting "k8s.io/client-go/testing"
+	"k8s.io/ingress/core/pkg/ingress/annotations/class"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/controller/test"
 )
 
@@ -360,3 +362,70 @@ func TestCleanupIngresses(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateIngressClassAnnotation(t *testing.T) {
+	tests := map[string]struct {
+		defaultClass  string
+		challengeConf *v1alpha1.HTTP01SolverConfig
+		expectedClass *string
+	}{
+		"no class annotation if neither the challenge nor the controller set one": {
+			challengeConf: &v1alpha1.HTTP01SolverConfig{},
+		},
+		"uses the controller default class if the challenge does not set one": {
+			defaultClass:  "nginx",
+			challengeConf: &v1alpha1.HTTP01SolverConfig{},
+			expectedClass: strPtr("nginx"),
+		},
+		"uses the challenge class if set": {
+			challengeConf: &v1alpha1.HTTP01SolverConfig{IngressClass: strPtr("traefik")},
+			expectedClass: strPtr("traefik"),
+		},
+		"the challenge class takes precedence over the controller default": {
+			defaultClass:  "nginx",
+			challengeConf: &v1alpha1.HTTP01SolverConfig{IngressClass: strPtr("traefik")},
+			expectedClass: strPtr("traefik"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &solverFixture{
+				Builder: &test.Builder{
+					Context: &controller.Context{
+						ACMEOptions: controller.ACMEOptions{
+							HTTP01SolverIngressClass: tt.defaultClass,
+						},
+					},
+				},
+				Challenge: &v1alpha1.Challenge{
+					Spec: v1alpha1.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "token",
+						Config: v1alpha1.SolverConfig{
+							HTTP01: tt.challengeConf,
+						},
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			ing, err := f.Solver.createIngress(f.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error creating ingress: %v", err)
+			}
+
+			got, ok := ing.Annotations[class.IngressKey]
+			if tt.expectedClass == nil {
+				if ok {
+					t.Errorf("expected no ingress class annotation, got %q", got)
+				}
+				return
+			}
+			if got != *tt.expectedClass {
+				t.Errorf("expected ingress class annotation %q, got %q", *tt.expectedClass, got)
+			}
+		})
+	}
+}
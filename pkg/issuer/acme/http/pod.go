@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"hash/adler32"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -28,6 +27,7 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 func podLabels(ch *v1alpha1.Challenge) map[string]string {
@@ -43,7 +43,7 @@ func podLabels(ch *v1alpha1.Challenge) map[string]string {
 	}
 }
 
-func (s *Solver) ensurePod(ch *v1alpha1.Challenge) (*corev1.Pod, error) {
+func (s *Solver) ensurePod(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) (*corev1.Pod, error) {
 	existingPods, err := s.getPodsForChallenge(ch)
 	if err != nil {
 		return nil, err
@@ -53,7 +53,7 @@ func (s *Solver) ensurePod(ch *v1alpha1.Challenge) (*corev1.Pod, error) {
 	}
 	if len(existingPods) > 1 {
 		errMsg := fmt.Sprintf("multiple challenge solver pods found for certificate '%s/%s'. Cleaning up existing pods.", ch.Namespace, ch.Name)
-		glog.Infof(errMsg)
+		logs.Infof(errMsg)
 		err := s.cleanupPods(ch)
 		if err != nil {
 			return nil, err
@@ -61,8 +61,8 @@ func (s *Solver) ensurePod(ch *v1alpha1.Challenge) (*corev1.Pod, error) {
 		return nil, fmt.Errorf(errMsg)
 	}
 
-	glog.Infof("No existing HTTP01 challenge solver pod found for Certificate %q. One will be created.", ch.Namespace+"/"+ch.Name)
-	return s.createPod(ch)
+	logs.Infof("No existing HTTP01 challenge solver pod found for Certificate %q. One will be created.", ch.Namespace+"/"+ch.Name)
+	return s.createPod(issuer, ch)
 }
 
 // getPodsForChallenge returns a list of pods that were created to solve
@@ -86,7 +86,7 @@ func (s *Solver) getPodsForChallenge(ch *v1alpha1.Challenge) ([]*corev1.Pod, err
 	var relevantPods []*corev1.Pod
 	for _, pod := range podList {
 		if !metav1.IsControlledBy(pod, ch) {
-			glog.Infof("Found pod %q with acme-order-url annotation set to that of Certificate %q"+
+			logs.Infof("Found pod %q with acme-order-url annotation set to that of Certificate %q"+
 				"but it is not owned by the Certificate resource, so skipping it.", pod.Namespace+"/"+pod.Name, ch.Namespace+"/"+ch.Name)
 			continue
 		}
@@ -115,21 +115,27 @@ func (s *Solver) cleanupPods(ch *v1alpha1.Challenge) error {
 
 // createPod will create a challenge solving pod for the given certificate,
 // domain, token and key.
-func (s *Solver) createPod(ch *v1alpha1.Challenge) (*corev1.Pod, error) {
-	return s.Client.CoreV1().Pods(ch.Namespace).Create(s.buildPod(ch))
+func (s *Solver) createPod(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) (*corev1.Pod, error) {
+	return s.Client.CoreV1().Pods(ch.Namespace).Create(s.buildPod(issuer, ch))
 }
 
 // buildPod will build a challenge solving pod for the given certificate,
-// domain, token and key. It will not create it in the API server
-func (s *Solver) buildPod(ch *v1alpha1.Challenge) *corev1.Pod {
+// domain, token and key. It will not create it in the API server.
+// Scheduling constraints configured on the controller are merged with those
+// set on the issuer's HTTP01 config.
+func (s *Solver) buildPod(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) *corev1.Pod {
 	podLabels := podLabels(ch)
-	return &corev1.Pod{
+	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "cm-acme-http-solver-",
 			Namespace:    ch.Namespace,
 			Labels:       podLabels,
 			Annotations: map[string]string{
 				"sidecar.istio.io/inject": "false",
+				// run the solver with the container runtime's default
+				// seccomp profile, as required by restrictive pod security
+				// policies
+				"seccomp.security.alpha.kubernetes.io/pod": "runtime/default",
 			},
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ch, challengeGvk)},
 		},
@@ -158,6 +164,7 @@ func (s *Solver) buildPod(ch *v1alpha1.Challenge) *corev1.Pod {
 							corev1.ResourceMemory: s.ACMEOptions.HTTP01SolverResourceLimitsMemory,
 						},
 					},
+					SecurityContext: s.solverSecurityContext(),
 					Ports: []corev1.ContainerPort{
 						{
 							Name:          "http",
@@ -168,4 +175,59 @@ func (s *Solver) buildPod(ch *v1alpha1.Challenge) *corev1.Pod {
 			},
 		},
 	}
+
+	nodeSelector := map[string]string{}
+	for k, v := range s.ACMEOptions.HTTP01SolverNodeSelector {
+		nodeSelector[k] = v
+	}
+	tolerations := append([]corev1.Toleration{}, s.ACMEOptions.HTTP01SolverTolerations...)
+	var pullSecrets []corev1.LocalObjectReference
+	for _, name := range s.ACMEOptions.HTTP01SolverImagePullSecrets {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+
+	if http01 := issuer.GetSpec().ACME.HTTP01; http01 != nil {
+		for k, v := range http01.NodeSelector {
+			nodeSelector[k] = v
+		}
+		tolerations = append(tolerations, http01.Tolerations...)
+		if http01.Affinity != nil {
+			pod.Spec.Affinity = http01.Affinity.DeepCopy()
+		}
+		if len(http01.ImagePullSecrets) > 0 {
+			pullSecrets = append([]corev1.LocalObjectReference{}, http01.ImagePullSecrets...)
+		}
+	}
+
+	if len(nodeSelector) > 0 {
+		pod.Spec.NodeSelector = nodeSelector
+	}
+	if len(tolerations) > 0 {
+		pod.Spec.Tolerations = tolerations
+	}
+	pod.Spec.ImagePullSecrets = pullSecrets
+
+	return pod
+}
+
+// solverSecurityContext returns the security context for the HTTP01 solver
+// container. Privilege escalation is always disabled and all capabilities are
+// dropped, as the solver only needs to listen on an unprivileged port.
+func (s *Solver) solverSecurityContext() *corev1.SecurityContext {
+	allowPrivilegeEscalation := false
+	runAsNonRoot := s.ACMEOptions.HTTP01SolverRunAsNonRoot
+	readOnlyRootFilesystem := s.ACMEOptions.HTTP01SolverReadOnlyRootFilesystem
+	sc := &corev1.SecurityContext{
+		RunAsNonRoot:             &runAsNonRoot,
+		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+	if s.ACMEOptions.HTTP01SolverRunAsUser > 0 {
+		runAsUser := s.ACMEOptions.HTTP01SolverRunAsUser
+		sc.RunAsUser = &runAsUser
+	}
+	return sc
 }
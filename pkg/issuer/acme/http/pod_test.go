@@ -26,6 +26,9 @@ import (
 	coretesting "k8s.io/client-go/testing"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/test/util/generate"
 )
 
 func TestEnsurePod(t *testing.T) {
@@ -43,7 +46,7 @@ func TestEnsurePod(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createPod(s.Challenge)
+				ing, err := s.Solver.createPod(s.Issuer, s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -84,7 +87,7 @@ func TestEnsurePod(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				expectedPod := s.Solver.buildPod(s.Challenge)
+				expectedPod := s.Solver.buildPod(s.Issuer, s.Challenge)
 				// create a reactor that fails the test if a pod is created
 				s.Builder.FakeKubeClient().PrependReactor("create", "pods", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
 					pod := action.(coretesting.CreateAction).GetObject().(*v1.Pod)
@@ -135,11 +138,11 @@ func TestEnsurePod(t *testing.T) {
 			},
 			Err: true,
 			PreFn: func(t *testing.T, s *solverFixture) {
-				_, err := s.Solver.createPod(s.Challenge)
+				_, err := s.Solver.createPod(s.Issuer, s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
-				_, err = s.Solver.createPod(s.Challenge)
+				_, err = s.Solver.createPod(s.Issuer, s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -162,7 +165,7 @@ func TestEnsurePod(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			test.Setup(t)
-			resp, err := test.Solver.ensurePod(test.Challenge)
+			resp, err := test.Solver.ensurePod(test.Issuer, test.Challenge)
 			if err != nil && !test.Err {
 				t.Errorf("Expected function to not error, but got: %v", err)
 			}
@@ -174,6 +177,191 @@ func TestEnsurePod(t *testing.T) {
 	}
 }
 
+func TestBuildPodScheduling(t *testing.T) {
+	globalToleration := v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "ingress", Effect: v1.TaintEffectNoSchedule}
+	issuerToleration := v1.Toleration{Key: "example.com/spot", Operator: v1.TolerationOpExists}
+	affinity := &v1.Affinity{
+		NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		acmeOptions controller.ACMEOptions
+		http01      *v1alpha1.ACMEIssuerHTTP01Config
+
+		expectedNodeSelector map[string]string
+		expectedTolerations  []v1.Toleration
+		expectedAffinity     *v1.Affinity
+	}{
+		"no scheduling constraints": {},
+		"uses the controller defaults": {
+			acmeOptions: controller.ACMEOptions{
+				HTTP01SolverNodeSelector: map[string]string{"role": "ingress"},
+				HTTP01SolverTolerations:  []v1.Toleration{globalToleration},
+			},
+			http01:               &v1alpha1.ACMEIssuerHTTP01Config{},
+			expectedNodeSelector: map[string]string{"role": "ingress"},
+			expectedTolerations:  []v1.Toleration{globalToleration},
+		},
+		"merges issuer constraints over the controller defaults": {
+			acmeOptions: controller.ACMEOptions{
+				HTTP01SolverNodeSelector: map[string]string{"role": "ingress", "os": "linux"},
+				HTTP01SolverTolerations:  []v1.Toleration{globalToleration},
+			},
+			http01: &v1alpha1.ACMEIssuerHTTP01Config{
+				NodeSelector: map[string]string{"role": "edge"},
+				Tolerations:  []v1.Toleration{issuerToleration},
+				Affinity:     affinity,
+			},
+			expectedNodeSelector: map[string]string{"role": "edge", "os": "linux"},
+			expectedTolerations:  []v1.Toleration{globalToleration, issuerToleration},
+			expectedAffinity:     affinity,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &solverFixture{
+				Builder: &test.Builder{
+					Context: &controller.Context{ACMEOptions: tt.acmeOptions},
+				},
+				Issuer: generate.Issuer(generate.IssuerConfig{
+					Name:      defaultTestIssuerName,
+					Namespace: defaultTestNamespace,
+					HTTP01:    tt.http01,
+				}),
+				Challenge: &v1alpha1.Challenge{
+					Spec: v1alpha1.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "token",
+						Key:     "key",
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			pod := f.Solver.buildPod(f.Issuer, f.Challenge)
+			if !reflect.DeepEqual(pod.Spec.NodeSelector, tt.expectedNodeSelector) {
+				t.Errorf("expected node selector %v, got %v", tt.expectedNodeSelector, pod.Spec.NodeSelector)
+			}
+			if !reflect.DeepEqual(pod.Spec.Tolerations, tt.expectedTolerations) {
+				t.Errorf("expected tolerations %v, got %v", tt.expectedTolerations, pod.Spec.Tolerations)
+			}
+			if !reflect.DeepEqual(pod.Spec.Affinity, tt.expectedAffinity) {
+				t.Errorf("expected affinity %v, got %v", tt.expectedAffinity, pod.Spec.Affinity)
+			}
+		})
+	}
+}
+
+func TestBuildPodImagePullSecrets(t *testing.T) {
+	tests := map[string]struct {
+		controllerSecrets []string
+		http01            *v1alpha1.ACMEIssuerHTTP01Config
+		expected          []v1.LocalObjectReference
+	}{
+		"no image pull secrets": {},
+		"uses the controller image pull secrets": {
+			controllerSecrets: []string{"registry-a", "registry-b"},
+			expected:          []v1.LocalObjectReference{{Name: "registry-a"}, {Name: "registry-b"}},
+		},
+		"issuer image pull secrets override the controller's": {
+			controllerSecrets: []string{"registry-a"},
+			http01: &v1alpha1.ACMEIssuerHTTP01Config{
+				ImagePullSecrets: []v1.LocalObjectReference{{Name: "issuer-registry"}},
+			},
+			expected: []v1.LocalObjectReference{{Name: "issuer-registry"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &solverFixture{
+				Builder: &test.Builder{
+					Context: &controller.Context{
+						ACMEOptions: controller.ACMEOptions{
+							HTTP01SolverImagePullSecrets: tt.controllerSecrets,
+						},
+					},
+				},
+				Issuer: generate.Issuer(generate.IssuerConfig{
+					Name:      defaultTestIssuerName,
+					Namespace: defaultTestNamespace,
+					HTTP01:    tt.http01,
+				}),
+				Challenge: &v1alpha1.Challenge{
+					Spec: v1alpha1.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "token",
+						Key:     "key",
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			pod := f.Solver.buildPod(f.Issuer, f.Challenge)
+			if !reflect.DeepEqual(pod.Spec.ImagePullSecrets, tt.expected) {
+				t.Errorf("expected image pull secrets %v, got %v", tt.expected, pod.Spec.ImagePullSecrets)
+			}
+		})
+	}
+}
+
+func TestBuildPodSecurityContext(t *testing.T) {
+	f := &solverFixture{
+		Builder: &test.Builder{
+			Context: &controller.Context{
+				ACMEOptions: controller.ACMEOptions{
+					HTTP01SolverRunAsNonRoot:           true,
+					HTTP01SolverRunAsUser:              1000,
+					HTTP01SolverReadOnlyRootFilesystem: true,
+				},
+			},
+		},
+		Challenge: &v1alpha1.Challenge{
+			Spec: v1alpha1.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "token",
+				Key:     "key",
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	pod := f.Solver.buildPod(f.Issuer, f.Challenge)
+	sc := pod.Spec.Containers[0].SecurityContext
+	if sc == nil {
+		t.Fatalf("expected solver container to have a security context")
+	}
+	if sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot {
+		t.Errorf("expected runAsNonRoot to be true, got %v", sc.RunAsNonRoot)
+	}
+	if sc.RunAsUser == nil || *sc.RunAsUser != 1000 {
+		t.Errorf("expected runAsUser to be 1000, got %v", sc.RunAsUser)
+	}
+	if sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+		t.Errorf("expected readOnlyRootFilesystem to be true, got %v", sc.ReadOnlyRootFilesystem)
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		t.Errorf("expected allowPrivilegeEscalation to be false, got %v", sc.AllowPrivilegeEscalation)
+	}
+	if sc.Capabilities == nil || !reflect.DeepEqual(sc.Capabilities.Drop, []v1.Capability{"ALL"}) {
+		t.Errorf("expected all capabilities to be dropped, got %v", sc.Capabilities)
+	}
+}
+
 func TestGetPodsForCertificate(t *testing.T) {
 	const createdPodKey = "createdPod"
 	tests := map[string]solverFixture{
@@ -187,7 +375,7 @@ func TestGetPodsForCertificate(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createPod(s.Challenge)
+				ing, err := s.Solver.createPod(s.Issuer, s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -220,7 +408,7 @@ func TestGetPodsForCertificate(t *testing.T) {
 			PreFn: func(t *testing.T, s *solverFixture) {
 				differentChallenge := s.Challenge.DeepCopy()
 				differentChallenge.Spec.DNSName = "notexample.com"
-				_, err := s.Solver.createPod(differentChallenge)
+				_, err := s.Solver.createPod(s.Issuer, differentChallenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -26,8 +26,8 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
-	"github.com/golang/glog"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
 func (s *Solver) ensureService(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) (*corev1.Service, error) {
@@ -40,7 +40,7 @@ func (s *Solver) ensureService(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Chall
 	}
 	if len(existingServices) > 1 {
 		errMsg := fmt.Sprintf("multiple challenge solver services found for certificate '%s/%s'. Cleaning up existing services.", ch.Namespace, ch.Name)
-		glog.Infof(errMsg)
+		logs.Infof(errMsg)
 		err := s.cleanupServices(ch)
 		if err != nil {
 			return nil, err
@@ -48,7 +48,7 @@ func (s *Solver) ensureService(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Chall
 		return nil, fmt.Errorf(errMsg)
 	}
 
-	glog.Infof("No existing HTTP01 challenge solver service found for Certificate %q. One will be created.", ch.Namespace+"/"+ch.Name)
+	logs.Infof("No existing HTTP01 challenge solver service found for Certificate %q. One will be created.", ch.Namespace+"/"+ch.Name)
 	return s.createService(issuer, ch)
 }
 
@@ -73,7 +73,7 @@ func (s *Solver) getServicesForChallenge(ch *v1alpha1.Challenge) ([]*corev1.Serv
 	var relevantServices []*corev1.Service
 	for _, service := range serviceList {
 		if !metav1.IsControlledBy(service, ch) {
-			glog.Infof("Found service %q with acme-order-url annotation set to that of Certificate %q"+
+			logs.Infof("Found service %q with acme-order-url annotation set to that of Certificate %q"+
 				"but it is not owned by the Certificate resource, so skipping it.", service.Namespace+"/"+service.Name, ch.Namespace+"/"+ch.Name)
 			continue
 		}
@@ -113,15 +113,26 @@ func buildService(issuer v1alpha1.GenericIssuer, ch *v1alpha1.Challenge) *corev1
 		},
 	}
 
-	// checking for presence of http01 config and if set serviceType is set, override our default (NodePort)
-	service.Spec.Type = corev1.ServiceTypeNodePort
-	if issuer.GetSpec().ACME.HTTP01 != nil && issuer.GetSpec().ACME.HTTP01.ServiceType != "" {
-		service.Spec.Type = issuer.GetSpec().ACME.HTTP01.ServiceType
+	service.Spec.Type = serviceType(issuer)
+	// a LoadBalancer service is reached directly by the ACME server rather
+	// than through an ingress controller, so it must serve on port 80
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		service.Spec.Ports[0].Port = 80
 	}
 
 	return service
 }
 
+// serviceType returns the type of service that should be created for the
+// given issuer's HTTP01 challenges. If not set on the issuer, it defaults to
+// NodePort.
+func serviceType(issuer v1alpha1.GenericIssuer) corev1.ServiceType {
+	if issuer.GetSpec().ACME.HTTP01 != nil && issuer.GetSpec().ACME.HTTP01.ServiceType != "" {
+		return issuer.GetSpec().ACME.HTTP01.ServiceType
+	}
+	return corev1.ServiceTypeNodePort
+}
+
 func (s *Solver) cleanupServices(ch *v1alpha1.Challenge) error {
 	services, err := s.getServicesForChallenge(ch)
 	if err != nil {
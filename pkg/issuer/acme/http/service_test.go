@@ -26,6 +26,7 @@ import (
 	coretesting "k8s.io/client-go/testing"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/test/util/generate"
 )
 
 func TestEnsureService(t *testing.T) {
@@ -168,6 +169,55 @@ func TestEnsureService(t *testing.T) {
 	}
 }
 
+func TestBuildServiceType(t *testing.T) {
+	tests := map[string]struct {
+		http01       *v1alpha1.ACMEIssuerHTTP01Config
+		expectedType v1.ServiceType
+		expectedPort int32
+	}{
+		"defaults to NodePort": {
+			expectedType: v1.ServiceTypeNodePort,
+			expectedPort: acmeSolverListenPort,
+		},
+		"uses ClusterIP if set": {
+			http01:       &v1alpha1.ACMEIssuerHTTP01Config{ServiceType: v1.ServiceTypeClusterIP},
+			expectedType: v1.ServiceTypeClusterIP,
+			expectedPort: acmeSolverListenPort,
+		},
+		"serves LoadBalancer services on port 80": {
+			http01:       &v1alpha1.ACMEIssuerHTTP01Config{ServiceType: v1.ServiceTypeLoadBalancer},
+			expectedType: v1.ServiceTypeLoadBalancer,
+			expectedPort: 80,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			issuer := generate.Issuer(generate.IssuerConfig{
+				Name:      defaultTestIssuerName,
+				Namespace: defaultTestNamespace,
+				HTTP01:    tt.http01,
+			})
+			ch := &v1alpha1.Challenge{
+				Spec: v1alpha1.ChallengeSpec{
+					DNSName: "example.com",
+				},
+			}
+
+			svc := buildService(issuer, ch)
+			if svc.Spec.Type != tt.expectedType {
+				t.Errorf("expected service type %q, got %q", tt.expectedType, svc.Spec.Type)
+			}
+			if svc.Spec.Ports[0].Port != tt.expectedPort {
+				t.Errorf("expected service port %d, got %d", tt.expectedPort, svc.Spec.Ports[0].Port)
+			}
+			if svc.Spec.Ports[0].TargetPort.IntValue() != acmeSolverListenPort {
+				t.Errorf("expected target port %d, got %s", acmeSolverListenPort, svc.Spec.Ports[0].TargetPort.String())
+			}
+		})
+	}
+}
+
 func TestGetServicesForCertificate(t *testing.T) {
 	const createdServiceKey = "createdService"
 	tests := map[string]solverFixture{
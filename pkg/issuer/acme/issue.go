@@ -17,6 +17,7 @@ limitations under the License.
 package acme
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/x509"
@@ -25,7 +26,6 @@ import (
 	"hash/fnv"
 	"time"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,6 +36,7 @@ import (
 	"github.com/jetstack/cert-manager/pkg/acme"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
@@ -52,13 +53,13 @@ var (
 func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
 	key, generated, err := a.getCertificatePrivateKey(crt)
 	if err != nil {
-		glog.Errorf("Error getting certificate private key: %v", err)
+		logs.Errorf("Error getting certificate private key: %v", err)
 		return nil, err
 	}
 	if generated {
 		// If we have generated a new private key, we return here to ensure we
 		// successfully persist the key before creating any CSRs with it.
-		glog.V(4).Infof("Storing new certificate private key for %s/%s", crt.Namespace, crt.Name)
+		logs.V(4).Infof("Storing new certificate private key for %s/%s", crt.Namespace, crt.Name)
 		a.Recorder.Eventf(crt, corev1.EventTypeNormal, "Generated", "Generated new private key")
 
 		keyPem, err := pki.EncodePrivateKey(key)
@@ -89,7 +90,7 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 	// have the same name.
 	err = a.cleanupOwnedOrders(crt, expectedOrder.Name)
 	if err != nil {
-		glog.Errorf("Error cleaning up old orders: %v", err)
+		logs.Errorf("Error cleaning up old orders: %v", err)
 		return nil, err
 	}
 
@@ -98,7 +99,7 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 	// the generated expectedOrder.
 	existingOrder, err := a.orderLister.Orders(expectedOrder.Namespace).Get(expectedOrder.Name)
 	if err != nil && !apierrors.IsNotFound(err) {
-		glog.Errorf("Error getting existing Order resource: %v", err)
+		logs.Errorf("Error getting existing Order resource: %v", err)
 		return nil, err
 	}
 	if existingOrder == nil {
@@ -112,14 +113,14 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 	// well as the back-off applied to failing ACME Orders.
 	// They should therefore *only* match on changes to the actual Certificate
 	// resource, or underlying Order (i.e. user interaction).
-	glog.V(4).Infof("Validating existing order CSR for Certificate %s/%s", crt.Namespace, crt.Name)
+	logs.V(4).Infof("Validating existing order CSR for Certificate %s/%s", crt.Namespace, crt.Name)
 
 	validForKey, err := existingOrderIsValidForKey(existingOrder, key)
 	if err != nil {
 		return nil, err
 	}
 	if !validForKey {
-		glog.V(4).Infof("CSR on existing order resource does not match certificate %s/%s private key", crt.Namespace, crt.Name)
+		logs.V(4).Infof("CSR on existing order resource does not match certificate %s/%s private key", crt.Namespace, crt.Name)
 		return nil, a.retryOrder(crt, existingOrder)
 	}
 
@@ -144,14 +145,14 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 		}
 
 		if time.Now().Sub(crt.Status.LastFailureTime.Time) < createOrderWaitDuration {
-			return nil, fmt.Errorf("applying acme order back-off for certificate %s/%s because it has failed within the last %s", crt.Namespace, crt.Name, createOrderWaitDuration)
+			return nil, errors.NewBackoff("applying acme order back-off for certificate %s/%s because it has failed within the last %s", crt.Namespace, crt.Name, createOrderWaitDuration)
 		}
 
 		return nil, a.retryOrder(crt, existingOrder)
 	}
 
 	if existingOrder.Status.State != v1alpha1.Valid {
-		glog.Infof("Order %s/%s is not in 'valid' state. Waiting for Order to transition before attempting to issue Certificate.", existingOrder.Namespace, existingOrder.Name)
+		logs.Infof("Order %s/%s is not in 'valid' state. Waiting for Order to transition before attempting to issue Certificate.", existingOrder.Namespace, existingOrder.Name)
 
 		// We don't immediately requeue, as the change to the Order resource on
 		// transition should trigger the certificate to be re-synced.
@@ -169,7 +170,7 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 	// TODO: replace with a call to a function that returns the whole chain
 	x509Certs, err := pki.DecodeX509CertificateBytes(existingOrder.Status.Certificate)
 	if err != nil {
-		glog.Infof("Error parsing existing x509 certificate on Order resource %q: %v", existingOrder.Name, err)
+		logs.Infof("Error parsing existing x509 certificate on Order resource %q: %v", existingOrder.Name, err)
 		// if parsing the certificate fails, recreate the order
 		return nil, a.retryOrder(crt, existingOrder)
 	}
@@ -182,13 +183,21 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 	// If it is, we recreate the order so we can obtain a fresh certificate.
 	// If not, we return the existing order's certificate to save additional
 	// orders.
-	if a.Context.IssuerOptions.CertificateNeedsRenew(x509Cert, crt.Spec.RenewBefore) {
+	if a.Context.IssuerOptions.CertificateNeedsRenew(x509Cert, crt) {
 		a.Recorder.Eventf(crt, corev1.EventTypeNormal, "OrderExpired", "Order %q contains a certificate nearing expiry. "+
 			"Creating new order...")
 		// existing order's certificate is near expiry
 		return nil, a.retryOrder(crt, existingOrder)
 	}
 
+	// A renewal requested with the force-renew annotation must not be
+	// fulfilled by the certificate that was already issued from this order,
+	// so a new order is created instead.
+	if _, ok := crt.Annotations[v1alpha1.ForceRenewAnnotationKey]; ok && a.certificateInSecret(crt, x509Cert) {
+		a.Recorder.Eventf(crt, corev1.EventTypeNormal, "OrderRenewalRequested", "Creating new order as renewal was requested by the %s annotation", v1alpha1.ForceRenewAnnotationKey)
+		return nil, a.retryOrder(crt, existingOrder)
+	}
+
 	// encode the private key and return
 	keyPem, err := pki.EncodePrivateKey(key)
 	if err != nil {
@@ -202,6 +211,16 @@ func (a *Acme) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Is
 	}, nil
 }
 
+// certificateInSecret returns true if cert is the certificate currently
+// stored in the Secret of crt.
+func (a *Acme) certificateInSecret(crt *v1alpha1.Certificate, cert *x509.Certificate) bool {
+	existing, err := kube.SecretTLSCert(a.secretsLister, crt.Namespace, crt.Spec.SecretName)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(existing.Raw, cert.Raw)
+}
+
 func (a *Acme) cleanupOwnedOrders(crt *v1alpha1.Certificate, retain string) error {
 	labelMap := certLabels(crt.Name)
 	selector := labels.NewSelector()
@@ -226,18 +245,18 @@ func (a *Acme) cleanupOwnedOrders(crt *v1alpha1.Certificate, retain string) erro
 		}
 
 		if o.Name == retain {
-			glog.V(4).Infof("Skipping cleanup for active order resource %q", retain)
+			logs.V(4).Infof("Skipping cleanup for active order resource %q", retain)
 			continue
 		}
 
 		// delete any old order resources
-		glog.Infof("Deleting Order resource %s/%s", o.Namespace, o.Name)
+		logs.Infof("Deleting Order resource %s/%s", o.Namespace, o.Name)
 		a.Recorder.Eventf(crt, corev1.EventTypeNormal, "Cleanup",
 			fmt.Sprintf("Deleting old Order resource %q", o.Name))
 
 		err := a.CMClient.CertmanagerV1alpha1().Orders(o.Namespace).Delete(o.Name, nil)
 		if err != nil && !apierrors.IsNotFound(err) {
-			glog.Errorf("Error deleting Order resource %s/%s: %v", o.Namespace, o.Name, err)
+			logs.Errorf("Error deleting Order resource %s/%s: %v", o.Namespace, o.Name, err)
 			errs = append(errs, err)
 			continue
 		}
@@ -247,14 +266,15 @@ func (a *Acme) cleanupOwnedOrders(crt *v1alpha1.Certificate, retain string) erro
 }
 
 func (a *Acme) getCertificatePrivateKey(crt *v1alpha1.Certificate) (crypto.Signer, bool, error) {
-	glog.V(4).Infof("Attempting to fetch existing certificate private key")
+	logs.V(4).Infof("Attempting to fetch existing certificate private key")
 
-	// If a private key already exists, reuse it.
+	// If a private key already exists, reuse it unless the Certificate's
+	// rotation policy requires a new one.
 	// TODO: if we have not observed the update to the Secret resource with the
 	// private key yet, we may in some cases loop and re-generate the private key
 	// over and over. We could attempt to use the live clientset to read the
 	// private key too to avoid this case.
-	key, err := kube.SecretTLSKey(a.secretsLister, crt.Namespace, crt.Spec.SecretName)
+	key, err := kube.SecretTLSKeyForCertificate(a.secretsLister, crt)
 	if err == nil {
 		return key, false, nil
 	}
@@ -266,19 +286,19 @@ func (a *Acme) getCertificatePrivateKey(crt *v1alpha1.Certificate) (crypto.Signe
 		return nil, false, err
 	}
 
-	glog.V(4).Infof("Generating new private key for %s/%s", crt.Namespace, crt.Name)
+	logs.V(4).Infof("Generating new private key for %s/%s", crt.Namespace, crt.Name)
 
 	// generate a new private key.
-	rsaKey, err := pki.GenerateRSAPrivateKey(2048)
+	key, err = pki.GeneratePrivateKeyForCertificate(crt)
 	if err != nil {
 		return nil, false, err
 	}
 
-	return rsaKey, true, nil
+	return key, true, nil
 }
 
 func (a *Acme) createNewOrder(crt *v1alpha1.Certificate, template *v1alpha1.Order, key crypto.Signer) error {
-	glog.V(4).Infof("Creating new Order resource for Certificate %s/%s", crt.Namespace, crt.Name)
+	logs.V(4).Infof("Creating new Order resource for Certificate %s/%s", crt.Namespace, crt.Name)
 
 	csr, err := pki.GenerateCSR(a.issuer, crt)
 	if err != nil {
@@ -301,7 +321,7 @@ func (a *Acme) createNewOrder(crt *v1alpha1.Certificate, template *v1alpha1.Orde
 	}
 
 	a.Recorder.Eventf(crt, corev1.EventTypeNormal, "OrderCreated", "Created Order resource %q", o.Name)
-	glog.V(4).Infof("Created new Order resource named %q for Certificate %s/%s", template.Name, crt.Namespace, crt.Name)
+	logs.V(4).Infof("Created new Order resource named %q for Certificate %s/%s", template.Name, crt.Namespace, crt.Name)
 
 	return nil
 }
@@ -359,7 +379,11 @@ func buildOrder(crt *v1alpha1.Certificate, csr []byte) (*v1alpha1.Order, error)
 		IssuerRef:  crt.Spec.IssuerRef,
 		CommonName: crt.Spec.CommonName,
 		DNSNames:   crt.Spec.DNSNames,
-		Config:     crt.Spec.ACME.Config,
+		Duration:   crt.Spec.Duration,
+	}
+	// Certificates without spec.acme are solved using the issuer's solvers
+	if crt.Spec.ACME != nil {
+		spec.Config = crt.Spec.ACME.Config
 	}
 	hash, err := hashOrder(spec)
 	if err != nil {
@@ -370,7 +394,7 @@ func buildOrder(crt *v1alpha1.Certificate, csr []byte) (*v1alpha1.Order, error)
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            fmt.Sprintf("%s-%d", crt.Name, hash),
 			Namespace:       crt.Namespace,
-			Labels:          certLabels(crt.Name),
+			Labels:          orderLabels(crt),
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(crt, certificateGvk)},
 		},
 		Spec: spec,
@@ -383,9 +407,26 @@ func certLabels(crtName string) map[string]string {
 	}
 }
 
+// orderLabels returns the labels for an Order created for crt. The
+// Certificate's own labels are copied so that the issuer's solvers can be
+// selected using them.
+func orderLabels(crt *v1alpha1.Certificate) map[string]string {
+	lbls := make(map[string]string, len(crt.Labels)+1)
+	for k, v := range crt.Labels {
+		lbls[k] = v
+	}
+	for k, v := range certLabels(crt.Name) {
+		lbls[k] = v
+	}
+	return lbls
+}
+
 func hashOrder(orderSpec v1alpha1.OrderSpec) (uint32, error) {
 	// create a shallow copy of the OrderSpec so we can overwrite the CSR field
 	orderSpec.CSR = nil
+	// the requested duration is only a hint to the ACME server, so changing
+	// it should not cause an order that is in progress to be abandoned
+	orderSpec.Duration = nil
 
 	orderSpecBytes, err := json.Marshal(orderSpec)
 	if err != nil {
@@ -151,6 +151,17 @@ func TestIssueHappyPath(t *testing.T) {
 	testCertExpiredCertOrder := testCertValidOrder.DeepCopy()
 	testCertExpiredCertOrder.Status.Certificate = testCertExpiringSignedBytesPEM
 
+	// a Certificate whose renewal has been requested with the force-renew
+	// annotation, and Secrets holding either the certificate of the valid
+	// order or an older one
+	testCertForceRenew := testCert.DeepCopy()
+	testCertForceRenew.Annotations = map[string]string{v1alpha1.ForceRenewAnnotationKey: "true"}
+	_, testCertPreviousSignedBytesPEM := generateSelfSignedCert(t, testCert, pk, time.Hour*24*365)
+	testCertIssuedSecret := testCertPrivateKeySecret.DeepCopy()
+	testCertIssuedSecret.Data["tls.crt"] = testCertSignedBytesPEM
+	testCertPreviousSecret := testCertPrivateKeySecret.DeepCopy()
+	testCertPreviousSecret.Data["tls.crt"] = testCertPreviousSignedBytesPEM
+
 	tests := map[string]acmeFixture{
 		"generate a new private key if one does not exist": {
 			Certificate: testCert,
@@ -248,6 +259,38 @@ func TestIssueHappyPath(t *testing.T) {
 			},
 			Err: false,
 		},
+		"create a new order if renewal is forced and the order's certificate has already been issued": {
+			Certificate: testCertForceRenew,
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testCertValidOrder},
+				KubeObjects:        []runtime.Object{testCertIssuedSecret},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(
+						coretesting.NewDeleteAction(v1alpha1.SchemeGroupVersion.WithResource("orders"), testCertValidOrder.Namespace, testCertValidOrder.Name),
+					),
+				},
+			},
+			CheckFn: func(t *testing.T, s *acmeFixture, args ...interface{}) {
+				resp := args[1].(*issuer.IssueResponse)
+				if resp != nil {
+					t.Errorf("expected IssuerResponse to be nil")
+				}
+			},
+		},
+		"retrieve the Certificate bytes from a new 'valid' Order if renewal is forced": {
+			Certificate: testCertForceRenew,
+			Builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testCertValidOrder},
+				KubeObjects:        []runtime.Object{testCertPreviousSecret},
+				ExpectedActions:    []testpkg.Action{},
+			},
+			CheckFn: func(t *testing.T, s *acmeFixture, args ...interface{}) {
+				resp := args[1].(*issuer.IssueResponse)
+				if resp == nil || !reflect.DeepEqual(resp.Certificate, testCertSignedBytesPEM) {
+					t.Errorf("expected the certificate of the new order to be returned")
+				}
+			},
+		},
 		"trigger a renewal if the certificate associated with the order is nearing expiry": {
 			Certificate: testCert,
 			Builder: &testpkg.Builder{
@@ -576,3 +619,86 @@ func TestIssueRetryCases(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildOrderOwnerReference(t *testing.T) {
+	crt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "test-uid"},
+		Spec: v1alpha1.CertificateSpec{
+			CommonName: "example.com",
+			ACME:       &v1alpha1.ACMECertificateConfig{},
+		},
+	}
+	order, err := buildOrder(crt, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building order: %v", err)
+	}
+	refs := order.OwnerReferences
+	if len(refs) != 1 {
+		t.Fatalf("expected one owner reference, got %v", refs)
+	}
+	if refs[0].Kind != "Certificate" || refs[0].Name != crt.Name || refs[0].UID != crt.UID {
+		t.Errorf("expected order to be owned by certificate %q, got %+v", crt.Name, refs[0])
+	}
+	if refs[0].Controller == nil || !*refs[0].Controller {
+		t.Errorf("expected the owner reference to be a controller reference")
+	}
+}
+
+func TestBuildOrderLabels(t *testing.T) {
+	crt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			Labels: map[string]string{
+				"exposure":                              "internal",
+				"acme.cert-manager.io/certificate-name": "other",
+			},
+		},
+		Spec: v1alpha1.CertificateSpec{
+			CommonName: "example.com",
+		},
+	}
+	order, err := buildOrder(crt, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building order: %v", err)
+	}
+	expected := map[string]string{
+		"exposure":                              "internal",
+		"acme.cert-manager.io/certificate-name": "test",
+	}
+	if !reflect.DeepEqual(order.Labels, expected) {
+		t.Errorf("expected order labels %v, got %v", expected, order.Labels)
+	}
+	if order.Spec.Config != nil {
+		t.Errorf("expected no solver config for a certificate without spec.acme, got %v", order.Spec.Config)
+	}
+}
+
+func TestBuildOrderDuration(t *testing.T) {
+	crt := &v1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1alpha1.CertificateSpec{
+			CommonName: "example.com",
+		},
+	}
+	order, err := buildOrder(crt, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building order: %v", err)
+	}
+	if order.Spec.Duration != nil {
+		t.Errorf("expected no duration on the order, got %v", order.Spec.Duration)
+	}
+
+	crt.Spec.Duration = &metav1.Duration{Duration: time.Hour * 24 * 30}
+	orderWithDuration, err := buildOrder(crt, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building order: %v", err)
+	}
+	if orderWithDuration.Spec.Duration == nil || orderWithDuration.Spec.Duration.Duration != crt.Spec.Duration.Duration {
+		t.Errorf("expected order duration %v, got %v", crt.Spec.Duration, orderWithDuration.Spec.Duration)
+	}
+	// changing the requested duration should not replace an existing order
+	if orderWithDuration.Name != order.Name {
+		t.Errorf("expected order name %q to not depend on the duration, got %q", order.Name, orderWithDuration.Name)
+	}
+}
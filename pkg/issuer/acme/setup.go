@@ -18,20 +18,23 @@ package acme
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto"
+	"encoding/base64"
 	"fmt"
 	"net/url"
 	"strings"
 
-	"github.com/golang/glog"
 	"k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/jetstack/cert-manager/pkg/acme"
 	"github.com/jetstack/cert-manager/pkg/acme/client"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
 	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
@@ -49,11 +52,15 @@ const (
 	messageAccountVerified           = "The ACME account was verified with the ACME server"
 )
 
+// clientWithKey builds the ACME client used to verify or register the
+// account. It is a variable so that it can be replaced in tests.
+var clientWithKey = acme.ClientWithKey
+
 // Setup will verify an existing ACME registration, or create one if not
 // already registered.
 func (a *Acme) Setup(ctx context.Context) error {
 	// check if user has specified a v1 account URL, and set a status condition if so.
-	if newURL, ok := acmev1ToV2Mappings[a.issuer.GetSpec().ACME.Server]; ok {
+	if newURL, ok := acmev1ToV2Mappings[a.issuer.GetSpec().ACME.ServerURL()]; ok {
 		a.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, "InvalidConfig",
 			fmt.Sprintf("Your ACME server URL is set to a v1 endpoint (%s). "+
 				"You should update the spec.acme.server field to %q", a.issuer.GetSpec().ACME.Server, newURL))
@@ -75,7 +82,7 @@ func (a *Acme) Setup(ctx context.Context) error {
 	pk, err := a.helper.ReadPrivateKey(a.issuer.GetSpec().ACME.PrivateKey, ns)
 	switch {
 	case apierrors.IsNotFound(err):
-		glog.Infof("%s: generating acme account private key %q", a.issuer.GetObjectMeta().Name, a.issuer.GetSpec().ACME.PrivateKey.Name)
+		logs.Infof("%s: generating acme account private key %q", a.issuer.GetObjectMeta().Name, a.issuer.GetSpec().ACME.PrivateKey.Name)
 		pk, err = a.createAccountPrivateKey(a.issuer.GetSpec().ACME.PrivateKey, ns)
 		if err != nil {
 			s := messageAccountRegistrationFailed + err.Error()
@@ -98,10 +105,10 @@ func (a *Acme) Setup(ctx context.Context) error {
 
 	acme.ClearClientCache()
 
-	cl, err := acme.ClientWithKey(a.issuer, pk)
+	cl, err := clientWithKey(a.issuer, pk)
 	if err != nil {
 		s := messageAccountVerificationFailed + err.Error()
-		glog.Infof("%s: %s", a.issuer.GetObjectMeta().Name, s)
+		logs.Infof("%s: %s", a.issuer.GetObjectMeta().Name, s)
 		a.Recorder.Event(a.issuer, v1.EventTypeWarning, errorAccountVerificationFailed, s)
 		a.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorAccountVerificationFailed, s)
 		return err
@@ -115,7 +122,7 @@ func (a *Acme) Setup(ctx context.Context) error {
 	// the most recent copy of the Issuer and Secret resource we have checked
 	// already.
 
-	rawServerURL := a.issuer.GetSpec().ACME.Server
+	rawServerURL := a.issuer.GetSpec().ACME.ServerURL()
 	parsedServerURL, err := url.Parse(rawServerURL)
 	if err != nil {
 		r := "InvalidURL"
@@ -145,26 +152,33 @@ func (a *Acme) Setup(ctx context.Context) error {
 	// If the Host components of the server URL and the account URL match, then
 	// we skip re-checking the account status to save excess calls to the
 	// ACME api.
+	contacts := accountContacts(a.issuer.GetSpec().ACME)
 	if hasReadyCondition &&
 		a.issuer.GetStatus().ACMEStatus().URI != "" &&
-		parsedAccountURL.Host == parsedServerURL.Host {
-		glog.Infof("Skipping re-verifying ACME account as cached registration " +
+		parsedAccountURL.Host == parsedServerURL.Host &&
+		contactsEqual(a.issuer.GetStatus().ACMEStatus().LastRegisteredContacts, contacts) {
+		logs.Infof("Skipping re-verifying ACME account as cached registration " +
 			"details look sufficient.")
 		return nil
 	}
 
 	if parsedAccountURL.Host != parsedServerURL.Host {
-		glog.Infof("ACME server URL host and ACME private key registration " +
+		logs.Infof("ACME server URL host and ACME private key registration " +
 			"host differ. Re-checking ACME account registration.")
 		a.issuer.GetStatus().ACMEStatus().URI = ""
 	}
 
 	// registerAccount will also verify the account exists if it already
 	// exists.
-	account, err := a.registerAccount(ctx, cl)
+	account, err := a.registerAccount(ctx, cl, ns, contacts)
 	if err != nil {
 		s := messageAccountVerificationFailed + err.Error()
-		glog.Infof("%s: %s", a.issuer.GetObjectMeta().Name, s)
+		logs.Infof("%s: %s", a.issuer.GetObjectMeta().Name, s)
+		if r, ok := errors.SecretErrorReason(err); ok {
+			a.Recorder.Event(a.issuer, v1.EventTypeWarning, r, s)
+			a.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, r, s)
+			return err
+		}
 		a.Recorder.Event(a.issuer, v1.EventTypeWarning, errorAccountVerificationFailed, s)
 		a.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorAccountRegistrationFailed, s)
 
@@ -178,7 +192,7 @@ func (a *Acme) Setup(ctx context.Context) error {
 		// as it implies that something about the request (i.e. email address or private key)
 		// is invalid.
 		if acmeErr.StatusCode >= 400 && acmeErr.StatusCode < 500 {
-			glog.Infof("Skipping retrying account registration as a BadRequest response was returned from the ACME server: %v", acmeErr)
+			logs.Infof("Skipping retrying account registration as a BadRequest response was returned from the ACME server: %v", acmeErr)
 			return nil
 		}
 
@@ -186,36 +200,81 @@ func (a *Acme) Setup(ctx context.Context) error {
 		return err
 	}
 
-	glog.Infof("%s: verified existing registration with ACME server", a.issuer.GetObjectMeta().Name)
+	logs.Infof("%s: verified existing registration with ACME server", a.issuer.GetObjectMeta().Name)
 	a.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionTrue, successAccountRegistered, messageAccountRegistered)
 	a.issuer.GetStatus().ACMEStatus().URI = account.URL
+	a.issuer.GetStatus().ACMEStatus().LastRegisteredContacts = contacts
+
+	return nil
+}
+
+// Validate checks that the directory of the ACME server can be fetched, and
+// that each DNS01 provider configured on the issuer can be instantiated with
+// its credentials. No order is created and no DNS records are presented.
+func (a *Acme) Validate(ctx context.Context) error {
+	cl, err := a.helper.ClientForIssuer(a.issuer)
+	if err != nil {
+		return err
+	}
+
+	if _, err := cl.Discover(ctx); err != nil {
+		return fmt.Errorf("error fetching ACME server directory %q: %v", a.issuer.GetSpec().ACME.ServerURL(), err)
+	}
+
+	dns01 := a.issuer.GetSpec().ACME.DNS01
+	if dns01 == nil {
+		return nil
+	}
+	for _, p := range dns01.Providers {
+		if err := a.dnsProviders.CheckProvider(a.issuer, p.Name); err != nil {
+			return fmt.Errorf("error configuring dns01 provider %q: %v", p.Name, err)
+		}
+	}
 
 	return nil
 }
 
 // registerAccount will register a new ACME account with the server. If an
 // account with the clients private key already exists, it will attempt to look
-// up and verify the corresponding account, and will return that. If this fails
-// due to a not found error it will register a new account with the given key.
-func (a *Acme) registerAccount(ctx context.Context, cl client.Interface) (*acmeapi.Account, error) {
+// up and verify the corresponding account, updating its contacts if they
+// differ, and will return that. If this fails due to a not found error it will
+// register a new account with the given key and contacts.
+// Any external account binding on the issuer is read from the namespace ns.
+func (a *Acme) registerAccount(ctx context.Context, cl client.Interface, ns string, contacts []string) (*acmeapi.Account, error) {
 	// check if the account already exists
 	acc, err := cl.GetAccount(ctx)
 	if err == nil {
-		return acc, nil
+		logs.Infof("%s: found existing acme account %q for private key", a.issuer.GetObjectMeta().Name, acc.URL)
+		if contactsEqual(acc.Contact, contacts) {
+			return acc, nil
+		}
+		logs.Infof("%s: updating contacts of existing acme account", a.issuer.GetObjectMeta().Name)
+		return cl.UpdateAccount(ctx, &acmeapi.Account{
+			URL:     acc.URL,
+			Contact: contacts,
+		})
 	}
 
-	// return all errors except for 404 errors (which indicate the account
-	// is not yet registered)
-	acmeErr, ok := err.(*acmeapi.Error)
-	if !ok || (acmeErr.StatusCode != 400 && acmeErr.StatusCode != 404) {
+	// return all errors except for those indicating the account is not yet
+	// registered. Other bad request errors must not cause a new account to be
+	// registered, as the key may already belong to an existing account.
+	if !acme.IsAccountDoesNotExist(err) {
 		return nil, err
 	}
+	logs.Infof("%s: no existing acme account found for private key, registering a new account", a.issuer.GetObjectMeta().Name)
 
 	acc = &acmeapi.Account{
-		Contact:     []string{fmt.Sprintf("mailto:%s", strings.ToLower(a.issuer.GetSpec().ACME.Email))},
+		Contact:     contacts,
 		TermsAgreed: true,
 	}
 
+	if eab := a.issuer.GetSpec().ACME.ExternalAccountBinding; eab != nil {
+		acc.ExternalAccountBinding, err = a.externalAccountBinding(eab, ns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	acc, err = cl.CreateAccount(ctx, acc)
 	if err != nil {
 		return nil, err
@@ -228,11 +287,66 @@ func (a *Acme) registerAccount(ctx context.Context, cl client.Interface) (*acmea
 	return acc, nil
 }
 
-// createAccountPrivateKey will generate a new RSA private key, and create it
-// as a secret resource in the apiserver.
-func (a *Acme) createAccountPrivateKey(sel v1alpha1.SecretKeySelector, ns string) (*rsa.PrivateKey, error) {
+// accountContacts returns the contact URIs for the emails configured on the
+// given ACME issuer.
+func accountContacts(iss *v1alpha1.ACMEIssuer) []string {
+	emails := iss.Emails
+	if len(iss.Email) > 0 {
+		emails = []string{iss.Email}
+	}
+
+	var contacts []string
+	for _, email := range emails {
+		contacts = append(contacts, fmt.Sprintf("mailto:%s", strings.ToLower(email)))
+	}
+	return contacts
+}
+
+// contactsEqual returns true if a and b contain the same contacts, ignoring
+// order.
+func contactsEqual(a, b []string) bool {
+	return sets.NewString(a...).Equal(sets.NewString(b...))
+}
+
+// externalAccountBinding reads the MAC key of the given external account
+// binding from its Secret in the namespace ns.
+func (a *Acme) externalAccountBinding(eab *v1alpha1.ACMEExternalAccountBinding, ns string) (*acmeapi.ExternalAccountBinding, error) {
+	secrets, err := a.CredentialsLister(a.issuer, a.secretsLister)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := kube.SecretKeyData(secrets, ns, eab.Key.Name, eab.Key.Key)
+	if _, ok := errors.SecretErrorReason(err); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading external account binding key from secret %s/%s: %v", ns, eab.Key.Name, err)
+	}
+
+	// CAs provide the MAC key base64url encoded, with or without padding
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(string(data)), "="))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding external account binding key in secret '%s/%s': %v", ns, eab.Key.Name, err)
+	}
+
+	return &acmeapi.ExternalAccountBinding{
+		KID:       eab.KeyID,
+		Key:       key,
+		Algorithm: string(eab.KeyAlgorithm),
+	}, nil
+}
+
+// createAccountPrivateKey will generate a new private key using the account
+// key parameters on the issuer, and create it as a secret resource in the
+// apiserver.
+func (a *Acme) createAccountPrivateKey(sel v1alpha1.SecretKeySelector, ns string) (crypto.Signer, error) {
 	sel = acme.PrivateKeySelector(sel)
-	accountPrivKey, err := pki.GenerateRSAPrivateKey(pki.MinRSAKeySize)
+	accountPrivKey, err := pki.GeneratePrivateKeyForACMEIssuer(a.issuer.GetSpec().ACME)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := pki.EncodePrivateKey(accountPrivKey)
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +357,7 @@ func (a *Acme) createAccountPrivateKey(sel v1alpha1.SecretKeySelector, ns string
 			Namespace: ns,
 		},
 		Data: map[string][]byte{
-			sel.Key: pki.EncodePKCS1PrivateKey(accountPrivKey),
+			sel.Key: keyPEM,
 		},
 	})
 
@@ -0,0 +1,502 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/acme/client"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
+)
+
+func TestCreateAccountPrivateKey(t *testing.T) {
+	sel := v1alpha1.SecretKeySelector{
+		LocalObjectReference: v1alpha1.LocalObjectReference{Name: "acme-account-key"},
+	}
+
+	tests := map[string]struct {
+		acme v1alpha1.ACMEIssuer
+		// checkKey verifies the key stored in the account key Secret
+		checkKey func(*testing.T, interface{})
+	}{
+		"generate a 2048 bit rsa key by default": {
+			acme: v1alpha1.ACMEIssuer{PrivateKey: sel},
+			checkKey: func(t *testing.T, pk interface{}) {
+				key, ok := pk.(*rsa.PrivateKey)
+				if !ok {
+					t.Fatalf("expected rsa private key, but got %T", pk)
+				}
+				if size := key.N.BitLen(); size != 2048 {
+					t.Errorf("expected 2048 bit key, but got %d", size)
+				}
+			},
+		},
+		"generate an ecdsa key with the requested curve": {
+			acme: v1alpha1.ACMEIssuer{
+				PrivateKey:          sel,
+				PrivateKeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm,
+				PrivateKeyCurve:     v1alpha1.P384KeyCurve,
+			},
+			checkKey: func(t *testing.T, pk interface{}) {
+				key, ok := pk.(*ecdsa.PrivateKey)
+				if !ok {
+					t.Fatalf("expected ecdsa private key, but got %T", pk)
+				}
+				if size := key.Curve.Params().BitSize; size != 384 {
+					t.Errorf("expected P384 curve, but got %d bit curve", size)
+				}
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &acmeFixture{
+				Issuer: gen.Issuer("test-issuer", gen.SetIssuerACME(test.acme)),
+			}
+			s.Setup(t)
+			defer s.Builder.Stop()
+
+			pk, err := s.Acme.createAccountPrivateKey(sel, gen.DefaultTestNamespace)
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			test.checkKey(t, pk)
+
+			secret, err := s.FakeKubeClient().CoreV1().Secrets(gen.DefaultTestNamespace).Get(sel.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting account key secret: %v", err)
+			}
+			stored, err := pki.DecodePrivateKeyBytes(secret.Data["tls.key"])
+			if err != nil {
+				t.Fatalf("error decoding stored account key: %v", err)
+			}
+			test.checkKey(t, stored)
+		})
+	}
+}
+
+func TestRegisterAccountExternalAccountBinding(t *testing.T) {
+	eab := &v1alpha1.ACMEExternalAccountBinding{
+		KeyID: "test-kid",
+		Key: v1alpha1.SecretKeySelector{
+			LocalObjectReference: v1alpha1.LocalObjectReference{Name: "eab-secret"},
+			Key:                  "key",
+		},
+		KeyAlgorithm: v1alpha1.HS384,
+	}
+	eabSecret := func(data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "eab-secret",
+				Namespace: gen.DefaultTestNamespace,
+			},
+			Data: map[string][]byte{"key": []byte(data)},
+		}
+	}
+
+	tests := map[string]struct {
+		secret    *corev1.Secret
+		expectKey []byte
+		expectErr bool
+	}{
+		"register the account with the decoded binding key": {
+			// base64url encoding of "test-mac-key?>"
+			secret:    eabSecret("dGVzdC1tYWMta2V5Pz4"),
+			expectKey: []byte("test-mac-key?>"),
+		},
+		"accept padded binding keys": {
+			secret:    eabSecret("dGVzdC1tYWMta2V5Pz4=\n"),
+			expectKey: []byte("test-mac-key?>"),
+		},
+		"fail if the binding key secret does not exist": {
+			expectErr: true,
+		},
+		"fail if the binding key is not base64url encoded": {
+			secret:    eabSecret("not base64!"),
+			expectErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created *acmeapi.Account
+			builder := &test.Builder{}
+			if tt.secret != nil {
+				builder.KubeObjects = []runtime.Object{tt.secret}
+			}
+			s := &acmeFixture{
+				Builder: builder,
+				Issuer: gen.Issuer("test-issuer", gen.SetIssuerACME(v1alpha1.ACMEIssuer{
+					Email:                  "user@example.com",
+					ExternalAccountBinding: eab,
+				})),
+				Client: &client.FakeACME{
+					FakeGetAccount: func(context.Context) (*acmeapi.Account, error) {
+						return nil, &acmeapi.Error{StatusCode: 404}
+					},
+					FakeCreateAccount: func(ctx context.Context, a *acmeapi.Account) (*acmeapi.Account, error) {
+						created = a
+						return a, nil
+					},
+				},
+			}
+			s.Setup(t)
+			defer s.Builder.Stop()
+
+			_, err := s.Acme.registerAccount(s.Ctx, s.Client, gen.DefaultTestNamespace, nil)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				if created != nil {
+					t.Errorf("expected no account to be created")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+
+			if created == nil || created.ExternalAccountBinding == nil {
+				t.Fatalf("expected account to be created with an external account binding")
+			}
+			binding := created.ExternalAccountBinding
+			if binding.KID != eab.KeyID || binding.Algorithm != string(eab.KeyAlgorithm) {
+				t.Errorf("unexpected binding kid %q and algorithm %q", binding.KID, binding.Algorithm)
+			}
+			if !bytes.Equal(binding.Key, tt.expectKey) {
+				t.Errorf("expected binding key %q, got %q", tt.expectKey, binding.Key)
+			}
+		})
+	}
+}
+
+func TestRegisterAccountContacts(t *testing.T) {
+	notFound := func(context.Context) (*acmeapi.Account, error) {
+		return nil, &acmeapi.Error{StatusCode: 404}
+	}
+	existing := func(contacts ...string) func(context.Context) (*acmeapi.Account, error) {
+		return func(context.Context) (*acmeapi.Account, error) {
+			return &acmeapi.Account{URL: "https://acme.example.com/acct/1", Contact: contacts}, nil
+		}
+	}
+
+	tests := map[string]struct {
+		acme       v1alpha1.ACMEIssuer
+		getAccount func(context.Context) (*acmeapi.Account, error)
+
+		expectCreated []string
+		expectUpdated []string
+		expectNoCalls bool
+	}{
+		"register an account without contacts": {
+			getAccount:    notFound,
+			expectCreated: nil,
+		},
+		"register an account with a single email": {
+			acme:          v1alpha1.ACMEIssuer{Email: "User@example.com"},
+			getAccount:    notFound,
+			expectCreated: []string{"mailto:user@example.com"},
+		},
+		"register an account with multiple emails": {
+			acme:          v1alpha1.ACMEIssuer{Emails: []string{"one@example.com", "two@example.com"}},
+			getAccount:    notFound,
+			expectCreated: []string{"mailto:one@example.com", "mailto:two@example.com"},
+		},
+		"update the contacts of an existing account": {
+			acme:          v1alpha1.ACMEIssuer{Emails: []string{"one@example.com", "two@example.com"}},
+			getAccount:    existing("mailto:one@example.com"),
+			expectUpdated: []string{"mailto:one@example.com", "mailto:two@example.com"},
+		},
+		"remove all contacts from an existing account": {
+			getAccount:    existing("mailto:one@example.com"),
+			expectUpdated: []string{},
+		},
+		"do not update an existing account with the same contacts": {
+			acme:          v1alpha1.ACMEIssuer{Emails: []string{"one@example.com", "two@example.com"}},
+			getAccount:    existing("mailto:two@example.com", "mailto:one@example.com"),
+			expectNoCalls: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created, updated *acmeapi.Account
+			s := &acmeFixture{
+				Issuer: gen.Issuer("test-issuer", gen.SetIssuerACME(tt.acme)),
+				Client: &client.FakeACME{
+					FakeGetAccount: tt.getAccount,
+					FakeCreateAccount: func(ctx context.Context, a *acmeapi.Account) (*acmeapi.Account, error) {
+						created = a
+						return a, nil
+					},
+					FakeUpdateAccount: func(ctx context.Context, a *acmeapi.Account) (*acmeapi.Account, error) {
+						updated = a
+						return a, nil
+					},
+				},
+			}
+			s.Setup(t)
+			defer s.Builder.Stop()
+
+			contacts := accountContacts(s.Issuer.GetSpec().ACME)
+			if _, err := s.Acme.registerAccount(s.Ctx, s.Client, gen.DefaultTestNamespace, contacts); err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+
+			switch {
+			case tt.expectNoCalls:
+				if created != nil || updated != nil {
+					t.Errorf("expected the account not to be created or updated")
+				}
+			case tt.expectUpdated != nil:
+				if created != nil {
+					t.Errorf("expected the existing account not to be re-created")
+				}
+				if updated == nil {
+					t.Fatalf("expected the existing account to be updated")
+				}
+				if updated.URL != "https://acme.example.com/acct/1" {
+					t.Errorf("expected the existing account to be updated, got %q", updated.URL)
+				}
+				if !contactsEqual(updated.Contact, tt.expectUpdated) {
+					t.Errorf("expected contacts %v, got %v", tt.expectUpdated, updated.Contact)
+				}
+			default:
+				if created == nil {
+					t.Fatalf("expected an account to be created")
+				}
+				if !reflect.DeepEqual(created.Contact, tt.expectCreated) {
+					t.Errorf("expected contacts %v, got %v", tt.expectCreated, created.Contact)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterAccountLookup(t *testing.T) {
+	existing := &acmeapi.Account{URL: "https://acme.example.com/acct/1"}
+
+	tests := map[string]struct {
+		getErr error
+
+		expectCreated bool
+		expectErr     bool
+	}{
+		"reuse the existing account registered for the key": {},
+		"register a new account if the server returns not found": {
+			getErr:        &acmeapi.Error{StatusCode: 404},
+			expectCreated: true,
+		},
+		"register a new account if the server returns accountDoesNotExist": {
+			getErr:        &acmeapi.Error{StatusCode: 400, Type: "urn:ietf:params:acme:error:accountDoesNotExist"},
+			expectCreated: true,
+		},
+		"do not register a new account on other bad request errors": {
+			getErr:    &acmeapi.Error{StatusCode: 400, Type: "urn:ietf:params:acme:error:malformed"},
+			expectErr: true,
+		},
+		"do not register a new account on server errors": {
+			getErr:    &acmeapi.Error{StatusCode: 500},
+			expectErr: true,
+		},
+		"do not register a new account on non-acme errors": {
+			getErr:    fmt.Errorf("connection refused"),
+			expectErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			created := false
+			s := &acmeFixture{
+				Issuer: gen.Issuer("test-issuer", gen.SetIssuerACME(v1alpha1.ACMEIssuer{})),
+				Client: &client.FakeACME{
+					FakeGetAccount: func(context.Context) (*acmeapi.Account, error) {
+						if tt.getErr != nil {
+							return nil, tt.getErr
+						}
+						return existing, nil
+					},
+					FakeCreateAccount: func(ctx context.Context, a *acmeapi.Account) (*acmeapi.Account, error) {
+						created = true
+						return &acmeapi.Account{URL: "https://acme.example.com/acct/2"}, nil
+					},
+				},
+			}
+			s.Setup(t)
+			defer s.Builder.Stop()
+
+			acc, err := s.Acme.registerAccount(s.Ctx, s.Client, gen.DefaultTestNamespace, nil)
+			if created != tt.expectCreated {
+				t.Errorf("expected account created=%v, got %v", tt.expectCreated, created)
+			}
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if !tt.expectCreated && acc.URL != existing.URL {
+				t.Errorf("expected existing account %q to be reused, got %q", existing.URL, acc.URL)
+			}
+		})
+	}
+}
+
+func TestSetupReusesExistingAccount(t *testing.T) {
+	pk, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+
+	// an issuer that was deleted and recreated has no account URI in its
+	// status, but its account key Secret still exists
+	s := &acmeFixture{
+		Issuer: gen.Issuer("test-issuer", gen.SetIssuerACME(v1alpha1.ACMEIssuer{
+			Email: "user@example.com",
+			PrivateKey: v1alpha1.SecretKeySelector{
+				LocalObjectReference: v1alpha1.LocalObjectReference{Name: "acme-account-key"},
+			},
+		})),
+		PrivateKey: pk,
+		Client: &client.FakeACME{
+			FakeGetAccount: func(context.Context) (*acmeapi.Account, error) {
+				return &acmeapi.Account{
+					URL:     "https://acme.example.com/acct/1",
+					Contact: []string{"mailto:user@example.com"},
+				}, nil
+			},
+			FakeCreateAccount: func(ctx context.Context, a *acmeapi.Account) (*acmeapi.Account, error) {
+				t.Errorf("expected no new account to be registered")
+				return a, nil
+			},
+		},
+	}
+	s.Setup(t)
+	defer s.Builder.Stop()
+
+	defer func(f func(v1alpha1.GenericIssuer, crypto.Signer) (client.Interface, error)) { clientWithKey = f }(clientWithKey)
+	clientWithKey = func(v1alpha1.GenericIssuer, crypto.Signer) (client.Interface, error) {
+		return s.Client, nil
+	}
+
+	if err := s.Acme.Setup(s.Ctx); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if uri := s.Issuer.GetStatus().ACMEStatus().URI; uri != "https://acme.example.com/acct/1" {
+		t.Errorf("expected existing account URI to be stored in status, got %q", uri)
+	}
+	if !s.Issuer.HasCondition(v1alpha1.IssuerCondition{
+		Type:   v1alpha1.IssuerConditionReady,
+		Status: v1alpha1.ConditionTrue,
+	}) {
+		t.Errorf("expected issuer to be marked as ready")
+	}
+}
+
+// fakeDNSProviders fails to instantiate the DNS01 providers with the given
+// names, and records the providers that were checked.
+type fakeDNSProviders struct {
+	invalid map[string]bool
+	checked []string
+}
+
+func (f *fakeDNSProviders) CheckProvider(issuer v1alpha1.GenericIssuer, providerName string) error {
+	f.checked = append(f.checked, providerName)
+	if f.invalid[providerName] {
+		return fmt.Errorf("invalid credentials")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	acmeIssuer := v1alpha1.ACMEIssuer{
+		Server: "https://acme.example.com/directory",
+		DNS01: &v1alpha1.ACMEIssuerDNS01Config{
+			Providers: []v1alpha1.ACMEIssuerDNS01Provider{
+				{Name: "cloudflare"},
+				{Name: "route53"},
+			},
+		},
+	}
+	discover := func(err error) func(context.Context) (acmeapi.Directory, error) {
+		return func(context.Context) (acmeapi.Directory, error) {
+			return acmeapi.Directory{}, err
+		}
+	}
+
+	tests := map[string]struct {
+		discoverErr     error
+		invalid         map[string]bool
+		expectedChecked []string
+		expectedErr     bool
+	}{
+		"configuration is valid if the directory and all dns01 providers can be loaded": {
+			expectedChecked: []string{"cloudflare", "route53"},
+		},
+		"configuration is invalid if the directory cannot be fetched": {
+			discoverErr: fmt.Errorf("connection refused"),
+			expectedErr: true,
+		},
+		"configuration is invalid if a dns01 provider cannot be instantiated": {
+			invalid:         map[string]bool{"cloudflare": true},
+			expectedChecked: []string{"cloudflare"},
+			expectedErr:     true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &acmeFixture{
+				Issuer: gen.Issuer("test-issuer", gen.SetIssuerACME(acmeIssuer)),
+				Client: &client.FakeACME{FakeDiscover: discover(test.discoverErr)},
+			}
+			s.Setup(t)
+			defer s.Builder.Stop()
+			providers := &fakeDNSProviders{invalid: test.invalid}
+			s.Acme.dnsProviders = providers
+
+			err := s.Acme.Validate(context.Background())
+			if err != nil != test.expectedErr {
+				t.Errorf("expected error %t, got %v", test.expectedErr, err)
+			}
+			if !reflect.DeepEqual(providers.checked, test.expectedChecked) {
+				t.Errorf("expected dns01 providers %v to be checked, got %v", test.expectedChecked, providers.checked)
+			}
+		})
+	}
+}
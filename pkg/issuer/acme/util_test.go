@@ -18,7 +18,7 @@ package acme
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"fmt"
 	"testing"
 	"time"
@@ -42,6 +42,7 @@ type acmeFixture struct {
 	Issuer      v1alpha1.GenericIssuer
 	Certificate *v1alpha1.Certificate
 	Client      *client.FakeACME
+	PrivateKey  crypto.Signer
 	Clock       *fakeclock.FakeClock
 
 	PreFn   func(*testing.T, *acmeFixture)
@@ -119,6 +120,9 @@ func (s *acmeFixture) ClientForIssuer(iss v1alpha1.GenericIssuer) (client.Interf
 	return s.Client, nil
 }
 
-func (s *acmeFixture) ReadPrivateKey(sel v1alpha1.SecretKeySelector, ns string) (*rsa.PrivateKey, error) {
+func (s *acmeFixture) ReadPrivateKey(sel v1alpha1.SecretKeySelector, ns string) (crypto.Signer, error) {
+	if s.PrivateKey != nil {
+		return s.PrivateKey, nil
+	}
 	return nil, fmt.Errorf("not implemented")
 }
@@ -19,12 +19,12 @@ package ca
 import (
 	"context"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
@@ -45,7 +45,7 @@ const (
 // are fixed, it always returns an error on any failure.
 func (c *CA) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
 	// get a copy of the existing/currently issued Certificate's private key
-	signeeKey, err := kube.SecretTLSKey(c.secretsLister, crt.Namespace, crt.Spec.SecretName)
+	signeeKey, err := kube.SecretTLSKeyForCertificate(c.secretsLister, crt)
 	if k8sErrors.IsNotFound(err) || errors.IsInvalidData(err) {
 		// if one does not already exist, generate a new one
 		signeeKey, err = pki.GeneratePrivateKeyForCertificate(crt)
@@ -58,21 +58,21 @@ func (c *CA) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Issu
 		}
 	}
 	if err != nil {
-		glog.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
+		logs.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
 		return nil, err
 	}
 
 	// extract the public component of the key
 	signeePublicKey, err := pki.PublicKeyForPrivateKey(signeeKey)
 	if err != nil {
-		glog.Errorf("Error getting public key from private key: %v", err)
+		logs.Errorf("Error getting public key from private key: %v", err)
 		return nil, err
 	}
 
 	// get a copy of the CA certificate named on the Issuer
 	caCerts, caKey, err := kube.SecretTLSKeyPair(c.secretsLister, c.resourceNamespace, c.issuer.GetSpec().CA.SecretName)
 	if err != nil {
-		glog.Errorf("Error getting signing CA for Issuer: %v", err)
+		logs.Errorf("Error getting signing CA for Issuer: %v", err)
 		return nil, err
 	}
 
@@ -83,6 +83,12 @@ func (c *CA) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.Issu
 		return nil, err
 	}
 
+	// use the serial number strategy configured on the issuer
+	if err := c.setSerialNumber(template); err != nil {
+		c.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorSigning", "Error allocating serial number: %v", err)
+		return nil, err
+	}
+
 	caCert := caCerts[0]
 
 	// sign and encode the certificate
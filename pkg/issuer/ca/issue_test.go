@@ -96,6 +96,47 @@ func allFieldsSetCheck(expectedCA []byte) func(t *testing.T, s *caFixture, args
 	}
 }
 
+func ipAddressesCheck(expected ...string) func(t *testing.T, s *caFixture, args ...interface{}) {
+	return func(t *testing.T, s *caFixture, args ...interface{}) {
+		resp := args[1].(*issuer.IssueResponse)
+		if resp == nil {
+			t.Errorf("expected new certificate to be issued")
+			return
+		}
+		cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+		if err != nil {
+			t.Errorf("error decoding issued certificate: %v", err)
+			return
+		}
+		if got := pki.IPAddressesToString(cert.IPAddresses); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected issued certificate to have IP addresses %v, got %v", expected, got)
+		}
+	}
+}
+
+func urisCheck(expected ...string) func(t *testing.T, s *caFixture, args ...interface{}) {
+	return func(t *testing.T, s *caFixture, args ...interface{}) {
+		resp := args[1].(*issuer.IssueResponse)
+		if resp == nil {
+			t.Errorf("expected new certificate to be issued")
+			return
+		}
+		cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+		if err != nil {
+			t.Errorf("error decoding issued certificate: %v", err)
+			return
+		}
+		if got := pki.URIsToString(cert.URIs); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected issued certificate to have URIs %v, got %v", expected, got)
+		}
+		for _, uri := range cert.URIs {
+			if uri.Scheme != "spiffe" || uri.Host != "example.org" {
+				t.Errorf("expected URI to be parsed as a SPIFFE ID, got %#v", uri)
+			}
+		}
+	}
+}
+
 func TestIssue(t *testing.T) {
 	// Build root RSA CA
 	rsaPK := generateRSAPrivateKey(t)
@@ -193,6 +234,36 @@ func TestIssue(t *testing.T) {
 			CheckFn: allFieldsSetCheck(ecdsaPEMCert),
 			Err:     false,
 		},
+		"sign a Certificate with IP address subject alternative names": {
+			Issuer: gen.Issuer("ca-issuer",
+				gen.SetIssuerCA(v1alpha1.CAIssuer{SecretName: "root-ca-secret"}),
+			),
+			Certificate: gen.Certificate("test-crt",
+				gen.SetCertificateSecretName("crt-output"),
+				gen.SetCertificateIPAddresses("10.0.0.1", "fd00::1"),
+			),
+			Builder: &testpkg.Builder{
+				KubeObjects:        []runtime.Object{rootRSACASecret},
+				CertManagerObjects: []runtime.Object{},
+			},
+			CheckFn: ipAddressesCheck("10.0.0.1", "fd00::1"),
+			Err:     false,
+		},
+		"sign a Certificate with URI subject alternative names": {
+			Issuer: gen.Issuer("ca-issuer",
+				gen.SetIssuerCA(v1alpha1.CAIssuer{SecretName: "root-ca-secret"}),
+			),
+			Certificate: gen.Certificate("test-crt",
+				gen.SetCertificateSecretName("crt-output"),
+				gen.SetCertificateURIs("spiffe://example.org/ns/default/sa/app"),
+			),
+			Builder: &testpkg.Builder{
+				KubeObjects:        []runtime.Object{rootRSACASecret},
+				CertManagerObjects: []runtime.Object{},
+			},
+			CheckFn: urisCheck("spiffe://example.org/ns/default/sa/app"),
+			Err:     false,
+		},
 	}
 
 	for name, test := range tests {
@@ -0,0 +1,144 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// serialNumberConfigMapKey is the key in the counter ConfigMap holding the
+// last serial number allocated.
+const serialNumberConfigMapKey = "serial"
+
+var (
+	// now returns the current time, and can be replaced in tests.
+	now = time.Now
+
+	// lastTimestampSerial is the last serial number allocated using the
+	// timestamp strategy. It ensures serial numbers remain unique if two
+	// certificates are signed within the resolution of the clock.
+	lastTimestampSerial     int64
+	lastTimestampSerialLock sync.Mutex
+)
+
+// setSerialNumber sets the serial number of template according to the
+// serial number strategy configured on the issuer. Templates generated by
+// pki.GenerateTemplate already have a random serial number, so nothing is
+// changed when using the random strategy.
+func (c *CA) setSerialNumber(template *x509.Certificate) error {
+	sn := c.issuer.GetSpec().CA.SerialNumber
+	if sn == nil {
+		return nil
+	}
+
+	switch sn.Strategy {
+	case "", v1alpha1.CASerialNumberRandom:
+		return nil
+	case v1alpha1.CASerialNumberTimestamp:
+		template.SerialNumber = timestampSerialNumber()
+		return nil
+	case v1alpha1.CASerialNumberCounter:
+		serial, err := c.nextCounterSerialNumber(sn.ConfigMapName)
+		if err != nil {
+			return err
+		}
+		template.SerialNumber = serial
+		return nil
+	}
+
+	return fmt.Errorf("unsupported serial number strategy %q", sn.Strategy)
+}
+
+func timestampSerialNumber() *big.Int {
+	lastTimestampSerialLock.Lock()
+	defer lastTimestampSerialLock.Unlock()
+
+	serial := now().UnixNano()
+	if serial <= lastTimestampSerial {
+		serial = lastTimestampSerial + 1
+	}
+	lastTimestampSerial = serial
+
+	return big.NewInt(serial)
+}
+
+// nextCounterSerialNumber increments the counter stored in the named
+// ConfigMap and returns the new value, creating the ConfigMap starting from 1
+// if it does not exist.
+// Certificates are only signed by the elected leader so there is a single
+// writer, but updates are made using the ConfigMap's resourceVersion so that
+// a serial number can never be allocated twice, even if two controllers
+// briefly overlap during a leader election. The counter is persisted before
+// the certificate is signed, so a failure after this point skips a serial
+// number rather than reusing one.
+func (c *CA) nextCounterSerialNumber(name string) (*big.Int, error) {
+	var serial *big.Int
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := c.Client.CoreV1().ConfigMaps(c.resourceNamespace).Get(name, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			serial = big.NewInt(1)
+			_, err = c.Client.CoreV1().ConfigMaps(c.resourceNamespace).Create(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: c.resourceNamespace,
+				},
+				Data: map[string]string{
+					serialNumberConfigMapKey: serial.String(),
+				},
+			})
+			if k8sErrors.IsAlreadyExists(err) {
+				// another writer created the ConfigMap first, so retry
+				// incrementing its counter
+				return k8sErrors.NewConflict(corev1.Resource("configmaps"), name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		last, ok := new(big.Int).SetString(cm.Data[serialNumberConfigMapKey], 10)
+		if !ok || last.Sign() < 0 {
+			return fmt.Errorf("invalid serial number %q in key %q of configmap %s/%s", cm.Data[serialNumberConfigMapKey], serialNumberConfigMapKey, c.resourceNamespace, name)
+		}
+
+		serial = last.Add(last, big.NewInt(1))
+		cm = cm.DeepCopy()
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[serialNumberConfigMapKey] = serial.String()
+		_, err = c.Client.CoreV1().ConfigMaps(c.resourceNamespace).Update(cm)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error allocating serial number from configmap %s/%s: %v", c.resourceNamespace, name, err)
+	}
+
+	return serial, nil
+}
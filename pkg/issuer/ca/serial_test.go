@@ -0,0 +1,200 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coretesting "k8s.io/client-go/testing"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	testpkg "github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func serialNumberIssuer(sn *v1alpha1.CASerialNumber) v1alpha1.GenericIssuer {
+	return gen.Issuer("ca-issuer",
+		gen.SetIssuerCA(v1alpha1.CAIssuer{
+			SecretName:   "root-ca-secret",
+			SerialNumber: sn,
+		}),
+	)
+}
+
+func buildSerialNumberCA(t *testing.T, issuer v1alpha1.GenericIssuer, objects ...runtime.Object) (*CA, *testpkg.Builder) {
+	b := &testpkg.Builder{KubeObjects: objects}
+	b.Start()
+	c, err := NewCA(b.Context, issuer)
+	if err != nil {
+		t.Fatalf("error creating ca issuer: %v", err)
+	}
+	return c.(*CA), b
+}
+
+func TestSetSerialNumberRandom(t *testing.T) {
+	for name, sn := range map[string]*v1alpha1.CASerialNumber{
+		"no serial number config": nil,
+		"random strategy":         {Strategy: v1alpha1.CASerialNumberRandom},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, b := buildSerialNumberCA(t, serialNumberIssuer(sn))
+			defer b.Stop()
+
+			template := &x509.Certificate{SerialNumber: big.NewInt(12345)}
+			if err := c.setSerialNumber(template); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if template.SerialNumber.Int64() != 12345 {
+				t.Errorf("expected random serial number to be left unchanged, got %s", template.SerialNumber)
+			}
+		})
+	}
+}
+
+func TestSetSerialNumberTimestamp(t *testing.T) {
+	defer func() { now = time.Now }()
+	fixed := time.Unix(1546300800, 0)
+	now = func() time.Time { return fixed }
+
+	c, b := buildSerialNumberCA(t, serialNumberIssuer(&v1alpha1.CASerialNumber{Strategy: v1alpha1.CASerialNumberTimestamp}))
+	defer b.Stop()
+
+	first := &x509.Certificate{}
+	if err := c.setSerialNumber(first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.SerialNumber.Int64() < fixed.UnixNano() {
+		t.Errorf("expected serial number to be at least %d, got %s", fixed.UnixNano(), first.SerialNumber)
+	}
+
+	// certificates signed at the same instant must still have unique,
+	// increasing serial numbers
+	second := &x509.Certificate{}
+	if err := c.setSerialNumber(second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.SerialNumber.Cmp(first.SerialNumber) <= 0 {
+		t.Errorf("expected serial number %s to be greater than %s", second.SerialNumber, first.SerialNumber)
+	}
+}
+
+func TestSetSerialNumberCounter(t *testing.T) {
+	counterIssuer := serialNumberIssuer(&v1alpha1.CASerialNumber{
+		Strategy:      v1alpha1.CASerialNumberCounter,
+		ConfigMapName: "ca-serial",
+	})
+	counterConfigMap := func(serial string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ca-serial",
+				Namespace: gen.DefaultTestNamespace,
+			},
+			Data: map[string]string{serialNumberConfigMapKey: serial},
+		}
+	}
+	storedSerial := func(t *testing.T, b *testpkg.Builder) string {
+		cm, err := b.Client.CoreV1().ConfigMaps(gen.DefaultTestNamespace).Get("ca-serial", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting counter configmap: %v", err)
+		}
+		return cm.Data[serialNumberConfigMapKey]
+	}
+
+	t.Run("creates the counter configmap", func(t *testing.T) {
+		c, b := buildSerialNumberCA(t, counterIssuer)
+		defer b.Stop()
+
+		for _, expected := range []int64{1, 2, 3} {
+			template := &x509.Certificate{}
+			if err := c.setSerialNumber(template); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if template.SerialNumber.Int64() != expected {
+				t.Errorf("expected serial number %d, got %s", expected, template.SerialNumber)
+			}
+		}
+		if serial := storedSerial(t, b); serial != "3" {
+			t.Errorf("expected stored serial number to be 3, got %q", serial)
+		}
+	})
+
+	t.Run("increments an existing counter", func(t *testing.T) {
+		c, b := buildSerialNumberCA(t, counterIssuer, counterConfigMap("18446744073709551615"))
+		defer b.Stop()
+
+		template := &x509.Certificate{}
+		if err := c.setSerialNumber(template); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if template.SerialNumber.String() != "18446744073709551616" {
+			t.Errorf("expected serial number 18446744073709551616, got %s", template.SerialNumber)
+		}
+		if serial := storedSerial(t, b); serial != "18446744073709551616" {
+			t.Errorf("expected stored serial number to be 18446744073709551616, got %q", serial)
+		}
+	})
+
+	t.Run("retries if the counter is updated concurrently", func(t *testing.T) {
+		// another writer has already allocated serial number 42, but the
+		// first read returns the stale value and the update conflicts
+		c, b := buildSerialNumberCA(t, counterIssuer, counterConfigMap("42"))
+		defer b.Stop()
+
+		staleRead, conflicted := false, false
+		b.FakeKubeClient().PrependReactor("get", "configmaps", func(action coretesting.Action) (bool, runtime.Object, error) {
+			if staleRead {
+				return false, nil, nil
+			}
+			staleRead = true
+			return true, counterConfigMap("41"), nil
+		})
+		b.FakeKubeClient().PrependReactor("update", "configmaps", func(action coretesting.Action) (bool, runtime.Object, error) {
+			if conflicted {
+				return false, nil, nil
+			}
+			conflicted = true
+			return true, nil, k8sErrors.NewConflict(corev1.Resource("configmaps"), "ca-serial", nil)
+		})
+
+		template := &x509.Certificate{}
+		if err := c.setSerialNumber(template); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if template.SerialNumber.Int64() != 43 {
+			t.Errorf("expected serial number 43, got %s", template.SerialNumber)
+		}
+		if serial := storedSerial(t, b); serial != "43" {
+			t.Errorf("expected stored serial number to be 43, got %q", serial)
+		}
+	})
+
+	t.Run("fails if the counter is invalid", func(t *testing.T) {
+		c, b := buildSerialNumberCA(t, counterIssuer, counterConfigMap("not-a-number"))
+		defer b.Stop()
+
+		if err := c.setSerialNumber(&x509.Certificate{}); err == nil {
+			t.Errorf("expected an error for an invalid counter")
+		}
+	})
+}
@@ -19,10 +19,10 @@ package ca
 import (
 	"context"
 
-	"github.com/golang/glog"
 	"k8s.io/api/core/v1"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
 )
 
@@ -42,7 +42,7 @@ func (c *CA) Setup(ctx context.Context) error {
 	cert, err := kube.SecretTLSCert(c.secretsLister, c.resourceNamespace, c.issuer.GetSpec().CA.SecretName)
 	if err != nil {
 		s := messageErrorGetKeyPair + err.Error()
-		glog.Info(s)
+		logs.Info(s)
 		c.Recorder.Event(c.issuer, v1.EventTypeWarning, errorGetKeyPair, s)
 		c.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorGetKeyPair, s)
 		return err
@@ -51,7 +51,7 @@ func (c *CA) Setup(ctx context.Context) error {
 	_, err = kube.SecretTLSKey(c.secretsLister, c.resourceNamespace, c.issuer.GetSpec().CA.SecretName)
 	if err != nil {
 		s := messageErrorGetKeyPair + err.Error()
-		glog.Info(s)
+		logs.Info(s)
 		c.Recorder.Event(c.issuer, v1.EventTypeWarning, errorGetKeyPair, s)
 		c.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorGetKeyPair, s)
 		return err
@@ -59,14 +59,14 @@ func (c *CA) Setup(ctx context.Context) error {
 
 	if !cert.IsCA {
 		s := messageErrorGetKeyPair + "certificate is not a CA"
-		glog.Info(s)
+		logs.Info(s)
 		c.Recorder.Event(c.issuer, v1.EventTypeWarning, errorInvalidKeyPair, s)
 		c.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorInvalidKeyPair, s)
 		// Don't return an error here as there is nothing more we can do
 		return nil
 	}
 
-	glog.Info(messageKeyPairVerified)
+	logs.Info(messageKeyPairVerified)
 	c.Recorder.Event(c.issuer, v1.EventTypeNormal, successKeyPairVerified, messageKeyPairVerified)
 	c.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionTrue, successKeyPairVerified, messageKeyPairVerified)
 
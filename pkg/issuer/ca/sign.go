@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+var _ issuer.Signer = &CA{}
+
+// Sign signs the certificate signing request of the given CertificateRequest
+// with the CA certificate and private key named on the Issuer.
+func (c *CA) Sign(ctx context.Context, cr *v1alpha1.CertificateRequest) (*issuer.IssueResponse, error) {
+	// generate a x509 certificate template for this CertificateRequest
+	template, err := pki.GenerateTemplateFromCertificateRequest(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	// get a copy of the CA certificate named on the Issuer. Errors are not
+	// returned as invalid data, as the CA may be fixed without changing the
+	// CertificateRequest.
+	caCerts, caKey, err := kube.SecretTLSKeyPair(c.secretsLister, c.resourceNamespace, c.issuer.GetSpec().CA.SecretName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signing CA for Issuer: %v", err)
+	}
+
+	// use the serial number strategy configured on the issuer
+	if err := c.setSerialNumber(template); err != nil {
+		c.Recorder.Eventf(cr, corev1.EventTypeWarning, "ErrorSigning", "Error allocating serial number: %v", err)
+		return nil, err
+	}
+
+	// sign and encode the certificate
+	certPem, _, err := pki.SignCertificate(template, caCerts[0], template.PublicKey, caKey)
+	if err != nil {
+		c.Recorder.Eventf(cr, corev1.EventTypeWarning, "ErrorSigning", "Error signing certificate: %v", err)
+		return nil, err
+	}
+
+	// encode the chain
+	chainPem, err := pki.EncodeX509Chain(caCerts)
+	if err != nil {
+		return nil, err
+	}
+	certPem = append(certPem, chainPem...)
+
+	// encode the CA certificate to be returned alongside the certificate
+	caPem, err := pki.EncodeX509(caCerts[0])
+	if err != nil {
+		c.Recorder.Eventf(cr, corev1.EventTypeWarning, "ErrorSigning", "Error encoding certificate: %v", err)
+		return nil, err
+	}
+
+	return &issuer.IssueResponse{
+		Certificate: certPem,
+		CA:          caPem,
+	}, nil
+}
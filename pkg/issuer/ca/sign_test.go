@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	testpkg "github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func generateCSR(t *testing.T, key crypto.Signer, commonName string, dnsNames ...string) []byte {
+	der, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: dnsNames,
+	}, key)
+	if err != nil {
+		t.Fatalf("error generating certificate request: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestSign(t *testing.T) {
+	caKey := generateRSAPrivateKey(t)
+	_, caPEM := generateSelfSignedCert(t, gen.Certificate("test-root-ca",
+		gen.SetCertificateCommonName("root-ca"),
+		gen.SetCertificateIsCA(true),
+	), caKey, time.Hour*24*60)
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "root-ca-secret",
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			corev1.TLSPrivateKeyKey: pki.EncodePKCS1PrivateKey(caKey),
+			corev1.TLSCertKey:       caPEM,
+		},
+	}
+
+	csrPEM := generateCSR(t, generateECDSAPrivateKey(t), "testing-cn", "example.com")
+	baseCR := gen.CertificateRequest("test-cr",
+		gen.SetCertificateRequestCSR(csrPEM),
+		gen.SetCertificateRequestDuration(&metav1.Duration{Duration: time.Hour}),
+	)
+
+	spiffeID, err := url.Parse("spiffe://example.org/ns/default/sa/app")
+	if err != nil {
+		t.Fatalf("error parsing URI: %v", err)
+	}
+	uriCSR, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "testing-cn"},
+		DNSNames: []string{"example.com"},
+		URIs:     []*url.URL{spiffeID},
+	}, generateRSAPrivateKey(t))
+	if err != nil {
+		t.Fatalf("error generating certificate request: %v", err)
+	}
+
+	tests := map[string]struct {
+		cr           *v1alpha1.CertificateRequest
+		kubeObjects  []runtime.Object
+		expectedURIs []string
+		expectedEKU  []x509.ExtKeyUsage
+		invalidData  bool
+		err          bool
+	}{
+		"sign a CertificateRequest": {
+			cr:          baseCR.DeepCopy(),
+			kubeObjects: []runtime.Object{caSecret},
+		},
+		"sign a CertificateRequest with URI subject alternative names": {
+			cr: gen.CertificateRequestFrom(baseCR.DeepCopy(),
+				gen.SetCertificateRequestCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: uriCSR})),
+			),
+			kubeObjects:  []runtime.Object{caSecret},
+			expectedURIs: []string{"spiffe://example.org/ns/default/sa/app"},
+		},
+		"sign a CertificateRequest with client auth usage": {
+			cr: gen.CertificateRequestFrom(baseCR.DeepCopy(),
+				gen.SetCertificateRequestUsages(v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageClientAuth),
+			),
+			kubeObjects: []runtime.Object{caSecret},
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		"fail to sign a CertificateRequest with an unknown usage": {
+			cr: gen.CertificateRequestFrom(baseCR.DeepCopy(),
+				gen.SetCertificateRequestUsages("teleportation"),
+			),
+			kubeObjects: []runtime.Object{caSecret},
+			invalidData: true,
+			err:         true,
+		},
+		"fail to sign an invalid certificate request": {
+			cr:          gen.CertificateRequestFrom(baseCR.DeepCopy(), gen.SetCertificateRequestCSR([]byte("invalid"))),
+			kubeObjects: []runtime.Object{caSecret},
+			invalidData: true,
+			err:         true,
+		},
+		"retry if the CA secret does not exist": {
+			cr:  baseCR.DeepCopy(),
+			err: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &testpkg.Builder{KubeObjects: test.kubeObjects}
+			c := (&caFixture{}).buildFakeCA(b, gen.Issuer("ca-issuer",
+				gen.SetIssuerCA(v1alpha1.CAIssuer{SecretName: "root-ca-secret"}),
+			))
+			defer b.Stop()
+
+			resp, err := c.Sign(context.Background(), test.cr)
+			if err != nil != test.err {
+				t.Fatalf("expected error %v, got: %v", test.err, err)
+			}
+			if err != nil {
+				if errors.IsInvalidData(err) != test.invalidData {
+					t.Errorf("expected invalid data error %v, got: %v", test.invalidData, err)
+				}
+				return
+			}
+
+			if !bytes.Equal(resp.CA, caPEM) {
+				t.Errorf("expected CA certificate to be returned")
+			}
+			if resp.PrivateKey != nil {
+				t.Errorf("expected no private key to be returned")
+			}
+			cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+			if err != nil {
+				t.Fatalf("error decoding issued certificate: %v", err)
+			}
+			caCert, err := pki.DecodeX509CertificateBytes(resp.CA)
+			if err != nil {
+				t.Fatalf("error decoding CA certificate: %v", err)
+			}
+			if err := cert.CheckSignatureFrom(caCert); err != nil {
+				t.Errorf("expected certificate to be signed by the CA: %v", err)
+			}
+			if cert.Subject.CommonName != "testing-cn" || len(cert.DNSNames) != 1 || cert.DNSNames[0] != "example.com" {
+				t.Errorf("unexpected certificate subject %v and dnsNames %v", cert.Subject, cert.DNSNames)
+			}
+			if d := cert.NotAfter.Sub(cert.NotBefore); d != time.Hour {
+				t.Errorf("expected certificate to be valid for 1h, got %s", d)
+			}
+			if got := pki.URIsToString(cert.URIs); !reflect.DeepEqual(got, test.expectedURIs) {
+				t.Errorf("expected certificate to have URIs %v, got %v", test.expectedURIs, got)
+			}
+			if !reflect.DeepEqual(cert.ExtKeyUsage, test.expectedEKU) {
+				t.Errorf("expected certificate to have extended key usages %v, got %v", test.expectedEKU, cert.ExtKeyUsage)
+			}
+		})
+	}
+}
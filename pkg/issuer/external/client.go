@@ -0,0 +1,253 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// maxResponseSize is the maximum number of bytes read from a webhook
+	// response.
+	maxResponseSize = 1 << 20
+)
+
+// SignRequest is the JSON body POSTed to the webhook for each certificate
+// signing request.
+type SignRequest struct {
+	// Kind, Namespace and Name identify the resource the certificate is
+	// being requested for. Kind is either Certificate or
+	// CertificateRequest.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// CSR is the PEM encoded certificate signing request.
+	CSR string `json:"csr"`
+
+	// Duration is the requested validity period of the certificate, for
+	// example "2160h0m0s".
+	Duration string `json:"duration"`
+
+	// IsCA is true if the certificate should be usable as a CA.
+	IsCA bool `json:"isCA"`
+}
+
+// SignResponse is the JSON body expected from the webhook when a
+// certificate has been signed, along with a 200 OK status.
+type SignResponse struct {
+	// Certificate is the PEM encoded signed certificate, followed by any
+	// intermediate certificates.
+	Certificate string `json:"certificate"`
+
+	// CA is the PEM encoded certificate of the signing CA. If not set, the
+	// second certificate in the chain is used, if any.
+	// +optional
+	CA string `json:"ca,omitempty"`
+}
+
+// ErrorResponse is the JSON body the webhook may return along with a non
+// 200 status to explain why a request could not be signed.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpClient returns an HTTP client configured with the CA bundle, client
+// certificate, proxy and timeout set on the issuer.
+func (e *External) httpClient() (*http.Client, error) {
+	cfg := e.issuer.GetSpec().External
+	if cfg == nil {
+		return nil, fmt.Errorf("external config cannot be empty")
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(cfg.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CABundle) {
+			return nil, fmt.Errorf("error loading external issuer CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if ref := cfg.ClientCertSecretRef; ref != nil {
+		secret, err := kube.GetSecret(e.credentialsLister, e.resourceNamespace, ref.Name)
+		if _, ok := errors.SecretErrorReason(err); ok {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading client certificate from secret %s/%s: %s", e.resourceNamespace, ref.Name, err.Error())
+		}
+		for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+			if _, ok := secret.Data[key]; !ok {
+				return nil, errors.NewSecretMissingKey("no data for %q in secret '%s/%s'", key, e.resourceNamespace, ref.Name)
+			}
+		}
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate from secret %s/%s: %s", e.resourceNamespace, ref.Name, err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	timeout := defaultTimeout
+	if cfg.Timeout != nil {
+		timeout = cfg.Timeout.Duration
+	}
+
+	proxyFunc, err := util.ProxyFunc(e.issuer.GetSpec().Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               proxyFunc,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		Timeout: timeout,
+	}, nil
+}
+
+// sign POSTs the given request to the webhook and returns the signed
+// certificate chain, once it has been checked to be a chain for publicKey,
+// the public key of the request's certificate signing request. Requests
+// rejected by the webhook with a 4xx status, other than 408 and 429, are
+// returned as invalid data errors as retrying the same request will not
+// succeed.
+func (e *External) sign(req *SignRequest, publicKey crypto.PublicKey) (*issuer.IssueResponse, error) {
+	client, err := e.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request: %s", err.Error())
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, e.issuer.GetSpec().External.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error calling external signer: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading external signer response: %s", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(respBody))
+		errResp := ErrorResponse{}
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != "" {
+			msg = errResp.Error
+		}
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, errors.NewInvalidData("external signer rejected request with status %d: %s", resp.StatusCode, msg)
+		}
+		return nil, fmt.Errorf("external signer returned unexpected status %d: %s", resp.StatusCode, msg)
+	}
+
+	signResp := SignResponse{}
+	if err := json.Unmarshal(respBody, &signResp); err != nil {
+		return nil, fmt.Errorf("error decoding external signer response: %s", err.Error())
+	}
+
+	return decodeSignResponse(&signResp, publicKey)
+}
+
+// decodeSignResponse validates the PEM data returned by the webhook and
+// converts it into an IssueResponse. The certificate chain must start with a
+// certificate for publicKey, the public key of the certificate signing
+// request, and each certificate must be signed by the one that follows it.
+func decodeSignResponse(resp *SignResponse, publicKey crypto.PublicKey) (*issuer.IssueResponse, error) {
+	if len(strings.TrimSpace(resp.Certificate)) == 0 {
+		return nil, fmt.Errorf("external signer returned an empty certificate")
+	}
+	certs, err := pki.DecodeX509CertificateChainBytes([]byte(resp.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("external signer returned an invalid certificate: %s", err.Error())
+	}
+	matches, err := pki.PublicKeyMatchesCertificate(publicKey, certs[0])
+	if err != nil {
+		return nil, fmt.Errorf("error checking certificate returned by external signer: %s", err.Error())
+	}
+	if !matches {
+		return nil, fmt.Errorf("external signer returned a certificate that does not match the public key of the certificate signing request")
+	}
+	for i := 0; i+1 < len(certs); i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return nil, fmt.Errorf("external signer returned a certificate chain that is not in order: certificate %d is not signed by certificate %d: %s", i, i+1, err.Error())
+		}
+	}
+	certPEM, err := pki.EncodeX509Chain(certs)
+	if err != nil {
+		return nil, err
+	}
+
+	var caPEM []byte
+	switch {
+	case len(resp.CA) > 0:
+		ca, err := pki.DecodeX509CertificateBytes([]byte(resp.CA))
+		if err != nil {
+			return nil, fmt.Errorf("external signer returned an invalid CA certificate: %s", err.Error())
+		}
+		caPEM, err = pki.EncodeX509(ca)
+		if err != nil {
+			return nil, err
+		}
+	case len(certs) > 1:
+		caPEM, err = pki.EncodeX509(certs[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &issuer.IssueResponse{
+		Certificate: certPEM,
+		CA:          caPEM,
+	}, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+func TestDecodeSignResponse(t *testing.T) {
+	ca := newTestCA(t)
+	key, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	otherKey, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	der, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "example.com"},
+		DNSNames: []string{"example.com"},
+	}, key)
+	if err != nil {
+		t.Fatalf("failed to generate certificate request: %v", err)
+	}
+	chainPEM, err := ca.sign(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to sign certificate request: %v", err)
+	}
+	leafPEM := bytes.TrimSuffix(chainPEM, ca.pem)
+
+	tests := map[string]struct {
+		certificate string
+		publicKey   interface{}
+		expectErr   bool
+	}{
+		"accept a certificate chain for the public key": {
+			certificate: string(chainPEM),
+			publicKey:   key.Public(),
+		},
+		"accept a certificate without a chain": {
+			certificate: string(leafPEM),
+			publicKey:   key.Public(),
+		},
+		"reject an empty certificate": {
+			certificate: "  \n",
+			publicKey:   key.Public(),
+			expectErr:   true,
+		},
+		"reject a certificate for another public key": {
+			certificate: string(chainPEM),
+			publicKey:   otherKey.Public(),
+			expectErr:   true,
+		},
+		"reject a certificate chain that is not in order": {
+			certificate: string(ca.pem) + string(leafPEM),
+			publicKey:   key.Public(),
+			expectErr:   true,
+		},
+		"reject a certificate chain with an unrelated certificate": {
+			certificate: string(leafPEM) + string(newTestCA(t).pem),
+			publicKey:   key.Public(),
+			expectErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := decodeSignResponse(&SignResponse{Certificate: test.certificate}, test.publicKey)
+			if err != nil && !test.expectErr {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if err == nil && test.expectErr {
+				t.Fatalf("expected an error, but got none")
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.HasPrefix(resp.Certificate, leafPEM) {
+				t.Errorf("expected the certificate chain to start with the issued certificate, got %q", resp.Certificate)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+)
+
+// External is an implementation of the Issuer interface that delegates
+// signing to an external service. Certificate signing requests are POSTed
+// to a webhook, which responds with the signed certificate chain.
+type External struct {
+	*controller.Context
+	issuer v1alpha1.GenericIssuer
+
+	secretsLister corelisters.SecretLister
+
+	// credentialsLister is used to read the credentials referenced by the
+	// issuer, which may be read from files instead of Secrets.
+	credentialsLister corelisters.SecretLister
+
+	// Namespace in which to read resources related to this Issuer from.
+	// For Issuers, this will be the namespace of the Issuer.
+	// For ClusterIssuers, this will be the cluster resource namespace.
+	resourceNamespace string
+}
+
+var _ issuer.Signer = &External{}
+
+func NewExternal(ctx *controller.Context, issuer v1alpha1.GenericIssuer) (issuer.Interface, error) {
+	secretsLister := ctx.KubeSharedInformerFactory.Core().V1().Secrets().Lister()
+	credentialsLister, err := ctx.IssuerOptions.CredentialsLister(issuer, secretsLister)
+	if err != nil {
+		return nil, err
+	}
+
+	return &External{
+		Context:           ctx,
+		issuer:            issuer,
+		secretsLister:     secretsLister,
+		credentialsLister: credentialsLister,
+		resourceNamespace: ctx.IssuerOptions.ResourceNamespace(issuer),
+	}, nil
+}
+
+// Register this Issuer with the issuer factory
+func init() {
+	controller.RegisterIssuer(controller.IssuerExternal, NewExternal)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+func (e *External) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
+	// get a copy of the existing/currently issued Certificate's private key
+	signeePrivateKey, err := kube.SecretTLSKeyForCertificate(e.secretsLister, crt)
+	if k8sErrors.IsNotFound(err) || errors.IsInvalidData(err) {
+		// if one does not already exist, generate a new one
+		signeePrivateKey, err = pki.GeneratePrivateKeyForCertificate(crt)
+		if err != nil {
+			e.Recorder.Eventf(crt, corev1.EventTypeWarning, "PrivateKeyError", "Error generating certificate private key: %v", err)
+			// don't trigger a retry. An error from this function implies some
+			// invalid input parameters, and retrying without updating the
+			// resource will not help.
+			return nil, nil
+		}
+	}
+	if err != nil {
+		logs.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
+		return nil, err
+	}
+
+	template, err := pki.GenerateCSR(e.issuer, crt)
+	if err != nil {
+		return nil, err
+	}
+	derBytes, err := pki.EncodeCSR(template, signeePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	csrPEM := &bytes.Buffer{}
+	if err := pem.Encode(csrPEM, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: derBytes}); err != nil {
+		return nil, fmt.Errorf("error encoding certificate request: %s", err.Error())
+	}
+
+	duration := v1alpha1.DefaultCertificateDuration
+	if crt.Spec.Duration != nil {
+		duration = crt.Spec.Duration.Duration
+	}
+
+	resp, err := e.sign(&SignRequest{
+		Kind:      v1alpha1.CertificateKind,
+		Namespace: crt.Namespace,
+		Name:      crt.Name,
+		CSR:       csrPEM.String(),
+		Duration:  duration.String(),
+		IsCA:      crt.Spec.IsCA,
+	}, signeePrivateKey.Public())
+	if err != nil {
+		e.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorSigning", "Failed to sign certificate: %v", err)
+		if errors.IsInvalidData(err) {
+			// the webhook has rejected this request, so don't retry until
+			// the resource has been updated.
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := checkIssuedCertificate(crt, resp.Certificate); err != nil {
+		e.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorSigning", "Certificate returned by external signer does not match the spec: %v", err)
+		// the certificate would be re-issued on every sync, and the webhook
+		// is likely to return the same certificate again, so don't retry
+		// until the resource has been updated.
+		return nil, nil
+	}
+
+	key, err := pki.EncodePrivateKey(signeePrivateKey)
+	if err != nil {
+		e.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorPrivateKey", "Error encoding private key: %v", err)
+		return nil, err
+	}
+	resp.PrivateKey = key
+
+	return resp, nil
+}
+
+// checkIssuedCertificate returns an error describing how the first
+// certificate in certPEM differs from the spec of crt, using the same checks
+// as the certificates controller. The webhook may ignore parts of the
+// certificate signing request, and a certificate that does not match would
+// otherwise be re-issued on every sync.
+func checkIssuedCertificate(crt *v1alpha1.Certificate, certPEM []byte) error {
+	cert, err := pki.DecodeX509CertificateBytes(certPEM)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	if expected := pki.CommonNameForCertificate(crt); cert.Subject.CommonName != expected {
+		errs = append(errs, fmt.Sprintf("common name is %q, expected %q", cert.Subject.CommonName, expected))
+	}
+	if expected := pki.DNSNamesForCertificate(crt); !util.EqualUnsorted(cert.DNSNames, expected) {
+		errs = append(errs, fmt.Sprintf("DNS names are %q, expected %q", cert.DNSNames, expected))
+	}
+	actualIPs, expectedIPs := pki.IPAddressesToString(cert.IPAddresses), pki.IPAddressesToString(pki.IPAddressesForCertificate(crt))
+	if !util.EqualUnsorted(actualIPs, expectedIPs) {
+		errs = append(errs, fmt.Sprintf("IP addresses are %q, expected %q", actualIPs, expectedIPs))
+	}
+	actualURIs, expectedURIs := pki.URIsToString(cert.URIs), pki.URIsToString(pki.URIsForCertificate(crt))
+	if !util.EqualUnsorted(actualURIs, expectedURIs) {
+		errs = append(errs, fmt.Sprintf("URIs are %q, expected %q", actualURIs, expectedURIs))
+	}
+
+	// key usages are not checked if they have been replaced by a custom
+	// extension
+	if !hasExtension(crt, oidKeyUsage) {
+		if certSign := cert.KeyUsage&x509.KeyUsageCertSign != 0; certSign != crt.Spec.IsCA {
+			errs = append(errs, fmt.Sprintf("cert sign usage is %t, expected %t", certSign, crt.Spec.IsCA))
+		}
+	}
+	if len(crt.Spec.Usages) > 0 {
+		ku, eku, err := pki.KeyUsagesForCertificate(crt)
+		if err != nil {
+			return err
+		}
+		if !hasExtension(crt, oidKeyUsage) && cert.KeyUsage != ku {
+			errs = append(errs, fmt.Sprintf("key usages are %#x, expected %#x", cert.KeyUsage, ku))
+		}
+		if !hasExtension(crt, oidExtKeyUsage) && !equalExtKeyUsages(cert.ExtKeyUsage, eku) {
+			errs = append(errs, fmt.Sprintf("extended key usages are %v, expected %v", cert.ExtKeyUsage, eku))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+const (
+	// oidKeyUsage is the object identifier of the x509 key usage extension.
+	oidKeyUsage = "2.5.29.15"
+
+	// oidExtKeyUsage is the object identifier of the x509 extended key usage
+	// extension.
+	oidExtKeyUsage = "2.5.29.37"
+)
+
+func hasExtension(crt *v1alpha1.Certificate, oid string) bool {
+	for _, e := range crt.Spec.Extensions {
+		if e.OID == oid {
+			return true
+		}
+	}
+	return false
+}
+
+// equalExtKeyUsages returns true if a and b contain the same extended key
+// usages, in any order.
+func equalExtKeyUsages(a, b []x509.ExtKeyUsage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+Outer:
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				continue Outer
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+// clientCertSecret returns a kubernetes.io/tls Secret containing a client
+// certificate signed by ca.
+func clientCertSecret(t *testing.T, ca *testCA, name string) *corev1.Secret {
+	key, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate client private key: %v", err)
+	}
+	der, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "cert-manager"},
+	}, key)
+	if err != nil {
+		t.Fatalf("failed to generate client certificate request: %v", err)
+	}
+	crtPEM, err := ca.sign(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to sign client certificate: %v", err)
+	}
+	keyPEM, err := pki.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to encode client private key: %v", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       crtPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+}
+
+func TestIssue(t *testing.T) {
+	baseCrt := gen.Certificate("test-crt",
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateDNSNames("example.com", "www.example.com"),
+		gen.SetCertificateSecretName("test-crt-tls"),
+	)
+
+	tests := map[string]struct {
+		// status is returned by the webhook instead of signing the request
+		status int
+		// mTLS requires the webhook client to present a certificate
+		mTLS bool
+		// modifiers are applied to the Certificate being issued
+		modifiers []gen.CertificateModifier
+
+		expectNil bool
+		expectErr bool
+	}{
+		"issue a certificate using the webhook": {},
+		"present a client certificate to the webhook": {
+			mTLS: true,
+		},
+		"do not retry if the webhook rejects the request": {
+			status:    http.StatusForbidden,
+			expectNil: true,
+		},
+		"retry if the webhook fails": {
+			status:    http.StatusServiceUnavailable,
+			expectNil: true,
+			expectErr: true,
+		},
+		"do not retry if the webhook ignores the requested IP addresses": {
+			modifiers: []gen.CertificateModifier{gen.SetCertificateIPAddresses("10.0.0.1")},
+			expectNil: true,
+		},
+		"do not retry if the webhook does not issue a requested CA certificate": {
+			modifiers: []gen.CertificateModifier{gen.SetCertificateIsCA(true)},
+			expectNil: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var clientCA *testCA
+			var clientCAs *x509.CertPool
+			if tt.mTLS {
+				clientCA = newTestCA(t)
+				clientCAs = x509.NewCertPool()
+				clientCAs.AddCert(clientCA.cert)
+			}
+			w := newWebhook(t, clientCAs)
+			defer w.Close()
+			w.status = tt.status
+
+			cfg := v1alpha1.ExternalIssuer{
+				URL:      w.URL,
+				CABundle: w.caBundle(),
+			}
+			builder := &test.Builder{}
+			if tt.mTLS {
+				cfg.ClientCertSecretRef = &v1alpha1.LocalObjectReference{Name: "client-cert"}
+				builder.KubeObjects = []runtime.Object{clientCertSecret(t, clientCA, "client-cert")}
+			}
+
+			f := &externalFixture{
+				Builder: builder,
+				Issuer:  gen.Issuer("external-issuer", gen.SetIssuerExternal(cfg)),
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			resp, err := f.External.Issue(f.Ctx, gen.CertificateFrom(baseCrt.DeepCopy(), tt.modifiers...))
+			if err != nil && !tt.expectErr {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if err == nil && tt.expectErr {
+				t.Fatalf("expected an error but got none")
+			}
+
+			if len(w.requests) != 1 {
+				t.Fatalf("expected 1 request to the webhook, got %d", len(w.requests))
+			}
+			req := w.requests[0]
+			if req.Kind != v1alpha1.CertificateKind || req.Namespace != baseCrt.Namespace || req.Name != baseCrt.Name {
+				t.Errorf("unexpected resource in request: %s %s/%s", req.Kind, req.Namespace, req.Name)
+			}
+			if req.Duration != v1alpha1.DefaultCertificateDuration.String() {
+				t.Errorf("expected duration %q, got %q", v1alpha1.DefaultCertificateDuration, req.Duration)
+			}
+
+			if tt.expectNil {
+				if resp != nil {
+					t.Errorf("expected nil response, got: %+v", resp)
+				}
+				return
+			}
+			checkIssueResponse(t, resp, w.ca, "example.com")
+			if len(resp.PrivateKey) == 0 {
+				t.Errorf("expected a private key to be returned")
+			}
+		})
+	}
+}
+
+// checkIssueResponse checks that resp contains a certificate for commonName
+// signed by ca, and that ca is returned as the CA.
+func checkIssueResponse(t *testing.T, resp *issuer.IssueResponse, ca *testCA, commonName string) {
+	if resp == nil {
+		t.Fatalf("expected a response, got nil")
+	}
+	cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+	if err != nil {
+		t.Fatalf("failed to decode issued certificate: %v", err)
+	}
+	if cert.Subject.CommonName != commonName {
+		t.Errorf("expected common name %q, got %q", commonName, cert.Subject.CommonName)
+	}
+	if err := cert.CheckSignatureFrom(ca.cert); err != nil {
+		t.Errorf("issued certificate not signed by webhook CA: %v", err)
+	}
+	if !bytes.Equal(resp.CA, ca.pem) {
+		t.Errorf("expected the webhook CA to be returned")
+	}
+}
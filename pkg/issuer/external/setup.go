@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+)
+
+const (
+	successExternalVerified = "ExternalVerified"
+	messageExternalVerified = "External issuer configured"
+
+	errorExternal = "ExternalError"
+
+	messageExternalClientInitFailed = "Failed to initialise external signer client: "
+)
+
+// Setup checks that a client for the webhook can be built from the issuer
+// configuration. The webhook itself is not contacted, as the protocol does
+// not define a health check.
+func (e *External) Setup(ctx context.Context) error {
+	if _, err := e.httpClient(); err != nil {
+		s := messageExternalClientInitFailed + err.Error()
+		reason := errorExternal
+		if r, ok := errors.SecretErrorReason(err); ok {
+			reason = r
+		}
+		logs.Infof("%s: %s", e.issuer.GetObjectMeta().Name, s)
+		e.Recorder.Event(e.issuer, corev1.EventTypeWarning, reason, s)
+		e.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, reason, s)
+		return err
+	}
+
+	logs.Infof("%s: %s", e.issuer.GetObjectMeta().Name, messageExternalVerified)
+	e.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionTrue, successExternalVerified, messageExternalVerified)
+	return nil
+}
+
+// Validate checks that the CA bundle, client certificate and proxy of the
+// issuer can be loaded. As with Setup, the webhook itself is not contacted.
+func (e *External) Validate(ctx context.Context) error {
+	_, err := e.httpClient()
+	return err
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestSetup(t *testing.T) {
+	readyCheck := func(expected v1alpha1.ConditionStatus, reason string) func(*testing.T, *externalFixture, ...interface{}) {
+		return func(t *testing.T, s *externalFixture, args ...interface{}) {
+			conds := s.Issuer.GetStatus().Conditions
+			if len(conds) != 1 {
+				t.Fatalf("expected 1 condition to be set, got %d", len(conds))
+			}
+			if conds[0].Type != v1alpha1.IssuerConditionReady || conds[0].Status != expected || conds[0].Reason != reason {
+				t.Errorf("expected Ready condition with status %q and reason %q, got %+v", expected, reason, conds[0])
+			}
+		}
+	}
+
+	tests := map[string]externalFixture{
+		"mark the issuer ready if the client can be configured": {
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{URL: "https://pki.example.com/sign"}),
+			),
+			CheckFn: readyCheck(v1alpha1.ConditionTrue, successExternalVerified),
+		},
+		"mark the issuer not ready if the client certificate secret does not exist": {
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{
+					URL:                 "https://pki.example.com/sign",
+					ClientCertSecretRef: &v1alpha1.LocalObjectReference{Name: "client-cert"},
+				}),
+			),
+			CheckFn: readyCheck(v1alpha1.ConditionFalse, errors.ReasonSecretNotFound),
+			Err:     true,
+		},
+		"mark the issuer not ready if the client certificate secret is missing the private key": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "client-cert",
+							Namespace: gen.DefaultTestNamespace,
+						},
+						Data: map[string][]byte{corev1.TLSCertKey: []byte("certificate")},
+					},
+				},
+			},
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{
+					URL:                 "https://pki.example.com/sign",
+					ClientCertSecretRef: &v1alpha1.LocalObjectReference{Name: "client-cert"},
+				}),
+			),
+			CheckFn: readyCheck(v1alpha1.ConditionFalse, errors.ReasonSecretMissingKey),
+			Err:     true,
+		},
+		"mark the issuer not ready if the CA bundle is invalid": {
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{
+					URL:      "https://pki.example.com/sign",
+					CABundle: []byte("not a certificate"),
+				}),
+			),
+			CheckFn: readyCheck(v1alpha1.ConditionFalse, errorExternal),
+			Err:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.External.Setup(test.Ctx)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+
+			test.Finish(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]externalFixture{
+		"configuration is valid if the client can be configured": {
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{URL: "https://pki.example.com/sign"}),
+			),
+		},
+		"configuration is invalid if the client certificate secret does not exist": {
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{
+					URL:                 "https://pki.example.com/sign",
+					ClientCertSecretRef: &v1alpha1.LocalObjectReference{Name: "client-cert"},
+				}),
+			),
+			Err: true,
+		},
+		"configuration is invalid if the CA bundle is invalid": {
+			Issuer: gen.Issuer("external-issuer",
+				gen.SetIssuerExternal(v1alpha1.ExternalIssuer{
+					URL:      "https://pki.example.com/sign",
+					CABundle: []byte("not a certificate"),
+				}),
+			),
+			Err: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.External.Validate(test.Ctx)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+
+			test.Finish(t, err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+// Sign forwards the certificate signing request of the given
+// CertificateRequest to the webhook.
+func (e *External) Sign(ctx context.Context, cr *v1alpha1.CertificateRequest) (*issuer.IssueResponse, error) {
+	// check the certificate signing request can be decoded before sending
+	// it to the webhook
+	csr, err := pki.DecodeX509CertificateRequestBytes(cr.Spec.CSRPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := v1alpha1.DefaultCertificateDuration
+	if cr.Spec.Duration != nil {
+		duration = cr.Spec.Duration.Duration
+	}
+
+	resp, err := e.sign(&SignRequest{
+		Kind:      v1alpha1.CertificateRequestKind,
+		Namespace: cr.Namespace,
+		Name:      cr.Name,
+		CSR:       string(cr.Spec.CSRPEM),
+		Duration:  duration.String(),
+		IsCA:      cr.Spec.IsCA,
+	}, csr.PublicKey)
+	if err != nil {
+		e.Recorder.Eventf(cr, corev1.EventTypeWarning, "ErrorSigning", "Failed to sign certificate: %v", err)
+		return nil, err
+	}
+
+	return resp, nil
+}
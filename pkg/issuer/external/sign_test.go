@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net/http"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestSign(t *testing.T) {
+	key, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	der, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "example.com"},
+		DNSNames: []string{"example.com"},
+	}, key)
+	if err != nil {
+		t.Fatalf("failed to generate certificate request: %v", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+
+	tests := map[string]struct {
+		csr    []byte
+		status int
+
+		expectRequest     bool
+		expectErr         bool
+		expectInvalidData bool
+	}{
+		"sign a certificate request using the webhook": {
+			csr:           csrPEM,
+			expectRequest: true,
+		},
+		"fail if the webhook rejects the request": {
+			csr:               csrPEM,
+			status:            http.StatusBadRequest,
+			expectRequest:     true,
+			expectErr:         true,
+			expectInvalidData: true,
+		},
+		"retry if the webhook is rate limiting requests": {
+			csr:           csrPEM,
+			status:        http.StatusTooManyRequests,
+			expectRequest: true,
+			expectErr:     true,
+		},
+		"fail without calling the webhook if the csr is invalid": {
+			csr:               []byte("not a csr"),
+			expectErr:         true,
+			expectInvalidData: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := newWebhook(t, nil)
+			defer w.Close()
+			w.status = tt.status
+
+			f := &externalFixture{
+				Issuer: gen.Issuer("external-issuer", gen.SetIssuerExternal(v1alpha1.ExternalIssuer{
+					URL:      w.URL,
+					CABundle: w.caBundle(),
+				})),
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			cr := gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestCSR(tt.csr),
+				gen.SetCertificateRequestDuration(&metav1.Duration{Duration: time.Hour}),
+			)
+			resp, err := f.External.Sign(f.Ctx, cr)
+			if err != nil && !tt.expectErr {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if err == nil && tt.expectErr {
+				t.Fatalf("expected an error but got none")
+			}
+			if errors.IsInvalidData(err) != tt.expectInvalidData {
+				t.Errorf("expected invalid data error %t, got: %v", tt.expectInvalidData, err)
+			}
+
+			if !tt.expectRequest {
+				if len(w.requests) != 0 {
+					t.Errorf("expected no requests to the webhook, got %d", len(w.requests))
+				}
+				return
+			}
+			if len(w.requests) != 1 {
+				t.Fatalf("expected 1 request to the webhook, got %d", len(w.requests))
+			}
+			req := w.requests[0]
+			if req.Kind != v1alpha1.CertificateRequestKind || req.Name != "test-cr" || req.Duration != "1h0m0s" {
+				t.Errorf("unexpected request sent to webhook: %+v", req)
+			}
+
+			if tt.expectErr {
+				return
+			}
+			checkIssueResponse(t, resp, w.ca, "example.com")
+			if len(resp.PrivateKey) != 0 {
+				t.Errorf("expected no private key to be returned")
+			}
+		})
+	}
+}
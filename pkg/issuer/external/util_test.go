@@ -0,0 +1,190 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+type externalFixture struct {
+	External *External
+	*test.Builder
+
+	Issuer v1alpha1.GenericIssuer
+
+	CheckFn func(*testing.T, *externalFixture, ...interface{})
+	Err     bool
+
+	Ctx context.Context
+}
+
+func (s *externalFixture) Setup(t *testing.T) {
+	if s.Ctx == nil {
+		s.Ctx = context.Background()
+	}
+	if s.Builder == nil {
+		s.Builder = &test.Builder{}
+	}
+	s.Builder.Start()
+	e, err := NewExternal(s.Builder.Context, s.Issuer)
+	if err != nil {
+		t.Fatalf("error creating external issuer: %v", err)
+	}
+	s.External = e.(*External)
+	s.Builder.Sync()
+}
+
+func (s *externalFixture) Finish(t *testing.T, args ...interface{}) {
+	defer s.Builder.Stop()
+	// resync listers before running checks
+	s.Builder.Sync()
+	// run custom checks
+	if s.CheckFn != nil {
+		s.CheckFn(t, s, args...)
+	}
+}
+
+// testCA is a self signed CA used to sign certificates in tests.
+type testCA struct {
+	key  crypto.Signer
+	cert *x509.Certificate
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate CA private key: %v", err)
+	}
+	crt := gen.Certificate("test-root-ca",
+		gen.SetCertificateCommonName("root-ca"),
+		gen.SetCertificateIsCA(true),
+	)
+	selfSigned := gen.Issuer("test", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	template, err := pki.GenerateTemplate(selfSigned, crt)
+	if err != nil {
+		t.Fatalf("failed to generate CA template: %v", err)
+	}
+	caPEM, caCert, err := pki.SignCertificate(template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to sign CA certificate: %v", err)
+	}
+	return &testCA{key: key, cert: caCert, pem: caPEM}
+}
+
+// sign signs the given PEM encoded certificate request, returning the PEM
+// encoded certificate followed by the CA.
+func (ca *testCA) sign(csrPEM []byte, duration time.Duration) ([]byte, error) {
+	csr, err := pki.DecodeX509CertificateRequestBytes(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: ca.cert.SerialNumber,
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(duration),
+	}
+	crtPEM, _, err := pki.SignCertificate(template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+	return append(crtPEM, ca.pem...), nil
+}
+
+// webhook is a fake external signer. Requests are signed by ca unless
+// status is set, in which case an ErrorResponse is returned with that
+// status.
+type webhook struct {
+	*httptest.Server
+	ca *testCA
+
+	lock     sync.Mutex
+	status   int
+	requests []SignRequest
+}
+
+// newWebhook starts a fake external signer. If clientCAs is not nil, the
+// server requires clients to present a certificate signed by it.
+func newWebhook(t *testing.T, clientCAs *x509.CertPool) *webhook {
+	w := &webhook{ca: newTestCA(t)}
+	w.Server = httptest.NewUnstartedServer(http.HandlerFunc(w.serveHTTP))
+	if clientCAs != nil {
+		w.Server.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		}
+	}
+	w.StartTLS()
+	return w
+}
+
+func (w *webhook) serveHTTP(rw http.ResponseWriter, r *http.Request) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	req := SignRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(rw, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	w.requests = append(w.requests, req)
+
+	if w.status != 0 {
+		writeJSON(rw, w.status, ErrorResponse{Error: "request denied"})
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		writeJSON(rw, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	crtPEM, err := w.ca.sign([]byte(req.CSR), duration)
+	if err != nil {
+		writeJSON(rw, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(rw, http.StatusOK, SignResponse{Certificate: string(crtPEM)})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(v)
+}
+
+// caBundle returns the PEM encoded serving certificate of the webhook.
+func (w *webhook) caBundle() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: w.Certificate().Raw})
+}
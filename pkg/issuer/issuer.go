@@ -33,6 +33,28 @@ type Interface interface {
 	Issue(context.Context, *v1alpha1.Certificate) (*IssueResponse, error)
 }
 
+// Validator is implemented by issuers that can check their configuration,
+// including the reachability of any remote service and the validity of the
+// credentials used to access it, without issuing a certificate.
+type Validator interface {
+	// Validate checks the configuration of the issuer. It is called after
+	// Setup has succeeded, and must not request a certificate.
+	Validate(ctx context.Context) error
+}
+
+// Signer is implemented by issuers that can sign the certificate signing
+// request of a CertificateRequest resource directly, without managing a
+// private key or Secret.
+type Signer interface {
+	// Sign attempts to sign the certificate signing request of the given
+	// CertificateRequest. Only the Certificate and CA fields of the returned
+	// IssueResponse are set.
+	// If the request can never be signed without being changed, for example
+	// because the certificate signing request is invalid, the returned error
+	// satisfies errors.IsInvalidData.
+	Sign(context.Context, *v1alpha1.CertificateRequest) (*IssueResponse, error)
+}
+
 type IssueResponse struct {
 	// Certificate is the certificate resource that should be stored in the
 	// target secret.
@@ -19,12 +19,12 @@ package selfsigned
 import (
 	"context"
 
-	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
@@ -32,7 +32,7 @@ import (
 
 func (c *SelfSigned) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
 	// get a copy of the existing/currently issued Certificate's private key
-	signeePrivateKey, err := kube.SecretTLSKey(c.secretsLister, crt.Namespace, crt.Spec.SecretName)
+	signeePrivateKey, err := kube.SecretTLSKeyForCertificate(c.secretsLister, crt)
 	if k8sErrors.IsNotFound(err) || errors.IsInvalidData(err) {
 		// if one does not already exist, generate a new one
 		signeePrivateKey, err = pki.GeneratePrivateKeyForCertificate(crt)
@@ -45,14 +45,14 @@ func (c *SelfSigned) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*iss
 		}
 	}
 	if err != nil {
-		glog.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
+		logs.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
 		return nil, err
 	}
 
 	// extract the public component of the key
 	signeePublicKey, err := pki.PublicKeyForPrivateKey(signeePrivateKey)
 	if err != nil {
-		glog.Errorf("Error getting public key from private key: %v", err)
+		logs.Errorf("Error getting public key from private key: %v", err)
 		return nil, err
 	}
 
@@ -63,6 +63,13 @@ func (c *SelfSigned) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*iss
 		return nil, err
 	}
 
+	// add any additional extensions requested on the Certificate
+	template.ExtraExtensions, err = pki.ExtensionsForCertificate(crt)
+	if err != nil {
+		c.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorSigning", "Error signing certificate: %v", err)
+		return nil, err
+	}
+
 	// sign and encode the certificate
 	certPem, _, err := pki.SignCertificate(template, template, signeePublicKey, signeePrivateKey)
 	if err != nil {
@@ -0,0 +1,433 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selfsigned
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("error marshalling extension value: %v", err)
+	}
+	return b
+}
+
+func TestIssueExtensions(t *testing.T) {
+	oidExtKeyUsage := asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidCustom := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+
+	clientAuth := mustMarshal(t, []asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 2}})
+	custom := mustMarshal(t, "cert-manager")
+
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("crt-output"),
+		gen.SetCertificateCommonName("example.com"),
+	)
+	crt.Spec.Extensions = []v1alpha1.X509Extension{
+		{OID: oidExtKeyUsage.String(), Value: clientAuth},
+		{OID: oidCustom.String(), Critical: true, Value: custom},
+	}
+
+	b := &test.Builder{}
+	b.Start()
+	defer b.Stop()
+	i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+	if err != nil {
+		t.Fatalf("error creating selfsigned issuer: %v", err)
+	}
+	b.Sync()
+
+	resp, err := i.Issue(context.Background(), crt)
+	if err != nil {
+		t.Fatalf("unexpected error issuing certificate: %v", err)
+	}
+	if resp == nil {
+		t.Fatalf("expected a certificate to be issued")
+	}
+
+	cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+	if err != nil {
+		t.Fatalf("error decoding issued certificate: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidExtKeyUsage):
+			if ext.Critical || string(ext.Value) != string(clientAuth) {
+				t.Errorf("unexpected extended key usage extension: %+v", ext)
+			}
+		case ext.Id.Equal(oidCustom):
+			if !ext.Critical || string(ext.Value) != string(custom) {
+				t.Errorf("unexpected custom extension: %+v", ext)
+			}
+		default:
+			continue
+		}
+		if found[ext.Id.String()] {
+			t.Errorf("extension %s appears more than once", ext.Id)
+		}
+		found[ext.Id.String()] = true
+	}
+	if !found[oidExtKeyUsage.String()] || !found[oidCustom.String()] {
+		t.Errorf("expected both extensions to be present, found %v", found)
+	}
+
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageClientAuth {
+		t.Errorf("expected client auth extended key usage, got %v", cert.ExtKeyUsage)
+	}
+	// a critical extension that is not understood must be reported
+	if len(cert.UnhandledCriticalExtensions) != 1 || !cert.UnhandledCriticalExtensions[0].Equal(oidCustom) {
+		t.Errorf("expected custom extension to be an unhandled critical extension, got %v", cert.UnhandledCriticalExtensions)
+	}
+}
+
+func TestIssueIPAddresses(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("crt-output"),
+		gen.SetCertificateIPAddresses("10.0.0.1", "fd00::1"),
+	)
+
+	b := &test.Builder{}
+	b.Start()
+	defer b.Stop()
+	i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+	if err != nil {
+		t.Fatalf("error creating selfsigned issuer: %v", err)
+	}
+	b.Sync()
+
+	resp, err := i.Issue(context.Background(), crt)
+	if err != nil {
+		t.Fatalf("unexpected error issuing certificate: %v", err)
+	}
+	if resp == nil {
+		t.Fatalf("expected a certificate to be issued")
+	}
+
+	cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+	if err != nil {
+		t.Fatalf("error decoding issued certificate: %v", err)
+	}
+	if got := pki.IPAddressesToString(cert.IPAddresses); !reflect.DeepEqual(got, crt.Spec.IPAddresses) {
+		t.Errorf("expected issued certificate to have IP addresses %v, got %v", crt.Spec.IPAddresses, got)
+	}
+}
+
+func TestIssueURIs(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("crt-output"),
+		gen.SetCertificateCommonName("app"),
+		gen.SetCertificateURIs("spiffe://example.org/ns/default/sa/app"),
+	)
+
+	b := &test.Builder{}
+	b.Start()
+	defer b.Stop()
+	i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+	if err != nil {
+		t.Fatalf("error creating selfsigned issuer: %v", err)
+	}
+	b.Sync()
+
+	resp, err := i.Issue(context.Background(), crt)
+	if err != nil {
+		t.Fatalf("unexpected error issuing certificate: %v", err)
+	}
+
+	cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+	if err != nil {
+		t.Fatalf("error decoding issued certificate: %v", err)
+	}
+	if len(cert.URIs) != 1 {
+		t.Fatalf("expected issued certificate to have 1 URI, got %v", cert.URIs)
+	}
+	if uri := cert.URIs[0]; uri.Scheme != "spiffe" || uri.Host != "example.org" || uri.Path != "/ns/default/sa/app" {
+		t.Errorf("unexpected URI on issued certificate: %q", uri)
+	}
+}
+
+func TestIssueUsages(t *testing.T) {
+	tests := map[string]struct {
+		usages      []v1alpha1.KeyUsage
+		isCA        bool
+		expectedKU  x509.KeyUsage
+		expectedEKU []x509.ExtKeyUsage
+	}{
+		"default usages": {
+			expectedKU: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		},
+		"client auth only": {
+			usages:      []v1alpha1.KeyUsage{v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageClientAuth},
+			expectedKU:  x509.KeyUsageDigitalSignature,
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		"code signing": {
+			usages:      []v1alpha1.KeyUsage{v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageCodeSigning},
+			expectedKU:  x509.KeyUsageDigitalSignature,
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		},
+		"server and client auth CA": {
+			usages:      []v1alpha1.KeyUsage{v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageServerAuth, v1alpha1.KeyUsageClientAuth},
+			isCA:        true,
+			expectedKU:  x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := gen.Certificate("test-crt",
+				gen.SetCertificateSecretName("crt-output"),
+				gen.SetCertificateCommonName("example.com"),
+				gen.SetCertificateKeyAlgorithm(v1alpha1.ECDSAKeyAlgorithm),
+				gen.SetCertificateUsages(tt.usages...),
+				gen.SetCertificateIsCA(tt.isCA),
+			)
+
+			b := &test.Builder{}
+			b.Start()
+			defer b.Stop()
+			i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+			if err != nil {
+				t.Fatalf("error creating selfsigned issuer: %v", err)
+			}
+			b.Sync()
+
+			resp, err := i.Issue(context.Background(), crt)
+			if err != nil {
+				t.Fatalf("unexpected error issuing certificate: %v", err)
+			}
+			cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+			if err != nil {
+				t.Fatalf("error decoding issued certificate: %v", err)
+			}
+			if cert.KeyUsage != tt.expectedKU {
+				t.Errorf("expected key usages %#x, got %#x", tt.expectedKU, cert.KeyUsage)
+			}
+			if !reflect.DeepEqual(cert.ExtKeyUsage, tt.expectedEKU) {
+				t.Errorf("expected extended key usages %v, got %v", tt.expectedEKU, cert.ExtKeyUsage)
+			}
+		})
+	}
+}
+
+func TestIssueKeyAlgorithms(t *testing.T) {
+	tests := map[string]struct {
+		algorithm v1alpha1.KeyAlgorithm
+		size      int
+		curve     v1alpha1.KeyCurve
+		check     func(crypto.Signer) bool
+	}{
+		"default rsa key": {
+			check: func(k crypto.Signer) bool {
+				rsaKey, ok := k.(*rsa.PrivateKey)
+				return ok && rsaKey.N.BitLen() == 2048
+			},
+		},
+		"rsa key with keySize": {
+			algorithm: v1alpha1.RSAKeyAlgorithm,
+			size:      4096,
+			check: func(k crypto.Signer) bool {
+				rsaKey, ok := k.(*rsa.PrivateKey)
+				return ok && rsaKey.N.BitLen() == 4096
+			},
+		},
+		"ecdsa key with keyCurve": {
+			algorithm: v1alpha1.ECDSAKeyAlgorithm,
+			curve:     v1alpha1.P384KeyCurve,
+			check: func(k crypto.Signer) bool {
+				ecKey, ok := k.(*ecdsa.PrivateKey)
+				return ok && ecKey.Curve.Params().BitSize == 384
+			},
+		},
+		"ecdsa key with legacy keySize": {
+			algorithm: v1alpha1.ECDSAKeyAlgorithm,
+			size:      521,
+			check: func(k crypto.Signer) bool {
+				ecKey, ok := k.(*ecdsa.PrivateKey)
+				return ok && ecKey.Curve.Params().BitSize == 521
+			},
+		},
+		"ed25519 key": {
+			algorithm: v1alpha1.Ed25519KeyAlgorithm,
+			check: func(k crypto.Signer) bool {
+				_, ok := k.(ed25519.PrivateKey)
+				return ok
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := gen.Certificate("test-crt",
+				gen.SetCertificateSecretName("crt-output"),
+				gen.SetCertificateCommonName("example.com"),
+				gen.SetCertificateKeyAlgorithm(tc.algorithm),
+				gen.SetCertificateKeySize(tc.size),
+				gen.SetCertificateKeyCurve(tc.curve),
+			)
+
+			b := &test.Builder{}
+			b.Start()
+			defer b.Stop()
+			i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+			if err != nil {
+				t.Fatalf("error creating selfsigned issuer: %v", err)
+			}
+			b.Sync()
+
+			resp, err := i.Issue(context.Background(), crt)
+			if err != nil {
+				t.Fatalf("unexpected error issuing certificate: %v", err)
+			}
+			if resp == nil {
+				t.Fatalf("expected a certificate to be issued")
+			}
+
+			key, err := pki.DecodePrivateKeyBytes(resp.PrivateKey)
+			if err != nil {
+				t.Fatalf("error decoding private key: %v", err)
+			}
+			if !tc.check(key) {
+				t.Errorf("unexpected private key type %T", key)
+			}
+			cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+			if err != nil {
+				t.Fatalf("error decoding issued certificate: %v", err)
+			}
+			matches, err := pki.PublicKeyMatchesCertificate(key.Public(), cert)
+			if err != nil || !matches {
+				t.Errorf("expected issued certificate to match private key, matches=%v err=%v", matches, err)
+			}
+		})
+	}
+}
+
+func TestIssuePrivateKeyRotationPolicy(t *testing.T) {
+	existingKey, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	existingKeyPEM, err := pki.EncodePrivateKey(existingKey)
+	if err != nil {
+		t.Fatalf("error encoding private key: %v", err)
+	}
+
+	baseCrt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("crt-output"),
+		gen.SetCertificateCommonName("example.com"),
+	)
+	template, err := pki.GenerateTemplate(nil, baseCrt)
+	if err != nil {
+		t.Fatalf("error generating certificate template: %v", err)
+	}
+	existingCertPEM, _, err := pki.SignCertificate(template, template, existingKey.Public(), existingKey)
+	if err != nil {
+		t.Fatalf("error signing certificate: %v", err)
+	}
+
+	keyOnlySecret := keySecret(t, "crt-output", existingKey)
+	issuedSecret := keySecret(t, "crt-output", existingKey)
+	issuedSecret.Data[corev1.TLSCertKey] = existingCertPEM
+
+	tests := map[string]struct {
+		policy       v1alpha1.PrivateKeyRotationPolicy
+		algorithm    v1alpha1.KeyAlgorithm
+		secret       *corev1.Secret
+		expectReused bool
+		expectErr    bool
+	}{
+		"existing key is re-used if no policy is set": {
+			secret:       issuedSecret,
+			expectReused: true,
+		},
+		"existing key is re-used with the Never policy": {
+			policy:       v1alpha1.RotationPolicyNever,
+			secret:       issuedSecret,
+			expectReused: true,
+		},
+		"existing key that does not match the key algorithm fails with the Never policy": {
+			policy:    v1alpha1.RotationPolicyNever,
+			algorithm: v1alpha1.ECDSAKeyAlgorithm,
+			secret:    issuedSecret,
+			expectErr: true,
+		},
+		"new key is generated with the Always policy": {
+			policy: v1alpha1.RotationPolicyAlways,
+			secret: issuedSecret,
+		},
+		"key without an issued certificate is re-used with the Always policy": {
+			policy:       v1alpha1.RotationPolicyAlways,
+			secret:       keyOnlySecret,
+			expectReused: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := baseCrt.DeepCopy()
+			crt.Spec.KeyAlgorithm = tc.algorithm
+			if tc.policy != "" {
+				crt.Spec.PrivateKey = &v1alpha1.CertificatePrivateKey{RotationPolicy: tc.policy}
+			}
+
+			b := &test.Builder{KubeObjects: []runtime.Object{tc.secret}}
+			b.Start()
+			defer b.Stop()
+			i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+			if err != nil {
+				t.Fatalf("error creating selfsigned issuer: %v", err)
+			}
+			b.Sync()
+
+			resp, err := i.Issue(context.Background(), crt)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error issuing certificate: %v", err)
+			}
+			if resp == nil {
+				t.Fatalf("expected a certificate to be issued")
+			}
+
+			reused := string(resp.PrivateKey) == string(existingKeyPEM)
+			if reused != tc.expectReused {
+				t.Errorf("expected existing private key to be re-used: %v, but got re-used: %v", tc.expectReused, reused)
+			}
+		})
+	}
+}
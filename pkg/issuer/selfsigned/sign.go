@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selfsigned
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+var _ issuer.Signer = &SelfSigned{}
+
+// Sign self signs the certificate signing request of the given
+// CertificateRequest. The private key of the request is read from the Secret
+// named by the CertificateRequest's private-key-secret-name annotation, and
+// must match the public key of the certificate signing request.
+func (c *SelfSigned) Sign(ctx context.Context, cr *v1alpha1.CertificateRequest) (*issuer.IssueResponse, error) {
+	secretName := cr.Annotations[v1alpha1.PrivateKeySecretNameAnnotationKey]
+	if secretName == "" {
+		return nil, errors.NewInvalidData("the %q annotation must be set to the name of the Secret containing the private key of the certificate request", v1alpha1.PrivateKeySecretNameAnnotationKey)
+	}
+
+	// Errors are not returned as invalid data, as the Secret may be created
+	// or fixed without changing the CertificateRequest.
+	signeePrivateKey, err := kube.SecretTLSKey(c.secretsLister, cr.Namespace, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting private key %q for certificate request: %v", secretName, err)
+	}
+
+	csr, err := pki.DecodeX509CertificateRequestBytes(cr.Spec.CSRPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	// extract the public component of the key
+	signeePublicKey, err := pki.PublicKeyForPrivateKey(signeePrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error getting public key from private key: %v", err)
+	}
+
+	matches, err := pki.PublicKeyMatchesCSR(signeePublicKey, csr)
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		return nil, fmt.Errorf("private key in Secret %q does not match the public key of the certificate request", secretName)
+	}
+
+	// generate a x509 certificate template for this CertificateRequest
+	template, err := pki.GenerateTemplateFromCertificateRequest(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	// sign and encode the certificate
+	certPem, _, err := pki.SignCertificate(template, template, signeePublicKey, signeePrivateKey)
+	if err != nil {
+		c.Recorder.Eventf(cr, corev1.EventTypeWarning, "ErrorSigning", "Error signing certificate: %v", err)
+		return nil, err
+	}
+
+	return &issuer.IssueResponse{
+		Certificate: certPem,
+		CA:          certPem,
+	}, nil
+}
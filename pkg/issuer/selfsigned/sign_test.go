@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selfsigned
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func generateCSR(t *testing.T, key crypto.Signer, commonName string) []byte {
+	der, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		t.Fatalf("error generating certificate request: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func keySecret(t *testing.T, name string, key crypto.Signer) *corev1.Secret {
+	keyPEM, err := pki.EncodePrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding private key: %v", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+}
+
+func TestSign(t *testing.T) {
+	key, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	otherKey, err := pki.GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+
+	baseCR := gen.CertificateRequest("test-cr",
+		gen.SetCertificateRequestCSR(generateCSR(t, key, "example.com")),
+		gen.SetCertificateRequestIsCA(true),
+		gen.SetCertificateRequestAnnotations(map[string]string{
+			v1alpha1.PrivateKeySecretNameAnnotationKey: "test-key",
+		}),
+	)
+
+	tests := map[string]struct {
+		cr          *v1alpha1.CertificateRequest
+		kubeObjects []runtime.Object
+		invalidData bool
+		err         bool
+	}{
+		"sign a CertificateRequest with the private key in the annotated Secret": {
+			cr:          baseCR.DeepCopy(),
+			kubeObjects: []runtime.Object{keySecret(t, "test-key", key)},
+		},
+		"fail to sign a CertificateRequest without the private key annotation": {
+			cr:          gen.CertificateRequestFrom(baseCR.DeepCopy(), gen.SetCertificateRequestAnnotations(nil)),
+			kubeObjects: []runtime.Object{keySecret(t, "test-key", key)},
+			invalidData: true,
+			err:         true,
+		},
+		"retry if the private key Secret does not exist": {
+			cr:  baseCR.DeepCopy(),
+			err: true,
+		},
+		"retry if the private key does not match the certificate request": {
+			cr:          baseCR.DeepCopy(),
+			kubeObjects: []runtime.Object{keySecret(t, "test-key", otherKey)},
+			err:         true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &test.Builder{KubeObjects: tc.kubeObjects}
+			b.Start()
+			defer b.Stop()
+			i, err := NewSelfSigned(b.Context, gen.Issuer("selfsigned", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})))
+			if err != nil {
+				t.Fatalf("error creating selfsigned issuer: %v", err)
+			}
+			b.Sync()
+
+			resp, err := i.(*SelfSigned).Sign(context.Background(), tc.cr)
+			if err != nil != tc.err {
+				t.Fatalf("expected error %v, got: %v", tc.err, err)
+			}
+			if err != nil {
+				if errors.IsInvalidData(err) != tc.invalidData {
+					t.Errorf("expected invalid data error %v, got: %v", tc.invalidData, err)
+				}
+				return
+			}
+
+			if resp.PrivateKey != nil {
+				t.Errorf("expected no private key to be returned")
+			}
+			if !bytes.Equal(resp.CA, resp.Certificate) {
+				t.Errorf("expected the certificate to be returned as its own CA")
+			}
+			cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+			if err != nil {
+				t.Fatalf("error decoding issued certificate: %v", err)
+			}
+			if err := cert.CheckSignatureFrom(cert); err != nil {
+				t.Errorf("expected certificate to be self signed: %v", err)
+			}
+			if !cert.IsCA || cert.Subject.CommonName != "example.com" {
+				t.Errorf("unexpected certificate: isCA=%v commonName=%q", cert.IsCA, cert.Subject.CommonName)
+			}
+		})
+	}
+}
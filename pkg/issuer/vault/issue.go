@@ -27,7 +27,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	vault "github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/certutil"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +34,8 @@ import (
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
@@ -53,7 +54,7 @@ const (
 
 func (v *Vault) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
 	// get a copy of the existing/currently issued Certificate's private key
-	signeePrivateKey, err := kube.SecretTLSKey(v.secretsLister, crt.Namespace, crt.Spec.SecretName)
+	signeePrivateKey, err := kube.SecretTLSKeyForCertificate(v.secretsLister, crt)
 	if k8sErrors.IsNotFound(err) || errors.IsInvalidData(err) {
 		// if one does not already exist, generate a new one
 		signeePrivateKey, err = pki.GeneratePrivateKeyForCertificate(crt)
@@ -66,7 +67,7 @@ func (v *Vault) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.I
 		}
 	}
 	if err != nil {
-		glog.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
+		logs.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
 		return nil, err
 	}
 
@@ -130,6 +131,24 @@ func (v *Vault) configureCertPool(cfg *vault.Config) error {
 	return nil
 }
 
+// configureProxy sends requests to Vault through the proxy configured on the
+// issuer, if any. Otherwise the proxy set in the environment is used.
+func (v *Vault) configureProxy(cfg *vault.Config) error {
+	proxy := v.issuer.GetSpec().Proxy
+	if proxy == "" {
+		return nil
+	}
+
+	proxyFunc, err := util.ProxyFunc(proxy)
+	if err != nil {
+		return err
+	}
+
+	cfg.HttpClient.Transport.(*http.Transport).Proxy = proxyFunc
+
+	return nil
+}
+
 func (v *Vault) initVaultClient() (*vault.Client, error) {
 	vaultCfg := vault.DefaultConfig()
 	vaultCfg.Address = v.issuer.GetSpec().Vault.Server
@@ -137,6 +156,9 @@ func (v *Vault) initVaultClient() (*vault.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := v.configureProxy(vaultCfg); err != nil {
+		return nil, err
+	}
 
 	client, err := vault.NewClient(vaultCfg)
 	if err != nil {
@@ -146,6 +168,9 @@ func (v *Vault) initVaultClient() (*vault.Client, error) {
 	tokenRef := v.issuer.GetSpec().Vault.Auth.TokenSecretRef
 	if tokenRef.Name != "" {
 		token, err := v.vaultTokenRef(tokenRef.Name, tokenRef.Key)
+		if _, ok := errors.SecretErrorReason(err); ok {
+			return nil, err
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error reading Vault token from secret %s/%s: %s", v.resourceNamespace, tokenRef.Name, err.Error())
 		}
@@ -157,6 +182,9 @@ func (v *Vault) initVaultClient() (*vault.Client, error) {
 	appRole := v.issuer.GetSpec().Vault.Auth.AppRole
 	if appRole.RoleId != "" {
 		token, err := v.requestTokenWithAppRoleRef(client, &appRole)
+		if _, ok := errors.SecretErrorReason(err); ok {
+			return nil, err
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error reading Vault token from AppRole: %s", err.Error())
 		}
@@ -165,11 +193,28 @@ func (v *Vault) initVaultClient() (*vault.Client, error) {
 		return client, nil
 	}
 
-	return nil, fmt.Errorf("error initializing Vault client. tokenSecretRef or appRoleSecretRef not set")
+	kubernetesAuth := v.issuer.GetSpec().Vault.Auth.Kubernetes
+	if kubernetesAuth != nil {
+		token, err := v.requestTokenWithKubernetesAuth(client, kubernetesAuth)
+		if _, ok := errors.SecretErrorReason(err); ok {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading Vault token using Kubernetes auth: %s", err.Error())
+		}
+		client.SetToken(token)
+
+		return client, nil
+	}
+
+	return nil, fmt.Errorf("error initializing Vault client. tokenSecretRef, appRoleSecretRef or kubernetes not set")
 }
 
 func (v *Vault) requestTokenWithAppRoleRef(client *vault.Client, appRole *v1alpha1.VaultAppRole) (string, error) {
 	roleId, secretId, err := v.appRoleRef(appRole)
+	if _, ok := errors.SecretErrorReason(err); ok {
+		return "", err
+	}
 	if err != nil {
 		return "", fmt.Errorf("error reading Vault AppRole from secret: %s/%s: %s", appRole.SecretRef.Name, v.resourceNamespace, err.Error())
 	}
@@ -221,7 +266,7 @@ func (v *Vault) requestVaultCert(commonName string, certDuration time.Duration,
 		return nil, nil, err
 	}
 
-	glog.V(4).Infof("Vault certificate request for commonName %s altNames: %q ipSans: %q", commonName, altNames, ipSans)
+	logs.V(4).Infof("Vault certificate request for commonName %s altNames: %q ipSans: %q", commonName, altNames, ipSans)
 
 	parameters := map[string]string{
 		"common_name":          commonName,
@@ -242,6 +287,27 @@ func (v *Vault) requestVaultCert(commonName string, certDuration time.Duration,
 	}
 
 	resp, err := client.RawRequest(request)
+	kubernetesAuth := v.issuer.GetSpec().Vault.Auth.Kubernetes
+	if err != nil && resp != nil && resp.StatusCode == http.StatusForbidden && kubernetesAuth != nil {
+		// The cached token may have expired or been revoked, so log in
+		// again and retry the request once.
+		resp.Body.Close()
+		logs.V(4).Infof("Vault token for issuer %q was rejected, logging in again", v.issuer.GetObjectMeta().Name)
+		kubernetesTokens.forget(v.kubernetesTokenCacheKey(kubernetesAuth))
+
+		client, err = v.initVaultClient()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		request = client.NewRequest("POST", url)
+		err = request.SetJSONBody(parameters)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error encoding Vault parameters: %s", err.Error())
+		}
+
+		resp, err = client.RawRequest(request)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("error signing certificate in Vault: %s", err.Error())
 	}
@@ -275,19 +341,14 @@ func (v *Vault) requestVaultCert(commonName string, certDuration time.Duration,
 func (v *Vault) appRoleRef(appRole *v1alpha1.VaultAppRole) (roleId, secretId string, err error) {
 	roleId = strings.TrimSpace(appRole.RoleId)
 
-	secret, err := v.secretsLister.Secrets(v.resourceNamespace).Get(appRole.SecretRef.Name)
-	if err != nil {
-		return "", "", err
-	}
-
 	key := "secretId"
 	if appRole.SecretRef.Key != "secretId" {
 		key = appRole.SecretRef.Key
 	}
 
-	keyBytes, ok := secret.Data[key]
-	if !ok {
-		return "", "", fmt.Errorf("no data for %q in secret '%s/%s'", key, appRole.SecretRef.Name, v.resourceNamespace)
+	keyBytes, err := kube.SecretKeyData(v.credentialsLister, v.resourceNamespace, appRole.SecretRef.Name, key)
+	if err != nil {
+		return "", "", err
 	}
 
 	secretId = string(keyBytes)
@@ -297,18 +358,13 @@ func (v *Vault) appRoleRef(appRole *v1alpha1.VaultAppRole) (roleId, secretId str
 }
 
 func (v *Vault) vaultTokenRef(name, key string) (string, error) {
-	secret, err := v.secretsLister.Secrets(v.resourceNamespace).Get(name)
-	if err != nil {
-		return "", err
-	}
-
 	if key == "" {
 		key = "token"
 	}
 
-	keyBytes, ok := secret.Data[key]
-	if !ok {
-		return "", fmt.Errorf("no data for %q in secret '%s/%s'", key, name, v.resourceNamespace)
+	keyBytes, err := kube.SecretKeyData(v.credentialsLister, v.resourceNamespace, name, key)
+	if err != nil {
+		return "", err
 	}
 
 	token := string(keyBytes)
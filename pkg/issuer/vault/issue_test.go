@@ -0,0 +1,217 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestConfigureCertPool(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tests := map[string]struct {
+		caBundle   []byte
+		configErr  bool
+		requestErr bool
+	}{
+		"server is not trusted without a CA bundle": {
+			requestErr: true,
+		},
+		"server is trusted using the CA bundle": {
+			caBundle: caBundle,
+		},
+		"invalid CA bundle": {
+			caBundle:  []byte("not a certificate"),
+			configErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := &Vault{
+				issuer: gen.Issuer("vault-issuer",
+					gen.SetIssuerVault(v1alpha1.VaultIssuer{
+						Server:   server.URL,
+						CABundle: test.caBundle,
+					}),
+				),
+			}
+			cfg := vault.DefaultConfig()
+			err := v.configureCertPool(cfg)
+			if err != nil != test.configErr {
+				t.Fatalf("expected configuration error %t, got %v", test.configErr, err)
+			}
+			if err != nil {
+				return
+			}
+			resp, err := cfg.HttpClient.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if err != nil != test.requestErr {
+				t.Errorf("expected request error %t, got %v", test.requestErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigureProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	iss := gen.Issuer("vault-issuer",
+		gen.SetIssuerVault(v1alpha1.VaultIssuer{
+			Server: "http://vault.example.com:8200",
+		}),
+	)
+	iss.Spec.Proxy = proxy.URL
+	v := &Vault{issuer: iss}
+
+	cfg := vault.DefaultConfig()
+	if err := v.configureProxy(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := cfg.HttpClient.Get("http://vault.example.com:8200/v1/sys/health")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://vault.example.com:8200/v1/sys/health" {
+		t.Errorf("expected request to be sent through the proxy, but proxy received %q", proxied)
+	}
+}
+
+func TestIssueIPAddresses(t *testing.T) {
+	caKey, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	caTemplate, err := pki.GenerateTemplate(gen.Issuer("ca", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})),
+		gen.Certificate("ca", gen.SetCertificateCommonName("vault-ca"), gen.SetCertificateIsCA(true)))
+	if err != nil {
+		t.Fatalf("error generating CA template: %v", err)
+	}
+	caPEM, caCert, err := pki.SignCertificate(caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("error signing CA certificate: %v", err)
+	}
+
+	// the fake sign endpoint behaves like Vault, using the ip_sans
+	// parameter rather than the IP addresses in the CSR
+	var ipSans string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("error decoding sign request: %v", err)
+		}
+		ipSans = params["ip_sans"]
+		csr, err := pki.DecodeX509CertificateRequestBytes([]byte(params["csr"]))
+		if err != nil {
+			t.Errorf("error decoding CSR: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		template := *caTemplate
+		template.IsCA = false
+		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		template.Subject = csr.Subject
+		template.DNSNames = csr.DNSNames
+		template.IPAddresses = nil
+		for _, ip := range strings.Split(ipSans, ",") {
+			if ip != "" {
+				template.IPAddresses = append(template.IPAddresses, net.ParseIP(ip))
+			}
+		}
+		certPEM, _, err := pki.SignCertificate(&template, caCert, csr.PublicKey, caKey)
+		if err != nil {
+			t.Errorf("error signing certificate: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": string(certPEM),
+				"issuing_ca":  string(caPEM),
+			},
+		})
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vault-token",
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte("vault-token"),
+		},
+	}
+	issuer := gen.Issuer("vault-issuer", gen.SetIssuerVault(v1alpha1.VaultIssuer{
+		Server: server.URL,
+		Path:   "pki/sign/example-dot-com",
+		Auth: v1alpha1.VaultAuth{
+			TokenSecretRef: v1alpha1.SecretKeySelector{
+				LocalObjectReference: v1alpha1.LocalObjectReference{Name: "vault-token"},
+				Key:                  "token",
+			},
+		},
+	}))
+	v, b := buildFakeVault(t, issuer, secret)
+	defer b.Stop()
+
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("crt-output"),
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateIPAddresses("10.0.0.1", "fd00::1"),
+	)
+
+	resp, err := v.Issue(context.Background(), crt)
+	if err != nil {
+		t.Fatalf("unexpected error issuing certificate: %v", err)
+	}
+	if ipSans != "10.0.0.1,fd00::1" {
+		t.Errorf("expected ip_sans to be sent to Vault, got %q", ipSans)
+	}
+	cert, err := pki.DecodeX509CertificateBytes(resp.Certificate)
+	if err != nil {
+		t.Fatalf("error decoding issued certificate: %v", err)
+	}
+	if got := pki.IPAddressesToString(cert.IPAddresses); !reflect.DeepEqual(got, crt.Spec.IPAddresses) {
+		t.Errorf("expected issued certificate to have IP addresses %v, got %v", crt.Spec.IPAddresses, got)
+	}
+}
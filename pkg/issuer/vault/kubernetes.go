@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+)
+
+const (
+	defaultKubernetesAuthPath = "kubernetes"
+	defaultServiceAccountKey  = "token"
+
+	// defaultServiceAccountTokenPath is where the token of the service
+	// account cert-manager runs as is mounted.
+	defaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// kubernetesTokenExpiryMargin is how long before a cached Vault token
+	// expires that a new one will be requested.
+	kubernetesTokenExpiryMargin = 30 * time.Second
+)
+
+// kubernetesTokens caches Vault tokens obtained with the Kubernetes auth
+// method. A new Vault issuer is built for every sync, so without this every
+// sync would log in to Vault and create a new token.
+var kubernetesTokens = &tokenCache{tokens: make(map[string]cachedToken)}
+
+// now returns the current time, and can be replaced in tests.
+var now = time.Now
+
+type cachedToken struct {
+	token string
+	// expiry is the time after which the token should no longer be used.
+	// A zero expiry means the token does not expire.
+	expiry time.Time
+}
+
+type tokenCache struct {
+	lock   sync.Mutex
+	tokens map[string]cachedToken
+}
+
+func (c *tokenCache) get(key string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t, ok := c.tokens[key]
+	if !ok {
+		return "", false
+	}
+	if !t.expiry.IsZero() && !now().Before(t.expiry) {
+		delete(c.tokens, key)
+		return "", false
+	}
+	return t.token, true
+}
+
+func (c *tokenCache) set(key, token string, expiry time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.tokens[key] = cachedToken{token: token, expiry: expiry}
+}
+
+func (c *tokenCache) forget(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.tokens, key)
+}
+
+// kubernetesTokenCacheKey returns the key used to cache Vault tokens for
+// this issuer. It includes every field that affects the login so that
+// changing the issuer's configuration causes a new login.
+func (v *Vault) kubernetesTokenCacheKey(auth *v1alpha1.VaultKubernetesAuth) string {
+	return strings.Join([]string{
+		string(v.issuer.GetObjectMeta().UID),
+		v.issuer.GetObjectMeta().Namespace,
+		v.issuer.GetObjectMeta().Name,
+		v.issuer.GetSpec().Vault.Server,
+		auth.Path,
+		auth.Role,
+		auth.SecretRef.Name,
+		auth.SecretRef.Key,
+		auth.TokenPath,
+	}, "/")
+}
+
+func (v *Vault) requestTokenWithKubernetesAuth(client *vault.Client, auth *v1alpha1.VaultKubernetesAuth) (string, error) {
+	cacheKey := v.kubernetesTokenCacheKey(auth)
+	if token, ok := kubernetesTokens.get(cacheKey); ok {
+		return token, nil
+	}
+
+	jwt, err := v.kubernetesServiceAccountToken(auth)
+	if err != nil {
+		return "", err
+	}
+
+	parameters := map[string]string{
+		"role": auth.Role,
+		"jwt":  jwt,
+	}
+
+	authPath := auth.Path
+	if authPath == "" {
+		authPath = defaultKubernetesAuthPath
+	}
+
+	url := path.Join("/v1", "auth", authPath, "login")
+
+	request := client.NewRequest("POST", url)
+
+	err = request.SetJSONBody(parameters)
+	if err != nil {
+		return "", fmt.Errorf("error encoding Vault parameters: %s", err.Error())
+	}
+
+	resp, err := client.RawRequest(request)
+	if err != nil {
+		return "", fmt.Errorf("error logging in to Vault server: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	vaultResult := vault.Secret{}
+	err = resp.DecodeJSON(&vaultResult)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode JSON payload: %s", err.Error())
+	}
+
+	token, err := vaultResult.TokenID()
+	if err != nil {
+		return "", fmt.Errorf("unable to read token: %s", err.Error())
+	}
+	if token == "" {
+		return "", fmt.Errorf("no token returned by Vault")
+	}
+
+	ttl, err := vaultResult.TokenTTL()
+	if err != nil {
+		return "", fmt.Errorf("unable to read token TTL: %s", err.Error())
+	}
+
+	var expiry time.Time
+	if ttl > 0 {
+		// renew the token shortly before it expires, or half way through
+		// its lifetime if it is very short lived
+		margin := kubernetesTokenExpiryMargin
+		if ttl < 2*margin {
+			margin = ttl / 2
+		}
+		expiry = now().Add(ttl - margin)
+	}
+	kubernetesTokens.set(cacheKey, token, expiry)
+
+	return token, nil
+}
+
+// kubernetesServiceAccountToken returns the service account token used to
+// log in to Vault, read either from the referenced Secret or from a file.
+func (v *Vault) kubernetesServiceAccountToken(auth *v1alpha1.VaultKubernetesAuth) (string, error) {
+	if auth.SecretRef.Name != "" {
+		key := auth.SecretRef.Key
+		if key == "" {
+			key = defaultServiceAccountKey
+		}
+
+		keyBytes, err := kube.SecretKeyData(v.credentialsLister, v.resourceNamespace, auth.SecretRef.Name, key)
+		if _, ok := errors.SecretErrorReason(err); ok {
+			return "", err
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading Kubernetes service account token from secret %s/%s: %s", v.resourceNamespace, auth.SecretRef.Name, err.Error())
+		}
+
+		return strings.TrimSpace(string(keyBytes)), nil
+	}
+
+	// Reading a token from disk uses cert-manager's own identity, which must
+	// not be available to users who can only create namespaced Issuers.
+	if _, ok := v.issuer.(*v1alpha1.ClusterIssuer); !ok {
+		return "", fmt.Errorf("kubernetes auth secretRef must be set on Issuers, tokenPath may only be used by ClusterIssuers")
+	}
+
+	tokenPath := auth.TokenPath
+	if tokenPath == "" {
+		tokenPath = defaultServiceAccountTokenPath
+	}
+
+	tokenBytes, err := ioutil.ReadFile(tokenPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading Kubernetes service account token from %q: %s", tokenPath, err.Error())
+	}
+
+	return strings.TrimSpace(string(tokenBytes)), nil
+}
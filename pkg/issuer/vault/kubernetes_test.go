@@ -0,0 +1,329 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+// fakeVault is a Vault server that supports logging in with the Kubernetes
+// auth method, looking up and signing certificates with tokens issued by that
+// login.
+type fakeVault struct {
+	*httptest.Server
+
+	lock   sync.Mutex
+	jwt    string
+	logins int
+	// valid is the set of tokens that will be accepted by the sign endpoint
+	valid map[string]bool
+	// certificate is returned by the sign endpoint
+	certificate string
+}
+
+func newFakeVault(t *testing.T, jwt string) *fakeVault {
+	f := &fakeVault{jwt: jwt, valid: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		req := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("error decoding login request: %v", err)
+		}
+		if req["role"] != "cert-manager" || req["jwt"] != f.jwt {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or service account token"]}`))
+			return
+		}
+		f.logins++
+		token := fmt.Sprintf("token-%d", f.logins)
+		f.valid[token] = true
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   token,
+				"lease_duration": 3600,
+			},
+		})
+	})
+	mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if !f.valid[r.Header.Get("X-Vault-Token")] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"id": r.Header.Get("X-Vault-Token"),
+			},
+		})
+	})
+	mux.HandleFunc("/v1/pki/sign/example-dot-com", func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if !f.valid[r.Header.Get("X-Vault-Token")] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": f.certificate,
+				"issuing_ca":  f.certificate,
+			},
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// revokeAll causes all previously issued tokens to be rejected.
+func (f *fakeVault) revokeAll() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.valid = make(map[string]bool)
+}
+
+func (f *fakeVault) loginCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.logins
+}
+
+func kubernetesAuthIssuer(server string, auth v1alpha1.VaultKubernetesAuth) v1alpha1.VaultIssuer {
+	return v1alpha1.VaultIssuer{
+		Server: server,
+		Path:   "pki/sign/example-dot-com",
+		Auth: v1alpha1.VaultAuth{
+			Kubernetes: &auth,
+		},
+	}
+}
+
+func buildFakeVault(t *testing.T, issuer v1alpha1.GenericIssuer, objects ...runtime.Object) (*Vault, *test.Builder) {
+	b := &test.Builder{
+		KubeObjects: objects,
+	}
+	b.Start()
+	v, err := NewVault(b.Context, issuer)
+	if err != nil {
+		t.Fatalf("error creating vault issuer: %v", err)
+	}
+	b.Sync()
+	return v.(*Vault), b
+}
+
+func resetKubernetesTokens() {
+	kubernetesTokens = &tokenCache{tokens: make(map[string]cachedToken)}
+}
+
+func TestKubernetesAuthSecretRef(t *testing.T) {
+	defer resetKubernetesTokens()
+	defer func() { now = time.Now }()
+
+	server := newFakeVault(t, "service-account-jwt")
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vault-sa-token",
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte("service-account-jwt\n"),
+		},
+	}
+	issuer := gen.Issuer("vault-issuer", gen.SetIssuerVault(kubernetesAuthIssuer(server.URL, v1alpha1.VaultKubernetesAuth{
+		Role: "cert-manager",
+		SecretRef: v1alpha1.SecretKeySelector{
+			LocalObjectReference: v1alpha1.LocalObjectReference{Name: "vault-sa-token"},
+		},
+	})))
+	v, b := buildFakeVault(t, issuer, secret)
+	defer b.Stop()
+
+	client, err := v.initVaultClient()
+	if err != nil {
+		t.Fatalf("unexpected error initialising vault client: %v", err)
+	}
+	if client.Token() != "token-1" {
+		t.Errorf("expected client to use token %q, got %q", "token-1", client.Token())
+	}
+
+	// a second client should reuse the cached token
+	client, err = v.initVaultClient()
+	if err != nil {
+		t.Fatalf("unexpected error initialising vault client: %v", err)
+	}
+	if client.Token() != "token-1" || server.loginCount() != 1 {
+		t.Errorf("expected cached token to be reused, got token %q after %d logins", client.Token(), server.loginCount())
+	}
+
+	// once the token is about to expire a new one should be requested
+	now = func() time.Time { return time.Now().Add(time.Hour) }
+	client, err = v.initVaultClient()
+	if err != nil {
+		t.Fatalf("unexpected error initialising vault client: %v", err)
+	}
+	if client.Token() != "token-2" || server.loginCount() != 2 {
+		t.Errorf("expected a new token to be requested after expiry, got token %q after %d logins", client.Token(), server.loginCount())
+	}
+}
+
+func TestKubernetesAuthInvalidCredentials(t *testing.T) {
+	defer resetKubernetesTokens()
+
+	server := newFakeVault(t, "service-account-jwt")
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vault-sa-token",
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte("another-jwt"),
+		},
+	}
+	issuer := gen.Issuer("vault-issuer", gen.SetIssuerVault(kubernetesAuthIssuer(server.URL, v1alpha1.VaultKubernetesAuth{
+		Role: "cert-manager",
+		SecretRef: v1alpha1.SecretKeySelector{
+			LocalObjectReference: v1alpha1.LocalObjectReference{Name: "vault-sa-token"},
+		},
+	})))
+	v, b := buildFakeVault(t, issuer, secret)
+	defer b.Stop()
+
+	if _, err := v.initVaultClient(); err == nil {
+		t.Errorf("expected an error logging in with an invalid service account token")
+	}
+}
+
+func TestKubernetesAuthTokenPath(t *testing.T) {
+	defer resetKubernetesTokens()
+
+	server := newFakeVault(t, "service-account-jwt")
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "vault-kubernetes-auth")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenPath, []byte("service-account-jwt"), 0600); err != nil {
+		t.Fatalf("error writing token file: %v", err)
+	}
+
+	auth := v1alpha1.VaultKubernetesAuth{
+		Role:      "cert-manager",
+		TokenPath: tokenPath,
+	}
+
+	clusterIssuer := gen.ClusterIssuer("vault-issuer", gen.SetIssuerVault(kubernetesAuthIssuer(server.URL, auth)))
+	v, b := buildFakeVault(t, clusterIssuer)
+	defer b.Stop()
+	client, err := v.initVaultClient()
+	if err != nil {
+		t.Fatalf("unexpected error initialising vault client: %v", err)
+	}
+	if client.Token() != "token-1" {
+		t.Errorf("expected client to use token %q, got %q", "token-1", client.Token())
+	}
+
+	// namespaced issuers must not be able to use cert-manager's own token
+	issuer := gen.Issuer("vault-issuer", gen.SetIssuerVault(kubernetesAuthIssuer(server.URL, auth)))
+	v, b = buildFakeVault(t, issuer)
+	defer b.Stop()
+	if _, err := v.initVaultClient(); err == nil {
+		t.Errorf("expected an error using tokenPath on a namespaced Issuer")
+	}
+}
+
+func TestKubernetesAuthReauthenticatesOnForbidden(t *testing.T) {
+	defer resetKubernetesTokens()
+
+	server := newFakeVault(t, "service-account-jwt")
+	defer server.Close()
+
+	pk, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	crt := gen.Certificate("test", gen.SetCertificateCommonName("example.com"))
+	template, err := pki.GenerateTemplate(gen.Issuer("test", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{})), crt)
+	if err != nil {
+		t.Fatalf("error generating certificate template: %v", err)
+	}
+	certPEM, _, err := pki.SignCertificate(template, template, pk.Public(), pk)
+	if err != nil {
+		t.Fatalf("error signing certificate: %v", err)
+	}
+	server.certificate = string(certPEM)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vault-sa-token",
+			Namespace: gen.DefaultTestNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte("service-account-jwt"),
+		},
+	}
+	issuer := gen.Issuer("vault-issuer", gen.SetIssuerVault(kubernetesAuthIssuer(server.URL, v1alpha1.VaultKubernetesAuth{
+		Role: "cert-manager",
+		SecretRef: v1alpha1.SecretKeySelector{
+			LocalObjectReference: v1alpha1.LocalObjectReference{Name: "vault-sa-token"},
+		},
+	})))
+	v, b := buildFakeVault(t, issuer, secret)
+	defer b.Stop()
+
+	if _, _, err := v.requestVaultCert("example.com", time.Hour, nil, nil, []byte("csr")); err != nil {
+		t.Fatalf("unexpected error requesting certificate: %v", err)
+	}
+
+	// the cached token is no longer accepted by Vault, so the issuer
+	// should log in again and retry the request
+	server.revokeAll()
+	if _, _, err := v.requestVaultCert("example.com", time.Hour, nil, nil, []byte("csr")); err != nil {
+		t.Fatalf("unexpected error requesting certificate after token was revoked: %v", err)
+	}
+	if server.loginCount() != 2 {
+		t.Errorf("expected 2 logins, got %d", server.loginCount())
+	}
+}
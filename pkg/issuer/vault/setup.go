@@ -20,8 +20,11 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
 )
 
 const (
@@ -35,13 +38,14 @@ const (
 	messageVaultStatusVerificationFailed = "Vault is not initialized or is sealed"
 	messageVaultConfigRequired           = "Vault config cannot be empty"
 	messageServerAndPathRequired         = "Vault server and path are required fields"
-	messsageAuthFieldsRequired           = "Vault tokenSecretRef or appRole is required"
+	messsageAuthFieldsRequired           = "Vault tokenSecretRef, appRole or kubernetes is required"
 	messageAuthFieldRequired             = "Vault tokenSecretRef and appRole cannot be set on the same issuer"
+	messageKubernetesAuthFieldRequired   = "Vault kubernetes auth cannot be set with tokenSecretRef or appRole, and requires a role"
 )
 
 func (v *Vault) Setup(ctx context.Context) error {
 	if v.issuer.GetSpec().Vault == nil {
-		glog.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageVaultConfigRequired)
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageVaultConfigRequired)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messageVaultConfigRequired)
 		return nil
 	}
@@ -49,16 +53,29 @@ func (v *Vault) Setup(ctx context.Context) error {
 	// check if Vault server info is specified.
 	if v.issuer.GetSpec().Vault.Server == "" ||
 		v.issuer.GetSpec().Vault.Path == "" {
-		glog.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageServerAndPathRequired)
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageServerAndPathRequired)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messageServerAndPathRequired)
 		return nil
 	}
 
+	// check if the kubernetes auth method is set on its own, with a role.
+	if kubernetesAuth := v.issuer.GetSpec().Vault.Auth.Kubernetes; kubernetesAuth != nil {
+		if kubernetesAuth.Role == "" ||
+			v.issuer.GetSpec().Vault.Auth.TokenSecretRef.Name != "" ||
+			v.issuer.GetSpec().Vault.Auth.AppRole.RoleId != "" ||
+			v.issuer.GetSpec().Vault.Auth.AppRole.SecretRef.Name != "" {
+			logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageKubernetesAuthFieldRequired)
+			v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messageKubernetesAuthFieldRequired)
+			return nil
+		}
+	}
+
 	// check if at least one auth method is specified.
-	if v.issuer.GetSpec().Vault.Auth.TokenSecretRef.Name == "" &&
+	if v.issuer.GetSpec().Vault.Auth.Kubernetes == nil &&
+		v.issuer.GetSpec().Vault.Auth.TokenSecretRef.Name == "" &&
 		v.issuer.GetSpec().Vault.Auth.AppRole.RoleId == "" &&
 		v.issuer.GetSpec().Vault.Auth.AppRole.SecretRef.Name == "" {
-		glog.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messsageAuthFieldsRequired)
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messsageAuthFieldsRequired)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messsageAuthFieldsRequired)
 		return nil
 	}
@@ -67,16 +84,17 @@ func (v *Vault) Setup(ctx context.Context) error {
 	if v.issuer.GetSpec().Vault.Auth.TokenSecretRef.Name != "" &&
 		(v.issuer.GetSpec().Vault.Auth.AppRole.RoleId != "" ||
 			v.issuer.GetSpec().Vault.Auth.AppRole.SecretRef.Name != "") {
-		glog.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageAuthFieldRequired)
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageAuthFieldRequired)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messageAuthFieldRequired)
 		return nil
 	}
 
 	// check if all mandatory Vault appRole fields are set.
-	if v.issuer.GetSpec().Vault.Auth.TokenSecretRef.Name == "" &&
+	if v.issuer.GetSpec().Vault.Auth.Kubernetes == nil &&
+		v.issuer.GetSpec().Vault.Auth.TokenSecretRef.Name == "" &&
 		(v.issuer.GetSpec().Vault.Auth.AppRole.RoleId == "" ||
 			v.issuer.GetSpec().Vault.Auth.AppRole.SecretRef.Name == "") {
-		glog.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageAuthFieldRequired)
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageAuthFieldRequired)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messageAuthFieldRequired)
 		return nil
 	}
@@ -84,26 +102,46 @@ func (v *Vault) Setup(ctx context.Context) error {
 	client, err := v.initVaultClient()
 	if err != nil {
 		s := messageVaultClientInitFailed + err.Error()
-		glog.V(4).Infof("%s: %s", v.issuer.GetObjectMeta().Name, s)
-		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, s)
+		reason := errorVault
+		if r, ok := errors.SecretErrorReason(err); ok {
+			reason = r
+		}
+		logs.V(4).Infof("%s: %s", v.issuer.GetObjectMeta().Name, s)
+		v.Recorder.Event(v.issuer, corev1.EventTypeWarning, reason, s)
+		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, reason, s)
 		return err
 	}
 
 	health, err := client.Sys().Health()
 	if err != nil {
 		s := messageVaultHealthCheckFailed + err.Error()
-		glog.V(4).Infof("%s: %s", v.issuer.GetObjectMeta().Name, s)
+		logs.V(4).Infof("%s: %s", v.issuer.GetObjectMeta().Name, s)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, s)
 		return err
 	}
 
 	if !health.Initialized || health.Sealed {
-		glog.V(4).Infof("%s: %s: health: %v", v.issuer.GetObjectMeta().Name, messageVaultStatusVerificationFailed, health)
+		logs.V(4).Infof("%s: %s: health: %v", v.issuer.GetObjectMeta().Name, messageVaultStatusVerificationFailed, health)
 		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVault, messageVaultStatusVerificationFailed)
 		return fmt.Errorf(messageVaultStatusVerificationFailed)
 	}
 
-	glog.Info(messageVaultVerified)
+	logs.Info(messageVaultVerified)
 	v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionTrue, successVaultVerified, messageVaultVerified)
 	return nil
 }
+
+// Validate checks that the issuer can authenticate with Vault, by logging in
+// with the configured auth method and looking up the resulting token.
+func (v *Vault) Validate(ctx context.Context) error {
+	client, err := v.initVaultClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Auth().Token().LookupSelf(); err != nil {
+		return fmt.Errorf("error looking up Vault token: %s", err.Error())
+	}
+
+	return nil
+}
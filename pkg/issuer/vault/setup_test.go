@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestValidate(t *testing.T) {
+	saTokenSecret := func(jwt string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vault-sa-token",
+				Namespace: gen.DefaultTestNamespace,
+			},
+			Data: map[string][]byte{
+				"token": []byte(jwt),
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		objects     []runtime.Object
+		revoke      bool
+		expectedErr bool
+	}{
+		"configuration is valid if the issuer can log in to Vault": {
+			objects: []runtime.Object{saTokenSecret("service-account-jwt")},
+		},
+		"configuration is invalid if Vault rejects the login": {
+			objects:     []runtime.Object{saTokenSecret("another-jwt")},
+			expectedErr: true,
+		},
+		"configuration is invalid if the token cannot be looked up": {
+			objects:     []runtime.Object{saTokenSecret("service-account-jwt")},
+			revoke:      true,
+			expectedErr: true,
+		},
+		"configuration is invalid if the credentials secret does not exist": {
+			expectedErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer resetKubernetesTokens()
+
+			server := newFakeVault(t, "service-account-jwt")
+			defer server.Close()
+
+			issuer := gen.Issuer("vault-issuer", gen.SetIssuerVault(kubernetesAuthIssuer(server.URL, v1alpha1.VaultKubernetesAuth{
+				Role: "cert-manager",
+				SecretRef: v1alpha1.SecretKeySelector{
+					LocalObjectReference: v1alpha1.LocalObjectReference{Name: "vault-sa-token"},
+				},
+			})))
+			v, b := buildFakeVault(t, issuer, test.objects...)
+			defer b.Stop()
+
+			if test.revoke {
+				// log in so that the token is cached, then revoke it
+				if _, err := v.initVaultClient(); err != nil {
+					t.Fatalf("unexpected error initialising vault client: %v", err)
+				}
+				server.revokeAll()
+			}
+
+			err := v.Validate(context.Background())
+			if err != nil != test.expectedErr {
+				t.Errorf("expected error %t, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
@@ -30,6 +30,10 @@ type Vault struct {
 
 	secretsLister corelisters.SecretLister
 
+	// credentialsLister is used to read the credentials referenced by the
+	// issuer, which may be read from files instead of Secrets.
+	credentialsLister corelisters.SecretLister
+
 	// Namespace in which to read resources related to this Issuer from.
 	// For Issuers, this will be the namespace of the Issuer.
 	// For ClusterIssuers, this will be the cluster resource namespace.
@@ -38,11 +42,16 @@ type Vault struct {
 
 func NewVault(ctx *controller.Context, issuer v1alpha1.GenericIssuer) (issuer.Interface, error) {
 	secretsLister := ctx.KubeSharedInformerFactory.Core().V1().Secrets().Lister()
+	credentialsLister, err := ctx.IssuerOptions.CredentialsLister(issuer, secretsLister)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Vault{
 		Context:           ctx,
 		issuer:            issuer,
 		secretsLister:     secretsLister,
+		credentialsLister: credentialsLister,
 		resourceNamespace: ctx.IssuerOptions.ResourceNamespace(issuer),
 	}, nil
 }
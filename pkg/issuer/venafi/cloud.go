@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// cloudConnector is a connector for the Venafi Cloud REST API.
+type cloudConnector struct {
+	baseURL string
+	client  *http.Client
+	apiKey  string
+}
+
+type cloudZone struct {
+	ID string `json:"id"`
+}
+
+type cloudCertificateRequest struct {
+	ZoneID                    string `json:"zoneId"`
+	CertificateSigningRequest string `json:"certificateSigningRequest"`
+}
+
+type cloudCertificateRequestStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type cloudCertificateRequestsResponse struct {
+	CertificateRequests []cloudCertificateRequestStatus `json:"certificateRequests"`
+}
+
+const (
+	cloudStatusIssued   = "ISSUED"
+	cloudStatusFailed   = "FAILED"
+	cloudStatusRejected = "REJECTED"
+)
+
+func (c *cloudConnector) headers() map[string]string {
+	return map[string]string{"tppl-api-key": c.apiKey}
+}
+
+func (c *cloudConnector) Ping() error {
+	_, err := doJSON(c.client, http.MethodGet, c.baseURL+"/useraccounts", c.headers(), nil, nil)
+	return err
+}
+
+func (c *cloudConnector) RequestCertificate(zone string, csr []byte) (string, error) {
+	z := cloudZone{}
+	if _, err := doJSON(c.client, http.MethodGet, c.baseURL+"/zones/tag/"+url.PathEscape(zone), c.headers(), nil, &z); err != nil {
+		return "", fmt.Errorf("error looking up venafi cloud zone %q: %s", zone, err.Error())
+	}
+
+	resp := cloudCertificateRequestsResponse{}
+	req := cloudCertificateRequest{
+		ZoneID:                    z.ID,
+		CertificateSigningRequest: string(csr),
+	}
+	if _, err := doJSON(c.client, http.MethodPost, c.baseURL+"/certificaterequests", c.headers(), req, &resp); err != nil {
+		return "", fmt.Errorf("error requesting certificate from venafi cloud: %s", err.Error())
+	}
+	if len(resp.CertificateRequests) == 0 || resp.CertificateRequests[0].ID == "" {
+		return "", fmt.Errorf("error requesting certificate from venafi cloud: no request ID returned")
+	}
+
+	return resp.CertificateRequests[0].ID, nil
+}
+
+func (c *cloudConnector) RetrieveCertificate(id string) ([]byte, error) {
+	status := cloudCertificateRequestStatus{}
+	if _, err := doJSON(c.client, http.MethodGet, c.baseURL+"/certificaterequests/"+url.PathEscape(id), c.headers(), nil, &status); err != nil {
+		return nil, fmt.Errorf("error retrieving certificate request status from venafi cloud: %s", err.Error())
+	}
+
+	switch status.Status {
+	case cloudStatusIssued:
+	case cloudStatusFailed, cloudStatusRejected:
+		return nil, &requestFailedError{fmt.Errorf("venafi cloud certificate request %q is %s", id, strings.ToLower(status.Status))}
+	default:
+		return nil, errCertificatePending
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/certificaterequests/"+url.PathEscape(id)+"/certificate?certificateFormat=PEM&chainOrder=EE_FIRST", nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.headers() {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving certificate from venafi cloud: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	certs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading certificate from venafi cloud: %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error retrieving certificate from venafi cloud: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(certs)))
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+)
+
+const (
+	defaultCloudURL = "https://api.venafi.cloud/v1"
+
+	tppAccessTokenKey = "access-token"
+	tppUsernameKey    = "username"
+	tppPasswordKey    = "password"
+)
+
+// errCertificatePending is returned by RetrieveCertificate if the requested
+// certificate has not been issued yet.
+var errCertificatePending = errors.New("certificate has not been issued yet")
+
+// requestFailedError is returned by RetrieveCertificate if Venafi will never
+// issue the requested certificate, so a new request has to be made.
+type requestFailedError struct{ error }
+
+// connector is the set of operations the issuer needs from Venafi. It
+// mirrors the connector interface of the VCert SDK, so that TPP and Venafi
+// Cloud can be used interchangeably. The SDK itself is not vendored, so the
+// connectors call the TPP WebSDK and Venafi Cloud REST APIs directly.
+type connector interface {
+	// Ping checks that Venafi is reachable and the configured credentials
+	// are valid.
+	Ping() error
+
+	// RequestCertificate submits a PEM encoded certificate signing request
+	// to the given zone, and returns an ID that can be used to retrieve the
+	// signed certificate.
+	RequestCertificate(zone string, csr []byte) (string, error)
+
+	// RetrieveCertificate returns the PEM encoded certificate chain, leaf
+	// first, for the given request ID. If the certificate has not been
+	// issued yet, errCertificatePending is returned, and if it never will
+	// be, a *requestFailedError is returned.
+	RetrieveCertificate(id string) ([]byte, error)
+}
+
+// newConnector builds a connector for the TPP or Venafi Cloud instance
+// configured on the issuer, reading any credentials from its secrets.
+func newConnector(v *Venafi) (connector, error) {
+	venCfg := v.issuer.GetSpec().Venafi
+	if venCfg == nil {
+		return nil, fmt.Errorf("venafi config cannot be empty")
+	}
+
+	switch {
+	case venCfg.TPP != nil:
+		return v.tppConnector(venCfg.TPP)
+	case venCfg.Cloud != nil:
+		return v.cloudConnector(venCfg.Cloud)
+	}
+
+	return nil, fmt.Errorf("neither tpp or cloud are configured")
+}
+
+func (v *Venafi) tppConnector(tpp *v1alpha1.VenafiTPP) (connector, error) {
+	secret, err := kube.GetSecret(v.credentialsLister, v.resourceNamespace, tpp.CredentialsRef.Name)
+	if _, ok := cmerrors.SecretErrorReason(err); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading venafi tpp credentials from secret %s/%s: %s", v.resourceNamespace, tpp.CredentialsRef.Name, err.Error())
+	}
+
+	client, err := httpClient(tpp.CABundle, v.issuer.GetSpec().Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &tppConnector{
+		baseURL:     strings.TrimSuffix(tpp.URL, "/"),
+		client:      client,
+		accessToken: strings.TrimSpace(string(secret.Data[tppAccessTokenKey])),
+		username:    strings.TrimSpace(string(secret.Data[tppUsernameKey])),
+		password:    strings.TrimSpace(string(secret.Data[tppPasswordKey])),
+	}
+	if c.accessToken == "" && (c.username == "" || c.password == "") {
+		return nil, cmerrors.NewSecretMissingKey("secret '%s/%s' must contain either an %q key, or %q and %q keys",
+			v.resourceNamespace, tpp.CredentialsRef.Name, tppAccessTokenKey, tppUsernameKey, tppPasswordKey)
+	}
+
+	return c, nil
+}
+
+func (v *Venafi) cloudConnector(cloud *v1alpha1.VenafiCloud) (connector, error) {
+	ref := cloud.APITokenSecretRef
+	secret, err := kube.GetSecret(v.credentialsLister, v.resourceNamespace, ref.Name)
+	if _, ok := cmerrors.SecretErrorReason(err); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading venafi cloud api key from secret %s/%s: %s", v.resourceNamespace, ref.Name, err.Error())
+	}
+
+	apiKey := strings.TrimSpace(string(secret.Data[ref.Key]))
+	if apiKey == "" {
+		return nil, cmerrors.NewSecretMissingKey("no data for %q in secret '%s/%s'", ref.Key, v.resourceNamespace, ref.Name)
+	}
+
+	baseURL := cloud.URL
+	if baseURL == "" {
+		baseURL = defaultCloudURL
+	}
+
+	client, err := httpClient(nil, v.issuer.GetSpec().Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudConnector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+		apiKey:  apiKey,
+	}, nil
+}
+
+// httpClient returns an HTTP client that trusts the given PEM encoded CA
+// bundle, or the system roots if caBundle is empty. If proxy is not empty,
+// requests are sent through it instead of the proxy set in the environment.
+func httpClient(caBundle []byte, proxy string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport)
+	if len(caBundle) > 0 || proxy != "" {
+		proxyFunc, err := util.ProxyFunc(proxy)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig := &tls.Config{}
+		if len(caBundle) > 0 {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caBundle) {
+				return nil, fmt.Errorf("error loading venafi CA bundle")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport = &http.Transport{
+			Proxy:               proxyFunc,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}, nil
+}
+
+// doJSON performs an HTTP request with an optional JSON encoded body, and
+// decodes a JSON response into out if it is not nil. The response status
+// code is returned so that callers can handle non-error statuses such as
+// 202 Accepted.
+func doJSON(client *http.Client, method, url string, headers map[string]string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, fmt.Errorf("error encoding request: %s", err.Error())
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error querying venafi: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("error reading venafi response: %s", err.Error())
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("venafi returned unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if out != nil && resp.StatusCode != http.StatusAccepted && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("error decoding venafi response: %s", err.Error())
+		}
+	}
+
+	return resp.StatusCode, nil
+}
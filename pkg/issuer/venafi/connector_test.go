@@ -0,0 +1,293 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testChainPEM = "-----BEGIN CERTIFICATE-----\nleaf\n-----END CERTIFICATE-----\n"
+
+func TestTPPConnector(t *testing.T) {
+	retrieved := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vedsdk/authorize/", func(w http.ResponseWriter, r *http.Request) {
+		req := tppAuthorizeRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username != "user" || req.Password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(tppAuthorizeResponse{APIKey: "api-key"})
+	})
+	mux.HandleFunc("/vedsdk/certificates/request", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Venafi-Api-Key") != "api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		req := tppCertificateRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.PolicyDN != `\VED\Policy\devops\cert-manager` || req.PKCS10 != "csr" || !req.DisableAutomaticRenewal {
+			t.Errorf("unexpected certificate request: %+v", req)
+		}
+		json.NewEncoder(w).Encode(tppCertificateRequestResponse{CertificateDN: `\VED\Policy\devops\cert-manager\example.com`})
+	})
+	mux.HandleFunc("/vedsdk/certificates/retrieve", func(w http.ResponseWriter, r *http.Request) {
+		retrieved++
+		if retrieved == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		json.NewEncoder(w).Encode(tppCertificateRetrieveResponse{
+			CertificateData: base64.StdEncoding.EncodeToString([]byte(testChainPEM)),
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &tppConnector{
+		baseURL:  server.URL + "/vedsdk",
+		client:   server.Client(),
+		username: "user",
+		password: "pass",
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatalf("unexpected error pinging tpp: %v", err)
+	}
+	id, err := c.RequestCertificate(`devops\cert-manager`, []byte("csr"))
+	if err != nil {
+		t.Fatalf("unexpected error requesting certificate: %v", err)
+	}
+	if _, err := c.RetrieveCertificate(id); err != errCertificatePending {
+		t.Errorf("expected errCertificatePending, got %v", err)
+	}
+	certs, err := c.RetrieveCertificate(id)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving certificate: %v", err)
+	}
+	if string(certs) != testChainPEM {
+		t.Errorf("expected %q, got %q", testChainPEM, string(certs))
+	}
+
+	bad := &tppConnector{
+		baseURL:  server.URL + "/vedsdk",
+		client:   server.Client(),
+		username: "user",
+		password: "wrong",
+	}
+	if err := bad.Ping(); err == nil {
+		t.Errorf("expected an error authenticating with invalid credentials")
+	}
+}
+
+func TestTPPConnectorRequestFailed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vedsdk/certificates/retrieve", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"Stage":500,"Status":"This certificate cannot be processed while it is in an error state."}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &tppConnector{
+		baseURL:     server.URL + "/vedsdk",
+		client:      server.Client(),
+		accessToken: "token",
+	}
+	_, err := c.RetrieveCertificate(`\VED\Policy\devops\cert-manager\example.com`)
+	if err == nil {
+		t.Fatalf("expected an error retrieving a failed certificate request")
+	} else if _, ok := err.(*requestFailedError); !ok {
+		t.Errorf("expected a requestFailedError for a failed certificate request, got %T: %v", err, err)
+	}
+}
+
+func TestTPPConnectorRetrieveServerError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vedsdk/certificates/retrieve", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &tppConnector{
+		baseURL:     server.URL + "/vedsdk",
+		client:      server.Client(),
+		accessToken: "token",
+	}
+	_, err := c.RetrieveCertificate(`\VED\Policy\devops\cert-manager\example.com`)
+	if err == nil {
+		t.Fatalf("expected an error retrieving a certificate from a failing server")
+	} else if _, ok := err.(*requestFailedError); ok {
+		t.Errorf("expected a transient error not to be a requestFailedError")
+	}
+}
+
+func TestCloudConnector(t *testing.T) {
+	status := "PENDING"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("tppl-api-key") != "api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/useraccounts":
+			w.Write([]byte(`{}`))
+		case "/v1/zones/tag/Default":
+			json.NewEncoder(w).Encode(cloudZone{ID: "zone-id"})
+		case "/v1/certificaterequests":
+			req := cloudCertificateRequest{}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.ZoneID != "zone-id" || req.CertificateSigningRequest != "csr" {
+				t.Errorf("unexpected certificate request: %+v", req)
+			}
+			json.NewEncoder(w).Encode(cloudCertificateRequestsResponse{
+				CertificateRequests: []cloudCertificateRequestStatus{{ID: "request-id"}},
+			})
+		case "/v1/certificaterequests/request-id":
+			json.NewEncoder(w).Encode(cloudCertificateRequestStatus{ID: "request-id", Status: status})
+		case "/v1/certificaterequests/request-id/certificate":
+			if r.URL.Query().Get("chainOrder") != "EE_FIRST" {
+				t.Errorf("expected chain to be requested leaf first")
+			}
+			w.Write([]byte(testChainPEM))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &cloudConnector{
+		baseURL: server.URL + "/v1",
+		client:  server.Client(),
+		apiKey:  "api-key",
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatalf("unexpected error pinging venafi cloud: %v", err)
+	}
+	id, err := c.RequestCertificate("Default", []byte("csr"))
+	if err != nil {
+		t.Fatalf("unexpected error requesting certificate: %v", err)
+	}
+	if _, err := c.RetrieveCertificate(id); err != errCertificatePending {
+		t.Errorf("expected errCertificatePending, got %v", err)
+	}
+	status = cloudStatusIssued
+	certs, err := c.RetrieveCertificate(id)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving certificate: %v", err)
+	}
+	if string(certs) != testChainPEM {
+		t.Errorf("expected %q, got %q", testChainPEM, string(certs))
+	}
+	status = cloudStatusRejected
+	if _, err := c.RetrieveCertificate(id); err == nil || err == errCertificatePending {
+		t.Errorf("expected an error for a rejected certificate request, got %v", err)
+	} else if _, ok := err.(*requestFailedError); !ok {
+		t.Errorf("expected a requestFailedError for a rejected certificate request, got %T", err)
+	}
+
+	bad := &cloudConnector{
+		baseURL: server.URL + "/v1",
+		client:  server.Client(),
+		apiKey:  "wrong",
+	}
+	if err := bad.Ping(); err == nil {
+		t.Errorf("expected an error pinging with an invalid api key")
+	}
+}
+
+func TestTPPPolicyDN(t *testing.T) {
+	tests := map[string]string{
+		`devops\cert-manager`:             `\VED\Policy\devops\cert-manager`,
+		`\devops\cert-manager`:            `\VED\Policy\devops\cert-manager`,
+		`\VED\Policy\devops\cert-manager`: `\VED\Policy\devops\cert-manager`,
+	}
+	for zone, expected := range tests {
+		if dn := tppPolicyDN(zone); dn != expected {
+			t.Errorf("expected policy DN for zone %q to be %q, got %q", zone, expected, dn)
+		}
+	}
+}
+
+func TestHTTPClientCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tests := map[string]struct {
+		caBundle   []byte
+		buildErr   bool
+		requestErr bool
+	}{
+		"server is not trusted without a CA bundle": {
+			requestErr: true,
+		},
+		"server is trusted using the CA bundle": {
+			caBundle: caBundle,
+		},
+		"invalid CA bundle": {
+			caBundle: []byte("not a certificate"),
+			buildErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl, err := httpClient(test.caBundle, "")
+			if err != nil != test.buildErr {
+				t.Fatalf("expected build error %t, got %v", test.buildErr, err)
+			}
+			if err != nil {
+				return
+			}
+			resp, err := cl.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if err != nil != test.requestErr {
+				t.Errorf("expected request error %t, got %v", test.requestErr, err)
+			}
+		})
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	cl, err := httpClient(nil, proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := cl.Get("http://tpp.example.com/vedsdk/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://tpp.example.com/vedsdk/" {
+		t.Errorf("expected request to be sent through the proxy, but proxy received %q", proxied)
+	}
+}
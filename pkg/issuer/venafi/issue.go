@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/pem"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+)
+
+func (v *Venafi) Issue(ctx context.Context, crt *v1alpha1.Certificate) (*issuer.IssueResponse, error) {
+	// get a copy of the existing/currently issued Certificate's private key
+	signeePrivateKey, err := kube.SecretTLSKeyForCertificate(v.secretsLister, crt)
+	if k8sErrors.IsNotFound(err) || errors.IsInvalidData(err) {
+		// if one does not already exist, generate a new one
+		signeePrivateKey, err = pki.GeneratePrivateKeyForCertificate(crt)
+		if err != nil {
+			v.Recorder.Eventf(crt, corev1.EventTypeWarning, "PrivateKeyError", "Error generating certificate private key: %v", err)
+			// don't trigger a retry. An error from this function implies some
+			// invalid input parameters, and retrying without updating the
+			// resource will not help.
+			return nil, nil
+		}
+
+		// We persist the new private key before requesting a certificate
+		// with it, so that a request that is still pending when we next
+		// sync is for a key that we hold. Any pending request was made for
+		// the previous key, and is forgotten.
+		keyPem, err := pki.EncodePrivateKey(signeePrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		delete(crt.Annotations, v1alpha1.VenafiPickupIDAnnotationKey)
+		v.Recorder.Event(crt, corev1.EventTypeNormal, "Generated", "Generated new private key")
+		return &issuer.IssueResponse{
+			PrivateKey: keyPem,
+		}, nil
+	}
+	if err != nil {
+		logs.Errorf("Error getting private key %q for certificate: %v", crt.Spec.SecretName, err)
+		return nil, err
+	}
+
+	client, err := v.connectorFor(v)
+	if err != nil {
+		v.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorVenafiInit", "Failed to initialise Venafi client: %v", err)
+		return nil, err
+	}
+
+	// resume retrieving a certificate that was requested on an earlier sync
+	id := crt.Annotations[v1alpha1.VenafiPickupIDAnnotationKey]
+	if id == "" {
+		id, err = v.requestCertificate(client, crt, signeePrivateKey)
+		if err != nil {
+			v.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorSigning", "Failed to request certificate: %v", err)
+			return nil, err
+		}
+		if crt.Annotations == nil {
+			crt.Annotations = make(map[string]string)
+		}
+		crt.Annotations[v1alpha1.VenafiPickupIDAnnotationKey] = id
+		v.Recorder.Eventf(crt, corev1.EventTypeNormal, "Requested", "Requested certificate %q from Venafi", id)
+	}
+
+	chainPEM, err := client.RetrieveCertificate(id)
+	if err == errCertificatePending {
+		logs.V(4).Infof("Certificate %q has not been issued by Venafi yet", id)
+		// the Certificate is requeued, and retrieval resumed on the next sync
+		return nil, errors.NewBackoff("waiting for venafi to issue certificate %q", id)
+	}
+	if _, ok := err.(*requestFailedError); ok {
+		delete(crt.Annotations, v1alpha1.VenafiPickupIDAnnotationKey)
+	}
+	if err != nil {
+		v.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorRetrieving", "Failed to retrieve certificate: %v", err)
+		return nil, err
+	}
+	// the request has been fulfilled, so is never retrieved again
+	delete(crt.Annotations, v1alpha1.VenafiPickupIDAnnotationKey)
+
+	certs, err := pki.DecodeX509CertificateChainBytes(chainPEM)
+	if err != nil {
+		v.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorRetrieving", "Venafi returned an invalid certificate: %v", err)
+		return nil, err
+	}
+	matches, err := pki.PublicKeyMatchesCertificate(signeePrivateKey.Public(), certs[0])
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		v.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorRetrieving", "Certificate %q issued by Venafi does not match the private key, requesting a new certificate", id)
+		return nil, fmt.Errorf("certificate %q issued by venafi does not match the private key of certificate %s/%s", id, crt.Namespace, crt.Name)
+	}
+	certPEM, err := pki.EncodeX509Chain(certs)
+	if err != nil {
+		return nil, err
+	}
+	var caPEM []byte
+	if len(certs) > 1 {
+		caPEM, err = pki.EncodeX509(certs[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := pki.EncodePrivateKey(signeePrivateKey)
+	if err != nil {
+		v.Recorder.Eventf(crt, corev1.EventTypeWarning, "ErrorPrivateKey", "Error encoding private key: %v", err)
+		return nil, err
+	}
+
+	return &issuer.IssueResponse{
+		PrivateKey:  key,
+		Certificate: certPEM,
+		CA:          caPEM,
+	}, nil
+}
+
+// requestCertificate submits a certificate signing request for crt, signed
+// with key, to the zone of the issuer, and returns the ID of the request.
+func (v *Venafi) requestCertificate(client connector, crt *v1alpha1.Certificate, key crypto.Signer) (string, error) {
+	template, err := pki.GenerateCSR(v.issuer, crt)
+	if err != nil {
+		return "", err
+	}
+	derBytes, err := pki.EncodeCSR(template, key)
+	if err != nil {
+		return "", err
+	}
+	csrPEM := &bytes.Buffer{}
+	if err := pem.Encode(csrPEM, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: derBytes}); err != nil {
+		return "", fmt.Errorf("error encoding certificate request: %s", err.Error())
+	}
+
+	zone := v.issuer.GetSpec().Venafi.Zone
+	id, err := client.RequestCertificate(zone, csrPEM.Bytes())
+	if err != nil {
+		return "", err
+	}
+	logs.V(4).Infof("Requested certificate %q from Venafi zone %q", id, zone)
+	return id, nil
+}
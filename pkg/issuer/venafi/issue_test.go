@@ -0,0 +1,284 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+// testSigner returns a function that signs PEM encoded certificate requests
+// with a freshly generated self signed CA, returning the issued certificate
+// followed by the CA. The PEM encoded CA is also returned.
+func testSigner(t *testing.T) (func([]byte) ([]byte, error), []byte) {
+	caKey, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate CA private key: %v", err)
+	}
+	caCrt := gen.Certificate("test-root-ca",
+		gen.SetCertificateCommonName("root-ca"),
+		gen.SetCertificateIsCA(true),
+	)
+	selfSigned := gen.Issuer("test", gen.SetIssuerSelfSigned(v1alpha1.SelfSignedIssuer{}))
+	caTemplate, err := pki.GenerateTemplate(selfSigned, caCrt)
+	if err != nil {
+		t.Fatalf("failed to generate CA template: %v", err)
+	}
+	caPEM, caCert, err := pki.SignCertificate(caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to sign CA certificate: %v", err)
+	}
+
+	return func(csrPEM []byte) ([]byte, error) {
+		block, _ := pem.Decode(csrPEM)
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode certificate request")
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		template := &x509.Certificate{
+			SerialNumber: caTemplate.SerialNumber,
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		crtPEM, _, err := pki.SignCertificate(template, caCert, csr.PublicKey, caKey)
+		if err != nil {
+			return nil, err
+		}
+		return append(crtPEM, caPEM...), nil
+	}, caPEM
+}
+
+func TestIssue(t *testing.T) {
+	sign, caPEM := testSigner(t)
+
+	venafiIssuer := gen.Issuer("venafi-issuer",
+		gen.SetIssuerVenafi(v1alpha1.VenafiIssuer{
+			Zone: "devops\\cert-manager",
+			TPP: &v1alpha1.VenafiTPP{
+				URL:            "https://tpp.example.com/vedsdk",
+				CredentialsRef: v1alpha1.LocalObjectReference{Name: "tpp-credentials"},
+			},
+		}),
+	)
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateSecretName("crt-output"),
+		gen.SetCertificateCommonName("example.com"),
+	)
+	pendingCrt := crt.DeepCopy()
+	pendingCrt.Annotations = map[string]string{v1alpha1.VenafiPickupIDAnnotationKey: "request-id"}
+
+	key, err := pki.GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	keyPEM := pki.EncodePKCS1PrivateKey(key)
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "crt-output", Namespace: gen.DefaultTestNamespace},
+		Data:       map[string][]byte{corev1.TLSPrivateKeyKey: keyPEM},
+	}
+	// the CSR of a request made on an earlier sync with the stored key
+	template, err := pki.GenerateCSR(venafiIssuer, crt)
+	if err != nil {
+		t.Fatalf("failed to generate certificate request: %v", err)
+	}
+	csrDER, err := pki.EncodeCSR(template, key)
+	if err != nil {
+		t.Fatalf("failed to encode certificate request: %v", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+	otherCSRPEM := func() []byte {
+		otherKey, err := pki.GenerateRSAPrivateKey(2048)
+		if err != nil {
+			t.Fatalf("failed to generate private key: %v", err)
+		}
+		der, err := pki.EncodeCSR(template, otherKey)
+		if err != nil {
+			t.Fatalf("failed to encode certificate request: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}()
+
+	pickupID := func(t *testing.T, args []interface{}) string {
+		return args[0].(*v1alpha1.Certificate).Annotations[v1alpha1.VenafiPickupIDAnnotationKey]
+	}
+	issuedCheck := func(t *testing.T, s *venafiFixture, args ...interface{}) {
+		resp := args[1].(*issuer.IssueResponse)
+		if resp == nil {
+			t.Fatalf("expected a response to be returned")
+		}
+		if !bytes.Equal(resp.PrivateKey, keyPEM) {
+			t.Errorf("expected the existing private key to be returned")
+		}
+		if !bytes.Equal(resp.CA, caPEM) {
+			t.Errorf("expected CA certificate to be returned")
+		}
+		certs, err := pki.DecodeX509CertificateChainBytes(resp.Certificate)
+		if err != nil {
+			t.Fatalf("failed to decode returned certificate: %v", err)
+		}
+		if len(certs) != 1 || certs[0].Subject.CommonName != "example.com" {
+			t.Errorf("expected only the issued certificate to be returned, got %d certificates", len(certs))
+		}
+		if id := pickupID(t, args); id != "" {
+			t.Errorf("expected the pickup ID to be removed once the certificate is retrieved, got %q", id)
+		}
+	}
+
+	tests := map[string]venafiFixture{
+		"generate and return a new private key before requesting a certificate": {
+			Issuer:      venafiIssuer,
+			Certificate: pendingCrt,
+			Connector:   &fakeConnector{sign: sign},
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				resp := args[1].(*issuer.IssueResponse)
+				if resp == nil || resp.PrivateKey == nil || resp.Certificate != nil {
+					t.Fatalf("expected only a new private key to be returned, got %+v", resp)
+				}
+				if s.Connector.requestCalls != 0 || s.Connector.retrieveCalls != 0 {
+					t.Errorf("expected no certificate to be requested or retrieved")
+				}
+				if id := pickupID(t, args); id != "" {
+					t.Errorf("expected the pickup ID of the previous key to be removed, got %q", id)
+				}
+			},
+		},
+		"issue a certificate": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: crt,
+			Connector:   &fakeConnector{sign: sign},
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				issuedCheck(t, s, args...)
+				if s.Connector.requestedZone != "devops\\cert-manager" {
+					t.Errorf("expected certificate to be requested in zone %q, got %q", "devops\\cert-manager", s.Connector.requestedZone)
+				}
+			},
+		},
+		"store the pickup ID and return if the certificate has not been issued yet": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: crt,
+			Connector:   &fakeConnector{sign: sign, pending: 1},
+			Err:         true,
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				if resp := args[1].(*issuer.IssueResponse); resp != nil {
+					t.Errorf("expected no response to be returned")
+				}
+				if id := pickupID(t, args); id != "request-id" {
+					t.Errorf("expected the pickup ID to be stored, got %q", id)
+				}
+				if s.Connector.retrieveCalls != 1 {
+					t.Errorf("expected the certificate to be retrieved once, got %d", s.Connector.retrieveCalls)
+				}
+				if err := args[2].(error); !errors.IsBackoff(err) {
+					t.Errorf("expected a back-off error, got %v", err)
+				}
+			},
+		},
+		"resume retrieving a previously requested certificate": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: pendingCrt,
+			Connector:   &fakeConnector{sign: sign, csr: csrPEM},
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				issuedCheck(t, s, args...)
+				if s.Connector.requestCalls != 0 {
+					t.Errorf("expected no new certificate to be requested, got %d requests", s.Connector.requestCalls)
+				}
+			},
+		},
+		"forget the pickup ID if the certificate does not match the private key": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: pendingCrt,
+			Connector:   &fakeConnector{sign: sign, csr: otherCSRPEM},
+			Err:         true,
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				if id := pickupID(t, args); id != "" {
+					t.Errorf("expected the pickup ID to be removed, got %q", id)
+				}
+			},
+		},
+		"fail if the certificate request is rejected": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: crt,
+			Connector:   &fakeConnector{sign: sign, requestErr: fmt.Errorf("policy violation")},
+			Err:         true,
+		},
+		"keep the pickup ID if the certificate cannot be retrieved": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: pendingCrt,
+			Connector:   &fakeConnector{sign: sign, retrieveErr: fmt.Errorf("connection refused")},
+			Err:         true,
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				if id := pickupID(t, args); id != "request-id" {
+					t.Errorf("expected the pickup ID to be kept, got %q", id)
+				}
+			},
+		},
+		"forget the pickup ID if the certificate request failed": {
+			Builder:     &test.Builder{KubeObjects: []runtime.Object{keySecret}},
+			Issuer:      venafiIssuer,
+			Certificate: pendingCrt,
+			Connector:   &fakeConnector{sign: sign, retrieveErr: &requestFailedError{fmt.Errorf("request rejected")}},
+			Err:         true,
+			CheckFn: func(t *testing.T, s *venafiFixture, args ...interface{}) {
+				if id := pickupID(t, args); id != "" {
+					t.Errorf("expected the pickup ID to be removed, got %q", id)
+				}
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			certCopy := test.Certificate.DeepCopy()
+			resp, err := test.Venafi.Issue(test.Ctx, certCopy)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+
+			test.Finish(t, certCopy, resp, err)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+)
+
+const (
+	successVenafiVerified = "VenafiVerified"
+	messageVenafiVerified = "Venafi issuer started"
+
+	errorVenafi = "VenafiError"
+
+	messageVenafiClientInitFailed = "Failed to initialise Venafi client: "
+	messageVenafiPingFailed       = "Failed to connect to Venafi: "
+)
+
+func (v *Venafi) Setup(ctx context.Context) error {
+	client, err := v.connectorFor(v)
+	if err != nil {
+		s := messageVenafiClientInitFailed + err.Error()
+		reason := errorVenafi
+		if r, ok := errors.SecretErrorReason(err); ok {
+			reason = r
+		}
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, s)
+		v.Recorder.Event(v.issuer, corev1.EventTypeWarning, reason, s)
+		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, reason, s)
+		return err
+	}
+
+	if err := client.Ping(); err != nil {
+		s := messageVenafiPingFailed + err.Error()
+		logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, s)
+		v.Recorder.Event(v.issuer, corev1.EventTypeWarning, errorVenafi, s)
+		v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionFalse, errorVenafi, s)
+		return err
+	}
+
+	logs.Infof("%s: %s", v.issuer.GetObjectMeta().Name, messageVenafiVerified)
+	v.issuer.UpdateStatusCondition(v1alpha1.IssuerConditionReady, v1alpha1.ConditionTrue, successVenafiVerified, messageVenafiVerified)
+	return nil
+}
+
+// Validate checks that Venafi is reachable and accepts the credentials
+// configured on the issuer.
+func (v *Venafi) Validate(ctx context.Context) error {
+	client, err := v.connectorFor(v)
+	if err != nil {
+		return err
+	}
+
+	return client.Ping()
+}
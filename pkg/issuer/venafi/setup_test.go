@@ -0,0 +1,163 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestSetup(t *testing.T) {
+	newIssuer := func() v1alpha1.GenericIssuer {
+		return gen.Issuer("venafi-issuer",
+			gen.SetIssuerVenafi(v1alpha1.VenafiIssuer{
+				Zone: "Default",
+				Cloud: &v1alpha1.VenafiCloud{
+					APITokenSecretRef: v1alpha1.SecretKeySelector{
+						LocalObjectReference: v1alpha1.LocalObjectReference{Name: "cloud-token"},
+						Key:                  "api-key",
+					},
+				},
+			}),
+		)
+	}
+
+	readyCheck := func(expected v1alpha1.ConditionStatus, reason string) func(*testing.T, *venafiFixture, ...interface{}) {
+		return func(t *testing.T, s *venafiFixture, args ...interface{}) {
+			conds := s.Issuer.GetStatus().Conditions
+			if len(conds) != 1 {
+				t.Fatalf("expected 1 condition to be set, got %d", len(conds))
+			}
+			if conds[0].Type != v1alpha1.IssuerConditionReady || conds[0].Status != expected || conds[0].Reason != reason {
+				t.Errorf("expected Ready condition with status %q and reason %q, got %+v", expected, reason, conds[0])
+			}
+		}
+	}
+
+	tests := map[string]venafiFixture{
+		"mark the issuer ready if Venafi can be reached": {
+			Issuer:    newIssuer(),
+			Connector: &fakeConnector{},
+			CheckFn:   readyCheck(v1alpha1.ConditionTrue, successVenafiVerified),
+		},
+		"mark the issuer not ready if Venafi cannot be reached": {
+			Issuer:    newIssuer(),
+			Connector: &fakeConnector{pingErr: fmt.Errorf("unauthorized")},
+			CheckFn:   readyCheck(v1alpha1.ConditionFalse, errorVenafi),
+			Err:       true,
+		},
+		"mark the issuer not ready if the credentials secret does not exist": {
+			Issuer:  newIssuer(),
+			CheckFn: readyCheck(v1alpha1.ConditionFalse, errors.ReasonSecretNotFound),
+			Err:     true,
+		},
+		"mark the issuer not ready if the credentials secret is missing the api key": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "cloud-token",
+							Namespace: gen.DefaultTestNamespace,
+						},
+						Data: map[string][]byte{"token": []byte("secret")},
+					},
+				},
+			},
+			Issuer:  newIssuer(),
+			CheckFn: readyCheck(v1alpha1.ConditionFalse, errors.ReasonSecretMissingKey),
+			Err:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Venafi.Setup(test.Ctx)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+
+			test.Finish(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	newIssuer := func() v1alpha1.GenericIssuer {
+		return gen.Issuer("venafi-issuer",
+			gen.SetIssuerVenafi(v1alpha1.VenafiIssuer{
+				Zone: "Default",
+				Cloud: &v1alpha1.VenafiCloud{
+					APITokenSecretRef: v1alpha1.SecretKeySelector{
+						LocalObjectReference: v1alpha1.LocalObjectReference{Name: "cloud-token"},
+						Key:                  "api-key",
+					},
+				},
+			}),
+		)
+	}
+	noIssuance := func(t *testing.T, s *venafiFixture, args ...interface{}) {
+		if s.Connector.requestedZone != "" || s.Connector.retrieveCalls > 0 {
+			t.Errorf("expected no certificate to be requested while validating")
+		}
+	}
+
+	tests := map[string]venafiFixture{
+		"configuration is valid if Venafi can be reached": {
+			Issuer:    newIssuer(),
+			Connector: &fakeConnector{},
+			CheckFn:   noIssuance,
+		},
+		"configuration is invalid if Venafi rejects the credentials": {
+			Issuer:    newIssuer(),
+			Connector: &fakeConnector{pingErr: fmt.Errorf("unauthorized")},
+			CheckFn:   noIssuance,
+			Err:       true,
+		},
+		"configuration is invalid if the credentials secret does not exist": {
+			Issuer: newIssuer(),
+			Err:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Venafi.Validate(test.Ctx)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+
+			test.Finish(t, err)
+		})
+	}
+}
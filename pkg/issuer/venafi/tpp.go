@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const tppPolicyRoot = `\VED\Policy`
+
+// tppConnector is a connector for the Venafi Trust Protection Platform
+// WebSDK.
+type tppConnector struct {
+	baseURL string
+	client  *http.Client
+
+	// accessToken is an OAuth bearer token. If not set, username and
+	// password are exchanged for an API key on first use.
+	accessToken string
+	username    string
+	password    string
+
+	apiKey string
+}
+
+type tppAuthorizeRequest struct {
+	Username string `json:"Username"`
+	Password string `json:"Password"`
+}
+
+type tppAuthorizeResponse struct {
+	APIKey string `json:"APIKey"`
+}
+
+type tppCertificateRequest struct {
+	PolicyDN                string `json:"PolicyDN"`
+	PKCS10                  string `json:"PKCS10"`
+	DisableAutomaticRenewal bool   `json:"DisableAutomaticRenewal"`
+}
+
+type tppCertificateRequestResponse struct {
+	CertificateDN string `json:"CertificateDN"`
+}
+
+type tppCertificateRetrieveRequest struct {
+	CertificateDN  string `json:"CertificateDN"`
+	Format         string `json:"Format"`
+	IncludeChain   bool   `json:"IncludeChain"`
+	RootFirstOrder bool   `json:"RootFirstOrder"`
+}
+
+type tppCertificateRetrieveResponse struct {
+	CertificateData string `json:"CertificateData"`
+}
+
+func (c *tppConnector) Ping() error {
+	if c.accessToken != "" {
+		_, err := doJSON(c.client, http.MethodGet, c.baseURL+"/authorize/checkvalid", c.headers(), nil, nil)
+		return err
+	}
+	return c.authorize()
+}
+
+// authorize exchanges the configured username and password for an API key.
+func (c *tppConnector) authorize() error {
+	resp := tppAuthorizeResponse{}
+	req := tppAuthorizeRequest{Username: c.username, Password: c.password}
+	if _, err := doJSON(c.client, http.MethodPost, c.baseURL+"/authorize/", nil, req, &resp); err != nil {
+		return fmt.Errorf("error authenticating with venafi tpp: %s", err.Error())
+	}
+	if resp.APIKey == "" {
+		return fmt.Errorf("error authenticating with venafi tpp: no api key returned")
+	}
+	c.apiKey = resp.APIKey
+	return nil
+}
+
+func (c *tppConnector) ensureAuthorized() error {
+	if c.accessToken != "" || c.apiKey != "" {
+		return nil
+	}
+	return c.authorize()
+}
+
+func (c *tppConnector) headers() map[string]string {
+	if c.accessToken != "" {
+		return map[string]string{"Authorization": "Bearer " + c.accessToken}
+	}
+	return map[string]string{"X-Venafi-Api-Key": c.apiKey}
+}
+
+func (c *tppConnector) RequestCertificate(zone string, csr []byte) (string, error) {
+	if err := c.ensureAuthorized(); err != nil {
+		return "", err
+	}
+
+	resp := tppCertificateRequestResponse{}
+	req := tppCertificateRequest{
+		PolicyDN: tppPolicyDN(zone),
+		PKCS10:   string(csr),
+		// renewal is handled by cert-manager
+		DisableAutomaticRenewal: true,
+	}
+	if _, err := doJSON(c.client, http.MethodPost, c.baseURL+"/certificates/request", c.headers(), req, &resp); err != nil {
+		return "", fmt.Errorf("error requesting certificate from venafi tpp: %s", err.Error())
+	}
+	if resp.CertificateDN == "" {
+		return "", fmt.Errorf("error requesting certificate from venafi tpp: no certificate DN returned")
+	}
+
+	return resp.CertificateDN, nil
+}
+
+func (c *tppConnector) RetrieveCertificate(id string) ([]byte, error) {
+	if err := c.ensureAuthorized(); err != nil {
+		return nil, err
+	}
+
+	resp := tppCertificateRetrieveResponse{}
+	req := tppCertificateRetrieveRequest{
+		CertificateDN:  id,
+		Format:         "base64",
+		IncludeChain:   true,
+		RootFirstOrder: false,
+	}
+	status, err := doJSON(c.client, http.MethodPost, c.baseURL+"/certificates/retrieve", c.headers(), req, &resp)
+	// TPP responds with a 400 Bad Request if the certificate is in an error
+	// state, for example because the request was rejected or the CA failed
+	// to sign it, or if it no longer exists. None of these will resolve on
+	// their own, so a new request has to be made.
+	if status == http.StatusBadRequest {
+		return nil, &requestFailedError{fmt.Errorf("venafi tpp certificate request %q failed: %s", id, err.Error())}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving certificate from venafi tpp: %s", err.Error())
+	}
+	if status == http.StatusAccepted {
+		return nil, errCertificatePending
+	}
+
+	certs, err := base64.StdEncoding.DecodeString(resp.CertificateData)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding certificate from venafi tpp: %s", err.Error())
+	}
+
+	return certs, nil
+}
+
+// tppPolicyDN returns the distinguished name of the policy folder for zone.
+// Zones may be given either relative to the policy root, or as a full DN.
+func tppPolicyDN(zone string) string {
+	if strings.HasPrefix(zone, tppPolicyRoot) {
+		return zone
+	}
+	return tppPolicyRoot + `\` + strings.TrimPrefix(zone, `\`)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+)
+
+type venafiFixture struct {
+	Venafi *Venafi
+	*test.Builder
+
+	Issuer      v1alpha1.GenericIssuer
+	Certificate *v1alpha1.Certificate
+	Connector   *fakeConnector
+
+	CheckFn func(*testing.T, *venafiFixture, ...interface{})
+	Err     bool
+
+	Ctx context.Context
+}
+
+func (s *venafiFixture) Setup(t *testing.T) {
+	if s.Ctx == nil {
+		s.Ctx = context.Background()
+	}
+	if s.Builder == nil {
+		s.Builder = &test.Builder{}
+	}
+	s.Venafi = s.buildFakeVenafi(s.Builder, s.Issuer)
+	if s.Connector != nil {
+		s.Venafi.connectorFor = func(*Venafi) (connector, error) {
+			return s.Connector, nil
+		}
+	}
+}
+
+func (s *venafiFixture) Finish(t *testing.T, args ...interface{}) {
+	defer s.Builder.Stop()
+	// resync listers before running checks
+	s.Builder.Sync()
+	// run custom checks
+	if s.CheckFn != nil {
+		s.CheckFn(t, s, args...)
+	}
+}
+
+func (s *venafiFixture) buildFakeVenafi(b *test.Builder, issuer v1alpha1.GenericIssuer) *Venafi {
+	b.Start()
+	v, err := NewVenafi(b.Context, issuer)
+	if err != nil {
+		panic("error creating fake venafi: " + err.Error())
+	}
+	venafiStruct := v.(*Venafi)
+	b.Sync()
+	return venafiStruct
+}
+
+// fakeConnector is a connector that signs certificate requests with a local
+// CA, and optionally reports requests as pending a number of times before
+// returning the certificate.
+type fakeConnector struct {
+	pingErr     error
+	requestErr  error
+	retrieveErr error
+
+	// pending is the number of times RetrieveCertificate will return
+	// errCertificatePending before the certificate is returned
+	pending int
+
+	sign func(csr []byte) ([]byte, error)
+
+	requestedZone string
+	csr           []byte
+	requestCalls  int
+	retrieveCalls int
+}
+
+var _ connector = &fakeConnector{}
+
+func (f *fakeConnector) Ping() error {
+	return f.pingErr
+}
+
+func (f *fakeConnector) RequestCertificate(zone string, csr []byte) (string, error) {
+	f.requestCalls++
+	f.requestedZone = zone
+	f.csr = csr
+	if f.requestErr != nil {
+		return "", f.requestErr
+	}
+	return "request-id", nil
+}
+
+func (f *fakeConnector) RetrieveCertificate(id string) ([]byte, error) {
+	f.retrieveCalls++
+	if f.retrieveErr != nil {
+		return nil, f.retrieveErr
+	}
+	if f.retrieveCalls <= f.pending {
+		return nil, errCertificatePending
+	}
+	return f.sign(f.csr)
+}
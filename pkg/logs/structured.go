@@ -0,0 +1,235 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const (
+	// FormatText writes logs in the default glog text format.
+	FormatText = "text"
+	// FormatJSON writes each log line as a JSON object.
+	FormatJSON = "json"
+)
+
+var (
+	formatLock sync.RWMutex
+	format     = FormatText
+
+	// out is the writer that JSON formatted log lines are written to
+	out     io.Writer = os.Stderr
+	outLock sync.Mutex
+)
+
+// SetFormat switches the format that lines logged with this package are
+// written in. When the JSON format is selected, each line is written to
+// stderr as a JSON object as soon as it is logged, so that none are lost if
+// the process exits. Errors passed to HandleError in
+// k8s.io/apimachinery/pkg/util/runtime are logged with this package too.
+// Lines that vendored libraries log with glog directly are still written by
+// glog in its text format, as glog does not allow its output to be replaced.
+func SetFormat(f string) error {
+	switch f {
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %q: must be one of %q or %q", f, FormatText, FormatJSON)
+	}
+
+	formatLock.Lock()
+	defer formatLock.Unlock()
+	format = f
+	// the first error handler is the one that logs the error with glog
+	utilruntime.ErrorHandlers[0] = handleError
+	return nil
+}
+
+func currentFormat() string {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	return format
+}
+
+func writeJSON(entry map[string]interface{}) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{
+			"level": "error",
+			"msg":   fmt.Sprintf("error encoding log entry: %v", err),
+		})
+	}
+	outLock.Lock()
+	defer outLock.Unlock()
+	out.Write(append(b, '\n'))
+}
+
+// Logger writes log lines tagged with a fixed set of key/value pairs, such
+// as the name of the controller or resource the line relates to.
+// In the text format the pairs are appended to the message, and in the JSON
+// format they are written as additional fields of the JSON object.
+type Logger struct {
+	keysAndValues []interface{}
+}
+
+// WithValues returns a Logger that tags each log line with the given
+// alternating keys and values.
+func WithValues(keysAndValues ...interface{}) Logger {
+	return Logger{}.WithValues(keysAndValues...)
+}
+
+// WithValues returns a copy of l that additionally tags each log line with the
+// given alternating keys and values.
+func (l Logger) WithValues(keysAndValues ...interface{}) Logger {
+	kvs := make([]interface{}, 0, len(l.keysAndValues)+len(keysAndValues))
+	kvs = append(kvs, l.keysAndValues...)
+	kvs = append(kvs, keysAndValues...)
+	return Logger{keysAndValues: kvs}
+}
+
+// Infof logs a message at info level.
+func (l Logger) Infof(format string, args ...interface{}) {
+	l.log("info", format, args...)
+}
+
+// Warningf logs a message at warning level.
+func (l Logger) Warningf(format string, args ...interface{}) {
+	l.log("warning", format, args...)
+}
+
+// Errorf logs a message at error level.
+func (l Logger) Errorf(format string, args ...interface{}) {
+	l.log("error", format, args...)
+}
+
+func (l Logger) log(level, format string, args ...interface{}) {
+	output(level, 2, fmt.Sprintf(format, args...), l.keysAndValues)
+}
+
+// output writes msg at level in the current format. depth is the number of
+// stack frames between output and the caller that is reported as the source
+// of the line.
+func output(level string, depth int, msg string, keysAndValues []interface{}) {
+	if currentFormat() == FormatJSON {
+		entry := map[string]interface{}{
+			"level": level,
+			"ts":    time.Now().Format(time.RFC3339Nano),
+			"msg":   msg,
+		}
+		if _, file, line, ok := runtime.Caller(depth + 1); ok {
+			entry["caller"] = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+		}
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			entry[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+		}
+		writeJSON(entry)
+		if level == "fatal" {
+			os.Exit(255)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(msg)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fmt.Fprintf(&buf, " %v=%q", keysAndValues[i], fmt.Sprint(keysAndValues[i+1]))
+	}
+
+	switch level {
+	case "warning":
+		glog.WarningDepth(depth+1, buf.String())
+	case "error":
+		glog.ErrorDepth(depth+1, buf.String())
+	case "fatal":
+		glog.FatalDepth(depth+1, buf.String())
+	default:
+		glog.InfoDepth(depth+1, buf.String())
+	}
+}
+
+// Info logs a message at info level, formatting args as fmt.Sprint does.
+func Info(args ...interface{}) {
+	output("info", 1, fmt.Sprint(args...), nil)
+}
+
+// Infof logs a message at info level.
+func Infof(format string, args ...interface{}) {
+	output("info", 1, fmt.Sprintf(format, args...), nil)
+}
+
+// Warningf logs a message at warning level.
+func Warningf(format string, args ...interface{}) {
+	output("warning", 1, fmt.Sprintf(format, args...), nil)
+}
+
+// Error logs a message at error level, formatting args as fmt.Sprint does.
+func Error(args ...interface{}) {
+	output("error", 1, fmt.Sprint(args...), nil)
+}
+
+// Errorf logs a message at error level.
+func Errorf(format string, args ...interface{}) {
+	output("error", 1, fmt.Sprintf(format, args...), nil)
+}
+
+// Fatalf logs a message at fatal level and exits the process.
+func Fatalf(format string, args ...interface{}) {
+	output("fatal", 1, fmt.Sprintf(format, args...), nil)
+}
+
+// Verbose logs messages only if the verbosity level it was created with by V
+// is enabled.
+type Verbose bool
+
+// V returns a Verbose that logs messages if the glog verbosity, as set by the
+// -v flag, is at least level. The -vmodule flag is matched against this
+// package rather than the caller.
+func V(level glog.Level) Verbose {
+	return Verbose(glog.V(level))
+}
+
+// Info logs a message at info level if v is enabled, formatting args as
+// fmt.Sprint does.
+func (v Verbose) Info(args ...interface{}) {
+	if v {
+		output("info", 1, fmt.Sprint(args...), nil)
+	}
+}
+
+// Infof logs a message at info level if v is enabled.
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v {
+		output("info", 1, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// handleError is installed as the logging handler of
+// k8s.io/apimachinery/pkg/util/runtime.HandleError, so that errors reported
+// by client-go and the controllers are written in the current format.
+func handleError(err error) {
+	output("error", 2, err.Error(), nil)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+func TestLoggerJSON(t *testing.T) {
+	tests := map[string]struct {
+		log      func(Logger)
+		expected map[string]interface{}
+	}{
+		"info line": {
+			log: func(l Logger) { l.Infof("syncing item %q", "default/test") },
+			expected: map[string]interface{}{
+				"level":      "info",
+				"msg":        `syncing item "default/test"`,
+				"controller": "certificates",
+			},
+		},
+		"error line": {
+			log: func(l Logger) { l.WithValues("key", "default/test").Errorf("something failed: %v", "oops") },
+			expected: map[string]interface{}{
+				"level":      "error",
+				"msg":        "something failed: oops",
+				"controller": "certificates",
+				"key":        "default/test",
+			},
+		},
+		"package level line": {
+			log: func(Logger) { Warningf("retrying %s", "default/test") },
+			expected: map[string]interface{}{
+				"level": "warning",
+				"msg":   "retrying default/test",
+			},
+		},
+		"verbose line": {
+			log: func(Logger) { V(0).Infof("synced %d items", 2) },
+			expected: map[string]interface{}{
+				"level": "info",
+				"msg":   "synced 2 items",
+			},
+		},
+		"handled error": {
+			log: func(Logger) { utilruntime.HandleError(fmt.Errorf("watch failed")) },
+			expected: map[string]interface{}{
+				"level": "error",
+				"msg":   "watch failed",
+			},
+		},
+	}
+
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatalf("unexpected error setting format: %v", err)
+	}
+	defer SetFormat(FormatText)
+
+	for n, test := range tests {
+		t.Run(n, func(t *testing.T) {
+			var buf bytes.Buffer
+			outLock.Lock()
+			oldOut := out
+			out = &buf
+			outLock.Unlock()
+			defer func() {
+				outLock.Lock()
+				out = oldOut
+				outLock.Unlock()
+			}()
+
+			test.log(WithValues("controller", "certificates"))
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("error decoding output %q: %v", buf.String(), err)
+			}
+			if _, ok := entry["ts"]; !ok {
+				t.Errorf("expected a ts field to be set")
+			}
+			if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "structured_test.go:") {
+				t.Errorf("expected the caller to be the test, got %q", caller)
+			}
+			for k, v := range test.expected {
+				if entry[k] != v {
+					t.Errorf("expected field %q to be %q but got %q", k, v, entry[k])
+				}
+			}
+		})
+	}
+}
+
+func TestSetFormatInvalid(t *testing.T) {
+	if err := SetFormat("xml"); err == nil {
+		t.Errorf("expected an error for an invalid format")
+	}
+	if f := currentFormat(); f != FormatText {
+		t.Errorf("expected the format to be unchanged, got %q", f)
+	}
+}
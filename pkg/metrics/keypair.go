@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// keyPairReloader serves a TLS certificate and private key from disk,
+// reloading them whenever either file is modified, so that certificates
+// rotated in a mounted Secret are picked up without a restart.
+type keyPairReloader struct {
+	certFile, keyFile string
+
+	lock            sync.Mutex
+	cert            *tls.Certificate
+	certMod, keyMod time.Time
+}
+
+// newKeyPairReloader loads the key pair in certFile and keyFile, returning
+// an error if it cannot be loaded.
+func newKeyPairReloader(certFile, keyFile string) (*keyPairReloader, error) {
+	r := &keyPairReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. If the key pair has
+// been modified on disk but cannot be loaded, for example because only one
+// of the files has been updated so far, the previous key pair is served.
+func (r *keyPairReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.reload(); err != nil {
+		logs.Errorf("Error reloading metrics TLS certificate, serving the previous certificate: %v", err)
+	}
+	return r.cert, nil
+}
+
+// reload loads the key pair if either file has been modified since it was
+// last loaded. It must be called with r.lock held, or before r is shared.
+func (r *keyPairReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("error reading TLS certificate: %s", err.Error())
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("error reading TLS private key: %s", err.Error())
+	}
+	if r.cert != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate: %s", err.Error())
+	}
+	if r.cert != nil {
+		logs.Infof("Reloaded metrics TLS certificate from %q", r.certFile)
+	}
+	r.cert = &cert
+	r.certMod = certInfo.ModTime()
+	r.keyMod = keyInfo.ModTime()
+	return nil
+}
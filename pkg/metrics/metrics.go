@@ -17,16 +17,30 @@ limitations under the License.
 // Package metrics contains global structures related to metrics collection
 // cert-manager exposes the following metrics:
 // certificate_expiration_timestamp_seconds{name, namespace}
+// certificate_ready_status{name, namespace, condition}
+// controller_restart_count{controller}
+// controller_enabled{controller}
+// acme_order_result_total{issuer, result, reason}
+// acme_challenge_result_total{type, result, reason}
+// controller_workqueue_depth{controller}
+// controller_workqueue_adds_total{controller}
+// controller_workqueue_retries_total{controller}
+// controller_workqueue_queue_duration_seconds{controller}
+// controller_workqueue_work_duration_seconds{controller}
+// leader_election_status{name}
 package metrics
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -35,14 +49,20 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/kube"
+	"github.com/jetstack/cert-manager/pkg/util/pki"
+	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
 
+// DefaultListenAddress is the address the metrics server listens on if none
+// is configured.
+const DefaultListenAddress = "0.0.0.0:9402"
+
 const (
 	// Namespace is the namespace for cert-manager metric names
 	namespace                              = "certmanager"
-	prometheusMetricsServerAddress         = "0.0.0.0:9402"
 	prometheusMetricsServerShutdownTimeout = 5 * time.Second
 	prometheusMetricsServerReadTimeout     = 8 * time.Second
 	prometheusMetricsServerWriteTimeout    = 8 * time.Second
@@ -61,8 +81,24 @@ var CertificateExpiryTimeSeconds = prometheus.NewGaugeVec(
 	[]string{"name", "namespace"},
 )
 
-// ACMEClientRequestCount is a Prometheus summary to collect the number of
-// requests made to each endpoint with the ACME client.
+// CertificateReadyStatus is a Prometheus gauge that is set to 1 for the
+// current status of each Certificate's Ready condition, and 0 for the others.
+var CertificateReadyStatus = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificate_ready_status",
+		Help:      "The ready status of the certificate.",
+	},
+	[]string{"name", "namespace", "condition"},
+)
+
+// readyConditionStatuses are the values of the condition label of
+// CertificateReadyStatus
+var readyConditionStatuses = []v1alpha1.ConditionStatus{v1alpha1.ConditionTrue, v1alpha1.ConditionFalse, v1alpha1.ConditionUnknown}
+
+// ACMEClientRequestCount is a Prometheus counter to collect the number of
+// requests made to each endpoint with the ACME client, by response status.
+// Requests that fail without a response are recorded with status 999.
 var ACMEClientRequestCount = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Namespace: namespace,
@@ -86,14 +122,118 @@ var ACMEClientRequestDurationSeconds = prometheus.NewSummaryVec(
 	[]string{"scheme", "host", "path", "method", "status"},
 )
 
+// ControllerRestartCount is a Prometheus counter of the number of times each
+// controller has been restarted after exiting with an error.
+var ControllerRestartCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "controller_restart_count",
+		Help:      "The number of times a controller has been restarted after exiting with an error.",
+	},
+	[]string{"controller"},
+)
+
+// ControllerEnabled is a Prometheus gauge that is set to 1 for each known
+// controller that is running, and 0 for each known controller that is not.
+var ControllerEnabled = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "controller_enabled",
+		Help:      "Whether a known controller is enabled (1) or disabled (0).",
+	},
+	[]string{"controller"},
+)
+
+// LeaderElectionStatus is a Prometheus gauge that is set to 1 while this
+// instance holds the named leader election lock, and 0 otherwise.
+var LeaderElectionStatus = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "leader_election_status",
+		Help:      "Whether this instance is the leader (1) or not (0) for the named leader election lock.",
+	},
+	[]string{"name"},
+)
+
+// ACMEOrderResultCount is a Prometheus counter of the number of ACME orders
+// that have reached a final state.
+var ACMEOrderResultCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "acme_order_result_total",
+		Help:      "The number of ACME orders that have reached a final state, by issuer, result and failure reason.",
+	},
+	[]string{"issuer", "result", "reason"},
+)
+
+// ACMEChallengeResultCount is a Prometheus counter of the number of ACME
+// challenges that have reached a final state.
+var ACMEChallengeResultCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "acme_challenge_result_total",
+		Help:      "The number of ACME challenges that have reached a final state, by challenge type, result and failure reason.",
+	},
+	[]string{"type", "result", "reason"},
+)
+
+const (
+	// ACMEReasonNone is the reason recorded for ACME orders and challenges
+	// that have not failed
+	ACMEReasonNone = "none"
+	// ACMEReasonUnknown is the reason recorded for ACME orders and challenges
+	// that have failed without an ACME problem document
+	ACMEReasonUnknown = "unknown"
+	// ACMEReasonOther is the reason recorded for ACME problem types that are
+	// not defined by RFC 8555
+	ACMEReasonOther = "other"
+
+	acmeErrorNamespace = "urn:ietf:params:acme:error:"
+)
+
+// acmeProblemTypes are the ACME error types defined in RFC 8555 section 6.7.
+// They are used to bound the values of the reason label.
+var acmeProblemTypes = map[string]bool{
+	"accountDoesNotExist":     true,
+	"alreadyRevoked":          true,
+	"badCSR":                  true,
+	"badNonce":                true,
+	"badPublicKey":            true,
+	"badRevocationReason":     true,
+	"badSignatureAlgorithm":   true,
+	"caa":                     true,
+	"compound":                true,
+	"connection":              true,
+	"dns":                     true,
+	"externalAccountRequired": true,
+	"incorrectResponse":       true,
+	"invalidContact":          true,
+	"malformed":               true,
+	"orderNotReady":           true,
+	"rateLimited":             true,
+	"rejectedIdentifier":      true,
+	"serverInternal":          true,
+	"tls":                     true,
+	"unauthorized":            true,
+	"unsupportedContact":      true,
+	"unsupportedIdentifier":   true,
+	"userActionRequired":      true,
+}
+
 type Metrics struct {
 	http.Server
 
 	// TODO (@dippynark): switch this to use an interface to make it testable
 	registry                         *prometheus.Registry
 	CertificateExpiryTimeSeconds     *prometheus.GaugeVec
+	CertificateReadyStatus           *prometheus.GaugeVec
 	ACMEClientRequestDurationSeconds *prometheus.SummaryVec
 	ACMEClientRequestCount           *prometheus.CounterVec
+	ControllerRestartCount           *prometheus.CounterVec
+	ControllerEnabled                *prometheus.GaugeVec
+	LeaderElectionStatus             *prometheus.GaugeVec
+	ACMEOrderResultCount             *prometheus.CounterVec
+	ACMEChallengeResultCount         *prometheus.CounterVec
 }
 
 func New() *Metrics {
@@ -103,7 +243,7 @@ func New() *Metrics {
 	// Create server and register prometheus metrics handler
 	s := &Metrics{
 		Server: http.Server{
-			Addr:           prometheusMetricsServerAddress,
+			Addr:           DefaultListenAddress,
 			ReadTimeout:    prometheusMetricsServerReadTimeout,
 			WriteTimeout:   prometheusMetricsServerWriteTimeout,
 			MaxHeaderBytes: prometheusMetricsServerMaxHeaderBytes,
@@ -111,8 +251,14 @@ func New() *Metrics {
 		},
 		registry:                         prometheus.NewRegistry(),
 		CertificateExpiryTimeSeconds:     CertificateExpiryTimeSeconds,
+		CertificateReadyStatus:           CertificateReadyStatus,
 		ACMEClientRequestDurationSeconds: ACMEClientRequestDurationSeconds,
 		ACMEClientRequestCount:           ACMEClientRequestCount,
+		ControllerRestartCount:           ControllerRestartCount,
+		ControllerEnabled:                ControllerEnabled,
+		LeaderElectionStatus:             LeaderElectionStatus,
+		ACMEOrderResultCount:             ACMEOrderResultCount,
+		ACMEChallengeResultCount:         ACMEChallengeResultCount,
 	}
 
 	router.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
@@ -122,41 +268,134 @@ func New() *Metrics {
 
 func (m *Metrics) waitShutdown(stopCh <-chan struct{}) {
 	<-stopCh
-	glog.Info("Stopping Prometheus metrics server...")
+	logs.Info("Stopping Prometheus metrics server...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), prometheusMetricsServerShutdownTimeout)
 	defer cancel()
 
 	if err := m.Shutdown(ctx); err != nil {
-		glog.Errorf("Prometheus metrics server shutdown error: %v", err)
+		logs.Errorf("Prometheus metrics server shutdown error: %v", err)
 		return
 	}
 
-	glog.Info("Prometheus metrics server gracefully stopped")
+	logs.Info("Prometheus metrics server gracefully stopped")
+}
+
+// ServerOptions configures the address and TLS settings of the metrics
+// server.
+type ServerOptions struct {
+	// ListenAddress is the host:port the metrics server listens on.
+	ListenAddress string
+	// TLSCertFile and TLSKeyFile are the paths to a PEM encoded certificate
+	// and private key. If set, metrics are served over HTTPS. The files are
+	// reloaded when they change.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile is the path to a PEM encoded CA bundle. If set, clients
+	// must present a certificate signed by one of these CAs.
+	TLSClientCAFile string
+}
+
+// Configure applies opts to the metrics server. It must be called before
+// Start.
+func (m *Metrics) Configure(opts ServerOptions) error {
+	if opts.ListenAddress != "" {
+		m.Addr = opts.ListenAddress
+	}
+	if opts.TLSCertFile == "" && opts.TLSKeyFile == "" {
+		if opts.TLSClientCAFile != "" {
+			return fmt.Errorf("a client CA can only be used when serving metrics over TLS")
+		}
+		m.TLSConfig = nil
+		return nil
+	}
+
+	keyPair, err := newKeyPairReloader(opts.TLSCertFile, opts.TLSKeyFile)
+	if err != nil {
+		return err
+	}
+	tlsConfig := &tls.Config{
+		GetCertificate: keyPair.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+	if opts.TLSClientCAFile != "" {
+		caPEM, err := ioutil.ReadFile(opts.TLSClientCAFile)
+		if err != nil {
+			return fmt.Errorf("error reading client CA: %s", err.Error())
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in client CA file %q", opts.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	m.TLSConfig = tlsConfig
+	return nil
 }
 
 func (m *Metrics) Start(stopCh <-chan struct{}) {
 	m.registry.MustRegister(m.CertificateExpiryTimeSeconds)
+	m.registry.MustRegister(m.CertificateReadyStatus)
 	m.registry.MustRegister(m.ACMEClientRequestDurationSeconds)
 	m.registry.MustRegister(m.ACMEClientRequestCount)
+	m.registry.MustRegister(m.ControllerRestartCount)
+	m.registry.MustRegister(m.ControllerEnabled)
+	m.registry.MustRegister(m.LeaderElectionStatus)
+	m.registry.MustRegister(m.ACMEOrderResultCount)
+	m.registry.MustRegister(m.ACMEChallengeResultCount)
+	// the workqueue metrics are shared by all work queues in the process, as
+	// client-go only supports a single metrics provider
+	m.registry.MustRegister(WorkqueueDepth)
+	m.registry.MustRegister(WorkqueueAddsCount)
+	m.registry.MustRegister(WorkqueueRetriesCount)
+	m.registry.MustRegister(WorkqueueQueueDurationSeconds)
+	m.registry.MustRegister(WorkqueueWorkDurationSeconds)
 
 	go func() {
-
-		glog.Infof("Listening on http://%s", m.Addr)
-		if err := m.ListenAndServe(); err != nil {
-			glog.Errorf("Error running prometheus metrics server: %s", err.Error())
+		ln, err := net.Listen("tcp", m.Addr)
+		if err != nil {
+			logs.Errorf("Error running prometheus metrics server: %s", err.Error())
 			return
 		}
-
-		glog.Infof("Prometheus metrics server exited")
-
+		m.serve(ln)
 	}()
 
 	m.waitShutdown(stopCh)
 }
 
+// serve serves metrics on ln until the server is shut down, using HTTPS if
+// a TLS config has been set by Configure.
+func (m *Metrics) serve(ln net.Listener) {
+	var err error
+	if m.TLSConfig != nil {
+		logs.Infof("Listening on https://%s", ln.Addr())
+		err = m.ServeTLS(ln, "", "")
+	} else {
+		logs.Infof("Listening on http://%s", ln.Addr())
+		err = m.Serve(ln)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		logs.Errorf("Error running prometheus metrics server: %s", err.Error())
+		return
+	}
+
+	logs.Infof("Prometheus metrics server exited")
+}
+
 // UpdateCertificateExpiry updates the expiry time of a certificate
 func (m *Metrics) UpdateCertificateExpiry(crt *v1alpha1.Certificate, secretLister corelisters.SecretLister) {
+	if crt.Spec.Output == v1alpha1.CertificateOutputSecretless {
+		if len(crt.Status.Certificate) == 0 {
+			return
+		}
+		cert, err := pki.DecodeX509CertificateBytes(crt.Status.Certificate)
+		if err != nil {
+			return
+		}
+		updateX509Expiry(crt.Name, crt.Namespace, cert)
+		return
+	}
 
 	// grab existing certificate
 	cert, err := kube.SecretTLSCert(secretLister, crt.Namespace, crt.Spec.SecretName)
@@ -177,3 +416,96 @@ func updateX509Expiry(name, namespace string, cert *x509.Certificate) {
 		"name":      name,
 		"namespace": namespace}).Set(float64(expiryTime.Unix()))
 }
+
+// RemoveCertificateExpiry removes the expiry time of a certificate, so that
+// a stale value is not reported once the certificate has been deleted
+func (m *Metrics) RemoveCertificateExpiry(name, namespace string) {
+	m.CertificateExpiryTimeSeconds.Delete(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace})
+}
+
+// UpdateCertificateReadyStatus sets the ready status of a certificate from
+// its Ready condition. A certificate without a Ready condition is reported
+// as Unknown.
+func (m *Metrics) UpdateCertificateReadyStatus(crt *v1alpha1.Certificate) {
+	status := v1alpha1.ConditionUnknown
+	for _, cond := range crt.Status.Conditions {
+		if cond.Type == v1alpha1.CertificateConditionReady {
+			status = cond.Status
+			break
+		}
+	}
+	for _, s := range readyConditionStatuses {
+		value := 0.0
+		if s == status {
+			value = 1
+		}
+		m.CertificateReadyStatus.With(prometheus.Labels{
+			"name":      crt.Name,
+			"namespace": crt.Namespace,
+			"condition": string(s)}).Set(value)
+	}
+}
+
+// RemoveCertificateReadyStatus removes the ready status of a certificate, so
+// that a stale value is not reported once the certificate has been deleted
+func (m *Metrics) RemoveCertificateReadyStatus(name, namespace string) {
+	for _, s := range readyConditionStatuses {
+		m.CertificateReadyStatus.Delete(prometheus.Labels{
+			"name":      name,
+			"namespace": namespace,
+			"condition": string(s)})
+	}
+}
+
+// IncrementControllerRestarts increments the restart count for the named
+// controller
+func (m *Metrics) IncrementControllerRestarts(controller string) {
+	m.ControllerRestartCount.With(prometheus.Labels{"controller": controller}).Inc()
+}
+
+// SetControllerEnabled records whether the named controller is enabled
+func (m *Metrics) SetControllerEnabled(controller string, enabled bool) {
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	m.ControllerEnabled.With(prometheus.Labels{"controller": controller}).Set(value)
+}
+
+// SetLeaderElectionStatus records whether this instance holds the named
+// leader election lock
+func (m *Metrics) SetLeaderElectionStatus(name string, leading bool) {
+	value := 0.0
+	if leading {
+		value = 1
+	}
+	m.LeaderElectionStatus.With(prometheus.Labels{"name": name}).Set(value)
+}
+
+// IncrementACMEOrderResult records that an ACME order for the named issuer
+// has reached the final state result.
+func (m *Metrics) IncrementACMEOrderResult(issuer string, result v1alpha1.State, reason string) {
+	m.ACMEOrderResultCount.With(prometheus.Labels{"issuer": issuer, "result": string(result), "reason": reason}).Inc()
+}
+
+// IncrementACMEChallengeResult records that an ACME challenge of the given
+// type has reached the final state result.
+func (m *Metrics) IncrementACMEChallengeResult(challengeType string, result v1alpha1.State, reason string) {
+	m.ACMEChallengeResultCount.With(prometheus.Labels{"type": challengeType, "result": string(result), "reason": reason}).Inc()
+}
+
+// ACMEProblemReason returns a bounded reason label for an ACME problem. This
+// is the short name of the problem type if it is defined by RFC 8555,
+// ACMEReasonOther if it is not, or ACMEReasonUnknown if problem is nil.
+func ACMEProblemReason(problem *acmeapi.Error) string {
+	if problem == nil {
+		return ACMEReasonUnknown
+	}
+	t := strings.TrimPrefix(problem.Type, acmeErrorNamespace)
+	if t != problem.Type && acmeProblemTypes[t] {
+		return t
+	}
+	return ACMEReasonOther
+}
@@ -23,6 +23,10 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	acmeapi "github.com/jetstack/cert-manager/third_party/crypto/acme"
 )
 
 func TestUpdateCertificateExpiry(t *testing.T) {
@@ -64,3 +68,222 @@ func TestUpdateCertificateExpiry(t *testing.T) {
 		})
 	}
 }
+
+func TestIncrementControllerRestarts(t *testing.T) {
+	const metadata = `
+	# HELP certmanager_controller_restart_count The number of times a controller has been restarted after exiting with an error.
+	# TYPE certmanager_controller_restart_count counter
+`
+	m := New()
+	m.IncrementControllerRestarts("certificates")
+	m.IncrementControllerRestarts("certificates")
+	m.IncrementControllerRestarts("issuers")
+
+	expected := `
+	certmanager_controller_restart_count{controller="certificates"} 2
+	certmanager_controller_restart_count{controller="issuers"} 1
+`
+	if err := testutil.CollectAndCompare(
+		ControllerRestartCount,
+		strings.NewReader(metadata+expected),
+		"certmanager_controller_restart_count",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestSetControllerEnabled(t *testing.T) {
+	const metadata = `
+	# HELP certmanager_controller_enabled Whether a known controller is enabled (1) or disabled (0).
+	# TYPE certmanager_controller_enabled gauge
+`
+	m := New()
+	m.SetControllerEnabled("certificates", true)
+	m.SetControllerEnabled("clusterissuers", false)
+
+	expected := `
+	certmanager_controller_enabled{controller="certificates"} 1
+	certmanager_controller_enabled{controller="clusterissuers"} 0
+`
+	if err := testutil.CollectAndCompare(
+		ControllerEnabled,
+		strings.NewReader(metadata+expected),
+		"certmanager_controller_enabled",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestSetLeaderElectionStatus(t *testing.T) {
+	const metadata = `
+	# HELP certmanager_leader_election_status Whether this instance is the leader (1) or not (0) for the named leader election lock.
+	# TYPE certmanager_leader_election_status gauge
+`
+	m := New()
+	m.SetLeaderElectionStatus("cert-manager-controller", true)
+	m.SetLeaderElectionStatus("cert-manager-controller", false)
+	m.SetLeaderElectionStatus("other-lock", true)
+
+	expected := `
+	certmanager_leader_election_status{name="cert-manager-controller"} 0
+	certmanager_leader_election_status{name="other-lock"} 1
+`
+	if err := testutil.CollectAndCompare(
+		LeaderElectionStatus,
+		strings.NewReader(metadata+expected),
+		"certmanager_leader_election_status",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestIncrementACMEResults(t *testing.T) {
+	const orderMetadata = `
+	# HELP certmanager_acme_order_result_total The number of ACME orders that have reached a final state, by issuer, result and failure reason.
+	# TYPE certmanager_acme_order_result_total counter
+`
+	const challengeMetadata = `
+	# HELP certmanager_acme_challenge_result_total The number of ACME challenges that have reached a final state, by challenge type, result and failure reason.
+	# TYPE certmanager_acme_challenge_result_total counter
+`
+	m := New()
+	m.IncrementACMEOrderResult("letsencrypt", v1alpha1.Valid, ACMEReasonNone)
+	m.IncrementACMEOrderResult("letsencrypt", v1alpha1.Valid, ACMEReasonNone)
+	m.IncrementACMEOrderResult("letsencrypt", v1alpha1.Errored, "rateLimited")
+	m.IncrementACMEChallengeResult("dns-01", v1alpha1.Valid, ACMEReasonNone)
+	m.IncrementACMEChallengeResult("http-01", v1alpha1.Invalid, "connection")
+
+	expectedOrders := `
+	certmanager_acme_order_result_total{issuer="letsencrypt",reason="none",result="valid"} 2
+	certmanager_acme_order_result_total{issuer="letsencrypt",reason="rateLimited",result="errored"} 1
+`
+	if err := testutil.CollectAndCompare(
+		ACMEOrderResultCount,
+		strings.NewReader(orderMetadata+expectedOrders),
+		"certmanager_acme_order_result_total",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+
+	expectedChallenges := `
+	certmanager_acme_challenge_result_total{reason="connection",result="invalid",type="http-01"} 1
+	certmanager_acme_challenge_result_total{reason="none",result="valid",type="dns-01"} 1
+`
+	if err := testutil.CollectAndCompare(
+		ACMEChallengeResultCount,
+		strings.NewReader(challengeMetadata+expectedChallenges),
+		"certmanager_acme_challenge_result_total",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestACMEProblemReason(t *testing.T) {
+	tests := map[string]struct {
+		problem  *acmeapi.Error
+		expected string
+	}{
+		"no problem":        {expected: ACMEReasonUnknown},
+		"rfc 8555 type":     {problem: &acmeapi.Error{Type: "urn:ietf:params:acme:error:rateLimited"}, expected: "rateLimited"},
+		"unknown acme type": {problem: &acmeapi.Error{Type: "urn:ietf:params:acme:error:somethingNew"}, expected: ACMEReasonOther},
+		"non acme type":     {problem: &acmeapi.Error{Type: "about:blank"}, expected: ACMEReasonOther},
+		"empty type":        {problem: &acmeapi.Error{StatusCode: 400}, expected: ACMEReasonOther},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ACMEProblemReason(test.problem); got != test.expected {
+				t.Errorf("expected reason %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRemoveCertificateExpiry(t *testing.T) {
+	const metadata = `
+	# HELP certmanager_certificate_expiration_timestamp_seconds The date after which the certificate expires. Expressed as a Unix Epoch Time.
+	# TYPE certmanager_certificate_expiration_timestamp_seconds gauge
+`
+	CertificateExpiryTimeSeconds.Reset()
+	m := New()
+	updateX509Expiry("removed", "default", &x509.Certificate{NotAfter: time.Unix(2208988804, 0)})
+	updateX509Expiry("kept", "default", &x509.Certificate{NotAfter: time.Unix(2208988804, 0)})
+	m.RemoveCertificateExpiry("removed", "default")
+	defer m.RemoveCertificateExpiry("kept", "default")
+
+	expected := `
+	certmanager_certificate_expiration_timestamp_seconds{name="kept",namespace="default"} 2.208988804e+09
+`
+	if err := testutil.CollectAndCompare(
+		CertificateExpiryTimeSeconds,
+		strings.NewReader(metadata+expected),
+		"certmanager_certificate_expiration_timestamp_seconds",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestUpdateCertificateReadyStatus(t *testing.T) {
+	const metadata = `
+	# HELP certmanager_certificate_ready_status The ready status of the certificate.
+	# TYPE certmanager_certificate_ready_status gauge
+`
+	crt := &v1alpha1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: "something", Namespace: "default"}}
+	setReady := func(status v1alpha1.ConditionStatus) {
+		crt.Status.Conditions = []v1alpha1.CertificateCondition{{Type: v1alpha1.CertificateConditionReady, Status: status}}
+	}
+
+	tests := []struct {
+		name     string
+		update   func()
+		expected string
+	}{
+		{
+			name:   "no Ready condition is reported as Unknown",
+			update: func() {},
+			expected: `
+	certmanager_certificate_ready_status{condition="False",name="something",namespace="default"} 0
+	certmanager_certificate_ready_status{condition="True",name="something",namespace="default"} 0
+	certmanager_certificate_ready_status{condition="Unknown",name="something",namespace="default"} 1
+`,
+		},
+		{
+			name:   "Ready condition set to False",
+			update: func() { setReady(v1alpha1.ConditionFalse) },
+			expected: `
+	certmanager_certificate_ready_status{condition="False",name="something",namespace="default"} 1
+	certmanager_certificate_ready_status{condition="True",name="something",namespace="default"} 0
+	certmanager_certificate_ready_status{condition="Unknown",name="something",namespace="default"} 0
+`,
+		},
+		{
+			name:   "Ready condition toggled to True",
+			update: func() { setReady(v1alpha1.ConditionTrue) },
+			expected: `
+	certmanager_certificate_ready_status{condition="False",name="something",namespace="default"} 0
+	certmanager_certificate_ready_status{condition="True",name="something",namespace="default"} 1
+	certmanager_certificate_ready_status{condition="Unknown",name="something",namespace="default"} 0
+`,
+		},
+	}
+
+	m := New()
+	// the tests are run in order, as each builds on the state of the last
+	for _, test := range tests {
+		test.update()
+		m.UpdateCertificateReadyStatus(crt)
+		if err := testutil.CollectAndCompare(
+			CertificateReadyStatus,
+			strings.NewReader(metadata+test.expected),
+			"certmanager_certificate_ready_status",
+		); err != nil {
+			t.Errorf("%s: unexpected collecting result:\n%s", test.name, err)
+		}
+	}
+
+	m.RemoveCertificateReadyStatus("something", "default")
+	for _, condition := range []string{"True", "False", "Unknown"} {
+		if CertificateReadyStatus.Delete(map[string]string{"name": "something", "namespace": "default", "condition": condition}) {
+			t.Errorf("expected ready status with condition %q to be removed", condition)
+		}
+	}
+}
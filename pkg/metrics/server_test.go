@@ -0,0 +1,244 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert writes a self signed certificate for 127.0.0.1, valid
+// for both server and client authentication, and its private key to dir.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string, cert tls.Certificate, pool *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "metrics"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding private key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatalf("error writing private key: %v", err)
+	}
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("error loading key pair: %v", err)
+	}
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return certFile, keyFile, cert, pool
+}
+
+// startTestServer configures a new metrics server with opts and serves it on
+// a random local port, returning the address it is listening on.
+func startTestServer(t *testing.T, opts ServerOptions) (*Metrics, string) {
+	m := New()
+	if err := m.Configure(opts); err != nil {
+		t.Fatalf("error configuring metrics server: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	go m.serve(ln)
+	return m, ln.Addr().String()
+}
+
+func TestConfigureListenAddress(t *testing.T) {
+	m := New()
+	if m.Addr != DefaultListenAddress {
+		t.Errorf("expected default address %q, got %q", DefaultListenAddress, m.Addr)
+	}
+	if err := m.Configure(ServerOptions{ListenAddress: "127.0.0.1:8443"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Addr != "127.0.0.1:8443" {
+		t.Errorf("expected address %q, got %q", "127.0.0.1:8443", m.Addr)
+	}
+	if m.TLSConfig != nil {
+		t.Errorf("expected no TLS config when no certificate is configured")
+	}
+	if err := m.Configure(ServerOptions{TLSClientCAFile: "ca.crt"}); err == nil {
+		t.Errorf("expected an error configuring a client CA without a serving certificate")
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-tls")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile, cert, pool := writeSelfSignedCert(t, dir)
+
+	m, addr := startTestServer(t, ServerOptions{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	defer m.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("https://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("error scraping metrics over TLS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 || !bytes.Equal(resp.TLS.PeerCertificates[0].Raw, cert.Certificate[0]) {
+		t.Errorf("expected the configured certificate to be served")
+	}
+
+	resp, err = http.Get("http://" + addr + "/metrics")
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Errorf("expected plain HTTP requests to be rejected")
+		}
+	}
+}
+
+func TestServeTLSReloadsCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-tls-reload")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile, _, _ := writeSelfSignedCert(t, dir)
+
+	m, addr := startTestServer(t, ServerOptions{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	defer m.Close()
+
+	// replace the key pair on disk, making sure its modification time
+	// differs from the one that was loaded
+	_, _, cert, pool := writeSelfSignedCert(t, dir)
+	later := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, later, later); err != nil {
+			t.Fatalf("error updating modification time: %v", err)
+		}
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("https://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("error scraping metrics over TLS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 || !bytes.Equal(resp.TLS.PeerCertificates[0].Raw, cert.Certificate[0]) {
+		t.Errorf("expected the updated certificate to be served")
+	}
+}
+
+func TestKeyPairReloaderKeepsPreviousOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-tls-reload")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile, cert, _ := writeSelfSignedCert(t, dir)
+
+	r, err := newKeyPairReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("error loading key pair: %v", err)
+	}
+
+	// a certificate that has been updated without its key does not load
+	if err := ioutil.WriteFile(certFile, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatalf("error updating modification time: %v", err)
+	}
+
+	served, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(served.Certificate[0], cert.Certificate[0]) {
+		t.Errorf("expected the previous certificate to be served")
+	}
+}
+
+func TestServeMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-mtls")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile, cert, pool := writeSelfSignedCert(t, dir)
+
+	m, addr := startTestServer(t, ServerOptions{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: certFile})
+	defer m.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	if resp, err := client.Get("https://" + addr + "/metrics"); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected a client without a certificate to be rejected")
+	}
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+	}}}
+	resp, err := client.Get("https://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("error scraping metrics with a client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// The workqueue metrics are labelled with the name of the queue, which for
+// cert-manager's controllers is the name of the controller.
+var (
+	WorkqueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "controller_workqueue",
+			Name:      "depth",
+			Help:      "The number of items waiting in a controller's work queue.",
+		},
+		[]string{"controller"},
+	)
+
+	WorkqueueAddsCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "controller_workqueue",
+			Name:      "adds_total",
+			Help:      "The number of items added to a controller's work queue.",
+		},
+		[]string{"controller"},
+	)
+
+	WorkqueueRetriesCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "controller_workqueue",
+			Name:      "retries_total",
+			Help:      "The number of items re-queued with rate limiting after failing to be processed.",
+		},
+		[]string{"controller"},
+	)
+
+	WorkqueueQueueDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "controller_workqueue",
+			Name:      "queue_duration_seconds",
+			Help:      "How long items wait in a controller's work queue before being processed.",
+			Buckets:   workqueueDurationBuckets,
+		},
+		[]string{"controller"},
+	)
+
+	WorkqueueWorkDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "controller_workqueue",
+			Name:      "work_duration_seconds",
+			Help:      "How long a controller takes to process an item from its work queue.",
+			Buckets:   workqueueDurationBuckets,
+		},
+		[]string{"controller"},
+	)
+
+	// workqueueDurationBuckets range from 1ms to roughly 16 minutes, as items
+	// may be re-queued with long back-offs
+	workqueueDurationBuckets = prometheus.ExponentialBuckets(0.001, 4, 11)
+)
+
+func init() {
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// workqueueMetricsProvider provides Prometheus metrics to client-go work
+// queues. It must be set before any queues are created.
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return WorkqueueDepth.With(prometheus.Labels{"controller": name})
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return WorkqueueAddsCount.With(prometheus.Labels{"controller": name})
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return microsecondsObserver{WorkqueueQueueDurationSeconds.With(prometheus.Labels{"controller": name})}
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return microsecondsObserver{WorkqueueWorkDurationSeconds.With(prometheus.Labels{"controller": name})}
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return WorkqueueRetriesCount.With(prometheus.Labels{"controller": name})
+}
+
+// microsecondsObserver converts the durations observed by client-go work
+// queues, which are in microseconds, to seconds.
+type microsecondsObserver struct {
+	prometheus.Observer
+}
+
+func (o microsecondsObserver) Observe(microseconds float64) {
+	o.Observer.Observe(microseconds / 1e6)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestWorkqueueMetrics(t *testing.T) {
+	labels := prometheus.Labels{"controller": "test-controller"}
+	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test-controller")
+	defer q.ShutDown()
+
+	q.Add("a")
+	q.Add("b")
+	if v := testutil.ToFloat64(WorkqueueDepth.With(labels)); v != 2 {
+		t.Errorf("expected depth 2, got %v", v)
+	}
+	if v := testutil.ToFloat64(WorkqueueAddsCount.With(labels)); v != 2 {
+		t.Errorf("expected 2 adds, got %v", v)
+	}
+
+	item, _ := q.Get()
+	q.Done(item)
+	if v := testutil.ToFloat64(WorkqueueDepth.With(labels)); v != 1 {
+		t.Errorf("expected depth 1 after processing an item, got %v", v)
+	}
+	if n := histogramSampleCount(t, WorkqueueQueueDurationSeconds.With(labels)); n != 1 {
+		t.Errorf("expected 1 queue duration sample, got %d", n)
+	}
+	if n := histogramSampleCount(t, WorkqueueWorkDurationSeconds.With(labels)); n != 1 {
+		t.Errorf("expected 1 work duration sample, got %d", n)
+	}
+
+	q.AddRateLimited("c")
+	if v := testutil.ToFloat64(WorkqueueRetriesCount.With(labels)); v != 1 {
+		t.Errorf("expected 1 retry, got %v", v)
+	}
+}
+
+func histogramSampleCount(t *testing.T, o prometheus.Observer) uint64 {
+	m := &dto.Metric{}
+	if err := o.(prometheus.Metric).Write(m); err != nil {
+		t.Fatalf("error reading histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
@@ -30,3 +30,50 @@ func IsInvalidData(err error) bool {
 	}
 	return true
 }
+
+type backoffError struct{ error }
+
+// NewBackoff returns an error for work that has deliberately not been done
+// yet, because a recent attempt failed and is being backed off or because an
+// external service has not finished processing a request.
+func NewBackoff(str string, obj ...interface{}) error {
+	return &backoffError{error: fmt.Errorf(str, obj...)}
+}
+
+func IsBackoff(err error) bool {
+	_, ok := err.(*backoffError)
+	return ok
+}
+
+const (
+	// ReasonSecretNotFound is the reason used for errors returned when a
+	// referenced Secret does not exist.
+	ReasonSecretNotFound = "SecretNotFound"
+
+	// ReasonSecretMissingKey is the reason used for errors returned when a
+	// referenced Secret exists but does not contain the requested key.
+	ReasonSecretMissingKey = "SecretMissingKey"
+)
+
+type secretError struct {
+	reason string
+	error
+}
+
+func NewSecretNotFound(str string, obj ...interface{}) error {
+	return &secretError{reason: ReasonSecretNotFound, error: fmt.Errorf(str, obj...)}
+}
+
+func NewSecretMissingKey(str string, obj ...interface{}) error {
+	return &secretError{reason: ReasonSecretMissingKey, error: fmt.Errorf(str, obj...)}
+}
+
+// SecretErrorReason returns the reason for an error created by
+// NewSecretNotFound or NewSecretMissingKey, and false for any other error.
+func SecretErrorReason(err error) (string, bool) {
+	serr, ok := err.(*secretError)
+	if !ok {
+		return "", false
+	}
+	return serr.reason, true
+}
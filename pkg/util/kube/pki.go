@@ -19,10 +19,12 @@ package kube
 import (
 	"crypto"
 	"crypto/x509"
+	"fmt"
 
 	api "k8s.io/api/core/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
 	"github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
 )
@@ -55,6 +57,44 @@ func SecretTLSKey(secretLister corelisters.SecretLister, namespace, name string)
 	return SecretTLSKeyRef(secretLister, namespace, name, api.TLSPrivateKeyKey)
 }
 
+// SecretTLSKeyForCertificate will decode the private key stored in the secret
+// for the given Certificate, taking into account the Certificate's private
+// key rotation policy.
+// With the 'Always' policy, an InvalidData error is returned if the secret
+// also contains a certificate issued for the private key, so that a new
+// private key is generated for the next issuance.
+// With the 'Never' policy, an error is returned if the private key does not
+// match the key algorithm, size and curve requested by the Certificate.
+func SecretTLSKeyForCertificate(secretLister corelisters.SecretLister, crt *v1alpha1.Certificate) (crypto.Signer, error) {
+	key, err := SecretTLSKey(secretLister, crt.Namespace, crt.Spec.SecretName)
+	if err != nil {
+		return key, err
+	}
+
+	if crt.Spec.PrivateKey == nil {
+		return key, nil
+	}
+
+	switch crt.Spec.PrivateKey.RotationPolicy {
+	case v1alpha1.RotationPolicyAlways:
+		cert, err := SecretTLSCert(secretLister, crt.Namespace, crt.Spec.SecretName)
+		if err != nil {
+			// no certificate has been issued for this private key yet
+			return key, nil
+		}
+		matches, err := pki.PublicKeyMatchesCertificate(key.Public(), cert)
+		if err == nil && matches {
+			return nil, errors.NewInvalidData("private key in secret '%s/%s' must be rotated on issuance", crt.Namespace, crt.Spec.SecretName)
+		}
+	case v1alpha1.RotationPolicyNever:
+		if err := pki.PrivateKeyMatchesSpec(key, crt); err != nil {
+			return nil, fmt.Errorf("existing private key in secret '%s/%s' cannot be re-used: %v", crt.Namespace, crt.Spec.SecretName, err)
+		}
+	}
+
+	return key, nil
+}
+
 func SecretTLSCertChain(secretLister corelisters.SecretLister, namespace, name string) ([]*x509.Certificate, error) {
 	secret, err := secretLister.Secrets(namespace).Get(name)
 	if err != nil {
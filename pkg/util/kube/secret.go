@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	api "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/jetstack/cert-manager/pkg/util/errors"
+)
+
+// GetSecret returns the Secret with 'name' in 'namespace'. If the Secret does
+// not exist, the returned error has the reason errors.ReasonSecretNotFound.
+func GetSecret(secretLister corelisters.SecretLister, namespace, name string) (*api.Secret, error) {
+	secret, err := secretLister.Secrets(namespace).Get(name)
+	if k8sErrors.IsNotFound(err) {
+		return nil, errors.NewSecretNotFound("secret '%s/%s' not found", namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// SecretKeyData returns the data stored under 'key' in the Secret with 'name'
+// in 'namespace'. If the Secret does not exist or does not contain 'key', the
+// returned error has the reason errors.ReasonSecretNotFound or
+// errors.ReasonSecretMissingKey respectively.
+func SecretKeyData(secretLister corelisters.SecretLister, namespace, name, key string) ([]byte, error) {
+	secret, err := GetSecret(secretLister, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, errors.NewSecretMissingKey("no data for %q in secret '%s/%s'", key, namespace, name)
+	}
+	return data, nil
+}
@@ -26,9 +26,11 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"net/url"
 	"time"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/util/errors"
 )
 
 // CommonNameForCertificate returns the common name that should be used for the
@@ -78,6 +80,28 @@ func IPAddressesToString(ipAddresses []net.IP) []string {
 	return ipNames
 }
 
+// URIsForCertificate returns the URI subject alternative names that should be
+// used for the given Certificate resource. Entries that cannot be parsed are
+// skipped, as they are rejected when the Certificate is validated.
+func URIsForCertificate(crt *v1alpha1.Certificate) []*url.URL {
+	var uris []*url.URL
+	for _, uriName := range crt.Spec.URIs {
+		uri, err := url.Parse(uriName)
+		if err == nil {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+func URIsToString(uris []*url.URL) []string {
+	var uriNames []string
+	for _, uri := range uris {
+		uriNames = append(uriNames, uri.String())
+	}
+	return uriNames
+}
+
 func removeDuplicates(in []string) []string {
 	var found []string
 Outer:
@@ -114,10 +138,11 @@ var serialNumberLimit = new(big.Int).Lsh(big.NewInt(1), 128)
 func GenerateCSR(issuer v1alpha1.GenericIssuer, crt *v1alpha1.Certificate) (*x509.CertificateRequest, error) {
 	commonName := CommonNameForCertificate(crt)
 	dnsNames := DNSNamesForCertificate(crt)
-	iPAddresses := IPAddressesForCertificate(crt)
+	ipAddresses := IPAddressesForCertificate(crt)
+	uris := URIsForCertificate(crt)
 	organization := OrganizationForCertificate(crt)
 
-	if len(commonName) == 0 && len(dnsNames) == 0 {
+	if len(commonName) == 0 && len(dnsNames) == 0 && len(ipAddresses) == 0 && len(uris) == 0 {
 		return nil, fmt.Errorf("no domains specified on certificate")
 	}
 
@@ -126,6 +151,20 @@ func GenerateCSR(issuer v1alpha1.GenericIssuer, crt *v1alpha1.Certificate) (*x50
 		return nil, err
 	}
 
+	// only request key usages that have been explicitly set, leaving the
+	// defaults to the issuer
+	extensions := []pkix.Extension{}
+	if len(crt.Spec.Usages) > 0 {
+		ku, eku, err := KeyUsagesForCertificate(crt)
+		if err != nil {
+			return nil, err
+		}
+		extensions, err = keyUsageExtensions(ku, eku)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &x509.CertificateRequest{
 		Version:            3,
 		SignatureAlgorithm: sigAlgo,
@@ -134,10 +173,10 @@ func GenerateCSR(issuer v1alpha1.GenericIssuer, crt *v1alpha1.Certificate) (*x50
 			Organization: organization,
 			CommonName:   commonName,
 		},
-		DNSNames:    dnsNames,
-		IPAddresses: iPAddresses,
-		// TODO: work out how best to handle extensions/key usages here
-		ExtraExtensions: []pkix.Extension{},
+		DNSNames:        dnsNames,
+		IPAddresses:     ipAddresses,
+		URIs:            uris,
+		ExtraExtensions: extensions,
 	}, nil
 }
 
@@ -149,9 +188,10 @@ func GenerateTemplate(issuer v1alpha1.GenericIssuer, crt *v1alpha1.Certificate)
 	commonName := CommonNameForCertificate(crt)
 	dnsNames := DNSNamesForCertificate(crt)
 	ipAddresses := IPAddressesForCertificate(crt)
+	uris := URIsForCertificate(crt)
 	organization := OrganizationForCertificate(crt)
 
-	if len(commonName) == 0 && len(dnsNames) == 0 {
+	if len(commonName) == 0 && len(dnsNames) == 0 && len(ipAddresses) == 0 && len(uris) == 0 {
 		return nil, fmt.Errorf("no domains specified on certificate")
 	}
 
@@ -170,9 +210,9 @@ func GenerateTemplate(issuer v1alpha1.GenericIssuer, crt *v1alpha1.Certificate)
 		return nil, err
 	}
 
-	keyUsages := x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
-	if crt.Spec.IsCA {
-		keyUsages |= x509.KeyUsageCertSign
+	keyUsages, extKeyUsages, err := KeyUsagesForCertificate(crt)
+	if err != nil {
+		return nil, err
 	}
 
 	return &x509.Certificate{
@@ -189,8 +229,65 @@ func GenerateTemplate(issuer v1alpha1.GenericIssuer, crt *v1alpha1.Certificate)
 		NotAfter:  time.Now().Add(certDuration),
 		// see http://golang.org/pkg/crypto/x509/#KeyUsage
 		KeyUsage:    keyUsages,
+		ExtKeyUsage: extKeyUsages,
 		DNSNames:    dnsNames,
 		IPAddresses: ipAddresses,
+		URIs:        uris,
+	}, nil
+}
+
+// GenerateTemplateFromCertificateRequest will create a x509.Certificate
+// template for the PEM encoded certificate signing request of the given
+// CertificateRequest. The subject, subject alternative names and public key
+// are taken from the certificate signing request, and the duration and CA
+// usage from the CertificateRequest.
+// Errors caused by the certificate signing request satisfy
+// errors.IsInvalidData, as the CertificateRequest cannot be signed without
+// being changed.
+func GenerateTemplateFromCertificateRequest(cr *v1alpha1.CertificateRequest) (*x509.Certificate, error) {
+	csr, err := DecodeX509CertificateRequestBytes(cr.Spec.CSRPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(csr.Subject.CommonName) == 0 && len(csr.DNSNames) == 0 && len(csr.IPAddresses) == 0 && len(csr.URIs) == 0 {
+		return nil, errors.NewInvalidData("no domains specified on certificate request")
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %s", err.Error())
+	}
+
+	certDuration := v1alpha1.DefaultCertificateDuration
+	if cr.Spec.Duration != nil {
+		certDuration = cr.Spec.Duration.Duration
+	}
+
+	keyUsages, extKeyUsages, err := KeyUsages(cr.Spec.Usages, cr.Spec.IsCA)
+	if err != nil {
+		return nil, errors.NewInvalidData("invalid usages: %s", err.Error())
+	}
+
+	return &x509.Certificate{
+		Version:               3,
+		BasicConstraintsValid: true,
+		SerialNumber:          serialNumber,
+		PublicKeyAlgorithm:    csr.PublicKeyAlgorithm,
+		PublicKey:             csr.PublicKey,
+		IsCA:                  cr.Spec.IsCA,
+		Subject: pkix.Name{
+			Organization: csr.Subject.Organization,
+			CommonName:   csr.Subject.CommonName,
+		},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(certDuration),
+		// see http://golang.org/pkg/crypto/x509/#KeyUsage
+		KeyUsage:    keyUsages,
+		ExtKeyUsage: extKeyUsages,
+		DNSNames:    csr.DNSNames,
+		IPAddresses: csr.IPAddresses,
+		URIs:        csr.URIs,
 	}, nil
 }
 
@@ -288,20 +385,25 @@ func SignatureAlgorithm(crt *v1alpha1.Certificate) (x509.PublicKeyAlgorithm, x50
 		}
 	case v1alpha1.ECDSAKeyAlgorithm:
 		pubKeyAlgo = x509.ECDSA
-		switch crt.Spec.KeySize {
+		keySize, err := ecdsaKeySize(crt)
+		if err != nil {
+			return x509.UnknownPublicKeyAlgorithm, x509.UnknownSignatureAlgorithm, err
+		}
+		switch keySize {
 		case 521:
 			sigAlgo = x509.ECDSAWithSHA512
 		case 384:
 			sigAlgo = x509.ECDSAWithSHA384
 		case 256:
 			sigAlgo = x509.ECDSAWithSHA256
-		case 0:
-			sigAlgo = x509.ECDSAWithSHA256
 		default:
 			return x509.UnknownPublicKeyAlgorithm, x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported ecdsa keysize specified: %d", crt.Spec.KeySize)
 		}
+	case v1alpha1.Ed25519KeyAlgorithm:
+		pubKeyAlgo = x509.Ed25519
+		sigAlgo = x509.PureEd25519
 	default:
-		return x509.UnknownPublicKeyAlgorithm, x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported algorithm specified: %s. should be either 'ecdsa' or 'rsa", crt.Spec.KeyAlgorithm)
+		return x509.UnknownPublicKeyAlgorithm, x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported algorithm specified: %s. should be one of 'rsa', 'ecdsa' or 'ed25519'", crt.Spec.KeyAlgorithm)
 	}
 	return pubKeyAlgo, sigAlgo, nil
 }
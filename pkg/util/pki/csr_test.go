@@ -222,6 +222,12 @@ func TestSignatureAlgorithmForCertificate(t *testing.T) {
 			keySize:   100,
 			expectErr: true,
 		},
+		{
+			name:            "certificate with KeyAlgorithm ed25519",
+			keyAlgo:         v1alpha1.Ed25519KeyAlgorithm,
+			expectedSigAlgo: x509.PureEd25519,
+			expectedKeyType: x509.Ed25519,
+		},
 		{
 			name:      "certificate with KeyAlgorithm set to unknown key algo",
 			keyAlgo:   v1alpha1.KeyAlgorithm("blah"),
@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// dnsNameProfile maps internationalised domain names to their ASCII form for
+// lookup. Underscores are permitted, as they are commonly used in the names
+// of internal services.
+var dnsNameProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(false),
+)
+
+// dnsLabelRegexp matches a single lowercase ASCII label of a DNS name.
+var dnsLabelRegexp = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]*[a-z0-9_])?$`)
+
+// NormalizeDNSName returns the form of a DNS name that should be included in
+// a certificate: lowercase, without a trailing dot, and with any
+// internationalised labels punycode encoded. A leading wildcard label is
+// preserved. An error is returned if name is not a valid DNS name.
+func NormalizeDNSName(name string) (string, error) {
+	prefix := ""
+	host := strings.TrimSuffix(name, ".")
+	if strings.HasPrefix(host, "*.") {
+		prefix = "*."
+		host = host[2:]
+	}
+	if host == "" {
+		return "", fmt.Errorf("invalid DNS name %q: must not be empty", name)
+	}
+
+	ascii, err := dnsNameProfile.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid DNS name %q: %v", name, err)
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if !dnsLabelRegexp.MatchString(label) {
+			return "", fmt.Errorf("invalid DNS name %q: label %q must consist of letters, digits, hyphens and underscores, and must not start or end with a hyphen", name, label)
+		}
+	}
+	return prefix + ascii, nil
+}
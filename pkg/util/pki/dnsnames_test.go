@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDNSName(t *testing.T) {
+	tests := map[string]struct {
+		name     string
+		expected string
+		err      string
+	}{
+		"already normalized": {
+			name:     "example.com",
+			expected: "example.com",
+		},
+		"uppercase": {
+			name:     "WWW.Example.COM",
+			expected: "www.example.com",
+		},
+		"trailing dot": {
+			name:     "example.com.",
+			expected: "example.com",
+		},
+		"wildcard with trailing dot": {
+			name:     "*.Example.com.",
+			expected: "*.example.com",
+		},
+		"internationalised domain name": {
+			name:     "bücher.example",
+			expected: "xn--bcher-kva.example",
+		},
+		"uppercase internationalised domain name": {
+			name:     "BÜCHER.example.",
+			expected: "xn--bcher-kva.example",
+		},
+		"wildcard internationalised domain name": {
+			name:     "*.münchen.de",
+			expected: "*.xn--mnchen-3ya.de",
+		},
+		"punycode is left alone": {
+			name:     "xn--bcher-kva.example",
+			expected: "xn--bcher-kva.example",
+		},
+		"underscore": {
+			name:     "_service.example.com",
+			expected: "_service.example.com",
+		},
+		"empty": {
+			name: "",
+			err:  "must not be empty",
+		},
+		"only a dot": {
+			name: ".",
+			err:  "must not be empty",
+		},
+		"empty label": {
+			name: "foo..example.com",
+			err:  "invalid DNS name",
+		},
+		"space": {
+			name: "foo bar.example.com",
+			err:  "invalid DNS name",
+		},
+		"leading hyphen": {
+			name: "-foo.example.com",
+			err:  "invalid DNS name",
+		},
+		"wildcard in the middle": {
+			name: "foo.*.example.com",
+			err:  "invalid DNS name",
+		},
+		"label too long": {
+			name: strings.Repeat("a", 64) + ".example.com",
+			err:  "invalid DNS name",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			normalized, err := NormalizeDNSName(tt.name)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error containing %q, got %q, %v", tt.err, normalized, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if normalized != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, normalized)
+			}
+		})
+	}
+}
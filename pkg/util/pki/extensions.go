@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// ParseObjectIdentifier parses an object identifier in dotted decimal
+// notation, such as '1.3.6.1.5.5.7.3.1'.
+func ParseObjectIdentifier(s string) (asn1.ObjectIdentifier, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("object identifier must have at least two components")
+	}
+
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for i, p := range parts {
+		// reject signs, whitespace and leading zeros, which strconv would
+		// otherwise accept
+		if len(p) == 0 || strings.TrimLeft(p, "0123456789") != "" || (len(p) > 1 && p[0] == '0') {
+			return nil, fmt.Errorf("invalid object identifier component %q", p)
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object identifier component %q: %v", p, err)
+		}
+		oid[i] = n
+	}
+
+	// the first two components are encoded together, which limits their
+	// range (see X.690 section 8.19.4)
+	if oid[0] > 2 {
+		return nil, fmt.Errorf("first object identifier component must be 0, 1 or 2")
+	}
+	if oid[0] < 2 && oid[1] >= 40 {
+		return nil, fmt.Errorf("second object identifier component must be less than 40")
+	}
+
+	return oid, nil
+}
+
+// ExtensionsForCertificate returns the additional x509 extensions requested
+// on the given Certificate resource.
+func ExtensionsForCertificate(crt *v1alpha1.Certificate) ([]pkix.Extension, error) {
+	var exts []pkix.Extension
+	for _, e := range crt.Spec.Extensions {
+		oid, err := ParseObjectIdentifier(e.OID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extension oid %q: %v", e.OID, err)
+		}
+		exts = append(exts, pkix.Extension{
+			Id:       oid,
+			Critical: e.Critical,
+			Value:    e.Value,
+		})
+	}
+	return exts, nil
+}
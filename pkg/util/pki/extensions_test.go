@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"encoding/asn1"
+	"reflect"
+	"testing"
+)
+
+func TestParseObjectIdentifier(t *testing.T) {
+	tests := map[string]struct {
+		oid      string
+		expected asn1.ObjectIdentifier
+		err      bool
+	}{
+		"server auth extended key usage": {
+			oid:      "1.3.6.1.5.5.7.3.1",
+			expected: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1},
+		},
+		"two components": {
+			oid:      "2.999",
+			expected: asn1.ObjectIdentifier{2, 999},
+		},
+		"single component":            {oid: "1", err: true},
+		"empty":                       {oid: "", err: true},
+		"empty component":             {oid: "1..3", err: true},
+		"trailing dot":                {oid: "1.3.", err: true},
+		"non numeric component":       {oid: "1.3.a", err: true},
+		"negative component":          {oid: "1.-3", err: true},
+		"leading zero":                {oid: "1.03", err: true},
+		"first component too large":   {oid: "3.1", err: true},
+		"second component too large":  {oid: "1.40", err: true},
+		"component overflows integer": {oid: "1.3.99999999999999999999", err: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			oid, err := ParseObjectIdentifier(test.oid)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error parsing %q, got %v", test.oid, oid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error parsing %q: %v", test.oid, err)
+			}
+			if !reflect.DeepEqual(oid, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, oid)
+			}
+		})
+	}
+}
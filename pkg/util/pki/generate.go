@@ -17,8 +17,10 @@ limitations under the License.
 package pki
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -49,7 +51,7 @@ const (
 // GeneratePrivateKeyForCertificate will generate a private key suitable for
 // the provided cert-manager Certificate resource, taking into account the
 // parameters on the provided resource.
-// The returned key will either be RSA or ECDSA.
+// The returned key will either be RSA, ECDSA or Ed25519.
 func GeneratePrivateKeyForCertificate(crt *v1alpha1.Certificate) (crypto.Signer, error) {
 	switch crt.Spec.KeyAlgorithm {
 	case v1alpha1.KeyAlgorithm(""), v1alpha1.RSAKeyAlgorithm:
@@ -61,15 +63,91 @@ func GeneratePrivateKeyForCertificate(crt *v1alpha1.Certificate) (crypto.Signer,
 
 		return GenerateRSAPrivateKey(keySize)
 	case v1alpha1.ECDSAKeyAlgorithm:
-		keySize := ECCurve256
+		keySize, err := ecdsaKeySize(crt)
+		if err != nil {
+			return nil, err
+		}
+
+		return GenerateECPrivateKey(keySize)
+	case v1alpha1.Ed25519KeyAlgorithm:
+		return GenerateEd25519PrivateKey()
+	default:
+		return nil, fmt.Errorf("unsupported private key algorithm specified: %s", crt.Spec.KeyAlgorithm)
+	}
+}
 
+// PrivateKeyMatchesSpec returns an error if the given private key does not
+// use the key algorithm, size or curve requested by the provided cert-manager
+// Certificate resource, applying the same defaults as
+// GeneratePrivateKeyForCertificate.
+func PrivateKeyMatchesSpec(pk crypto.PrivateKey, crt *v1alpha1.Certificate) error {
+	switch crt.Spec.KeyAlgorithm {
+	case v1alpha1.KeyAlgorithm(""), v1alpha1.RSAKeyAlgorithm:
+		k, ok := pk.(*rsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("private key is not an rsa key")
+		}
+
+		keySize := MinRSAKeySize
 		if crt.Spec.KeySize > 0 {
 			keySize = crt.Spec.KeySize
 		}
 
-		return GenerateECPrivateKey(keySize)
+		if k.N.BitLen() != keySize {
+			return fmt.Errorf("rsa private key size %d does not match requested key size %d", k.N.BitLen(), keySize)
+		}
+	case v1alpha1.ECDSAKeyAlgorithm:
+		k, ok := pk.(*ecdsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("private key is not an ecdsa key")
+		}
+
+		keySize, err := ecdsaKeySize(crt)
+		if err != nil {
+			return err
+		}
+
+		if k.Curve.Params().BitSize != keySize {
+			return fmt.Errorf("ecdsa private key size %d does not match requested key size %d", k.Curve.Params().BitSize, keySize)
+		}
+	case v1alpha1.Ed25519KeyAlgorithm:
+		if _, ok := pk.(ed25519.PrivateKey); !ok {
+			return fmt.Errorf("private key is not an ed25519 key")
+		}
 	default:
-		return nil, fmt.Errorf("unsupported private key algorithm specified: %s", crt.Spec.KeyAlgorithm)
+		return fmt.Errorf("unsupported private key algorithm specified: %s", crt.Spec.KeyAlgorithm)
+	}
+
+	return nil
+}
+
+// GeneratePrivateKeyForACMEIssuer will generate an ACME account private key
+// using the parameters on the provided ACME issuer configuration.
+// The returned key will either be RSA or ECDSA, as these are the only key
+// types supported by ACME for signing requests.
+func GeneratePrivateKeyForACMEIssuer(iss *v1alpha1.ACMEIssuer) (crypto.Signer, error) {
+	switch iss.PrivateKeyAlgorithm {
+	case v1alpha1.KeyAlgorithm(""), v1alpha1.RSAKeyAlgorithm:
+		keySize := MinRSAKeySize
+
+		if iss.PrivateKeySize > 0 {
+			keySize = iss.PrivateKeySize
+		}
+
+		return GenerateRSAPrivateKey(keySize)
+	case v1alpha1.ECDSAKeyAlgorithm:
+		switch iss.PrivateKeyCurve {
+		case v1alpha1.KeyCurve(""), v1alpha1.P256KeyCurve:
+			return GenerateECPrivateKey(ECCurve256)
+		case v1alpha1.P384KeyCurve:
+			return GenerateECPrivateKey(ECCurve384)
+		case v1alpha1.P521KeyCurve:
+			return GenerateECPrivateKey(ECCurve521)
+		default:
+			return nil, fmt.Errorf("unsupported ecdsa key curve specified: %s", iss.PrivateKeyCurve)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported acme account private key algorithm specified: %s", iss.PrivateKeyAlgorithm)
 	}
 }
 
@@ -107,15 +185,44 @@ func GenerateECPrivateKey(keySize int) (*ecdsa.PrivateKey, error) {
 	return ecdsa.GenerateKey(ecCurve, rand.Reader)
 }
 
+// GenerateEd25519PrivateKey will generate an Ed25519 private key.
+func GenerateEd25519PrivateKey() (ed25519.PrivateKey, error) {
+	_, pk, err := ed25519.GenerateKey(rand.Reader)
+	return pk, err
+}
+
+// ecdsaKeySize returns the size of the ECDSA key to generate for crt. The
+// keyCurve field takes precedence over the legacy keySize field.
+func ecdsaKeySize(crt *v1alpha1.Certificate) (int, error) {
+	switch crt.Spec.KeyCurve {
+	case v1alpha1.KeyCurve(""):
+	case v1alpha1.P256KeyCurve:
+		return ECCurve256, nil
+	case v1alpha1.P384KeyCurve:
+		return ECCurve384, nil
+	case v1alpha1.P521KeyCurve:
+		return ECCurve521, nil
+	default:
+		return 0, fmt.Errorf("unsupported ecdsa key curve specified: %s", crt.Spec.KeyCurve)
+	}
+	if crt.Spec.KeySize > 0 {
+		return crt.Spec.KeySize, nil
+	}
+	return ECCurve256, nil
+}
+
 // EncodePrivateKey will encode a given crypto.PrivateKey by first inspecting
 // the type of key provided.
-// It only supports encoding RSA or ECDSA keys.
+// It only supports encoding RSA, ECDSA or Ed25519 keys. Ed25519 keys are
+// encoded in PKCS#8 format, as they have no other standard encoding.
 func EncodePrivateKey(pk crypto.PrivateKey) ([]byte, error) {
 	switch k := pk.(type) {
 	case *rsa.PrivateKey:
 		return EncodePKCS1PrivateKey(k), nil
 	case *ecdsa.PrivateKey:
 		return EncodeECPrivateKey(k)
+	case ed25519.PrivateKey:
+		return EncodePKCS8PrivateKey(k)
 	default:
 		return nil, fmt.Errorf("error encoding private key: unknown key type: %T", pk)
 	}
@@ -151,13 +258,15 @@ func EncodeECPrivateKey(pk *ecdsa.PrivateKey) ([]byte, error) {
 }
 
 // PublicKeyForPrivateKey will return the crypto.PublicKey for the given
-// crypto.PrivateKey. It only supports RSA and ECDSA keys.
+// crypto.PrivateKey. It only supports RSA, ECDSA and Ed25519 keys.
 func PublicKeyForPrivateKey(pk crypto.PrivateKey) (crypto.PublicKey, error) {
 	switch k := pk.(type) {
 	case *rsa.PrivateKey:
 		return k.Public(), nil
 	case *ecdsa.PrivateKey:
 		return k.Public(), nil
+	case ed25519.PrivateKey:
+		return k.Public(), nil
 	default:
 		return nil, fmt.Errorf("unknown private key type: %T", pk)
 	}
@@ -169,7 +278,7 @@ func PublicKeyForPrivateKey(pk crypto.PrivateKey) (crypto.PublicKey, error) {
 // given Certificate.
 // It will return true if the public key *is* valid for the given Certificate.
 // It will return an error if either of the passed parameters are of an
-// unrecognised type (i.e. non RSA/ECDSA)
+// unrecognised type (i.e. non RSA/ECDSA/Ed25519)
 func PublicKeyMatchesCertificate(check crypto.PublicKey, crt *x509.Certificate) (bool, error) {
 	switch pub := crt.PublicKey.(type) {
 	case *rsa.PublicKey:
@@ -190,6 +299,12 @@ func PublicKeyMatchesCertificate(check crypto.PublicKey, crt *x509.Certificate)
 			return false, nil
 		}
 		return true, nil
+	case ed25519.PublicKey:
+		ed25519Check, ok := check.(ed25519.PublicKey)
+		if !ok {
+			return false, nil
+		}
+		return bytes.Equal(pub, ed25519Check), nil
 	default:
 		return false, fmt.Errorf("unrecognised Certificate public key type")
 	}
@@ -201,7 +316,7 @@ func PublicKeyMatchesCertificate(check crypto.PublicKey, crt *x509.Certificate)
 // given CertificateRequest.
 // It will return true if the public key *is* valid for the given CertificateRequest.
 // It will return an error if either of the passed parameters are of an
-// unrecognised type (i.e. non RSA/ECDSA)
+// unrecognised type (i.e. non RSA/ECDSA/Ed25519)
 func PublicKeyMatchesCSR(check crypto.PublicKey, csr *x509.CertificateRequest) (bool, error) {
 	switch pub := csr.PublicKey.(type) {
 	case *rsa.PublicKey:
@@ -222,6 +337,12 @@ func PublicKeyMatchesCSR(check crypto.PublicKey, csr *x509.CertificateRequest) (
 			return false, nil
 		}
 		return true, nil
+	case ed25519.PublicKey:
+		ed25519Check, ok := check.(ed25519.PublicKey)
+		if !ok {
+			return false, nil
+		}
+		return bytes.Equal(pub, ed25519Check), nil
 	default:
 		return false, fmt.Errorf("unrecognised Certificate public key type")
 	}
@@ -19,6 +19,7 @@ package pki
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -140,6 +141,11 @@ func TestGeneratePrivateKeyForCertificate(t *testing.T) {
 			keyAlgo:   v1alpha1.ECDSAKeyAlgorithm,
 			expectErr: false,
 		},
+		{
+			name:      "ed25519 key",
+			keyAlgo:   v1alpha1.Ed25519KeyAlgorithm,
+			expectErr: false,
+		},
 	}
 
 	testFn := func(test testT) func(*testing.T) {
@@ -213,6 +219,19 @@ func TestGeneratePrivateKeyForCertificate(t *testing.T) {
 						return
 					}
 				}
+
+				if test.keyAlgo == v1alpha1.Ed25519KeyAlgorithm {
+					key, ok := privateKey.(ed25519.PrivateKey)
+					if !ok {
+						t.Errorf("expected ed25519 private key, but got %T", privateKey)
+						return
+					}
+
+					if len(key) != ed25519.PrivateKeySize {
+						t.Errorf("expected %d byte key, but got %d", ed25519.PrivateKeySize, len(key))
+						return
+					}
+				}
 			}
 		}
 	}
@@ -222,6 +241,151 @@ func TestGeneratePrivateKeyForCertificate(t *testing.T) {
 	}
 }
 
+func TestGeneratePrivateKeyForCertificateKeyCurve(t *testing.T) {
+	tests := map[string]struct {
+		keyCurve     v1alpha1.KeyCurve
+		keySize      int
+		expectedSize int
+		expectedAlgo x509.SignatureAlgorithm
+		expectErr    bool
+	}{
+		"no curve or size defaults to P256": {
+			expectedSize: 256,
+			expectedAlgo: x509.ECDSAWithSHA256,
+		},
+		"P256 curve": {
+			keyCurve:     v1alpha1.P256KeyCurve,
+			expectedSize: 256,
+			expectedAlgo: x509.ECDSAWithSHA256,
+		},
+		"P384 curve": {
+			keyCurve:     v1alpha1.P384KeyCurve,
+			expectedSize: 384,
+			expectedAlgo: x509.ECDSAWithSHA384,
+		},
+		"P521 curve": {
+			keyCurve:     v1alpha1.P521KeyCurve,
+			expectedSize: 521,
+			expectedAlgo: x509.ECDSAWithSHA512,
+		},
+		"curve takes precedence over key size": {
+			keyCurve:     v1alpha1.P384KeyCurve,
+			keySize:      521,
+			expectedSize: 384,
+			expectedAlgo: x509.ECDSAWithSHA384,
+		},
+		"unsupported curve": {
+			keyCurve:  v1alpha1.KeyCurve("P192"),
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := buildCertificateWithKeyParams(v1alpha1.ECDSAKeyAlgorithm, test.keySize)
+			crt.Spec.KeyCurve = test.keyCurve
+
+			privateKey, err := GeneratePrivateKeyForCertificate(crt)
+			_, sigAlgo, sigErr := SignatureAlgorithm(crt)
+			if test.expectErr {
+				if err == nil || sigErr == nil {
+					t.Errorf("expected errors, but got %v and %v", err, sigErr)
+				}
+				return
+			}
+			if err != nil || sigErr != nil {
+				t.Fatalf("expected no errors, but got %v and %v", err, sigErr)
+			}
+
+			key, ok := privateKey.(*ecdsa.PrivateKey)
+			if !ok {
+				t.Fatalf("expected ecdsa private key, but got %T", privateKey)
+			}
+			if size := key.Curve.Params().BitSize; size != test.expectedSize {
+				t.Errorf("expected %d but got %d", test.expectedSize, size)
+			}
+			if sigAlgo != test.expectedAlgo {
+				t.Errorf("expected %q but got %q", test.expectedAlgo, sigAlgo)
+			}
+		})
+	}
+}
+
+func TestPrivateKeyMatchesSpec(t *testing.T) {
+	rsaKey, err := GenerateRSAPrivateKey(2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+	ecKey, err := GenerateECPrivateKey(ECCurve256)
+	if err != nil {
+		t.Fatalf("error generating ecdsa key: %v", err)
+	}
+	edKey, err := GenerateEd25519PrivateKey()
+	if err != nil {
+		t.Fatalf("error generating ed25519 key: %v", err)
+	}
+
+	tests := map[string]struct {
+		key       crypto.Signer
+		keyAlgo   v1alpha1.KeyAlgorithm
+		keySize   int
+		keyCurve  v1alpha1.KeyCurve
+		expectErr bool
+	}{
+		"rsa key matches default key algorithm": {
+			key: rsaKey,
+		},
+		"rsa key matches rsa key size": {
+			key:     rsaKey,
+			keyAlgo: v1alpha1.RSAKeyAlgorithm,
+			keySize: 2048,
+		},
+		"rsa key does not match larger key size": {
+			key:       rsaKey,
+			keyAlgo:   v1alpha1.RSAKeyAlgorithm,
+			keySize:   4096,
+			expectErr: true,
+		},
+		"ecdsa key matches default curve": {
+			key:     ecKey,
+			keyAlgo: v1alpha1.ECDSAKeyAlgorithm,
+		},
+		"ecdsa key does not match P384 curve": {
+			key:       ecKey,
+			keyAlgo:   v1alpha1.ECDSAKeyAlgorithm,
+			keyCurve:  v1alpha1.P384KeyCurve,
+			expectErr: true,
+		},
+		"ecdsa key does not match rsa key algorithm": {
+			key:       ecKey,
+			keyAlgo:   v1alpha1.RSAKeyAlgorithm,
+			expectErr: true,
+		},
+		"ed25519 key matches ed25519 key algorithm": {
+			key:     edKey,
+			keyAlgo: v1alpha1.Ed25519KeyAlgorithm,
+		},
+		"rsa key does not match ed25519 key algorithm": {
+			key:       rsaKey,
+			keyAlgo:   v1alpha1.Ed25519KeyAlgorithm,
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := buildCertificateWithKeyParams(test.keyAlgo, test.keySize)
+			crt.Spec.KeyCurve = test.keyCurve
+
+			err := PrivateKeyMatchesSpec(test.key, crt)
+			if test.expectErr && err == nil {
+				t.Errorf("expected an error but got none")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
 func signTestCert(key crypto.Signer) *x509.Certificate {
 	commonName := "testingcert"
 
@@ -335,3 +499,77 @@ func TestPublicKeyMatchesCertificateRequest(t *testing.T) {
 		t.Errorf("expected private key to not match certificate, but it did")
 	}
 }
+
+func TestGeneratePrivateKeyForACMEIssuer(t *testing.T) {
+	tests := map[string]struct {
+		iss          v1alpha1.ACMEIssuer
+		expectedAlgo v1alpha1.KeyAlgorithm
+		expectedSize int
+		expectErr    bool
+	}{
+		"defaults to a 2048 bit rsa key": {
+			expectedAlgo: v1alpha1.RSAKeyAlgorithm,
+			expectedSize: 2048,
+		},
+		"rsa key with a custom size": {
+			iss:          v1alpha1.ACMEIssuer{PrivateKeyAlgorithm: v1alpha1.RSAKeyAlgorithm, PrivateKeySize: 3072},
+			expectedAlgo: v1alpha1.RSAKeyAlgorithm,
+			expectedSize: 3072,
+		},
+		"ecdsa key defaults to P256": {
+			iss:          v1alpha1.ACMEIssuer{PrivateKeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm},
+			expectedAlgo: v1alpha1.ECDSAKeyAlgorithm,
+			expectedSize: 256,
+		},
+		"ecdsa key with P384 curve": {
+			iss:          v1alpha1.ACMEIssuer{PrivateKeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm, PrivateKeyCurve: v1alpha1.P384KeyCurve},
+			expectedAlgo: v1alpha1.ECDSAKeyAlgorithm,
+			expectedSize: 384,
+		},
+		"weak rsa key size": {
+			iss:       v1alpha1.ACMEIssuer{PrivateKeySize: 1024},
+			expectErr: true,
+		},
+		"unsupported curve": {
+			iss:       v1alpha1.ACMEIssuer{PrivateKeyAlgorithm: v1alpha1.ECDSAKeyAlgorithm, PrivateKeyCurve: v1alpha1.KeyCurve("P192")},
+			expectErr: true,
+		},
+		"ed25519 is not supported": {
+			iss:       v1alpha1.ACMEIssuer{PrivateKeyAlgorithm: v1alpha1.Ed25519KeyAlgorithm},
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			privateKey, err := GeneratePrivateKeyForACMEIssuer(&test.iss)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+
+			switch key := privateKey.(type) {
+			case *rsa.PrivateKey:
+				if test.expectedAlgo != v1alpha1.RSAKeyAlgorithm {
+					t.Fatalf("expected %s private key, but got %T", test.expectedAlgo, privateKey)
+				}
+				if size := key.N.BitLen(); size != test.expectedSize {
+					t.Errorf("expected %d but got %d", test.expectedSize, size)
+				}
+			case *ecdsa.PrivateKey:
+				if test.expectedAlgo != v1alpha1.ECDSAKeyAlgorithm {
+					t.Fatalf("expected %s private key, but got %T", test.expectedAlgo, privateKey)
+				}
+				if size := key.Curve.Params().BitSize; size != test.expectedSize {
+					t.Errorf("expected %d but got %d", test.expectedSize, size)
+				}
+			default:
+				t.Fatalf("unexpected private key type %T", privateKey)
+			}
+		})
+	}
+}
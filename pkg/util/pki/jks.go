@@ -0,0 +1,219 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// This file implements writing Java KeyStore (JKS) files, in the format
+// read by Java's sun.security.provider.JavaKeyStore.
+
+const (
+	jksMagic              = 0xfeedfeed
+	jksVersion            = 2
+	jksPrivateKeyEntryTag = 1
+	jksTrustedCertTag     = 2
+	jksCertType           = "X.509"
+	jksSaltLength         = sha1.Size
+	// jksWhitener is mixed into the keystore integrity digest by Java.
+	jksWhitener = "Mighty Aphrodite"
+)
+
+// oidJKSKeyProtector identifies Sun's proprietary private key protection
+// algorithm used in JKS files.
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// jksNow is the creation time recorded for keystore entries.
+var jksNow = time.Now
+
+// EncodeJKSKeystore will encode the given private key and certificate chain
+// into a password protected JKS keystore, containing a single private key
+// entry with the given alias. The first certificate in chain must be the
+// certificate corresponding to key.
+func EncodeJKSKeystore(key crypto.PrivateKey, chain []*x509.Certificate, alias, password string) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("error encoding jks keystore: no certificates given")
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding private key: %s", err.Error())
+	}
+	protectedKey, err := jksProtectKey(pkcs8, jksPassword(password))
+	if err != nil {
+		return nil, err
+	}
+
+	w := newJKSWriter(1)
+	w.writeUint32(jksPrivateKeyEntryTag)
+	w.writeEntryHeader(alias)
+	w.writeBytes(protectedKey)
+	w.writeUint32(uint32(len(chain)))
+	for _, cert := range chain {
+		w.writeCertificate(cert)
+	}
+	return w.finish(password), nil
+}
+
+// EncodeJKSTruststore will encode the given certificates into a password
+// protected JKS keystore as trusted certificate entries. The first
+// certificate is given the alias 'ca', and any others 'ca-1', 'ca-2' etc.
+func EncodeJKSTruststore(certs []*x509.Certificate, password string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("error encoding jks truststore: no certificates given")
+	}
+
+	w := newJKSWriter(len(certs))
+	for i, cert := range certs {
+		alias := "ca"
+		if i > 0 {
+			alias = fmt.Sprintf("ca-%d", i)
+		}
+		w.writeUint32(jksTrustedCertTag)
+		w.writeEntryHeader(alias)
+		w.writeCertificate(cert)
+	}
+	return w.finish(password), nil
+}
+
+type jksWriter struct {
+	buf bytes.Buffer
+}
+
+func newJKSWriter(entries int) *jksWriter {
+	w := &jksWriter{}
+	w.writeUint32(jksMagic)
+	w.writeUint32(jksVersion)
+	w.writeUint32(uint32(entries))
+	return w
+}
+
+func (w *jksWriter) writeUint32(v uint32) {
+	binary.Write(&w.buf, binary.BigEndian, v)
+}
+
+// writeBytes writes b prefixed with its length.
+func (w *jksWriter) writeBytes(b []byte) {
+	w.writeUint32(uint32(len(b)))
+	w.buf.Write(b)
+}
+
+// writeUTF writes s in the modified UTF-8 encoding used by Java's
+// DataOutput.writeUTF.
+func (w *jksWriter) writeUTF(s string) {
+	var encoded []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		switch {
+		case c >= 0x01 && c <= 0x7f:
+			encoded = append(encoded, byte(c))
+		case c <= 0x7ff:
+			encoded = append(encoded, byte(0xc0|(c>>6)&0x1f), byte(0x80|c&0x3f))
+		default:
+			encoded = append(encoded, byte(0xe0|(c>>12)&0x0f), byte(0x80|(c>>6)&0x3f), byte(0x80|c&0x3f))
+		}
+	}
+	binary.Write(&w.buf, binary.BigEndian, uint16(len(encoded)))
+	w.buf.Write(encoded)
+}
+
+// writeEntryHeader writes the alias and creation time of an entry. Java
+// treats aliases case insensitively and stores them in lower case.
+func (w *jksWriter) writeEntryHeader(alias string) {
+	w.writeUTF(strings.ToLower(alias))
+	binary.Write(&w.buf, binary.BigEndian, jksNow().UnixNano()/int64(time.Millisecond))
+}
+
+func (w *jksWriter) writeCertificate(cert *x509.Certificate) {
+	w.writeUTF(jksCertType)
+	w.writeBytes(cert.Raw)
+}
+
+// finish appends the keystore integrity digest and returns the keystore.
+func (w *jksWriter) finish(password string) []byte {
+	h := sha1.New()
+	h.Write(jksPassword(password))
+	h.Write([]byte(jksWhitener))
+	h.Write(w.buf.Bytes())
+	w.buf.Write(h.Sum(nil))
+	return w.buf.Bytes()
+}
+
+// jksPassword returns password encoded as big endian UTF-16, as used by the
+// JKS key protection and integrity algorithms.
+func jksPassword(password string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(password)) {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return b
+}
+
+// jksProtectKey encrypts a PKCS#8 encoded private key using Sun's JKS key
+// protection algorithm, returning a DER encoded EncryptedPrivateKeyInfo.
+func jksProtectKey(plainKey, password []byte) ([]byte, error) {
+	salt := make([]byte, jksSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating jks key salt: %s", err.Error())
+	}
+
+	encrypted := append([]byte{}, salt...)
+	encrypted = append(encrypted, jksKeystream(plainKey, password, salt)...)
+	check := sha1.New()
+	check.Write(password)
+	check.Write(plainKey)
+	encrypted = append(encrypted, check.Sum(nil)...)
+
+	info, err := asn1.Marshal(pkcs12EncryptedPrivateKeyInfo{
+		AlgorithmIdentifier: pkix.AlgorithmIdentifier{
+			Algorithm:  oidJKSKeyProtector,
+			Parameters: asn1.NullRawValue,
+		},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding jks private key: %s", err.Error())
+	}
+	return info, nil
+}
+
+// jksKeystream XORs data with the keystream derived from password and salt
+// by the JKS key protection algorithm. It is its own inverse.
+func jksKeystream(data, password, salt []byte) []byte {
+	out := make([]byte, len(data))
+	digest := salt
+	for i := 0; i < len(data); i += sha1.Size {
+		h := sha1.New()
+		h.Write(password)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(data); j++ {
+			out[i+j] = data[i+j] ^ digest[j]
+		}
+	}
+	return out
+}
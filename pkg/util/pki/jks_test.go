@@ -0,0 +1,287 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+type testJKSEntry struct {
+	tag     uint32
+	alias   string
+	created time.Time
+	key     crypto.PrivateKey
+	certs   []*x509.Certificate
+}
+
+// decodeTestJKS decodes a keystore produced by EncodeJKSKeystore or
+// EncodeJKSTruststore, verifying its integrity digest and decrypting any
+// private keys with password.
+func decodeTestJKS(t *testing.T, data []byte, password string) []testJKSEntry {
+	if len(data) < sha1.Size {
+		t.Fatalf("keystore too short")
+	}
+	body, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	h := sha1.New()
+	h.Write(jksPassword(password))
+	h.Write([]byte(jksWhitener))
+	h.Write(body)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		t.Fatalf("keystore integrity check failed")
+	}
+
+	r := bytes.NewReader(body)
+	readUint32 := func() uint32 {
+		var v uint32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatalf("error reading keystore: %v", err)
+		}
+		return v
+	}
+	readBytes := func(n int) []byte {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatalf("error reading keystore: %v", err)
+		}
+		return b
+	}
+	readUTF := func() string {
+		var n uint16
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			t.Fatalf("error reading keystore: %v", err)
+		}
+		return string(readBytes(int(n)))
+	}
+	readCert := func() *x509.Certificate {
+		if typ := readUTF(); typ != jksCertType {
+			t.Fatalf("unexpected certificate type %q", typ)
+		}
+		cert, err := x509.ParseCertificate(readBytes(int(readUint32())))
+		if err != nil {
+			t.Fatalf("error parsing certificate: %v", err)
+		}
+		return cert
+	}
+
+	if magic := readUint32(); magic != jksMagic {
+		t.Fatalf("unexpected magic %x", magic)
+	}
+	if version := readUint32(); version != jksVersion {
+		t.Fatalf("unexpected version %d", version)
+	}
+	entries := make([]testJKSEntry, readUint32())
+	for i := range entries {
+		e := &entries[i]
+		e.tag = readUint32()
+		e.alias = readUTF()
+		var created int64
+		if err := binary.Read(r, binary.BigEndian, &created); err != nil {
+			t.Fatalf("error reading keystore: %v", err)
+		}
+		e.created = time.Unix(0, created*int64(time.Millisecond))
+		switch e.tag {
+		case jksPrivateKeyEntryTag:
+			e.key = decodeTestJKSKey(t, readBytes(int(readUint32())), password)
+			for n := readUint32(); n > 0; n-- {
+				e.certs = append(e.certs, readCert())
+			}
+		case jksTrustedCertTag:
+			e.certs = []*x509.Certificate{readCert()}
+		default:
+			t.Fatalf("unexpected entry tag %d", e.tag)
+		}
+	}
+	if r.Len() > 0 {
+		t.Fatalf("unexpected %d trailing bytes in keystore", r.Len())
+	}
+	return entries
+}
+
+func decodeTestJKSKey(t *testing.T, data []byte, password string) crypto.PrivateKey {
+	var info pkcs12EncryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(data, &info); err != nil || len(rest) > 0 {
+		t.Fatalf("error decoding encrypted private key: %v", err)
+	}
+	if !info.AlgorithmIdentifier.Algorithm.Equal(oidJKSKeyProtector) {
+		t.Fatalf("unexpected key protection algorithm %v", info.AlgorithmIdentifier.Algorithm)
+	}
+	encrypted := info.EncryptedData
+	salt := encrypted[:jksSaltLength]
+	check := encrypted[len(encrypted)-sha1.Size:]
+	plainKey := jksKeystream(encrypted[jksSaltLength:len(encrypted)-sha1.Size], jksPassword(password), salt)
+	h := sha1.New()
+	h.Write(jksPassword(password))
+	h.Write(plainKey)
+	if !bytes.Equal(h.Sum(nil), check) {
+		t.Fatalf("private key integrity check failed")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(plainKey)
+	if err != nil {
+		t.Fatalf("error parsing private key: %v", err)
+	}
+	return key
+}
+
+func TestEncodeJKSKeystore(t *testing.T) {
+	for _, keyAlgo := range []v1alpha1.KeyAlgorithm{v1alpha1.RSAKeyAlgorithm, v1alpha1.ECDSAKeyAlgorithm, v1alpha1.Ed25519KeyAlgorithm} {
+		t.Run(string(keyAlgo), func(t *testing.T) {
+			key, chain := generateTestChain(t, keyAlgo)
+
+			data, err := EncodeJKSKeystore(key, chain, "MyAlias", "p4ssw0rd")
+			if err != nil {
+				t.Fatalf("unexpected error encoding keystore: %v", err)
+			}
+
+			entries := decodeTestJKS(t, data, "p4ssw0rd")
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 entry but got %d", len(entries))
+			}
+			e := entries[0]
+			if e.tag != jksPrivateKeyEntryTag || e.alias != "myalias" {
+				t.Errorf("expected private key entry with alias %q but got tag %d alias %q", "myalias", e.tag, e.alias)
+			}
+			if len(e.certs) != len(chain) {
+				t.Fatalf("expected %d certificates but got %d", len(chain), len(e.certs))
+			}
+			for i := range chain {
+				if !e.certs[i].Equal(chain[i]) {
+					t.Errorf("certificate %d does not match the given chain", i)
+				}
+			}
+			signer, ok := e.key.(crypto.Signer)
+			if !ok {
+				t.Fatalf("decoded key of type %T is not a signer", e.key)
+			}
+			matches, err := PublicKeyMatchesCertificate(signer.Public(), chain[0])
+			if err != nil || !matches {
+				t.Errorf("expected decoded key to match the leaf certificate, matches=%v err=%v", matches, err)
+			}
+		})
+	}
+}
+
+func TestEncodeJKSTruststore(t *testing.T) {
+	_, chain := generateTestChain(t, v1alpha1.RSAKeyAlgorithm)
+
+	data, err := EncodeJKSTruststore(chain, "p4ssw0rd")
+	if err != nil {
+		t.Fatalf("unexpected error encoding truststore: %v", err)
+	}
+
+	entries := decodeTestJKS(t, data, "p4ssw0rd")
+	if len(entries) != len(chain) {
+		t.Fatalf("expected %d entries but got %d", len(chain), len(entries))
+	}
+	for i, alias := range []string{"ca", "ca-1"} {
+		e := entries[i]
+		if e.tag != jksTrustedCertTag || e.alias != alias {
+			t.Errorf("expected trusted certificate entry with alias %q but got tag %d alias %q", alias, e.tag, e.alias)
+		}
+		if len(e.certs) != 1 || !e.certs[0].Equal(chain[i]) {
+			t.Errorf("entry %q does not contain certificate %d", e.alias, i)
+		}
+	}
+}
+
+func TestEncodeJKSPassword(t *testing.T) {
+	key, chain := generateTestChain(t, v1alpha1.RSAKeyAlgorithm)
+	data, err := EncodeJKSKeystore(key, chain, "certificate", "pässw\U0001F512rd")
+	if err != nil {
+		t.Fatalf("unexpected error encoding keystore: %v", err)
+	}
+	decodeTestJKS(t, data, "pässw\U0001F512rd")
+
+	h := sha1.New()
+	h.Write(jksPassword("wrong"))
+	h.Write([]byte(jksWhitener))
+	h.Write(data[:len(data)-sha1.Size])
+	if bytes.Equal(h.Sum(nil), data[len(data)-sha1.Size:]) {
+		t.Errorf("expected the integrity check to fail with the wrong password")
+	}
+}
+
+func TestEncodeJKSErrors(t *testing.T) {
+	key, _ := generateTestChain(t, v1alpha1.RSAKeyAlgorithm)
+	if _, err := EncodeJKSKeystore(key, nil, "certificate", "password"); err == nil {
+		t.Errorf("expected an error encoding a keystore without certificates")
+	}
+	if _, err := EncodeJKSTruststore(nil, "password"); err == nil {
+		t.Errorf("expected an error encoding a truststore without certificates")
+	}
+}
+
+// TestEncodeJKSKeytool checks that keystores can be read by keytool, if it
+// is installed.
+func TestEncodeJKSKeytool(t *testing.T) {
+	keytool, err := exec.LookPath("keytool")
+	if err != nil {
+		t.Skip("keytool not found")
+	}
+	key, chain := generateTestChain(t, v1alpha1.RSAKeyAlgorithm)
+	keystore, err := EncodeJKSKeystore(key, chain, "certificate", "p4ssw0rd")
+	if err != nil {
+		t.Fatalf("unexpected error encoding keystore: %v", err)
+	}
+	truststore, err := EncodeJKSTruststore(chain[1:], "p4ssw0rd")
+	if err != nil {
+		t.Fatalf("unexpected error encoding truststore: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "jks")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, tc := range map[string]struct {
+		data  []byte
+		entry string
+	}{
+		"keystore.jks":   {keystore, "PrivateKeyEntry"},
+		"truststore.jks": {truststore, "trustedCertEntry"},
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, tc.data, 0600); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+		out, err := exec.Command(keytool, "-list", "-storetype", "JKS", "-keystore", path, "-storepass", "p4ssw0rd").CombinedOutput()
+		if err != nil {
+			t.Fatalf("keytool failed to read %s: %v: %s", name, err, out)
+		}
+		if !strings.Contains(string(out), tc.entry) {
+			t.Errorf("expected keytool to list a %s in %s: %s", tc.entry, name, out)
+		}
+		if _, err := exec.Command(keytool, "-list", "-storetype", "JKS", "-keystore", path, "-storepass", "wrong").CombinedOutput(); err == nil {
+			t.Errorf("expected keytool to reject the wrong password for %s", name)
+		}
+	}
+}
@@ -26,7 +26,8 @@ import (
 )
 
 // DecodePrivateKeyBytes will decode a PEM encoded private key into a crypto.Signer.
-// It supports ECDSA and RSA private keys only. All other types will return err.
+// It supports ECDSA, RSA and (PKCS#8 encoded) Ed25519 private keys only. All
+// other types will return err.
 func DecodePrivateKeyBytes(keyBytes []byte) (crypto.Signer, error) {
 	// decode the private key pem
 	block, _ := pem.Decode(keyBytes)
@@ -117,6 +118,26 @@ func DecodeX509CertificateChainBytes(certBytes []byte) ([]*x509.Certificate, err
 	return certs, nil
 }
 
+// DecodeX509CertificateRequestBytes will decode a PEM encoded x509
+// certificate signing request and verify its signature.
+func DecodeX509CertificateRequestBytes(csrBytes []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrBytes)
+	if block == nil {
+		return nil, errors.NewInvalidData("error decoding certificate request PEM block")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, errors.NewInvalidData("error parsing certificate request: %s", err.Error())
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, errors.NewInvalidData("error verifying certificate request signature: %s", err.Error())
+	}
+
+	return csr, nil
+}
+
 // DecodeX509CertificateBytes will decode a PEM encoded x509 Certificate.
 func DecodeX509CertificateBytes(certBytes []byte) (*x509.Certificate, error) {
 	certs, err := DecodeX509CertificateChainBytes(certBytes)
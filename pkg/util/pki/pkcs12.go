@@ -0,0 +1,362 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+// This file implements the subset of PKCS#12 (RFC 7292) needed to write a
+// keystore containing a single private key and its certificate chain, in the
+// form understood by Java's keytool, Windows and OpenSSL:
+//
+//  - the private key is stored in a pkcs8ShroudedKeyBag encrypted with
+//    pbeWithSHAAnd3-KeyTripleDES-CBC
+//  - the certificates are stored unencrypted in certBags
+//  - the keystore is integrity protected with a SHA-1 HMAC
+
+var (
+	oidDataContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPBEWithSHAAnd3DES   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidLocalKeyID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidSHA1                = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+)
+
+const (
+	// pkcs12Iterations is the number of key derivation iterations used for
+	// both the key encryption and the integrity MAC.
+	pkcs12Iterations = 2048
+	pkcs12SaltLength = 8
+)
+
+type pfxPDU struct {
+	Version  int
+	AuthSafe pkcs12ContentInfo
+	MacData  pkcs12MacData `asn1:"optional"`
+}
+
+type pkcs12ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type pkcs12MacData struct {
+	Mac        pkcs12DigestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type pkcs12DigestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type pkcs12SafeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type pkcs12CertBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type pkcs12PBEParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pkcs12EncryptedPrivateKeyInfo struct {
+	AlgorithmIdentifier pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+// EncodePKCS12 will encode the given private key and certificate chain into
+// a password protected PKCS#12 keystore. The first certificate in chain must
+// be the certificate corresponding to key.
+func EncodePKCS12(key crypto.PrivateKey, chain []*x509.Certificate, password string) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("error encoding pkcs#12 keystore: no certificates given")
+	}
+	encodedPassword, err := bmpStringZeroTerminated(password)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding pkcs#12 keystore: %s", err.Error())
+	}
+
+	// the local key id associates the private key with its certificate
+	localKeyID := sha1.Sum(chain[0].Raw)
+	attrs, err := localKeyIDAttributes(localKeyID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var certBags []pkcs12SafeBag
+	for i, cert := range chain {
+		bag, err := certSafeBag(cert)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			bag.Attributes = attrs
+		}
+		certBags = append(certBags, bag)
+	}
+
+	keyBag, err := shroudedKeySafeBag(key, encodedPassword)
+	if err != nil {
+		return nil, err
+	}
+	keyBag.Attributes = attrs
+
+	var authenticatedSafe []pkcs12ContentInfo
+	for _, bags := range [][]pkcs12SafeBag{certBags, {keyBag}} {
+		ci, err := dataContentInfo(bags)
+		if err != nil {
+			return nil, err
+		}
+		authenticatedSafe = append(authenticatedSafe, ci)
+	}
+	authenticatedSafeBytes, err := asn1.Marshal(authenticatedSafe)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding pkcs#12 keystore: %s", err.Error())
+	}
+
+	macSalt := make([]byte, pkcs12SaltLength)
+	if _, err := rand.Read(macSalt); err != nil {
+		return nil, fmt.Errorf("error generating pkcs#12 mac salt: %s", err.Error())
+	}
+	macKey := pkcs12KDF(sha1.New, sha1.Size, 64, macSalt, encodedPassword, pkcs12Iterations, 3, sha1.Size)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(authenticatedSafeBytes)
+
+	authSafe, err := octetStringContent(authenticatedSafeBytes)
+	if err != nil {
+		return nil, err
+	}
+	pfx := pfxPDU{
+		Version: 3,
+		AuthSafe: pkcs12ContentInfo{
+			ContentType: oidDataContentType,
+			Content:     authSafe,
+		},
+		MacData: pkcs12MacData{
+			Mac: pkcs12DigestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+				Digest:    mac.Sum(nil),
+			},
+			MacSalt:    macSalt,
+			Iterations: pkcs12Iterations,
+		},
+	}
+	pfxBytes, err := asn1.Marshal(pfx)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding pkcs#12 keystore: %s", err.Error())
+	}
+
+	return pfxBytes, nil
+}
+
+func localKeyIDAttributes(id []byte) ([]pkcs12Attribute, error) {
+	value, err := asn1.Marshal(id)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding pkcs#12 local key id: %s", err.Error())
+	}
+	return []pkcs12Attribute{{
+		ID:    oidLocalKeyID,
+		Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+	}}, nil
+}
+
+func certSafeBag(cert *x509.Certificate) (pkcs12SafeBag, error) {
+	certBag, err := asn1.Marshal(pkcs12CertBag{ID: oidCertTypeX509, Data: cert.Raw})
+	if err != nil {
+		return pkcs12SafeBag{}, fmt.Errorf("error encoding pkcs#12 certificate bag: %s", err.Error())
+	}
+	return pkcs12SafeBag{
+		ID:    oidCertBag,
+		Value: asn1.RawValue{FullBytes: explicitTag0(certBag)},
+	}, nil
+}
+
+func shroudedKeySafeBag(key crypto.PrivateKey, encodedPassword []byte) (pkcs12SafeBag, error) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return pkcs12SafeBag{}, fmt.Errorf("error encoding private key: %s", err.Error())
+	}
+
+	params := pkcs12PBEParams{Salt: make([]byte, pkcs12SaltLength), Iterations: pkcs12Iterations}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return pkcs12SafeBag{}, fmt.Errorf("error generating pkcs#12 key salt: %s", err.Error())
+	}
+	encrypted, err := pbeEncrypt(pkcs8, params, encodedPassword)
+	if err != nil {
+		return pkcs12SafeBag{}, err
+	}
+	paramBytes, err := asn1.Marshal(params)
+	if err != nil {
+		return pkcs12SafeBag{}, fmt.Errorf("error encoding pkcs#12 key parameters: %s", err.Error())
+	}
+
+	info, err := asn1.Marshal(pkcs12EncryptedPrivateKeyInfo{
+		AlgorithmIdentifier: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBEWithSHAAnd3DES,
+			Parameters: asn1.RawValue{FullBytes: paramBytes},
+		},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return pkcs12SafeBag{}, fmt.Errorf("error encoding pkcs#12 key bag: %s", err.Error())
+	}
+	return pkcs12SafeBag{
+		ID:    oidPKCS8ShroudedKeyBag,
+		Value: asn1.RawValue{FullBytes: explicitTag0(info)},
+	}, nil
+}
+
+// pbeEncrypt encrypts data using pbeWithSHAAnd3-KeyTripleDES-CBC.
+func pbeEncrypt(data []byte, params pkcs12PBEParams, encodedPassword []byte) ([]byte, error) {
+	key := pkcs12KDF(sha1.New, sha1.Size, 64, params.Salt, encodedPassword, params.Iterations, 1, 24)
+	iv := pkcs12KDF(sha1.New, sha1.Size, 64, params.Salt, encodedPassword, params.Iterations, 2, des.BlockSize)
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pkcs#12 cipher: %s", err.Error())
+	}
+
+	padding := des.BlockSize - len(data)%des.BlockSize
+	padded := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+	return encrypted, nil
+}
+
+func dataContentInfo(bags []pkcs12SafeBag) (pkcs12ContentInfo, error) {
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return pkcs12ContentInfo{}, fmt.Errorf("error encoding pkcs#12 safe contents: %s", err.Error())
+	}
+	content, err := octetStringContent(safeContents)
+	if err != nil {
+		return pkcs12ContentInfo{}, err
+	}
+	return pkcs12ContentInfo{ContentType: oidDataContentType, Content: content}, nil
+}
+
+// octetStringContent returns the [0] EXPLICIT OCTET STRING content of a
+// ContentInfo of type data.
+func octetStringContent(data []byte) (asn1.RawValue, error) {
+	octets, err := asn1.Marshal(data)
+	if err != nil {
+		return asn1.RawValue{}, fmt.Errorf("error encoding pkcs#12 content: %s", err.Error())
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets}, nil
+}
+
+// explicitTag0 wraps the DER encoded value in a context specific [0] tag.
+func explicitTag0(der []byte) []byte {
+	b, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der})
+	return b
+}
+
+// bmpStringZeroTerminated returns s encoded as a zero terminated, big endian
+// UTF-16 string, which is how PKCS#12 passwords are represented.
+func bmpStringZeroTerminated(s string) ([]byte, error) {
+	ret := make([]byte, 0, 2*len(s)+2)
+	for _, r := range s {
+		if r > 0xffff {
+			return nil, fmt.Errorf("password contains characters outside of the basic multilingual plane")
+		}
+		ret = append(ret, byte(r>>8), byte(r))
+	}
+	return append(ret, 0, 0), nil
+}
+
+// pkcs12KDF implements the key derivation function described in RFC 7292
+// appendix B.2. u and v are the output and block size of newHash in bytes, id
+// selects the purpose of the derived key and size is its length in bytes.
+func pkcs12KDF(newHash func() hash.Hash, u, v int, salt, password []byte, r int, id byte, size int) []byte {
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		n := v * ((len(b) + v - 1) / v)
+		out := make([]byte, n)
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+
+	D := bytes.Repeat([]byte{id}, v)
+	I := append(fill(salt), fill(password)...)
+
+	one := big.NewInt(1)
+	var A []byte
+	for len(A) < size {
+		h := newHash()
+		h.Write(D)
+		h.Write(I)
+		Ai := h.Sum(nil)
+		for j := 1; j < r; j++ {
+			h = newHash()
+			h.Write(Ai)
+			Ai = h.Sum(nil)
+		}
+		A = append(A, Ai...)
+
+		if len(A) >= size {
+			break
+		}
+		// I_j = (I_j + B + 1) mod 2^(v*8) for each v byte block I_j of I
+		B := new(big.Int).SetBytes(fill(Ai[:u]))
+		for j := 0; j < len(I); j += v {
+			Ij := new(big.Int).SetBytes(I[j : j+v])
+			Ij.Add(Ij, B)
+			Ij.Add(Ij, one)
+			b := Ij.Bytes()
+			if len(b) > v {
+				b = b[len(b)-v:]
+			}
+			block := I[j : j+v]
+			for k := range block {
+				block[k] = 0
+			}
+			copy(block[v-len(b):], b)
+		}
+	}
+	return A[:size]
+}
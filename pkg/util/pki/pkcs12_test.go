@@ -0,0 +1,248 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+// decodeTestPKCS12 decodes a keystore produced by EncodePKCS12, verifying
+// its integrity MAC with password.
+func decodeTestPKCS12(t *testing.T, data []byte, password string) (crypto.PrivateKey, []*x509.Certificate) {
+	encodedPassword, err := bmpStringZeroTerminated(password)
+	if err != nil {
+		t.Fatalf("error encoding password: %v", err)
+	}
+
+	var pfx pfxPDU
+	if rest, err := asn1.Unmarshal(data, &pfx); err != nil || len(rest) > 0 {
+		t.Fatalf("error decoding pfx: %v", err)
+	}
+	if pfx.Version != 3 || !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		t.Fatalf("unexpected pfx version %d or content type %v", pfx.Version, pfx.AuthSafe.ContentType)
+	}
+	var authenticatedSafeBytes []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authenticatedSafeBytes); err != nil {
+		t.Fatalf("error decoding authenticated safe: %v", err)
+	}
+
+	macKey := pkcs12KDF(sha1.New, sha1.Size, 64, pfx.MacData.MacSalt, encodedPassword, pfx.MacData.Iterations, 3, sha1.Size)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(authenticatedSafeBytes)
+	if !hmac.Equal(mac.Sum(nil), pfx.MacData.Mac.Digest) {
+		t.Fatalf("pkcs#12 mac verification failed")
+	}
+
+	var authenticatedSafe []pkcs12ContentInfo
+	if _, err := asn1.Unmarshal(authenticatedSafeBytes, &authenticatedSafe); err != nil {
+		t.Fatalf("error decoding authenticated safe contents: %v", err)
+	}
+
+	var key crypto.PrivateKey
+	var certs []*x509.Certificate
+	for _, ci := range authenticatedSafe {
+		if !ci.ContentType.Equal(oidDataContentType) {
+			t.Fatalf("unexpected content type %v", ci.ContentType)
+		}
+		var safeContents []byte
+		if _, err := asn1.Unmarshal(ci.Content.Bytes, &safeContents); err != nil {
+			t.Fatalf("error decoding safe contents: %v", err)
+		}
+		var bags []pkcs12SafeBag
+		if _, err := asn1.Unmarshal(safeContents, &bags); err != nil {
+			t.Fatalf("error decoding safe bags: %v", err)
+		}
+		for _, bag := range bags {
+			switch {
+			case bag.ID.Equal(oidCertBag):
+				var certBag pkcs12CertBag
+				if _, err := asn1.Unmarshal(bag.Value.Bytes, &certBag); err != nil {
+					t.Fatalf("error decoding cert bag: %v", err)
+				}
+				cert, err := x509.ParseCertificate(certBag.Data)
+				if err != nil {
+					t.Fatalf("error parsing certificate: %v", err)
+				}
+				certs = append(certs, cert)
+			case bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+				var info pkcs12EncryptedPrivateKeyInfo
+				if _, err := asn1.Unmarshal(bag.Value.Bytes, &info); err != nil {
+					t.Fatalf("error decoding key bag: %v", err)
+				}
+				if !info.AlgorithmIdentifier.Algorithm.Equal(oidPBEWithSHAAnd3DES) {
+					t.Fatalf("unexpected key encryption algorithm %v", info.AlgorithmIdentifier.Algorithm)
+				}
+				var params pkcs12PBEParams
+				if _, err := asn1.Unmarshal(info.AlgorithmIdentifier.Parameters.FullBytes, &params); err != nil {
+					t.Fatalf("error decoding key encryption parameters: %v", err)
+				}
+				k := pkcs12KDF(sha1.New, sha1.Size, 64, params.Salt, encodedPassword, params.Iterations, 1, 24)
+				iv := pkcs12KDF(sha1.New, sha1.Size, 64, params.Salt, encodedPassword, params.Iterations, 2, des.BlockSize)
+				block, err := des.NewTripleDESCipher(k)
+				if err != nil {
+					t.Fatalf("error creating cipher: %v", err)
+				}
+				decrypted := make([]byte, len(info.EncryptedData))
+				cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+				padding := int(decrypted[len(decrypted)-1])
+				key, err = x509.ParsePKCS8PrivateKey(decrypted[:len(decrypted)-padding])
+				if err != nil {
+					t.Fatalf("error parsing private key: %v", err)
+				}
+			default:
+				t.Fatalf("unexpected safe bag %v", bag.ID)
+			}
+		}
+	}
+	return key, certs
+}
+
+func generateTestChain(t *testing.T, keyAlgo v1alpha1.KeyAlgorithm) (crypto.Signer, []*x509.Certificate) {
+	caKey, err := GenerateECPrivateKey(ECCurve256)
+	if err != nil {
+		t.Fatalf("error generating ca key: %v", err)
+	}
+	caTemplate, err := GenerateTemplate(nil, &v1alpha1.Certificate{Spec: v1alpha1.CertificateSpec{CommonName: "ca", IsCA: true}})
+	if err != nil {
+		t.Fatalf("error generating ca template: %v", err)
+	}
+	_, caCert, err := SignCertificate(caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("error signing ca certificate: %v", err)
+	}
+
+	crt := buildCertificateWithKeyParams(keyAlgo, 0)
+	key, err := GeneratePrivateKeyForCertificate(crt)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	template, err := GenerateTemplate(nil, crt)
+	if err != nil {
+		t.Fatalf("error generating template: %v", err)
+	}
+	_, cert, err := SignCertificate(template, caCert, key.Public(), caKey)
+	if err != nil {
+		t.Fatalf("error signing certificate: %v", err)
+	}
+	return key, []*x509.Certificate{cert, caCert}
+}
+
+func TestPKCS12KDF(t *testing.T) {
+	// test vector taken from golang.org/x/crypto/pkcs12
+	salt := []byte("\xff\xff\xff\xff\xff\xff\xff\xff")
+	password, _ := bmpStringZeroTerminated("sesame")
+	key := pkcs12KDF(sha1.New, sha1.Size, 64, salt, password, 2048, 1, 24)
+	expected := []byte("\x7c\xd9\xfd\x3e\x2b\x3b\xe7\x69\x1a\x44\xe3\xbe\xf0\xf9\xea\x0f\xb9\xb8\x97\xd4\xe3\x25\xd9\xd1")
+	if !bytes.Equal(key, expected) {
+		t.Errorf("expected derived key %x but got %x", expected, key)
+	}
+}
+
+func TestEncodePKCS12(t *testing.T) {
+	for _, keyAlgo := range []v1alpha1.KeyAlgorithm{v1alpha1.RSAKeyAlgorithm, v1alpha1.ECDSAKeyAlgorithm, v1alpha1.Ed25519KeyAlgorithm} {
+		t.Run(string(keyAlgo), func(t *testing.T) {
+			key, chain := generateTestChain(t, keyAlgo)
+
+			data, err := EncodePKCS12(key, chain, "p4ssw0rd")
+			if err != nil {
+				t.Fatalf("unexpected error encoding keystore: %v", err)
+			}
+
+			decodedKey, certs := decodeTestPKCS12(t, data, "p4ssw0rd")
+			if len(certs) != len(chain) {
+				t.Fatalf("expected %d certificates but got %d", len(chain), len(certs))
+			}
+			for i := range chain {
+				if !certs[i].Equal(chain[i]) {
+					t.Errorf("certificate %d does not match the given chain", i)
+				}
+			}
+			signer, ok := decodedKey.(crypto.Signer)
+			if !ok {
+				t.Fatalf("decoded key of type %T is not a signer", decodedKey)
+			}
+			matches, err := PublicKeyMatchesCertificate(signer.Public(), certs[0])
+			if err != nil || !matches {
+				t.Errorf("expected decoded key to match the leaf certificate, matches=%v err=%v", matches, err)
+			}
+		})
+	}
+}
+
+func TestEncodePKCS12Errors(t *testing.T) {
+	key, chain := generateTestChain(t, v1alpha1.RSAKeyAlgorithm)
+	if _, err := EncodePKCS12(key, nil, "password"); err == nil {
+		t.Errorf("expected an error encoding a keystore without certificates")
+	}
+	if _, err := EncodePKCS12(key, chain, "\U0001F512"); err == nil {
+		t.Errorf("expected an error encoding a keystore with a password outside of the BMP")
+	}
+}
+
+// TestEncodePKCS12OpenSSL checks that keystores can be read by OpenSSL, if
+// it is installed.
+func TestEncodePKCS12OpenSSL(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found")
+	}
+	key, chain := generateTestChain(t, v1alpha1.RSAKeyAlgorithm)
+	data, err := EncodePKCS12(key, chain, "p4ssw0rd")
+	if err != nil {
+		t.Fatalf("unexpected error encoding keystore: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "pkcs12")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keystore.p12")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("error writing keystore: %v", err)
+	}
+
+	out, err := exec.Command(openssl, "pkcs12", "-in", path, "-passin", "pass:p4ssw0rd", "-nodes").CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl failed to read keystore: %v: %s", err, out)
+	}
+	if n := strings.Count(string(out), "BEGIN CERTIFICATE"); n != len(chain) {
+		t.Errorf("expected openssl to output %d certificates but got %d", len(chain), n)
+	}
+	if !strings.Contains(string(out), "BEGIN PRIVATE KEY") {
+		t.Errorf("expected openssl to output the private key: %s", out)
+	}
+
+	if _, err := exec.Command(openssl, "pkcs12", "-in", path, "-passin", "pass:wrong", "-nodes").CombinedOutput(); err == nil {
+		t.Errorf("expected openssl to reject the wrong password")
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+var keyUsages = map[v1alpha1.KeyUsage]x509.KeyUsage{
+	v1alpha1.KeyUsageSigning:           x509.KeyUsageDigitalSignature,
+	v1alpha1.KeyUsageDigitalSignature:  x509.KeyUsageDigitalSignature,
+	v1alpha1.KeyUsageContentCommitment: x509.KeyUsageContentCommitment,
+	v1alpha1.KeyUsageKeyEncipherment:   x509.KeyUsageKeyEncipherment,
+	v1alpha1.KeyUsageKeyAgreement:      x509.KeyUsageKeyAgreement,
+	v1alpha1.KeyUsageDataEncipherment:  x509.KeyUsageDataEncipherment,
+	v1alpha1.KeyUsageCertSign:          x509.KeyUsageCertSign,
+	v1alpha1.KeyUsageCRLSign:           x509.KeyUsageCRLSign,
+	v1alpha1.KeyUsageEncipherOnly:      x509.KeyUsageEncipherOnly,
+	v1alpha1.KeyUsageDecipherOnly:      x509.KeyUsageDecipherOnly,
+}
+
+var extKeyUsages = map[v1alpha1.KeyUsage]x509.ExtKeyUsage{
+	v1alpha1.KeyUsageAny:             x509.ExtKeyUsageAny,
+	v1alpha1.KeyUsageServerAuth:      x509.ExtKeyUsageServerAuth,
+	v1alpha1.KeyUsageClientAuth:      x509.ExtKeyUsageClientAuth,
+	v1alpha1.KeyUsageCodeSigning:     x509.ExtKeyUsageCodeSigning,
+	v1alpha1.KeyUsageEmailProtection: x509.ExtKeyUsageEmailProtection,
+	v1alpha1.KeyUsageIPsecEndSystem:  x509.ExtKeyUsageIPSECEndSystem,
+	v1alpha1.KeyUsageIPsecTunnel:     x509.ExtKeyUsageIPSECTunnel,
+	v1alpha1.KeyUsageIPsecUser:       x509.ExtKeyUsageIPSECUser,
+	v1alpha1.KeyUsageTimestamping:    x509.ExtKeyUsageTimeStamping,
+	v1alpha1.KeyUsageOCSPSigning:     x509.ExtKeyUsageOCSPSigning,
+}
+
+// extKeyUsageOIDs are the object identifiers of the extended key usages in
+// extKeyUsages, which the x509 package does not export.
+var extKeyUsageOIDs = map[x509.ExtKeyUsage]asn1.ObjectIdentifier{
+	x509.ExtKeyUsageAny:             {2, 5, 29, 37, 0},
+	x509.ExtKeyUsageServerAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	x509.ExtKeyUsageClientAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	x509.ExtKeyUsageCodeSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	x509.ExtKeyUsageEmailProtection: {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	x509.ExtKeyUsageIPSECEndSystem:  {1, 3, 6, 1, 5, 5, 7, 3, 5},
+	x509.ExtKeyUsageIPSECTunnel:     {1, 3, 6, 1, 5, 5, 7, 3, 6},
+	x509.ExtKeyUsageIPSECUser:       {1, 3, 6, 1, 5, 5, 7, 3, 7},
+	x509.ExtKeyUsageTimeStamping:    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	x509.ExtKeyUsageOCSPSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+var (
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// IsKnownKeyUsage returns true if the given usage can be translated to an
+// x509 key usage or extended key usage.
+func IsKnownKeyUsage(usage v1alpha1.KeyUsage) bool {
+	_, ku := keyUsages[usage]
+	_, eku := extKeyUsages[usage]
+	return ku || eku
+}
+
+// KeyUsages translates the given usages into x509 key usages and extended
+// key usages. If no usages are given, the digital signature and key
+// encipherment key usages are returned. The cert sign key usage is always
+// included if isCA is true.
+func KeyUsages(usages []v1alpha1.KeyUsage, isCA bool) (x509.KeyUsage, []x509.ExtKeyUsage, error) {
+	var ku x509.KeyUsage
+	var eku []x509.ExtKeyUsage
+	if len(usages) == 0 {
+		ku = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+	for _, u := range usages {
+		if k, ok := keyUsages[u]; ok {
+			ku |= k
+			continue
+		}
+		e, ok := extKeyUsages[u]
+		if !ok {
+			return 0, nil, fmt.Errorf("unknown key usage %q", u)
+		}
+		if !hasExtKeyUsage(eku, e) {
+			eku = append(eku, e)
+		}
+	}
+	if isCA {
+		ku |= x509.KeyUsageCertSign
+	}
+	return ku, eku, nil
+}
+
+// KeyUsagesForCertificate returns the x509 key usages and extended key
+// usages that should be set on the certificate issued for the given
+// Certificate resource.
+func KeyUsagesForCertificate(crt *v1alpha1.Certificate) (x509.KeyUsage, []x509.ExtKeyUsage, error) {
+	return KeyUsages(crt.Spec.Usages, crt.Spec.IsCA)
+}
+
+func hasExtKeyUsage(eku []x509.ExtKeyUsage, e x509.ExtKeyUsage) bool {
+	for _, existing := range eku {
+		if existing == e {
+			return true
+		}
+	}
+	return false
+}
+
+// keyUsageExtensions encodes the given key usages and extended key usages as
+// x509 extensions, in the same way as the x509 package does for
+// certificates. Certificate signing requests have no fields for key usages,
+// so they must be requested using extensions.
+func keyUsageExtensions(ku x509.KeyUsage, eku []x509.ExtKeyUsage) ([]pkix.Extension, error) {
+	var exts []pkix.Extension
+	if ku != 0 {
+		var a [2]byte
+		a[0] = reverseBitsInAByte(byte(ku))
+		a[1] = reverseBitsInAByte(byte(ku >> 8))
+		l := 1
+		if a[1] != 0 {
+			l = 2
+		}
+		bitString := a[:l]
+		value, err := asn1.Marshal(asn1.BitString{Bytes: bitString, BitLength: asn1BitLength(bitString)})
+		if err != nil {
+			return nil, fmt.Errorf("error encoding key usage extension: %v", err)
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value})
+	}
+	if len(eku) > 0 {
+		oids := make([]asn1.ObjectIdentifier, len(eku))
+		for i, e := range eku {
+			oids[i] = extKeyUsageOIDs[e]
+		}
+		value, err := asn1.Marshal(oids)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding extended key usage extension: %v", err)
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionExtendedKeyUsage, Value: value})
+	}
+	return exts, nil
+}
+
+func reverseBitsInAByte(in byte) byte {
+	b1 := in>>4 | in<<4
+	b2 := b1>>2&0x33 | b1<<2&0xcc
+	b3 := b2>>1&0x55 | b2<<1&0xaa
+	return b3
+}
+
+// asn1BitLength returns the bit-length of bitString by considering the
+// most-significant bit in a byte to be the "first" bit.
+func asn1BitLength(bitString []byte) int {
+	bitLen := len(bitString) * 8
+	for i := range bitString {
+		b := bitString[len(bitString)-i-1]
+		for bit := uint(0); bit < 8; bit++ {
+			if (b>>bit)&1 == 1 {
+				return bitLen
+			}
+			bitLen--
+		}
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"reflect"
+	"testing"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+func TestKeyUsages(t *testing.T) {
+	tests := map[string]struct {
+		usages      []v1alpha1.KeyUsage
+		isCA        bool
+		expectedKU  x509.KeyUsage
+		expectedEKU []x509.ExtKeyUsage
+		expectErr   bool
+	}{
+		"defaults if no usages are set": {
+			expectedKU: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		},
+		"defaults with cert sign for a CA": {
+			isCA:       true,
+			expectedKU: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		},
+		"client auth only": {
+			usages:      []v1alpha1.KeyUsage{v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageClientAuth},
+			expectedKU:  x509.KeyUsageDigitalSignature,
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		"code signing": {
+			usages:      []v1alpha1.KeyUsage{v1alpha1.KeyUsageSigning, v1alpha1.KeyUsageCodeSigning},
+			expectedKU:  x509.KeyUsageDigitalSignature,
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		},
+		"server and client auth with duplicates": {
+			usages: []v1alpha1.KeyUsage{
+				v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageKeyEncipherment,
+				v1alpha1.KeyUsageServerAuth, v1alpha1.KeyUsageClientAuth, v1alpha1.KeyUsageServerAuth,
+			},
+			expectedKU:  x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		},
+		"extended key usages only": {
+			usages:      []v1alpha1.KeyUsage{v1alpha1.KeyUsageOCSPSigning},
+			expectedEKU: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		},
+		"explicit usages for a CA": {
+			usages:     []v1alpha1.KeyUsage{v1alpha1.KeyUsageCRLSign},
+			isCA:       true,
+			expectedKU: x509.KeyUsageCRLSign | x509.KeyUsageCertSign,
+		},
+		"unknown usage": {
+			usages:    []v1alpha1.KeyUsage{"teleportation"},
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ku, eku, err := KeyUsages(tt.usages, tt.isCA)
+			if err != nil != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if ku != tt.expectedKU {
+				t.Errorf("expected key usages %#x, got %#x", tt.expectedKU, ku)
+			}
+			if !reflect.DeepEqual(eku, tt.expectedEKU) {
+				t.Errorf("expected extended key usages %v, got %v", tt.expectedEKU, eku)
+			}
+		})
+	}
+}
+
+func TestKeyUsageExtensions(t *testing.T) {
+	key, err := GenerateECPrivateKey(256)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+
+	tests := map[string][]v1alpha1.KeyUsage{
+		"one byte of key usages":  {v1alpha1.KeyUsageDigitalSignature, v1alpha1.KeyUsageClientAuth},
+		"two bytes of key usages": {v1alpha1.KeyUsageDecipherOnly, v1alpha1.KeyUsageKeyAgreement, v1alpha1.KeyUsageServerAuth},
+		"no extended key usages":  {v1alpha1.KeyUsageKeyEncipherment},
+	}
+	for name, usages := range tests {
+		t.Run(name, func(t *testing.T) {
+			crt := buildCertificate("example.com")
+			crt.Spec.KeyAlgorithm = v1alpha1.ECDSAKeyAlgorithm
+			crt.Spec.Usages = usages
+			template, err := GenerateCSR(nil, crt)
+			if err != nil {
+				t.Fatalf("error generating CSR: %v", err)
+			}
+			der, err := EncodeCSR(template, key)
+			if err != nil {
+				t.Fatalf("error encoding CSR: %v", err)
+			}
+			csr, err := x509.ParseCertificateRequest(der)
+			if err != nil {
+				t.Fatalf("error parsing CSR: %v", err)
+			}
+
+			// the extensions in the CSR must decode to the requested usages
+			// when copied onto a certificate
+			certTemplate, err := GenerateTemplate(nil, crt)
+			if err != nil {
+				t.Fatalf("error generating template: %v", err)
+			}
+			certTemplate.ExtKeyUsage = nil
+			certTemplate.KeyUsage = 0
+			certTemplate.ExtraExtensions = csr.Extensions
+			certDER, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, key.Public(), key)
+			if err != nil {
+				t.Fatalf("error creating certificate: %v", err)
+			}
+			cert, err := x509.ParseCertificate(certDER)
+			if err != nil {
+				t.Fatalf("error parsing certificate: %v", err)
+			}
+
+			ku, eku, _ := KeyUsages(usages, false)
+			if cert.KeyUsage != ku {
+				t.Errorf("expected key usages %#x, got %#x", ku, cert.KeyUsage)
+			}
+			if !reflect.DeepEqual(cert.ExtKeyUsage, eku) {
+				t.Errorf("expected extended key usages %v, got %v", eku, cert.ExtKeyUsage)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ParseProxyURL parses the URL of a proxy server. Only the http, https and
+// socks5 schemes are supported.
+func ParseProxyURL(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy url scheme must be one of 'http', 'https' or 'socks5'")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy url must include a host")
+	}
+	return u, nil
+}
+
+// ProxyFunc returns the function used by an http.Transport to choose the
+// proxy for a request. If proxy is empty, the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables are used. Otherwise every request is sent
+// through the given proxy.
+func ProxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := ParseProxyURL(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %q: %v", proxy, err)
+	}
+	return http.ProxyURL(u), nil
+}
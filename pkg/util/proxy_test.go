@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestProxyFunc(t *testing.T) {
+	defer os.Setenv("HTTPS_PROXY", os.Getenv("HTTPS_PROXY"))
+	os.Setenv("HTTPS_PROXY", "http://env-proxy:3128")
+
+	tests := map[string]struct {
+		proxy     string
+		expected  string
+		expectErr bool
+	}{
+		"configured http proxy is used": {
+			proxy:    "http://proxy.example.com:8080",
+			expected: "http://proxy.example.com:8080",
+		},
+		"configured socks5 proxy is used": {
+			proxy:    "socks5://proxy.example.com:1080",
+			expected: "socks5://proxy.example.com:1080",
+		},
+		"unsupported scheme is rejected": {
+			proxy:     "ftp://proxy.example.com",
+			expectErr: true,
+		},
+		"missing host is rejected": {
+			proxy:     "http://",
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			proxyFunc, err := ProxyFunc(test.proxy)
+			if err != nil {
+				if !test.expectErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if test.expectErr {
+				t.Fatalf("expected error but got none")
+			}
+			req, _ := http.NewRequest(http.MethodGet, "https://acme.example.com/directory", nil)
+			u, err := proxyFunc(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if u == nil || u.String() != test.expected {
+				t.Errorf("expected proxy %q but got %v", test.expected, u)
+			}
+		})
+	}
+}
@@ -54,6 +54,24 @@ func SetCertificateDNSNames(dnsNames ...string) CertificateModifier {
 	}
 }
 
+func SetCertificateIPAddresses(ipAddresses ...string) CertificateModifier {
+	return func(crt *v1alpha1.Certificate) {
+		crt.Spec.IPAddresses = ipAddresses
+	}
+}
+
+func SetCertificateURIs(uris ...string) CertificateModifier {
+	return func(crt *v1alpha1.Certificate) {
+		crt.Spec.URIs = uris
+	}
+}
+
+func SetCertificateUsages(usages ...v1alpha1.KeyUsage) CertificateModifier {
+	return func(crt *v1alpha1.Certificate) {
+		crt.Spec.Usages = usages
+	}
+}
+
 func SetCertificateCommonName(commonName string) CertificateModifier {
 	return func(crt *v1alpha1.Certificate) {
 		crt.Spec.CommonName = commonName
@@ -72,6 +90,12 @@ func SetCertificateKeyAlgorithm(keyAlgorithm v1alpha1.KeyAlgorithm) CertificateM
 	}
 }
 
+func SetCertificateKeyCurve(keyCurve v1alpha1.KeyCurve) CertificateModifier {
+	return func(crt *v1alpha1.Certificate) {
+		crt.Spec.KeyCurve = keyCurve
+	}
+}
+
 func SetCertificateKeySize(keySize int) CertificateModifier {
 	return func(crt *v1alpha1.Certificate) {
 		crt.Spec.KeySize = keySize
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gen
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+)
+
+type CertificateRequestModifier func(*v1alpha1.CertificateRequest)
+
+func CertificateRequest(name string, mods ...CertificateRequestModifier) *v1alpha1.CertificateRequest {
+	c := &v1alpha1.CertificateRequest{
+		ObjectMeta: ObjectMeta(name),
+	}
+	for _, mod := range mods {
+		mod(c)
+	}
+	return c
+}
+
+func CertificateRequestFrom(cr *v1alpha1.CertificateRequest, mods ...CertificateRequestModifier) *v1alpha1.CertificateRequest {
+	for _, mod := range mods {
+		mod(cr)
+	}
+	return cr
+}
+
+// SetCertificateRequestIssuer sets the CertificateRequest.spec.issuerRef field
+func SetCertificateRequestIssuer(o v1alpha1.ObjectReference) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Spec.IssuerRef = o
+	}
+}
+
+func SetCertificateRequestCSR(csr []byte) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Spec.CSRPEM = csr
+	}
+}
+
+func SetCertificateRequestDuration(duration *metav1.Duration) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Spec.Duration = duration
+	}
+}
+
+func SetCertificateRequestIsCA(isCA bool) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Spec.IsCA = isCA
+	}
+}
+
+func SetCertificateRequestUsages(usages ...v1alpha1.KeyUsage) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Spec.Usages = usages
+	}
+}
+
+func SetCertificateRequestAnnotations(annotations map[string]string) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Annotations = annotations
+	}
+}
+
+func AddCertificateRequestCondition(c v1alpha1.CertificateRequestCondition) CertificateRequestModifier {
+	return func(cr *v1alpha1.CertificateRequest) {
+		cr.Status.Conditions = append(cr.Status.Conditions, c)
+	}
+}
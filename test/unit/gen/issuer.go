@@ -75,6 +75,24 @@ func SetIssuerSelfSigned(a v1alpha1.SelfSignedIssuer) IssuerModifier {
 	}
 }
 
+func SetIssuerVault(a v1alpha1.VaultIssuer) IssuerModifier {
+	return func(iss v1alpha1.GenericIssuer) {
+		iss.GetSpec().Vault = &a
+	}
+}
+
+func SetIssuerVenafi(a v1alpha1.VenafiIssuer) IssuerModifier {
+	return func(iss v1alpha1.GenericIssuer) {
+		iss.GetSpec().Venafi = &a
+	}
+}
+
+func SetIssuerExternal(a v1alpha1.ExternalIssuer) IssuerModifier {
+	return func(iss v1alpha1.GenericIssuer) {
+		iss.GetSpec().External = &a
+	}
+}
+
 func AddIssuerCondition(c v1alpha1.IssuerCondition) IssuerModifier {
 	return func(iss v1alpha1.GenericIssuer) {
 		iss.GetStatus().Conditions = append(iss.GetStatus().Conditions, c)
@@ -30,6 +30,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -560,9 +561,11 @@ func (c *Client) HTTP01ChallengePath(token string) string {
 // the Account. Only the Contact field can be updated.
 func (c *Client) doAccount(ctx context.Context, url string, getExistingWithKey bool, acct *Account) (*Account, error) {
 	req := struct {
-		Contact     []string `json:"contact,omitempty"`
-		TermsAgreed bool     `json:"termsOfServiceAgreed,omitempty"`
-		GetExisting bool     `json:"onlyReturnExisting,omitempty"`
+		Contact     *[]string `json:"contact,omitempty"`
+		TermsAgreed bool      `json:"termsOfServiceAgreed,omitempty"`
+		GetExisting bool      `json:"onlyReturnExisting,omitempty"`
+
+		ExternalAccountBinding json.RawMessage `json:"externalAccountBinding,omitempty"`
 	}{
 		GetExisting: getExistingWithKey,
 	}
@@ -571,9 +574,21 @@ func (c *Client) doAccount(ctx context.Context, url string, getExistingWithKey b
 		accountURL = url
 	}
 	if acct != nil {
-		req.Contact = acct.Contact
+		// an empty contact list is sent when updating an account, so that
+		// all existing contacts are removed
+		if len(acct.Contact) > 0 || accountURL != "" {
+			contact := append([]string{}, acct.Contact...)
+			req.Contact = &contact
+		}
 		req.TermsAgreed = acct.TermsAgreed
 	}
+	if acct != nil && acct.ExternalAccountBinding != nil && url == c.dir.NewAccountURL {
+		eab, err := jwsWithMAC(acct.ExternalAccountBinding, c.Key, url)
+		if err != nil {
+			return nil, err
+		}
+		req.ExternalAccountBinding = eab
+	}
 	res, err := c.retryPostJWS(ctx, c.Key, accountURL, url, req)
 	if err != nil {
 		return nil, err
@@ -861,6 +876,58 @@ func (c *Client) GetCertificate(ctx context.Context, url string) ([][]byte, erro
 	return chain, nil
 }
 
+// ListCertAlternates retrieves any alternate certificate chain URLs for the
+// given certificate chain URL. These alternate URLs can be passed to
+// GetCertificate.
+//
+// If there are no alternate issuer certificate chains, a nil slice will be
+// returned.
+func (c *Client) ListCertAlternates(ctx context.Context, url string) ([]string, error) {
+	res, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	// We don't need the body but we need to discard it so we don't end up
+	// preventing keep-alive
+	if _, err := io.Copy(ioutil.Discard, res.Body); err != nil {
+		return nil, fmt.Errorf("acme: cert alternates response stream: %v", err)
+	}
+	alts := linkHeader(res.Header, "alternate")
+	for i, alt := range alts {
+		u, err := res.Request.URL.Parse(alt)
+		if err != nil {
+			return nil, fmt.Errorf("acme: invalid alternate certificate URL %q: %v", alt, err)
+		}
+		alts[i] = u.String()
+	}
+	return alts, nil
+}
+
+// linkHeader returns URI-Reference values of all Link headers
+// with relation-type rel.
+// See https://tools.ietf.org/html/rfc5988#section-5 for details.
+func linkHeader(h http.Header, rel string) []string {
+	var links []string
+	for _, v := range h["Link"] {
+		parts := strings.Split(v, ";")
+		for _, p := range parts {
+			p = strings.TrimSpace(p)
+			if !strings.HasPrefix(p, "rel=") {
+				continue
+			}
+			if v := strings.Trim(p[4:], `"`); v == rel {
+				links = append(links, strings.Trim(strings.TrimSpace(parts[0]), "<>"))
+			}
+		}
+	}
+	return links
+}
+
 // responseError creates an error of Error type from resp.
 func responseError(resp *http.Response) error {
 	// don't care if ReadAll returns an error:
@@ -6,7 +6,9 @@ package acme
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/base64"
@@ -160,6 +162,79 @@ func TestCreateAccount(t *testing.T) {
 	}
 }
 
+func TestCreateAccountWithExternalAccountBinding(t *testing.T) {
+	eab := &ExternalAccountBinding{
+		KID:       "test-kid",
+		Key:       []byte("test-mac-key"),
+		Algorithm: "HS256",
+	}
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Replay-Nonce", "test-nonce")
+			return
+		}
+
+		var j struct {
+			ExternalAccountBinding struct {
+				Protected string
+				Payload   string
+				Signature string
+			}
+		}
+		decodeJWSRequest(t, &j, r)
+		binding := j.ExternalAccountBinding
+
+		rawHead, err := base64.RawURLEncoding.DecodeString(binding.Protected)
+		if err != nil {
+			t.Fatalf("failed to decode binding header: %v", err)
+		}
+		var head struct {
+			Alg   string
+			KID   string
+			URL   string
+			Nonce string
+			JWK   json.RawMessage
+		}
+		if err := json.Unmarshal(rawHead, &head); err != nil {
+			t.Fatalf("failed to unmarshal binding header: %v", err)
+		}
+		if head.Alg != "HS256" || head.KID != eab.KID || head.URL != ts.URL {
+			t.Errorf("binding header = %s; want alg HS256, kid %q and url %q", rawHead, eab.KID, ts.URL)
+		}
+		if head.Nonce != "" || head.JWK != nil {
+			t.Errorf("binding header = %s; must not contain a nonce or jwk", rawHead)
+		}
+
+		payload, err := base64.RawURLEncoding.DecodeString(binding.Payload)
+		if err != nil {
+			t.Fatalf("failed to decode binding payload: %v", err)
+		}
+		jwk, _ := jwkEncode(testKeyEC.Public())
+		if string(payload) != jwk {
+			t.Errorf("binding payload = %s; want account key %s", payload, jwk)
+		}
+
+		mac := hmac.New(sha256.New, eab.Key)
+		mac.Write([]byte(binding.Protected + "." + binding.Payload))
+		if sig := base64.RawURLEncoding.EncodeToString(mac.Sum(nil)); binding.Signature != sig {
+			t.Errorf("binding signature = %q; want %q", binding.Signature, sig)
+		}
+
+		w.Header().Set("Location", "https://example.com/acme/account/1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"status":"valid"}`)
+	}))
+	defer ts.Close()
+
+	c := Client{Key: testKeyEC, dir: &Directory{NewAccountURL: ts.URL, NewNonceURL: ts.URL}}
+	a := &Account{TermsAgreed: true, ExternalAccountBinding: eab}
+	if _, err := c.CreateAccount(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestUpdateAccount(t *testing.T) {
 	contacts := []string{"mailto:admin@example.com"}
 
@@ -205,6 +280,29 @@ func TestUpdateAccount(t *testing.T) {
 	}
 }
 
+func TestUpdateAccountRemoveContacts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Replay-Nonce", "test-nonce")
+			return
+		}
+
+		var j map[string]json.RawMessage
+		decodeJWSRequest(t, &j, r)
+
+		if string(j["contact"]) != "[]" {
+			t.Errorf("j.contact = %s; want []", j["contact"])
+		}
+		fmt.Fprint(w, `{"status":"valid"}`)
+	}))
+	defer ts.Close()
+
+	c := Client{Key: testKeyEC, dir: &Directory{NewNonceURL: ts.URL}}
+	if _, err := c.UpdateAccount(context.Background(), &Account{URL: ts.URL}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGetAccount(t *testing.T) {
 	contacts := []string{"mailto:admin@example.com"}
 
@@ -858,6 +956,43 @@ func TestGetOrder(t *testing.T) {
 	}
 }
 
+func TestListCertAlternates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", `<https://example.com/acme/cert/1/1>;rel="alternate"`)
+		w.Header().Add("Link", `</acme/cert/1/2>; rel="alternate"`)
+		w.Header().Add("Link", `<https://example.com/acme/directory>;rel="index"`)
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		fmt.Fprint(w, "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n")
+	}))
+	defer ts.Close()
+
+	var client Client
+	alts, err := client.ListCertAlternates(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://example.com/acme/cert/1/1", ts.URL + "/acme/cert/1/2"}
+	if !reflect.DeepEqual(alts, want) {
+		t.Errorf("ListCertAlternates = %v; want %v", alts, want)
+	}
+}
+
+func TestListCertAlternatesNone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n")
+	}))
+	defer ts.Close()
+
+	var client Client
+	alts, err := client.ListCertAlternates(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alts != nil {
+		t.Errorf("ListCertAlternates = %v; want nil", alts)
+	}
+}
+
 func TestRevokeCert(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "HEAD" {
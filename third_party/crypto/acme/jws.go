@@ -7,12 +7,14 @@ package acme
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	_ "crypto/sha512" // need for EC keys
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 )
@@ -60,6 +62,51 @@ func jwsEncodeJSON(claimset interface{}, key crypto.Signer, accountURL, url, non
 	return json.Marshal(&enc)
 }
 
+// jwsWithMAC creates an External Account Binding JWS for the public key of
+// the given account key, signed with the binding's MAC key.
+// See https://tools.ietf.org/html/rfc8555#section-7.3.4.
+func jwsWithMAC(eab *ExternalAccountBinding, accountKey crypto.Signer, url string) (json.RawMessage, error) {
+	if len(eab.Key) == 0 {
+		return nil, errors.New("acme: cannot sign external account binding with an empty MAC key")
+	}
+	alg := eab.Algorithm
+	if alg == "" {
+		alg = "HS256"
+	}
+	var sha crypto.Hash
+	switch alg {
+	case "HS256":
+		sha = crypto.SHA256
+	case "HS384":
+		sha = crypto.SHA384
+	case "HS512":
+		sha = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("acme: unsupported external account binding algorithm %q", alg)
+	}
+
+	jwk, err := jwkEncode(accountKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	phead := fmt.Sprintf(`{"alg":%q,"kid":%q,"url":%q}`, alg, eab.KID, url)
+	phead = base64.RawURLEncoding.EncodeToString([]byte(phead))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(jwk))
+	mac := hmac.New(sha.New, eab.Key)
+	mac.Write([]byte(phead + "." + payload))
+
+	enc := struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Sig       string `json:"signature"`
+	}{
+		Protected: phead,
+		Payload:   payload,
+		Sig:       base64.RawURLEncoding.EncodeToString(mac.Sum(nil)),
+	}
+	return json.Marshal(&enc)
+}
+
 // jwkEncode encodes public part of an RSA or ECDSA key into a JWK.
 // The result is also suitable for creating a JWK thumbprint.
 // https://tools.ietf.org/html/rfc7517
@@ -318,3 +318,49 @@ func TestJWKThumbprintErrUnsupportedKey(t *testing.T) {
 		t.Errorf("err = %q; want %q", err, ErrUnsupportedKey)
 	}
 }
+
+func TestJWSWithMAC(t *testing.T) {
+	tests := []struct {
+		alg     string
+		wantAlg string
+		sigLen  int
+		wantErr bool
+	}{
+		{alg: "", wantAlg: "HS256", sigLen: 32},
+		{alg: "HS256", wantAlg: "HS256", sigLen: 32},
+		{alg: "HS384", wantAlg: "HS384", sigLen: 48},
+		{alg: "HS512", wantAlg: "HS512", sigLen: 64},
+		{alg: "RS256", wantErr: true},
+	}
+	for _, test := range tests {
+		eab := &ExternalAccountBinding{KID: "kid", Key: []byte("key"), Algorithm: test.alg}
+		b, err := jwsWithMAC(eab, testKeyEC, "https://example.com/new-account")
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("jwsWithMAC(%q): expected an error", test.alg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("jwsWithMAC(%q): %v", test.alg, err)
+			continue
+		}
+		var jws struct{ Protected, Signature string }
+		if err := json.Unmarshal(b, &jws); err != nil {
+			t.Fatal(err)
+		}
+		head, _ := base64.RawURLEncoding.DecodeString(jws.Protected)
+		want := fmt.Sprintf(`{"alg":%q,"kid":"kid","url":"https://example.com/new-account"}`, test.wantAlg)
+		if string(head) != want {
+			t.Errorf("jwsWithMAC(%q): protected = %s; want %s", test.alg, head, want)
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(jws.Signature)
+		if len(sig) != test.sigLen {
+			t.Errorf("jwsWithMAC(%q): signature length = %d; want %d", test.alg, len(sig), test.sigLen)
+		}
+	}
+
+	if _, err := jwsWithMAC(&ExternalAccountBinding{KID: "kid"}, testKeyEC, "https://example.com"); err == nil {
+		t.Errorf("jwsWithMAC: expected an error for an empty MAC key")
+	}
+}
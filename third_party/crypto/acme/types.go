@@ -156,6 +156,25 @@ type Account struct {
 	// OrdersURL is the URL used to fetch a list of orders submitted by this
 	// account.
 	OrdersURL string
+
+	// ExternalAccountBinding, if set, binds the new account to an existing
+	// account with the CA. It is only used when creating an account.
+	// See https://tools.ietf.org/html/rfc8555#section-7.3.4.
+	ExternalAccountBinding *ExternalAccountBinding
+}
+
+// ExternalAccountBinding contains the key ID and MAC key of an account with
+// the CA that a new ACME account is bound to.
+type ExternalAccountBinding struct {
+	// KID is the key identifier provided by the CA.
+	KID string
+
+	// Key is the decoded MAC key provided by the CA.
+	Key []byte
+
+	// Algorithm is the MAC algorithm used to sign the binding. Valid values
+	// are "HS256", "HS384" and "HS512". Defaults to "HS256".
+	Algorithm string
 }
 
 // Directory is ACME server discovery data.